@@ -203,6 +203,7 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 		t := common.NewMixedcaseAddress(*tx.To())
 		to = &t
 	}
+	txType := hexutil.Uint64(tx.Type())
 	args := &apitypes.SendTxArgs{
 		Data:  &data,
 		Nonce: hexutil.Uint64(tx.Nonce()),
@@ -210,6 +211,7 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 		Gas:   hexutil.Uint64(tx.Gas()),
 		To:    to,
 		From:  common.NewMixedcaseAddress(account.Address),
+		Type:  &txType,
 	}
 	switch tx.Type() {
 	case types.LegacyTxType, types.AccessListTxType:
@@ -0,0 +1,97 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/crypto"
+)
+
+// errInvalidChild is returned in the (astronomically unlikely) case that a child
+// key is invalid, in which case BIP-32 mandates moving on to the next index.
+var errInvalidChild = errors.New("invalid child key, use the next index")
+
+var secp256k1N = crypto.S256().Params().N
+
+// extendedKey is a BIP-32 extended private key.
+type extendedKey struct {
+	key       []byte // 32 byte private key
+	chainCode []byte // 32 byte chain code
+}
+
+// newMasterKey derives the BIP-32 master key from a seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if k := new(big.Int).SetBytes(sum[:32]); k.Sign() == 0 || k.Cmp(secp256k1N) >= 0 {
+		return nil, errors.New("invalid master key, use another seed")
+	}
+	return &extendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// child derives the child key at the given index, which is hardened if the index
+// is at least accounts.DerivationPath's hardened offset.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	var ser [4]byte
+	binary.BigEndian.PutUint32(ser[:], index)
+	data = append(data, ser[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(secp256k1N) >= 0 {
+		return nil, errInvalidChild
+	}
+	il.Add(il, new(big.Int).SetBytes(k.key))
+	il.Mod(il, secp256k1N)
+	if il.Sign() == 0 {
+		return nil, errInvalidChild
+	}
+	return &extendedKey{key: il.FillBytes(make([]byte, 32)), chainCode: sum[32:]}, nil
+}
+
+// derive derives the private key at the given path from the master key.
+func (k *extendedKey) derive(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	var err error
+	for _, index := range path {
+		if k, err = k.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(k.key)
+}
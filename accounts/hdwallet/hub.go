@@ -0,0 +1,236 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hdwallet implements hierarchical deterministic software wallets, which
+// derive their accounts from a BIP-39 mnemonic along BIP-32 derivation paths.
+//
+// Every wallet is stored in a file of the wallet directory, holding the seed of
+// the mnemonic encrypted like the secret of a version 4 key file, as well as the
+// accounts tracked by the wallet. The mnemonic itself is never stored. Opening a
+// wallet decrypts the seed, after which accounts can be derived and discovered
+// just like with hardware wallets, the same mnemonic always producing the same
+// accounts.
+package hdwallet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/accounts/keystore"
+	"github.com/sdcereum/go-sdcereum/event"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// Scheme is the URI prefix for HD wallets.
+const Scheme = "hdwallet"
+
+// refreshCycle is the maximum time between wallet refreshes, picking up wallet
+// files added or removed by other processes.
+const refreshCycle = 3 * time.Second
+
+// refreshThrottling is the minimum time between wallet refreshes to avoid thrashing.
+const refreshThrottling = time.Second
+
+// Hub is an accounts.Backend that handles the HD wallets stored in a directory.
+type Hub struct {
+	dir     string // Directory the wallet files are stored in
+	scryptN int    // Scrypt N parameter to encrypt new seeds with
+	scryptP int    // Scrypt P parameter to encrypt new seeds with
+
+	refreshed   time.Time               // Time instance when the list of wallets was last refreshed
+	wallets     map[string]*Wallet      // Mapping from wallet file paths to wallet instances
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+	updating    bool                    // Whsdcer the event notification loop is running
+
+	stateLock sync.RWMutex // Protects the internals of the hub from racey access
+}
+
+// NewHub creates a wallet manager for the HD wallets stored in the given
+// directory, encrypting the seeds of new wallets with the given scrypt parameters.
+func NewHub(dir string, scryptN, scryptP int) (*Hub, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	hub := &Hub{
+		dir:     dir,
+		scryptN: scryptN,
+		scryptP: scryptP,
+		wallets: make(map[string]*Wallet),
+	}
+	hub.refreshWallets()
+	return hub, nil
+}
+
+// Wallets implements accounts.Backend, returning all the HD wallets stored in the
+// wallet directory.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	// Make sure the list of wallets is up to date
+	hub.refreshWallets()
+
+	hub.stateLock.RLock()
+	defer hub.stateLock.RUnlock()
+
+	cpy := make([]accounts.Wallet, 0, len(hub.wallets))
+	for _, wallet := range hub.wallets {
+		cpy = append(cpy, wallet)
+	}
+	sort.Sort(accounts.WalletsByURL(cpy))
+	return cpy
+}
+
+// refreshWallets scans the wallet directory and updates the list of wallets based
+// on the wallet files found.
+func (hub *Hub) refreshWallets() {
+	// Don't scan the directory like crazy if the user fetches wallets in a loop
+	hub.stateLock.RLock()
+	elapsed := time.Since(hub.refreshed)
+	hub.stateLock.RUnlock()
+
+	if elapsed < refreshThrottling {
+		return
+	}
+	// Scan the directory while holding the lock, so wallets created in between
+	// aren't mistaken for deleted ones
+	hub.stateLock.Lock()
+
+	files, err := os.ReadDir(hub.dir)
+	if err != nil && !os.IsNotExist(err) {
+		hub.stateLock.Unlock()
+		log.Error("Failed to list HD wallet directory", "dir", hub.dir, "err", err)
+		return
+	}
+	// Transform the current list of wallets into the new one
+	events := []accounts.WalletEvent{}
+	seen := make(map[string]struct{})
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(hub.dir, file.Name())
+		seen[path] = struct{}{}
+
+		if _, ok := hub.wallets[path]; ok {
+			continue
+		}
+		wallet, err := loadWallet(hub, path)
+		if err != nil {
+			log.Debug("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		hub.wallets[path] = wallet
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	// Remove any wallets whose file was deleted
+	for path, wallet := range hub.wallets {
+		if _, ok := seen[path]; !ok {
+			wallet.Close()
+			events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+			delete(hub.wallets, path)
+		}
+	}
+	hub.refreshed = time.Now()
+	hub.stateLock.Unlock()
+
+	for _, event := range events {
+		hub.updateFeed.Send(event)
+	}
+}
+
+// NewWallet creates a new HD wallet from the mnemonic, salted by the optional
+// seed passphrase, and stores it in the wallet directory with its seed encrypted
+// by the password.
+func (hub *Hub) NewWallet(mnemonic, seedPassphrase, password string) (*Wallet, error) {
+	seed, err := NewSeed(mnemonic, seedPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	// Make sure the seed is usable before storing it
+	if _, err := newMasterKey(seed); err != nil {
+		return nil, err
+	}
+	cryptoJSON, err := keystore.EncryptDataV4(seed, password, hub.scryptN, hub.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New()
+	path := filepath.Join(hub.dir, fmt.Sprintf("hdwallet--%s.json", id))
+
+	wallet := newWallet(hub, path, walletJSON{
+		ID:      id.String(),
+		Version: walletVersion,
+		Crypto:  cryptoJSON,
+	})
+	hub.stateLock.Lock()
+	if err := wallet.store(); err != nil {
+		hub.stateLock.Unlock()
+		return nil, err
+	}
+	hub.wallets[path] = wallet
+	hub.stateLock.Unlock()
+
+	hub.updateFeed.Send(accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	return wallet, nil
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of HD wallets.
+func (hub *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	// We need the mutex to reliably start/stop the update loop
+	hub.stateLock.Lock()
+	defer hub.stateLock.Unlock()
+
+	// Subscribe the caller and track the subscriber count
+	sub := hub.updateScope.Track(hub.updateFeed.Subscribe(sink))
+
+	// Subscribers require an active notification loop, start it
+	if !hub.updating {
+		hub.updating = true
+		go hub.updater()
+	}
+	return sub
+}
+
+// updater is responsible for maintaining an up-to-date list of wallets managed
+// by the HD wallet hub, and for firing wallet addition/removal events.
+func (hub *Hub) updater() {
+	for {
+		time.Sleep(refreshCycle)
+
+		// Run the wallet refresher
+		hub.refreshWallets()
+
+		// If all our subscribers left, stop the updater
+		hub.stateLock.Lock()
+		if hub.updateScope.Count() == 0 {
+			hub.updating = false
+			hub.stateLock.Unlock()
+			return
+		}
+		hub.stateLock.Unlock()
+	}
+}
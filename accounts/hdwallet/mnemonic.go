@@ -0,0 +1,125 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordlist is the English BIP-39 wordlist.
+//
+//go:embed wordlist.txt
+var wordlist string
+
+var (
+	words     = strings.Fields(wordlist)
+	wordIndex = make(map[string]int, len(words))
+)
+
+func init() {
+	for i, word := range words {
+		wordIndex[word] = i
+	}
+}
+
+// ErrInvalidMnemonic is returned if a mnemonic contains unknown words, has an
+// invalid length or fails its checksum.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// NewMnemonic generates a random BIP-39 mnemonic of the given entropy size,
+// which must be a multiple of 32 between 128 and 256 bits.
+func NewMnemonic(bits int) (string, error) {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", fmt.Errorf("invalid entropy size %d", bits)
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return entropyToMnemonic(entropy), nil
+}
+
+// entropyToMnemonic encodes the entropy, followed by the first bits of its hash
+// as checksum, in words of 11 bits each.
+func entropyToMnemonic(entropy []byte) string {
+	var (
+		checksumBits = len(entropy) / 4
+		hash         = sha256.Sum256(entropy)
+		data         = new(big.Int).SetBytes(entropy)
+	)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	count := (len(entropy)*8 + checksumBits) / 11
+	result := make([]string, count)
+	mask := big.NewInt(2047)
+	for i := count - 1; i >= 0; i-- {
+		result[i] = words[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(result, " ")
+}
+
+// ValidateMnemonic checks that the mnemonic consists of a valid number of words
+// from the wordlist and that its checksum matches.
+func ValidateMnemonic(mnemonic string) error {
+	fields := strings.Fields(mnemonic)
+	if len(fields)%3 != 0 || len(fields) < 12 || len(fields) > 24 {
+		return ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, word := range fields {
+		index, ok := wordIndex[word]
+		if !ok {
+			return ErrInvalidMnemonic
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	checksumBits := len(fields) / 3
+	checksum := new(big.Int).And(data, big.NewInt(1<<checksumBits-1)).Int64()
+	data.Rsh(data, uint(checksumBits))
+
+	entropy := make([]byte, (len(fields)*11-checksumBits)/8)
+	data.FillBytes(entropy)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return ErrInvalidMnemonic
+	}
+	return nil
+}
+
+// NewSeed validates the mnemonic and derives the BIP-39 seed from it, salted by
+// the optional passphrase.
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(norm.NFKD.String(mnemonic)), []byte(salt), 2048, 64, sha512.New), nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Tests the test vectors of BIP-39, which use "TREZOR" as passphrase.
+func TestMnemonicVectors(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			"00000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	}
+	for i, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		if mnemonic := entropyToMnemonic(entropy); mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, tt.mnemonic)
+		}
+		seed, err := NewSeed(tt.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("test %d: failed to derive seed: %v", i, err)
+		}
+		if want, _ := hex.DecodeString(tt.seed); !bytes.Equal(seed, want) {
+			t.Errorf("test %d: seed mismatch: have %x, want %x", i, seed, want)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		if err != nil {
+			t.Fatalf("%d bits: failed to generate mnemonic: %v", bits, err)
+		}
+		if words := len(strings.Fields(mnemonic)); words != bits/32*3 {
+			t.Errorf("%d bits: have %d words, want %d", bits, words, bits/32*3)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Errorf("%d bits: generated mnemonic invalid: %v", bits, err)
+		}
+	}
+	for _, bits := range []int{0, 96, 129, 288} {
+		if _, err := NewMnemonic(bits); err == nil {
+			t.Errorf("%d bits: expected error", bits)
+		}
+	}
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		valid    bool
+	}{
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", true},
+		{"  abandon abandon abandon abandon abandon abandon\tabandon abandon abandon abandon abandon about ", true},
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", false}, // checksum
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abound", false},  // unknown word
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon about", false},                   // too short
+		{"Abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", false},   // case
+		{"", false},
+	}
+	for i, tt := range tests {
+		err := ValidateMnemonic(tt.mnemonic)
+		if tt.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !tt.valid && err != ErrInvalidMnemonic {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrInvalidMnemonic)
+		}
+	}
+}
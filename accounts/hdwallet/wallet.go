@@ -0,0 +1,608 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum"
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/accounts/keystore"
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// walletVersion is the version of the wallet file format.
+const walletVersion = 1
+
+// selfDeriveThrottling is the minimum time between account discoveries.
+const selfDeriveThrottling = time.Second
+
+// ErrPasswordNeeded is returned when opening a wallet without a password, if the
+// seed isn't encrypted with an empty one. In this case, the calling application
+// should request user input to enter the password and send it back.
+var ErrPasswordNeeded = errors.New("hdwallet: password needed")
+
+// accountJSON is an account tracked by a wallet, stored so that it is listed
+// even while the wallet is closed.
+type accountJSON struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
+}
+
+// walletJSON is the content of a wallet file.
+type walletJSON struct {
+	ID       string                `json:"id"`
+	Version  int                   `json:"version"`
+	Crypto   keystore.CryptoJSONV4 `json:"crypto"`
+	Accounts []accountJSON         `json:"accounts"`
+}
+
+// Wallet represents an HD wallet stored in the wallet directory.
+type Wallet struct {
+	hub  *Hub         // HD wallet hub the wallet was found through
+	url  accounts.URL // Textual URL uniquely identifying this wallet
+	path string       // Path of the wallet file
+	file walletJSON   // Content of the wallet file
+
+	master *extendedKey // BIP-32 master key, nil while the wallet is closed
+
+	accounts []accounts.Account                         // List of accounts tracked by the wallet
+	paths    map[common.Address]accounts.DerivationPath // Known derivation paths for signing operations
+
+	deriveNextPaths []accounts.DerivationPath // Next derivation paths for account auto-discovery (multiple bases supported)
+	deriveNextAddrs []common.Address          // Next derived account addresses for auto-discovery (multiple bases supported)
+	deriveChain     sdcereum.ChainStateReader // Blockchain state reader to discover used account with
+	deriveReq       chan chan struct{}        // Channel to request a self-derivation on
+	deriveQuit      chan chan error           // Channel to terminate the self-deriver with
+
+	stateLock sync.RWMutex // Protects read and write access to the wallet struct fields
+}
+
+// newWallet creates the wallet of a wallet file, tracking the accounts listed in
+// the file.
+func newWallet(hub *Hub, path string, file walletJSON) *Wallet {
+	w := &Wallet{
+		hub:   hub,
+		url:   accounts.URL{Scheme: Scheme, Path: file.ID},
+		path:  path,
+		file:  file,
+		paths: make(map[common.Address]accounts.DerivationPath),
+	}
+	for _, acc := range file.Accounts {
+		path, err := accounts.ParseDerivationPath(acc.Path)
+		if err != nil {
+			log.Warn("Skipping HD wallet account with invalid path", "wallet", w.url, "path", acc.Path, "err", err)
+			continue
+		}
+		w.track(acc.Address, path)
+	}
+	return w
+}
+
+// loadWallet reads a wallet file, without decrypting its seed.
+func loadWallet(hub *Hub, path string) (*Wallet, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file walletJSON
+	if err := json.Unmarshal(blob, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != walletVersion {
+		return nil, fmt.Errorf("unsupported wallet version %d", file.Version)
+	}
+	if file.ID == "" {
+		return nil, errors.New("missing wallet id")
+	}
+	return newWallet(hub, path, file), nil
+}
+
+// track adds an account to the list of tracked accounts, returning whsdcer it
+// wasn't tracked yet.
+//
+// Note, track assumes the state lock is held!
+func (w *Wallet) track(address common.Address, path accounts.DerivationPath) bool {
+	if _, ok := w.paths[address]; ok {
+		return false
+	}
+	w.accounts = append(w.accounts, accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+	})
+	w.paths[address] = append(accounts.DerivationPath{}, path...)
+	return true
+}
+
+// store writes the wallet file, including the accounts currently tracked. The
+// file is replaced atomically so a crash never leaves a truncated seed behind.
+//
+// Note, store assumes the state lock is held!
+func (w *Wallet) store() error {
+	w.file.Accounts = make([]accountJSON, len(w.accounts))
+	for i, acc := range w.accounts {
+		w.file.Accounts[i] = accountJSON{Address: acc.Address, Path: w.paths[acc.Address].String()}
+	}
+	blob, err := json.MarshalIndent(w.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(w.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(w.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), w.path)
+}
+
+// URL implements accounts.Wallet, returning the URL of the HD wallet.
+func (w *Wallet) URL() accounts.URL {
+	return w.url // Immutable, no need for a lock
+}
+
+// Status implements accounts.Wallet, returning whsdcer the seed of the wallet is
+// decrypted and the number of accounts tracked.
+func (w *Wallet) Status() (string, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.master == nil {
+		return fmt.Sprintf("Closed, %d accounts", len(w.accounts)), nil
+	}
+	return fmt.Sprintf("Online, %d accounts", len(w.accounts)), nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet with the
+// passphrase and starting account discovery.
+//
+// As the accounts listed in the wallet file aren't protected by the encryption,
+// they are all derived again and the wallet refuses to open if any of them
+// doesn't match its derivation path.
+func (w *Wallet) Open(passphrase string) error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master != nil {
+		return accounts.ErrWalletAlreadyOpen
+	}
+	master, err := w.decryptMaster(passphrase)
+	if err != nil {
+		return err
+	}
+	for _, acc := range w.accounts {
+		key, err := master.derive(w.paths[acc.Address])
+		if err != nil {
+			zeroBytes(master.key)
+			return err
+		}
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		zeroKey(key)
+
+		if address != acc.Address {
+			zeroBytes(master.key)
+			return fmt.Errorf("hdwallet: account %s doesn't match path %s", acc.Address.Hex(), w.paths[acc.Address])
+		}
+	}
+	w.master = master
+
+	w.deriveReq = make(chan chan struct{})
+	w.deriveQuit = make(chan chan error)
+
+	go w.selfDerive()
+
+	// Notify anyone listening for wallet events that the wallet is accessible
+	go w.hub.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// decryptMaster decrypts the seed of the wallet and derives the master key.
+func (w *Wallet) decryptMaster(passphrase string) (*extendedKey, error) {
+	seed, err := keystore.DecryptDataV4(w.file.Crypto, passphrase)
+	if err != nil {
+		if err == keystore.ErrDecrypt && passphrase == "" {
+			return nil, ErrPasswordNeeded
+		}
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	return newMasterKey(seed)
+}
+
+// Close implements accounts.Wallet, stopping account discovery and clearing the
+// master key from memory. The accounts stay tracked.
+func (w *Wallet) Close() error {
+	w.stateLock.RLock()
+	dQuit := w.deriveQuit
+	w.stateLock.RUnlock()
+
+	// Terminate the self-derivations
+	var err error
+	if dQuit != nil {
+		errc := make(chan error)
+		dQuit <- errc
+		err = <-errc
+	}
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveQuit = nil
+	w.deriveReq = nil
+
+	if w.master != nil {
+		zeroBytes(w.master.key)
+		w.master = nil
+	}
+	return err
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts tracked by
+// the wallet. If self-derivation was enabled, the account list is periodically
+// expanded based on current chain state.
+func (w *Wallet) Accounts() []accounts.Account {
+	w.stateLock.RLock()
+	deriveReq := w.deriveReq
+	w.stateLock.RUnlock()
+
+	// Attempt self-derivation if it's running
+	reqc := make(chan struct{}, 1)
+	select {
+	case deriveReq <- reqc:
+		// Self-derivation request accepted, wait for it
+		<-reqc
+	default:
+		// Self-derivation offline, throttled or busy, skip
+	}
+	// Return whatever account list we ended up with
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// selfDerive is an account derivation loop that upon request attempts to find
+// new non-zero accounts.
+func (w *Wallet) selfDerive() {
+	log.Debug("HD wallet self-derivation started", "url", w.url)
+	defer log.Debug("HD wallet self-derivation stopped", "url", w.url)
+
+	// Execute self-derivations until termination or error
+	var (
+		reqc chan struct{}
+		errc chan error
+		err  error
+	)
+	for errc == nil && err == nil {
+		// Wait until either derivation or termination is requested
+		select {
+		case errc = <-w.deriveQuit:
+			// Termination requested
+			continue
+		case reqc = <-w.deriveReq:
+			// Account discovery requested
+		}
+		// Derivation needs a chain, skip if unavailable. The master key can't
+		// change until this loop is terminated, so it's safe to use unlocked.
+		w.stateLock.RLock()
+		if w.deriveChain == nil {
+			w.stateLock.RUnlock()
+			reqc <- struct{}{}
+			continue
+		}
+		var (
+			master = w.master
+			chain  = w.deriveChain
+
+			nextPaths = make([]accounts.DerivationPath, len(w.deriveNextPaths))
+			nextAddrs = append([]common.Address{}, w.deriveNextAddrs...)
+
+			paths []accounts.DerivationPath
+			addrs []common.Address
+		)
+		for i, path := range w.deriveNextPaths {
+			nextPaths[i] = append(accounts.DerivationPath{}, path...)
+		}
+		w.stateLock.RUnlock()
+
+		for i := 0; i < len(nextAddrs) && err == nil; i++ {
+			for empty := false; !empty; {
+				// Retrieve the next derived sdcereum account
+				if nextAddrs[i] == (common.Address{}) {
+					var key *ecdsa.PrivateKey
+					if key, err = master.derive(nextPaths[i]); err != nil {
+						log.Warn("HD wallet account derivation failed", "url", w.url, "path", nextPaths[i], "err", err)
+						break
+					}
+					nextAddrs[i] = crypto.PubkeyToAddress(key.PublicKey)
+					zeroKey(key)
+				}
+				// Check the account's status against the current chain state
+				var (
+					balance *big.Int
+					nonce   uint64
+				)
+				balance, err = chain.BalanceAt(context.Background(), nextAddrs[i], nil)
+				if err != nil {
+					log.Warn("HD wallet balance retrieval failed", "url", w.url, "err", err)
+					break
+				}
+				nonce, err = chain.NonceAt(context.Background(), nextAddrs[i], nil)
+				if err != nil {
+					log.Warn("HD wallet nonce retrieval failed", "url", w.url, "err", err)
+					break
+				}
+				// Track the account, unless it's empty and there's a later base to
+				// derive the next empty account from
+				if balance.Sign() == 0 && nonce == 0 {
+					empty = true
+					if i < len(nextAddrs)-1 {
+						break
+					}
+				}
+				paths = append(paths, append(accounts.DerivationPath{}, nextPaths[i]...))
+				addrs = append(addrs, nextAddrs[i])
+
+				// Fetch the next potential account
+				if !empty {
+					nextAddrs[i] = common.Address{}
+					nextPaths[i][len(nextPaths[i])-1]++
+				}
+			}
+		}
+		// Insert any accounts successfully derived, persisting the new ones
+		w.stateLock.Lock()
+		var added bool
+		for i := range addrs {
+			if w.track(addrs[i], paths[i]) {
+				log.Info("HD wallet discovered new account", "url", w.url, "address", addrs[i], "path", paths[i])
+				added = true
+			}
+		}
+		if added {
+			if err := w.store(); err != nil {
+				log.Warn("Failed to store HD wallet", "url", w.url, "err", err)
+			}
+		}
+		// Shift the self-derivation forward
+		w.deriveNextAddrs = nextAddrs
+		w.deriveNextPaths = nextPaths
+		w.stateLock.Unlock()
+
+		// Notify the user of termination and loop after a bit of time (to avoid trashing)
+		reqc <- struct{}{}
+		if err == nil {
+			select {
+			case errc = <-w.deriveQuit:
+				// Termination requested, abort
+			case <-time.After(selfDeriveThrottling):
+				// Waited enough, willing to self-derive again
+			}
+		}
+	}
+	// In case of error, wait for termination
+	if err != nil {
+		log.Debug("HD wallet self-derivation failed", "url", w.url, "err", err)
+		errc = <-w.deriveQuit
+	}
+	errc <- err
+}
+
+// Contains implements accounts.Wallet, returning whsdcer a particular account is
+// or is not tracked by this wallet.
+func (w *Wallet) Contains(account accounts.Account) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	_, exists := w.paths[account.Address]
+	return exists
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and stored in the wallet file.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	key, err := w.master.derive(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	account := accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+	}
+	if pin && w.track(address, path) {
+		if err := w.store(); err != nil {
+			return accounts.Account{}, err
+		}
+	}
+	return account, nil
+}
+
+// SelfDerive sets a base account derivation path from which the wallet attempts
+// to discover non zero accounts and automatically add them to list of tracked
+// accounts.
+//
+// Note, self derivation will increment the last component of the specified path
+// opposed to descending into a child path to allow discovering accounts starting
+// from non zero components.
+//
+// Multiple bases may be given to discover accounts of wallets that used other
+// derivation paths in the past. Only the last base will be used to derive the
+// next empty account.
+//
+// You can disable automatic account discovery by calling SelfDerive with a nil
+// chain state reader.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain sdcereum.ChainStateReader) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveNextPaths = make([]accounts.DerivationPath, len(bases))
+	for i, base := range bases {
+		w.deriveNextPaths[i] = make(accounts.DerivationPath, len(base))
+		copy(w.deriveNextPaths[i][:], base[:])
+	}
+	w.deriveNextAddrs = make([]common.Address, len(bases))
+	w.deriveChain = chain
+}
+
+// signHash derives the key of the account from the master key of the open wallet
+// and signs the hash with it.
+func (w *Wallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.master == nil {
+		return nil, accounts.NewAuthNeededError("password")
+	}
+	return w.signHashWithMaster(w.master, account, hash)
+}
+
+// signHashWithPassphrase decrypts the seed with the passphrase, regardless of
+// whsdcer the wallet is open, and signs the hash with the key of the account.
+func (w *Wallet) signHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	master, err := w.decryptMaster(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(master.key)
+
+	return w.signHashWithMaster(master, account, hash)
+}
+
+// signHashWithMaster signs the hash with the key of the account derived from the
+// given master key.
+//
+// Note, signHashWithMaster assumes the state lock is held!
+func (w *Wallet) signHashWithMaster(master *extendedKey, account accounts.Account, hash []byte) ([]byte, error) {
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	key, err := master.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return crypto.Sign(hash, key)
+}
+
+// SignData implements accounts.Wallet, signing the keccak256 hash of the given
+// data with the key of the account.
+//
+// If the wallet isn't open yet, an AuthNeededError is returned. The user may
+// retry by providing the password via SignDataWithPassphrase.
+func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet, signing the keccak256 hash
+// of the given data with the key of the account, decrypted with the passphrase.
+func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.signHashWithPassphrase(account, passphrase, crypto.Keccak256(data))
+}
+
+// SignText implements accounts.Wallet, signing the hash of the given text, prefixed
+// by the sdcereum prefix scheme, with the key of the account.
+//
+// If the wallet isn't open yet, an AuthNeededError is returned. The user may
+// retry by providing the password via SignTextWithPassphrase.
+func (w *Wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, signing the hash of the
+// prefixed text with the key of the account, decrypted with the passphrase.
+func (w *Wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.signHashWithPassphrase(account, passphrase, accounts.TextHash(text))
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with the key
+// of the account.
+//
+// If the wallet isn't open yet, an AuthNeededError is returned. The user may
+// retry by providing the password via SignTxWithPassphrase.
+func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+	sig, err := w.signHash(account, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, signing the given transaction
+// with the key of the account, decrypted with the passphrase.
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+	sig, err := w.signHashWithPassphrase(account, passphrase, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// zeroKey clears a private key from memory.
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// zeroBytes clears a secret from memory.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
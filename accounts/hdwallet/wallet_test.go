@@ -0,0 +1,257 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/accounts/keystore"
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/crypto"
+)
+
+// testMnemonic is the mnemonic commonly used by development tooling.
+const testMnemonic = "test test test test test test test test test test test junk"
+
+// testAddresses are the first accounts of testMnemonic on the default base path.
+var testAddresses = []common.Address{
+	common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+	common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+	common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"),
+}
+
+// Tests the first test vector of BIP-32.
+func TestDerivationVector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		t.Fatalf("failed to create master key: %v", err)
+	}
+	path, _ := accounts.ParseDerivationPath("m/0'/1/2'/2/1000000000")
+	key, err := master.derive(path)
+	if err != nil {
+		t.Fatalf("failed to derive key: %v", err)
+	}
+	if have, want := hex.EncodeToString(crypto.FromECDSA(key)), "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"; have != want {
+		t.Errorf("key mismatch: have %s, want %s", have, want)
+	}
+}
+
+func newTestWallet(t *testing.T) (*Hub, *Wallet) {
+	t.Helper()
+
+	hub, err := NewHub(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("failed to create hub: %v", err)
+	}
+	wallet, err := hub.NewWallet(testMnemonic, "", "password")
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	return hub, wallet
+}
+
+func TestWalletDerive(t *testing.T) {
+	hub, wallet := newTestWallet(t)
+
+	if _, err := wallet.Derive(accounts.DefaultBaseDerivationPath, true); err != accounts.ErrWalletClosed {
+		t.Fatalf("derive on closed wallet: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if err := wallet.Open(""); err != ErrPasswordNeeded {
+		t.Fatalf("open without password: have %v, want %v", err, ErrPasswordNeeded)
+	}
+	if err := wallet.Open("wrong"); err != keystore.ErrDecrypt {
+		t.Fatalf("open with wrong password: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	if err := wallet.Open("password"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	next := accounts.DefaultIterator(accounts.DefaultBaseDerivationPath)
+	for i, want := range testAddresses {
+		account, err := wallet.Derive(next(), i != 2)
+		if err != nil {
+			t.Fatalf("account %d: failed to derive: %v", i, err)
+		}
+		if account.Address != want {
+			t.Errorf("account %d: address mismatch: have %s, want %s", i, account.Address.Hex(), want.Hex())
+		}
+	}
+	if have := wallet.Accounts(); len(have) != 2 {
+		t.Fatalf("pinned account count mismatch: have %d, want 2", len(have))
+	}
+	if have, want := wallet.Accounts()[1].URL.String(), "hdwallet://"+wallet.file.ID+"/m/44'/60'/0'/0/1"; have != want {
+		t.Errorf("account URL mismatch: have %s, want %s", have, want)
+	}
+	wallet.Close()
+
+	// The pinned accounts should be listed by a new hub, without opening the wallet
+	reloaded, err := NewHub(hub.dir, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("failed to create hub: %v", err)
+	}
+	wallets := reloaded.Wallets()
+	if len(wallets) != 1 || wallets[0].URL() != wallet.URL() {
+		t.Fatalf("wallet mismatch: have %v, want [%v]", wallets, wallet.URL())
+	}
+	accs := wallets[0].Accounts()
+	if len(accs) != 2 || accs[0].Address != testAddresses[0] || accs[1].Address != testAddresses[1] {
+		t.Fatalf("reloaded accounts mismatch: have %v", accs)
+	}
+	if !wallets[0].Contains(accounts.Account{Address: testAddresses[1]}) {
+		t.Errorf("reloaded wallet doesn't contain pinned account")
+	}
+	if wallets[0].Contains(accounts.Account{Address: testAddresses[2]}) {
+		t.Errorf("reloaded wallet contains unpinned account")
+	}
+}
+
+func TestWalletSign(t *testing.T) {
+	_, wallet := newTestWallet(t)
+
+	if err := wallet.Open("password"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	account, err := wallet.Derive(accounts.DefaultBaseDerivationPath, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	sig, err := wallet.SignText(account, []byte("hello"))
+	if err != nil {
+		t.Fatalf("failed to sign text: %v", err)
+	}
+	pubkey, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != account.Address {
+		t.Errorf("signer mismatch: have %s, want %s", signer.Hex(), account.Address.Hex())
+	}
+	if _, err := wallet.SignText(accounts.Account{Address: testAddresses[1]}, []byte("hello")); err != accounts.ErrUnknownAccount {
+		t.Errorf("signing with unknown account: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+	wallet.Close()
+
+	// Closed wallets require the password to sign
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	var authErr *accounts.AuthNeededError
+	if _, err := wallet.SignTx(account, tx, big.NewInt(1)); !errors.As(err, &authErr) {
+		t.Fatalf("signing with closed wallet: have %v, want auth needed error", err)
+	}
+	if _, err := wallet.SignTxWithPassphrase(account, "wrong", tx, big.NewInt(1)); err != keystore.ErrDecrypt {
+		t.Fatalf("signing with wrong password: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	signed, err := wallet.SignTxWithPassphrase(account, "password", tx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed); err != nil || sender != account.Address {
+		t.Errorf("sender mismatch: have %s (%v), want %s", sender.Hex(), err, account.Address.Hex())
+	}
+}
+
+// Tests that a wallet refuses to open if its file lists an account that doesn't
+// belong to the seed.
+func TestWalletTampered(t *testing.T) {
+	hub, wallet := newTestWallet(t)
+
+	if err := wallet.Open("password"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	if _, err := wallet.Derive(accounts.DefaultBaseDerivationPath, true); err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	wallet.Close()
+
+	blob, err := os.ReadFile(wallet.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob = []byte(strings.Replace(string(blob), strings.ToLower(testAddresses[0].Hex()), strings.ToLower(testAddresses[1].Hex()), 1))
+	if err := os.WriteFile(wallet.path, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tampered, err := loadWallet(hub, wallet.path)
+	if err != nil {
+		t.Fatalf("failed to load wallet: %v", err)
+	}
+	if err := tampered.Open("password"); err == nil {
+		t.Fatalf("tampered wallet opened")
+	}
+}
+
+// testChain is a chain state reader where a fixed set of accounts has been used.
+type testChain struct {
+	used map[common.Address]bool
+}
+
+func (c *testChain) BalanceAt(ctx context.Context, account common.Address, number *big.Int) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (c *testChain) StorageAt(ctx context.Context, account common.Address, key common.Hash, number *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *testChain) CodeAt(ctx context.Context, account common.Address, number *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *testChain) NonceAt(ctx context.Context, account common.Address, number *big.Int) (uint64, error) {
+	if c.used[account] {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Tests that self-derivation discovers the used accounts, as well as the first
+// empty one.
+func TestWalletSelfDerive(t *testing.T) {
+	_, wallet := newTestWallet(t)
+
+	if err := wallet.Open("password"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	wallet.SelfDerive([]accounts.DerivationPath{accounts.DefaultBaseDerivationPath}, &testChain{
+		used: map[common.Address]bool{testAddresses[0]: true, testAddresses[1]: true},
+	})
+	// Self-derivation requests are dropped while the deriver is busy, so retry
+	var accs []accounts.Account
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if accs = wallet.Accounts(); len(accs) == len(testAddresses) {
+			break
+		}
+	}
+	if len(accs) != len(testAddresses) {
+		t.Fatalf("account count mismatch: have %d, want %d", len(accs), len(testAddresses))
+	}
+	for i, want := range testAddresses {
+		if accs[i].Address != want {
+			t.Errorf("account %d: address mismatch: have %s, want %s", i, accs[i].Address.Hex(), want.Hex())
+		}
+	}
+}
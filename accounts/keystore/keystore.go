@@ -483,9 +483,35 @@ func (ks *KeyStore) Update(a accounts.Account, passphrase, newPassphrase string)
 	if err != nil {
 		return err
 	}
+	// Keep version 4 key files in their format
+	if ksp, ok := ks.storage.(*keyStorePassphrase); ok {
+		v4, err := isKeyFileV4(a.URL.Path)
+		if err != nil {
+			return err
+		}
+		if v4 {
+			return migrateKeyFile(a.URL.Path, key, newPassphrase, versionV4, ksp.scryptN, ksp.scryptP)
+		}
+	}
 	return ks.storage.StoreKey(a.URL.Path, key, newPassphrase)
 }
 
+// Migrate re-encrypts the key file of an existing account with the key file format
+// of the given version (3 or 4) and scrypt parameters, keeping its passphrase. The
+// old key file is only replaced once the new one is verified to hold the same key.
+func (ks *KeyStore) Migrate(a accounts.Account, passphrase string, version, scryptN, scryptP int) error {
+	if _, ok := ks.storage.(*keyStorePassphrase); !ok {
+		return errors.New("plaintext key files can't be migrated")
+	}
+	a, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return err
+	}
+	defer zeroKey(key.PrivateKey)
+
+	return migrateKeyFile(a.URL.Path, key, passphrase, version, scryptN, scryptP)
+}
+
 // ImportPreSaleKey decrypts the given sdcereum presale wallet and stores
 // a key file in the key directory. The key file is encrypted with the same passphrase.
 func (ks *KeyStore) ImportPreSaleKey(keyJSON []byte, passphrase string) (accounts.Account, error) {
@@ -17,8 +17,11 @@
 package keystore
 
 import (
+	"bytes"
+	"encoding/json"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -384,6 +387,92 @@ func TestImportExport(t *testing.T) {
 	}
 }
 
+// Tests that accounts can be migrated between key file formats, keeping their key
+// file and passphrase.
+func TestMigrate(t *testing.T) {
+	_, ks := tmpKeyStore(t, true)
+	acc, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := os.ReadFile(acc.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A wrong passphrase leaves the key file untouched
+	if err := ks.Migrate(acc, "bar", versionV4, veryLightScryptN, veryLightScryptP); err != ErrDecrypt {
+		t.Fatalf("migration with wrong passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	if err := ks.Migrate(acc, "foo", 5, veryLightScryptN, veryLightScryptP); err == nil {
+		t.Fatal("migration to unknown version succeeded")
+	}
+	if have, _ := os.ReadFile(acc.URL.Path); !bytes.Equal(have, old) {
+		t.Fatal("key file changed by failed migration")
+	}
+	for _, version := range []int{versionV4, 3} {
+		if err := ks.Migrate(acc, "foo", version, veryLightScryptN, veryLightScryptP); err != nil {
+			t.Fatalf("migration to version %d failed: %v", version, err)
+		}
+		keyjson, err := os.ReadFile(acc.URL.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var header struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal(keyjson, &header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Version != version {
+			t.Fatalf("key file version mismatch: have %d, want %d", header.Version, version)
+		}
+		if err := ks.Unlock(acc, "foo"); err != nil {
+			t.Fatalf("unlocking version %d key failed: %v", version, err)
+		}
+		ks.Lock(acc.Address)
+	}
+	if files, _ := os.ReadDir(filepath.Dir(acc.URL.Path)); len(files) != 1 {
+		t.Fatalf("key file count mismatch: have %d, want 1", len(files))
+	}
+}
+
+// Tests that updating the passphrase of an account keeps the format of its key file.
+func TestUpdateKeepsVersion(t *testing.T) {
+	_, ks := tmpKeyStore(t, true)
+	acc, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []int{versionV4, 3} {
+		if err := ks.Migrate(acc, "foo", version, veryLightScryptN, veryLightScryptP); err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.Update(acc, "foo", "bar"); err != nil {
+			t.Fatalf("updating version %d key failed: %v", version, err)
+		}
+		keyjson, err := os.ReadFile(acc.URL.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var header struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal(keyjson, &header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Version != version {
+			t.Fatalf("key file version changed: have %d, want %d", header.Version, version)
+		}
+		if err := ks.Unlock(acc, "bar"); err != nil {
+			t.Fatalf("unlocking updated version %d key failed: %v", version, err)
+		}
+		ks.Lock(acc.Address)
+		if err := ks.Update(acc, "bar", "foo"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // TestImportRace tests the keystore on races.
 // This test should fail under -race if importing races.
 func TestImportRace(t *testing.T) {
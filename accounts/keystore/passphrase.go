@@ -32,6 +32,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -129,6 +130,70 @@ func (ks keyStorePassphrase) StoreKey(filename string, key *Key, auth string) er
 	return os.Rename(tmpName, filename)
 }
 
+// migrateKeyFile re-encrypts the key stored in the given file with the key file
+// format of the given version and scrypt parameters. The file is only replaced
+// once the new contents are verified to decrypt to the same key.
+func migrateKeyFile(filename string, key *Key, auth string, version, scryptN, scryptP int) error {
+	var (
+		keyjson []byte
+		err     error
+	)
+	switch version {
+	case 3:
+		keyjson, err = EncryptKey(key, auth, scryptN, scryptP)
+	case versionV4:
+		keyjson, err = EncryptKeyV4(key, auth, scryptN, scryptP)
+	default:
+		return fmt.Errorf("version not supported: %v", version)
+	}
+	if err != nil {
+		return err
+	}
+	tmpName, err := writeTemporaryKeyFile(filename, keyjson)
+	if err != nil {
+		return err
+	}
+	// Read the new file back, making sure the key round-trips before replacing
+	// the old one
+	if err := verifyKeyFile(tmpName, key, auth); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("migrated key failed verification: %v", err)
+	}
+	return os.Rename(tmpName, filename)
+}
+
+// isKeyFileV4 reports whether the given key file has the version 4 format.
+func isKeyFileV4(filename string) (bool, error) {
+	keyjson, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(keyjson, &m); err != nil {
+		return false, err
+	}
+	version, ok := m["version"].(float64)
+	return ok && version == versionV4, nil
+}
+
+// verifyKeyFile checks that the given file decrypts to the expected key.
+func verifyKeyFile(filename string, want *Key, auth string) error {
+	keyjson, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	have, err := DecryptKey(keyjson, auth)
+	if err != nil {
+		return err
+	}
+	defer zeroKey(have.PrivateKey)
+
+	if have.Address != want.Address || have.Id != want.Id || have.PrivateKey.D.Cmp(want.PrivateKey.D) != 0 {
+		return errors.New("key content mismatch")
+	}
+	return nil
+}
+
 func (ks keyStorePassphrase) JoinPath(filename string) string {
 	if filepath.IsAbs(filename) {
 		return filename
@@ -214,6 +279,12 @@ func DecryptKey(keyjson []byte, auth string) (*Key, error) {
 			return nil, err
 		}
 		keyBytes, keyId, err = decryptKeyV1(k, auth)
+	} else if version, ok := m["version"].(float64); ok && version == versionV4 {
+		k := new(encryptedKeyJSONV4)
+		if err := json.Unmarshal(keyjson, k); err != nil {
+			return nil, err
+		}
+		keyBytes, keyId, err = decryptKeyV4(k, auth)
 	} else {
 		k := new(encryptedKeyJSONV3)
 		if err := json.Unmarshal(keyjson, k); err != nil {
@@ -17,10 +17,14 @@
 package keystore
 
 import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
 	"os"
 	"testing"
 
 	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/crypto"
 )
 
 const (
@@ -58,3 +62,84 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+type KeyStoreTestV4 struct {
+	Json     encryptedKeyJSONV4
+	Password string
+	Priv     string
+}
+
+// Tests that the EIP-2335 test vectors decrypt, including the normalization of
+// their Unicode password.
+func TestV4TestVectors(t *testing.T) {
+	t.Parallel()
+
+	tests := make(map[string]KeyStoreTestV4)
+	if err := common.LoadJSON("testdata/v4_test_vector.json", &tests); err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range tests {
+		privBytes, _, err := decryptKeyV4(&test.Json, test.Password)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if privHex := hex.EncodeToString(privBytes); privHex != test.Priv {
+			t.Fatalf("%s: decrypted bytes mismatch: have %v, want %v", name, privHex, test.Priv)
+		}
+		if _, _, err := decryptKeyV4(&test.Json, "testpassword"); err != ErrDecrypt {
+			t.Fatalf("%s: wrong password: have %v, want %v", name, err, ErrDecrypt)
+		}
+	}
+}
+
+// Tests that version 4 key files round-trip through DecryptKey, and that their
+// passwords are normalized.
+func TestKeyEncryptDecryptV4(t *testing.T) {
+	key := newKeyFromECDSA(mustGenerateKey(t))
+
+	// "Å" composed, decomposed and with a control code typed along
+	keyjson, err := EncryptKeyV4(key, "pass\u00c5", veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, password := range []string{"pass\u00c5", "passA\u030a", "pass\u00c5\u007f"} {
+		have, err := DecryptKey(keyjson, password)
+		if err != nil {
+			t.Fatalf("password %q: %v", password, err)
+		}
+		if have.Address != key.Address || have.Id != key.Id || have.PrivateKey.D.Cmp(key.PrivateKey.D) != 0 {
+			t.Fatalf("password %q: key mismatch", password)
+		}
+	}
+	if _, err := DecryptKey(keyjson, "passA"); err != ErrDecrypt {
+		t.Fatalf("wrong password: have %v, want %v", err, ErrDecrypt)
+	}
+	// The key file must be indexable by address like version 3 ones
+	var header struct {
+		Address string `json:"address"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(keyjson, &header); err != nil {
+		t.Fatal(err)
+	}
+	if common.HexToAddress(header.Address) != key.Address || header.Version != versionV4 {
+		t.Fatalf("header mismatch: have %+v", header)
+	}
+	// Unknown modules are rejected
+	var k encryptedKeyJSONV4
+	if err := json.Unmarshal(keyjson, &k); err != nil {
+		t.Fatal(err)
+	}
+	k.Crypto.KDF.Function = "argon2id"
+	if _, _, err := decryptKeyV4(&k, "pass\u00c5"); err == nil {
+		t.Fatal("unknown kdf accepted")
+	}
+}
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,307 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+
+Version 4 key files follow the keystore format of EIP-2335: the encryption is
+described by a kdf, a checksum and a cipher module, each consisting of a function
+name, its parameters and a message, and passwords are normalized before use so
+the same password typed on different systems decrypts the key.
+
+In addition to the fields of EIP-2335, the key files contain the address of the
+key, which is what the keystore indexes them by.
+
+*/
+
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sdcereum/go-sdcereum/common/math"
+	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+const versionV4 = 4
+
+type encryptedKeyJSONV4 struct {
+	Crypto      CryptoJSONV4 `json:"crypto"`
+	Description string       `json:"description"`
+	Pubkey      string       `json:"pubkey"`
+	Path        string       `json:"path"`
+	Address     string       `json:"address"`
+	UUID        string       `json:"uuid"`
+	Version     int          `json:"version"`
+}
+
+// CryptoJSONV4 is the encrypted secret of a version 4 key file.
+type CryptoJSONV4 struct {
+	KDF      CryptoModuleJSON `json:"kdf"`
+	Checksum CryptoModuleJSON `json:"checksum"`
+	Cipher   CryptoModuleJSON `json:"cipher"`
+}
+
+// CryptoModuleJSON is a step of the encryption of a version 4 key file.
+type CryptoModuleJSON struct {
+	Function string                 `json:"function"`
+	Params   map[string]interface{} `json:"params"`
+	Message  string                 `json:"message"`
+}
+
+// kdfV4 derives the decryption key from the normalized password.
+type kdfV4 func(password []byte, params map[string]interface{}) ([]byte, error)
+
+// checksumV4 computes the checksum of the cipher message, proving the derived
+// key is correct.
+type checksumV4 func(derivedKey, cipherText []byte) []byte
+
+// cipherV4 encrypts or decrypts the secret with the derived key.
+type cipherV4 struct {
+	encrypt func(key, plainText []byte, params map[string]interface{}) ([]byte, error)
+	decrypt func(key, cipherText []byte, params map[string]interface{}) ([]byte, error)
+}
+
+// The functions available for the modules of version 4 key files.
+var (
+	kdfsV4 = map[string]kdfV4{
+		"scrypt": scryptKDFV4,
+		"pbkdf2": pbkdf2KDFV4,
+	}
+	checksumsV4 = map[string]checksumV4{
+		"sha256": sha256ChecksumV4,
+	}
+	ciphersV4 = map[string]cipherV4{
+		"aes-128-ctr": {encrypt: aesCTRCipherV4, decrypt: aesCTRCipherV4},
+	}
+)
+
+// normalizePassword prepares a password as specified by EIP-2335: it is NFKD
+// normalized and stripped of control codes.
+func normalizePassword(password string) []byte {
+	return []byte(strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, norm.NFKD.String(password)))
+}
+
+func scryptKDFV4(password []byte, params map[string]interface{}) ([]byte, error) {
+	salt, err := hexParam(params, "salt")
+	if err != nil {
+		return nil, err
+	}
+	var n, r, p, dkLen int
+	for name, v := range map[string]*int{"n": &n, "r": &r, "p": &p, "dklen": &dkLen} {
+		if *v, err = intParam(params, name); err != nil {
+			return nil, err
+		}
+	}
+	if dkLen < 32 {
+		return nil, fmt.Errorf("derived key too short: %d bytes", dkLen)
+	}
+	return scrypt.Key(password, salt, n, r, p, dkLen)
+}
+
+func pbkdf2KDFV4(password []byte, params map[string]interface{}) ([]byte, error) {
+	salt, err := hexParam(params, "salt")
+	if err != nil {
+		return nil, err
+	}
+	if prf, _ := params["prf"].(string); prf != "hmac-sha256" {
+		return nil, fmt.Errorf("unsupported PBKDF2 PRF: %v", params["prf"])
+	}
+	c, err := intParam(params, "c")
+	if err != nil {
+		return nil, err
+	}
+	dkLen, err := intParam(params, "dklen")
+	if err != nil {
+		return nil, err
+	}
+	if dkLen < 32 {
+		return nil, fmt.Errorf("derived key too short: %d bytes", dkLen)
+	}
+	return pbkdf2.Key(password, salt, c, dkLen, sha256.New), nil
+}
+
+func sha256ChecksumV4(derivedKey, cipherText []byte) []byte {
+	h := sha256.New()
+	h.Write(derivedKey[16:32])
+	h.Write(cipherText)
+	return h.Sum(nil)
+}
+
+// aesCTRCipherV4 both encrypts and decrypts, as CTR mode is its own inverse.
+func aesCTRCipherV4(key, data []byte, params map[string]interface{}) ([]byte, error) {
+	iv, err := hexParam(params, "iv")
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV length: %d bytes", len(iv))
+	}
+	return aesCTRXOR(key[:16], data, iv)
+}
+
+// intParam retrieves an integer parameter of a module, which is a float64 if the
+// parameters were decoded from JSON.
+func intParam(params map[string]interface{}, name string) (int, error) {
+	switch v := params[name].(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid parameter %q: %v", name, params[name])
+}
+
+// hexParam retrieves a hex encoded binary parameter of a module.
+func hexParam(params map[string]interface{}, name string) ([]byte, error) {
+	s, ok := params[name].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing parameter %q", name)
+	}
+	return hex.DecodeString(s)
+}
+
+// EncryptDataV4 encrypts the data given as 'data' with the password 'auth' in the
+// format of version 4 key files, using scrypt as kdf.
+func EncryptDataV4(data []byte, auth string, scryptN, scryptP int) (CryptoJSONV4, error) {
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		panic("reading from crypto/rand failed: " + err.Error())
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		panic("reading from crypto/rand failed: " + err.Error())
+	}
+	cryptoStruct := CryptoJSONV4{
+		KDF: CryptoModuleJSON{
+			Function: keyHeaderKDF,
+			Params: map[string]interface{}{
+				"n":     scryptN,
+				"r":     scryptR,
+				"p":     scryptP,
+				"dklen": scryptDKLen,
+				"salt":  hex.EncodeToString(salt),
+			},
+		},
+		Checksum: CryptoModuleJSON{
+			Function: "sha256",
+			Params:   map[string]interface{}{},
+		},
+		Cipher: CryptoModuleJSON{
+			Function: "aes-128-ctr",
+			Params:   map[string]interface{}{"iv": hex.EncodeToString(iv)},
+		},
+	}
+	derivedKey, err := scryptKDFV4(normalizePassword(auth), cryptoStruct.KDF.Params)
+	if err != nil {
+		return CryptoJSONV4{}, err
+	}
+	cipherText, err := aesCTRCipherV4(derivedKey, data, cryptoStruct.Cipher.Params)
+	if err != nil {
+		return CryptoJSONV4{}, err
+	}
+	cryptoStruct.Cipher.Message = hex.EncodeToString(cipherText)
+	cryptoStruct.Checksum.Message = hex.EncodeToString(sha256ChecksumV4(derivedKey, cipherText))
+	return cryptoStruct, nil
+}
+
+// DecryptDataV4 decrypts the secret of a version 4 key file with the password
+// 'auth'.
+func DecryptDataV4(cryptoJSON CryptoJSONV4, auth string) ([]byte, error) {
+	kdf, ok := kdfsV4[cryptoJSON.KDF.Function]
+	if !ok {
+		return nil, fmt.Errorf("unsupported KDF: %s", cryptoJSON.KDF.Function)
+	}
+	checksum, ok := checksumsV4[cryptoJSON.Checksum.Function]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum: %s", cryptoJSON.Checksum.Function)
+	}
+	cipher, ok := ciphersV4[cryptoJSON.Cipher.Function]
+	if !ok {
+		return nil, fmt.Errorf("cipher not supported: %s", cryptoJSON.Cipher.Function)
+	}
+	cipherText, err := hex.DecodeString(cryptoJSON.Cipher.Message)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := hex.DecodeString(cryptoJSON.Checksum.Message)
+	if err != nil {
+		return nil, err
+	}
+	derivedKey, err := kdf(normalizePassword(auth), cryptoJSON.KDF.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(checksum(derivedKey, cipherText), mac) {
+		return nil, ErrDecrypt
+	}
+	return cipher.decrypt(derivedKey, cipherText, cryptoJSON.Cipher.Params)
+}
+
+// EncryptKeyV4 encrypts a key using the specified scrypt parameters into a
+// version 4 json blob that can be decrypted later on.
+func EncryptKeyV4(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
+	keyBytes := math.PaddedBigBytes(key.PrivateKey.D, 32)
+	cryptoStruct, err := EncryptDataV4(keyBytes, auth, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encryptedKeyJSONV4{
+		Crypto:  cryptoStruct,
+		Pubkey:  hex.EncodeToString(crypto.CompressPubkey(&key.PrivateKey.PublicKey)),
+		Address: hex.EncodeToString(key.Address[:]),
+		UUID:    key.Id.String(),
+		Version: versionV4,
+	})
+}
+
+func decryptKeyV4(keyProtected *encryptedKeyJSONV4, auth string) (keyBytes []byte, keyId []byte, err error) {
+	if keyProtected.Version != versionV4 {
+		return nil, nil, fmt.Errorf("version not supported: %v", keyProtected.Version)
+	}
+	keyUUID, err := uuid.Parse(keyProtected.UUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyId = keyUUID[:]
+	plainText, err := DecryptDataV4(keyProtected.Crypto, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(plainText) != 32 {
+		return nil, nil, errors.New("invalid private key length")
+	}
+	return plainText, keyId, nil
+}
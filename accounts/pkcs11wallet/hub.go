@@ -0,0 +1,223 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pkcs11wallet implements support for secp256k1 keys held by PKCS#11
+// tokens, such as hardware security modules or cloud key management services
+// exposing a PKCS#11 interface.
+//
+// Every token present in the slots of the module is represented by a wallet.
+// Opening a wallet logs into the token with the passphrase as user PIN and
+// discovers the secp256k1 key pairs stored on it. The private keys never leave
+// the token: signing is done with the CKM_ECDSA mechanism, after which the
+// signature is normalized and its recovery id computed to produce a signature
+// in the [R || S || V] format used by sdcereum.
+package pkcs11wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/event"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// Scheme is the URI prefix for PKCS#11 wallets.
+const Scheme = "pkcs11"
+
+// refreshCycle is the maximum time between wallet refreshes, as PKCS#11 has
+// no notifications about token insertion or removal.
+const refreshCycle = 5 * time.Second
+
+// refreshThrottling is the minimum time between wallet refreshes to avoid thrashing.
+const refreshThrottling = time.Second
+
+// Hub is an accounts.Backend that finds and handles the tokens of a PKCS#11 module.
+type Hub struct {
+	scheme string      // Protocol scheme prefixing account and wallet URLs.
+	module string      // Path of the PKCS#11 module in use
+	ctx    *pkcs11.Ctx // Handle of the loaded PKCS#11 module
+
+	refreshed   time.Time               // Time instance when the list of wallets was last refreshed
+	wallets     map[string]*Wallet      // Mapping from token serial numbers to wallet instances
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+	updating    bool                    // Whsdcer the event notification loop is running
+	closed      bool                    // Whsdcer the PKCS#11 module was already finalized
+
+	stateLock sync.RWMutex // Protects the internals of the hub from racey access
+}
+
+// NewHub loads the PKCS#11 module at the given path and creates a wallet manager
+// for its tokens.
+func NewHub(module string) (*Hub, error) {
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", module)
+	}
+	// The module might already be initialized by another user within the process
+	if err := ctx.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module %s: %v", module, err)
+	}
+	hub := &Hub{
+		scheme:  Scheme,
+		module:  module,
+		ctx:     ctx,
+		wallets: make(map[string]*Wallet),
+	}
+	hub.refreshWallets()
+	return hub, nil
+}
+
+// Wallets implements accounts.Backend, returning all the tokens currently present
+// in the slots of the PKCS#11 module.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	// Make sure the list of wallets is up to date
+	hub.refreshWallets()
+
+	hub.stateLock.RLock()
+	defer hub.stateLock.RUnlock()
+
+	cpy := make([]accounts.Wallet, 0, len(hub.wallets))
+	for _, wallet := range hub.wallets {
+		cpy = append(cpy, wallet)
+	}
+	sort.Sort(accounts.WalletsByURL(cpy))
+	return cpy
+}
+
+// refreshWallets scans the slots of the PKCS#11 module and updates the list of
+// wallets based on the tokens found.
+func (hub *Hub) refreshWallets() {
+	// Don't scan the slots like crazy if the user fetches wallets in a loop
+	hub.stateLock.RLock()
+	elapsed, closed := time.Since(hub.refreshed), hub.closed
+	hub.stateLock.RUnlock()
+
+	if closed || elapsed < refreshThrottling {
+		return
+	}
+	slots, err := hub.ctx.GetSlotList(true)
+	if err != nil {
+		log.Error("Failed to enumerate PKCS#11 slots", "module", hub.module, "err", err)
+		return
+	}
+	// Transform the current list of wallets into the new one
+	hub.stateLock.Lock()
+
+	events := []accounts.WalletEvent{}
+	seen := make(map[string]struct{})
+
+	for _, slot := range slots {
+		info, err := hub.ctx.GetTokenInfo(slot)
+		if err != nil {
+			log.Debug("Failed to retrieve PKCS#11 token info", "slot", slot, "err", err)
+			continue
+		}
+		// Tokens without a serial number can't be told apart, identify them by slot
+		serial := strings.TrimSpace(info.SerialNumber)
+		if serial == "" {
+			serial = fmt.Sprintf("slot%d", slot)
+		}
+		seen[serial] = struct{}{}
+
+		// Tokens might be moved between slots, so track known ones by serial number
+		if wallet, ok := hub.wallets[serial]; ok {
+			wallet.setSlot(slot)
+			continue
+		}
+		wallet := newWallet(hub, slot, serial, strings.TrimSpace(info.Label))
+		hub.wallets[serial] = wallet
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	// Remove any wallets no longer present
+	for serial, wallet := range hub.wallets {
+		if _, ok := seen[serial]; !ok {
+			wallet.Close()
+			events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+			delete(hub.wallets, serial)
+		}
+	}
+	hub.refreshed = time.Now()
+	hub.stateLock.Unlock()
+
+	for _, event := range events {
+		hub.updateFeed.Send(event)
+	}
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of PKCS#11 tokens.
+func (hub *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	// We need the mutex to reliably start/stop the update loop
+	hub.stateLock.Lock()
+	defer hub.stateLock.Unlock()
+
+	// Subscribe the caller and track the subscriber count
+	sub := hub.updateScope.Track(hub.updateFeed.Subscribe(sink))
+
+	// Subscribers require an active notification loop, start it
+	if !hub.updating {
+		hub.updating = true
+		go hub.updater()
+	}
+	return sub
+}
+
+// updater is responsible for maintaining an up-to-date list of wallets managed
+// by the PKCS#11 hub, and for firing wallet addition/removal events.
+func (hub *Hub) updater() {
+	for {
+		time.Sleep(refreshCycle)
+
+		// Run the wallet refresher
+		hub.refreshWallets()
+
+		// If all our subscribers left, stop the updater
+		hub.stateLock.Lock()
+		if hub.updateScope.Count() == 0 {
+			hub.updating = false
+			hub.stateLock.Unlock()
+			return
+		}
+		hub.stateLock.Unlock()
+	}
+}
+
+// Close closes all the wallets of the hub and finalizes the PKCS#11 module.
+func (hub *Hub) Close() error {
+	hub.stateLock.Lock()
+	defer hub.stateLock.Unlock()
+
+	if hub.closed {
+		return nil
+	}
+	hub.closed = true
+	for _, wallet := range hub.wallets {
+		wallet.Close()
+	}
+	hub.wallets = make(map[string]*Wallet)
+
+	// The module is only finalized, not unloaded, so any refresh still in flight
+	// fails gracefully instead of calling into unmapped code.
+	return hub.ctx.Finalize()
+}
@@ -0,0 +1,438 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/sdcereum/go-sdcereum"
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// ErrPINNeeded is returned if opening the token requires a PIN code. In this
+// case, the calling application should request user input to enter the PIN and
+// send it back.
+var ErrPINNeeded = errors.New("pkcs11: token PIN needed")
+
+// ErrPINIncorrect is returned if the token rejected the PIN code.
+var ErrPINIncorrect = errors.New("pkcs11: token PIN incorrect")
+
+// errNotRecoverable is returned if a signature produced by the token doesn't
+// recover to the public key of the signing key.
+var errNotRecoverable = errors.New("pkcs11: signature doesn't recover to signing key")
+
+// secp256k1OID is the DER encoding of the secp256k1 curve identifier, used as
+// CKA_EC_PARAMS of the key objects.
+var secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1halfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// key is a secp256k1 key pair discovered on the token.
+type key struct {
+	account accounts.Account    // Account derived from the public key
+	pubkey  []byte              // Uncompressed public key to verify signatures against
+	handle  pkcs11.ObjectHandle // Handle of the private key object within the session
+}
+
+// Wallet represents a PKCS#11 token holding secp256k1 keys.
+type Wallet struct {
+	hub    *Hub         // PKCS#11 hub the token was found through
+	url    accounts.URL // Textual URL uniquely identifying this token
+	serial string       // Serial number of the token
+	label  string       // Label of the token
+
+	slot    uint                 // Slot the token currently resides in
+	session pkcs11.SessionHandle // Logged in session if the wallet is open
+	open    bool                 // Whsdcer the wallet is open
+	keys    []*key               // Keys discovered when opening the wallet
+
+	// Locking a PKCS#11 wallet is done with a single mutex, as sessions must not
+	// be used concurrently and every signing operation consists of two calls.
+	lock sync.Mutex
+}
+
+// newWallet creates the wallet of a token found by the hub.
+func newWallet(hub *Hub, slot uint, serial, label string) *Wallet {
+	return &Wallet{
+		hub:    hub,
+		url:    accounts.URL{Scheme: hub.scheme, Path: serial},
+		serial: serial,
+		label:  label,
+		slot:   slot,
+	}
+}
+
+// setSlot updates the slot the token resides in.
+func (w *Wallet) setSlot(slot uint) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.slot = slot
+}
+
+// URL implements accounts.Wallet, returning the URL of the PKCS#11 token.
+func (w *Wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning a custom status message from the
+// underlying token.
+func (w *Wallet) Status() (string, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		return fmt.Sprintf("Closed, token %q", w.label), nil
+	}
+	if _, err := w.hub.ctx.GetSessionInfo(w.session); err != nil {
+		return fmt.Sprintf("Failed, token %q", w.label), err
+	}
+	return fmt.Sprintf("Online, token %q, %d keys", w.label, len(w.keys)), nil
+}
+
+// Open implements accounts.Wallet, logging into the token with the passphrase as
+// user PIN and discovering the secp256k1 keys stored on it.
+func (w *Wallet) Open(passphrase string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.open {
+		return accounts.ErrWalletAlreadyOpen
+	}
+	info, err := w.hub.ctx.GetTokenInfo(w.slot)
+	if err != nil {
+		return err
+	}
+	if info.Flags&pkcs11.CKF_LOGIN_REQUIRED != 0 && passphrase == "" &&
+		info.Flags&pkcs11.CKF_PROTECTED_AUTHENTICATION_PATH == 0 {
+		return ErrPINNeeded
+	}
+	session, err := w.hub.ctx.OpenSession(w.slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return err
+	}
+	if info.Flags&pkcs11.CKF_LOGIN_REQUIRED != 0 {
+		// Logins are shared by all sessions of the application, so another session
+		// might already have logged in
+		err := w.hub.ctx.Login(session, pkcs11.CKU_USER, passphrase)
+		switch {
+		case errors.Is(err, pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)):
+			w.hub.ctx.CloseSession(session)
+			return ErrPINIncorrect
+		case err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)):
+			w.hub.ctx.CloseSession(session)
+			return err
+		}
+	}
+	keys, err := w.findKeys(session)
+	if err != nil {
+		w.hub.ctx.CloseSession(session)
+		return err
+	}
+	w.session, w.open, w.keys = session, true, keys
+
+	// Notify anyone listening for wallet events that a new device is accessible
+	go w.hub.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// findKeys discovers the secp256k1 key pairs stored on the token. Only keys with
+// both the public and private key object present (matched by CKA_ID) are usable.
+func (w *Wallet) findKeys(session pkcs11.SessionHandle) ([]*key, error) {
+	pubkeys, err := w.findObjects(session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var keys []*key
+	for _, handle := range pubkeys {
+		attrs, err := w.hub.ctx.GetAttributeValue(session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		id, point := attrs[0].Value, attrs[1].Value
+
+		pubkey, err := parseECPoint(point)
+		if err != nil {
+			log.Warn("Skipping malformed PKCS#11 public key", "token", w.label, "id", hex.EncodeToString(id), "err", err)
+			continue
+		}
+		privkeys, err := w.findObjects(session, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(privkeys) != 1 {
+			log.Debug("Skipping PKCS#11 public key without unique private key", "token", w.label, "id", hex.EncodeToString(id), "matches", len(privkeys))
+			continue
+		}
+		keys = append(keys, &key{
+			account: accounts.Account{
+				Address: crypto.PubkeyToAddress(*pubkey),
+				URL:     accounts.URL{Scheme: w.url.Scheme, Path: w.url.Path + "/" + hex.EncodeToString(id)},
+			},
+			pubkey: crypto.FromECDSAPub(pubkey),
+			handle: privkeys[0],
+		})
+	}
+	return keys, nil
+}
+
+// findObjects returns the handles of all objects matching the template.
+func (w *Wallet) findObjects(session pkcs11.SessionHandle, template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := w.hub.ctx.FindObjectsInit(session, template); err != nil {
+		return nil, err
+	}
+	var handles []pkcs11.ObjectHandle
+	for {
+		batch, _, err := w.hub.ctx.FindObjects(session, 64)
+		if err != nil {
+			w.hub.ctx.FindObjectsFinal(session)
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		handles = append(handles, batch...)
+	}
+	return handles, w.hub.ctx.FindObjectsFinal(session)
+}
+
+// parseECPoint parses the CKA_EC_POINT attribute of a public key, which is a DER
+// encoded octet string wrapping the uncompressed point. Some modules omit the
+// wrapping, so the raw point is accepted too.
+func parseECPoint(point []byte) (*ecdsa.PublicKey, error) {
+	if len(point) != 65 || point[0] != 0x04 {
+		var raw []byte
+		if rest, err := asn1.Unmarshal(point, &raw); err != nil || len(rest) != 0 {
+			return nil, errors.New("invalid EC point encoding")
+		}
+		point = raw
+	}
+	return crypto.UnmarshalPubkey(point)
+}
+
+// Close implements accounts.Wallet, logging out of the token and closing the
+// session.
+func (w *Wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		return nil
+	}
+	w.hub.ctx.Logout(w.session)
+	err := w.hub.ctx.CloseSession(w.session)
+
+	w.session, w.open, w.keys = 0, false, nil
+	return err
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts backed by
+// the keys discovered on the token. The list is empty until the wallet is opened.
+func (w *Wallet) Accounts() []accounts.Account {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	cpy := make([]accounts.Account, len(w.keys))
+	for i, key := range w.keys {
+		cpy[i] = key.account
+	}
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whsdcer a particular account is
+// or is not backed by a key of this token.
+func (w *Wallet) Contains(account accounts.Account) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.findKey(account) != nil
+}
+
+// findKey returns the key backing the account, or nil if the account is unknown.
+func (w *Wallet) findKey(account accounts.Account) *key {
+	for _, key := range w.keys {
+		if key.account.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == key.account.URL) {
+			return key
+		}
+	}
+	return nil
+}
+
+// Derive implements accounts.Wallet, but is a noop for PKCS#11 wallets since
+// there is no notion of hierarchical account derivation for plain keys.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for PKCS#11 wallets since
+// there is no notion of hierarchical account derivation for plain keys.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain sdcereum.ChainStateReader) {
+}
+
+// signHash signs the given hash with the key backing the account on the token,
+// returning the signature in [R || S || V] format where V is 0 or 1.
+func (w *Wallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.open {
+		return nil, accounts.NewAuthNeededError("PKCS#11 token PIN")
+	}
+	key := w.findKey(account)
+	if key == nil {
+		return nil, accounts.ErrUnknownAccount
+	}
+	mech := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}
+	if err := w.hub.ctx.SignInit(w.session, mech, key.handle); err != nil {
+		return nil, err
+	}
+	sig, err := w.hub.ctx.Sign(w.session, hash)
+	if err != nil {
+		return nil, err
+	}
+	return toRecoverable(hash, sig, key.pubkey)
+}
+
+// toRecoverable converts a signature produced by the CKM_ECDSA mechanism into the
+// [R || S || V] format: S is normalized to the lower half of the curve order, as
+// sdcereum rejects high S values, and V is found by trying which recovery id
+// recovers the public key of the signer.
+func toRecoverable(hash, sig, pubkey []byte) ([]byte, error) {
+	r, s, err := parseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if r.Sign() <= 0 || r.Cmp(secp256k1N) >= 0 || s.Sign() <= 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, errors.New("pkcs11: signature values out of range")
+	}
+	if s.Cmp(secp256k1halfN) > 0 {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+	out := make([]byte, crypto.SignatureLength)
+	r.FillBytes(out[:32])
+	s.FillBytes(out[32:64])
+	for v := byte(0); v < 2; v++ {
+		out[64] = v
+		if recovered, err := crypto.Ecrecover(hash, out); err == nil && bytes.Equal(recovered, pubkey) {
+			return out, nil
+		}
+	}
+	return nil, errNotRecoverable
+}
+
+// parseSignature parses an ECDSA signature, which PKCS#11 defines as the
+// concatenation of R and S. Some modules return the DER encoding instead, so that
+// is accepted too.
+func parseSignature(sig []byte) (*big.Int, *big.Int, error) {
+	if len(sig) == 64 {
+		return new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]), nil
+	}
+	var der struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(sig, &der); err != nil || len(rest) != 0 {
+		return nil, nil, fmt.Errorf("pkcs11: invalid signature encoding (%d bytes)", len(sig))
+	}
+	return der.R, der.S, nil
+}
+
+// signHashWithPassphrase opens the wallet with the passphrase as PIN if it isn't
+// open yet, and signs the hash.
+func (w *Wallet) signHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	if err := w.Open(passphrase); err != nil && err != accounts.ErrWalletAlreadyOpen {
+		return nil, err
+	}
+	return w.signHash(account, hash)
+}
+
+// SignData implements accounts.Wallet, signing the keccak256 hash of the given
+// data with the key backing the account.
+//
+// If the wallet isn't open yet, an AuthNeededError is returned. The user may
+// retry by providing the PIN via SignDataWithPassphrase.
+func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet, opening the wallet with the
+// passphrase as PIN if needed before signing the keccak256 hash of the data.
+func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.signHashWithPassphrase(account, passphrase, crypto.Keccak256(data))
+}
+
+// SignText implements accounts.Wallet, signing the hash of the given text, prefixed
+// by the sdcereum prefix scheme, with the key backing the account.
+//
+// If the wallet isn't open yet, an AuthNeededError is returned. The user may
+// retry by providing the PIN via SignTextWithPassphrase.
+func (w *Wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, opening the wallet with the
+// passphrase as PIN if needed before signing the hash of the prefixed text.
+func (w *Wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.signHashWithPassphrase(account, passphrase, accounts.TextHash(text))
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with the key
+// backing the account.
+//
+// If the wallet isn't open yet, an AuthNeededError is returned. The user may
+// retry by providing the PIN via SignTxWithPassphrase.
+func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+	sig, err := w.signHash(account, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, opening the wallet with the
+// passphrase as PIN if needed before signing the transaction.
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if err := w.Open(passphrase); err != nil && err != accounts.ErrWalletAlreadyOpen {
+		return nil, err
+	}
+	return w.SignTx(account, tx, chainID)
+}
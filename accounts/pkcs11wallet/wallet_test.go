@@ -0,0 +1,361 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11wallet
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/crypto"
+)
+
+// Tests that signatures are converted into the recoverable format regardless of
+// their encoding and of the half of the curve order S falls in.
+func TestToRecoverable(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	pubkey := crypto.FromECDSAPub(&key.PublicKey)
+
+	for i := 0; i < 16; i++ {
+		hash := crypto.Keccak256([]byte{byte(i)})
+		want, err := crypto.Sign(hash, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, s := new(big.Int).SetBytes(want[:32]), new(big.Int).SetBytes(want[32:64])
+		highS := new(big.Int).Sub(secp256k1N, s)
+
+		der, _ := asn1.Marshal(struct{ R, S *big.Int }{r, highS})
+		raw := make([]byte, 64)
+		r.FillBytes(raw[:32])
+		highS.FillBytes(raw[32:])
+
+		for name, sig := range map[string][]byte{"raw": want[:64], "raw high-s": raw, "der high-s": der} {
+			have, err := toRecoverable(hash, sig, pubkey)
+			if err != nil {
+				t.Fatalf("%d %s: conversion failed: %v", i, name, err)
+			}
+			if !bytes.Equal(have, want) {
+				t.Fatalf("%d %s: signature mismatch: have %x, want %x", i, name, have, want)
+			}
+		}
+	}
+	// Signatures of other keys or malformed ones are rejected
+	hash := crypto.Keccak256([]byte("hello"))
+	other, _ := crypto.GenerateKey()
+	sig, _ := crypto.Sign(hash, other)
+	if _, err := toRecoverable(hash, sig[:64], pubkey); err != errNotRecoverable {
+		t.Fatalf("foreign signature: have %v, want %v", err, errNotRecoverable)
+	}
+	if _, err := toRecoverable(hash, make([]byte, 64), pubkey); err == nil {
+		t.Fatal("zero signature accepted")
+	}
+	if _, err := toRecoverable(hash, sig[:63], pubkey); err == nil {
+		t.Fatal("truncated signature accepted")
+	}
+}
+
+func TestParseECPoint(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	point := crypto.FromECDSAPub(&key.PublicKey)
+	wrapped, _ := asn1.Marshal(point)
+
+	for _, enc := range [][]byte{point, wrapped} {
+		pubkey, err := parseECPoint(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pubkey.Equal(&key.PublicKey) {
+			t.Fatal("public key mismatch")
+		}
+	}
+	if _, err := parseECPoint(append(wrapped, 0)); err == nil {
+		t.Fatal("trailing data accepted")
+	}
+	if _, err := parseECPoint(point[:33]); err == nil {
+		t.Fatal("truncated point accepted")
+	}
+}
+
+const (
+	testSOPIN   = "87654321"
+	testUserPIN = "12345678"
+	testLabel   = "sdc-test"
+)
+
+// softHSMModule returns the path of the SoftHSM module, skipping the test if it
+// isn't installed. The path can be overridden with the SOFTHSM2_MODULE variable.
+func softHSMModule(t *testing.T) string {
+	paths := []string{
+		os.Getenv("SOFTHSM2_MODULE"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/lib64/pkcs11/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Skip("SoftHSM not installed, set SOFTHSM2_MODULE to the path of libsofthsm2.so")
+	return ""
+}
+
+// newTestHub initializes a fresh SoftHSM token with the given number of secp256k1
+// keys, plus a P-256 key which must be ignored, and returns a hub on top of it.
+func newTestHub(t *testing.T, keys int) (*Hub, []common.Address) {
+	module := softHSMModule(t)
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "tokens"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(conf, []byte(fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\nlog.level = ERROR\n", filepath.Join(dir, "tokens"))), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		t.Fatalf("failed to load %s", module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	// SoftHSM always offers an uninitialized token, which moves to a new slot
+	// after initialization
+	slots, err := ctx.GetSlotList(true)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("no slots: %v", err)
+	}
+	if err := ctx.InitToken(slots[0], testSOPIN, testLabel); err != nil {
+		t.Fatal(err)
+	}
+	slot, err := findTestSlot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Login(session, pkcs11.CKU_SO, testSOPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.InitPIN(session, testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	ctx.Logout(session)
+	if err := ctx.Login(session, pkcs11.CKU_USER, testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	var addrs []common.Address
+	for i := 0; i < keys; i++ {
+		addrs = append(addrs, generateTestKey(t, ctx, session, []byte{byte(i)}, secp256k1OID))
+	}
+	// P-256, which isn't usable for sdcereum
+	generateTestKey(t, ctx, session, []byte{0xff}, []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07})
+
+	ctx.Logout(session)
+	ctx.CloseSession(session)
+	ctx.Finalize()
+	ctx.Destroy()
+
+	hub, err := NewHub(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hub.Close() })
+	return hub, addrs
+}
+
+func findTestSlot(ctx *pkcs11.Ctx) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err == nil && info.Label == testLabel && info.Flags&pkcs11.CKF_TOKEN_INITIALIZED != 0 {
+			return slot, nil
+		}
+	}
+	return 0, errors.New("initialized token not found")
+}
+
+// generateTestKey generates an EC key pair on the curve with the given DER encoded
+// identifier, returning the address of the public key.
+func generateTestKey(t *testing.T, ctx *pkcs11.Ctx, session pkcs11.SessionHandle, id []byte, curve []byte) common.Address {
+	t.Helper()
+
+	pub, _, err := ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, curve),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(curve, secp256k1OID) {
+		return common.Address{}
+	}
+	attrs, err := ctx.GetAttributeValue(session, pub, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := parseECPoint(attrs[0].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crypto.PubkeyToAddress(*pubkey)
+}
+
+// Tests that the keys of a token are discovered once the wallet is opened.
+func TestSoftHSMDiscovery(t *testing.T) {
+	hub, addrs := newTestHub(t, 2)
+
+	wallets := hub.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if wallet.URL().Scheme != Scheme {
+		t.Fatalf("wallet scheme mismatch: have %s, want %s", wallet.URL().Scheme, Scheme)
+	}
+	if len(wallet.Accounts()) != 0 {
+		t.Fatal("accounts available before opening")
+	}
+	if err := wallet.Open(""); err != ErrPINNeeded {
+		t.Fatalf("open without PIN: have %v, want %v", err, ErrPINNeeded)
+	}
+	if err := wallet.Open("00000000"); err != ErrPINIncorrect {
+		t.Fatalf("open with wrong PIN: have %v, want %v", err, ErrPINIncorrect)
+	}
+	if err := wallet.Open(testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := wallet.Open(testUserPIN); err != accounts.ErrWalletAlreadyOpen {
+		t.Fatalf("reopen: have %v, want %v", err, accounts.ErrWalletAlreadyOpen)
+	}
+	accs := wallet.Accounts()
+	if len(accs) != len(addrs) {
+		t.Fatalf("account count mismatch: have %d, want %d", len(accs), len(addrs))
+	}
+	for i, acc := range accs {
+		if acc.Address != addrs[i] {
+			t.Errorf("account %d: address mismatch: have %x, want %x", i, acc.Address, addrs[i])
+		}
+		if !wallet.Contains(accounts.Account{Address: addrs[i]}) {
+			t.Errorf("account %d: not contained", i)
+		}
+	}
+	if err := wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(wallet.Accounts()) != 0 {
+		t.Fatal("accounts available after closing")
+	}
+}
+
+// Tests that data, text and transactions signed by the token recover to the
+// address of the signing key.
+func TestSoftHSMSigning(t *testing.T) {
+	hub, addrs := newTestHub(t, 1)
+	wallet := hub.Wallets()[0]
+	account := accounts.Account{Address: addrs[0]}
+
+	if _, err := wallet.SignText(account, []byte("hello")); !isAuthNeeded(err) {
+		t.Fatalf("signing with closed wallet: have %v, want auth needed", err)
+	}
+	// Signing with passphrase opens the wallet
+	sig, err := wallet.SignTextWithPassphrase(account, testUserPIN, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSigner(t, accounts.TextHash([]byte("hello")), sig, addrs[0])
+
+	for i := 0; i < 16; i++ {
+		data := []byte{byte(i)}
+		sig, err := wallet.SignData(account, accounts.MimetypeTypedData, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSigner(t, crypto.Keccak256(data), sig, addrs[0])
+	}
+	chainID := big.NewInt(1337)
+	for _, tx := range []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &common.Address{0x01}, Value: big.NewInt(1)}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{0x01}}),
+	} {
+		signed, err := wallet.SignTx(account, tx, chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sender != addrs[0] {
+			t.Fatalf("sender mismatch: have %x, want %x", sender, addrs[0])
+		}
+	}
+	if _, err := wallet.SignText(accounts.Account{Address: common.Address{0x01}}, []byte("hello")); err != accounts.ErrUnknownAccount {
+		t.Fatalf("signing with unknown account: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+}
+
+func isAuthNeeded(err error) bool {
+	_, ok := err.(*accounts.AuthNeededError)
+	return ok
+}
+
+func checkSigner(t *testing.T, hash []byte, sig []byte, want common.Address) {
+	t.Helper()
+
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := crypto.PubkeyToAddress(*pubkey); have != want {
+		t.Fatalf("signer mismatch: have %x, want %x", have, want)
+	}
+}
@@ -49,15 +49,18 @@ type ledgerParam1 byte
 type ledgerParam2 byte
 
 const (
-	ledgerOpRetrieveAddress  ledgerOpcode = 0x02 // Returns the public key and sdcereum address for a given BIP 32 path
-	ledgerOpSignTransaction  ledgerOpcode = 0x04 // Signs an sdcereum transaction after having the user validate the parameters
-	ledgerOpGetConfiguration ledgerOpcode = 0x06 // Returns specific wallet application configuration
-	ledgerOpSignTypedMessage ledgerOpcode = 0x0c // Signs an sdcereum message following the EIP 712 specification
+	ledgerOpRetrieveAddress     ledgerOpcode = 0x02 // Returns the public key and sdcereum address for a given BIP 32 path
+	ledgerOpSignTransaction     ledgerOpcode = 0x04 // Signs an sdcereum transaction after having the user validate the parameters
+	ledgerOpGetConfiguration    ledgerOpcode = 0x06 // Returns specific wallet application configuration
+	ledgerOpSignPersonalMessage ledgerOpcode = 0x08 // Signs a message following the EIP 191 personal message specification
+	ledgerOpSignTypedMessage    ledgerOpcode = 0x0c // Signs an sdcereum message following the EIP 712 specification
 
 	ledgerP1DirectlyFetchAddress    ledgerParam1 = 0x00 // Return address directly from the wallet
 	ledgerP1InitTypedMessageData    ledgerParam1 = 0x00 // First chunk of Typed Message data
 	ledgerP1InitTransactionData     ledgerParam1 = 0x00 // First transaction data block for signing
 	ledgerP1ContTransactionData     ledgerParam1 = 0x80 // Subsequent transaction data block for signing
+	ledgerP1InitPersonalMessageData ledgerParam1 = 0x00 // First personal message data block for signing
+	ledgerP1ContPersonalMessageData ledgerParam1 = 0x80 // Subsequent personal message data block for signing
 	ledgerP2DiscardAddressChainCode ledgerParam2 = 0x00 // Do not return the chain code along with the address
 )
 
@@ -172,6 +175,24 @@ func (w *ledgerDriver) SignTx(path accounts.DerivationPath, tx *types.Transactio
 	return w.ledgerSign(path, tx, chainID)
 }
 
+// SignPersonalMessage implements usbwallet.driver, sending the message to the Ledger
+// and waiting for the user to sign or deny it.
+//
+// Note: this was introduced in the ledger 1.0.8 firmware
+func (w *ledgerDriver) SignPersonalMessage(path accounts.DerivationPath, message []byte) ([]byte, error) {
+	// If the sdcereum app doesn't run, abort
+	if w.offline() {
+		return nil, accounts.ErrWalletClosed
+	}
+	// Ensure the wallet is capable of signing personal messages
+	if w.version[0] < 1 || w.version[0] == 1 && w.version[1] == 0 && w.version[2] < 8 {
+		//lint:ignore ST1005 brand name displayed on the console
+		return nil, fmt.Errorf("Ledger version >= 1.0.8 required for personal message signing (found version v%d.%d.%d)", w.version[0], w.version[1], w.version[2])
+	}
+	// All infos gathered and metadata checks out, request signing
+	return w.ledgerSignPersonalMessage(path, message)
+}
+
 // SignTypedMessage implements usbwallet.driver, sending the message to the Ledger and
 // waiting for the user to sign or deny the transaction.
 //
@@ -182,7 +203,7 @@ func (w *ledgerDriver) SignTypedMessage(path accounts.DerivationPath, domainHash
 		return nil, accounts.ErrWalletClosed
 	}
 	// Ensure the wallet is capable of signing the given transaction
-	if w.version[0] < 1 && w.version[1] < 5 {
+	if w.version[0] < 1 || w.version[0] == 1 && w.version[1] < 5 {
 		//lint:ignore ST1005 brand name displayed on the console
 		return nil, fmt.Errorf("Ledger version >= 1.5.0 required for EIP-712 signing (found version v%d.%d.%d)", w.version[0], w.version[1], w.version[2])
 	}
@@ -439,11 +460,90 @@ func (w *ledgerDriver) ledgerSignTypedMessage(derivationPath []uint32, domainHas
 		return nil, err
 	}
 
+	return ledgerSignature(reply)
+}
+
+// ledgerSignPersonalMessage sends the message to the Ledger wallet, and waits for
+// the user to confirm or deny signing it.
+//
+// The signing protocol is defined as follows:
+//
+//	CLA | INS | P1 | P2 | Lc  | Le
+//	----+-----+----+----+-----+---
+//	 E0 | 08  | 00 | 00 | variable | variable
+//
+// Where the input for the first APDU call is:
+//
+//	Description                                      | Length
+//	-------------------------------------------------+----------
+//	Number of BIP 32 derivations to perform (max 10) | 1 byte
+//	First derivation index (big endian)              | 4 bytes
+//	...                                              | 4 bytes
+//	Last derivation index (big endian)               | 4 bytes
+//	Message length (big endian)                      | 4 bytes
+//	Message chunk                                    | arbitrary
+//
+// And the input for subsequent calls (marked with P1=0x80) is:
+//
+//	Description   | Length
+//	--------------+----------
+//	Message chunk | arbitrary
+//
+// The output data is:
+//
+//	Description | Length
+//	------------+---------
+//	signature V | 1 byte
+//	signature R | 32 bytes
+//	signature S | 32 bytes
+func (w *ledgerDriver) ledgerSignPersonalMessage(derivationPath []uint32, message []byte) ([]byte, error) {
+	// Flatten the derivation path into the Ledger request
+	path := make([]byte, 1+4*len(derivationPath))
+	path[0] = byte(len(derivationPath))
+	for i, component := range derivationPath {
+		binary.BigEndian.PutUint32(path[1+4*i:], component)
+	}
+	// Prefix the message with its length, the device adds the EIP 191 header itself
+	payload := make([]byte, len(path)+4, len(path)+4+len(message))
+	copy(payload, path)
+	binary.BigEndian.PutUint32(payload[len(path):], uint32(len(message)))
+	payload = append(payload, message...)
+
+	// Send the request and wait for the response
+	var (
+		op    = ledgerP1InitPersonalMessageData
+		reply []byte
+		err   error
+	)
+	for len(payload) > 0 {
+		// Calculate the size of the next data chunk
+		chunk := 255
+		if chunk > len(payload) {
+			chunk = len(payload)
+		}
+		// Send the chunk over, ensuring it's processed correctly
+		reply, err = w.ledgerExchange(ledgerOpSignPersonalMessage, op, 0, payload[:chunk])
+		if err != nil {
+			return nil, err
+		}
+		// Shift the payload and ensure subsequent chunks are marked as such
+		payload = payload[chunk:]
+		op = ledgerP1ContPersonalMessageData
+	}
+	return ledgerSignature(reply)
+}
+
+// ledgerSignature converts a [V || R || S] message signature reply of the Ledger
+// into the canonical [R || S || V] format with V being 0 or 1.
+func ledgerSignature(reply []byte) ([]byte, error) {
 	// Extract the sdcereum signature and do a sanity validation
 	if len(reply) != crypto.SignatureLength {
 		return nil, errors.New("reply lacks signature")
 	}
-	signature := append(reply[1:], reply[0])
+	signature := append(common.CopyBytes(reply[1:]), reply[0])
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
 	return signature, nil
 }
 
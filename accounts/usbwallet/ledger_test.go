@@ -0,0 +1,286 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// ledgerMock is a USB transport emulating the sdcereum app of a Ledger, signing
+// all messages with a single key regardless of the derivation path.
+type ledgerMock struct {
+	key *ecdsa.PrivateKey
+
+	apdu []byte // APDU being reassembled from the written chunks
+	size int    // Total size of the APDU being reassembled
+
+	message []byte // Personal message being streamed
+	left    int    // Personal message bytes still expected
+
+	apdus [][]byte     // APDUs received from the driver
+	paths [][]byte     // Derivation paths the signatures were requested for
+	reply bytes.Buffer // Reply chunks to be read by the driver
+}
+
+func newLedgerMock(t *testing.T) *ledgerMock {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return &ledgerMock{key: key}
+}
+
+func (m *ledgerMock) Close() error { return nil }
+
+func (m *ledgerMock) Read(b []byte) (int, error) { return m.reply.Read(b) }
+
+func (m *ledgerMock) Write(chunk []byte) (int, error) {
+	if len(chunk) < 7 || chunk[0] != 0x01 || chunk[1] != 0x01 || chunk[2] != 0x05 {
+		return 0, errors.New("invalid chunk header")
+	}
+	if binary.BigEndian.Uint16(chunk[3:5]) == 0 {
+		m.size, m.apdu = int(binary.BigEndian.Uint16(chunk[5:7])), append([]byte{}, chunk[7:]...)
+	} else {
+		m.apdu = append(m.apdu, chunk[5:]...)
+	}
+	if len(m.apdu) >= m.size {
+		apdu := m.apdu[:m.size]
+		m.apdus = append(m.apdus, apdu)
+
+		reply, err := m.handle(apdu[1], ledgerParam1(apdu[2]), apdu[5:5+int(apdu[4])])
+		if err != nil {
+			return 0, err
+		}
+		m.respond(reply)
+	}
+	return len(chunk), nil
+}
+
+// handle processes a single APDU command, returning the reply data.
+func (m *ledgerMock) handle(opcode byte, p1 ledgerParam1, data []byte) ([]byte, error) {
+	switch ledgerOpcode(opcode) {
+	case ledgerOpSignPersonalMessage:
+		if p1 == ledgerP1InitPersonalMessageData {
+			path := data[:1+4*int(data[0])]
+			rest := data[len(path):]
+			m.paths = append(m.paths, path)
+			m.left = int(binary.BigEndian.Uint32(rest))
+			m.message, data = nil, rest[4:]
+		}
+		m.message = append(m.message, data...)
+		if m.left -= len(data); m.left > 0 {
+			return nil, nil
+		}
+		return m.sign(firmwareTextHash(m.message))
+
+	case ledgerOpSignTypedMessage:
+		path := data[:1+4*int(data[0])]
+		m.paths = append(m.paths, path)
+		rest := data[len(path):]
+		return m.sign(crypto.Keccak256([]byte{0x19, 0x01}, rest[:32], rest[32:64]))
+	}
+	return nil, errors.New("unsupported opcode")
+}
+
+// sign signs the hash and returns it in the [V || R || S] format of the Ledger.
+func (m *ledgerMock) sign(hash []byte) ([]byte, error) {
+	sig, err := crypto.Sign(hash, m.key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{sig[64] + 27}, sig[:64]...), nil
+}
+
+// respond chunks up a reply with a success status word for the driver to read.
+func (m *ledgerMock) respond(data []byte) {
+	payload := make([]byte, 2, 4+len(data))
+	binary.BigEndian.PutUint16(payload, uint16(len(data)+2))
+	payload = append(append(payload, data...), 0x90, 0x00)
+
+	for i := 0; len(payload) > 0; i++ {
+		chunk := make([]byte, 64)
+		copy(chunk, []byte{0x01, 0x01, 0x05})
+		binary.BigEndian.PutUint16(chunk[3:], uint16(i))
+		payload = payload[copy(chunk[5:], payload):]
+		m.reply.Write(chunk)
+	}
+}
+
+func newTestLedger(mock *ledgerMock, version [3]byte) *ledgerDriver {
+	return &ledgerDriver{device: mock, version: version, log: log.New()}
+}
+
+// verifySignature checks that the signature is in the canonical format and was
+// made by the given key.
+func verifySignature(t *testing.T, hash []byte, sig []byte, key *ecdsa.PrivateKey) {
+	t.Helper()
+
+	if len(sig) != crypto.SignatureLength || sig[crypto.RecoveryIDOffset] > 1 {
+		t.Fatalf("invalid signature: %x", sig)
+	}
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer, want := crypto.PubkeyToAddress(*pubkey), crypto.PubkeyToAddress(key.PublicKey); signer != want {
+		t.Errorf("signer mismatch: have %s, want %s", signer.Hex(), want.Hex())
+	}
+}
+
+func TestLedgerSignPersonalMessage(t *testing.T) {
+	path := accounts.DefaultBaseDerivationPath
+	tests := []struct {
+		message []byte
+		apdus   int
+	}{
+		{[]byte("hello"), 1},
+		{bytes.Repeat([]byte{0xaa}, 230), 1}, // Fills up the first chunk exactly
+		{bytes.Repeat([]byte{0xaa}, 231), 2},
+		{[]byte(strings.Repeat("long message ", 100)), 6},
+	}
+	for i, tt := range tests {
+		mock := newLedgerMock(t)
+		sig, err := newTestLedger(mock, [3]byte{1, 9, 17}).SignPersonalMessage(path, tt.message)
+		if err != nil {
+			t.Fatalf("test %d: failed to sign message: %v", i, err)
+		}
+		verifySignature(t, firmwareTextHash(tt.message), sig, mock.key)
+
+		if len(mock.apdus) != tt.apdus {
+			t.Errorf("test %d: APDU count mismatch: have %d, want %d", i, len(mock.apdus), tt.apdus)
+		}
+		for j, apdu := range mock.apdus {
+			want := ledgerP1ContPersonalMessageData
+			if j == 0 {
+				want = ledgerP1InitPersonalMessageData
+			}
+			if apdu[1] != byte(ledgerOpSignPersonalMessage) || ledgerParam1(apdu[2]) != want {
+				t.Errorf("test %d, APDU %d: header mismatch: have %x, want %x%x", i, j, apdu[1:3], byte(ledgerOpSignPersonalMessage), byte(want))
+			}
+		}
+		if want := []byte{5, 0x80, 0, 0, 44, 0x80, 0, 0, 60, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}; !bytes.Equal(mock.paths[0], want) {
+			t.Errorf("test %d: path mismatch: have %x, want %x", i, mock.paths[0], want)
+		}
+	}
+}
+
+func TestLedgerSignTypedMessage(t *testing.T) {
+	var (
+		domainHash  = crypto.Keccak256([]byte("domain"))
+		messageHash = crypto.Keccak256([]byte("message"))
+	)
+	mock := newLedgerMock(t)
+	sig, err := newTestLedger(mock, [3]byte{1, 5, 0}).SignTypedMessage(accounts.DefaultBaseDerivationPath, domainHash, messageHash)
+	if err != nil {
+		t.Fatalf("failed to sign typed message: %v", err)
+	}
+	verifySignature(t, crypto.Keccak256([]byte{0x19, 0x01}, domainHash, messageHash), sig, mock.key)
+}
+
+// Tests that signing requests are rejected without reaching out to the device if
+// the sdcereum app is too old to support them.
+func TestLedgerSignVersions(t *testing.T) {
+	tests := []struct {
+		version  [3]byte
+		personal bool
+		typed    bool
+	}{
+		{[3]byte{0, 0, 0}, false, false}, // App offline
+		{[3]byte{1, 0, 7}, false, false},
+		{[3]byte{1, 0, 8}, true, false},
+		{[3]byte{1, 4, 9}, true, false},
+		{[3]byte{1, 5, 0}, true, true},
+		{[3]byte{2, 0, 0}, true, true},
+	}
+	for _, tt := range tests {
+		mock := newLedgerMock(t)
+		driver := newTestLedger(mock, tt.version)
+
+		if _, err := driver.SignPersonalMessage(accounts.DefaultBaseDerivationPath, []byte("hello")); (err == nil) != tt.personal {
+			t.Errorf("v%d.%d.%d: personal message signing error mismatch: have %v, want success %v", tt.version[0], tt.version[1], tt.version[2], err, tt.personal)
+		}
+		if _, err := driver.SignTypedMessage(accounts.DefaultBaseDerivationPath, make([]byte, 32), make([]byte, 32)); (err == nil) != tt.typed {
+			t.Errorf("v%d.%d.%d: typed message signing error mismatch: have %v, want success %v", tt.version[0], tt.version[1], tt.version[2], err, tt.typed)
+		}
+	}
+}
+
+// Tests that the wallet routes the data signing requests of the signer to the
+// matching device operations.
+func TestWalletSignData(t *testing.T) {
+	mock := newLedgerMock(t)
+	account := accounts.Account{Address: crypto.PubkeyToAddress(mock.key.PublicKey)}
+
+	w := &wallet{
+		hub:       new(Hub),
+		driver:    newTestLedger(mock, [3]byte{1, 9, 17}),
+		device:    mock,
+		paths:     map[common.Address]accounts.DerivationPath{account.Address: accounts.DefaultBaseDerivationPath},
+		commsLock: make(chan struct{}, 1),
+	}
+	w.commsLock <- struct{}{}
+
+	// Text with the prefix of the firmware is signed as a personal message
+	for _, text := range []string{"", "hello", strings.Repeat("0123456789", 11)} {
+		prefixed := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(text), text)
+		sig, err := w.SignData(account, accounts.MimetypeTextPlain, []byte(prefixed))
+		if err != nil {
+			t.Fatalf("text %q: failed to sign: %v", text, err)
+		}
+		verifySignature(t, crypto.Keccak256([]byte(prefixed)), sig, mock.key)
+		if have := string(mock.message); have != text {
+			t.Errorf("text %q: device message mismatch: have %q", text, have)
+		}
+	}
+	// Typed data is signed from its hashes
+	typed := append([]byte{0x19, 0x01}, crypto.Keccak256([]byte("domain"))...)
+	typed = append(typed, crypto.Keccak256([]byte("message"))...)
+	sig, err := w.SignData(account, accounts.MimetypeTypedData, typed)
+	if err != nil {
+		t.Fatalf("failed to sign typed data: %v", err)
+	}
+	verifySignature(t, crypto.Keccak256(typed), sig, mock.key)
+
+	// Arbitrary data is refused, as is text with a prefix the device can't sign
+	if _, err := w.SignData(account, accounts.MimetypeTextPlain, []byte("\x19unprefixed")); err != accounts.ErrNotSupported {
+		t.Errorf("unprefixed text: error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	_, foreign := accounts.TextAndHash([]byte("hello"))
+	if _, err := w.SignData(account, accounts.MimetypeTextPlain, []byte(foreign)); err != accounts.ErrNotSupported {
+		t.Errorf("text with foreign prefix: error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	if _, err := w.SignData(account, accounts.MimetypeClique, []byte("header")); err != accounts.ErrNotSupported {
+		t.Errorf("clique header: error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	// Signatures not made by the requested account are rejected
+	other := accounts.Account{Address: common.HexToAddress("0xdeadbeef")}
+	w.paths[other.Address] = accounts.DefaultBaseDerivationPath
+	if _, err := w.SignText(other, []byte("hello")); err == nil || !strings.Contains(err.Error(), "signer mismatch") {
+		t.Errorf("foreign signature: error mismatch: have %v, want signer mismatch", err)
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/common"
 	"github.com/sdcereum/go-sdcereum/common/hexutil"
 	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/crypto"
 	"github.com/sdcereum/go-sdcereum/log"
 	"github.com/golang/protobuf/proto"
 )
@@ -185,8 +186,38 @@ func (w *trezorDriver) SignTx(path accounts.DerivationPath, tx *types.Transactio
 	return w.trezorSign(path, tx, chainID)
 }
 
+// SignPersonalMessage implements usbwallet.driver, sending the message to the
+// Trezor and waiting for the user to confirm or deny signing it.
+func (w *trezorDriver) SignPersonalMessage(path accounts.DerivationPath, message []byte) ([]byte, error) {
+	if w.device == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	response := new(trezor.sdcereumMessageSignature)
+	if _, err := w.trezorExchange(&trezor.sdcereumSignMessage{AddressN: path, Message: message}, response); err != nil {
+		return nil, err
+	}
+	return trezorSignature(response.GetSignature())
+}
+
+// SignTypedMessage implements usbwallet.driver, sending the EIP-712 hashes to the
+// Trezor and waiting for the user to confirm or deny signing them.
+//
+// Note: blind signing of typed data hashes was introduced in the Trezor One 1.10.5
+// firmware, older devices will reply with an unknown message failure.
 func (w *trezorDriver) SignTypedMessage(path accounts.DerivationPath, domainHash []byte, messageHash []byte) ([]byte, error) {
-	return nil, accounts.ErrNotSupported
+	if w.device == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	request := &trezor.sdcereumSignTypedHash{
+		AddressN:            path,
+		DomainSeparatorHash: domainHash,
+		MessageHash:         messageHash,
+	}
+	response := new(trezor.sdcereumTypedDataSignature)
+	if _, err := w.trezorExchange(request, response); err != nil {
+		return nil, err
+	}
+	return trezorSignature(response.GetSignature())
 }
 
 // trezorDerive sends a derivation request to the Trezor device and returns the
@@ -276,6 +307,19 @@ func (w *trezorDriver) trezorSign(derivationPath []uint32, tx *types.Transaction
 	return sender, signed, nil
 }
 
+// trezorSignature converts a [R || S || V] message signature reply of the Trezor,
+// with V being 27 or 28, into the canonical format with V being 0 or 1.
+func trezorSignature(reply []byte) ([]byte, error) {
+	if len(reply) != crypto.SignatureLength {
+		return nil, errors.New("reply lacks signature")
+	}
+	signature := common.CopyBytes(reply)
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+	return signature, nil
+}
+
 // trezorExchange performs a data exchange with the Trezor wallet, sending it a
 // message and retrieving the response. If multiple responses are possible, the
 // msdcod will also return the index of the destination object used.
@@ -640,6 +640,116 @@ func (m *sdcereumVerifyMessage) GetAddressHex() string {
 	return ""
 }
 
+// *
+// Request: Ask device to sign the hashes of EIP-712 typed data
+// @start
+// @next sdcereumTypedDataSignature
+// @next Failure
+type sdcereumSignTypedHash struct {
+	AddressN             []uint32 `protobuf:"varint,1,rep,name=address_n,json=addressN" json:"address_n,omitempty"`
+	DomainSeparatorHash  []byte   `protobuf:"bytes,2,opt,name=domain_separator_hash,json=domainSeparatorHash" json:"domain_separator_hash,omitempty"`
+	MessageHash          []byte   `protobuf:"bytes,3,opt,name=message_hash,json=messageHash" json:"message_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *sdcereumSignTypedHash) Reset()         { *m = sdcereumSignTypedHash{} }
+func (m *sdcereumSignTypedHash) String() string { return proto.CompactTextString(m) }
+func (*sdcereumSignTypedHash) ProtoMessage()    {}
+func (*sdcereumSignTypedHash) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cb33f46ba915f15c, []int{10}
+}
+
+func (m *sdcereumSignTypedHash) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_sdcereumSignTypedHash.Unmarshal(m, b)
+}
+func (m *sdcereumSignTypedHash) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_sdcereumSignTypedHash.Marshal(b, m, deterministic)
+}
+func (m *sdcereumSignTypedHash) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_sdcereumSignTypedHash.Merge(m, src)
+}
+func (m *sdcereumSignTypedHash) XXX_Size() int {
+	return xxx_messageInfo_sdcereumSignTypedHash.Size(m)
+}
+func (m *sdcereumSignTypedHash) XXX_DiscardUnknown() {
+	xxx_messageInfo_sdcereumSignTypedHash.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_sdcereumSignTypedHash proto.InternalMessageInfo
+
+func (m *sdcereumSignTypedHash) GetAddressN() []uint32 {
+	if m != nil {
+		return m.AddressN
+	}
+	return nil
+}
+
+func (m *sdcereumSignTypedHash) GetDomainSeparatorHash() []byte {
+	if m != nil {
+		return m.DomainSeparatorHash
+	}
+	return nil
+}
+
+func (m *sdcereumSignTypedHash) GetMessageHash() []byte {
+	if m != nil {
+		return m.MessageHash
+	}
+	return nil
+}
+
+// *
+// Response: Signed typed data
+// @end
+type sdcereumTypedDataSignature struct {
+	Signature            []byte   `protobuf:"bytes,1,opt,name=signature" json:"signature,omitempty"`
+	Address              *string  `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *sdcereumTypedDataSignature) Reset()         { *m = sdcereumTypedDataSignature{} }
+func (m *sdcereumTypedDataSignature) String() string { return proto.CompactTextString(m) }
+func (*sdcereumTypedDataSignature) ProtoMessage()    {}
+func (*sdcereumTypedDataSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cb33f46ba915f15c, []int{11}
+}
+
+func (m *sdcereumTypedDataSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_sdcereumTypedDataSignature.Unmarshal(m, b)
+}
+func (m *sdcereumTypedDataSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_sdcereumTypedDataSignature.Marshal(b, m, deterministic)
+}
+func (m *sdcereumTypedDataSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_sdcereumTypedDataSignature.Merge(m, src)
+}
+func (m *sdcereumTypedDataSignature) XXX_Size() int {
+	return xxx_messageInfo_sdcereumTypedDataSignature.Size(m)
+}
+func (m *sdcereumTypedDataSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_sdcereumTypedDataSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_sdcereumTypedDataSignature proto.InternalMessageInfo
+
+func (m *sdcereumTypedDataSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *sdcereumTypedDataSignature) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*sdcereumGetPublicKey)(nil), "hw.trezor.messages.sdcereum.sdcereumGetPublicKey")
 	proto.RegisterType((*sdcereumPublicKey)(nil), "hw.trezor.messages.sdcereum.sdcereumPublicKey")
@@ -651,48 +761,54 @@ func init() {
 	proto.RegisterType((*sdcereumSignMessage)(nil), "hw.trezor.messages.sdcereum.sdcereumSignMessage")
 	proto.RegisterType((*sdcereumMessageSignature)(nil), "hw.trezor.messages.sdcereum.sdcereumMessageSignature")
 	proto.RegisterType((*sdcereumVerifyMessage)(nil), "hw.trezor.messages.sdcereum.sdcereumVerifyMessage")
+	proto.RegisterType((*sdcereumSignTypedHash)(nil), "hw.trezor.messages.sdcereum.sdcereumSignTypedHash")
+	proto.RegisterType((*sdcereumTypedDataSignature)(nil), "hw.trezor.messages.sdcereum.sdcereumTypedDataSignature")
 }
 
 func init() { proto.RegisterFile("messages-sdcereum.proto", fileDescriptor_cb33f46ba915f15c) }
 
 var fileDescriptor_cb33f46ba915f15c = []byte{
-	// 593 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x4d, 0x6f, 0xd3, 0x40,
-	0x10, 0x95, 0x9b, 0xb4, 0x49, 0x26, 0x0d, 0x1f, 0xa6, 0x55, 0x17, 0x0a, 0x34, 0x18, 0x21, 0xe5,
-	0x00, 0x3e, 0x70, 0x43, 0xe2, 0xd2, 0x52, 0x44, 0x2b, 0x4a, 0x55, 0xdc, 0xa8, 0x57, 0x6b, 0x63,
-	0x6f, 0xe3, 0x55, 0x9d, 0xdd, 0xe0, 0x5d, 0xb7, 0x0e, 0x7f, 0x82, 0x23, 0xff, 0x87, 0x5f, 0x86,
-	0xf6, 0x2b, 0x71, 0x52, 0x54, 0x0e, 0xbd, 0x65, 0xde, 0xbc, 0x7d, 0xf3, 0x66, 0xf4, 0x62, 0xd8,
-	0x99, 0x10, 0x21, 0xf0, 0x98, 0x88, 0x77, 0x44, 0x66, 0xa4, 0x20, 0xe5, 0x24, 0x9c, 0x16, 0x5c,
-	0x72, 0x7f, 0x37, 0xbb, 0x09, 0x65, 0x41, 0x7e, 0xf2, 0x22, 0x74, 0x94, 0xd0, 0x51, 0x9e, 0x6d,
-	0xcf, 0x5f, 0x25, 0x7c, 0x32, 0xe1, 0xcc, 0xbc, 0x09, 0x2e, 0x60, 0xeb, 0xb3, 0xa5, 0x7c, 0x21,
-	0xf2, 0xac, 0x1c, 0xe5, 0x34, 0xf9, 0x4a, 0x66, 0xfe, 0x2e, 0x74, 0x70, 0x9a, 0x16, 0x44, 0x88,
-	0x98, 0x21, 0xaf, 0xdf, 0x18, 0xf4, 0xa2, 0xb6, 0x05, 0x4e, 0xfd, 0x57, 0xb0, 0x29, 0x32, 0x7e,
-	0x13, 0xa7, 0x54, 0x4c, 0x73, 0x3c, 0x43, 0x6b, 0x7d, 0x6f, 0xd0, 0x8e, 0xba, 0x0a, 0x3b, 0x34,
-	0x50, 0x30, 0x82, 0xc7, 0x4e, 0x77, 0x21, 0xfa, 0x01, 0x9a, 0x8c, 0xa7, 0x04, 0x79, 0x7d, 0x6f,
-	0xd0, 0x7d, 0xff, 0x26, 0xfc, 0x87, 0x5f, 0x6b, 0xee, 0xe8, 0xf0, 0x94, 0xa7, 0x64, 0x38, 0x9b,
-	0x92, 0x48, 0x3f, 0xf1, 0x7d, 0x68, 0x56, 0xd3, 0x72, 0xa4, 0x47, 0x75, 0x22, 0xfd, 0x3b, 0x18,
-	0x82, 0x5f, 0xf3, 0xbe, 0x6f, 0xdc, 0xdd, 0xdb, 0xf9, 0x77, 0x78, 0xe8, 0x54, 0x9d, 0xe4, 0x4b,
-	0x00, 0xab, 0x70, 0x40, 0x99, 0x76, 0xbf, 0x19, 0xd5, 0x90, 0x5a, 0xff, 0x88, 0x54, 0xd6, 0x62,
-	0x0d, 0x09, 0xfe, 0xac, 0xc1, 0x03, 0xa7, 0x79, 0x4e, 0xc7, 0x6c, 0x58, 0xdd, 0xed, 0x72, 0x0b,
-	0xd6, 0x19, 0x67, 0x09, 0xd1, 0x52, 0x9b, 0x91, 0x29, 0xd4, 0x93, 0x31, 0x16, 0xf1, 0xb4, 0xa0,
-	0x09, 0x41, 0x0d, 0xdd, 0x69, 0x8f, 0xb1, 0x38, 0x53, 0xb5, 0x6b, 0xe6, 0x74, 0x42, 0x25, 0x6a,
-	0xce, 0x9b, 0x27, 0xaa, 0x56, 0x7a, 0x92, 0x2b, 0xeb, 0xeb, 0x46, 0x4f, 0x17, 0x06, 0x55, 0x86,
-	0xbb, 0xda, 0xb0, 0x29, 0x14, 0x7a, 0x8d, 0xf3, 0x92, 0xa0, 0x0d, 0xc3, 0xd5, 0x85, 0xff, 0x16,
-	0xfc, 0x14, 0x4b, 0x1c, 0x53, 0x46, 0x25, 0xc5, 0x79, 0x9c, 0x64, 0x25, 0xbb, 0x42, 0x2d, 0x4d,
-	0x79, 0xa4, 0x3a, 0xc7, 0xa6, 0xf1, 0x49, 0xe1, 0xfe, 0x1e, 0x74, 0x35, 0x3b, 0x27, 0x6c, 0x2c,
-	0x33, 0xd4, 0xee, 0x7b, 0x83, 0x5e, 0x04, 0x0a, 0x3a, 0xd1, 0x88, 0xff, 0x14, 0xda, 0x49, 0x86,
-	0x29, 0x8b, 0x69, 0x8a, 0x3a, 0xba, 0xdb, 0xd2, 0xf5, 0x71, 0xea, 0xef, 0x40, 0x4b, 0x56, 0xb1,
-	0x9c, 0x4d, 0x09, 0x02, 0xdd, 0xd9, 0x90, 0x95, 0xca, 0x41, 0xf0, 0xdb, 0x5b, 0x44, 0x6a, 0x58,
-	0x45, 0xe4, 0x47, 0x49, 0x84, 0x5c, 0x1d, 0xe5, 0xdd, 0x1a, 0xb5, 0x07, 0x5d, 0x41, 0xc7, 0x0c,
-	0xcb, 0xb2, 0x20, 0xf1, 0xb5, 0xbe, 0x68, 0x2f, 0x82, 0x39, 0x74, 0xb1, 0x4c, 0x28, 0xec, 0x61,
-	0x17, 0x84, 0x68, 0x99, 0x20, 0xec, 0x71, 0x17, 0x84, 0xf3, 0x20, 0x84, 0xde, 0xc2, 0xd8, 0x7e,
-	0x72, 0xe5, 0xbf, 0x00, 0xed, 0xc0, 0x5e, 0xc9, 0xe4, 0xa5, 0xa3, 0x10, 0x7d, 0x9e, 0xe0, 0x04,
-	0x9e, 0xd4, 0xd3, 0xf0, 0xcd, 0x64, 0xff, 0xee, 0x48, 0x20, 0x68, 0xd9, 0xff, 0x88, 0x0d, 0x85,
-	0x2b, 0x83, 0x0a, 0x90, 0x53, 0xb3, 0x4a, 0xe7, 0xce, 0xda, 0x7f, 0x83, 0xfb, 0x1c, 0x3a, 0xf3,
-	0x3d, 0xac, 0xee, 0x02, 0x58, 0x89, 0x75, 0xe3, 0x56, 0xac, 0x7f, 0x79, 0xb0, 0xed, 0x46, 0x5f,
-	0x90, 0x82, 0x5e, 0xce, 0xdc, 0x2a, 0xf7, 0x9b, 0x5b, 0xdb, 0xb5, 0xb1, 0xb4, 0xeb, 0x8a, 0xa3,
-	0xe6, 0xaa, 0xa3, 0x83, 0x8f, 0xf0, 0x3a, 0xe1, 0x93, 0x50, 0x60, 0xc9, 0x45, 0x46, 0x73, 0x3c,
-	0x12, 0xee, 0x03, 0x93, 0xd3, 0x91, 0xf9, 0xe2, 0x8d, 0xca, 0xcb, 0x83, 0xed, 0xa1, 0x06, 0xad,
-	0x5b, 0xb7, 0xc2, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x8a, 0xce, 0x81, 0xc8, 0x59, 0x05, 0x00,
-	0x00,
+	// 668 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcd, 0x4e, 0xdb, 0x4c,
+	0x14, 0x95, 0x49, 0x20, 0xc9, 0x4d, 0xf2, 0xfd, 0x18, 0x22, 0x5c, 0x68, 0x4b, 0x70, 0x55, 0x29,
+	0x8b, 0xd6, 0x0b, 0x76, 0x95, 0xba, 0x81, 0x52, 0x15, 0x54, 0x8a, 0xa8, 0x13, 0xb1, 0xb5, 0x26,
+	0xf6, 0x10, 0x8f, 0xb0, 0x3d, 0xae, 0x67, 0x0c, 0x4e, 0x5f, 0xa1, 0x8b, 0x2e, 0xfb, 0x3e, 0x7d,
+	0xb2, 0x6a, 0xfe, 0x12, 0x07, 0x10, 0x5d, 0xb0, 0xcb, 0x3d, 0xf7, 0xcc, 0xb9, 0x67, 0x6e, 0x8e,
+	0x07, 0xb6, 0x53, 0xcc, 0x18, 0x9a, 0x61, 0xf6, 0x16, 0xf3, 0x18, 0x17, 0xb8, 0x4c, 0xbd, 0xbc,
+	0xa0, 0x9c, 0xda, 0xbb, 0xf1, 0xad, 0xc7, 0x0b, 0xfc, 0x9d, 0x16, 0x9e, 0xa1, 0x78, 0x86, 0xb2,
+	0x33, 0x58, 0x9c, 0x0a, 0x69, 0x9a, 0xd2, 0x4c, 0x9d, 0x71, 0x2f, 0x61, 0xeb, 0xa3, 0xa6, 0x7c,
+	0xc2, 0xfc, 0xa2, 0x9c, 0x26, 0x24, 0xfc, 0x8c, 0xe7, 0xf6, 0x2e, 0x74, 0x50, 0x14, 0x15, 0x98,
+	0xb1, 0x20, 0x73, 0xac, 0x61, 0x63, 0xd4, 0xf7, 0xdb, 0x1a, 0x38, 0xb7, 0xf7, 0xa1, 0xc7, 0x62,
+	0x7a, 0x1b, 0x44, 0x84, 0xe5, 0x09, 0x9a, 0x3b, 0x6b, 0x43, 0x6b, 0xd4, 0xf6, 0xbb, 0x02, 0x3b,
+	0x56, 0x90, 0x3b, 0x85, 0xff, 0x8d, 0xee, 0x52, 0xf4, 0x1d, 0x34, 0x33, 0x1a, 0x61, 0xc7, 0x1a,
+	0x5a, 0xa3, 0xee, 0xc1, 0x6b, 0xef, 0x01, 0xbf, 0xda, 0xdc, 0xc9, 0xf1, 0x39, 0x8d, 0xf0, 0x64,
+	0x9e, 0x63, 0x5f, 0x1e, 0xb1, 0x6d, 0x68, 0x56, 0x79, 0x39, 0x95, 0xa3, 0x3a, 0xbe, 0xfc, 0xed,
+	0x4e, 0xc0, 0xae, 0x79, 0x3f, 0x54, 0xee, 0x9e, 0xec, 0xfc, 0x2b, 0xfc, 0x6b, 0x54, 0x8d, 0xe4,
+	0x4b, 0x00, 0xad, 0x70, 0x44, 0x32, 0xe9, 0xbe, 0xe7, 0xd7, 0x90, 0x5a, 0xff, 0x04, 0x57, 0xda,
+	0x62, 0x0d, 0x71, 0x7f, 0xaf, 0xc1, 0x3f, 0x46, 0x73, 0x4c, 0x66, 0xd9, 0xa4, 0x7a, 0xdc, 0xe5,
+	0x16, 0xac, 0x67, 0x34, 0x0b, 0xb1, 0x94, 0xea, 0xf9, 0xaa, 0x10, 0x47, 0x66, 0x88, 0x05, 0x79,
+	0x41, 0x42, 0xec, 0x34, 0x64, 0xa7, 0x3d, 0x43, 0xec, 0xa2, 0x20, 0xcb, 0x66, 0x42, 0x52, 0xc2,
+	0x9d, 0xe6, 0xa2, 0x79, 0x26, 0x6a, 0xa1, 0xc7, 0xa9, 0xb0, 0xbe, 0xae, 0xf4, 0x64, 0xa1, 0x50,
+	0x61, 0xb8, 0x2b, 0x0d, 0xab, 0x42, 0xa0, 0x37, 0x28, 0x29, 0xb1, 0xb3, 0xa1, 0xb8, 0xb2, 0xb0,
+	0xdf, 0x80, 0x1d, 0x21, 0x8e, 0x02, 0x92, 0x11, 0x4e, 0x50, 0x12, 0x84, 0x71, 0x99, 0x5d, 0x3b,
+	0x2d, 0x49, 0xf9, 0x4f, 0x74, 0x4e, 0x55, 0xe3, 0x83, 0xc0, 0xed, 0x3d, 0xe8, 0x4a, 0x76, 0x82,
+	0xb3, 0x19, 0x8f, 0x9d, 0xf6, 0xd0, 0x1a, 0xf5, 0x7d, 0x10, 0xd0, 0x99, 0x44, 0xec, 0x67, 0xd0,
+	0x0e, 0x63, 0x44, 0xb2, 0x80, 0x44, 0x4e, 0x47, 0x76, 0x5b, 0xb2, 0x3e, 0x8d, 0xec, 0x6d, 0x68,
+	0xf1, 0x2a, 0xe0, 0xf3, 0x1c, 0x3b, 0x20, 0x3b, 0x1b, 0xbc, 0x12, 0x39, 0x70, 0x7f, 0x59, 0xcb,
+	0x48, 0x4d, 0x2a, 0x1f, 0x7f, 0x2b, 0x31, 0xe3, 0x77, 0x47, 0x59, 0xf7, 0x46, 0xed, 0x41, 0x97,
+	0x91, 0x59, 0x86, 0x78, 0x59, 0xe0, 0xe0, 0x46, 0x6e, 0xb4, 0xef, 0xc3, 0x02, 0xba, 0x5c, 0x25,
+	0x14, 0x7a, 0xb1, 0x4b, 0x82, 0xbf, 0x4a, 0x60, 0x4e, 0xf3, 0x0e, 0x61, 0xec, 0x7a, 0xd0, 0x5f,
+	0x1a, 0x3b, 0x0c, 0xaf, 0xed, 0x17, 0x20, 0x1d, 0xe8, 0x2d, 0xa9, 0xbc, 0x74, 0x04, 0x22, 0xd7,
+	0xe3, 0x9e, 0xc1, 0x66, 0x3d, 0x0d, 0x5f, 0x54, 0xf6, 0x1f, 0x8f, 0x84, 0x03, 0x2d, 0xfd, 0x8d,
+	0xe8, 0x50, 0x98, 0xd2, 0xad, 0xc0, 0x31, 0x6a, 0x5a, 0x69, 0x6c, 0xac, 0xfd, 0x35, 0xb8, 0xcf,
+	0xa1, 0xb3, 0xb8, 0x87, 0xd6, 0xed, 0xb0, 0x07, 0x4e, 0x8b, 0x94, 0x34, 0xee, 0xc5, 0xfa, 0xa7,
+	0x05, 0x03, 0x33, 0xfa, 0x12, 0x17, 0xe4, 0x6a, 0x6e, 0xae, 0xf2, 0xb4, 0xb9, 0xb5, 0xbb, 0x36,
+	0x56, 0xee, 0x7a, 0xc7, 0x51, 0xf3, 0x9e, 0xa3, 0x1f, 0x35, 0x47, 0xf2, 0x43, 0x9b, 0xe7, 0x38,
+	0x3a, 0x41, 0x2c, 0x7e, 0x7c, 0xb9, 0x07, 0x30, 0x88, 0x68, 0x2a, 0xf2, 0xc8, 0x70, 0x8e, 0x0a,
+	0xc4, 0x69, 0x11, 0xc4, 0x88, 0xc5, 0xda, 0xda, 0xa6, 0x6a, 0x8e, 0x4d, 0x4f, 0x0a, 0xee, 0x43,
+	0x4f, 0xbb, 0x52, 0x54, 0xe5, 0xb4, 0xab, 0x31, 0x41, 0x71, 0x27, 0xb0, 0xb3, 0xc8, 0x85, 0x30,
+	0x72, 0x8c, 0x38, 0x5a, 0xfe, 0x37, 0x2b, 0x3b, 0xb0, 0x1e, 0xd8, 0x81, 0xb6, 0xa7, 0xdf, 0x13,
+	0x53, 0x1e, 0xbd, 0x87, 0x57, 0x21, 0x4d, 0x3d, 0x86, 0x38, 0x65, 0x31, 0x49, 0xd0, 0x94, 0x99,
+	0x47, 0x34, 0x21, 0x53, 0xf5, 0xaa, 0x4f, 0xcb, 0xab, 0xa3, 0xc1, 0x44, 0x82, 0xfa, 0x1f, 0x31,
+	0x3e, 0xfe, 0x0c, 0x00, 0x3e, 0x2d, 0xec, 0x70, 0x3d, 0x06, 0x00, 0x00,
 }
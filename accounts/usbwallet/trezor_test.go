@@ -0,0 +1,218 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/accounts/usbwallet/trezor"
+	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// trezorMock is a USB transport emulating a Trezor, signing all messages with a
+// single key regardless of the derivation path. Every signing request is first
+// answered with a button request, as the device waits for user confirmation.
+type trezorMock struct {
+	key     *ecdsa.PrivateKey
+	noTyped bool // Whether to reject typed data hashes like old firmwares
+
+	receiving bool   // Whether a message is being reassembled
+	kind      uint16 // Type of the message being reassembled
+	size      int    // Total size of the message being reassembled
+	message   []byte // Message being reassembled from the written chunks
+
+	requests []proto.Message // Signing requests received from the driver
+	pending  proto.Message   // Response waiting for the button acknowledgement
+	reply    bytes.Buffer    // Reply chunks to be read by the driver
+}
+
+func newTrezorMock(t *testing.T) *trezorMock {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return &trezorMock{key: key}
+}
+
+func (m *trezorMock) Close() error { return nil }
+
+func (m *trezorMock) Read(b []byte) (int, error) { return m.reply.Read(b) }
+
+func (m *trezorMock) Write(chunk []byte) (int, error) {
+	if len(chunk) != 64 || chunk[0] != 0x3f {
+		return 0, errors.New("invalid chunk header")
+	}
+	if !m.receiving {
+		if chunk[1] != 0x23 || chunk[2] != 0x23 {
+			return 0, errors.New("invalid message header")
+		}
+		m.receiving = true
+		m.kind, m.size = binary.BigEndian.Uint16(chunk[3:5]), int(binary.BigEndian.Uint32(chunk[5:9]))
+		m.message = append([]byte{}, chunk[9:]...)
+	} else {
+		m.message = append(m.message, chunk[1:]...)
+	}
+	if len(m.message) >= m.size {
+		m.receiving = false
+
+		reply, err := m.handle(m.kind, m.message[:m.size])
+		if err != nil {
+			return 0, err
+		}
+		if err := m.respond(reply); err != nil {
+			return 0, err
+		}
+	}
+	return len(chunk), nil
+}
+
+// handle processes a single request message, returning the reply message.
+func (m *trezorMock) handle(kind uint16, data []byte) (proto.Message, error) {
+	switch trezor.MessageType(kind) {
+	case trezor.MessageType_MessageType_ButtonAck:
+		if m.pending == nil {
+			return nil, errors.New("unexpected button ack")
+		}
+		reply := m.pending
+		m.pending = nil
+		return reply, nil
+
+	case trezor.MessageType_MessageType_sdcereumSignMessage:
+		request := new(trezor.sdcereumSignMessage)
+		if err := proto.Unmarshal(data, request); err != nil {
+			return nil, err
+		}
+		m.requests = append(m.requests, request)
+
+		sig, err := m.sign(firmwareTextHash(request.GetMessage()))
+		if err != nil {
+			return nil, err
+		}
+		m.pending = &trezor.sdcereumMessageSignature{Signature: sig}
+		return new(trezor.ButtonRequest), nil
+
+	case trezor.MessageType_MessageType_sdcereumSignTypedHash:
+		if m.noTyped {
+			message := "Unexpected message"
+			return &trezor.Failure{Message: &message}, nil
+		}
+		request := new(trezor.sdcereumSignTypedHash)
+		if err := proto.Unmarshal(data, request); err != nil {
+			return nil, err
+		}
+		m.requests = append(m.requests, request)
+
+		sig, err := m.sign(crypto.Keccak256([]byte{0x19, 0x01}, request.GetDomainSeparatorHash(), request.GetMessageHash()))
+		if err != nil {
+			return nil, err
+		}
+		m.pending = &trezor.sdcereumTypedDataSignature{Signature: sig}
+		return new(trezor.ButtonRequest), nil
+	}
+	return nil, errors.New("unsupported message")
+}
+
+// sign signs the hash and returns it with the V value of 27 or 28 used by Trezor.
+func (m *trezorMock) sign(hash []byte) ([]byte, error) {
+	sig, err := crypto.Sign(hash, m.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// respond chunks up a reply message for the driver to read.
+func (m *trezorMock) respond(msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	payload := make([]byte, 8+len(data))
+	copy(payload, []byte{0x23, 0x23})
+	binary.BigEndian.PutUint16(payload[2:], trezor.Type(msg))
+	binary.BigEndian.PutUint32(payload[4:], uint32(len(data)))
+	copy(payload[8:], data)
+
+	for len(payload) > 0 {
+		chunk := make([]byte, 64)
+		chunk[0] = 0x3f
+		payload = payload[copy(chunk[1:], payload):]
+		m.reply.Write(chunk)
+	}
+	return nil
+}
+
+func newTestTrezor(mock *trezorMock) *trezorDriver {
+	return &trezorDriver{device: mock, log: log.New()}
+}
+
+func TestTrezorSignPersonalMessage(t *testing.T) {
+	path := accounts.DefaultBaseDerivationPath
+	for _, message := range [][]byte{[]byte("hello"), []byte(strings.Repeat("long message ", 100))} {
+		mock := newTrezorMock(t)
+		sig, err := newTestTrezor(mock).SignPersonalMessage(path, message)
+		if err != nil {
+			t.Fatalf("failed to sign message: %v", err)
+		}
+		verifySignature(t, firmwareTextHash(message), sig, mock.key)
+
+		request := mock.requests[0].(*trezor.sdcereumSignMessage)
+		if !bytes.Equal(request.GetMessage(), message) {
+			t.Errorf("message mismatch: have %q, want %q", request.GetMessage(), message)
+		}
+		if have := accounts.DerivationPath(request.GetAddressN()); have.String() != path.String() {
+			t.Errorf("path mismatch: have %s, want %s", have, path)
+		}
+	}
+}
+
+func TestTrezorSignTypedMessage(t *testing.T) {
+	var (
+		domainHash  = crypto.Keccak256([]byte("domain"))
+		messageHash = crypto.Keccak256([]byte("message"))
+	)
+	mock := newTrezorMock(t)
+	sig, err := newTestTrezor(mock).SignTypedMessage(accounts.DefaultBaseDerivationPath, domainHash, messageHash)
+	if err != nil {
+		t.Fatalf("failed to sign typed message: %v", err)
+	}
+	verifySignature(t, crypto.Keccak256([]byte{0x19, 0x01}, domainHash, messageHash), sig, mock.key)
+
+	request := mock.requests[0].(*trezor.sdcereumSignTypedHash)
+	if !bytes.Equal(request.GetDomainSeparatorHash(), domainHash) || !bytes.Equal(request.GetMessageHash(), messageHash) {
+		t.Errorf("hash mismatch: have %x/%x, want %x/%x", request.GetDomainSeparatorHash(), request.GetMessageHash(), domainHash, messageHash)
+	}
+	// Old firmwares reject the request as an unknown message
+	mock = newTrezorMock(t)
+	mock.noTyped = true
+	if _, err := newTestTrezor(mock).SignTypedMessage(accounts.DefaultBaseDerivationPath, domainHash, messageHash); err == nil || err.Error() != "trezor: Unexpected message" {
+		t.Errorf("error mismatch: have %v, want trezor failure", err)
+	}
+	// Closed devices are not reached out to
+	if _, err := new(trezorDriver).SignTypedMessage(accounts.DefaultBaseDerivationPath, domainHash, messageHash); err != accounts.ErrWalletClosed {
+		t.Errorf("closed device: error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+}
@@ -18,10 +18,12 @@
 package usbwallet
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"math/big"
+	"strconv"
 	"sync"
 	"time"
 
@@ -68,7 +70,15 @@ type driver interface {
 	// or deny the transaction.
 	SignTx(path accounts.DerivationPath, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error)
 
-	SignTypedMessage(path accounts.DerivationPath, messageHash []byte, domainHash []byte) ([]byte, error)
+	// SignPersonalMessage sends the message to the USB device and waits for the user
+	// to confirm or deny signing it with the EIP-191 personal message prefix. The
+	// signature is returned in [R || S || V] format, with V being 0 or 1.
+	SignPersonalMessage(path accounts.DerivationPath, message []byte) ([]byte, error)
+
+	// SignTypedMessage sends the EIP-712 domain and message hashes to the USB device
+	// and waits for the user to confirm or deny signing them. The signature is
+	// returned in [R || S || V] format, with V being 0 or 1.
+	SignTypedMessage(path accounts.DerivationPath, domainHash []byte, messageHash []byte) ([]byte, error)
 }
 
 // wallet represents the common functionality shared by all USB hardware
@@ -524,14 +534,55 @@ func (w *wallet) signHash(account accounts.Account, hash []byte) ([]byte, error)
 	return nil, accounts.ErrNotSupported
 }
 
-// SignData signs keccak256(data). The mimetype parameter describes the type of data being signed
+// SignData signs keccak256(data). The mimetype parameter describes the type of data
+// being signed: only EIP-712 typed data and prefixed text can be signed by hardware
+// wallets, as they refuse to sign arbitrary hashes.
 func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
-	// Unless we are doing 712 signing, simply dispatch to signHash
-	if !(mimeType == accounts.MimetypeTypedData && len(data) == 66 && data[0] == 0x19 && data[1] == 0x01) {
-		return w.signHash(account, crypto.Keccak256(data))
+	switch mimeType {
+	case accounts.MimetypeTypedData:
+		// Typed data is signed from its domain and message hashes
+		if len(data) == 66 && data[0] == 0x19 && data[1] == 0x01 {
+			return w.signMessage(account, crypto.Keccak256(data), func(path accounts.DerivationPath) ([]byte, error) {
+				return w.driver.SignTypedMessage(path, data[2:34], data[34:66])
+			})
+		}
+	case accounts.MimetypeTextPlain:
+		// Text is passed in prefixed, but the device adds the prefix by itself.
+		// Only the prefix of the firmware can be signed.
+		if text, ok := unprefixText(data); ok {
+			return w.SignText(account, text)
+		}
 	}
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// firmwareTextPrefix is the personal message prefix with which the Ledger and
+// Trezor firmware sign text. It differs from the prefix of accounts.TextHash.
+const firmwareTextPrefix = "\x19Ethereum Signed Message:\n"
 
-	// dispatch to 712 signing if the mimetype is TypedData and the format matches
+// firmwareTextHash returns the hash the device firmware signs for a text.
+func firmwareTextHash(text []byte) []byte {
+	return crypto.Keccak256([]byte(firmwareTextPrefix+strconv.Itoa(len(text))), text)
+}
+
+// unprefixText extracts the message from text prefixed for signing according to
+// the EIP-191 personal message scheme, with the prefix of the device firmware.
+func unprefixText(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, []byte(firmwareTextPrefix)) {
+		return nil, false
+	}
+	rest := data[len(firmwareTextPrefix):]
+	for digits := 1; digits <= len(rest); digits++ {
+		if strconv.Itoa(len(rest)-digits) == string(rest[:digits]) {
+			return rest[digits:], true
+		}
+	}
+	return nil, false
+}
+
+// signMessage requests the device to sign a message of the given account via the
+// sign callback, and verifies that the signature of the hash was made by the account.
+func (w *wallet) signMessage(account accounts.Account, hash []byte, sign func(path accounts.DerivationPath) ([]byte, error)) ([]byte, error) {
 	w.stateLock.RLock() // Comms have own mutex, this is for the state fields
 	defer w.stateLock.RUnlock()
 
@@ -559,11 +610,18 @@ func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte
 		w.hub.commsPend--
 		w.hub.commsLock.Unlock()
 	}()
-	// Sign the transaction
-	signature, err := w.driver.SignTypedMessage(path, data[2:34], data[34:66])
+	// Sign the message and verify the signer to avoid hardware fault surprises
+	signature, err := sign(path)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := crypto.SigToPub(hash, signature)
 	if err != nil {
 		return nil, err
 	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", account.Address.Hex(), signer.Hex())
+	}
 	return signature, nil
 }
 
@@ -574,8 +632,14 @@ func (w *wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mi
 	return w.SignData(account, mimeType, data)
 }
 
+// SignText implements accounts.Wallet, sending the text over to the hardware
+// wallet to request a confirmation from the user. The device shows the text and
+// signs it with the personal message prefix of its firmware, so the signature
+// is made over firmwareTextHash rather than accounts.TextHash.
 func (w *wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
-	return w.signHash(account, accounts.TextHash(text))
+	return w.signMessage(account, firmwareTextHash(text), func(path accounts.DerivationPath) ([]byte, error) {
+		return w.driver.SignPersonalMessage(path, text)
+	})
 }
 
 // SignTx implements accounts.Wallet. It sends the transaction over to the Ledger
@@ -624,11 +688,11 @@ func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 	return signed, nil
 }
 
-// SignHashWithPassphrase implements accounts.Wallet, however signing arbitrary
-// data is not supported for Ledger wallets, so this msdcod will always return
-// an error.
+// SignTextWithPassphrase implements accounts.Wallet, attempting to sign the given
+// text with the given account using passphrase as extra authentication.
+// Since USB wallets don't rely on passphrases, these are silently ignored.
 func (w *wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
-	return w.SignText(account, accounts.TextHash(text))
+	return w.SignText(account, text)
 }
 
 // SignTxWithPassphrase implements accounts.Wallet, attempting to sign the given
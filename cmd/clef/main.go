@@ -19,6 +19,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -34,12 +35,15 @@ import (
 	"time"
 
 	"github.com/sdcereum/go-sdcereum/accounts"
+	"github.com/sdcereum/go-sdcereum/accounts/hdwallet"
 	"github.com/sdcereum/go-sdcereum/accounts/keystore"
+	"github.com/sdcereum/go-sdcereum/accounts/pkcs11wallet"
 	"github.com/sdcereum/go-sdcereum/cmd/utils"
 	"github.com/sdcereum/go-sdcereum/common"
 	"github.com/sdcereum/go-sdcereum/common/hexutil"
 	"github.com/sdcereum/go-sdcereum/core/types"
 	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/ethclient"
 	"github.com/sdcereum/go-sdcereum/internal/sdcapi"
 	"github.com/sdcereum/go-sdcereum/internal/flags"
 	"github.com/sdcereum/go-sdcereum/log"
@@ -50,6 +54,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/signer/core"
 	"github.com/sdcereum/go-sdcereum/signer/core/apitypes"
 	"github.com/sdcereum/go-sdcereum/signer/fourbyte"
+	"github.com/sdcereum/go-sdcereum/signer/policy"
 	"github.com/sdcereum/go-sdcereum/signer/rules"
 	"github.com/sdcereum/go-sdcereum/signer/storage"
 	"github.com/mattn/go-colorable"
@@ -120,10 +125,77 @@ var (
 		Usage: "File used to emit audit logs. Set to \"\" to disable",
 		Value: "audit.log",
 	}
+	pkcs11Flag = &cli.StringFlag{
+		Name:  "pkcs11",
+		Usage: "Path to a PKCS#11 module (e.g. of an HSM or cloud KMS) to use secp256k1 keys from",
+	}
+	hdwalletFlag = &cli.StringFlag{
+		Name:  "hdwallets",
+		Usage: "Directory of the mnemonic based HD wallets to use",
+	}
+	mnemonicFlag = &cli.StringFlag{
+		Name:  "mnemonic",
+		Usage: "File containing the BIP-39 mnemonic to import (a new one is generated if omitted)",
+	}
+	feeOracleFlag = &cli.StringFlag{
+		Name:  "fee.oracle",
+		Usage: "RPC endpoint of a node whose fee suggestions transaction fees are judged against",
+	}
 	ruleFlag = &cli.StringFlag{
 		Name:  "rules",
 		Usage: "Path to the rule file to auto-authorize requests with",
 	}
+	policyFlag = &cli.StringFlag{
+		Name:  "policy",
+		Usage: "Path to a declarative (YAML or JSON) policy file to auto-authorize requests with",
+	}
+	policyDryRunFlag = &cli.BoolFlag{
+		Name:  "policy.dryrun",
+		Usage: "Only log the verdicts of the policy, forwarding all requests to the UI",
+	}
+	attestPolicyFlag = &cli.BoolFlag{
+		Name:  "policy",
+		Usage: "Attest the policy file instead of the js rule file",
+	}
+	approversFlag = &cli.StringFlag{
+		Name:  "approvers",
+		Usage: "Comma separated list of approvers (address or name=address) who must approve signing requests",
+	}
+	approvalThresholdFlag = &cli.IntFlag{
+		Name:  "approval.threshold",
+		Usage: "Number of approvers needed to sign a request",
+		Value: 1,
+	}
+	approvalTimeoutFlag = &cli.DurationFlag{
+		Name:  "approval.timeout",
+		Usage: "Time after which requests lacking approvals are rejected",
+		Value: time.Hour,
+	}
+	approvalPortFlag = &cli.IntFlag{
+		Name:  "approval.port",
+		Usage: "HTTP port of the approval RPC API (served with --http only)",
+		Value: 8552,
+	}
+	auditSignerFlag = &cli.StringFlag{
+		Name:  "signer",
+		Usage: "Address which must have signed the audit log checkpoints",
+	}
+	auditAccountFlag = &cli.StringFlag{
+		Name:  "account",
+		Usage: "Only show the records about this account",
+	}
+	auditFromFlag = &cli.StringFlag{
+		Name:  "from",
+		Usage: "Only show the records from this time on (RFC 3339 time or date)",
+	}
+	auditToFlag = &cli.StringFlag{
+		Name:  "to",
+		Usage: "Only show the records before this time (RFC 3339 time or date)",
+	}
+	auditMethodFlag = &cli.StringFlag{
+		Name:  "method",
+		Usage: "Only show the records of this API call, e.g. SignTransaction",
+	}
 	stdiouiFlag = &cli.BoolFlag{
 		Name: "stdio-ui",
 		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
@@ -150,18 +222,19 @@ the rule-engine to work.`,
 	attestCommand = &cli.Command{
 		Action:    attestFile,
 		Name:      "attest",
-		Usage:     "Attest that a js-file is to be used",
+		Usage:     "Attest that a js-file or policy file is to be used",
 		ArgsUsage: "<sha256sum>",
 		Flags: []cli.Flag{
 			logLevelFlag,
 			configdirFlag,
 			signerSecretFlag,
+			attestPolicyFlag,
 		},
 		Description: `
 The attest command stores the sha256 of the rule.js-file that you want to use for automatic processing of
-incoming requests.
+incoming requests. With --policy, the sha256 of the declarative policy file is stored instead.
 
-Whenever you make an edit to the rule file, you need to use attestation to tell
+Whenever you make an edit to the rule or policy file, you need to use attestation to tell
 Clef that the file is 'safe' to execute.`,
 	}
 	setCredentialCommand = &cli.Command{
@@ -206,6 +279,64 @@ The newaccount command creates a new keystore-backed account. It is a convenienc
 which can be used in lieu of an external UI.`,
 	}
 
+	newHDWalletCommand = &cli.Command{
+		Action:    newHDWallet,
+		Name:      "newhdwallet",
+		Usage:     "Create a new mnemonic based HD wallet",
+		ArgsUsage: "",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			hdwalletFlag,
+			mnemonicFlag,
+			utils.LightKDFFlag,
+			acceptFlag,
+		},
+		Description: `
+The newhdwallet command creates a new HD wallet in the --hdwallets directory, either from
+the mnemonic in the --mnemonic file or from a newly generated one, which is printed once.
+Only the seed of the mnemonic is stored, encrypted with the given password.
+
+The same mnemonic always produces the same accounts, which makes HD wallets suitable for
+reproducible test and automation setups.`,
+	}
+
+	auditCommand = &cli.Command{
+		Name:  "audit",
+		Usage: "Verify and query the audit log",
+		Subcommands: []*cli.Command{
+			{
+				Action: verifyAuditLog,
+				Name:   "verify",
+				Usage:  "Verify the integrity of the audit log",
+				Flags: []cli.Flag{
+					auditLogFlag,
+					auditSignerFlag,
+				},
+				Description: `
+The audit verify command checks the hash chain of the audit log, and that its checkpoints
+are signed by the --signer address (logged by Clef at startup). Without --signer, all
+checkpoints must be signed by the same key. Records after the last checkpoint are only
+protected by the hash chain, their number is reported.`,
+			},
+			{
+				Action: queryAuditLog,
+				Name:   "query",
+				Usage:  "Print the audit log records matching the given filters",
+				Flags: []cli.Flag{
+					auditLogFlag,
+					auditAccountFlag,
+					auditFromFlag,
+					auditToFlag,
+					auditMethodFlag,
+				},
+				Description: `
+The audit query command prints the records of the audit log about the given account,
+within the given time range and of the given API call as JSON lines. The records are
+not verified, use 'clef audit verify' for that.`,
+			},
+		},
+	}
+
 	gendocCommand = &cli.Command{
 		Action: GenDoc,
 		Name:   "gendoc",
@@ -227,6 +358,9 @@ func init() {
 		utils.LightKDFFlag,
 		utils.NoUSBFlag,
 		utils.SmartCardDaemonPathFlag,
+		pkcs11Flag,
+		hdwalletFlag,
+		feeOracleFlag,
 		utils.HTTPListenAddrFlag,
 		utils.HTTPVirtualHostsFlag,
 		utils.IPCDisabledFlag,
@@ -237,6 +371,12 @@ func init() {
 		customDBFlag,
 		auditLogFlag,
 		ruleFlag,
+		policyFlag,
+		policyDryRunFlag,
+		approversFlag,
+		approvalThresholdFlag,
+		approvalTimeoutFlag,
+		approvalPortFlag,
 		stdiouiFlag,
 		testFlag,
 		advancedMode,
@@ -248,6 +388,8 @@ func init() {
 		setCredentialCommand,
 		delCredentialCommand,
 		newAccountCommand,
+		newHDWalletCommand,
+		auditCommand,
 		gendocCommand,
 	}
 }
@@ -346,6 +488,11 @@ func attestFile(ctx *cli.Context) error {
 	// Initialize the encrypted storages
 	configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confKey)
 	val := ctx.Args().First()
+	if ctx.Bool(attestPolicyFlag.Name) {
+		configStorage.Put("policy_sha256", val)
+		log.Info("Policy attestation updated", "sha256", val)
+		return nil
+	}
 	configStorage.Put("ruleset_sha256", val)
 	log.Info("Ruleset attestation updated", "sha256", val)
 	return nil
@@ -434,6 +581,128 @@ func newAccount(c *cli.Context) error {
 	return err
 }
 
+func newHDWallet(c *cli.Context) error {
+	if err := initialize(c); err != nil {
+		return err
+	}
+	dir := c.String(hdwalletFlag.Name)
+	if dir == "" {
+		utils.Fatalf("The HD wallet directory must be specified with --%s", hdwalletFlag.Name)
+	}
+	n, p := keystore.StandardScryptN, keystore.StandardScryptP
+	if c.Bool(utils.LightKDFFlag.Name) {
+		n, p = keystore.LightScryptN, keystore.LightScryptP
+	}
+	var (
+		mnemonic  string
+		generated bool
+	)
+	if file := c.String(mnemonicFlag.Name); file != "" {
+		blob, err := os.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic: %v", err)
+		}
+		mnemonic = strings.TrimSpace(string(blob))
+	} else {
+		var err error
+		if mnemonic, err = hdwallet.NewMnemonic(256); err != nil {
+			utils.Fatalf("Failed to generate mnemonic: %v", err)
+		}
+		generated = true
+	}
+	if err := hdwallet.ValidateMnemonic(mnemonic); err != nil {
+		utils.Fatalf("Failed to import mnemonic: %v", err)
+	}
+	password := utils.GetPassPhrase("Please enter a password to encrypt the wallet seed with:", true)
+
+	hub, err := hdwallet.NewHub(dir, n, p)
+	if err != nil {
+		return err
+	}
+	wallet, err := hub.NewWallet(mnemonic, "", password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated HD wallet %v\n", wallet.URL())
+	if generated {
+		fmt.Printf("\nThe mnemonic of the wallet is not stored, write it down to be able to restore it:\n\n%s\n\n", mnemonic)
+	}
+	return nil
+}
+
+func verifyAuditLog(c *cli.Context) error {
+	var signer *common.Address
+	if addr := c.String(auditSignerFlag.Name); addr != "" {
+		if !common.IsHexAddress(addr) {
+			utils.Fatalf("Invalid signer address: %s", addr)
+		}
+		address := common.HexToAddress(addr)
+		signer = &address
+	}
+	f, err := os.Open(c.String(auditLogFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	summary, err := core.VerifyAuditLog(f, signer)
+	if err != nil {
+		return fmt.Errorf("audit log verification failed after %d records: %v", summary.Records, err)
+	}
+	fmt.Printf("Verified %d records with %d checkpoints\n", summary.Records, summary.Checkpoints)
+	if summary.Checkpoints > 0 {
+		fmt.Printf("Checkpoints signed by %s\n", summary.Signer.Hex())
+	}
+	if summary.Unsigned > 0 {
+		fmt.Printf("The last %d records are not covered by a checkpoint\n", summary.Unsigned)
+	}
+	return nil
+}
+
+func queryAuditLog(c *cli.Context) error {
+	var (
+		filter core.AuditFilter
+		err    error
+	)
+	if addr := c.String(auditAccountFlag.Name); addr != "" {
+		if !common.IsHexAddress(addr) {
+			utils.Fatalf("Invalid account address: %s", addr)
+		}
+		address := common.HexToAddress(addr)
+		filter.Account = &address
+	}
+	if filter.From, err = parseAuditTime(c.String(auditFromFlag.Name)); err != nil {
+		utils.Fatalf("Invalid --%s time: %v", auditFromFlag.Name, err)
+	}
+	if filter.To, err = parseAuditTime(c.String(auditToFlag.Name)); err != nil {
+		utils.Fatalf("Invalid --%s time: %v", auditToFlag.Name, err)
+	}
+	filter.Method = c.String(auditMethodFlag.Name)
+
+	f, err := os.Open(c.String(auditLogFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(os.Stdout)
+	return core.QueryAuditLog(f, filter, func(record *core.AuditRecord) error {
+		return enc.Encode(record)
+	})
+}
+
+// parseAuditTime parses an RFC 3339 time or date, returning the zero time for
+// empty input.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
 func initialize(c *cli.Context) error {
 	// Set up the logger to print everything
 	logOutput := os.Stdout
@@ -507,12 +776,33 @@ func signer(c *cli.Context) error {
 	embeds, locals := db.Size()
 	log.Info("Loaded 4byte database", "embeds", embeds, "locals", locals, "local", fourByteLocal)
 
+	if endpoint := c.String(feeOracleFlag.Name); endpoint != "" {
+		client, err := ethclient.Dial(endpoint)
+		if err != nil {
+			utils.Fatalf("Failed to connect to fee oracle: %v", err)
+		}
+		db.SetFeeOracle(client)
+		log.Info("Fee oracle configured", "endpoint", endpoint)
+	}
+
 	var (
 		api       core.ExternalAPI
 		pwStorage storage.Storage = &storage.NoStorage{}
+		approvals *core.ApprovalQueue
+		auditKey  *ecdsa.PrivateKey
 	)
+	approvers, err := parseApprovers(c.String(approversFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid approvers: %v", err)
+	}
+	if len(approvers) > 0 && !c.Bool(utils.HTTPEnabledFlag.Name) {
+		utils.Fatalf("Multi-party approval requires --%s to serve the approval API", utils.HTTPEnabledFlag.Name)
+	}
 	configDir := c.String(configdirFlag.Name)
 	if stretchedKey, err := readMasterKey(c, ui); err != nil {
+		if len(approvers) > 0 {
+			utils.Fatalf("Multi-party approval requires the master seed: %v", err)
+		}
 		log.Warn("Failed to open master, rules disabled", "err", err)
 	} else {
 		vaultLocation := filepath.Join(configDir, common.Bytes2Hex(crypto.Keccak256([]byte("vault"), stretchedKey)[:10]))
@@ -520,12 +810,19 @@ func signer(c *cli.Context) error {
 		// Generate domain specific keys
 		pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 		jskey := crypto.Keccak256([]byte("jsstorage"), stretchedKey)
+		policykey := crypto.Keccak256([]byte("policystorage"), stretchedKey)
+		approvalkey := crypto.Keccak256([]byte("approvals"), stretchedKey)
 		confkey := crypto.Keccak256([]byte("config"), stretchedKey)
 
+		// Derive the key signing the audit log checkpoints
+		if auditKey, err = crypto.ToECDSA(crypto.Keccak256([]byte("auditlog"), stretchedKey)); err != nil {
+			utils.Fatalf("Failed to derive audit log key: %v", err)
+		}
 		// Initialize the encrypted storages
 		pwStorage = storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
+		policyStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "policystorage.json"), policykey)
 
 		// Do we have a rule-file?
 		if ruleFile := c.String(ruleFlag.Name); ruleFile != "" {
@@ -550,6 +847,48 @@ func signer(c *cli.Context) error {
 				}
 			}
 		}
+		// Do we have a policy-file? It's evaluated before the rules, passing
+		// requests it doesn't cover on to them.
+		if policyFile := c.String(policyFlag.Name); policyFile != "" {
+			blob, err := os.ReadFile(policyFile)
+			if err != nil {
+				log.Warn("Could not load policy, disabling", "file", policyFile, "err", err)
+			} else {
+				shasum := sha256.Sum256(blob)
+				foundShaSum := hex.EncodeToString(shasum[:])
+				storedShasum, _ := configStorage.Get("policy_sha256")
+				if storedShasum != foundShaSum {
+					log.Warn("Policy hash not attested, disabling", "hash", foundShaSum, "attested", storedShasum)
+				} else {
+					p, err := policy.Parse(blob)
+					if err != nil {
+						utils.Fatalf(err.Error())
+					}
+					dryRun := c.Bool(policyDryRunFlag.Name)
+					engine, err := policy.NewEngine(ui, db, policyStorage, p, dryRun)
+					if err != nil {
+						utils.Fatalf("Invalid policy: %v", err)
+					}
+					ui = engine
+					log.Info("Policy engine configured", "file", policyFile, "dryrun", dryRun)
+				}
+			}
+		}
+		// Do we need multiple approvers? The queue wraps the policy and rules, so
+		// requests they approve still need the approvers' threshold.
+		if len(approvers) > 0 {
+			approvalStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "approvals.json"), approvalkey)
+			approvals, err = core.NewApprovalQueue(ui, approvalStorage, core.ApprovalConfig{
+				Approvers: approvers,
+				Threshold: c.Int(approvalThresholdFlag.Name),
+				Timeout:   c.Duration(approvalTimeoutFlag.Name),
+			})
+			if err != nil {
+				utils.Fatalf(err.Error())
+			}
+			ui = approvals
+			log.Info("Multi-party approval configured", "approvers", len(approvers), "threshold", c.Int(approvalThresholdFlag.Name))
+		}
 	}
 	var (
 		chainId  = c.Int64(chainIdFlag.Name)
@@ -562,6 +901,26 @@ func signer(c *cli.Context) error {
 	log.Info("Starting signer", "chainid", chainId, "keystore", ksLoc,
 		"light-kdf", lightKdf, "advanced", advanced)
 	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
+	if module := c.String(pkcs11Flag.Name); module != "" {
+		hub, err := pkcs11wallet.NewHub(module)
+		if err != nil {
+			utils.Fatalf("Failed to start PKCS#11 hub: %v", err)
+		}
+		am.AddBackend(hub)
+		log.Info("PKCS#11 support enabled", "module", module)
+	}
+	if dir := c.String(hdwalletFlag.Name); dir != "" {
+		n, p := keystore.StandardScryptN, keystore.StandardScryptP
+		if lightKdf {
+			n, p = keystore.LightScryptN, keystore.LightScryptP
+		}
+		hub, err := hdwallet.NewHub(dir, n, p)
+		if err != nil {
+			utils.Fatalf("Failed to start HD wallet hub: %v", err)
+		}
+		am.AddBackend(hub)
+		log.Info("HD wallet support enabled", "dir", dir)
+	}
 	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
 
 	// Establish the bidirectional communication, by creating a new UI backend and registering
@@ -570,11 +929,20 @@ func signer(c *cli.Context) error {
 	api = apiImpl
 	// Audit logging
 	if logfile := c.String(auditLogFlag.Name); logfile != "" {
-		api, err = core.NewAuditLogger(logfile, api)
+		auditLogger, auditLog, err := core.NewAuditLogger(logfile, api, auditKey)
 		if err != nil {
 			utils.Fatalf(err.Error())
 		}
-		log.Info("Audit logs configured", "file", logfile)
+		defer auditLog.Close()
+		if approvals != nil {
+			approvals.SetAuditLog(auditLogger)
+		}
+		api = auditLogger
+		if auditKey != nil {
+			log.Info("Audit logs configured", "file", logfile, "signer", crypto.PubkeyToAddress(auditKey.PublicKey))
+		} else {
+			log.Warn("Audit logs configured without checkpoints, master seed unavailable", "file", logfile)
+		}
 	}
 	// register signer API with server
 	var (
@@ -616,6 +984,29 @@ func signer(c *cli.Context) error {
 			log.Info("HTTP endpoint closed", "url", extapiURL)
 		}()
 	}
+	if approvals != nil {
+		vhosts := utils.SplitAndTrim(c.String(utils.HTTPVirtualHostsFlag.Name))
+		cors := utils.SplitAndTrim(c.String(utils.HTTPCORSDomainFlag.Name))
+
+		srv := rpc.NewServer()
+		if err := srv.RegisterName("approval", core.NewApprovalAPI(approvals)); err != nil {
+			utils.Fatalf("Could not register approval API: %v", err)
+		}
+		handler := node.NewHTTPHandlerStack(srv, cors, vhosts, nil)
+
+		approvalEndpoint := fmt.Sprintf("%s:%d", c.String(utils.HTTPListenAddrFlag.Name), c.Int(approvalPortFlag.Name))
+		approvalServer, addr, err := node.StartHTTPEndpoint(approvalEndpoint, rpc.DefaultHTTPTimeouts, handler)
+		if err != nil {
+			utils.Fatalf("Could not start approval api: %v", err)
+		}
+		approvalURL := fmt.Sprintf("http://%v/", addr)
+		log.Info("Approval endpoint opened", "url", approvalURL)
+
+		defer func() {
+			approvalServer.Shutdown(context.Background())
+			log.Info("Approval endpoint closed", "url", approvalURL)
+		}()
+	}
 	if !c.Bool(utils.IPCDisabledFlag.Name) {
 		givenPath := c.String(utils.IPCPathFlag.Name)
 		ipcapiURL = ipcEndpoint(filepath.Join(givenPath, "clef.ipc"), configDir)
@@ -652,6 +1043,30 @@ func signer(c *cli.Context) error {
 	return nil
 }
 
+// parseApprovers parses a comma separated list of approvers, given either as
+// plain addresses or as name=address pairs.
+func parseApprovers(list string) (map[common.Address]string, error) {
+	approvers := make(map[common.Address]string)
+	for _, entry := range utils.SplitAndTrim(list) {
+		name, hex := "", entry
+		if i := strings.IndexByte(entry, '='); i >= 0 {
+			name, hex = entry[:i], entry[i+1:]
+		}
+		if !common.IsHexAddress(hex) {
+			return nil, fmt.Errorf("invalid approver address %q", hex)
+		}
+		addr := common.HexToAddress(hex)
+		if _, exists := approvers[addr]; exists {
+			return nil, fmt.Errorf("duplicate approver %v", addr)
+		}
+		if name == "" {
+			name = addr.Hex()
+		}
+		approvers[addr] = name
+	}
+	return approvers, nil
+}
+
 // DefaultConfigDir is the default config directory to use for the vaults and other
 // persistence requirements.
 func DefaultConfigDir() string {
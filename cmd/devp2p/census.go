@@ -0,0 +1,341 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of go-spacedogechain.
+//
+// go-spacedogechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-spacedogechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-spacedogechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/cmd/devp2p/internal/ethtest"
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/eth/protocols/eth"
+	"github.com/spacedogechain/go-spacedogechain/log"
+	"github.com/spacedogechain/go-spacedogechain/p2p"
+	"github.com/spacedogechain/go-spacedogechain/p2p/enode"
+	"github.com/spacedogechain/go-spacedogechain/p2p/rlpx"
+	"github.com/spacedogechain/go-spacedogechain/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	censusCommand = &cli.Command{
+		Name:  "census",
+		Usage: "Network census tools",
+		Subcommands: []*cli.Command{
+			censusProbeCommand,
+			censusReportCommand,
+		},
+	}
+	censusProbeCommand = &cli.Command{
+		Name:      "probe",
+		Usage:     "Performs RLPx handshakes with all nodes of a node set",
+		ArgsUsage: "<nodes.json> <census.json>",
+		Action:    censusProbe,
+		Flags:     []cli.Flag{censusWorkersFlag, censusTimeoutFlag},
+	}
+	censusReportCommand = &cli.Command{
+		Name:      "report",
+		Usage:     "Shows client, fork and network distribution of a census",
+		ArgsUsage: "<census.json>",
+		Action:    censusReport,
+		Flags:     []cli.Flag{censusNetworkFlag, censusIP2ASNFlag},
+	}
+)
+
+var (
+	crawlCensusFlag = &cli.StringFlag{
+		Name:  "census",
+		Usage: "Performs RLPx handshakes with the crawled nodes and writes the results to this file",
+	}
+	censusWorkersFlag = &cli.IntFlag{
+		Name:  "workers",
+		Usage: "Number of concurrent handshakes",
+		Value: 32,
+	}
+	censusTimeoutFlag = &cli.DurationFlag{
+		Name:  "handshake-timeout",
+		Usage: "Time limit for the handshake with a single node",
+		Value: 10 * time.Second,
+	}
+	censusNetworkFlag = &cli.StringFlag{
+		Name:  "network",
+		Usage: "Network for the fork readiness report (mainnet, rinkeby, goerli, ropsten, sepolia or a genesis.json file)",
+	}
+	censusIP2ASNFlag = &cli.StringFlag{
+		Name:  "ip2asn",
+		Usage: "IP to ASN and country database (ip2asn-combined.tsv from iptoasn.com)",
+	}
+)
+
+// censusSet is the census.json file format. It holds the results of the handshakes
+// with a set of nodes.
+type censusSet map[enode.ID]censusJSON
+
+type censusJSON struct {
+	N       *enode.Node `json:"record"`
+	Checked time.Time   `json:"checked"`
+	Error   string      `json:"error,omitempty"`
+
+	// Latency is the time it took to establish the TCP connection, in milliseconds.
+	Latency uint64 `json:"latencyMs,omitempty"`
+	// These are taken from the devp2p hello message.
+	Client string   `json:"client,omitempty"`
+	Caps   []string `json:"caps,omitempty"`
+	// This is the status message of the highest common eth protocol version.
+	Status *censusStatus `json:"status,omitempty"`
+}
+
+type censusStatus struct {
+	Version   uint32        `json:"version"`
+	NetworkID uint64        `json:"networkId"`
+	TD        *big.Int      `json:"td"`
+	Head      common.Hash   `json:"head"`
+	Genesis   common.Hash   `json:"genesis"`
+	ForkHash  hexutil.Bytes `json:"forkHash"`
+	ForkNext  uint64        `json:"forkNext"`
+}
+
+func loadCensusJSON(file string) censusSet {
+	var cs censusSet
+	if err := common.LoadJSON(file, &cs); err != nil {
+		exit(err)
+	}
+	return cs
+}
+
+func writeCensusJSON(file string, cs censusSet) {
+	censusJSON, err := json.MarshalIndent(cs, "", jsonIndent)
+	if err != nil {
+		exit(err)
+	}
+	if file == "-" {
+		os.Stdout.Write(censusJSON)
+		return
+	}
+	if err := os.WriteFile(file, censusJSON, 0644); err != nil {
+		exit(err)
+	}
+}
+
+// reachable returns the entries of nodes which sent the devp2p hello message,
+// sorted by ID.
+func (cs censusSet) reachable() []censusJSON {
+	var result []censusJSON
+	for _, e := range cs {
+		if e.Client != "" {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].N.ID().Bytes(), result[j].N.ID().Bytes()) < 0
+	})
+	return result
+}
+
+func censusProbe(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return fmt.Errorf("need nodes file and census file as arguments")
+	}
+	ns := loadNodesJSON(ctx.Args().Get(0))
+	cs := runCensus(ctx, ns.nodes())
+	writeCensusJSON(ctx.Args().Get(1), cs)
+	return nil
+}
+
+// runCensus performs handshakes with the given nodes, configured by command line flags.
+func runCensus(ctx *cli.Context, nodes []*enode.Node) censusSet {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		exit(err)
+	}
+	p := &censusProber{key: key, timeout: censusTimeoutFlag.Value, workers: censusWorkersFlag.Value}
+	if ctx.IsSet(censusTimeoutFlag.Name) {
+		p.timeout = ctx.Duration(censusTimeoutFlag.Name)
+	}
+	if ctx.IsSet(censusWorkersFlag.Name) {
+		p.workers = ctx.Int(censusWorkersFlag.Name)
+	}
+	return p.run(nodes)
+}
+
+// censusProber performs the devp2p and eth handshakes with remote nodes.
+type censusProber struct {
+	key     *ecdsa.PrivateKey
+	timeout time.Duration
+	workers int
+}
+
+// run probes the given nodes concurrently.
+func (p *censusProber) run(nodes []*enode.Node) censusSet {
+	var (
+		cs    = make(censusSet, len(nodes))
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		queue = make(chan *enode.Node)
+	)
+	log.Info("Starting census", "nodes", len(nodes), "workers", p.workers)
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range queue {
+				e := p.probe(n)
+				mu.Lock()
+				cs[n.ID()] = e
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, n := range nodes {
+		queue <- n
+	}
+	close(queue)
+	wg.Wait()
+	log.Info("Census done", "nodes", len(cs), "reachable", len(cs.reachable()))
+	return cs
+}
+
+// probe connects to a node and records its client, capabilities and status.
+func (p *censusProber) probe(n *enode.Node) censusJSON {
+	e := censusJSON{N: n, Checked: truncNow()}
+	if err := p.handshake(n, &e); err != nil {
+		log.Debug("Census handshake failed", "id", n.ID(), "err", err)
+		e.Error = err.Error()
+	} else {
+		log.Debug("Census handshake done", "id", n.ID(), "client", e.Client)
+	}
+	return e
+}
+
+func (p *censusProber) handshake(n *enode.Node, e *censusJSON) error {
+	if n.IP() == nil || n.TCP() == 0 {
+		return errors.New("node has no TCP endpoint")
+	}
+	addr := net.JoinHostPort(n.IP().String(), strconv.Itoa(n.TCP()))
+	start := time.Now()
+	fd, err := net.DialTimeout("tcp", addr, p.timeout)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	e.Latency = uint64(time.Since(start) / time.Millisecond)
+	fd.SetDeadline(start.Add(p.timeout))
+
+	conn := rlpx.NewConn(fd, n.Pubkey())
+	if _, err := conn.Handshake(p.key); err != nil {
+		return fmt.Errorf("RLPx handshake failed: %v", err)
+	}
+	defer censusSend(conn, 0x01, []p2p.DiscReason{p2p.DiscQuitting})
+
+	// Exchange the devp2p hello message.
+	var caps []p2p.Cap
+	for _, version := range eth.ProtocolVersions {
+		caps = append(caps, p2p.Cap{Name: eth.ProtocolName, Version: version})
+	}
+	hello := &ethtest.Hello{Version: 5, Name: "devp2p-census", Caps: caps, ID: crypto.FromECDSAPub(&p.key.PublicKey)[1:]}
+	if err := censusSend(conn, 0x00, hello); err != nil {
+		return err
+	}
+	var remote ethtest.Hello
+	if err := censusRead(conn, 0x00, &remote); err != nil {
+		return err
+	}
+	e.Client = remote.Name
+	e.Caps = make([]string, len(remote.Caps))
+	for i, c := range remote.Caps {
+		e.Caps[i] = c.String()
+	}
+	if remote.Version >= 5 {
+		conn.SetSnappy(true)
+	}
+
+	// Read the status message if there is a common eth protocol version.
+	var version uint
+	for _, c := range remote.Caps {
+		for _, v := range eth.ProtocolVersions {
+			if c.Name == eth.ProtocolName && c.Version == v && v > version {
+				version = v
+			}
+		}
+	}
+	if version == 0 {
+		return nil
+	}
+	var status eth.StatusPacket
+	if err := censusRead(conn, 0x10+eth.StatusMsg, &status); err != nil {
+		return err
+	}
+	e.Status = &censusStatus{
+		Version:   status.ProtocolVersion,
+		NetworkID: status.NetworkID,
+		TD:        status.TD,
+		Head:      status.Head,
+		Genesis:   status.Genesis,
+		ForkHash:  status.ForkID.Hash[:],
+		ForkNext:  status.ForkID.Next,
+	}
+	return nil
+}
+
+// censusSend writes a message to the connection.
+func censusSend(conn *rlpx.Conn, code uint64, msg interface{}) error {
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(code, data)
+	return err
+}
+
+// censusRead reads messages until one with the given code arrives, answering pings.
+func censusRead(conn *rlpx.Conn, code uint64, msg interface{}) error {
+	for {
+		c, data, _, err := conn.Read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == code:
+			if err := rlp.DecodeBytes(data, msg); err != nil {
+				return fmt.Errorf("invalid message %d: %v", code, err)
+			}
+			return nil
+		case c == 0x01:
+			var reason []p2p.DiscReason
+			if rlp.DecodeBytes(data, &reason); len(reason) == 0 {
+				return errors.New("disconnected")
+			}
+			return fmt.Errorf("disconnected: %v", reason[0])
+		case c == 0x02:
+			if err := censusSend(conn, 0x03, []interface{}{}); err != nil {
+				return err
+			}
+		}
+	}
+}
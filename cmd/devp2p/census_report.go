@@ -0,0 +1,381 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of go-spacedogechain.
+//
+// go-spacedogechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-spacedogechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-spacedogechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/core"
+	"github.com/spacedogechain/go-spacedogechain/core/forkid"
+	"github.com/spacedogechain/go-spacedogechain/params"
+	"github.com/urfave/cli/v2"
+)
+
+// maxReportRows is the number of rows shown in distributions with many keys.
+const maxReportRows = 20
+
+func censusReport(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("need census file as argument")
+	}
+	cs := loadCensusJSON(ctx.Args().First())
+	entries := cs.reachable()
+	fmt.Printf("Census contains %d nodes, %d of them reachable.\n", len(cs), len(entries))
+	if len(entries) == 0 {
+		return nil
+	}
+
+	fmt.Println("\nClients:")
+	printDistribution("Client", countBy(entries, func(e censusJSON) string {
+		client, _ := parseClientName(e.Client)
+		return client
+	}), len(entries), 0)
+	fmt.Println("\nClient versions:")
+	printDistribution("Version", countBy(entries, func(e censusJSON) string {
+		client, version := parseClientName(e.Client)
+		return client + " " + version
+	}), len(entries), maxReportRows)
+
+	var withStatus []censusJSON
+	for _, e := range entries {
+		if e.Status != nil {
+			withStatus = append(withStatus, e)
+		}
+	}
+	fmt.Println("\nFork IDs:")
+	printDistribution("Genesis / Fork ID", countBy(withStatus, func(e censusJSON) string {
+		return fmt.Sprintf("%x / %x next %d", e.Status.Genesis[:4], []byte(e.Status.ForkHash), e.Status.ForkNext)
+	}), len(withStatus), maxReportRows)
+
+	if ctx.IsSet(censusNetworkFlag.Name) {
+		config, genesis, err := censusNetworkConfig(ctx.String(censusNetworkFlag.Name))
+		if err != nil {
+			return err
+		}
+		readiness, unknown := computeForkReadiness(withStatus, config, genesis)
+		fmt.Println("\nFork readiness:")
+		printForkReadiness(readiness)
+		if unknown > 0 {
+			fmt.Printf("%d nodes of the network have a fork ID unknown to the chain configuration.\n", unknown)
+		}
+	}
+
+	if ctx.IsSet(censusIP2ASNFlag.Name) {
+		db, err := loadIPDatabase(ctx.String(censusIP2ASNFlag.Name))
+		if err != nil {
+			return err
+		}
+		fmt.Println("\nCountries:")
+		printDistribution("Country", countBy(entries, func(e censusJSON) string {
+			if r := db.lookup(e.N.IP()); r != nil {
+				return r.country
+			}
+			return "unknown"
+		}), len(entries), maxReportRows)
+		fmt.Println("\nAutonomous systems:")
+		printDistribution("ASN", countBy(entries, func(e censusJSON) string {
+			if r := db.lookup(e.N.IP()); r != nil {
+				return fmt.Sprintf("AS%d %s", r.asn, r.name)
+			}
+			return "unknown"
+		}), len(entries), maxReportRows)
+	}
+	return nil
+}
+
+// censusNetworkConfig returns the chain configuration of a known network, or
+// loads it from a genesis file.
+func censusNetworkConfig(network string) (*params.ChainConfig, common.Hash, error) {
+	if !common.FileExist(network) {
+		return networkConfig(network)
+	}
+	var gen core.Genesis
+	if err := common.LoadJSON(network, &gen); err != nil {
+		return nil, common.Hash{}, err
+	}
+	if gen.Config == nil {
+		return nil, common.Hash{}, fmt.Errorf("genesis file %s has no chain configuration", network)
+	}
+	return gen.Config, gen.ToBlock().Hash(), nil
+}
+
+// parseClientName splits a client name like "Geth/v1.10.26-stable/linux-amd64/go1.18.5"
+// into the lowercase client name and its version.
+func parseClientName(name string) (client, version string) {
+	parts := strings.Split(name, "/")
+	client = strings.ToLower(parts[0])
+	for _, part := range parts[1:] {
+		if len(part) > 1 && part[0] == 'v' && part[1] >= '0' && part[1] <= '9' {
+			return client, part
+		}
+	}
+	return client, "unknown"
+}
+
+// censusCount is the number of nodes sharing a property.
+type censusCount struct {
+	key   string
+	count int
+}
+
+// countBy returns the distribution of the given property, most common first.
+func countBy(entries []censusJSON, key func(censusJSON) string) []censusCount {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[key(e)]++
+	}
+	result := make([]censusCount, 0, len(counts))
+	for k, n := range counts {
+		result = append(result, censusCount{k, n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].count == result[j].count {
+			return result[i].key < result[j].key
+		}
+		return result[i].count > result[j].count
+	})
+	return result
+}
+
+// printDistribution prints counts as a table. If limit is non-zero, the remaining
+// rows are summarized as 'other'.
+func printDistribution(name string, counts []censusCount, total int, limit int) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{name, "Nodes", "Share"})
+	var other int
+	for i, c := range counts {
+		if limit > 0 && i >= limit {
+			other += c.count
+			continue
+		}
+		table.Append([]string{c.key, strconv.Itoa(c.count), percentage(c.count, total)})
+	}
+	if other > 0 {
+		table.Append([]string{"other", strconv.Itoa(other), percentage(other, total)})
+	}
+	table.Render()
+}
+
+func percentage(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// forkReadiness is the state of the network with respect to a scheduled fork.
+type forkReadiness struct {
+	block    uint64 // block number of the fork
+	behind   int    // nodes which haven't passed the previous fork yet
+	ready    int    // nodes before the fork which have it scheduled
+	missing  int    // nodes before the fork which have no fork scheduled
+	conflict int    // nodes before the fork which have another fork scheduled
+	passed   int    // nodes which have already passed the fork
+}
+
+// total returns the number of nodes counted in r.
+func (r forkReadiness) total() int {
+	return r.behind + r.ready + r.missing + r.conflict + r.passed
+}
+
+// forkEpochs returns the fork IDs of the chain, one for each period between forks.
+func forkEpochs(config *params.ChainConfig, genesis common.Hash) []forkid.ID {
+	var ids []forkid.ID
+	for head := uint64(0); ; {
+		id := forkid.NewID(config, genesis, head)
+		ids = append(ids, id)
+		if id.Next == 0 {
+			return ids
+		}
+		head = id.Next
+	}
+}
+
+// computeForkReadiness computes the readiness of the nodes of a network for all
+// forks after the oldest fork ID reported by any node. It also returns the number
+// of nodes reporting a fork ID not known to the chain configuration.
+func computeForkReadiness(entries []censusJSON, config *params.ChainConfig, genesis common.Hash) ([]forkReadiness, int) {
+	epochs := forkEpochs(config, genesis)
+	index := make(map[[4]byte]int, len(epochs))
+	for i, id := range epochs {
+		index[id.Hash] = i
+	}
+
+	type nodeFork struct {
+		epoch int
+		next  uint64
+	}
+	var (
+		nodes    []nodeFork
+		unknown  int
+		minEpoch = len(epochs)
+	)
+	for _, e := range entries {
+		if e.Status == nil || e.Status.Genesis != genesis {
+			continue
+		}
+		var hash [4]byte
+		copy(hash[:], e.Status.ForkHash)
+		epoch, ok := index[hash]
+		if !ok || len(e.Status.ForkHash) != len(hash) {
+			unknown++
+			continue
+		}
+		nodes = append(nodes, nodeFork{epoch, e.Status.ForkNext})
+		if epoch < minEpoch {
+			minEpoch = epoch
+		}
+	}
+
+	var result []forkReadiness
+	for i := minEpoch; i < len(epochs)-1; i++ {
+		r := forkReadiness{block: epochs[i].Next}
+		for _, n := range nodes {
+			switch {
+			case n.epoch < i:
+				r.behind++
+			case n.epoch > i:
+				r.passed++
+			case n.next == r.block:
+				r.ready++
+			case n.next == 0:
+				r.missing++
+			default:
+				r.conflict++
+			}
+		}
+		result = append(result, r)
+	}
+	return result, unknown
+}
+
+func printForkReadiness(readiness []forkReadiness) {
+	if len(readiness) == 0 {
+		fmt.Println("No scheduled forks ahead of the nodes.")
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Fork block", "Passed", "Ready", "Not scheduled", "Other fork", "Behind", "Readiness"})
+	for _, r := range readiness {
+		table.Append([]string{
+			strconv.FormatUint(r.block, 10),
+			strconv.Itoa(r.passed),
+			strconv.Itoa(r.ready),
+			strconv.Itoa(r.missing),
+			strconv.Itoa(r.conflict),
+			strconv.Itoa(r.behind),
+			percentage(r.passed+r.ready, r.total()),
+		})
+	}
+	table.Render()
+}
+
+// ipDatabase maps IP address ranges to autonomous systems and countries. It is
+// loaded from the ip2asn TSV format, which has one range per line:
+//
+//	range_start	range_end	AS_number	country_code	AS_description
+type ipDatabase struct {
+	ranges []ipRange // sorted by start address
+}
+
+type ipRange struct {
+	start, end net.IP // 16-byte form
+	asn        uint64
+	country    string
+	name       string
+}
+
+func loadIPDatabase(file string) (*ipDatabase, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return parseIPDatabase(r)
+}
+
+func parseIPDatabase(r io.Reader) (*ipDatabase, error) {
+	var (
+		db      = new(ipDatabase)
+		scanner = bufio.NewScanner(r)
+	)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: invalid range %q", line, scanner.Text())
+		}
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("line %d: invalid IP address", line)
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid AS number %q", line, fields[2])
+		}
+		if asn == 0 {
+			continue // not routed
+		}
+		rng := ipRange{start: start.To16(), end: end.To16(), asn: asn, country: fields[3]}
+		if len(fields) > 4 {
+			rng.name = fields[4]
+		}
+		db.ranges = append(db.ranges, rng)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+	return db, nil
+}
+
+// lookup returns the range containing ip, or nil if there is none.
+func (db *ipDatabase) lookup(ip net.IP) *ipRange {
+	ip = ip.To16()
+	if ip == nil {
+		return nil
+	}
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	})
+	if i == 0 || bytes.Compare(ip, db.ranges[i-1].end) > 0 {
+		return nil
+	}
+	return &db.ranges[i-1]
+}
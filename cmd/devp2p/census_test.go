@@ -0,0 +1,190 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of go-spacedogechain.
+//
+// go-spacedogechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-spacedogechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-spacedogechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/core/forkid"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/eth/protocols/eth"
+	"github.com/spacedogechain/go-spacedogechain/p2p"
+	"github.com/spacedogechain/go-spacedogechain/p2p/enode"
+	"github.com/spacedogechain/go-spacedogechain/params"
+)
+
+// This test checks that the census records the hello and status messages of a node.
+func TestCensusProbe(t *testing.T) {
+	status := &eth.StatusPacket{
+		ProtocolVersion: 67,
+		NetworkID:       1337,
+		TD:              big.NewInt(100),
+		Head:            common.Hash{1},
+		Genesis:         common.Hash{2},
+		ForkID:          forkid.ID{Hash: [4]byte{1, 2, 3, 4}, Next: 500},
+	}
+	srv := &p2p.Server{Config: p2p.Config{
+		PrivateKey:  newTestKey(t),
+		Name:        "Test/v1.2.3/linux",
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		NoDiscovery: true,
+		Protocols: []p2p.Protocol{{
+			Name:    eth.ProtocolName,
+			Version: 67,
+			Length:  17,
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				if err := p2p.Send(rw, eth.StatusMsg, status); err != nil {
+					return err
+				}
+				_, err := rw.ReadMsg()
+				return err
+			},
+		}},
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	prober := &censusProber{key: newTestKey(t), timeout: 5 * time.Second, workers: 2}
+	cs := prober.run([]*enode.Node{srv.Self()})
+	e := cs[srv.Self().ID()]
+	if e.Error != "" {
+		t.Fatal("handshake failed:", e.Error)
+	}
+	if e.Client != "Test/v1.2.3/linux" {
+		t.Errorf("wrong client %q", e.Client)
+	}
+	if want := []string{eth.ProtocolName + "/67"}; !reflect.DeepEqual(e.Caps, want) {
+		t.Errorf("wrong caps %v, want %v", e.Caps, want)
+	}
+	want := &censusStatus{
+		Version:   67,
+		NetworkID: 1337,
+		TD:        big.NewInt(100),
+		Head:      common.Hash{1},
+		Genesis:   common.Hash{2},
+		ForkHash:  []byte{1, 2, 3, 4},
+		ForkNext:  500,
+	}
+	if !reflect.DeepEqual(e.Status, want) {
+		t.Errorf("wrong status %+v", e.Status)
+	}
+}
+
+func TestParseClientName(t *testing.T) {
+	tests := []struct{ name, client, version string }{
+		{"Geth/v1.10.26-stable/linux-amd64/go1.18.5", "geth", "v1.10.26-stable"},
+		{"Geth/mynode/v1.10.25-stable-69568c55/linux-amd64/go1.18.5", "geth", "v1.10.25-stable-69568c55"},
+		{"Nethermind/v1.14.5+380bf9c2/linux-x64/dotnet6.0.10", "nethermind", "v1.14.5+380bf9c2"},
+		{"besu", "besu", "unknown"},
+	}
+	for _, test := range tests {
+		client, version := parseClientName(test.name)
+		if client != test.client || version != test.version {
+			t.Errorf("%q: got %s %s, want %s %s", test.name, client, version, test.client, test.version)
+		}
+	}
+}
+
+func TestForkReadiness(t *testing.T) {
+	var (
+		genesis = common.Hash{1}
+		config  = &params.ChainConfig{
+			ChainID:        big.NewInt(1),
+			HomesteadBlock: big.NewInt(10),
+			ByzantiumBlock: big.NewInt(20),
+		}
+		epochs = forkEpochs(config, genesis)
+	)
+	if len(epochs) != 3 || epochs[0].Next != 10 || epochs[1].Next != 20 || epochs[2].Next != 0 {
+		t.Fatalf("wrong fork epochs %v", epochs)
+	}
+	node := func(g common.Hash, hash [4]byte, next uint64) censusJSON {
+		return censusJSON{Status: &censusStatus{Genesis: g, ForkHash: hash[:], ForkNext: next}}
+	}
+	entries := []censusJSON{
+		node(genesis, epochs[0].Hash, 10),       // ready for the first fork
+		node(genesis, epochs[1].Hash, 20),       // ready for the second fork
+		node(genesis, epochs[1].Hash, 0),        // not ready for the second fork
+		node(genesis, epochs[1].Hash, 30),       // different fork scheduled
+		node(genesis, epochs[2].Hash, 0),        // passed all forks
+		node(genesis, [4]byte{1, 2, 3, 4}, 0),   // unknown fork ID
+		node(common.Hash{2}, epochs[0].Hash, 0), // other network
+	}
+	readiness, unknown := computeForkReadiness(entries, config, genesis)
+	want := []forkReadiness{
+		{block: 10, ready: 1, passed: 4},
+		{block: 20, behind: 1, ready: 1, missing: 1, conflict: 1, passed: 1},
+	}
+	if !reflect.DeepEqual(readiness, want) {
+		t.Errorf("wrong readiness:\nhave %+v\nwant %+v", readiness, want)
+	}
+	if unknown != 1 {
+		t.Errorf("wrong number of unknown nodes %d", unknown)
+	}
+}
+
+func TestIPDatabase(t *testing.T) {
+	tsv := strings.Join([]string{
+		"1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET",
+		"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed",
+		"1.0.4.0\t1.0.7.255\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd",
+		"2001:200::\t2001:200:5ff:ffff:ffff:ffff:ffff:ffff\t2500\tJP\tWIDE-BB WIDE Project",
+	}, "\n")
+	db, err := parseIPDatabase(strings.NewReader(tsv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip  string
+		asn uint64
+	}{
+		{"1.0.0.1", 13335},
+		{"1.0.2.1", 0},
+		{"1.0.5.5", 38803},
+		{"1.0.8.0", 0},
+		{"0.255.255.255", 0},
+		{"2001:200::1", 2500},
+		{"2001:201::1", 0},
+	}
+	for _, test := range tests {
+		r := db.lookup(net.ParseIP(test.ip))
+		switch {
+		case test.asn == 0 && r != nil:
+			t.Errorf("%s: found AS%d, want none", test.ip, r.asn)
+		case test.asn != 0 && (r == nil || r.asn != test.asn):
+			t.Errorf("%s: found %v, want AS%d", test.ip, r, test.asn)
+		}
+	}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -77,7 +77,7 @@ var (
 		Name:   "crawl",
 		Usage:  "Updates a nodes.json file with random nodes found in the DHT",
 		Action: discv4Crawl,
-		Flags:  flags.Merge(v4NodeFlags, []cli.Flag{crawlTimeoutFlag}),
+		Flags:  flags.Merge(v4NodeFlags, []cli.Flag{crawlTimeoutFlag, crawlCensusFlag, censusWorkersFlag, censusTimeoutFlag}),
 	}
 	discv4TestCommand = &cli.Command{
 		Name:   "test",
@@ -210,6 +210,9 @@ func discv4Crawl(ctx *cli.Context) error {
 	c.revalidateInterval = 10 * time.Minute
 	output := c.run(ctx.Duration(crawlTimeoutFlag.Name))
 	writeNodesJSON(nodesFile, output)
+	if ctx.IsSet(crawlCensusFlag.Name) {
+		writeCensusJSON(ctx.String(crawlCensusFlag.Name), runCensus(ctx, output.nodes()))
+	}
 	return nil
 }
 
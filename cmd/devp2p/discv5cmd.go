@@ -53,7 +53,7 @@ var (
 		Name:   "crawl",
 		Usage:  "Updates a nodes.json file with random nodes found in the DHT",
 		Action: discv5Crawl,
-		Flags:  []cli.Flag{bootnodesFlag, crawlTimeoutFlag},
+		Flags:  []cli.Flag{bootnodesFlag, crawlTimeoutFlag, crawlCensusFlag, censusWorkersFlag, censusTimeoutFlag},
 	}
 	discv5TestCommand = &cli.Command{
 		Name:   "test",
@@ -113,6 +113,9 @@ func discv5Crawl(ctx *cli.Context) error {
 	c.revalidateInterval = 10 * time.Minute
 	output := c.run(ctx.Duration(crawlTimeoutFlag.Name))
 	writeNodesJSON(nodesFile, output)
+	if ctx.IsSet(crawlCensusFlag.Name) {
+		writeCensusJSON(ctx.String(crawlCensusFlag.Name), runCensus(ctx, output.nodes()))
+	}
 	return nil
 }
 
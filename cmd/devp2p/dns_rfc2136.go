@@ -0,0 +1,509 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of go-spacedogechain.
+//
+// go-spacedogechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-spacedogechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-spacedogechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/log"
+	"github.com/spacedogechain/go-spacedogechain/p2p/dnsdisc"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// Messages are exchanged over TCP, which limits their size to 64kB. Change sets
+	// are split well below that to leave room for the TSIG record.
+	rfc2136ChangeSizeLimit = 48000
+	rfc2136Timeout         = 30 * time.Second
+
+	opcodeUpdate = dnsmessage.OpCode(5)
+)
+
+var (
+	rfc2136ServerFlag = &cli.StringFlag{
+		Name:  "server",
+		Usage: "Address of the primary DNS server accepting updates (host[:port])",
+	}
+	rfc2136ZoneFlag = &cli.StringFlag{
+		Name:  "zone",
+		Usage: "DNS zone containing the tree (optional, found via SOA lookup)",
+	}
+	rfc2136TSIGKeyFlag = &cli.StringFlag{
+		Name:  "tsig-key",
+		Usage: "Name of the TSIG key authenticating the updates",
+	}
+	rfc2136TSIGSecretFlag = &cli.StringFlag{
+		Name:    "tsig-secret",
+		Usage:   "Base64 encoded TSIG secret",
+		EnvVars: []string{"DNS_TSIG_SECRET"},
+	}
+	rfc2136TSIGAlgorithmFlag = &cli.StringFlag{
+		Name:  "tsig-algorithm",
+		Usage: "TSIG algorithm (hmac-sha1, hmac-sha256, hmac-sha512)",
+		Value: "hmac-sha256",
+	}
+)
+
+// rfc2136Client deploys trees to a DNS server supporting dynamic updates as
+// specified in RFC 2136. The existing records are loaded using a zone transfer.
+type rfc2136Client struct {
+	server  string
+	zone    string
+	key     *tsigKey // nil if messages are not signed
+	timeout time.Duration
+}
+
+// txtRecordSet holds the TXT records of a name. The character strings of each
+// record are joined.
+type txtRecordSet struct {
+	values []string
+	ttl    uint32
+}
+
+// rfc2136Change is a change of the TXT record set of a name. Existing record sets
+// are always replaced as a whole.
+type rfc2136Change struct {
+	action string // "add", "replace" or "delete"
+	name   string
+	ttl    uint32
+	value  string
+}
+
+// newRFC2136Client sets up a dynamic update client from command line flags.
+func newRFC2136Client(ctx *cli.Context) *rfc2136Client {
+	server := ctx.String(rfc2136ServerFlag.Name)
+	if server == "" {
+		exit(fmt.Errorf("need DNS server address to proceed"))
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	c := &rfc2136Client{
+		server:  server,
+		zone:    strings.TrimSuffix(ctx.String(rfc2136ZoneFlag.Name), "."),
+		timeout: rfc2136Timeout,
+	}
+	if name := ctx.String(rfc2136TSIGKeyFlag.Name); name != "" {
+		key, err := newTSIGKey(name, ctx.String(rfc2136TSIGAlgorithmFlag.Name), ctx.String(rfc2136TSIGSecretFlag.Name))
+		if err != nil {
+			exit(err)
+		}
+		c.key = key
+	} else {
+		log.Warn("No TSIG key given, sending unsigned updates")
+	}
+	return c
+}
+
+// deploy uploads the given tree to the DNS server.
+func (c *rfc2136Client) deploy(name string, t *dnsdisc.Tree) error {
+	if err := c.checkZone(name); err != nil {
+		return err
+	}
+
+	// Compute DNS changes.
+	existing, err := c.collectRecords(name)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Found %d TXT records", len(existing)))
+	records := t.ToTXT(name)
+	changes := computeRFC2136Changes(name, records, existing)
+
+	// Submit to server.
+	return c.submitChanges(changes)
+}
+
+// checkZone finds the zone of the given domain, unless it is configured.
+func (c *rfc2136Client) checkZone(name string) error {
+	if c.zone != "" {
+		if !isSubdomain(name, c.zone) {
+			return fmt.Errorf("name %q is not within zone %q", name, c.zone)
+		}
+		return nil
+	}
+	log.Info(fmt.Sprintf("Finding zone of %s on %s", name, c.server))
+	msg, err := newQuery(name, dnsmessage.TypeSOA)
+	if err != nil {
+		return err
+	}
+	err = c.exchange(msg, func(h dnsmessage.Header, p *dnsmessage.Parser) (bool, error) {
+		// The SOA record is in the answer if name is the zone apex, and in the
+		// authority section otherwise.
+		if h.RCode != dnsmessage.RCodeSuccess && h.RCode != dnsmessage.RCodeNameError {
+			return true, rcodeError(h.RCode)
+		}
+		if err := p.SkipAllQuestions(); err != nil {
+			return true, err
+		}
+		soa, err := findSOA(p.AnswerHeader, p.SkipAnswer)
+		if err != nil || soa != "" {
+			c.zone = soa
+			return true, err
+		}
+		c.zone, err = findSOA(p.AuthorityHeader, p.SkipAuthority)
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	if c.zone == "" || !isSubdomain(name, c.zone) {
+		return fmt.Errorf("can't find zone of %s", name)
+	}
+	return nil
+}
+
+// findSOA returns the owner name of the first SOA record in a message section.
+func findSOA(next func() (dnsmessage.ResourceHeader, error), skip func() error) (string, error) {
+	for {
+		h, err := next()
+		if err == dnsmessage.ErrSectionDone {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if h.Type == dnsmessage.TypeSOA {
+			return strings.TrimSuffix(h.Name.String(), "."), nil
+		}
+		if err := skip(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// collectRecords loads all TXT records below the given name using a zone transfer.
+func (c *rfc2136Client) collectRecords(name string) (map[string]txtRecordSet, error) {
+	log.Info("Loading existing TXT records", "name", name, "zone", c.zone)
+	msg, err := newQuery(c.zone, dnsmessage.TypeAXFR)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		existing = make(map[string]txtRecordSet)
+		soaCount int
+	)
+	err = c.exchange(msg, func(h dnsmessage.Header, p *dnsmessage.Parser) (bool, error) {
+		if h.RCode != dnsmessage.RCodeSuccess {
+			return true, rcodeError(h.RCode)
+		}
+		if err := p.SkipAllQuestions(); err != nil {
+			return true, err
+		}
+		// The transfer starts and ends with the SOA record of the zone.
+		for {
+			rh, err := p.AnswerHeader()
+			if err == dnsmessage.ErrSectionDone {
+				return false, nil
+			}
+			if err != nil {
+				return true, err
+			}
+			switch rh.Type {
+			case dnsmessage.TypeSOA:
+				if soaCount++; soaCount == 2 {
+					return true, nil
+				}
+				err = p.SkipAnswer()
+			case dnsmessage.TypeTXT:
+				var r dnsmessage.TXTResource
+				if r, err = p.TXTResource(); err != nil {
+					break
+				}
+				owner := strings.ToLower(strings.TrimSuffix(rh.Name.String(), "."))
+				if isSubdomain(owner, name) {
+					set := existing[owner]
+					set.values = append(set.values, strings.Join(r.TXT, ""))
+					set.ttl = rh.TTL
+					existing[owner] = set
+				}
+			default:
+				err = p.SkipAnswer()
+			}
+			if err != nil {
+				return true, err
+			}
+		}
+	})
+	return existing, err
+}
+
+// computeRFC2136Changes creates DNS changes for the given set of DNS discovery
+// records. The 'existing' arg is the set of records that already exist on the server.
+func computeRFC2136Changes(name string, records map[string]string, existing map[string]txtRecordSet) []rfc2136Change {
+	// Convert all names to lowercase.
+	lrecords := make(map[string]string, len(records))
+	for name, r := range records {
+		lrecords[strings.ToLower(name)] = r
+	}
+	records = lrecords
+
+	var changes []rfc2136Change
+	for path, newValue := range records {
+		prev, exists := existing[path]
+
+		// Assign TTL.
+		ttl := uint32(rootTTL)
+		if path != name {
+			ttl = uint32(treeNodeTTL)
+		}
+
+		if !exists {
+			// Entry is unknown, push a new one.
+			log.Info(fmt.Sprintf("Creating %s = %q", path, newValue))
+			changes = append(changes, rfc2136Change{"add", path, ttl, newValue})
+		} else if len(prev.values) != 1 || prev.values[0] != newValue || prev.ttl != ttl {
+			// Entry already exists, only change its content.
+			log.Info(fmt.Sprintf("Updating %s from %q to %q", path, strings.Join(prev.values, ","), newValue))
+			changes = append(changes, rfc2136Change{"replace", path, ttl, newValue})
+		} else {
+			log.Debug(fmt.Sprintf("Skipping %s = %q", path, newValue))
+		}
+	}
+
+	// Iterate over the old records and delete anything stale.
+	for path, set := range existing {
+		if _, ok := records[path]; ok {
+			continue
+		}
+		log.Info(fmt.Sprintf("Deleting %s = %q", path, strings.Join(set.values, ",")))
+		changes = append(changes, rfc2136Change{action: "delete", name: path})
+	}
+
+	// Ensure changes are in leaf-added -> root-changed -> leaf-deleted order.
+	score := map[string]int{"add": 1, "replace": 2, "delete": 3}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].action == changes[j].action {
+			return changes[i].name < changes[j].name
+		}
+		return score[changes[i].action] < score[changes[j].action]
+	})
+	return changes
+}
+
+// splitRFC2136Changes splits up DNS changes such that the update message of
+// each batch stays below the given size limit.
+func splitRFC2136Changes(changes []rfc2136Change, sizeLimit int) [][]rfc2136Change {
+	var (
+		batches   [][]rfc2136Change
+		batchSize int
+	)
+	for _, ch := range changes {
+		size := ch.size()
+		if len(batches) == 0 || batchSize+size > sizeLimit {
+			batches = append(batches, nil)
+			batchSize = 0
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], ch)
+		batchSize += size
+	}
+	return batches
+}
+
+// size estimates the encoded size of the change in an update message.
+func (ch rfc2136Change) size() int {
+	const rrHeaderSize = 10
+	size := len(ch.name) + 2 + rrHeaderSize
+	if ch.action != "delete" {
+		size += len(ch.name) + 2 + rrHeaderSize + len(ch.value) + len(ch.value)/255 + 1
+	}
+	return size
+}
+
+// submitChanges sends the given DNS changes to the server. Every batch of
+// changes is applied atomically by the server.
+func (c *rfc2136Client) submitChanges(changes []rfc2136Change) error {
+	if len(changes) == 0 {
+		log.Info("No DNS changes needed")
+		return nil
+	}
+	batches := splitRFC2136Changes(changes, rfc2136ChangeSizeLimit)
+	for i, batch := range batches {
+		log.Info(fmt.Sprintf("Submitting %d changes to %s (%d/%d)", len(batch), c.server, i+1, len(batches)))
+		msg, err := newUpdate(c.zone, batch)
+		if err != nil {
+			return err
+		}
+		err = c.exchange(msg, func(h dnsmessage.Header, p *dnsmessage.Parser) (bool, error) {
+			if h.RCode != dnsmessage.RCodeSuccess {
+				return true, rcodeError(h.RCode)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("update failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// exchange sends a message to the server over TCP and passes the responses to
+// handle, until it returns true. Zone transfers span multiple responses.
+func (c *rfc2136Client) exchange(msg *dnsmessage.Message, handle func(dnsmessage.Header, *dnsmessage.Parser) (bool, error)) error {
+	req, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	var verifier *tsigVerifier
+	if c.key != nil {
+		var mac []byte
+		req, mac = c.key.sign(req, nil, false, time.Now())
+		verifier = &tsigVerifier{key: c.key, prevMAC: mac}
+	}
+
+	conn, err := net.DialTimeout("tcp", c.server, c.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := writeTCPMessage(conn, req); err != nil {
+		return err
+	}
+	for {
+		resp, err := readTCPMessage(conn)
+		if err != nil {
+			return err
+		}
+		var p dnsmessage.Parser
+		h, err := p.Start(resp)
+		if err != nil {
+			return err
+		}
+		if h.ID != msg.Header.ID || !h.Response {
+			return errors.New("unexpected message from server")
+		}
+		if verifier != nil {
+			if err := verifier.verify(resp, time.Now()); err != nil {
+				// Errors such as BADSIG are reported by the server in unsigned responses.
+				if h.RCode != dnsmessage.RCodeSuccess {
+					return rcodeError(h.RCode)
+				}
+				return err
+			}
+		}
+		done, err := handle(h, &p)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	if len(msg) > 0xffff {
+		return errors.New("message too large")
+	}
+	buf := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	_, err := w.Write(append(buf, msg...))
+	return err
+}
+
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var size [2]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(size[:]))
+	_, err := io.ReadFull(r, msg)
+	return msg, err
+}
+
+// newQuery creates a query message.
+func newQuery(name string, typ dnsmessage.Type) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, err
+	}
+	return &dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: randomMessageID()},
+		Questions: []dnsmessage.Question{{Name: qname, Type: typ, Class: dnsmessage.ClassINET}},
+	}, nil
+}
+
+// newUpdate creates an update message for the given changes. Existing record
+// sets are deleted before adding the new record.
+func newUpdate(zone string, changes []rfc2136Change) (*dnsmessage.Message, error) {
+	msg, err := newQuery(zone, dnsmessage.TypeSOA)
+	if err != nil {
+		return nil, err
+	}
+	msg.Header.OpCode = opcodeUpdate
+	for _, ch := range changes {
+		name, err := dnsmessage.NewName(ch.name + ".")
+		if err != nil {
+			return nil, err
+		}
+		if ch.action != "add" {
+			msg.Authorities = append(msg.Authorities, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassANY},
+				Body:   &dnsmessage.TXTResource{},
+			})
+		}
+		if ch.action != "delete" {
+			msg.Authorities = append(msg.Authorities, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ch.ttl},
+				Body:   &dnsmessage.TXTResource{TXT: txtStrings(ch.value)},
+			})
+		}
+	}
+	return msg, nil
+}
+
+// txtStrings splits value into 255-character strings.
+func txtStrings(value string) []string {
+	var result []string
+	for len(value) > 255 {
+		result = append(result, value[:255])
+		value = value[255:]
+	}
+	return append(result, value)
+}
+
+func randomMessageID() uint16 {
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic("can't generate message ID: " + err.Error())
+	}
+	return binary.BigEndian.Uint16(id[:])
+}
+
+// rcodeError converts a response code into an error. The codes specific to
+// dynamic updates are not known to package dnsmessage.
+func rcodeError(rcode dnsmessage.RCode) error {
+	names := map[dnsmessage.RCode]string{
+		6:  "YXDOMAIN",
+		7:  "YXRRSET",
+		8:  "NXRRSET",
+		9:  "NOTAUTH",
+		10: "NOTZONE",
+	}
+	if name, ok := names[rcode]; ok {
+		return fmt.Errorf("server returned %s", name)
+	}
+	return fmt.Errorf("server returned %v", rcode)
+}
@@ -0,0 +1,343 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of go-spacedogechain.
+//
+// go-spacedogechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-spacedogechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-spacedogechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/p2p/dnsdisc"
+	"github.com/spacedogechain/go-spacedogechain/p2p/enode"
+	"github.com/spacedogechain/go-spacedogechain/p2p/enr"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// This test checks TSIG signing against a MAC computed independently.
+func TestTSIGSign(t *testing.T) {
+	key, err := newTSIGKey("test-key", "hmac-sha256", "c2VjcmV0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := hexutil.MustDecode("0x123400000001000000000000076578616d706c65036f72670000060001")
+	signed, mac := key.sign(msg, nil, false, time.Unix(1660000000, 0))
+
+	wantMAC := "808e565de17c019545bb1e816491f1d3558173a5eb473b0e43911cba6ba5159c"
+	if hex.EncodeToString(mac) != wantMAC {
+		t.Fatalf("wrong MAC %x, want %s", mac, wantMAC)
+	}
+	stripped, rec, keyName, err := splitTSIG(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stripped, msg) {
+		t.Errorf("wrong message after removing TSIG: %x", stripped)
+	}
+	if keyName != "test-key." || rec.algorithm != "hmac-sha256." || !bytes.Equal(rec.mac, mac) || rec.timeSigned != 1660000000 {
+		t.Errorf("wrong TSIG record %s %+v", keyName, rec)
+	}
+}
+
+// This test checks that computeRFC2136Changes creates minimal DNS changes in
+// leaf-added -> root-changed -> leaf-deleted order.
+func TestRFC2136Changes(t *testing.T) {
+	existing := map[string]txtRecordSet{
+		"n":                            {ttl: rootTTL, values: []string{"enrtree-root:v1 old"}},
+		"2xs2367yhaxjfglzhvawlqd4zy.n": {ttl: treeNodeTTL, values: []string{"enr:-A"}},
+		"fdxn3sn67na5dka4j2gok7bvqi.n": {ttl: treeNodeTTL, values: []string{"enrtree-branch:"}},
+		"h4fht4b454p6uxfd7jcyq5pwdy.n": {ttl: 3333, values: []string{"enr:-B"}},
+		"mhtdo6tmubria2xwg5ludack24.n": {ttl: treeNodeTTL, values: []string{"enr:-C", "enr:-D"}},
+	}
+	records := map[string]string{
+		"n":                            "enrtree-root:v1 new",
+		"2XS2367YHAXJFGLZHVAWLQD4ZY.n": "enr:-A",
+		"C7HRFPF3BLGF3YR4DY5KX3SMBE.n": "enrtree-branch:2XS2367YHAXJFGLZHVAWLQD4ZY",
+		"H4FHT4B454P6UXFD7JCYQ5PWDY.n": "enr:-B",
+		"MHTDO6TMUBRIA2XWG5LUDACK24.n": "enr:-C",
+	}
+	want := []rfc2136Change{
+		{"add", "c7hrfpf3blgf3yr4dy5kx3smbe.n", treeNodeTTL, "enrtree-branch:2XS2367YHAXJFGLZHVAWLQD4ZY"},
+		{"replace", "h4fht4b454p6uxfd7jcyq5pwdy.n", treeNodeTTL, "enr:-B"},
+		{"replace", "mhtdo6tmubria2xwg5ludack24.n", treeNodeTTL, "enr:-C"},
+		{"replace", "n", rootTTL, "enrtree-root:v1 new"},
+		{"delete", "fdxn3sn67na5dka4j2gok7bvqi.n", 0, ""},
+	}
+	changes := computeRFC2136Changes("n", records, existing)
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("wrong changes:\nhave %v\nwant %v", changes, want)
+	}
+
+	// Check splitting according to size.
+	wantSplit := [][]rfc2136Change{want[:1], want[1:3], want[3:]}
+	if split := splitRFC2136Changes(changes, 200); !reflect.DeepEqual(split, wantSplit) {
+		t.Fatalf("wrong split batches: got %d, want %d", len(split), len(wantSplit))
+	}
+}
+
+// This test deploys trees to an in-process DNS server.
+func TestRFC2136Deploy(t *testing.T) {
+	key, _ := newTSIGKey("deploy-key", "hmac-sha256", "c2VjcmV0")
+	srv := newTestDNSServer(t, "example.org", key)
+	defer srv.close()
+
+	// Existing records: an old tree and a record which doesn't belong to the tree.
+	srv.records["nodes.example.org"] = txtRecordSet{ttl: rootTTL, values: []string{"enrtree-root:v1 old"}}
+	srv.records["2kfjogvxdqtxxugbh7gs7naaai.nodes.example.org"] = txtRecordSet{ttl: treeNodeTTL, values: []string{"enr:-old"}}
+	srv.records["other.example.org"] = txtRecordSet{ttl: 60, values: []string{"unrelated"}}
+
+	client := &rfc2136Client{server: srv.addr(), key: key, timeout: 5 * time.Second}
+	tree := testTree(t, 20)
+	if err := client.deploy("nodes.example.org", tree); err != nil {
+		t.Fatal("deploy failed:", err)
+	}
+	if client.zone != "example.org" {
+		t.Errorf("wrong zone %q", client.zone)
+	}
+	want := map[string]txtRecordSet{"other.example.org": {ttl: 60, values: []string{"unrelated"}}}
+	for name, value := range tree.ToTXT("nodes.example.org") {
+		ttl := uint32(treeNodeTTL)
+		if name == "nodes.example.org" {
+			ttl = rootTTL
+		}
+		want[strings.ToLower(name)] = txtRecordSet{ttl: ttl, values: []string{value}}
+	}
+	if !reflect.DeepEqual(srv.records, want) {
+		t.Fatalf("wrong records after deploy:\nhave %v\nwant %v", srv.records, want)
+	}
+
+	// Deploying the same tree again doesn't change anything.
+	updates := srv.updates
+	if err := client.deploy("nodes.example.org", tree); err != nil {
+		t.Fatal("second deploy failed:", err)
+	}
+	if srv.updates != updates {
+		t.Fatal("unchanged tree was updated")
+	}
+
+	// Messages signed with the wrong key are rejected.
+	badKey, _ := newTSIGKey("deploy-key", "hmac-sha256", "d3Jvbmc=")
+	client = &rfc2136Client{server: srv.addr(), key: badKey, timeout: 5 * time.Second}
+	if err := client.deploy("nodes.example.org", testTree(t, 5)); err == nil || !strings.Contains(err.Error(), "NOTAUTH") {
+		t.Fatalf("wrong error for bad key: %v", err)
+	}
+}
+
+func testTree(t *testing.T, n int) *dnsdisc.Tree {
+	var nodes []*enode.Node
+	for i := 0; i < n; i++ {
+		var r enr.Record
+		r.Set(enr.IPv4{127, 0, 0, 1})
+		r.Set(enr.UDP(30303 + i))
+		key, _ := crypto.GenerateKey()
+		if err := enode.SignV4(&r, key); err != nil {
+			t.Fatal(err)
+		}
+		node, err := enode.New(enode.ValidSchemes, &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	tree, err := dnsdisc.MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.GenerateKey()
+	if _, err := tree.Sign(key, "nodes.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// testDNSServer is an authoritative server for a single zone, supporting queries
+// for the SOA record, zone transfers and dynamic updates over TCP.
+type testDNSServer struct {
+	t    *testing.T
+	zone string
+	key  *tsigKey
+	ln   net.Listener
+	wg   sync.WaitGroup
+
+	// These fields must not be accessed while a client is active.
+	records map[string]txtRecordSet
+	updates int
+}
+
+func newTestDNSServer(t *testing.T, zone string, key *tsigKey) *testDNSServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testDNSServer{t: t, zone: zone, key: key, ln: ln, records: make(map[string]txtRecordSet)}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+func (s *testDNSServer) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *testDNSServer) close() {
+	s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *testDNSServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		for {
+			req, err := readTCPMessage(conn)
+			if err != nil {
+				break
+			}
+			for _, resp := range s.handle(req) {
+				writeTCPMessage(conn, resp)
+			}
+		}
+		conn.Close()
+	}
+}
+
+// handle verifies and answers a request.
+func (s *testDNSServer) handle(req []byte) [][]byte {
+	stripped, rec, keyName, err := splitTSIG(req)
+	if err != nil || rec == nil {
+		s.t.Errorf("invalid request: %v", err)
+		return nil
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(stripped); err != nil {
+		s.t.Errorf("can't decode request: %v", err)
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.Header.ID, Response: true, OpCode: msg.Header.OpCode, Authoritative: true},
+		Questions: msg.Questions,
+	}
+	mac := s.key.mac(nil, stripped, rec.variables(keyName, false))
+	if canonicalName(keyName) != s.key.name || !bytes.Equal(mac, rec.mac) {
+		resp.Header.RCode = 9 // NOTAUTH
+		packed, _ := resp.Pack()
+		badsig := tsigRecord{algorithm: rec.algorithm, timeSigned: rec.timeSigned, fudge: tsigFudge, origID: msg.Header.ID, err: 16}
+		return [][]byte{badsig.appendTo(packed, keyName)}
+	}
+
+	q := msg.Questions[0]
+	switch {
+	case msg.Header.OpCode == opcodeUpdate:
+		s.update(msg.Authorities)
+	case q.Type == dnsmessage.TypeSOA:
+		if strings.TrimSuffix(q.Name.String(), ".") == s.zone {
+			resp.Answers = []dnsmessage.Resource{s.soa()}
+		} else {
+			resp.Header.RCode = dnsmessage.RCodeNameError
+			resp.Authorities = []dnsmessage.Resource{s.soa()}
+		}
+	case q.Type == dnsmessage.TypeAXFR:
+		return s.transfer(resp, rec.mac)
+	default:
+		resp.Header.RCode = dnsmessage.RCodeNotImplemented
+	}
+	packed, _ := resp.Pack()
+	signed, _ := s.key.sign(packed, rec.mac, false, time.Now())
+	return [][]byte{signed}
+}
+
+// transfer creates the responses of a zone transfer. The records are split across
+// three messages, the one in the middle is not signed.
+func (s *testDNSServer) transfer(resp dnsmessage.Message, reqMAC []byte) [][]byte {
+	var names []string
+	for name := range s.records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rrs := []dnsmessage.Resource{s.soa()}
+	for _, name := range names {
+		set := s.records[name]
+		for _, value := range set.values {
+			rrs = append(rrs, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name + "."), Class: dnsmessage.ClassINET, TTL: set.ttl},
+				Body:   &dnsmessage.TXTResource{TXT: txtStrings(value)},
+			})
+		}
+	}
+	rrs = append(rrs, s.soa())
+
+	var msgs [][]byte
+	for i, part := range [][]dnsmessage.Resource{rrs[:len(rrs)/3], rrs[len(rrs)/3 : 2*len(rrs)/3], rrs[2*len(rrs)/3:]} {
+		resp.Answers = part
+		if i > 0 {
+			resp.Questions = nil
+		}
+		packed, _ := resp.Pack()
+		msgs = append(msgs, packed)
+	}
+	first, mac := s.key.sign(msgs[0], reqMAC, false, time.Now())
+
+	// The last message covers the unsigned one before it.
+	rec := tsigRecord{algorithm: s.key.algorithm, timeSigned: uint64(time.Now().Unix()), fudge: tsigFudge, origID: resp.Header.ID}
+	rec.mac = s.key.mac(mac, append(msgs[1], msgs[2]...), rec.variables(s.key.name, true))
+	return [][]byte{first, msgs[1], rec.appendTo(msgs[2], s.key.name)}
+}
+
+// update applies the changes of an update message.
+func (s *testDNSServer) update(changes []dnsmessage.Resource) {
+	s.updates++
+	for _, rr := range changes {
+		name := strings.ToLower(strings.TrimSuffix(rr.Header.Name.String(), "."))
+		switch rr.Header.Class {
+		case dnsmessage.ClassANY:
+			delete(s.records, name)
+		case dnsmessage.ClassINET:
+			set := s.records[name]
+			set.ttl = rr.Header.TTL
+			set.values = append(set.values, strings.Join(rr.Body.(*dnsmessage.TXTResource).TXT, ""))
+			s.records[name] = set
+		default:
+			s.t.Errorf("unexpected update class %v", rr.Header.Class)
+		}
+	}
+}
+
+func (s *testDNSServer) soa() dnsmessage.Resource {
+	name := dnsmessage.MustNewName(s.zone + ".")
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: 3600},
+		Body: &dnsmessage.SOAResource{
+			NS:      dnsmessage.MustNewName("ns." + s.zone + "."),
+			MBox:    dnsmessage.MustNewName("admin." + s.zone + "."),
+			Serial:  1,
+			Refresh: 3600,
+			Retry:   600,
+			Expire:  86400,
+			MinTTL:  60,
+		},
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of go-spacedogechain.
+//
+// go-spacedogechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-spacedogechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-spacedogechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
+
+// This file implements transaction signatures (TSIG) as specified in RFC 8945.
+
+const (
+	typeTSIG     = 250
+	classANY     = 255
+	tsigFudge    = 300 // allowed clock skew in seconds
+	maxUnsigned  = 99  // maximum number of unsigned messages in a zone transfer
+	maxNameLinks = 64  // maximum number of compression pointers in a name
+)
+
+var tsigAlgorithms = map[string]func() hash.Hash{
+	"hmac-sha1.":   sha1.New,
+	"hmac-sha256.": sha256.New,
+	"hmac-sha512.": sha512.New,
+}
+
+var tsigErrors = map[uint16]string{
+	16: "BADSIG",
+	17: "BADKEY",
+	18: "BADTIME",
+	22: "BADTRUNC",
+}
+
+// tsigKey is a shared secret for signing messages.
+type tsigKey struct {
+	name      string // lowercase, fully qualified
+	algorithm string // lowercase, fully qualified
+	secret    []byte
+}
+
+// tsigRecord is the content of a TSIG resource record.
+type tsigRecord struct {
+	algorithm  string
+	timeSigned uint64 // 48 bit
+	fudge      uint16
+	mac        []byte
+	origID     uint16
+	err        uint16
+	other      []byte
+}
+
+func newTSIGKey(name, algorithm, secret string) (*tsigKey, error) {
+	algorithm = canonicalName(algorithm)
+	if _, ok := tsigAlgorithms[algorithm]; !ok {
+		return nil, fmt.Errorf("unsupported TSIG algorithm %q", strings.TrimSuffix(algorithm, "."))
+	}
+	if secret == "" {
+		return nil, errors.New("need TSIG secret to proceed")
+	}
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TSIG secret: %v", err)
+	}
+	return &tsigKey{name: canonicalName(name), algorithm: algorithm, secret: key}, nil
+}
+
+// canonicalName returns the lowercase, fully qualified form of a domain name.
+func canonicalName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+}
+
+// sign appends a TSIG record to the packed message and returns the signed message
+// along with its MAC. The MAC of the request must be given when signing responses.
+// Only the timers are covered for subsequent messages of a zone transfer.
+func (k *tsigKey) sign(msg []byte, prevMAC []byte, timersOnly bool, now time.Time) ([]byte, []byte) {
+	t := tsigRecord{
+		algorithm:  k.algorithm,
+		timeSigned: uint64(now.Unix()),
+		fudge:      tsigFudge,
+		origID:     binary.BigEndian.Uint16(msg),
+	}
+	t.mac = k.mac(prevMAC, msg, t.variables(k.name, timersOnly))
+	return t.appendTo(msg, k.name), t.mac
+}
+
+// mac computes the message authentication code of a message.
+func (k *tsigKey) mac(prevMAC []byte, msg []byte, variables []byte) []byte {
+	h := hmac.New(tsigAlgorithms[k.algorithm], k.secret)
+	if prevMAC != nil {
+		var size [2]byte
+		binary.BigEndian.PutUint16(size[:], uint16(len(prevMAC)))
+		h.Write(size[:])
+		h.Write(prevMAC)
+	}
+	h.Write(msg)
+	h.Write(variables)
+	return h.Sum(nil)
+}
+
+// variables encodes the TSIG variables covered by the MAC.
+func (t *tsigRecord) variables(keyName string, timersOnly bool) []byte {
+	var b []byte
+	if !timersOnly {
+		b = appendName(b, strings.ToLower(keyName))
+		b = appendUint16(b, classANY)
+		b = appendUint32(b, 0)
+		b = appendName(b, strings.ToLower(t.algorithm))
+	}
+	b = appendUint48(b, t.timeSigned)
+	b = appendUint16(b, t.fudge)
+	if !timersOnly {
+		b = appendUint16(b, t.err)
+		b = appendUint16(b, uint16(len(t.other)))
+		b = append(b, t.other...)
+	}
+	return b
+}
+
+// pack encodes the TSIG resource record.
+func (t *tsigRecord) pack(keyName string) []byte {
+	var rdata []byte
+	rdata = appendName(rdata, t.algorithm)
+	rdata = appendUint48(rdata, t.timeSigned)
+	rdata = appendUint16(rdata, t.fudge)
+	rdata = appendUint16(rdata, uint16(len(t.mac)))
+	rdata = append(rdata, t.mac...)
+	rdata = appendUint16(rdata, t.origID)
+	rdata = appendUint16(rdata, t.err)
+	rdata = appendUint16(rdata, uint16(len(t.other)))
+	rdata = append(rdata, t.other...)
+
+	b := appendName(nil, keyName)
+	b = appendUint16(b, typeTSIG)
+	b = appendUint16(b, classANY)
+	b = appendUint32(b, 0)
+	b = appendUint16(b, uint16(len(rdata)))
+	return append(b, rdata...)
+}
+
+// appendTo adds the TSIG record to the additional section of a packed message.
+func (t *tsigRecord) appendTo(msg []byte, keyName string) []byte {
+	signed := append(msg[:len(msg):len(msg)], t.pack(keyName)...)
+	binary.BigEndian.PutUint16(signed[10:], binary.BigEndian.Uint16(signed[10:])+1)
+	return signed
+}
+
+// tsigVerifier checks the signatures of the responses to a signed request.
+type tsigVerifier struct {
+	key      *tsigKey
+	prevMAC  []byte // MAC of the request or the last signed response
+	signed   bool   // whsdcer a response was verified already
+	unsigned []byte // unsigned responses since the last signed one
+	count    int
+}
+
+// verify checks the TSIG record of a response. Subsequent messages of a zone
+// transfer may be unsigned, they are covered by the next signed message.
+func (v *tsigVerifier) verify(msg []byte, now time.Time) error {
+	stripped, t, keyName, err := splitTSIG(msg)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		if !v.signed {
+			return errors.New("response is not signed")
+		}
+		if v.count++; v.count > maxUnsigned {
+			return errors.New("too many unsigned responses")
+		}
+		v.unsigned = append(v.unsigned, msg...)
+		return nil
+	}
+	if canonicalName(keyName) != v.key.name || canonicalName(t.algorithm) != v.key.algorithm {
+		return fmt.Errorf("response signed with unknown key %s", keyName)
+	}
+	if t.err != 0 {
+		return fmt.Errorf("TSIG error %s", tsigErrorString(t.err))
+	}
+	mac := v.key.mac(v.prevMAC, append(v.unsigned, stripped...), t.variables(keyName, v.signed))
+	if !hmac.Equal(mac, t.mac) {
+		return errors.New("invalid TSIG signature in response")
+	}
+	if skew := now.Unix() - int64(t.timeSigned); skew > int64(t.fudge) || -skew > int64(t.fudge) {
+		return errors.New("TSIG signature time outside of allowed window")
+	}
+	v.prevMAC, v.signed, v.unsigned, v.count = t.mac, true, nil, 0
+	return nil
+}
+
+func tsigErrorString(code uint16) string {
+	if name, ok := tsigErrors[code]; ok {
+		return name
+	}
+	return fmt.Sprint(code)
+}
+
+// splitTSIG removes the TSIG record from a message. It returns the message as it
+// was before signing, along with the record and its owner name. If the message
+// is not signed, the returned record is nil.
+func splitTSIG(msg []byte) ([]byte, *tsigRecord, string, error) {
+	if len(msg) < 12 {
+		return nil, nil, "", errors.New("message too short")
+	}
+	var (
+		qdcount = int(binary.BigEndian.Uint16(msg[4:]))
+		rrcount = int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:]))
+		arcount = int(binary.BigEndian.Uint16(msg[10:]))
+		off     = 12
+		err     error
+	)
+	if arcount == 0 {
+		return msg, nil, "", nil
+	}
+	// Skip to the last record, the TSIG record must be placed there.
+	for i := 0; i < qdcount && err == nil; i++ {
+		if _, off, err = readName(msg, off); err == nil {
+			off, err = skipBytes(msg, off, 4)
+		}
+	}
+	for i := 0; i < rrcount+arcount-1 && err == nil; i++ {
+		off, err = skipRecord(msg, off)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	start := off
+	keyName, off, err := readName(msg, off)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if len(msg) < off+10 {
+		return nil, nil, "", errors.New("truncated record")
+	}
+	if binary.BigEndian.Uint16(msg[off:]) != typeTSIG {
+		return msg, nil, "", nil
+	}
+	rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	if len(msg) != off+rdlen {
+		return nil, nil, "", errors.New("invalid TSIG record length")
+	}
+	t, err := parseTSIG(msg, off)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// Restore the message header as it was before signing.
+	stripped := make([]byte, start)
+	copy(stripped, msg)
+	binary.BigEndian.PutUint16(stripped, t.origID)
+	binary.BigEndian.PutUint16(stripped[10:], uint16(arcount-1))
+	return stripped, t, keyName, nil
+}
+
+// parseTSIG decodes the RDATA of a TSIG record, which ends the message.
+func parseTSIG(msg []byte, off int) (*tsigRecord, error) {
+	var (
+		t   tsigRecord
+		err error
+	)
+	if t.algorithm, off, err = readName(msg, off); err != nil {
+		return nil, err
+	}
+	if len(msg) < off+10 {
+		return nil, errors.New("truncated TSIG record")
+	}
+	t.timeSigned = uint64(binary.BigEndian.Uint16(msg[off:]))<<32 | uint64(binary.BigEndian.Uint32(msg[off+2:]))
+	t.fudge = binary.BigEndian.Uint16(msg[off+6:])
+	macSize := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	if len(msg) < off+macSize+6 {
+		return nil, errors.New("truncated TSIG record")
+	}
+	t.mac = msg[off : off+macSize]
+	off += macSize
+	t.origID = binary.BigEndian.Uint16(msg[off:])
+	t.err = binary.BigEndian.Uint16(msg[off+2:])
+	otherSize := int(binary.BigEndian.Uint16(msg[off+4:]))
+	off += 6
+	if len(msg) != off+otherSize {
+		return nil, errors.New("invalid TSIG record length")
+	}
+	t.other = msg[off:]
+	return &t, nil
+}
+
+// readName decodes a possibly compressed domain name at the given offset. It
+// returns the fully qualified name and the offset after it.
+func readName(msg []byte, off int) (string, int, error) {
+	var (
+		labels []string
+		next   = -1
+	)
+	for links := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errors.New("truncated name")
+		}
+		c := int(msg[off])
+		switch c & 0xc0 {
+		case 0x00:
+			if c == 0 {
+				if next < 0 {
+					next = off + 1
+				}
+				return strings.Join(labels, ".") + ".", next, nil
+			}
+			if off+1+c > len(msg) {
+				return "", 0, errors.New("truncated name")
+			}
+			labels = append(labels, string(msg[off+1:off+1+c]))
+			off += 1 + c
+		case 0xc0:
+			if off+2 > len(msg) {
+				return "", 0, errors.New("truncated name")
+			}
+			if links++; links > maxNameLinks {
+				return "", 0, errors.New("too many compression pointers")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		default:
+			return "", 0, errors.New("invalid label type")
+		}
+	}
+}
+
+// skipRecord returns the offset after the resource record at off.
+func skipRecord(msg []byte, off int) (int, error) {
+	_, off, err := readName(msg, off)
+	if err != nil {
+		return 0, err
+	}
+	if len(msg) < off+10 {
+		return 0, errors.New("truncated record")
+	}
+	return skipBytes(msg, off+10, int(binary.BigEndian.Uint16(msg[off+8:])))
+}
+
+func skipBytes(msg []byte, off, n int) (int, error) {
+	if len(msg) < off+n {
+		return 0, errors.New("truncated message")
+	}
+	return off + n, nil
+}
+
+// appendName appends the uncompressed wire encoding of a fully qualified name.
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label != "" {
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint48(b []byte, v uint64) []byte {
+	return append(b, byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -43,6 +43,7 @@ var (
 			dnsCloudflareCommand,
 			dnsRoute53Command,
 			dnsRoute53NukeCommand,
+			dnsRFC2136Command,
 		},
 	}
 	dnsSyncCommand = &cli.Command{
@@ -84,6 +85,19 @@ var (
 			route53RegionFlag,
 		},
 	}
+	dnsRFC2136Command = &cli.Command{
+		Name:      "to-rfc2136",
+		Usage:     "Deploy DNS TXT records to a DNS server using dynamic updates (RFC 2136)",
+		ArgsUsage: "<tree-directory>",
+		Action:    dnsToRFC2136,
+		Flags: []cli.Flag{
+			rfc2136ServerFlag,
+			rfc2136ZoneFlag,
+			rfc2136TSIGKeyFlag,
+			rfc2136TSIGSecretFlag,
+			rfc2136TSIGAlgorithmFlag,
+		},
+	}
 	dnsRoute53NukeCommand = &cli.Command{
 		Name:      "nuke-route53",
 		Usage:     "Deletes DNS TXT records of a subdomain on Amazon Route53",
@@ -228,6 +242,19 @@ func dnsToCloudflare(ctx *cli.Context) error {
 	return client.deploy(domain, t)
 }
 
+// dnsToRFC2136 performs dnsRFC2136Command.
+func dnsToRFC2136(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("need tree definition directory as argument")
+	}
+	domain, t, err := loadTreeDefinitionForExport(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	client := newRFC2136Client(ctx)
+	return client.deploy(domain, t)
+}
+
 // dnsToRoute53 performs dnsRoute53Command.
 func dnsToRoute53(ctx *cli.Context) error {
 	if ctx.NArg() != 1 {
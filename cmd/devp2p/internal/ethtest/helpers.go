@@ -46,6 +46,13 @@ var (
 // dial attempts to dial the given node and perform a handshake,
 // returning the created Conn if successful.
 func (s *Suite) dial() (*Conn, error) {
+	return s.dialAs(68)
+}
+
+// dialAs attempts to dial the given node and perform a handshake, advertising
+// eth protocol versions up to the given one. It is used to check that nodes
+// keep serving peers which don't support the latest version.
+func (s *Suite) dialAs(version uint) (*Conn, error) {
 	// dial
 	fd, err := net.Dial("tcp", fmt.Sprintf("%v:%d", s.Dest.IP(), s.Dest.TCP()))
 	if err != nil {
@@ -60,11 +67,12 @@ func (s *Suite) dial() (*Conn, error) {
 		return nil, err
 	}
 	// set default p2p capabilities
-	conn.caps = []p2p.Cap{
-		{Name: "eth", Version: 66},
-		{Name: "eth", Version: 67},
+	for _, v := range []uint{66, 67, 68} {
+		if v <= version {
+			conn.caps = append(conn.caps, p2p.Cap{Name: "eth", Version: v})
+		}
 	}
-	conn.ourHighestProtoVersion = 67
+	conn.ourHighestProtoVersion = version
 	return &conn, nil
 }
 
@@ -168,7 +176,7 @@ loop:
 			if have, want := msg.ForkID, chain.ForkID(); !reflect.DeepEqual(have, want) {
 				return nil, fmt.Errorf("wrong fork ID in status: have %v, want %v", have, want)
 			}
-			if have, want := msg.ProtocolVersion, c.ourHighestProtoVersion; have != uint32(want) {
+			if have, want := msg.ProtocolVersion, c.negotiatedProtoVersion; have != uint32(want) {
 				return nil, fmt.Errorf("wrong protocol version: have %v, want %v", have, want)
 			}
 			message = msg
@@ -359,6 +367,8 @@ func (s *Suite) waitAnnounce(conn *Conn, blockAnnouncement *NewBlock) error {
 			return nil
 
 		// ignore tx announcements from previous tests
+		case *NewPooledTransactionHashes66:
+			continue
 		case *NewPooledTransactionHashes:
 			continue
 		case *Transactions:
@@ -20,7 +20,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"time"
 
 	"github.com/spacedogechain/go-spacedogechain/common"
 	"github.com/spacedogechain/go-spacedogechain/crypto"
@@ -217,9 +219,9 @@ var (
 	emptyCode = common.HexToHash("c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470")
 )
 
-// TestSnapGetByteCodes various forms of GetByteCodes requests.
-func (s *Suite) TestSnapGetByteCodes(t *utesting.T) {
-	// The halfchain import should yield these bytecodes
+// halfchainBytecodes returns the hashes of the contract codes deployed in the
+// halfchain used by the snap tests.
+func halfchainBytecodes() []common.Hash {
 	var hcBytecodes []common.Hash
 	for _, s := range []string{
 		"0x200c90460d8b0063210d5f5b9918e053c8f2c024485e0f1b48be8b1fc71b1317",
@@ -250,6 +252,13 @@ func (s *Suite) TestSnapGetByteCodes(t *utesting.T) {
 	} {
 		hcBytecodes = append(hcBytecodes, common.HexToHash(s))
 	}
+	return hcBytecodes
+}
+
+// TestSnapGetByteCodes various forms of GetByteCodes requests.
+func (s *Suite) TestSnapGetByteCodes(t *utesting.T) {
+	// The halfchain import should yield these bytecodes
+	hcBytecodes := halfchainBytecodes()
 
 	for i, tc := range []byteCodesTest{
 		// A few stateroots
@@ -475,6 +484,222 @@ func (s *Suite) TestSnapTrieNodes(t *utesting.T) {
 	}
 }
 
+// TestSnapMalformedProofs checks that the proofs served with account ranges are
+// binding, i.e. that tampering with the response fails verification.
+func (s *Suite) TestSnapMalformedProofs(t *utesting.T) {
+	conn, err := s.dialSnap()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err = conn.peer(s.chain, nil); err != nil {
+		t.Fatalf("peering failed: %v", err)
+	}
+	req := &GetAccountRange{
+		ID:    uint64(rand.Int63()),
+		Root:  s.chain.RootAt(999),
+		Limit: common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+		Bytes: 4000,
+	}
+	resp, err := conn.snapRequest(req, req.ID, s.chain)
+	if err != nil {
+		t.Fatalf("account range request failed: %v", err)
+	}
+	res, ok := resp.(*AccountRange)
+	if !ok {
+		t.Fatalf("account range response wrong: %T %v", resp, resp)
+	}
+	hashes, accounts, err := (*snap.AccountRangePacket)(res).Unpack()
+	if err != nil {
+		t.Fatalf("invalid account range response: %v", err)
+	}
+	if len(hashes) < 3 || len(res.Proof) == 0 {
+		t.Fatalf("response too small: %d accounts, %d proof nodes", len(hashes), len(res.Proof))
+	}
+	if err := verifyAccountRange(req.Root, req.Origin, hashes, accounts, res.Proof); err != nil {
+		t.Fatalf("valid response rejected: %v", err)
+	}
+	for _, tc := range []struct {
+		name   string
+		tamper func(hashes []common.Hash, accounts, proof [][]byte) ([]common.Hash, [][]byte, [][]byte)
+	}{
+		{
+			name: "missing proof node",
+			tamper: func(hashes []common.Hash, accounts, proof [][]byte) ([]common.Hash, [][]byte, [][]byte) {
+				return hashes, accounts, proof[:len(proof)-1]
+			},
+		},
+		{
+			name: "corrupt proof node",
+			tamper: func(hashes []common.Hash, accounts, proof [][]byte) ([]common.Hash, [][]byte, [][]byte) {
+				proof[0] = common.CopyBytes(proof[0])
+				proof[0][len(proof[0])-1] ^= 0xff
+				return hashes, accounts, proof
+			},
+		},
+		{
+			name: "missing account",
+			tamper: func(hashes []common.Hash, accounts, proof [][]byte) ([]common.Hash, [][]byte, [][]byte) {
+				return append(hashes[:1], hashes[2:]...), append(accounts[:1], accounts[2:]...), proof
+			},
+		},
+		{
+			name: "modified account",
+			tamper: func(hashes []common.Hash, accounts, proof [][]byte) ([]common.Hash, [][]byte, [][]byte) {
+				accounts[1] = append(common.CopyBytes(accounts[1]), 0x00)
+				return hashes, accounts, proof
+			},
+		},
+		{
+			name: "unordered accounts",
+			tamper: func(hashes []common.Hash, accounts, proof [][]byte) ([]common.Hash, [][]byte, [][]byte) {
+				hashes[0], hashes[1] = hashes[1], hashes[0]
+				accounts[0], accounts[1] = accounts[1], accounts[0]
+				return hashes, accounts, proof
+			},
+		},
+	} {
+		// Tamper with copies, the original response is reused by all cases
+		h, a, p := tc.tamper(append([]common.Hash{}, hashes...), append([][]byte{}, accounts...), append([][]byte{}, res.Proof...))
+		if err := verifyAccountRange(req.Root, req.Origin, h, a, p); err == nil {
+			t.Errorf("%s: tampered response passed verification", tc.name)
+		}
+	}
+}
+
+// snapSoftResponseLimit is the maximum number of bytes a node is expected to
+// serve in a single snap response, regardless of the requested amount.
+const snapSoftResponseLimit = 2 * 1024 * 1024
+
+// TestSnapResponseSize checks that nodes don't serve oversized responses, i.e.
+// that only the last item of a reply may exceed the requested number of bytes.
+func (s *Suite) TestSnapResponseSize(t *utesting.T) {
+	conn, err := s.dialSnap()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err = conn.peer(s.chain, nil); err != nil {
+		t.Fatalf("peering failed: %v", err)
+	}
+	var (
+		root    = s.chain.RootAt(999)
+		ffHash  = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		account = common.HexToHash("0xf493f79c43bd747129a226ad42529885a4b108aba6046b2d12071695a6627844")
+		codes   = halfchainBytecodes()
+	)
+	for _, limit := range []uint64{0, 1, 100, 500, 1000, 4000, math.MaxUint64} {
+		// Account ranges
+		resp, err := conn.snapRequest(&GetAccountRange{ID: 1, Root: root, Limit: ffHash, Bytes: limit}, 1, s.chain)
+		if err != nil {
+			t.Fatalf("account range request failed: %v", err)
+		}
+		if res, ok := resp.(*AccountRange); !ok {
+			t.Errorf("account range response wrong: %T %v", resp, resp)
+		} else {
+			sizes := make([]uint64, len(res.Accounts))
+			for i, acc := range res.Accounts {
+				sizes[i] = common.HashLength + uint64(len(acc.Body))
+			}
+			if err := checkResponseSize(sizes, limit, 0); err != nil {
+				t.Errorf("account range, %d bytes requested: %v", limit, err)
+			}
+		}
+		// Storage ranges, which may overshoot slightly to avoid aborting mid-trie
+		resp, err = conn.snapRequest(&GetStorageRanges{ID: 2, Root: root, Accounts: []common.Hash{account}, Limit: ffHash[:], Bytes: limit}, 2, s.chain)
+		if err != nil {
+			t.Fatalf("storage ranges request failed: %v", err)
+		}
+		if res, ok := resp.(*StorageRanges); !ok {
+			t.Errorf("storage ranges response wrong: %T %v", resp, resp)
+		} else {
+			var sizes []uint64
+			for _, slots := range res.Slots {
+				for _, slot := range slots {
+					sizes = append(sizes, common.HashLength+uint64(len(slot.Body)))
+				}
+			}
+			if err := checkResponseSize(sizes, limit, 0.1); err != nil {
+				t.Errorf("storage ranges, %d bytes requested: %v", limit, err)
+			}
+		}
+		// Bytecodes
+		resp, err = conn.snapRequest(&GetByteCodes{ID: 3, Hashes: codes, Bytes: limit}, 3, s.chain)
+		if err != nil {
+			t.Fatalf("bytecodes request failed: %v", err)
+		}
+		if res, ok := resp.(*ByteCodes); !ok {
+			t.Errorf("bytecodes response wrong: %T %v", resp, resp)
+		} else {
+			sizes := make([]uint64, len(res.Codes))
+			for i, code := range res.Codes {
+				sizes[i] = uint64(len(code))
+			}
+			if err := checkResponseSize(sizes, limit, 0); err != nil {
+				t.Errorf("bytecodes, %d bytes requested: %v", limit, err)
+			}
+		}
+	}
+}
+
+// checkResponseSize checks that the items of a snap response, except for the
+// last one, fit into the requested number of bytes plus the allowed slack.
+func checkResponseSize(sizes []uint64, limit uint64, slack float64) error {
+	if limit > snapSoftResponseLimit {
+		limit = snapSoftResponseLimit
+	}
+	limit = uint64(float64(limit) * (1 + slack))
+
+	var total uint64
+	for i := 0; i < len(sizes)-1; i++ {
+		total += sizes[i]
+	}
+	if total > limit {
+		return fmt.Errorf("response of %d items too large: %d bytes before the last item, limit %d", len(sizes), total, limit)
+	}
+	return nil
+}
+
+// TestSnapRequestIDs checks that nodes answer pipelined snap requests with the
+// request IDs they were sent with, including the edges of the ID space.
+func (s *Suite) TestSnapRequestIDs(t *utesting.T) {
+	conn, err := s.dialSnap()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err = conn.peer(s.chain, nil); err != nil {
+		t.Fatalf("peering failed: %v", err)
+	}
+	pending := make(map[uint64]bool)
+	for _, id := range []uint64{0, 1, 1 << 63, math.MaxUint64, uint64(rand.Int63())} {
+		req := &GetByteCodes{ID: id, Hashes: []common.Hash{emptyCode}, Bytes: 1000}
+		if err := conn.Write(req); err != nil {
+			t.Fatalf("could not write to connection: %v", err)
+		}
+		pending[id] = true
+	}
+	defer conn.SetReadDeadline(time.Time{})
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(pending) > 0 {
+		msg, err := conn.readSnapResponse()
+		if err != nil {
+			t.Fatalf("%d requests unanswered: %v", len(pending), err)
+		}
+		res, ok := msg.(*ByteCodes)
+		if !ok {
+			t.Fatalf("bytecodes response wrong: %T %v", msg, msg)
+		}
+		if !pending[res.ID] {
+			t.Fatalf("response with unknown or duplicate request ID %d", res.ID)
+		}
+		delete(pending, res.ID)
+		if len(res.Codes) != 1 {
+			t.Errorf("request %d: expected 1 bytecode, got %d", res.ID, len(res.Codes))
+		}
+	}
+}
+
 func (s *Suite) snapGetAccountRange(t *utesting.T, tc *accRangeTest) error {
 	conn, err := s.dialSnap()
 	if err != nil {
@@ -531,7 +756,12 @@ func (s *Suite) snapGetAccountRange(t *utesting.T, tc *accRangeTest) error {
 			return fmt.Errorf("expected last account %#x, got %#x", exp, got)
 		}
 	}
-	// Reconstruct a partial trie from the response and verify it
+	return verifyAccountRange(tc.root, tc.origin, hashes, accounts, proof)
+}
+
+// verifyAccountRange reconstructs a partial trie from an account range response
+// and verifies it against the state root.
+func verifyAccountRange(root, origin common.Hash, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
 	keys := make([][]byte, len(hashes))
 	for i, key := range hashes {
 		keys[i] = common.CopyBytes(key[:])
@@ -546,7 +776,7 @@ func (s *Suite) snapGetAccountRange(t *utesting.T, tc *accRangeTest) error {
 	if len(keys) > 0 {
 		end = keys[len(keys)-1]
 	}
-	_, err = trie.VerifyRangeProof(tc.root, tc.origin[:], end, keys, accounts, proofdb)
+	_, err := trie.VerifyRangeProof(root, origin[:], end, keys, accounts, proofdb)
 	return err
 }
 
@@ -17,9 +17,11 @@
 package ethtest
 
 import (
+	"strings"
 	"time"
 
 	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/core/types"
 	"github.com/spacedogechain/go-spacedogechain/eth/protocols/eth"
 	"github.com/spacedogechain/go-spacedogechain/internal/utesting"
 	"github.com/spacedogechain/go-spacedogechain/p2p/enode"
@@ -73,6 +75,8 @@ func (s *Suite) EthTests() []utesting.Test {
 		{Name: "TestMaliciousTx", Fn: s.TestMaliciousTx},
 		{Name: "TestLargeTxRequest", Fn: s.TestLargeTxRequest},
 		{Name: "TestNewPooledTxs", Fn: s.TestNewPooledTxs},
+		{Name: "TestNewPooledTxs66", Fn: s.TestNewPooledTxs66},
+		{Name: "TestMalformedTxAnnounce", Fn: s.TestMalformedTxAnnounce},
 	}
 }
 
@@ -83,6 +87,9 @@ func (s *Suite) SnapTests() []utesting.Test {
 		{Name: "TestSnapGetByteCodes", Fn: s.TestSnapGetByteCodes},
 		{Name: "TestSnapGetTrieNodes", Fn: s.TestSnapTrieNodes},
 		{Name: "TestSnapGetStorageRanges", Fn: s.TestSnapGetStorageRanges},
+		{Name: "TestSnapMalformedProofs", Fn: s.TestSnapMalformedProofs},
+		{Name: "TestSnapResponseSize", Fn: s.TestSnapResponseSize},
+		{Name: "TestSnapRequestIDs", Fn: s.TestSnapRequestIDs},
 	}
 }
 
@@ -501,8 +508,19 @@ func (s *Suite) TestLargeTxRequest(t *utesting.T) {
 }
 
 // TestNewPooledTxs tests whether a node will do a GetPooledTransactions
-// request upon receiving a NewPooledTransactionHashes announcement.
+// request upon receiving a NewPooledTransactionHashes announcement in the
+// format of the negotiated protocol version.
 func (s *Suite) TestNewPooledTxs(t *utesting.T) {
+	s.testNewPooledTxs(t, 68)
+}
+
+// TestNewPooledTxs66 is like TestNewPooledTxs, but negotiates eth/66 and
+// announces transactions without their types and sizes.
+func (s *Suite) TestNewPooledTxs66(t *utesting.T) {
+	s.testNewPooledTxs(t, 66)
+}
+
+func (s *Suite) testNewPooledTxs(t *utesting.T, version uint) {
 	// send the next block to ensure the node is no longer syncing and
 	// is able to accept txs
 	if err := s.sendNextBlock(); err != nil {
@@ -510,20 +528,13 @@ func (s *Suite) TestNewPooledTxs(t *utesting.T) {
 	}
 
 	// generate 50 txs
-	hashMap, _, err := generateTxs(s, 50)
+	_, txs, err := generateTxs(s, 50)
 	if err != nil {
 		t.Fatalf("failed to generate transactions: %v", err)
 	}
 
-	// create new pooled tx hashes announcement
-	hashes := make([]common.Hash, 0)
-	for _, hash := range hashMap {
-		hashes = append(hashes, hash)
-	}
-	announce := NewPooledTransactionHashes(hashes)
-
 	// send announcement
-	conn, err := s.dial()
+	conn, err := s.dialAs(version)
 	if err != nil {
 		t.Fatalf("dial failed: %v", err)
 	}
@@ -531,7 +542,7 @@ func (s *Suite) TestNewPooledTxs(t *utesting.T) {
 	if err = conn.peer(s.chain, nil); err != nil {
 		t.Fatalf("peering failed: %v", err)
 	}
-	if err = conn.Write(announce); err != nil {
+	if err = conn.Write(newPooledTxsAnnounce(conn, txs)); err != nil {
 		t.Fatalf("failed to write to connection: %v", err)
 	}
 
@@ -540,12 +551,14 @@ func (s *Suite) TestNewPooledTxs(t *utesting.T) {
 		msg := conn.readAndServe(s.chain, timeout)
 		switch msg := msg.(type) {
 		case *GetPooledTransactions:
-			if len(msg.GetPooledTransactionsPacket) != len(hashes) {
-				t.Fatalf("unexpected number of txs requested: wanted %d, got %d", len(hashes), len(msg.GetPooledTransactionsPacket))
+			if len(msg.GetPooledTransactionsPacket) != len(txs) {
+				t.Fatalf("unexpected number of txs requested: wanted %d, got %d", len(txs), len(msg.GetPooledTransactionsPacket))
 			}
 			return
 
 		// ignore propagated txs from previous tests
+		case *NewPooledTransactionHashes66:
+			continue
 		case *NewPooledTransactionHashes:
 			continue
 		case *Transactions:
@@ -561,3 +574,74 @@ func (s *Suite) TestNewPooledTxs(t *utesting.T) {
 		}
 	}
 }
+
+// TestMalformedTxAnnounce tests that a node disconnects peers sending eth/68
+// transaction announcements whose types and sizes don't match the hashes.
+func (s *Suite) TestMalformedTxAnnounce(t *utesting.T) {
+	// send the next block to ensure the node is no longer syncing and
+	// is able to accept txs
+	if err := s.sendNextBlock(); err != nil {
+		t.Fatalf("failed to send next block: %v", err)
+	}
+	_, txs, err := generateTxs(s, 2)
+	if err != nil {
+		t.Fatalf("failed to generate transactions: %v", err)
+	}
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err = conn.peer(s.chain, nil); err != nil {
+		t.Fatalf("peering failed: %v", err)
+	}
+	if conn.negotiatedProtoVersion < 68 {
+		t.Logf("node does not support eth/68 (negotiated eth/%d), skipping", conn.negotiatedProtoVersion)
+		return
+	}
+	announce := newPooledTxsAnnounce(conn, txs).(*NewPooledTransactionHashes)
+	announce.Types = announce.Types[:1]
+	if err = conn.Write(announce); err != nil {
+		t.Fatalf("failed to write to connection: %v", err)
+	}
+
+	// wait for the node to drop the connection
+	for {
+		switch msg := conn.readAndServe(s.chain, timeout).(type) {
+		case *Disconnect:
+			return
+		case *Error:
+			if !strings.Contains(msg.String(), "timeout") {
+				return
+			}
+			t.Fatalf("node did not disconnect after malformed announcement")
+
+		// ignore announcements from previous tests
+		case *NewPooledTransactionHashes, *Transactions, *NewBlockHashes, *NewBlock:
+			continue
+		case *GetPooledTransactions:
+			t.Fatalf("node requested transactions of a malformed announcement")
+		default:
+			t.Fatalf("unexpected %s", pretty.Sdump(msg))
+		}
+	}
+}
+
+// newPooledTxsAnnounce creates an announcement of the given transactions in the
+// format of the protocol version negotiated on the connection.
+func newPooledTxsAnnounce(conn *Conn, txs []*types.Transaction) Message {
+	if conn.negotiatedProtoVersion < 68 {
+		hashes := make(NewPooledTransactionHashes66, len(txs))
+		for i, tx := range txs {
+			hashes[i] = tx.Hash()
+		}
+		return &hashes
+	}
+	announce := new(NewPooledTransactionHashes)
+	for _, tx := range txs {
+		announce.Types = append(announce.Types, tx.Type())
+		announce.Sizes = append(announce.Sizes, uint32(tx.Size()))
+		announce.Hashes = append(announce.Hashes, tx.Hash())
+	}
+	return announce
+}
@@ -95,7 +95,7 @@ func sendSuccessfulTx(s *Suite, tx *types.Transaction, prevTx *types.Transaction
 				}
 			}
 			return fmt.Errorf("missing transaction: got %v missing %v", recTxs, tx.Hash())
-		case *NewPooledTransactionHashes:
+		case *NewPooledTransactionHashes66:
 			txHashes := *msg
 			// if you receive an old tx propagation, read from connection again
 			if len(txHashes) == 1 && prevTx != nil {
@@ -110,6 +110,30 @@ func sendSuccessfulTx(s *Suite, tx *types.Transaction, prevTx *types.Transaction
 				}
 			}
 			return fmt.Errorf("missing transaction announcement: got %v missing %v", txHashes, tx.Hash())
+		case *NewPooledTransactionHashes:
+			if err := checkTxAnnounce(msg); err != nil {
+				return err
+			}
+			txHashes := msg.Hashes
+			// if you receive an old tx propagation, read from connection again
+			if len(txHashes) == 1 && prevTx != nil {
+				if txHashes[0] == prevTx.Hash() {
+					continue
+				}
+			}
+			for i, gotHash := range txHashes {
+				if gotHash == tx.Hash() {
+					// The announced metadata must describe the transaction
+					if msg.Types[i] != tx.Type() {
+						return fmt.Errorf("wrong type in announcement of %v: have %d, want %d", tx.Hash(), msg.Types[i], tx.Type())
+					}
+					if msg.Sizes[i] != uint32(tx.Size()) {
+						return fmt.Errorf("wrong size in announcement of %v: have %d, want %d", tx.Hash(), msg.Sizes[i], uint32(tx.Size()))
+					}
+					return nil
+				}
+			}
+			return fmt.Errorf("missing transaction announcement: got %v missing %v", txHashes, tx.Hash())
 		default:
 			return fmt.Errorf("unexpected message in sendSuccessfulTx: %s", pretty.Sdump(msg))
 		}
@@ -201,8 +225,13 @@ func sendMultipleSuccessfulTxs(t *utesting.T, s *Suite, txs []*types.Transaction
 			for _, tx := range *msg {
 				recvHashes = append(recvHashes, tx.Hash())
 			}
-		case *NewPooledTransactionHashes:
+		case *NewPooledTransactionHashes66:
 			recvHashes = append(recvHashes, *msg...)
+		case *NewPooledTransactionHashes:
+			if err := checkTxAnnounce(msg); err != nil {
+				return err
+			}
+			recvHashes = append(recvHashes, msg.Hashes...)
 		default:
 			if !strings.Contains(pretty.Sdump(msg), "i/o timeout") {
 				return fmt.Errorf("unexpected message while waiting to receive txs: %s", pretty.Sdump(msg))
@@ -246,11 +275,16 @@ func checkMaliciousTxPropagation(s *Suite, txs []*types.Transaction, conn *Conn)
 		if len(badTxs) > 0 {
 			return fmt.Errorf("received %d bad txs: \n%v", len(badTxs), badTxs)
 		}
-	case *NewPooledTransactionHashes:
+	case *NewPooledTransactionHashes66:
 		badTxs, _ := compareReceivedTxs(*msg, txs)
 		if len(badTxs) > 0 {
 			return fmt.Errorf("received %d bad txs: \n%v", len(badTxs), badTxs)
 		}
+	case *NewPooledTransactionHashes:
+		badTxs, _ := compareReceivedTxs(msg.Hashes, txs)
+		if len(badTxs) > 0 {
+			return fmt.Errorf("received %d bad txs: \n%v", len(badTxs), badTxs)
+		}
 	case *Error:
 		// Transaction should not be announced -> wait for timeout
 		return nil
@@ -260,6 +294,15 @@ func checkMaliciousTxPropagation(s *Suite, txs []*types.Transaction, conn *Conn)
 	return nil
 }
 
+// checkTxAnnounce checks that an eth/68 transaction announcement carries a type
+// and a size for every announced hash.
+func checkTxAnnounce(msg *NewPooledTransactionHashes) error {
+	if len(msg.Types) != len(msg.Hashes) || len(msg.Sizes) != len(msg.Hashes) {
+		return fmt.Errorf("malformed transaction announcement: %d hashes, %d types, %d sizes", len(msg.Hashes), len(msg.Types), len(msg.Sizes))
+	}
+	return nil
+}
+
 // compareReceivedTxs compares the received set of txs against the given set of txs,
 // returning both the set received txs that were present within the given txs, and
 // the set of txs that were missing from the set of received txs
@@ -126,8 +126,16 @@ type NewBlock eth.NewBlockPacket
 func (msg NewBlock) Code() int     { return 23 }
 func (msg NewBlock) ReqID() uint64 { return 0 }
 
-// NewPooledTransactionHashes is the network packet for the tx hash propagation message.
-type NewPooledTransactionHashes eth.NewPooledTransactionHashesPacket
+// NewPooledTransactionHashes66 is the network packet for the tx hash propagation message
+// on eth/66 and eth/67.
+type NewPooledTransactionHashes66 eth.NewPooledTransactionHashesPacket66
+
+func (msg NewPooledTransactionHashes66) Code() int     { return 24 }
+func (msg NewPooledTransactionHashes66) ReqID() uint64 { return 0 }
+
+// NewPooledTransactionHashes is the network packet for the tx hash propagation message
+// on eth/68, which carries the type and size of every announced transaction.
+type NewPooledTransactionHashes eth.NewPooledTransactionHashesPacket68
 
 func (msg NewPooledTransactionHashes) Code() int     { return 24 }
 func (msg NewPooledTransactionHashes) ReqID() uint64 { return 0 }
@@ -203,7 +211,12 @@ func (c *Conn) Read() Message {
 	case (Transactions{}).Code():
 		msg = new(Transactions)
 	case (NewPooledTransactionHashes{}).Code():
-		msg = new(NewPooledTransactionHashes)
+		// The announcement format depends on the negotiated protocol version.
+		if c.negotiatedProtoVersion < 68 {
+			msg = new(NewPooledTransactionHashes66)
+		} else {
+			msg = new(NewPooledTransactionHashes)
+		}
 	case (GetPooledTransactions{}.Code()):
 		ethMsg := new(eth.GetPooledTransactionsPacket66)
 		if err := rlp.DecodeBytes(rawData, ethMsg); err != nil {
@@ -240,10 +253,23 @@ func (c *Conn) Write(msg Message) error {
 }
 
 // ReadSnap reads a snap/1 response with the given id from the connection.
+// A response carrying any other request ID is reported as an error.
 func (c *Conn) ReadSnap(id uint64) (Message, error) {
-	respId := id + 1
+	msg, err := c.readSnapResponse()
+	if err != nil {
+		return nil, err
+	}
+	if msg.ReqID() != id {
+		return nil, fmt.Errorf("request ID mismatch: sent %d, got %d (%T)", id, msg.ReqID(), msg)
+	}
+	return msg, nil
+}
+
+// readSnapResponse reads the next snap/1 message from the connection, skipping
+// over any messages of other protocols.
+func (c *Conn) readSnapResponse() (Message, error) {
 	start := time.Now()
-	for respId != id && time.Since(start) < timeout {
+	for time.Since(start) < timeout {
 		code, rawData, _, err := c.Conn.Read()
 		if err != nil {
 			return nil, fmt.Errorf("could not read from connection: %v", err)
@@ -25,6 +25,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/spacedogechain/go-spacedogechain/common"
 	"github.com/spacedogechain/go-spacedogechain/core/forkid"
 	"github.com/spacedogechain/go-spacedogechain/p2p/enr"
 	"github.com/spacedogechain/go-spacedogechain/params"
@@ -224,22 +225,30 @@ func minAgeFilter(args []string) (nodeFilter, error) {
 	return f, nil
 }
 
-func ethFilter(args []string) (nodeFilter, error) {
-	var filter forkid.Filter
-	switch args[0] {
+// networkConfig returns the chain configuration and genesis hash of a network.
+func networkConfig(name string) (*params.ChainConfig, common.Hash, error) {
+	switch name {
 	case "mainnet":
-		filter = forkid.NewStaticFilter(params.MainnetChainConfig, params.MainnetGenesisHash)
+		return params.MainnetChainConfig, params.MainnetGenesisHash, nil
 	case "rinkeby":
-		filter = forkid.NewStaticFilter(params.RinkebyChainConfig, params.RinkebyGenesisHash)
+		return params.RinkebyChainConfig, params.RinkebyGenesisHash, nil
 	case "goerli":
-		filter = forkid.NewStaticFilter(params.GoerliChainConfig, params.GoerliGenesisHash)
+		return params.GoerliChainConfig, params.GoerliGenesisHash, nil
 	case "ropsten":
-		filter = forkid.NewStaticFilter(params.RopstenChainConfig, params.RopstenGenesisHash)
+		return params.RopstenChainConfig, params.RopstenGenesisHash, nil
 	case "sepolia":
-		filter = forkid.NewStaticFilter(params.SepoliaChainConfig, params.SepoliaGenesisHash)
+		return params.SepoliaChainConfig, params.SepoliaGenesisHash, nil
 	default:
-		return nil, fmt.Errorf("unknown network %q", args[0])
+		return nil, common.Hash{}, fmt.Errorf("unknown network %q", name)
+	}
+}
+
+func ethFilter(args []string) (nodeFilter, error) {
+	config, genesis, err := networkConfig(args[0])
+	if err != nil {
+		return nil, err
 	}
+	filter := forkid.NewStaticFilter(config, genesis)
 
 	f := func(n nodeJSON) bool {
 		var eth struct {
@@ -29,6 +29,20 @@ import (
 )
 
 var (
+	keyFormatFlag = &cli.IntFlag{
+		Name:  "keyformat",
+		Usage: "Key file format version to migrate accounts to (3 or 4)",
+		Value: 4,
+	}
+	scryptNFlag = &cli.IntFlag{
+		Name:  "scrypt.n",
+		Usage: "Scrypt N parameter of the migrated key files (default: standard or light KDF)",
+	}
+	scryptPFlag = &cli.IntFlag{
+		Name:  "scrypt.p",
+		Usage: "Scrypt P parameter of the migrated key files (default: standard or light KDF)",
+	}
+
 	walletCommand = &cli.Command{
 		Name:      "wallet",
 		Usage:     "Manage spacedogechain presale wallets",
@@ -149,6 +163,39 @@ For non-interactive use the password can be specified with the --password flag:
 
 Since only one password can be given, only format update can be performed,
 changing your password is only possible interactively.
+`,
+			},
+			{
+				Name:      "migrate",
+				Usage:     "Re-encrypt existing accounts with a new key file format or KDF parameters",
+				Action:    accountMigrate,
+				ArgsUsage: "[<address> ...]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					keyFormatFlag,
+					scryptNFlag,
+					scryptPFlag,
+				},
+				Description: `
+    geth account migrate [options] [<address> ...]
+
+Re-encrypts the given accounts, or all accounts of the keystore if none are
+given, in the key file format selected by --keyformat:
+
+    3: Web3 Secret Storage (the format geth has been writing so far)
+    4: EIP-2335 style, with Unicode password normalization
+
+The keys are encrypted with scrypt, using the standard parameters, or the light
+ones with --lightkdf. They can be overridden with --scrypt.n and --scrypt.p.
+
+Passwords are kept. You are prompted for the password of each account, or they
+are read from the --password file, one per line in the order of the accounts.
+
+Each key file is only replaced after the new one was verified to decrypt to the
+same key. Note, the key files of version 4 can't be read by older versions.
 `,
 			},
 			{
@@ -313,6 +360,53 @@ func accountUpdate(ctx *cli.Context) error {
 	return nil
 }
 
+// accountMigrate re-encrypts accounts with a new key file format or scrypt
+// parameters, keeping their passwords.
+func accountMigrate(ctx *cli.Context) error {
+	version := ctx.Int(keyFormatFlag.Name)
+	if version != 3 && version != 4 {
+		utils.Fatalf("Unsupported key file format %d, must be 3 or 4", version)
+	}
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if ctx.Bool(utils.LightKDFFlag.Name) {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	if ctx.IsSet(scryptNFlag.Name) {
+		scryptN = ctx.Int(scryptNFlag.Name)
+	}
+	if ctx.IsSet(scryptPFlag.Name) {
+		scryptP = ctx.Int(scryptPFlag.Name)
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	// Migrate the requested accounts, or all of them
+	var accs []accounts.Account
+	if ctx.Args().Len() == 0 {
+		accs = ks.Accounts()
+	}
+	for _, addr := range ctx.Args().Slice() {
+		account, err := utils.MakeAddress(ks, addr)
+		if err != nil {
+			utils.Fatalf("Could not find account %s: %v", addr, err)
+		}
+		accs = append(accs, account)
+	}
+	if len(accs) == 0 {
+		utils.Fatalf("No accounts to migrate")
+	}
+	passwords := utils.MakePasswordList(ctx)
+	for i, account := range accs {
+		prompt := fmt.Sprintf("Please give the password of account %s", account.Address.Hex())
+		password := utils.GetPassPhraseWithList(prompt, false, i, passwords)
+		if err := ks.Migrate(account, password, version, scryptN, scryptP); err != nil {
+			utils.Fatalf("Could not migrate account %s: %v", account.Address.Hex(), err)
+		}
+		log.Info("Migrated account", "address", account.Address.Hex(), "format", version)
+	}
+	return nil
+}
+
 func importWallet(ctx *cli.Context) error {
 	if ctx.Args().Len() != 1 {
 		utils.Fatalf("keyfile must be given as the only argument")
@@ -36,6 +36,7 @@ import (
 	"github.com/spacedogechain/go-spacedogechain/core/types"
 	"github.com/spacedogechain/go-spacedogechain/crypto"
 	"github.com/spacedogechain/go-spacedogechain/ethdb"
+	"github.com/spacedogechain/go-spacedogechain/ethdb/remotedb"
 	"github.com/spacedogechain/go-spacedogechain/internal/flags"
 	"github.com/spacedogechain/go-spacedogechain/log"
 	"github.com/spacedogechain/go-spacedogechain/trie"
@@ -43,6 +44,13 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+var (
+	dbServeWriteFlag = &cli.BoolFlag{
+		Name:  "write",
+		Usage: "Allow remote clients to modify the database",
+	}
+)
+
 var (
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
@@ -71,6 +79,7 @@ Remove blockchain and state databases`,
 			dbMetadataCmd,
 			dbMigrateFreezerCmd,
 			dbCheckStateContentCmd,
+			dbServeCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -206,6 +215,29 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		Description: `The freezer-migrate command checks your database for receipts in a legacy format and updates those.
 WARNING: please back-up the receipt files in your ancients before running this command.`,
 	}
+	dbServeCmd = &cli.Command{
+		Action:    dbServe,
+		Name:      "serve",
+		Usage:     "Serve the database to remote clients",
+		ArgsUsage: "<endpoint>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+			dbServeWriteFlag,
+		}, utils.NetworkFlags, utils.DatabasePathFlags),
+		Description: `This command opens the database and serves it to remote clients until interrupted.
+The endpoint is either a TCP address on a loopback interface (tcp://127.0.0.1:port) or the path
+of a Unix domain socket (unix:///path/to/socket). Connections are not authenticated, so access
+to the endpoint must be restricted to trusted clients.
+
+Clients, including nodes, can use the database via --remotedb=<endpoint>. The database is
+served read-only, unless --write is given, in which case clients may modify both the key-value
+and the ancient store.
+
+The command needs exclusive access to the database. To share the database of a running node,
+start the node with --remotedb.serve=<endpoint> instead.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -397,6 +429,35 @@ func dbCompact(ctx *cli.Context) error {
 	return nil
 }
 
+// dbServe serves the database to remote clients until interrupted.
+func dbServe(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	readonly := !ctx.Bool(dbServeWriteFlag.Name)
+
+	db := utils.MakeChainDatabase(ctx, stack, readonly)
+	defer db.Close()
+
+	server := remotedb.NewServer(db, readonly)
+	defer server.Close()
+
+	if _, err := server.Listen(ctx.Args().First()); err != nil {
+		log.Error("Failed to start remote database server", "err", err)
+		return err
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	<-sigc
+	log.Info("Shutting down remote database server")
+	return nil
+}
+
 // dbGet shows the value of a given database key
 func dbGet(ctx *cli.Context) error {
 	if ctx.NArg() != 1 {
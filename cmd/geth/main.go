@@ -102,7 +102,15 @@ var (
 		utils.LightNoSyncServeFlag,
 		utils.EthRequiredBlocksFlag,
 		utils.LegacyWhitelistFlag,
+		utils.SyncAnchorFlag,
+		utils.SyncAnchorNoHistoryFlag,
 		utils.BloomFilterSizeFlag,
+		utils.OnlinePruningFlag,
+		utils.OnlinePruningIntervalFlag,
+		utils.OnlinePruningThrottleFlag,
+		utils.SecondaryFlag,
+		utils.SecondaryRefreshFlag,
+		utils.RemoteDBServeFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
@@ -131,7 +139,13 @@ var (
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
+		utils.DiscoveryTopicsFlag,
 		utils.NetrestrictFlag,
+		utils.BandwidthIngressFlag,
+		utils.BandwidthEgressFlag,
+		utils.BandwidthPeerIngressFlag,
+		utils.BandwidthPeerEgressFlag,
+		utils.BandwidthProtocolsFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DNSDiscoveryFlag,
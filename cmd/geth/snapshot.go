@@ -18,9 +18,14 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/spacedogechain/go-spacedogechain/cmd/utils"
@@ -46,6 +51,23 @@ var (
 	emptyCode = crypto.Keccak256(nil)
 )
 
+var (
+	stateStatsTopFlag = &cli.IntFlag{
+		Name:  "top",
+		Usage: "Number of contracts retained in the rankings",
+		Value: 20,
+	}
+	stateStatsFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: `Output format of the report ("json" or "csv")`,
+		Value: "json",
+	}
+	stateStatsFromFlag = &cli.StringFlag{
+		Name:  "from",
+		Usage: "State root to measure the growth from, instead of reporting the entire state",
+	}
+)
+
 var (
 	snapshotCommand = &cli.Command{
 		Name:        "snapshot",
@@ -109,6 +131,31 @@ data, and verifies that all snapshot storage data has a corresponding account.
 				Description: `
 geth snapshot inspect-account <address | hash> checks all snapshot layers and prints out
 information about the specified address. 
+`,
+			},
+			{
+				Name:      "state-stats",
+				Usage:     "Report the storage footprint of the contracts in the state",
+				ArgsUsage: "<root>",
+				Action:    stateStats,
+				Flags: flags.Merge([]cli.Flag{
+					stateStatsTopFlag,
+					stateStatsFormatFlag,
+					stateStatsFromFlag,
+				}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth snapshot state-stats <state-root>
+will iterate the snapshot of the given state and measure the slot count, storage
+size and code size of every contract. The contracts with the largest footprint are
+ranked, their number being configurable via --top. The default target is the HEAD
+state.
+
+If --from is specified, only the growth between the given root and the target is
+measured, computed from the snapshot diff layers in between. Both roots need to be
+covered by the journalled diff layers, from being an ancestor of the target.
+
+The report is printed as JSON by default. With --format=csv, a row is printed for
+every contract instead, or for every ranked contract when measuring the growth.
 `,
 			},
 			{
@@ -595,3 +642,126 @@ func checkAccount(ctx *cli.Context) error {
 	log.Info("Checked the snapshot journalled storage", "time", common.PrettyDuration(time.Since(start)))
 	return nil
 }
+
+// stateStats iterates the snapshot of a state and reports the storage footprint
+// of the contracts, or its growth since an ancestor state.
+func stateStats(ctx *cli.Context) error {
+	format := ctx.String(stateStatsFormatFlag.Name)
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("invalid output format %q", format)
+	}
+	if ctx.NArg() > 1 {
+		log.Error("Too many arguments given")
+		return errors.New("too many arguments")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	headBlock := rawdb.ReadHeadBlock(chaindb)
+	if headBlock == nil {
+		log.Error("Failed to load head block")
+		return errors.New("no head block")
+	}
+	snapconfig := snapshot.Config{
+		CacheSize:  256,
+		Recovery:   false,
+		NoBuild:    true,
+		AsyncBuild: false,
+	}
+	snaptree, err := snapshot.New(snapconfig, chaindb, trie.NewDatabase(chaindb), headBlock.Root())
+	if err != nil {
+		log.Error("Failed to open snapshot tree", "err", err)
+		return err
+	}
+	var root = headBlock.Root()
+	if ctx.NArg() == 1 {
+		root, err = parseRoot(ctx.Args().First())
+		if err != nil {
+			log.Error("Failed to resolve state root", "err", err)
+			return err
+		}
+	}
+	top := ctx.Int(stateStatsTopFlag.Name)
+
+	// Measure the state growth if an ancestor root was specified
+	if ctx.IsSet(stateStatsFromFlag.Name) {
+		from, err := parseRoot(ctx.String(stateStatsFromFlag.Name))
+		if err != nil {
+			log.Error("Failed to resolve state root", "err", err)
+			return err
+		}
+		growth, err := snaptree.StateGrowth(context.Background(), from, root, top)
+		if err != nil {
+			log.Error("Failed to measure state growth", "from", from, "to", root, "err", err)
+			return err
+		}
+		if format == "csv" {
+			return writeGrowthCSV(os.Stdout, growth)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(growth)
+	}
+	// Otherwise measure the entire state, streaming the contracts if requested
+	var (
+		out   *csv.Writer
+		visit func(*snapshot.ContractStats) error
+	)
+	if format == "csv" {
+		out = csv.NewWriter(os.Stdout)
+		if err := out.Write([]string{"hash", "address", "slots", "storage", "code"}); err != nil {
+			return err
+		}
+		visit = func(stats *snapshot.ContractStats) error {
+			return out.Write(contractRecord(stats.Hash, stats.Address,
+				strconv.FormatUint(stats.Slots, 10), strconv.FormatUint(stats.Storage, 10), strconv.FormatUint(stats.Code, 10)))
+		}
+	}
+	stats, err := snaptree.StateStats(context.Background(), root, top, visit)
+	if err != nil {
+		log.Error("Failed to gather state statistics", "root", root, "err", err)
+		return err
+	}
+	if out != nil {
+		out.Flush()
+		return out.Error()
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
+
+// writeGrowthCSV writes the ranked contracts of a state growth report as CSV.
+func writeGrowthCSV(w io.Writer, growth *snapshot.StateGrowth) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"ranking", "hash", "address", "slots", "storage", "code"}); err != nil {
+		return err
+	}
+	for _, ranking := range []struct {
+		name  string
+		items []*snapshot.ContractGrowth
+	}{{"growth", growth.TopGrowth}, {"shrink", growth.TopShrink}} {
+		for _, item := range ranking.items {
+			record := contractRecord(item.Hash, item.Address,
+				strconv.FormatInt(item.Slots, 10), strconv.FormatInt(item.Storage, 10), strconv.FormatInt(item.Code, 10))
+			if err := out.Write(append([]string{ranking.name}, record...)); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// contractRecord assembles a CSV record of a contract from its identifiers and
+// the given pre-formatted values.
+func contractRecord(hash common.Hash, addr *common.Address, values ...string) []string {
+	var address string
+	if addr != nil {
+		address = addr.Hex()
+	}
+	return append([]string{hash.Hex(), address}, values...)
+}
@@ -90,14 +90,30 @@ var (
 	}
 	RemoteDBFlag = &cli.StringFlag{
 		Name:     "remotedb",
-		Usage:    "URL for remote database",
+		Usage:    "URL for remote database (node RPC endpoint, or tcp:// and unix:// for a database server)",
 		Category: flags.LoggingCategory,
 	}
+	RemoteDBServeFlag = &cli.StringFlag{
+		Name:     "remotedb.serve",
+		Usage:    "Serve the chain database of the running node read-only on a loopback tcp:// or unix:// endpoint",
+		Category: flags.EthCategory,
+	}
 	AncientFlag = &flags.DirectoryFlag{
 		Name:     "datadir.ancient",
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
 		Category: flags.EthCategory,
 	}
+	SecondaryFlag = &flags.DirectoryFlag{
+		Name:     "datadir.secondary",
+		Usage:    "Data directory of a running node whose database to serve read-only, without networking (state is only available once persisted by that node)",
+		Category: flags.EthCategory,
+	}
+	SecondaryRefreshFlag = &cli.DurationFlag{
+		Name:     "datadir.secondary.refresh",
+		Usage:    "Time interval to catch up with the database of the followed node",
+		Value:    ethconfig.Defaults.SecondaryRefresh,
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &flags.DirectoryFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -255,6 +271,16 @@ var (
 		Usage:    "Comma separated block number-to-hash mappings to require for peering (<number>=<hash>)",
 		Category: flags.EthCategory,
 	}
+	SyncAnchorFlag = &cli.StringFlag{
+		Name:     "syncanchor",
+		Usage:    "Trusted block to sync an empty database from, instead of the genesis (block hash or checkpoint file of the JSON header)",
+		Category: flags.EthCategory,
+	}
+	SyncAnchorNoHistoryFlag = &cli.BoolFlag{
+		Name:     "syncanchor.nohistory",
+		Usage:    "Skip downloading the block bodies and receipts preceding the trusted sync anchor",
+		Category: flags.EthCategory,
+	}
 	LegacyWhitelistFlag = &cli.StringFlag{
 		Name:     "whitelist",
 		Usage:    "Comma separated block number-to-hash mappings to enforce (<number>=<hash>) (deprecated in favor of --eth.requiredblocks)",
@@ -266,6 +292,23 @@ var (
 		Value:    2048,
 		Category: flags.EthCategory,
 	}
+	OnlinePruningFlag = &cli.BoolFlag{
+		Name:     "pruning.online",
+		Usage:    "Enables pruning stale state in the background while the node is running",
+		Category: flags.EthCategory,
+	}
+	OnlinePruningIntervalFlag = &cli.DurationFlag{
+		Name:     "pruning.online.interval",
+		Usage:    "Time interval between two background pruning cycles (0 = only prune on demand)",
+		Value:    ethconfig.Defaults.OnlinePruner.Interval,
+		Category: flags.EthCategory,
+	}
+	OnlinePruningThrottleFlag = &cli.DurationFlag{
+		Name:     "pruning.online.throttle",
+		Usage:    "Pause after every database batch processed by the background pruner",
+		Value:    ethconfig.Defaults.OnlinePruner.Throttle,
+		Category: flags.EthCategory,
+	}
 	OverrideTerminalTotalDifficulty = &flags.BigFlag{
 		Name:     "override.terminaltotaldifficulty",
 		Usage:    "Manually specify TerminalTotalDifficulty, overriding the bundled setting",
@@ -840,11 +883,41 @@ var (
 		Usage:    "Enables the experimental RLPx V5 (Topic Discovery) mechanism",
 		Category: flags.NetworkingCategory,
 	}
+	DiscoveryTopicsFlag = &cli.StringFlag{
+		Name:     "v5disc.topics",
+		Usage:    "Comma separated topics to advertise the node under and find peers by on the V5 discovery DHT",
+		Category: flags.NetworkingCategory,
+	}
 	NetrestrictFlag = &cli.StringFlag{
 		Name:     "netrestrict",
 		Usage:    "Restricts network communication to the given IP networks (CIDR masks)",
 		Category: flags.NetworkingCategory,
 	}
+	BandwidthIngressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.ingress",
+		Usage:    "Maximum total inbound bandwidth of all peers in bytes per second (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthEgressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.egress",
+		Usage:    "Maximum total outbound bandwidth of all peers in bytes per second (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthPeerIngressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.peer.ingress",
+		Usage:    "Maximum inbound bandwidth of each peer in bytes per second (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthPeerEgressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.peer.egress",
+		Usage:    "Maximum outbound bandwidth of each peer in bytes per second (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthProtocolsFlag = &cli.StringFlag{
+		Name:     "bandwidth.protocols",
+		Usage:    "Comma separated bandwidth limits of protocols across all peers in bytes per second (<protocol>:<ingress>:<egress>, e.g. snap:0:1048576)",
+		Category: flags.NetworkingCategory,
+	}
 	DNSDiscoveryFlag = &cli.StringFlag{
 		Name:     "discovery.dns",
 		Usage:    "Sets DNS discovery entry points (use \"\" to disable DNS)",
@@ -1392,6 +1465,51 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
+// setRateLimits configures the bandwidth limits of the p2p server.
+func setRateLimits(ctx *cli.Context, cfg *p2p.Config) {
+	if ctx.IsSet(BandwidthIngressFlag.Name) {
+		cfg.RateLimit.Ingress = ctx.Uint64(BandwidthIngressFlag.Name)
+	}
+	if ctx.IsSet(BandwidthEgressFlag.Name) {
+		cfg.RateLimit.Egress = ctx.Uint64(BandwidthEgressFlag.Name)
+	}
+	if ctx.IsSet(BandwidthPeerIngressFlag.Name) {
+		cfg.PeerRateLimit.Ingress = ctx.Uint64(BandwidthPeerIngressFlag.Name)
+	}
+	if ctx.IsSet(BandwidthPeerEgressFlag.Name) {
+		cfg.PeerRateLimit.Egress = ctx.Uint64(BandwidthPeerEgressFlag.Name)
+	}
+	if ctx.IsSet(BandwidthProtocolsFlag.Name) {
+		limits, err := parseProtocolRateLimits(ctx.String(BandwidthProtocolsFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", BandwidthProtocolsFlag.Name, err)
+		}
+		cfg.ProtocolRateLimits = limits
+	}
+}
+
+// parseProtocolRateLimits parses a comma separated list of protocol bandwidth
+// limits in the form <protocol>:<ingress>:<egress>.
+func parseProtocolRateLimits(s string) (map[string]p2p.RateLimit, error) {
+	limits := make(map[string]p2p.RateLimit)
+	for _, entry := range SplitAndTrim(s) {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid limit %q, want <protocol>:<ingress>:<egress>", entry)
+		}
+		ingress, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ingress limit in %q: %v", entry, err)
+		}
+		egress, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress limit in %q: %v", entry, err)
+		}
+		limits[parts[0]] = p2p.RateLimit{Ingress: ingress, Egress: egress}
+	}
+	return limits, nil
+}
+
 func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setNodeKey(ctx, cfg)
 	setNAT(ctx, cfg)
@@ -1446,6 +1564,12 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	} else if forceV5Discovery {
 		cfg.DiscoveryV5 = true
 	}
+	if ctx.IsSet(DiscoveryTopicsFlag.Name) {
+		cfg.DiscoveryTopics = SplitAndTrim(ctx.String(DiscoveryTopicsFlag.Name))
+		if !cfg.DiscoveryV5 {
+			log.Warn("Discovery topics require V5 discovery, enable it with --" + DiscoveryV5Flag.Name)
+		}
+	}
 
 	if netrestrict := ctx.String(NetrestrictFlag.Name); netrestrict != "" {
 		list, err := netutil.ParseNetlist(netrestrict)
@@ -1454,7 +1578,16 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NetRestrict = list
 	}
+	setRateLimits(ctx, cfg)
 
+	if ctx.IsSet(SecondaryFlag.Name) {
+		// A secondary node only serves the database of another node.
+		cfg.MaxPeers = 0
+		cfg.ListenAddr = ""
+		cfg.NoDial = true
+		cfg.NoDiscovery = true
+		cfg.DiscoveryV5 = false
+	}
 	if ctx.Bool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
 		cfg.MaxPeers = 0
@@ -1696,6 +1829,21 @@ func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
+func setSyncAnchor(ctx *cli.Context, cfg *ethconfig.Config) {
+	if !ctx.IsSet(SyncAnchorFlag.Name) {
+		if ctx.IsSet(SyncAnchorNoHistoryFlag.Name) {
+			Fatalf("Flag --%s requires --%s", SyncAnchorNoHistoryFlag.Name, SyncAnchorFlag.Name)
+		}
+		return
+	}
+	anchor, err := downloader.ParseTrustedAnchor(ctx.String(SyncAnchorFlag.Name))
+	if err != nil {
+		Fatalf("Invalid sync anchor: %v", err)
+	}
+	anchor.NoHistory = ctx.Bool(SyncAnchorNoHistoryFlag.Name)
+	cfg.SyncAnchor = anchor
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1743,6 +1891,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, RopstenFlag, RinkebyFlag, GoerliFlag, SepoliaFlag, KilnFlag)
 	CheckExclusive(ctx, LightServeFlag, SyncModeFlag, "light")
 	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
+	CheckExclusive(ctx, SecondaryFlag, DeveloperFlag, LightServeFlag)
+	CheckExclusive(ctx, SecondaryFlag, SyncModeFlag, "light")
+	CheckExclusive(ctx, SyncAnchorFlag, SyncModeFlag, "light")
+	CheckExclusive(ctx, SyncAnchorFlag, SecondaryFlag)
+	CheckExclusive(ctx, RemoteDBFlag, SecondaryFlag)
+	CheckExclusive(ctx, RemoteDBFlag, SyncModeFlag, "light")
 	if ctx.String(GCModeFlag.Name) == "archive" && ctx.Uint64(TxLookupLimitFlag.Name) != 0 {
 		ctx.Set(TxLookupLimitFlag.Name, "0")
 		log.Warn("Disable transaction unindexing for archive node")
@@ -1760,6 +1914,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
+	setSyncAnchor(ctx, cfg)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1795,6 +1950,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(AncientFlag.Name) {
 		cfg.DatabaseFreezer = ctx.String(AncientFlag.Name)
 	}
+	if ctx.IsSet(SecondaryFlag.Name) {
+		cfg.Secondary = ctx.String(SecondaryFlag.Name)
+	}
+	if ctx.IsSet(SecondaryRefreshFlag.Name) {
+		cfg.SecondaryRefresh = ctx.Duration(SecondaryRefreshFlag.Name)
+	}
+	if ctx.IsSet(RemoteDBFlag.Name) && isRemoteDBServer(ctx.String(RemoteDBFlag.Name)) {
+		cfg.RemoteDB = ctx.String(RemoteDBFlag.Name)
+	}
+	if ctx.IsSet(RemoteDBServeFlag.Name) {
+		cfg.RemoteDBServe = ctx.String(RemoteDBServeFlag.Name)
+	}
 
 	if gcmode := ctx.String(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1805,6 +1972,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.IsSet(OnlinePruningFlag.Name) {
+		cfg.OnlinePruning = ctx.Bool(OnlinePruningFlag.Name)
+	}
+	if ctx.IsSet(BloomFilterSizeFlag.Name) {
+		cfg.OnlinePruner.BloomSize = ctx.Uint64(BloomFilterSizeFlag.Name)
+	}
+	if ctx.IsSet(OnlinePruningIntervalFlag.Name) {
+		cfg.OnlinePruner.Interval = ctx.Duration(OnlinePruningIntervalFlag.Name)
+	}
+	if ctx.IsSet(OnlinePruningThrottleFlag.Name) {
+		cfg.OnlinePruner.Throttle = ctx.Duration(OnlinePruningThrottleFlag.Name)
+	}
 	// Read the value from the flag no matter if it's set or not.
 	cfg.Preimages = ctx.Bool(CachePreimagesFlag.Name)
 	if cfg.NoPruning && !cfg.Preimages {
@@ -2016,6 +2195,11 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 	if err != nil {
 		Fatalf("Failed to register the spacedogechain service: %v", err)
 	}
+	if cfg.Secondary != "" {
+		// The chain of a secondary node is driven by the followed node only
+		stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+		return backend.APIBackend, backend
+	}
 	if cfg.LightServ > 0 {
 		_, err := les.NewLesServer(stack, backend, cfg)
 		if err != nil {
@@ -2143,6 +2327,9 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 		chainDb ethdb.Database
 	)
 	switch {
+	case ctx.IsSet(RemoteDBFlag.Name) && isRemoteDBServer(ctx.String(RemoteDBFlag.Name)):
+		log.Info("Using remote db server", "endpoint", ctx.String(RemoteDBFlag.Name))
+		chainDb, err = remotedb.Dial(ctx.String(RemoteDBFlag.Name), readonly)
 	case ctx.IsSet(RemoteDBFlag.Name):
 		log.Info("Using remote db", "url", ctx.String(RemoteDBFlag.Name), "headers", len(ctx.StringSlice(HttpHeaderFlag.Name)))
 		client, err := DialRPCWithHeaders(ctx.String(RemoteDBFlag.Name), ctx.StringSlice(HttpHeaderFlag.Name))
@@ -2161,6 +2348,12 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 	return chainDb
 }
 
+// isRemoteDBServer reports if the remote database endpoint refers to a dedicated
+// database server, rather than the RPC endpoint of a node.
+func isRemoteDBServer(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "tcp://") || strings.HasPrefix(endpoint, "unix://")
+}
+
 func IsNetworkPreset(ctx *cli.Context) bool {
 	for _, flag := range NetworkFlags {
 		bFlag, _ := flag.(*cli.BoolFlag)
@@ -20,6 +20,8 @@ package utils
 import (
 	"reflect"
 	"testing"
+
+	"github.com/spacedogechain/go-spacedogechain/p2p"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -62,3 +64,22 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProtocolRateLimits(t *testing.T) {
+	limits, err := parseProtocolRateLimits("snap:0:1048576, eth:2048:4096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]p2p.RateLimit{
+		"snap": {Egress: 1048576},
+		"eth":  {Ingress: 2048, Egress: 4096},
+	}
+	if !reflect.DeepEqual(limits, want) {
+		t.Errorf("wrong limits: have %v, want %v", limits, want)
+	}
+	for _, invalid := range []string{"snap", "snap:1", ":1:2", "snap:-1:0", "snap:1:x"} {
+		if _, err := parseProtocolRateLimits(invalid); err == nil {
+			t.Errorf("no error for invalid limit %q", invalid)
+		}
+	}
+}
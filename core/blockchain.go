@@ -87,6 +87,7 @@ var (
 
 	errInsertionInterrupted = errors.New("insertion is interrupted")
 	errChainStopped         = errors.New("blockchain is stopped")
+	errChainReadOnly        = errors.New("blockchain is read-only")
 )
 
 const (
@@ -139,6 +140,8 @@ type CacheConfig struct {
 
 	SnapshotNoBuild bool // Whsdcer the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
+
+	ReadOnly bool // Whsdcer the chain is served from the database of another process and must not be modified
 }
 
 // defaultCacheConfig are the default caching values if none are specified by the
@@ -173,6 +176,7 @@ type BlockChain struct {
 	snaps  *snapshot.Tree // Snapshot tree for fast trie leaf access
 	triegc *prque.Prque   // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration  // Accumulates canonical block processing for trie dumping
+	flushq uint32         // Flag if a full trie flush was explicitly requested
 
 	// txLookupLimit is the maximum number of blocks from head whose tx indices
 	// are reserved:
@@ -237,8 +241,17 @@ func NewBlockChain(db sdcdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
-	// stored one from database.
-	chainConfig, genesisHash, genesisErr := SetupGenesisBlockWithOverride(db, genesis, overrides)
+	// stored one from database. Read-only chains can only use the stored one.
+	var (
+		chainConfig *params.ChainConfig
+		genesisHash common.Hash
+		genesisErr  error
+	)
+	if cacheConfig.ReadOnly {
+		chainConfig, genesisHash, genesisErr = loadChainConfig(db, genesis, overrides)
+	} else {
+		chainConfig, genesisHash, genesisErr = SetupGenesisBlockWithOverride(db, genesis, overrides)
+	}
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
@@ -296,12 +309,25 @@ func NewBlockChain(db sdcdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	// missing chain indexes and chain flags. This procedure can survive crash
 	// and can be resumed in next restart since chain flags are updated in last step.
 	if bc.empty() {
+		if cacheConfig.ReadOnly {
+			return nil, errors.New("read-only chain database not initialised")
+		}
 		rawdb.InitDatabaseFromFreezer(bc.db)
 	}
 	// Load blockchain states from disk
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	// A read-only chain is maintained by the owner of the database, skip any
+	// repairs, recoveries and background maintenance.
+	if cacheConfig.ReadOnly {
+		head := bc.CurrentBlock()
+		if !bc.HasState(head.Root()) {
+			log.Warn("Head state not available in read-only chain", "number", head.Number(), "hash", head.Hash())
+		}
+		bc.engine.VerifyHeader(bc, bc.CurrentHeader(), true)
+		return bc, nil
+	}
 	// Make sure the state associated with the block is available
 	head := bc.CurrentBlock()
 	if _, err := state.New(head.Root(), bc.stateCache, bc.snaps); err != nil {
@@ -458,6 +484,9 @@ func (bc *BlockChain) loadLastState() error {
 	// Restore the last known head block
 	head := rawdb.ReadHeadBlockHash(bc.db)
 	if head == (common.Hash{}) {
+		if bc.cacheConfig.ReadOnly {
+			return errors.New("empty read-only chain database")
+		}
 		// Corrupt or empty database, init from scratch
 		log.Warn("Empty database, resetting chain")
 		return bc.Reset()
@@ -465,6 +494,9 @@ func (bc *BlockChain) loadLastState() error {
 	// Make sure the entire head block is available
 	currentBlock := bc.GetBlockByHash(head)
 	if currentBlock == nil {
+		if bc.cacheConfig.ReadOnly {
+			return fmt.Errorf("head block %x missing from read-only chain database", head)
+		}
 		// Corrupt or empty database, init from scratch
 		log.Warn("Head block missing, resetting chain", "hash", head)
 		return bc.Reset()
@@ -565,6 +597,9 @@ func (bc *BlockChain) SetSafe(block *types.Block) {
 //
 // The msdcod returns the block number where the requested root cap was found.
 func (bc *BlockChain) ssdceadBeyondRoot(head uint64, root common.Hash, repair bool) (uint64, error) {
+	if bc.cacheConfig.ReadOnly {
+		return 0, errChainReadOnly
+	}
 	if !bc.chainmu.TryLock() {
 		return 0, errChainStopped
 	}
@@ -889,6 +924,12 @@ func (bc *BlockChain) stopWithoutSaving() {
 func (bc *BlockChain) Stop() {
 	bc.stopWithoutSaving()
 
+	// Read-only chains have nothing to persist, the owner takes care of it
+	if bc.cacheConfig.ReadOnly {
+		log.Info("Blockchain stopped")
+		return
+	}
+
 	// Ensure that the entirety of the state snapshot is journalled to disk.
 	var snapBase common.Hash
 	if bc.snaps != nil {
@@ -909,7 +950,9 @@ func (bc *BlockChain) Stop() {
 		for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
 			if number := bc.CurrentBlock().NumberU64(); number > offset {
 				recent := bc.GetBlockByNumber(number - offset)
-
+				if recent == nil {
+					continue // Chain history skipped, no state to persist
+				}
 				log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
 				if err := triedb.Commit(recent.Root(), true, nil); err != nil {
 					log.Error("Failed to commit recent state trie", "err", err)
@@ -949,6 +992,13 @@ func (bc *BlockChain) StopInsert() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 }
 
+// RequestTrieFlush schedules the state trie of the next block leaving the in-memory
+// retention window to be persisted in full, regardless of the time allowance. It's
+// a noop for archive nodes, which persist every state anyway.
+func (bc *BlockChain) RequestTrieFlush() {
+	atomic.StoreUint32(&bc.flushq, 1)
+}
+
 // insertStopped returns true after StopInsert has been called.
 func (bc *BlockChain) insertStopped() bool {
 	return atomic.LoadInt32(&bc.procInterrupt) == 1
@@ -984,6 +1034,9 @@ const (
 // InsertReceiptChain attempts to complete an already existing header chain with
 // transaction and receipt data.
 func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain []types.Receipts, ancientLimit uint64) (int, error) {
+	if bc.cacheConfig.ReadOnly {
+		return 0, errChainReadOnly
+	}
 	// We don't require the chainMu here since we want to maximize the
 	// concurrency of header insertion and receipt insertion.
 	bc.wg.Add(1)
@@ -1250,6 +1303,87 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 	return 0, nil
 }
 
+// InsertAncientHeaders extends the snap synced chain with a batch of trusted
+// headers, writing them directly into the ancient store without block bodies and
+// receipts. It is meant to skip the chain history preceding a trusted sync anchor,
+// so the headers need to follow the current snap block and must already be known
+// to be canonical.
+func (bc *BlockChain) InsertAncientHeaders(headers []*types.Header) (int, error) {
+	if bc.cacheConfig.ReadOnly {
+		return 0, errChainReadOnly
+	}
+	if len(headers) == 0 {
+		return 0, nil
+	}
+	// Do a sanity check that the provided chain is actually ordered and linked
+	for i := 1; i < len(headers); i++ {
+		if headers[i].Number.Uint64() != headers[i-1].Number.Uint64()+1 || headers[i].ParentHash != headers[i-1].Hash() {
+			return 0, fmt.Errorf("non contiguous insert: item %d is #%d [%x..], item %d is #%d [%x..] (parent [%x..])", i-1, headers[i-1].Number,
+				headers[i-1].Hash().Bytes()[:4], i, headers[i].Number, headers[i].Hash().Bytes()[:4], headers[i].ParentHash.Bytes()[:4])
+		}
+	}
+	if !bc.chainmu.TryLock() {
+		return 0, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	// Ensure the headers extend the snap synced chain, all of which is frozen
+	var (
+		first = headers[0]
+		last  = headers[len(headers)-1]
+		head  = bc.CurrentFastBlock()
+	)
+	if first.ParentHash != head.Hash() || first.Number.Uint64() != head.NumberU64()+1 {
+		return 0, fmt.Errorf("headers don't extend the snap head: #%d [%x..] after #%d [%x..]", first.Number, first.Hash().Bytes()[:4], head.Number(), head.Hash().Bytes()[:4])
+	}
+	if number := bc.CurrentHeader().Number.Uint64(); number > head.NumberU64() {
+		return 0, fmt.Errorf("header chain #%d ahead of the snap head #%d", number, head.NumberU64())
+	}
+	frozen, err := bc.db.Ancients()
+	if err != nil {
+		return 0, err
+	}
+	if frozen == 0 && head.NumberU64() == 0 {
+		if _, err := rawdb.WriteAncientBlocks(bc.db, []*types.Block{bc.genesisBlock}, []types.Receipts{nil}, bc.genesisBlock.Difficulty()); err != nil {
+			log.Error("Error writing genesis to ancients", "err", err)
+			return 0, err
+		}
+		frozen = 1
+	}
+	if frozen != first.Number.Uint64() {
+		return 0, fmt.Errorf("ancient store not aligned with headers: %d frozen, first header #%d", frozen, first.Number)
+	}
+	// Write the headers into the ancient store and update the head markers
+	td := bc.GetTd(first.ParentHash, first.Number.Uint64()-1)
+	if td == nil {
+		return 0, consensus.ErrUnknownAncestor
+	}
+	size, err := rawdb.WriteAncientHeaderChain(bc.db, headers, new(big.Int).Add(td, first.Difficulty))
+	if err != nil {
+		log.Error("Error importing headers to ancients", "err", err)
+		return 0, err
+	}
+	if err := bc.db.Sync(); err != nil {
+		return 0, err
+	}
+	batch := bc.db.NewBatch()
+	for _, header := range headers {
+		rawdb.WriteHeaderNumber(batch, header.Hash(), header.Number.Uint64())
+	}
+	rawdb.WriteHeadHeaderHash(batch, last.Hash())
+	rawdb.WriteHeadFastBlockHash(batch, last.Hash())
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	bc.hc.SetCurrentHeader(last)
+	bc.currentFastBlock.Store(types.NewBlockWithHeader(last))
+	headFastBlockGauge.Update(last.Number.Int64())
+
+	log.Debug("Imported history-less headers", "count", len(headers), "number", last.Number, "hash", last.Hash(),
+		"age", common.PrettyAge(time.Unix(int64(last.Time), 0)), "size", common.StorageSize(size))
+	return len(headers), nil
+}
+
 var lastWrite uint64
 
 // writeBlockWithoutState writes only the block and its metadata to the database,
@@ -1332,8 +1466,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 			// Find the next state trie we need to commit
 			chosen := current - TriesInMemory
 
-			// If we exceeded out time allowance, flush an entire trie to disk
-			if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
+			// If we exceeded out time allowance or a flush was explicitly requested,
+			// flush an entire trie to disk
+			if bc.gcproc > bc.cacheConfig.TrieTimeLimit || atomic.LoadUint32(&bc.flushq) == 1 {
 				// If the header is missing (canonical chain behind), we're reorging a low
 				// diff sidechain. Suspend committing until this operation is completed.
 				header := bc.GsdceaderByNumber(chosen)
@@ -1349,6 +1484,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 					triedb.Commit(header.Root, true, nil)
 					lastWrite = chosen
 					bc.gcproc = 0
+					atomic.StoreUint32(&bc.flushq, 0)
 				}
 			}
 			// Garbage collect anything below our required write retention
@@ -1490,6 +1626,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, ssdcead bool)
 	if bc.insertStopped() {
 		return 0, nil
 	}
+	if bc.cacheConfig.ReadOnly {
+		return 0, errChainReadOnly
+	}
 
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
@@ -2195,6 +2334,9 @@ func (bc *BlockChain) InsertBlockWithoutSsdcead(block *types.Block) error {
 // block. It's possible that the state of the new head is missing, and it will
 // be recovered in this function as well.
 func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
+	if bc.cacheConfig.ReadOnly {
+		return common.Hash{}, errChainReadOnly
+	}
 	if !bc.chainmu.TryLock() {
 		return common.Hash{}, errChainStopped
 	}
@@ -2416,6 +2558,9 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int) (i
 	if len(chain) == 0 {
 		return 0, nil
 	}
+	if bc.cacheConfig.ReadOnly {
+		return 0, errChainReadOnly
+	}
 	start := time.Now()
 	if i, err := bc.hc.ValidateHeaderChain(chain, checkFreq); err != nil {
 		return i, err
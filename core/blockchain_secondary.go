@@ -0,0 +1,171 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/params"
+)
+
+// maxReloadEvents is the maximum number of blocks a head reload announces
+// individually. Larger jumps, e.g. while the owner of the database is syncing,
+// only announce the new head.
+const maxReloadEvents = 1024
+
+// loadChainConfig retrieves the chain configuration stored by the owner of a
+// read-only chain database, without ever writing to it.
+func loadChainConfig(db sdcdb.Database, genesis *Genesis, overrides *ChainOverrides) (*params.ChainConfig, common.Hash, error) {
+	stored := rawdb.ReadCanonicalHash(db, 0)
+	if stored == (common.Hash{}) {
+		return nil, common.Hash{}, ErrNoGenesis
+	}
+	if genesis != nil {
+		if hash := genesis.ToBlock().Hash(); hash != stored {
+			return nil, hash, &GenesisMismatchError{stored, hash}
+		}
+	}
+	config := rawdb.ReadChainConfig(db, stored)
+	if config == nil {
+		return nil, stored, errors.New("chain config missing from read-only database")
+	}
+	if overrides != nil && overrides.OverrideTerminalTotalDifficulty != nil {
+		config.TerminalTotalDifficulty = overrides.OverrideTerminalTotalDifficulty
+	}
+	if overrides != nil && overrides.OverrideTerminalTotalDifficultyPassed != nil {
+		config.TerminalTotalDifficultyPassed = *overrides.OverrideTerminalTotalDifficultyPassed
+	}
+	return config, stored, nil
+}
+
+// ReloadHead refreshes the head markers of a read-only chain from the database,
+// following the progress made by the process owning it. The blocks which became
+// canonical since the last reload are announced to the chain subscribers, along
+// with the logs of any blocks dropped by a reorg.
+func (bc *BlockChain) ReloadHead() error {
+	if !bc.cacheConfig.ReadOnly {
+		return errors.New("head reload requires a read-only chain")
+	}
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	// Resolve the new head block. The markers might be updated before all the
+	// data became visible to us, in which case the reload is tried again later.
+	current := bc.CurrentBlock()
+	head := current
+	if hash := rawdb.ReadHeadBlockHash(bc.db); hash != (common.Hash{}) && hash != current.Hash() {
+		if head = bc.GetBlockByHash(hash); head == nil {
+			return fmt.Errorf("head block %x not yet available", hash)
+		}
+	}
+	if hash := rawdb.ReadHeadHeaderHash(bc.db); hash != (common.Hash{}) && hash != bc.CurrentHeader().Hash() {
+		if header := bc.GsdceaderByHash(hash); header != nil {
+			bc.hc.SetCurrentHeader(header)
+		}
+	}
+	if hash := rawdb.ReadHeadFastBlockHash(bc.db); hash != (common.Hash{}) && hash != bc.CurrentFastBlock().Hash() {
+		if block := bc.GetBlockByHash(hash); block != nil {
+			bc.currentFastBlock.Store(block)
+			headFastBlockGauge.Update(int64(block.NumberU64()))
+		}
+	}
+	if hash := rawdb.ReadFinalizedBlockHash(bc.db); hash != (common.Hash{}) {
+		if finalized := bc.CurrentFinalizedBlock(); finalized == nil || finalized.Hash() != hash {
+			if block := bc.GetBlockByHash(hash); block != nil {
+				bc.currentFinalizedBlock.Store(block)
+				headFinalizedBlockGauge.Update(int64(block.NumberU64()))
+				bc.currentSafeBlock.Store(block)
+				headSafeBlockGauge.Update(int64(block.NumberU64()))
+			}
+		}
+	}
+	if head == current {
+		return nil
+	}
+	// Collect the blocks dropped from and added to the canonical chain. If the
+	// previous head is not canonical any more, walk back to the fork point.
+	var (
+		dropped []*types.Block
+		added   []*types.Block
+		ancient = current
+	)
+	for ancient != nil && len(dropped) <= maxReloadEvents && rawdb.ReadCanonicalHash(bc.db, ancient.NumberU64()) != ancient.Hash() {
+		dropped = append(dropped, ancient)
+		ancient = bc.GetBlock(ancient.ParentHash(), ancient.NumberU64()-1)
+	}
+	if ancient != nil && len(dropped) <= maxReloadEvents && head.NumberU64() <= ancient.NumberU64()+maxReloadEvents {
+		var missing bool
+		for number := ancient.NumberU64() + 1; number < head.NumberU64(); number++ {
+			block := bc.GetBlockByNumber(number)
+			if block == nil {
+				missing = true
+				break
+			}
+			added = append(added, block)
+		}
+		switch {
+		case missing:
+			// Some blocks are not yet visible to us, only advance to the last
+			// contiguous one and pick up the rest in a later reload.
+			head = ancient
+			if len(added) > 0 {
+				head = added[len(added)-1]
+			}
+		case head.NumberU64() > ancient.NumberU64():
+			added = append(added, head)
+		}
+	} else {
+		dropped = nil
+	}
+	if head.Hash() == current.Hash() {
+		return nil
+	}
+	// Update the head and drop any cached lookups invalidated by a reorg
+	bc.currentBlock.Store(head)
+	headBlockGauge.Update(int64(head.NumberU64()))
+
+	if current.Hash() != rawdb.ReadCanonicalHash(bc.db, current.NumberU64()) {
+		bc.txLookupCache.Purge()
+	}
+	log.Debug("Reloaded chain head", "number", head.Number(), "hash", head.Hash(), "dropped", len(dropped), "added", len(added))
+
+	// Announce the changes to the subscribers
+	var deletedLogs []*types.Log
+	for _, block := range dropped {
+		deletedLogs = append(deletedLogs, bc.collectLogs(block.Hash(), true)...)
+	}
+	if len(deletedLogs) > 0 {
+		bc.rmLogsFeed.Send(RemovedLogsEvent{deletedLogs})
+	}
+	for _, block := range added {
+		logs := bc.collectLogs(block.Hash(), false)
+		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
+		if len(logs) > 0 {
+			bc.logsFeed.Send(logs)
+		}
+	}
+	bc.chainHeadFeed.Send(ChainHeadEvent{Block: head})
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/consensus/sdcash"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/core/vm"
+	"github.com/sdcereum/go-sdcereum/params"
+)
+
+// Tests that a read-only chain follows the head of the chain owning the database.
+func TestReadOnlyChainReloadHead(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{Config: params.TestChainConfig}
+	)
+	primary, err := NewBlockChain(db, nil, gspec, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create primary chain: %v", err)
+	}
+	defer primary.Stop()
+
+	_, blocks, _ := GenerateChainWithGenesis(gspec, sdcash.NewFaker(), 5, func(i int, gen *BlockGen) {})
+	if _, err := primary.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	secondary, err := NewBlockChain(db, &CacheConfig{TrieCleanLimit: 256, TrieTimeLimit: 5 * time.Minute, ReadOnly: true}, nil, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create read-only chain: %v", err)
+	}
+	defer secondary.Stop()
+
+	if head := secondary.CurrentBlock().Hash(); head != blocks[2].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, blocks[2].Hash())
+	}
+	if _, err := secondary.InsertChain(blocks[3:]); err != errChainReadOnly {
+		t.Fatalf("unexpected insertion error: have %v, want %v", err, errChainReadOnly)
+	}
+	// Advance the primary and ensure the new blocks are announced
+	if _, err := primary.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	events := make(chan ChainEvent, 10)
+	sub := secondary.SubscribeChainEvent(events)
+	defer sub.Unsubscribe()
+
+	if err := secondary.ReloadHead(); err != nil {
+		t.Fatalf("failed to reload head: %v", err)
+	}
+	if head := secondary.CurrentBlock().Hash(); head != blocks[4].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, blocks[4].Hash())
+	}
+	for i := 3; i < 5; i++ {
+		if ev := <-events; ev.Hash != blocks[i].Hash() {
+			t.Fatalf("event %d mismatch: have %x, want %x", i, ev.Hash, blocks[i].Hash())
+		}
+	}
+	// Reorg the primary onto a longer fork and ensure it's followed
+	_, fork, _ := GenerateChainWithGenesis(gspec, sdcash.NewFaker(), 6, func(i int, gen *BlockGen) {
+		if i >= 3 {
+			gen.SetCoinbase(common.Address{0x01})
+		}
+	})
+	if _, err := primary.InsertChain(fork[3:]); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if err := secondary.ReloadHead(); err != nil {
+		t.Fatalf("failed to reload head: %v", err)
+	}
+	if head := secondary.CurrentBlock().Hash(); head != fork[5].Hash() {
+		t.Fatalf("head mismatch after reorg: have %x, want %x", head, fork[5].Hash())
+	}
+	if block := secondary.GetBlockByNumber(5); block == nil || block.Hash() != fork[4].Hash() {
+		t.Fatalf("canonical block mismatch after reorg")
+	}
+}
+
+// Tests that a read-only chain doesn't skip over blocks of the owner which are
+// not yet visible, but only advances to the last contiguous block.
+func TestReadOnlyChainReloadHeadGap(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{Config: params.TestChainConfig}
+	)
+	primary, err := NewBlockChain(db, nil, gspec, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create primary chain: %v", err)
+	}
+	defer primary.Stop()
+
+	_, blocks, _ := GenerateChainWithGenesis(gspec, sdcash.NewFaker(), 6, func(i int, gen *BlockGen) {})
+	if _, err := primary.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	secondary, err := NewBlockChain(db, &CacheConfig{TrieCleanLimit: 256, TrieTimeLimit: 5 * time.Minute, ReadOnly: true}, nil, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create read-only chain: %v", err)
+	}
+	defer secondary.Stop()
+
+	// Advance the primary, but hide one of the new blocks from the secondary
+	if _, err := primary.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	hidden := blocks[4]
+	rawdb.DeleteBody(db, hidden.Hash(), hidden.NumberU64())
+
+	events := make(chan ChainEvent, 10)
+	sub := secondary.SubscribeChainEvent(events)
+	defer sub.Unsubscribe()
+
+	if err := secondary.ReloadHead(); err != nil {
+		t.Fatalf("failed to reload head: %v", err)
+	}
+	if head := secondary.CurrentBlock().Hash(); head != blocks[3].Hash() {
+		t.Fatalf("head mismatch with missing block: have %x, want %x", head, blocks[3].Hash())
+	}
+	if ev := <-events; ev.Hash != blocks[3].Hash() {
+		t.Fatalf("event mismatch: have %x, want %x", ev.Hash, blocks[3].Hash())
+	}
+	// Make the block visible and ensure the rest of the chain is picked up
+	rawdb.WriteBody(db, hidden.Hash(), hidden.NumberU64(), hidden.Body())
+
+	if err := secondary.ReloadHead(); err != nil {
+		t.Fatalf("failed to reload head: %v", err)
+	}
+	if head := secondary.CurrentBlock().Hash(); head != blocks[5].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, blocks[5].Hash())
+	}
+	for i := 4; i < 6; i++ {
+		if ev := <-events; ev.Hash != blocks[i].Hash() {
+			t.Fatalf("event %d mismatch: have %x, want %x", i, ev.Hash, blocks[i].Hash())
+		}
+	}
+}
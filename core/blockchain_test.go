@@ -862,6 +862,87 @@ func TestFastVsFullChains(t *testing.T) {
 	}
 }
 
+// Tests that a header chain can be imported into the ancient store without the
+// block bodies and receipts, and the snap sync can continue on top of it.
+func TestInsertAncientHeaders(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, sdcash.NewFaker(), 8, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer db.Close()
+	chain, _ := NewBlockChain(db, nil, gspec, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	// Headers not extending the snap head should be rejected
+	if _, err := chain.InsertAncientHeaders(headers[1:5]); err == nil {
+		t.Fatal("disconnected headers accepted")
+	}
+	if n, err := chain.InsertAncientHeaders(headers[:5]); err != nil {
+		t.Fatalf("failed to insert headers %d: %v", n, err)
+	}
+	if head := chain.CurrentFastBlock(); head.Hash() != blocks[4].Hash() {
+		t.Fatalf("snap head mismatch: have %d, want %d", head.Number(), blocks[4].Number())
+	}
+	if head := chain.CurrentHeader(); head.Hash() != blocks[4].Hash() {
+		t.Fatalf("header head mismatch: have %d, want %d", head.Number, blocks[4].Number())
+	}
+	if frozen, _ := db.Ancients(); frozen != 6 {
+		t.Fatalf("ancient count mismatch: have %d, want %d", frozen, 6)
+	}
+	for i := 0; i < 5; i++ {
+		hash, number := blocks[i].Hash(), blocks[i].NumberU64()
+		if header := chain.GetHeaderByHash(hash); header == nil || header.Hash() != hash {
+			t.Fatalf("block %d: header missing", number)
+		}
+		if !chain.HasFastBlock(hash, number) {
+			t.Fatalf("block %d: snap block missing", number)
+		}
+		if block := chain.GetBlock(hash, number); block != nil {
+			t.Fatalf("block %d: unexpected body", number)
+		}
+		if receipts := chain.GetReceiptsByHash(hash); receipts != nil {
+			t.Fatalf("block %d: unexpected receipts", number)
+		}
+		if td := chain.GetTd(hash, number); td == nil || td.Cmp(new(big.Int).Mul(big.NewInt(int64(number+1)), params.GenesisDifficulty)) != 0 {
+			t.Fatalf("block %d: total difficulty mismatch: have %v", number, td)
+		}
+	}
+	// Continue the snap sync on top of the header-only chain segment
+	if n, err := chain.InsertHeaderChain(headers[5:], 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks[5:], receipts[5:], 0); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	if head := chain.CurrentFastBlock(); head.Hash() != blocks[7].Hash() {
+		t.Fatalf("snap head mismatch: have %d, want %d", head.Number(), blocks[7].Number())
+	}
+	if block := chain.GetBlock(blocks[7].Hash(), 8); block == nil || len(block.Transactions()) != 1 {
+		t.Fatal("live block missing")
+	}
+}
+
 // Tests that various import msdcods move the chain head pointers to the correct
 // positions.
 func TestLightVsFastVsFullChainHeads(t *testing.T) {
@@ -434,6 +434,17 @@ func isCanon(reader sdcdb.AncientReaderOp, number uint64, hash common.Hash) bool
 	return bytes.Equal(h, hash[:])
 }
 
+// readAncientHistory retrieves a block body or receipts item from the ancient
+// store. Blocks imported without their history (see WriteAncientHeaderChain)
+// store empty items, which are reported as missing.
+func readAncientHistory(reader sdcdb.AncientReaderOp, kind string, number uint64) []byte {
+	data, _ := reader.Ancient(kind, number)
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func ReadBodyRLP(db sdcdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
 	// First try to look up the data in ancient database. Extra hash
@@ -443,7 +454,7 @@ func ReadBodyRLP(db sdcdb.Reader, hash common.Hash, number uint64) rlp.RawValue
 	db.ReadAncients(func(reader sdcdb.AncientReaderOp) error {
 		// Check if the data is in ancients
 		if isCanon(reader, number, hash) {
-			data, _ = reader.Ancient(chainFreezerBodiesTable, number)
+			data = readAncientHistory(reader, chainFreezerBodiesTable, number)
 			return nil
 		}
 		// If not, try reading from leveldb
@@ -458,8 +469,7 @@ func ReadBodyRLP(db sdcdb.Reader, hash common.Hash, number uint64) rlp.RawValue
 func ReadCanonicalBodyRLP(db sdcdb.Reader, number uint64) rlp.RawValue {
 	var data []byte
 	db.ReadAncients(func(reader sdcdb.AncientReaderOp) error {
-		data, _ = reader.Ancient(chainFreezerBodiesTable, number)
-		if len(data) > 0 {
+		if data = readAncientHistory(reader, chainFreezerBodiesTable, number); data != nil {
 			return nil
 		}
 		// Block is not in ancients, read from leveldb by hash and number.
@@ -586,7 +596,7 @@ func ReadReceiptsRLP(db sdcdb.Reader, hash common.Hash, number uint64) rlp.RawVa
 	db.ReadAncients(func(reader sdcdb.AncientReaderOp) error {
 		// Check if the data is in ancients
 		if isCanon(reader, number, hash) {
-			data, _ = reader.Ancient(chainFreezerReceiptTable, number)
+			data = readAncientHistory(reader, chainFreezerReceiptTable, number)
 			return nil
 		}
 		// If not, try reading from leveldb
@@ -836,6 +846,38 @@ func writeAncientBlock(op sdcdb.AncientWriteOp, block *types.Block, header *type
 	return nil
 }
 
+// WriteAncientHeaderChain writes the given header chain directly into the ancient
+// store, without the associated block bodies and receipts. The chain history is
+// marked as unavailable by storing empty body and receipts items, which are not
+// valid RLP and are reported by all readers as missing data.
+func WriteAncientHeaderChain(db sdcdb.AncientWriter, headers []*types.Header, td *big.Int) (int64, error) {
+	tdSum := new(big.Int).Set(td)
+	return db.ModifyAncients(func(op sdcdb.AncientWriteOp) error {
+		for i, header := range headers {
+			if i > 0 {
+				tdSum.Add(tdSum, header.Difficulty)
+			}
+			num := header.Number.Uint64()
+			if err := op.AppendRaw(chainFreezerHashTable, num, header.Hash().Bytes()); err != nil {
+				return fmt.Errorf("can't add block %d hash: %v", num, err)
+			}
+			if err := op.Append(chainFreezerHeaderTable, num, header); err != nil {
+				return fmt.Errorf("can't append block header %d: %v", num, err)
+			}
+			if err := op.AppendRaw(chainFreezerBodiesTable, num, nil); err != nil {
+				return fmt.Errorf("can't append block body %d: %v", num, err)
+			}
+			if err := op.AppendRaw(chainFreezerReceiptTable, num, nil); err != nil {
+				return fmt.Errorf("can't append block %d receipts: %v", num, err)
+			}
+			if err := op.Append(chainFreezerDifficultyTable, num, tdSum); err != nil {
+				return fmt.Errorf("can't append block %d total difficulty: %v", num, err)
+			}
+		}
+		return nil
+	})
+}
+
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db sdcdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
@@ -29,6 +29,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/common"
 	"github.com/sdcereum/go-sdcereum/core/types"
 	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/log"
 	"github.com/sdcereum/go-sdcereum/params"
 	"github.com/sdcereum/go-sdcereum/rlp"
 	"golang.org/x/crypto/sha3"
@@ -496,6 +497,81 @@ func TestAncientStorage(t *testing.T) {
 	}
 }
 
+// Tests that the history of blocks imported as headers only is reported as missing
+// by all readers, without attempting to decode the placeholder items.
+func TestAncientHeaderChain(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Extra: []byte("test genesis")})
+	header := &types.Header{
+		Number:      big.NewInt(1),
+		ParentHash:  genesis.Hash(),
+		Difficulty:  big.NewInt(100),
+		TxHash:      common.Hash{0x01},
+		ReceiptHash: common.Hash{0x02},
+	}
+	if _, err := WriteAncientBlocks(db, []*types.Block{genesis}, []types.Receipts{nil}, big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	if _, err := WriteAncientHeaderChain(db, []*types.Header{header}, big.NewInt(200)); err != nil {
+		t.Fatalf("failed to write header chain: %v", err)
+	}
+	WriteHeaderNumber(db, header.Hash(), 1)
+
+	// Track any errors logged by the readers
+	var logged []string
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlWarn, log.FuncHandler(func(r *log.Record) error {
+		logged = append(logged, r.Msg)
+		return nil
+	})))
+	defer log.Root().SetHandler(log.DiscardHandler())
+
+	hash, number := header.Hash(), header.Number.Uint64()
+	if have := ReadHeader(db, hash, number); have == nil || have.Hash() != hash {
+		t.Fatal("header missing")
+	}
+	if td := ReadTd(db, hash, number); td == nil || td.Cmp(big.NewInt(200)) != 0 {
+		t.Fatalf("total difficulty mismatch: have %v, want %v", td, 200)
+	}
+	if blob := ReadBodyRLP(db, hash, number); blob != nil {
+		t.Fatalf("placeholder body returned: %x", blob)
+	}
+	if blob := ReadCanonicalBodyRLP(db, number); blob != nil {
+		t.Fatalf("placeholder canonical body returned: %x", blob)
+	}
+	if blob := ReadReceiptsRLP(db, hash, number); blob != nil {
+		t.Fatalf("placeholder receipts returned: %x", blob)
+	}
+	if body := ReadBody(db, hash, number); body != nil {
+		t.Fatalf("body returned: %v", body)
+	}
+	if block := ReadBlock(db, hash, number); block != nil {
+		t.Fatalf("block returned: %v", block)
+	}
+	if receipts := ReadReceipts(db, hash, number, params.TestChainConfig); receipts != nil {
+		t.Fatalf("receipts returned: %v", receipts)
+	}
+	if logs := ReadLogs(db, hash, number, params.TestChainConfig); logs != nil {
+		t.Fatalf("logs returned: %v", logs)
+	}
+	IndexTransactions(db, 0, 2, nil)
+
+	if len(logged) != 0 {
+		t.Fatalf("readers logged errors: %v", logged)
+	}
+	// The genesis history must still be available
+	if body := ReadBody(db, genesis.Hash(), 0); body == nil {
+		t.Fatal("genesis body missing")
+	}
+	if receipts := ReadRawReceipts(db, genesis.Hash(), 0); receipts == nil {
+		t.Fatal("genesis receipts missing")
+	}
+}
+
 func TestCanonicalHashIteration(t *testing.T) {
 	var cases = []struct {
 		from, to uint64
@@ -141,7 +141,17 @@ func iterateTransactions(db sdcdb.Database, from uint64, to uint64, reverse bool
 			}
 		}()
 		for data := range rlpCh {
+			// Blocks without stored history (e.g. ones synced as headers only)
+			// have no transactions to index.
 			var body types.Body
+			if len(data.rlp) == 0 {
+				select {
+				case hashesCh <- &blockTxHashes{number: data.number}:
+					continue
+				case <-interrupt:
+					return
+				}
+			}
 			if err := rlp.DecodeBytes(data.rlp, &body); err != nil {
 				log.Warn("Failed to decode block body", "block", data.number, "error", err)
 				return
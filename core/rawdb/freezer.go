@@ -85,6 +85,23 @@ type Freezer struct {
 // The 'tables' argument defines the data tables. If the value of a map
 // entry is true, snappy compression is disabled for the table.
 func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return newFreezer(datadir, namespace, readonly, false, maxTableSize, tables)
+}
+
+// NewSecondaryFreezer opens the freezer of another, running process in read-only
+// mode. The instance lock held by the owner is not acquired and the tables are
+// allowed to be out of sync, as the owner might be in the middle of appending to
+// them. Only the items present in all tables are exposed.
+//
+// Items frozen by the owner after opening are not picked up until the freezer
+// is refreshed.
+func NewSecondaryFreezer(datadir string, namespace string, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return newFreezer(datadir, namespace, true, true, maxTableSize, tables)
+}
+
+// newFreezer creates a freezer instance, optionally as the read-only secondary
+// of a freezer owned by another process.
+func newFreezer(datadir string, namespace string, readonly bool, secondary bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -99,10 +116,16 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 		}
 	}
 	// Leveldb uses LOCK as the filelock filename. To prevent the
-	// name collision, we use FLOCK as the lock name.
-	lock, _, err := fileutil.Flock(filepath.Join(datadir, "FLOCK"))
-	if err != nil {
-		return nil, err
+	// name collision, we use FLOCK as the lock name. Secondaries
+	// can't take the lock, it's held by the owner of the freezer.
+	var (
+		lock fileutil.Releaser
+		err  error
+	)
+	if !secondary {
+		if lock, _, err = fileutil.Flock(filepath.Join(datadir, "FLOCK")); err != nil {
+			return nil, err
+		}
 	}
 	// Open all the supported data tables
 	freezer := &Freezer{
@@ -113,22 +136,31 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 
 	// Create the tables.
 	for name, disableSnappy := range tables {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly)
+		table, err := openTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly, secondary)
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
 			}
-			lock.Release()
+			if lock != nil {
+				lock.Release()
+			}
 			return nil, err
 		}
 		freezer.tables[name] = table
 	}
 
-	if freezer.readonly {
+	switch {
+	case secondary:
+		// The owner might be appending to the tables, only expose the
+		// items already present in all of them.
+		head, tail := freezer.bounds()
+		atomic.StoreUint64(&freezer.frozen, head)
+		atomic.StoreUint64(&freezer.tail, tail)
+	case freezer.readonly:
 		// In readonly mode only validate, don't truncate.
 		// validate also sets `freezer.frozen`.
 		err = freezer.validate()
-	} else {
+	default:
 		// Truncate all tables to common length.
 		err = freezer.repair()
 	}
@@ -136,7 +168,9 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 		for _, table := range freezer.tables {
 			table.Close()
 		}
-		lock.Release()
+		if lock != nil {
+			lock.Release()
+		}
 		return nil, err
 	}
 
@@ -159,8 +193,10 @@ func (f *Freezer) Close() error {
 				errs = append(errs, err)
 			}
 		}
-		if err := f.instanceLock.Release(); err != nil {
-			errs = append(errs, err)
+		if f.instanceLock != nil {
+			if err := f.instanceLock.Release(); err != nil {
+				errs = append(errs, err)
+			}
 		}
 	})
 	if errs != nil {
@@ -345,12 +381,10 @@ func (f *Freezer) validate() error {
 	return nil
 }
 
-// repair truncates all data tables to the same length.
-func (f *Freezer) repair() error {
-	var (
-		head = uint64(math.MaxUint64)
-		tail = uint64(0)
-	)
+// bounds returns the number of items present in all tables and the highest
+// number of items hidden from the tail of any table.
+func (f *Freezer) bounds() (head uint64, tail uint64) {
+	head = math.MaxUint64
 	for _, table := range f.tables {
 		items := atomic.LoadUint64(&table.items)
 		if head > items {
@@ -361,6 +395,30 @@ func (f *Freezer) repair() error {
 			tail = hidden
 		}
 	}
+	return head, tail
+}
+
+// Refresh re-reads the indexes of a secondary freezer, picking up the items
+// frozen and pruned by the owner since it was opened or last refreshed. Only the
+// items present in all tables are exposed.
+func (f *Freezer) Refresh() error {
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	for _, table := range f.tables {
+		if err := table.refresh(); err != nil {
+			return err
+		}
+	}
+	head, tail := f.bounds()
+	atomic.StoreUint64(&f.frozen, head)
+	atomic.StoreUint64(&f.tail, tail)
+	return nil
+}
+
+// repair truncates all data tables to the same length.
+func (f *Freezer) repair() error {
+	head, tail := f.bounds()
 	for _, table := range f.tables {
 		if err := table.truncateHead(head); err != nil {
 			return err
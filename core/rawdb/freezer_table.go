@@ -103,6 +103,7 @@ type freezerTable struct {
 
 	noCompression bool // if true, disables snappy compression. Note: does not work retroactively
 	readonly      bool
+	secondary     bool   // if true, the table is read-only and still appended to by another process
 	maxFileSize   uint32 // Max file size for data-files
 	name          string
 	path          string
@@ -132,6 +133,15 @@ func newFreezerTable(path, name string, disableSnappy, readonly bool) (*freezerT
 // non-existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
 func newTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression, readonly bool) (*freezerTable, error) {
+	return openTable(path, name, readMeter, writeMeter, sizeGauge, maxFilesize, noCompression, readonly, false)
+}
+
+// openTable opens a freezer table. Secondary tables are opened read-only and
+// tolerate the index and data files being out of sync, since the owner of the
+// table might be in the middle of appending to it.
+func openTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression, readonly, secondary bool) (*freezerTable, error) {
+	readonly = readonly || secondary
+
 	// Ensure the containing directory exists and open the indexEntry file
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
@@ -180,6 +190,7 @@ func newTable(path string, name string, readMeter metrics.Meter, writeMeter metr
 		logger:        log.New("database", path, "table", name),
 		noCompression: noCompression,
 		readonly:      readonly,
+		secondary:     secondary,
 		maxFileSize:   maxFilesize,
 	}
 	if err := tab.repair(); err != nil {
@@ -213,8 +224,10 @@ func (t *freezerTable) repair() error {
 			return err
 		}
 	}
-	// Ensure the index is a multiple of indexEntrySize bytes
-	if overflow := stat.Size() % indexEntrySize; overflow != 0 {
+	// Ensure the index is a multiple of indexEntrySize bytes. For secondary
+	// tables the overflow might be an entry being written by the owner of the
+	// table, so it is only ignored.
+	if overflow := stat.Size() % indexEntrySize; overflow != 0 && !t.secondary {
 		truncateFreezerFile(t.index, stat.Size()-overflow) // New file can't trigger this path
 	}
 	// Retrieve the file sizes and prepare for truncation
@@ -222,6 +235,9 @@ func (t *freezerTable) repair() error {
 		return err
 	}
 	offsetsSize := stat.Size()
+	if t.secondary {
+		offsetsSize -= offsetsSize % indexEntrySize
+	}
 
 	// Open the head file
 	var (
@@ -240,14 +256,14 @@ func (t *freezerTable) repair() error {
 	// which is not enough in theory but enough in practice.
 	// TODO: use uint64 to represent total removed items.
 	t.tailId = firstIndex.filenum
-	t.itemOffset = uint64(firstIndex.offset)
+	atomic.StoreUint64(&t.itemOffset, uint64(firstIndex.offset))
 
 	// Load metadata from the file
-	meta, err := loadMetadata(t.meta, t.itemOffset)
+	meta, err := loadMetadata(t.meta, atomic.LoadUint64(&t.itemOffset))
 	if err != nil {
 		return err
 	}
-	t.itemHidden = meta.VirtualTail
+	atomic.StoreUint64(&t.itemHidden, meta.VirtualTail)
 
 	// Read the last index, use the default value in case the freezer is empty
 	if offsetsSize == indexEntrySize {
@@ -272,19 +288,29 @@ func (t *freezerTable) repair() error {
 	// Keep truncating both files until they come in sync
 	contentExp = int64(lastIndex.offset)
 	for contentExp != contentSize {
-		// Truncate the head file to the last offset pointer
+		// Truncate the head file to the last offset pointer. For secondary
+		// tables the dangling data is ignored instead, it might be an append
+		// of the owner of the table which is not yet indexed.
 		if contentExp < contentSize {
-			t.logger.Warn("Truncating dangling head", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
-			if err := truncateFreezerFile(t.head, contentExp); err != nil {
-				return err
+			if t.secondary {
+				t.logger.Debug("Ignoring dangling head", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
+			} else {
+				t.logger.Warn("Truncating dangling head", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
+				if err := truncateFreezerFile(t.head, contentExp); err != nil {
+					return err
+				}
 			}
 			contentSize = contentExp
 		}
 		// Truncate the index to point within the head file
 		if contentExp > contentSize {
-			t.logger.Warn("Truncating dangling indexes", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
-			if err := truncateFreezerFile(t.index, offsetsSize-indexEntrySize); err != nil {
-				return err
+			if t.secondary {
+				t.logger.Debug("Ignoring dangling indexes", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
+			} else {
+				t.logger.Warn("Truncating dangling indexes", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
+				if err := truncateFreezerFile(t.index, offsetsSize-indexEntrySize); err != nil {
+					return err
+				}
 			}
 			offsetsSize -= indexEntrySize
 
@@ -301,7 +327,12 @@ func (t *freezerTable) repair() error {
 			if newLastIndex.filenum != lastIndex.filenum {
 				// Release earlier opened file
 				t.releaseFile(lastIndex.filenum)
-				if t.head, err = t.openFile(newLastIndex.filenum, openFreezerFileForAppend); err != nil {
+				if t.readonly {
+					t.head, err = t.openFile(newLastIndex.filenum, openFreezerFileForReadOnly)
+				} else {
+					t.head, err = t.openFile(newLastIndex.filenum, openFreezerFileForAppend)
+				}
+				if err != nil {
 					return err
 				}
 				if stat, err = t.head.Stat(); err != nil {
@@ -329,15 +360,16 @@ func (t *freezerTable) repair() error {
 		}
 	}
 	// Update the item and byte counters and return
-	t.items = t.itemOffset + uint64(offsetsSize/indexEntrySize-1) // last indexEntry points to the end of the data file
+	atomic.StoreUint64(&t.items, atomic.LoadUint64(&t.itemOffset)+uint64(offsetsSize/indexEntrySize-1)) // last indexEntry points to the end of the data file
 	t.headBytes = contentSize
 	t.headId = lastIndex.filenum
 
-	// Delete the leftover files because of head deletion
-	t.releaseFilesAfter(t.headId, true)
+	// Delete the leftover files because of head deletion. Secondary tables
+	// must not delete anything, the files might be in use by the owner.
+	t.releaseFilesAfter(t.headId, !t.secondary)
 
 	// Delete the leftover files because of tail deletion
-	t.releaseFilesBefore(t.tailId, true)
+	t.releaseFilesBefore(t.tailId, !t.secondary)
 
 	// Close opened files and preopen all files
 	if err := t.preopen(); err != nil {
@@ -347,6 +379,48 @@ func (t *freezerTable) repair() error {
 	return nil
 }
 
+// refresh re-reads the index of a secondary table, picking up the items appended
+// and removed by the owner of the table since it was opened or last refreshed.
+func (t *freezerTable) refresh() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.secondary {
+		return errors.New("refresh requires a secondary table")
+	}
+	if t.index == nil {
+		return errClosed
+	}
+	before, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	// The owner replaces the index and metadata files when deleting from the
+	// tail, so reopen them instead of reading the stale ones.
+	index, err := openFreezerFileForReadOnly(t.index.Name())
+	if err != nil {
+		return err
+	}
+	meta, err := openFreezerFileForReadOnly(t.meta.Name())
+	if err != nil {
+		index.Close()
+		return err
+	}
+	t.index.Close()
+	t.meta.Close()
+	t.index, t.meta = index, meta
+
+	if err := t.repair(); err != nil {
+		return err
+	}
+	after, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeGauge.Inc(int64(after) - int64(before))
+	return nil
+}
+
 // preopen opens all files that the freezer will need. This msdcod should be called from an init-context,
 // since it assumes that it doesn't have to bother with locking
 // The rationale for doing preopen is to not have to do it from within Retrieve, thus not needing to ever
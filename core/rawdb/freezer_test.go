@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"math/rand"
 	"os"
@@ -283,6 +284,48 @@ func TestFreezerReadonlyValidate(t *testing.T) {
 	}
 }
 
+func TestFreezerSecondary(t *testing.T) {
+	tables := map[string]bool{"a": true, "b": true}
+	f, dir := newFreezerForTesting(t, tables)
+	defer f.Close()
+
+	// Fill the tables unevenly and leave some unindexed data behind, as if
+	// the owner was interrupted in the middle of an append.
+	var item = make([]byte, 1024)
+	aBatch := f.tables["a"].newBatch()
+	require.NoError(t, aBatch.AppendRaw(0, item))
+	require.NoError(t, aBatch.AppendRaw(1, item))
+	require.NoError(t, aBatch.AppendRaw(2, item))
+	require.NoError(t, aBatch.commit())
+	bBatch := f.tables["b"].newBatch()
+	require.NoError(t, bBatch.AppendRaw(0, item))
+	require.NoError(t, bBatch.AppendRaw(1, item))
+	require.NoError(t, bBatch.commit())
+	_, err := f.tables["b"].head.Write(item[:10])
+	require.NoError(t, err)
+
+	// Opening a secondary while the owner is still running should expose
+	// the items present in all tables without modifying any of them.
+	sf, err := NewSecondaryFreezer(dir, "", 2049, tables)
+	if err != nil {
+		t.Fatal("can't open secondary freezer", err)
+	}
+	defer sf.Close()
+
+	if frozen, _ := sf.Ancients(); frozen != 2 {
+		t.Fatalf("unexpected number of ancients: have %d, want %d", frozen, 2)
+	}
+	if _, err := sf.Ancient("a", 1); err != nil {
+		t.Fatalf("failed to retrieve item: %v", err)
+	}
+	if _, err := sf.ModifyAncients(func(op sdcdb.AncientWriteOp) error { return nil }); err != errReadOnly {
+		t.Fatalf("unexpected write error: have %v, want %v", err, errReadOnly)
+	}
+	if size, _ := f.tables["b"].head.Seek(0, io.SeekEnd); size != 2*1024+10 {
+		t.Fatalf("secondary modified the table: head size %d, want %d", size, 2*1024+10)
+	}
+}
+
 func newFreezerForTesting(t *testing.T, tables map[string]bool) (*Freezer, string) {
 	t.Helper()
 
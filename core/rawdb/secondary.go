@@ -0,0 +1,265 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/sdcdb/leveldb"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// errSecondaryClosed is returned if a secondary database is accessed after Close.
+var errSecondaryClosed = errors.New("secondary database closed")
+
+// catchUpper is implemented by key-value stores which are read-only views of a
+// database owned by another process, and which need to be refreshed to observe
+// the changes made by the owner.
+type catchUpper interface {
+	CatchUp() error
+}
+
+// secondarydb is a read-only database on top of the key-value store and the chain
+// freezer of another, running process. Both are periodically refreshed, so that
+// the database follows the writes of its owner.
+type secondarydb struct {
+	sdcdb.KeyValueStore
+	ancientRoot string // Root ancient directory of the owner
+
+	freezer *Freezer     // View of the chain freezer, nil after closing
+	lock    sync.RWMutex // Lock protecting the freezer view
+
+	quit chan chan error
+}
+
+// NewSecondaryDatabase creates a read-only high level database on top of the
+// given key-value store and the chain freezer in the root ancient directory of
+// another, running process. The key-value store itself needs to be a read-only
+// view of the owner's database, e.g. a secondary LevelDB instance.
+//
+// If refresh is non-zero, the key-value store and the freezer are periodically
+// refreshed to catch up with the owner. The freezer is always refreshed after the
+// key-value store, so that chain segments moved into the freezer in between are
+// not lost.
+func NewSecondaryDatabase(db sdcdb.KeyValueStore, ancient string, namespace string, refresh time.Duration) (sdcdb.Database, error) {
+	sdb := &secondarydb{
+		KeyValueStore: db,
+		ancientRoot:   ancient,
+		quit:          make(chan chan error),
+	}
+	freezer, err := NewSecondaryFreezer(resolveChainFreezerDir(ancient), namespace, freezerTableSize, chainFreezerNoSnappy)
+	if err != nil {
+		return nil, err
+	}
+	sdb.freezer = freezer
+
+	if refresh > 0 {
+		go sdb.loop(refresh)
+	} else {
+		close(sdb.quit)
+	}
+	return sdb, nil
+}
+
+// NewLevelDBSecondaryDatabase opens the LevelDB database and chain freezer of
+// another, running process as a read-only secondary, periodically catching up
+// with the changes made by the owner.
+func NewLevelDBSecondaryDatabase(file string, cache int, handles int, ancient string, namespace string, refresh time.Duration) (sdcdb.Database, error) {
+	kvdb, err := leveldb.NewSecondary(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	db, err := NewSecondaryDatabase(kvdb, ancient, namespace, refresh)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// loop periodically catches up with the owner of the database.
+func (db *secondarydb) loop(refresh time.Duration) {
+	timer := time.NewTimer(refresh)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := db.CatchUp(); err != nil {
+				log.Debug("Failed to catch up with primary database", "err", err)
+			}
+			timer.Reset(refresh)
+
+		case errc := <-db.quit:
+			errc <- nil
+			return
+		}
+	}
+}
+
+// CatchUp refreshes the key-value store and the freezer to observe the changes
+// made by the owner of the database since they were last refreshed.
+func (db *secondarydb) CatchUp() error {
+	if kvdb, ok := db.KeyValueStore.(catchUpper); ok {
+		if err := kvdb.CatchUp(); err != nil {
+			return err
+		}
+	}
+	return db.ancients(func(freezer *Freezer) error {
+		return freezer.Refresh()
+	})
+}
+
+// ancients runs the given read operation on the current freezer view.
+func (db *secondarydb) ancients(fn func(freezer *Freezer) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.freezer == nil {
+		return errSecondaryClosed
+	}
+	return fn(db.freezer)
+}
+
+// HasAncient returns an indicator whsdcer the specified data exists in the
+// ancient store.
+func (db *secondarydb) HasAncient(kind string, number uint64) (has bool, err error) {
+	err = db.ancients(func(freezer *Freezer) error {
+		has, err = freezer.HasAncient(kind, number)
+		return err
+	})
+	return has, err
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (db *secondarydb) Ancient(kind string, number uint64) (blob []byte, err error) {
+	err = db.ancients(func(freezer *Freezer) error {
+		blob, err = freezer.Ancient(kind, number)
+		return err
+	})
+	return blob, err
+}
+
+// AncientRange retrieves multiple items in sequence, starting from the index
+// 'start'.
+func (db *secondarydb) AncientRange(kind string, start, count, maxBytes uint64) (items [][]byte, err error) {
+	err = db.ancients(func(freezer *Freezer) error {
+		items, err = freezer.AncientRange(kind, start, count, maxBytes)
+		return err
+	})
+	return items, err
+}
+
+// Ancients returns the number of items available in the ancient store.
+func (db *secondarydb) Ancients() (items uint64, err error) {
+	err = db.ancients(func(freezer *Freezer) error {
+		items, err = freezer.Ancients()
+		return err
+	})
+	return items, err
+}
+
+// Tail returns the number of first stored item in the freezer.
+func (db *secondarydb) Tail() (tail uint64, err error) {
+	err = db.ancients(func(freezer *Freezer) error {
+		tail, err = freezer.Tail()
+		return err
+	})
+	return tail, err
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (db *secondarydb) AncientSize(kind string) (size uint64, err error) {
+	err = db.ancients(func(freezer *Freezer) error {
+		size, err = freezer.AncientSize(kind)
+		return err
+	})
+	return size, err
+}
+
+// ReadAncients runs the given read operation on a consistent view of the
+// ancient store.
+func (db *secondarydb) ReadAncients(fn func(sdcdb.AncientReaderOp) error) error {
+	return db.ancients(func(freezer *Freezer) error {
+		return freezer.ReadAncients(fn)
+	})
+}
+
+// ModifyAncients is not supported by a secondary database.
+func (db *secondarydb) ModifyAncients(func(sdcdb.AncientWriteOp) error) (int64, error) {
+	return 0, errReadOnly
+}
+
+// TruncateHead is not supported by a secondary database.
+func (db *secondarydb) TruncateHead(items uint64) error {
+	return errReadOnly
+}
+
+// TruncateTail is not supported by a secondary database.
+func (db *secondarydb) TruncateTail(items uint64) error {
+	return errReadOnly
+}
+
+// Sync is not supported by a secondary database.
+func (db *secondarydb) Sync() error {
+	return errReadOnly
+}
+
+// MigrateTable is not supported by a secondary database.
+func (db *secondarydb) MigrateTable(kind string, convert convertLegacyFn) error {
+	return errReadOnly
+}
+
+// AncientDatadir returns the path of root ancient directory.
+func (db *secondarydb) AncientDatadir() (string, error) {
+	return db.ancientRoot, nil
+}
+
+// Close stops catching up with the owner and releases both the key-value store
+// and the freezer.
+func (db *secondarydb) Close() error {
+	select {
+	case <-db.quit:
+	default:
+		errc := make(chan error)
+		db.quit <- errc
+		<-errc
+		close(db.quit)
+	}
+	db.lock.Lock()
+	freezer := db.freezer
+	db.freezer = nil
+	db.lock.Unlock()
+
+	var errs []error
+	if freezer != nil {
+		if err := freezer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := db.KeyValueStore.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
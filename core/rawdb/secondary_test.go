@@ -0,0 +1,103 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/sdcereum/go-sdcereum/core/types"
+)
+
+// Tests that a secondary database follows the key-value store and the chain
+// freezer of a primary database still in use.
+func TestSecondaryDatabase(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		ancient = filepath.Join(dir, "ancient")
+	)
+	primary, err := NewLevelDBDatabaseWithFreezer(dir, 0, 0, ancient, "", false)
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	defer primary.Close()
+
+	blocks := make([]*types.Block, 4)
+	receipts := make([]types.Receipts, len(blocks))
+	for i := range blocks {
+		blocks[i] = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), Extra: []byte("test block")})
+	}
+	if _, err := WriteAncientBlocks(primary, blocks[:2], receipts[:2], big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	WriteHeadBlockHash(primary, blocks[1].Hash())
+
+	secondary, err := NewLevelDBSecondaryDatabase(dir, 0, 0, ancient, "", 0)
+	if err != nil {
+		t.Fatalf("failed to open secondary: %v", err)
+	}
+	defer secondary.Close()
+
+	if head := ReadHeadBlockHash(secondary); head != blocks[1].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, blocks[1].Hash())
+	}
+	if header := ReadHeader(secondary, blocks[1].Hash(), 1); header == nil {
+		t.Fatal("ancient header missing")
+	}
+	if _, err := WriteAncientBlocks(secondary, blocks[2:], receipts[2:], big.NewInt(100)); err == nil {
+		t.Fatal("secondary accepted ancient write")
+	}
+	// Advance the primary and ensure the changes show up after catching up
+	if _, err := WriteAncientBlocks(primary, blocks[2:], receipts[2:], big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	WriteHeadBlockHash(primary, blocks[3].Hash())
+
+	if frozen, _ := secondary.Ancients(); frozen != 2 {
+		t.Fatalf("ancient count mismatch before catching up: have %d, want %d", frozen, 2)
+	}
+	freezer := secondary.(*secondarydb).freezer
+	if err := secondary.(*secondarydb).CatchUp(); err != nil {
+		t.Fatalf("failed to catch up: %v", err)
+	}
+	if secondary.(*secondarydb).freezer != freezer {
+		t.Fatal("freezer reopened while catching up")
+	}
+	if frozen, _ := secondary.Ancients(); frozen != 4 {
+		t.Fatalf("ancient count mismatch after catching up: have %d, want %d", frozen, 4)
+	}
+	if head := ReadHeadBlockHash(secondary); head != blocks[3].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, blocks[3].Hash())
+	}
+	if header := ReadHeader(secondary, blocks[3].Hash(), 3); header == nil {
+		t.Fatal("caught up ancient header missing")
+	}
+	// Prune the primary's freezer and ensure the tail is followed
+	if err := primary.TruncateTail(2); err != nil {
+		t.Fatalf("failed to truncate tail: %v", err)
+	}
+	if err := secondary.(*secondarydb).CatchUp(); err != nil {
+		t.Fatalf("failed to catch up: %v", err)
+	}
+	if tail, _ := secondary.Tail(); tail != 2 {
+		t.Fatalf("ancient tail mismatch after catching up: have %d, want %d", tail, 2)
+	}
+	if header := ReadHeader(secondary, blocks[3].Hash(), 3); header == nil {
+		t.Fatal("ancient header missing after pruning")
+	}
+}
@@ -0,0 +1,688 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/core/state/snapshot"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/metrics"
+	"github.com/sdcereum/go-sdcereum/rlp"
+	"github.com/sdcereum/go-sdcereum/trie"
+)
+
+const (
+	// onlineRetention is the number of blocks the chain keeps state tries in
+	// memory for. The online pruner has to wait for this many blocks on top of
+	// the cycle's starting point before sweeping, so that no state older than
+	// the pruning target is referenced anymore.
+	onlineRetention = 128
+
+	// onlineMarkBatch is the number of trie nodes marked between two throttle
+	// pauses while the target state is being traversed.
+	onlineMarkBatch = 10000
+
+	// onlineFlushTimeout is the maximum time to wait for the chain to persist
+	// a recent state trie which can be used as the pruning target.
+	onlineFlushTimeout = 10 * time.Minute
+)
+
+// onlineRecheck is the time interval to recheck the chain progress while waiting
+// on either a persisted target or on the retention window.
+var onlineRecheck = 3 * time.Second
+
+var (
+	onlineMarkedMeter      = metrics.NewRegisteredMeter("state/pruner/online/marked", nil)
+	onlineDeletedMeter     = metrics.NewRegisteredMeter("state/pruner/online/deleted", nil)
+	onlineDeletedSizeMeter = metrics.NewRegisteredMeter("state/pruner/online/deleted/size", nil)
+	onlineProgressGauge    = metrics.NewRegisteredGauge("state/pruner/online/progress", nil)
+	onlineCycleTimer       = metrics.NewRegisteredTimer("state/pruner/online/cycle", nil)
+)
+
+var (
+	// errPrunerRunning is returned if a pruning cycle is requested while the
+	// previous one is still in progress.
+	errPrunerRunning = errors.New("state pruning already in progress")
+
+	// errPrunerStopped is returned if the pruner was terminated.
+	errPrunerStopped = errors.New("state pruner stopped")
+
+	// errNoSnapshot is returned if the chain doesn't maintain a snapshot, which
+	// is required to track the recently modified state.
+	errNoSnapshot = errors.New("state snapshot not available")
+
+	// errNoTarget is returned if the chain failed to persist a recent state
+	// trie in time, which would be retained by the pruning cycle.
+	errNoTarget = errors.New("no persisted state available as pruning target")
+
+	// errPrunerSyncing is returned if a pruning cycle is attempted or running
+	// while the node is syncing. The downloaded state is written to the database
+	// directly, without passing the flush hook, so it can't be protected.
+	errPrunerSyncing = errors.New("node is syncing")
+
+	// errSnapshotGenerating is returned if a pruning cycle is attempted or
+	// running while the state snapshot is being generated.
+	errSnapshotGenerating = errors.New("state snapshot is being generated")
+)
+
+// Pruning phases reported by the online pruner.
+const (
+	PhaseIdle     = "idle"
+	PhaseMarking  = "marking"
+	PhaseWaiting  = "waiting"
+	PhaseSweeping = "sweeping"
+)
+
+// OnlineConfig includes all the configurations for online pruning.
+type OnlineConfig struct {
+	BloomSize uint64        // Megabytes of memory allocated to the bloom filter of a cycle
+	Interval  time.Duration // Time between two automatic pruning cycles, zero to only prune on demand
+	Throttle  time.Duration // Pause after every processed batch to limit the load on the database
+}
+
+// OnlineChain defines the small collection of functions needed from the chain to
+// prune state while it's running.
+type OnlineChain interface {
+	// CurrentBlock retrieves the current head block of the canonical chain.
+	CurrentBlock() *types.Block
+
+	// Snapshots returns the snapshot tree maintained by the chain.
+	Snapshots() *snapshot.Tree
+
+	// RequestTrieFlush schedules the next state trie leaving the in-memory
+	// retention window to be persisted in full.
+	RequestTrieFlush()
+}
+
+// SyncStatus reports whether the node is currently syncing chain or state data
+// from the network.
+type SyncStatus func() bool
+
+// OnlineStatus is a progress report of the online pruner.
+type OnlineStatus struct {
+	Phase    string      `json:"phase"`           // Current phase of the pruning cycle
+	Target   common.Hash `json:"target"`          // State root retained by the running cycle
+	Marked   uint64      `json:"marked"`          // Number of live entries marked in the running cycle
+	Deleted  uint64      `json:"deleted"`         // Number of stale trie nodes deleted in the running cycle
+	Size     uint64      `json:"size"`            // Storage size of the stale trie nodes deleted in the running cycle
+	Progress float64     `json:"progress"`        // Percentage of the database swept in the running cycle
+	Cycles   uint64      `json:"cycles"`          // Number of pruning cycles completed successfully
+	Error    string      `json:"error,omitempty"` // Failure of the last pruning cycle, if any
+}
+
+// liveSet is a thread safe wrapper around the state bloom, tracking the live
+// entries of a running pruning cycle. Its lock also serializes the deletion of
+// stale nodes with trie nodes being flushed to disk by the trie database.
+type liveSet struct {
+	bloom *stateBloom
+	lock  sync.Mutex
+}
+
+// Put implements the KeyValueWriter interface. But here only the key is needed.
+func (s *liveSet) Put(key []byte, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	onlineMarkedMeter.Mark(1)
+	return s.bloom.Put(key, nil)
+}
+
+// Delete removes the key from the key-value data store.
+func (s *liveSet) Delete(key []byte) error { panic("not supported") }
+
+// flushed is the trie database hook marking every node persisted during the
+// pruning cycle as live.
+func (s *liveSet) flushed(hash common.Hash) {
+	s.Put(hash.Bytes(), nil)
+}
+
+// contains reports if the key is possibly live. It assumes the lock is held.
+func (s *liveSet) contains(key []byte) bool {
+	ok, _ := s.bloom.Contain(key)
+	return ok
+}
+
+// OnlinePruner prunes stale state incrementally in the background while the
+// chain keeps importing blocks. A pruning cycle works as follows:
+//
+//   - hook into the trie database, marking every node flushed to disk as live
+//   - mark all nodes on the paths modified by the snapshot diff layers
+//   - pick the oldest persisted state within the snapshot layers as target
+//     and mark its entire trie, along with the genesis state
+//   - wait until the target left the chain's in-memory retention window
+//   - sweep the database, deleting all hash-keyed trie nodes not marked
+//
+// Nodes shared between the target and newer states are retained by marking the
+// target, nodes created by newer states are retained either via the diff layers
+// or via the flush hook. Contrary to the offline pruner, an interrupted cycle
+// does not leave the database in an inconsistent state, so no recovery is needed.
+type OnlinePruner struct {
+	config  OnlineConfig
+	db      sdcdb.Database
+	triedb  *trie.Database
+	chain   OnlineChain
+	syncing SyncStatus
+
+	status     OnlineStatus
+	statusLock sync.RWMutex
+
+	trigger chan struct{}
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewOnlinePruner creates the online pruner instance. The returned pruner needs
+// to be started before it accepts any pruning requests. Pruning cycles are skipped
+// or aborted as long as the given sync status reports the node to be syncing.
+func NewOnlinePruner(db sdcdb.Database, triedb *trie.Database, chain OnlineChain, syncing SyncStatus, config OnlineConfig) *OnlinePruner {
+	// Sanitize the bloom filter size if it's too small.
+	if config.BloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", config.BloomSize, "updated(MB)", 256)
+		config.BloomSize = 256
+	}
+	return &OnlinePruner{
+		config:  config,
+		db:      db,
+		triedb:  triedb,
+		chain:   chain,
+		syncing: syncing,
+		status:  OnlineStatus{Phase: PhaseIdle},
+		trigger: make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Start launches the background pruning loop.
+func (p *OnlinePruner) Start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+// Stop terminates the background pruning loop, aborting any running cycle.
+func (p *OnlinePruner) Stop() {
+	close(p.closeCh)
+	p.wg.Wait()
+}
+
+// Prune requests a new pruning cycle to be started immediately. The call does
+// not wait for the cycle to complete, its progress can be tracked via Status.
+func (p *OnlinePruner) Prune() error {
+	select {
+	case <-p.closeCh:
+		return errPrunerStopped
+	default:
+	}
+	if p.Status().Phase != PhaseIdle {
+		return errPrunerRunning
+	}
+	select {
+	case p.trigger <- struct{}{}:
+		return nil
+	default:
+		return errPrunerRunning // A cycle was already requested
+	}
+}
+
+// Status returns a progress report of the online pruner.
+func (p *OnlinePruner) Status() OnlineStatus {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+
+	return p.status
+}
+
+// loop runs pruning cycles either periodically or on demand.
+func (p *OnlinePruner) loop() {
+	defer p.wg.Done()
+
+	var (
+		timer *time.Timer
+		wait  <-chan time.Time
+	)
+	if p.config.Interval > 0 {
+		timer = time.NewTimer(p.config.Interval)
+		defer timer.Stop()
+		wait = timer.C
+	}
+	for {
+		select {
+		case <-wait:
+		case <-p.trigger:
+			if timer != nil && !timer.Stop() {
+				<-timer.C
+			}
+		case <-p.closeCh:
+			return
+		}
+		p.run()
+		if timer != nil {
+			timer.Reset(p.config.Interval)
+		}
+	}
+}
+
+// run executes a single pruning cycle and records its outcome.
+func (p *OnlinePruner) run() {
+	start := time.Now()
+	p.statusLock.Lock()
+	p.status = OnlineStatus{Phase: PhaseMarking, Cycles: p.status.Cycles}
+	p.statusLock.Unlock()
+
+	err := p.prune(start)
+
+	p.statusLock.Lock()
+	p.status.Phase = PhaseIdle
+	if err != nil {
+		p.status.Error = err.Error()
+	} else {
+		p.status.Cycles++
+	}
+	p.statusLock.Unlock()
+
+	if err != nil {
+		switch err {
+		case errPrunerStopped:
+			log.Info("Online state pruning aborted")
+		case errPrunerSyncing, errSnapshotGenerating:
+			log.Info("Online state pruning postponed", "reason", err)
+		default:
+			log.Error("Online state pruning failed", "err", err)
+		}
+		return
+	}
+	onlineCycleTimer.UpdateSince(start)
+}
+
+// prune marks all the live state entries and deletes every stale trie node.
+func (p *OnlinePruner) prune(start time.Time) error {
+	snaptree := p.chain.Snapshots()
+	if snaptree == nil {
+		return errNoSnapshot
+	}
+	if err := p.ready(); err != nil {
+		return err
+	}
+	bloom, err := newStateBloomWithSize(p.config.BloomSize)
+	if err != nil {
+		return err
+	}
+	// Track all the trie nodes persisted from now on. Nodes written before the
+	// hook is installed are covered either by the target or the diff layers.
+	live := &liveSet{bloom: bloom}
+	p.triedb.SetFlushHook(live.flushed)
+	defer p.triedb.SetFlushHook(nil)
+
+	// Mark all the nodes created by the recent state transitions. It's done
+	// before anything else, while all the relevant states are still available
+	// in the trie database.
+	head := p.chain.CurrentBlock()
+	layers := snaptree.Snapshots(head.Root(), onlineRetention+1, false)
+	if len(layers) == 0 {
+		return errNoSnapshot
+	}
+	if err := p.markLayers(snaptree, layers, live); err != nil {
+		return err
+	}
+	// Pick the oldest state within the snapshot layers which is persisted in
+	// full as the pruning target, asking the chain to persist one if needed.
+	root, err := p.findTarget(layers)
+	if err != nil {
+		return err
+	}
+	p.statusLock.Lock()
+	p.status.Target = root
+	p.statusLock.Unlock()
+
+	log.Info("Marking live state for online pruning", "target", root, "head", head.NumberU64())
+	var marked int
+	err = extractState(p.db, root, live, func() error {
+		if marked++; marked%onlineMarkBatch != 0 {
+			return nil
+		}
+		p.statusLock.Lock()
+		p.status.Marked = uint64(marked)
+		p.statusLock.Unlock()
+		return p.pause()
+	})
+	if err != nil {
+		return err
+	}
+	if err := extractGenesis(p.db, live); err != nil {
+		return err
+	}
+	p.statusLock.Lock()
+	p.status.Marked = uint64(marked)
+	p.status.Phase = PhaseWaiting
+	p.statusLock.Unlock()
+
+	// Wait until every state older than the target is released by the chain,
+	// then delete all the unmarked trie nodes.
+	if err := p.waitRetention(head.NumberU64() + onlineRetention); err != nil {
+		return err
+	}
+	if err := p.ready(); err != nil {
+		return err
+	}
+	p.statusLock.Lock()
+	p.status.Phase = PhaseSweeping
+	p.statusLock.Unlock()
+
+	return p.sweep(live, start)
+}
+
+// markLayers marks all the trie nodes on the paths modified by the given diff
+// layers, ordered from the newest to the oldest. If the state of a layer is
+// not available anymore, its modifications are carried over to the next newer
+// layer.
+func (p *OnlinePruner) markLayers(snaptree *snapshot.Tree, layers []snapshot.Snapshot, live *liveSet) error {
+	pending := make(map[common.Hash]map[common.Hash]struct{})
+	for i := len(layers) - 1; i >= 0; i-- {
+		root := layers[i].Root()
+		for account, slots := range snaptree.DiffKeys(root) {
+			if pending[account] == nil {
+				pending[account] = make(map[common.Hash]struct{})
+			}
+			for _, slot := range slots {
+				pending[account][slot] = struct{}{}
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		if err := markPaths(p.triedb, root, pending, live); err != nil {
+			if i == 0 {
+				return err // The head state must be available
+			}
+			log.Debug("Carrying over unavailable state modifications", "root", root, "accounts", len(pending), "err", err)
+			continue
+		}
+		pending = make(map[common.Hash]map[common.Hash]struct{})
+
+		if err := p.pause(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathMarker is a proof writer marking the proven trie nodes as live, along with
+// the nodes they reference. Inserting a key may split a node on its path, which
+// moves the remainder of that node into a new sibling off the proven path.
+type pathMarker struct {
+	live sdcdb.KeyValueWriter
+}
+
+// Put implements the KeyValueWriter interface, marking the node and its children.
+func (m *pathMarker) Put(key []byte, value []byte) error {
+	if err := m.live.Put(key, nil); err != nil {
+		return err
+	}
+	elems, _, err := rlp.SplitList(value)
+	if err != nil {
+		return err
+	}
+	for len(elems) > 0 {
+		kind, content, rest, err := rlp.Split(elems)
+		if err != nil {
+			return err
+		}
+		// Children are referenced by hash unless embedded. Values of the same
+		// length are marked too, which is harmless as they're not trie nodes.
+		if kind == rlp.String && len(content) == common.HashLength {
+			if err := m.live.Put(content, nil); err != nil {
+				return err
+			}
+		}
+		elems = rest
+	}
+	return nil
+}
+
+// Delete removes the key from the key-value data store.
+func (m *pathMarker) Delete(key []byte) error { panic("not supported") }
+
+// markPaths marks the trie nodes on the paths to the given accounts and their
+// storage slots within the state of the given root, along with their children.
+func markPaths(triedb *trie.Database, root common.Hash, keys map[common.Hash]map[common.Hash]struct{}, live sdcdb.KeyValueWriter) error {
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), triedb)
+	if err != nil {
+		return err
+	}
+	marker := &pathMarker{live: live}
+	for account, slots := range keys {
+		if err := accTrie.Prove(account.Bytes(), 0, marker); err != nil {
+			return err
+		}
+		if len(slots) == 0 {
+			continue
+		}
+		acc, err := accTrie.TryGetAccountWithPreHashedKey(account.Bytes())
+		if err != nil {
+			return err
+		}
+		if acc == nil || acc.Root == emptyRoot {
+			continue
+		}
+		storageTrie, err := trie.NewStateTrie(trie.StorageTrieID(root, account, acc.Root), triedb)
+		if err != nil {
+			return err
+		}
+		for slot := range slots {
+			if err := storageTrie.Prove(slot.Bytes(), 0, marker); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findTarget returns the root of the oldest layer whose state is persisted in
+// full. If there's none, the chain is requested to persist the next state trie
+// leaving the retention window, which belongs to one of the given layers.
+func (p *OnlinePruner) findTarget(layers []snapshot.Snapshot) (common.Hash, error) {
+	var (
+		requested bool
+		deadline  = time.NewTimer(onlineFlushTimeout)
+	)
+	defer deadline.Stop()
+
+	for {
+		// The weak assumption is the presence of the root can indicate the
+		// presence of the entire trie.
+		for i := len(layers) - 1; i >= 0; i-- {
+			if root := layers[i].Root(); rawdb.HasTrieNode(p.db, root) {
+				return root, nil
+			}
+		}
+		if !requested {
+			log.Info("Requesting state flush for online pruning")
+			p.chain.RequestTrieFlush()
+			requested = true
+		}
+		select {
+		case <-time.After(onlineRecheck):
+		case <-deadline.C:
+			return common.Hash{}, errNoTarget
+		case <-p.closeCh:
+			return common.Hash{}, errPrunerStopped
+		}
+	}
+}
+
+// waitRetention blocks until the chain head reaches the given block number.
+func (p *OnlinePruner) waitRetention(number uint64) error {
+	for p.chain.CurrentBlock().NumberU64() < number {
+		if err := p.ready(); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(onlineRecheck):
+		case <-p.closeCh:
+			return errPrunerStopped
+		}
+	}
+	return nil
+}
+
+// sweep iterates the database and deletes all the hash-keyed trie nodes which
+// are not marked as live.
+func (p *OnlinePruner) sweep(live *liveSet, start time.Time) error {
+	var (
+		count  uint64
+		size   common.StorageSize
+		stale  [][]byte
+		pstart = time.Now()
+		logged = time.Now()
+		batch  = p.db.NewBatch()
+		iter   = p.db.NewIterator(nil, nil)
+	)
+	defer func() { iter.Release() }()
+
+	// flush deletes the collected stale nodes. The liveness is rechecked while
+	// holding the lock, since the nodes might have been flushed again by the
+	// trie database since they were collected.
+	flush := func() error {
+		live.lock.Lock()
+		defer live.lock.Unlock()
+
+		for _, key := range stale {
+			if !live.contains(key) {
+				batch.Delete(key)
+			}
+		}
+		stale = stale[:0]
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for iter.Next() {
+		key := iter.Key()
+
+		// Only the legacy hash-keyed trie nodes are pruned, contract codes are
+		// retained as they are deduplicated across states anyway.
+		if len(key) != common.HashLength {
+			continue
+		}
+		live.lock.Lock()
+		ok := live.contains(key)
+		live.lock.Unlock()
+		if ok {
+			continue
+		}
+		stale = append(stale, common.CopyBytes(key))
+		count += 1
+		size += common.StorageSize(len(key) + len(iter.Value()))
+
+		if len(stale)*common.HashLength < sdcdb.IdealBatchSize {
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		progress := float64(binary.BigEndian.Uint64(key[:8])) / math.MaxUint64 * 100
+
+		onlineDeletedMeter.Mark(int64(count) - int64(p.Status().Deleted))
+		onlineDeletedSizeMeter.Mark(int64(size) - int64(p.Status().Size))
+		onlineProgressGauge.Update(int64(progress))
+
+		p.statusLock.Lock()
+		p.status.Deleted, p.status.Size, p.status.Progress = count, uint64(size), progress
+		p.statusLock.Unlock()
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data", "nodes", count, "size", size, "progress", progress,
+				"elapsed", common.PrettyDuration(time.Since(pstart)))
+			logged = time.Now()
+		}
+		if err := p.pause(); err != nil {
+			return err
+		}
+		// Recreate the iterator after every batch commit in order
+		// to allow the underlying compactor to delete the entries.
+		iter.Release()
+		iter = p.db.NewIterator(nil, key)
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	onlineDeletedMeter.Mark(int64(count) - int64(p.Status().Deleted))
+	onlineDeletedSizeMeter.Mark(int64(size) - int64(p.Status().Size))
+	onlineProgressGauge.Update(100)
+
+	p.statusLock.Lock()
+	p.status.Deleted, p.status.Size, p.status.Progress = count, uint64(size), 100
+	p.statusLock.Unlock()
+
+	log.Info("Online state pruning successful", "nodes", count, "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ready returns an error if the database is being written to by anything else
+// than the trie database, namely by the downloader during sync or by the snapshot
+// generator. Their nodes don't pass the flush hook, so they would be swept.
+func (p *OnlinePruner) ready() error {
+	if p.syncing != nil && p.syncing() {
+		return errPrunerSyncing
+	}
+	generating, err := p.chain.Snapshots().Generating()
+	if err != nil {
+		return err
+	}
+	if generating {
+		return errSnapshotGenerating
+	}
+	return nil
+}
+
+// pause blocks for the configured throttle duration, returning an error if the
+// pruner is stopped meanwhile or the node started syncing.
+func (p *OnlinePruner) pause() error {
+	if err := p.ready(); err != nil {
+		return err
+	}
+	if p.config.Throttle == 0 {
+		select {
+		case <-p.closeCh:
+			return errPrunerStopped
+		default:
+			return nil
+		}
+	}
+	timer := time.NewTimer(p.config.Throttle)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-p.closeCh:
+		return errPrunerStopped
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/consensus/sdcash"
+	"github.com/sdcereum/go-sdcereum/core"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/core/vm"
+	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/params"
+	"github.com/sdcereum/go-sdcereum/rlp"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/trie"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testStorage = common.HexToAddress("0xaaaa")
+)
+
+// onlineTestChain is a chain with state changes in every block, tracking the
+// state flush requests of the pruner.
+type onlineTestChain struct {
+	*core.BlockChain
+	diskdb  sdcdb.Database
+	triedb  *trie.Database
+	blocks  []*types.Block
+	flushes chan struct{}
+}
+
+// newOnlineTestChain creates a chain with snapshots enabled and generates the
+// given number of blocks for it, inserting the first few. Every block creates a
+// new account and writes a new slot into the storage of a contract.
+func newOnlineTestChain(t *testing.T, blocks int, insert int) *onlineTestChain {
+	t.Helper()
+
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			testAddr:    {Balance: big.NewInt(params.sdcer)},
+			testStorage: {Balance: new(big.Int), Code: []byte{byte(vm.NUMBER), byte(vm.NUMBER), byte(vm.SSTORE)}},
+		},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	signer := types.LatestSigner(gspec.Config)
+	_, generated, _ := core.GenerateChainWithGenesis(gspec, sdcash.NewFaker(), blocks, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testAddr), common.BigToAddress(big.NewInt(int64(0x1000+i))), big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, testKey)
+		b.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTransaction(b.TxNonce(testAddr), testStorage, new(big.Int), 100000, b.BaseFee(), nil), signer, testKey)
+		b.AddTx(tx)
+	})
+	config := &core.CacheConfig{
+		TrieCleanLimit: 16,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+		SnapshotLimit:  16,
+		SnapshotWait:   true,
+	}
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, config, gspec, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	c := &onlineTestChain{
+		BlockChain: chain,
+		diskdb:     db,
+		triedb:     chain.StateCache().TrieDB(),
+		blocks:     generated,
+		flushes:    make(chan struct{}, 1),
+	}
+	c.insert(t, 0, insert)
+	return c
+}
+
+// RequestTrieFlush implements OnlineChain, signalling the flush request to the test.
+func (c *onlineTestChain) RequestTrieFlush() {
+	c.BlockChain.RequestTrieFlush()
+	select {
+	case c.flushes <- struct{}{}:
+	default:
+	}
+}
+
+// insert imports the generated blocks within the given range.
+func (c *onlineTestChain) insert(t *testing.T, from, to int) {
+	t.Helper()
+
+	if _, err := c.InsertChain(c.blocks[from:to]); err != nil {
+		t.Fatalf("failed to insert blocks %d-%d: %v", from+1, to, err)
+	}
+}
+
+// persist writes the state of the given block number to disk in full.
+func (c *onlineTestChain) persist(t *testing.T, number int) common.Hash {
+	t.Helper()
+
+	root := c.blocks[number-1].Root()
+	if err := c.triedb.Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to persist state %d: %v", number, err)
+	}
+	return root
+}
+
+// checkState iterates the entire state with the given root, failing if any of its
+// trie nodes is missing from the database.
+func checkState(t *testing.T, triedb *trie.Database, root common.Hash) {
+	t.Helper()
+
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), triedb)
+	if err != nil {
+		t.Fatalf("state %x: failed to open account trie: %v", root, err)
+	}
+	accIter := accTrie.NodeIterator(nil)
+	for accIter.Next(true) {
+		if !accIter.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
+			t.Fatalf("state %x: invalid account: %v", root, err)
+		}
+		if acc.Root == emptyRoot {
+			continue
+		}
+		id := trie.StorageTrieID(root, common.BytesToHash(accIter.LeafKey()), acc.Root)
+		storageTrie, err := trie.NewStateTrie(id, triedb)
+		if err != nil {
+			t.Fatalf("state %x: failed to open storage trie: %v", root, err)
+		}
+		storageIter := storageTrie.NodeIterator(nil)
+		for storageIter.Next(true) {
+		}
+		if err := storageIter.Error(); err != nil {
+			t.Fatalf("state %x: storage trie incomplete: %v", root, err)
+		}
+	}
+	if err := accIter.Error(); err != nil {
+		t.Fatalf("state %x: account trie incomplete: %v", root, err)
+	}
+}
+
+// waitPhase blocks until the pruner reaches the given phase.
+func waitPhase(t *testing.T, p *OnlinePruner, phase string) {
+	t.Helper()
+
+	for start := time.Now(); p.Status().Phase != phase; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("pruner stuck in phase %s, want %s", p.Status().Phase, phase)
+		}
+	}
+}
+
+func newTestPruner(chain *onlineTestChain, syncing SyncStatus) *OnlinePruner {
+	p := NewOnlinePruner(chain.diskdb, chain.triedb, chain, syncing, OnlineConfig{})
+	p.config.BloomSize = 1 // Plenty for the test states, don't allocate the default
+	return p
+}
+
+// Tests that a pruning cycle deletes the stale trie nodes while retaining the
+// target state and all newer ones, both the ones persisted before the cycle and
+// the ones flushed while it's running.
+func TestOnlinePruner(t *testing.T) {
+	defer func(recheck time.Duration) { onlineRecheck = recheck }(onlineRecheck)
+	onlineRecheck = 10 * time.Millisecond
+
+	chain := newOnlineTestChain(t, 330, 50)
+	stale := chain.persist(t, 50)
+
+	chain.insert(t, 50, 200)
+	target := chain.persist(t, 190)
+	recent := chain.persist(t, 200) // Only retained by marking the diff layers
+
+	p := newTestPruner(chain, nil)
+	errc := make(chan error, 1)
+	go func() { errc <- p.prune(time.Now()) }()
+
+	waitPhase(t, p, PhaseWaiting)
+	if status := p.Status(); status.Target != target {
+		t.Fatalf("target mismatch: have %x, want %x", status.Target, target)
+	}
+	// Persist a state created during the cycle, only retained by the flush hook
+	chain.insert(t, 200, 260)
+	flushed := chain.persist(t, 260)
+
+	// Progress the chain beyond the retention window and wait for the sweep
+	chain.insert(t, 260, 330)
+	if err := <-errc; err != nil {
+		t.Fatalf("pruning failed: %v", err)
+	}
+	status := p.Status()
+	if status.Deleted == 0 || status.Progress != 100 {
+		t.Errorf("nothing swept: %+v", status)
+	}
+	if rawdb.HasTrieNode(chain.diskdb, stale) {
+		t.Errorf("stale state root %x not deleted", stale)
+	}
+	// Ensure all the retained states are complete on disk, and that all the
+	// states in memory are not missing any persisted nodes
+	disk := trie.NewDatabase(chain.diskdb)
+	for _, root := range []common.Hash{chain.Genesis().Root(), target, recent, flushed} {
+		checkState(t, disk, root)
+	}
+	for number := 330 - core.TriesInMemory + 1; number <= 330; number++ {
+		checkState(t, chain.triedb, chain.blocks[number-1].Root())
+	}
+}
+
+// Tests that the oldest persisted state within the snapshot layers is picked as
+// the pruning target, and that a state flush is requested if there's none.
+func TestOnlinePrunerTarget(t *testing.T) {
+	defer func(recheck time.Duration) { onlineRecheck = recheck }(onlineRecheck)
+	onlineRecheck = 10 * time.Millisecond
+
+	// Persisted states are picked from the oldest
+	chain := newOnlineTestChain(t, 200, 200)
+	chain.persist(t, 180)
+	oldest := chain.persist(t, 150)
+
+	p := newTestPruner(chain, nil)
+	layers := chain.Snapshots().Snapshots(chain.CurrentBlock().Root(), onlineRetention+1, false)
+	if root, err := p.findTarget(layers); err != nil || root != oldest {
+		t.Errorf("target mismatch: have %x (%v), want %x", root, err, oldest)
+	}
+	// Without any persisted state, the next one leaving memory is flushed
+	chain = newOnlineTestChain(t, 201, 200)
+	p = newTestPruner(chain, nil)
+	layers = chain.Snapshots().Snapshots(chain.CurrentBlock().Root(), onlineRetention+1, false)
+
+	type result struct {
+		root common.Hash
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		root, err := p.findTarget(layers)
+		resc <- result{root, err}
+	}()
+	select {
+	case <-chain.flushes:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("state flush not requested")
+	}
+	chain.insert(t, 200, 201)
+
+	want := chain.blocks[201-core.TriesInMemory-1].Root()
+	if res := <-resc; res.err != nil || res.root != want {
+		t.Errorf("target mismatch: have %x (%v), want %x", res.root, res.err, want)
+	}
+}
+
+// Tests that pruning is skipped while the node is syncing, and that a running
+// cycle is aborted without deleting anything if syncing starts meanwhile.
+func TestOnlinePrunerSyncing(t *testing.T) {
+	defer func(recheck time.Duration) { onlineRecheck = recheck }(onlineRecheck)
+	onlineRecheck = 10 * time.Millisecond
+
+	chain := newOnlineTestChain(t, 330, 50)
+	stale := chain.persist(t, 50)
+	chain.insert(t, 50, 200)
+	chain.persist(t, 190)
+
+	var syncing uint32 = 1
+	p := newTestPruner(chain, func() bool { return atomic.LoadUint32(&syncing) == 1 })
+
+	// Cycles are not started while syncing
+	p.run()
+	if status := p.Status(); status.Error != errPrunerSyncing.Error() || status.Cycles != 0 || status.Marked != 0 {
+		t.Fatalf("cycle ran while syncing: %+v", status)
+	}
+	// Running cycles are aborted once syncing starts
+	atomic.StoreUint32(&syncing, 0)
+	errc := make(chan error, 1)
+	go func() { errc <- p.prune(time.Now()) }()
+
+	waitPhase(t, p, PhaseWaiting)
+	atomic.StoreUint32(&syncing, 1)
+	chain.insert(t, 200, 330)
+
+	if err := <-errc; err != errPrunerSyncing {
+		t.Fatalf("error mismatch: have %v, want %v", err, errPrunerSyncing)
+	}
+	if !rawdb.HasTrieNode(chain.diskdb, stale) {
+		t.Errorf("stale state deleted while syncing")
+	}
+}
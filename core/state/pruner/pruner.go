@@ -418,7 +418,7 @@ func RecoverPruning(datadir string, db sdcdb.Database, trieCachePath string) err
 
 // extractGenesis loads the genesis state and commits all the state entries
 // into the given bloomfilter.
-func extractGenesis(db sdcdb.Database, stateBloom *stateBloom) error {
+func extractGenesis(db sdcdb.Database, stateBloom sdcdb.KeyValueWriter) error {
 	genesisHash := rawdb.ReadCanonicalHash(db, 0)
 	if genesisHash == (common.Hash{}) {
 		return errors.New("missing genesis hash")
@@ -427,7 +427,16 @@ func extractGenesis(db sdcdb.Database, stateBloom *stateBloom) error {
 	if genesis == nil {
 		return errors.New("missing genesis block")
 	}
-	t, err := trie.NewStateTrie(trie.StateTrieID(genesis.Root()), trie.NewDatabase(db))
+	return extractState(db, genesis.Root(), stateBloom, nil)
+}
+
+// extractState iterates the persisted state with the given root, including all
+// the storage tries and contract codes referenced by it, and commits all the
+// state entries into the given bloomfilter. The optional progress callback is
+// invoked after every visited trie node and can abort the iteration by returning
+// an error.
+func extractState(db sdcdb.Database, root common.Hash, stateBloom sdcdb.KeyValueWriter, progress func() error) error {
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), trie.NewDatabase(db))
 	if err != nil {
 		return err
 	}
@@ -447,7 +456,7 @@ func extractGenesis(db sdcdb.Database, stateBloom *stateBloom) error {
 				return err
 			}
 			if acc.Root != emptyRoot {
-				id := trie.StorageTrieID(genesis.Root(), common.BytesToHash(accIter.LeafKey()), acc.Root)
+				id := trie.StorageTrieID(root, common.BytesToHash(accIter.LeafKey()), acc.Root)
 				storageTrie, err := trie.NewStateTrie(id, trie.NewDatabase(db))
 				if err != nil {
 					return err
@@ -458,6 +467,11 @@ func extractGenesis(db sdcdb.Database, stateBloom *stateBloom) error {
 					if hash != (common.Hash{}) {
 						stateBloom.Put(hash.Bytes(), nil)
 					}
+					if progress != nil {
+						if err := progress(); err != nil {
+							return err
+						}
+					}
 				}
 				if storageIter.Error() != nil {
 					return storageIter.Error()
@@ -467,6 +481,11 @@ func extractGenesis(db sdcdb.Database, stateBloom *stateBloom) error {
 				stateBloom.Put(acc.CodeHash, nil)
 			}
 		}
+		if progress != nil {
+			if err := progress(); err != nil {
+				return err
+			}
+		}
 	}
 	return accIter.Error()
 }
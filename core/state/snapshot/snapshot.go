@@ -337,6 +337,27 @@ func (t *Tree) Snapshots(root common.Hash, limits int, nodisk bool) []Snapshot {
 	return ret
 }
 
+// DiffKeys returns the hashes of all accounts touched by the diff layer with the
+// given root, each mapped to the hashes of its touched storage slots. Deleted
+// accounts and slots are included. Nil is returned if the root is unknown or it
+// belongs to the disk layer.
+func (t *Tree) DiffKeys(root common.Hash) map[common.Hash][]common.Hash {
+	t.lock.RLock()
+	layer := t.layers[root]
+	t.lock.RUnlock()
+
+	diff, ok := layer.(*diffLayer)
+	if !ok {
+		return nil
+	}
+	keys := make(map[common.Hash][]common.Hash)
+	for _, account := range diff.AccountList() {
+		slots, _ := diff.StorageList(account)
+		keys[account] = slots
+	}
+	return keys
+}
+
 // Update adds a new snapshot into the tree, if that can be linked to an existing
 // old parent. It is disallowed to insert a disk layer (the origin of all).
 func (t *Tree) Update(blockRoot common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
@@ -743,7 +764,7 @@ func (t *Tree) Rebuild(root common.Hash) {
 // AccountIterator creates a new account iterator for the specified root hash and
 // seeks to a starting account hash.
 func (t *Tree) AccountIterator(root common.Hash, seek common.Hash) (AccountIterator, error) {
-	ok, err := t.generating()
+	ok, err := t.Generating()
 	if err != nil {
 		return nil, err
 	}
@@ -756,7 +777,7 @@ func (t *Tree) AccountIterator(root common.Hash, seek common.Hash) (AccountItera
 // StorageIterator creates a new storage iterator for the specified root hash and
 // account. The iterator will be move to the specific start position.
 func (t *Tree) StorageIterator(root common.Hash, account common.Hash, seek common.Hash) (StorageIterator, error) {
-	ok, err := t.generating()
+	ok, err := t.Generating()
 	if err != nil {
 		return nil, err
 	}
@@ -829,9 +850,8 @@ func (t *Tree) diskRoot() common.Hash {
 	return disklayer.Root()
 }
 
-// generating is an internal helper function which reports whsdcer the snapshot
-// is still under the construction.
-func (t *Tree) generating() (bool, error) {
+// Generating reports whsdcer the snapshot is still under the construction.
+func (t *Tree) Generating() (bool, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
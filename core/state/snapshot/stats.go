@@ -0,0 +1,358 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// ContractStats contains the storage footprint of a single contract.
+type ContractStats struct {
+	Hash    common.Hash     `json:"hash"`              // Hash of the contract address
+	Address *common.Address `json:"address,omitempty"` // Contract address, if the preimage is known
+	Slots   uint64          `json:"slots"`             // Number of storage slots in use
+	Storage uint64          `json:"storage"`           // Bytes of storage slot keys and values
+	Code    uint64          `json:"code"`              // Bytes of contract code
+}
+
+// StateStats contains the storage footprint of an entire state, along with the
+// contracts contributing to it the most.
+type StateStats struct {
+	Root       common.Hash      `json:"root"`
+	Accounts   uint64           `json:"accounts"`   // Number of accounts in the state
+	Contracts  uint64           `json:"contracts"`  // Number of accounts with either code or storage
+	Slots      uint64           `json:"slots"`      // Number of storage slots in the state
+	Storage    uint64           `json:"storage"`    // Bytes of storage slot keys and values
+	Code       uint64           `json:"code"`       // Bytes of contract code, counting duplicates
+	TopSlots   []*ContractStats `json:"topSlots"`   // Contracts with the most storage slots
+	TopStorage []*ContractStats `json:"topStorage"` // Contracts with the most storage bytes
+	TopCode    []*ContractStats `json:"topCode"`    // Contracts with the largest code
+}
+
+// ContractGrowth contains the change in the storage footprint of a contract
+// between two states.
+type ContractGrowth struct {
+	Hash    common.Hash     `json:"hash"`              // Hash of the contract address
+	Address *common.Address `json:"address,omitempty"` // Contract address, if the preimage is known
+	Slots   int64           `json:"slots"`             // Change in the number of storage slots
+	Storage int64           `json:"storage"`           // Change in the bytes of storage
+	Code    int64           `json:"code"`              // Change in the bytes of contract code
+}
+
+// StateGrowth contains the change in the storage footprint between two states,
+// along with the contracts contributing to it the most.
+type StateGrowth struct {
+	From      common.Hash       `json:"from"`
+	To        common.Hash       `json:"to"`
+	Layers    int               `json:"layers"`    // Number of diff layers between the two states
+	Accounts  uint64            `json:"accounts"`  // Number of accounts modified between the two states
+	Slots     int64             `json:"slots"`     // Change in the number of storage slots
+	Storage   int64             `json:"storage"`   // Change in the bytes of storage
+	Code      int64             `json:"code"`      // Change in the bytes of contract code
+	TopGrowth []*ContractGrowth `json:"topGrowth"` // Contracts with the largest storage growth
+	TopShrink []*ContractGrowth `json:"topShrink"` // Contracts with the largest storage reduction
+}
+
+// contractRanking tracks the top contracts by a specific metric.
+type contractRanking struct {
+	limit int
+	score func(*ContractStats) uint64
+	items []*ContractStats // Sorted in descending order of the score
+}
+
+// add inserts the contract into the ranking if it's large enough.
+func (r *contractRanking) add(stats *ContractStats) {
+	score := r.score(stats)
+	if score == 0 {
+		return
+	}
+	if len(r.items) == r.limit && score <= r.score(r.items[len(r.items)-1]) {
+		return
+	}
+	pos := sort.Search(len(r.items), func(i int) bool {
+		return r.score(r.items[i]) < score
+	})
+	if len(r.items) < r.limit {
+		r.items = append(r.items, nil)
+	}
+	copy(r.items[pos+1:], r.items[pos:])
+	r.items[pos] = stats
+}
+
+// StateStats iterates over the entire state of the given root and measures the
+// storage footprint of every contract. The top contracts by slot count, storage
+// and code size are retained in the returned stats, limited to the given number.
+// If visit is non-nil, it's invoked with the stats of every contract.
+func (t *Tree) StateStats(ctx context.Context, root common.Hash, top int, visit func(*ContractStats) error) (*StateStats, error) {
+	acctIt, err := t.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer acctIt.Release()
+
+	var (
+		stats  = &StateStats{Root: root}
+		codes  = make(map[common.Hash]uint64)
+		start  = time.Now()
+		logged = time.Now()
+
+		bySlots   = &contractRanking{limit: top, score: func(s *ContractStats) uint64 { return s.Slots }}
+		byStorage = &contractRanking{limit: top, score: func(s *ContractStats) uint64 { return s.Storage }}
+		byCode    = &contractRanking{limit: top, score: func(s *ContractStats) uint64 { return s.Code }}
+	)
+	for acctIt.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		account, err := FullAccount(acctIt.Account())
+		if err != nil {
+			return nil, err
+		}
+		stats.Accounts++
+
+		contract := &ContractStats{Hash: acctIt.Hash()}
+		contract.Code = t.codeSize(codes, account.CodeHash)
+		if !bytes.Equal(account.Root, emptyRoot[:]) {
+			if err := t.storageStats(root, contract); err != nil {
+				return nil, err
+			}
+		}
+		if contract.Code == 0 && contract.Slots == 0 {
+			continue
+		}
+		contract.Address = t.preimage(contract.Hash)
+
+		stats.Contracts++
+		stats.Slots += contract.Slots
+		stats.Storage += contract.Storage
+		stats.Code += contract.Code
+
+		if top > 0 {
+			bySlots.add(contract)
+			byStorage.add(contract)
+			byCode.add(contract)
+		}
+		if visit != nil {
+			if err := visit(contract); err != nil {
+				return nil, err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Gathering state statistics", "at", acctIt.Hash(), "accounts", stats.Accounts,
+				"contracts", stats.Contracts, "slots", stats.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := acctIt.Error(); err != nil {
+		return nil, err
+	}
+	stats.TopSlots, stats.TopStorage, stats.TopCode = bySlots.items, byStorage.items, byCode.items
+
+	log.Info("Gathered state statistics", "root", root, "accounts", stats.Accounts, "contracts", stats.Contracts,
+		"slots", stats.Slots, "storage", common.StorageSize(stats.Storage), "elapsed", common.PrettyDuration(time.Since(start)))
+	return stats, nil
+}
+
+// StateGrowth measures the change in the storage footprint between two states,
+// where the state of the from root needs to be an ancestor of the state of the
+// to root in the snapshot tree. Only the accounts and slots modified by the diff
+// layers in between are inspected, so the call is cheap even on large states.
+// The top contracts by storage growth and reduction are retained in the returned
+// stats, limited to the given number.
+func (t *Tree) StateGrowth(ctx context.Context, from common.Hash, to common.Hash, top int) (*StateGrowth, error) {
+	fromSnap, toSnap := t.Snapshot(from), t.Snapshot(to)
+	if fromSnap == nil {
+		return nil, fmt.Errorf("snapshot [%#x] missing", from)
+	}
+	if toSnap == nil {
+		return nil, fmt.Errorf("snapshot [%#x] missing", to)
+	}
+	// Collect all the accounts and slots modified in between. If an account was
+	// destructed, its entire storage needs to be compared.
+	var (
+		layers    int
+		slots     = make(map[common.Hash]map[common.Hash]struct{})
+		destructs = make(map[common.Hash]struct{})
+	)
+	t.lock.RLock()
+	for layer := t.layers[to]; layer.Root() != from; layer = layer.Parent() {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			t.lock.RUnlock()
+			return nil, fmt.Errorf("snapshot [%#x] is not an ancestor of [%#x]", from, to)
+		}
+		for _, account := range diff.AccountList() {
+			list, destructed := diff.StorageList(account)
+			if destructed {
+				destructs[account] = struct{}{}
+			}
+			if slots[account] == nil {
+				slots[account] = make(map[common.Hash]struct{})
+			}
+			for _, slot := range list {
+				slots[account][slot] = struct{}{}
+			}
+		}
+		layers++
+	}
+	t.lock.RUnlock()
+
+	var (
+		growth = &StateGrowth{From: from, To: to, Layers: layers, Accounts: uint64(len(slots))}
+		codes  = make(map[common.Hash]uint64)
+		all    []*ContractGrowth
+	)
+	for account, keys := range slots {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			before = &ContractStats{Hash: account}
+			after  = &ContractStats{Hash: account}
+		)
+		if _, destructed := destructs[account]; destructed {
+			if err := t.accountStats(fromSnap, codes, before, nil); err != nil {
+				return nil, err
+			}
+			if err := t.accountStats(toSnap, codes, after, nil); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := t.accountStats(fromSnap, codes, before, keys); err != nil {
+				return nil, err
+			}
+			if err := t.accountStats(toSnap, codes, after, keys); err != nil {
+				return nil, err
+			}
+		}
+		change := &ContractGrowth{
+			Hash:    account,
+			Slots:   int64(after.Slots) - int64(before.Slots),
+			Storage: int64(after.Storage) - int64(before.Storage),
+			Code:    int64(after.Code) - int64(before.Code),
+		}
+		growth.Slots += change.Slots
+		growth.Storage += change.Storage
+		growth.Code += change.Code
+
+		if change.Storage != 0 || change.Code != 0 {
+			all = append(all, change)
+		}
+	}
+	// Sort the contracts by their storage growth, retaining both ends
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Storage != all[j].Storage {
+			return all[i].Storage > all[j].Storage
+		}
+		return bytes.Compare(all[i].Hash[:], all[j].Hash[:]) < 0
+	})
+	for i := 0; i < len(all) && i < top && all[i].Storage > 0; i++ {
+		all[i].Address = t.preimage(all[i].Hash)
+		growth.TopGrowth = append(growth.TopGrowth, all[i])
+	}
+	for i := len(all) - 1; i >= 0 && len(all)-1-i < top && all[i].Storage < 0; i-- {
+		all[i].Address = t.preimage(all[i].Hash)
+		growth.TopShrink = append(growth.TopShrink, all[i])
+	}
+	return growth, nil
+}
+
+// accountStats measures the storage footprint of an account in the given state.
+// If keys is nil, the entire storage of the account is measured, otherwise only
+// the listed slots.
+func (t *Tree) accountStats(snap Snapshot, codes map[common.Hash]uint64, stats *ContractStats, keys map[common.Hash]struct{}) error {
+	account, err := snap.Account(stats.Hash)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return nil // Account doesn't exist in this state
+	}
+	stats.Code = t.codeSize(codes, account.CodeHash)
+
+	if keys == nil {
+		if len(account.Root) == 0 || bytes.Equal(account.Root, emptyRoot[:]) {
+			return nil
+		}
+		return t.storageStats(snap.Root(), stats)
+	}
+	for key := range keys {
+		blob, err := snap.Storage(stats.Hash, key)
+		if err != nil {
+			return err
+		}
+		if len(blob) > 0 {
+			stats.Slots++
+			stats.Storage += uint64(common.HashLength + len(blob))
+		}
+	}
+	return nil
+}
+
+// storageStats iterates over the entire storage of a contract and measures its
+// slot count and storage size.
+func (t *Tree) storageStats(root common.Hash, stats *ContractStats) error {
+	storageIt, err := t.StorageIterator(root, stats.Hash, common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer storageIt.Release()
+
+	for storageIt.Next() {
+		stats.Slots++
+		stats.Storage += uint64(common.HashLength + len(storageIt.Slot()))
+	}
+	return storageIt.Error()
+}
+
+// codeSize returns the size of the contract code with the given hash, caching
+// the result as contract codes are frequently shared between accounts.
+func (t *Tree) codeSize(codes map[common.Hash]uint64, hash []byte) uint64 {
+	if len(hash) == 0 || bytes.Equal(hash, emptyCode[:]) {
+		return 0
+	}
+	codeHash := common.BytesToHash(hash)
+	if size, ok := codes[codeHash]; ok {
+		return size
+	}
+	size := uint64(len(rawdb.ReadCode(t.diskdb, codeHash)))
+	codes[codeHash] = size
+	return size
+}
+
+// preimage resolves the address belonging to an account hash, if the preimage
+// was recorded in the database.
+func (t *Tree) preimage(hash common.Hash) *common.Address {
+	blob := rawdb.ReadPreimage(t.diskdb, hash)
+	if len(blob) != common.AddressLength {
+		return nil
+	}
+	addr := common.BytesToAddress(blob)
+	return &addr
+}
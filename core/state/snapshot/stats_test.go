@@ -0,0 +1,145 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/crypto"
+)
+
+// newStatsTestTree creates a snapshot tree with a persisted base layer holding
+// a contract with two slots and a plain account, and two diff layers on top:
+//
+//   - 0x02 adds a slot to the first contract and creates a second one
+//   - 0x03 deletes a slot of the first contract
+func newStatsTestTree() (*Tree, common.Hash, common.Hash) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		code  = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+		codeA = crypto.Keccak256(code)
+		codeB = crypto.Keccak256(append(code, code...))
+
+		contractA = common.HexToHash("0xaa")
+		contractB = common.HexToHash("0xbb")
+		plain     = common.HexToHash("0xcc")
+	)
+	rawdb.WriteCode(db, common.BytesToHash(codeA), code)
+	rawdb.WriteCode(db, common.BytesToHash(codeB), append(code, code...))
+
+	rawdb.WriteAccountSnapshot(db, contractA, SlimAccountRLP(0, big.NewInt(0), randomHash(), codeA))
+	rawdb.WriteAccountSnapshot(db, plain, SlimAccountRLP(1, big.NewInt(1), emptyRoot, emptyCode[:]))
+	rawdb.WriteStorageSnapshot(db, contractA, common.HexToHash("0x01"), []byte{0x01})
+	rawdb.WriteStorageSnapshot(db, contractA, common.HexToHash("0x02"), []byte{0x02, 0x02})
+
+	base := &diskLayer{
+		diskdb: db,
+		root:   common.HexToHash("0x01"),
+		cache:  fastcache.New(1024 * 500),
+	}
+	snaps := &Tree{
+		diskdb: db,
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	snaps.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil, map[common.Hash][]byte{
+		contractA: SlimAccountRLP(0, big.NewInt(0), randomHash(), codeA),
+		contractB: SlimAccountRLP(0, big.NewInt(0), randomHash(), codeB),
+	}, map[common.Hash]map[common.Hash][]byte{
+		contractA: {common.HexToHash("0x03"): []byte{0x03, 0x03, 0x03}},
+		contractB: {common.HexToHash("0x01"): []byte{0x01}},
+	})
+	snaps.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), nil, map[common.Hash][]byte{
+		contractA: SlimAccountRLP(0, big.NewInt(0), randomHash(), codeA),
+	}, map[common.Hash]map[common.Hash][]byte{
+		contractA: {common.HexToHash("0x01"): nil},
+	})
+	return snaps, contractA, contractB
+}
+
+// Tests that the storage footprint of a state is measured correctly.
+func TestStateStats(t *testing.T) {
+	snaps, contractA, contractB := newStatsTestTree()
+
+	// Measure the persisted base state
+	stats, err := snaps.StateStats(context.Background(), common.HexToHash("0x01"), 1, nil)
+	if err != nil {
+		t.Fatalf("failed to gather stats: %v", err)
+	}
+	if stats.Accounts != 2 || stats.Contracts != 1 {
+		t.Errorf("account count mismatch: have %d/%d, want %d/%d", stats.Accounts, stats.Contracts, 2, 1)
+	}
+	if stats.Slots != 2 || stats.Storage != 2*common.HashLength+3 || stats.Code != 5 {
+		t.Errorf("size mismatch: have %d/%d/%d, want %d/%d/%d", stats.Slots, stats.Storage, stats.Code, 2, 2*common.HashLength+3, 5)
+	}
+	// Measure the state on top of the diff layers and check the rankings
+	var visited int
+	stats, err = snaps.StateStats(context.Background(), common.HexToHash("0x03"), 1, func(*ContractStats) error {
+		visited++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to gather stats: %v", err)
+	}
+	if visited != 2 || stats.Contracts != 2 {
+		t.Errorf("contract count mismatch: have %d/%d, want %d", visited, stats.Contracts, 2)
+	}
+	if stats.Slots != 3 || stats.Code != 15 {
+		t.Errorf("size mismatch: have %d/%d, want %d/%d", stats.Slots, stats.Code, 3, 15)
+	}
+	if len(stats.TopSlots) != 1 || stats.TopSlots[0].Hash != contractA || stats.TopSlots[0].Slots != 2 {
+		t.Errorf("slot ranking mismatch: %+v", stats.TopSlots)
+	}
+	if len(stats.TopStorage) != 1 || stats.TopStorage[0].Hash != contractA || stats.TopStorage[0].Storage != 2*common.HashLength+5 {
+		t.Errorf("storage ranking mismatch: %+v", stats.TopStorage)
+	}
+	if len(stats.TopCode) != 1 || stats.TopCode[0].Hash != contractB || stats.TopCode[0].Code != 10 {
+		t.Errorf("code ranking mismatch: %+v", stats.TopCode)
+	}
+}
+
+// Tests that the state growth between two roots is measured from the diffs.
+func TestStateGrowth(t *testing.T) {
+	snaps, contractA, contractB := newStatsTestTree()
+
+	growth, err := snaps.StateGrowth(context.Background(), common.HexToHash("0x01"), common.HexToHash("0x03"), 10)
+	if err != nil {
+		t.Fatalf("failed to measure growth: %v", err)
+	}
+	if growth.Layers != 2 || growth.Accounts != 2 {
+		t.Errorf("layer or account count mismatch: have %d/%d, want %d/%d", growth.Layers, growth.Accounts, 2, 2)
+	}
+	if growth.Slots != 1 || growth.Storage != common.HashLength+3 || growth.Code != 10 {
+		t.Errorf("growth mismatch: have %d/%d/%d, want %d/%d/%d", growth.Slots, growth.Storage, growth.Code, 1, common.HashLength+3, 10)
+	}
+	if len(growth.TopGrowth) != 2 || growth.TopGrowth[0].Hash != contractB || growth.TopGrowth[1].Hash != contractA {
+		t.Errorf("growth ranking mismatch: %+v", growth.TopGrowth)
+	}
+	if len(growth.TopShrink) != 0 {
+		t.Errorf("unexpected shrink ranking: %+v", growth.TopShrink)
+	}
+	// Measuring against a non-ancestor must fail
+	if _, err := snaps.StateGrowth(context.Background(), common.HexToHash("0x03"), common.HexToHash("0x01"), 10); err == nil {
+		t.Error("expected error for non-ancestor root")
+	}
+}
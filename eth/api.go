@@ -33,6 +33,8 @@ import (
 	"github.com/sdcereum/go-sdcereum/core"
 	"github.com/sdcereum/go-sdcereum/core/rawdb"
 	"github.com/sdcereum/go-sdcereum/core/state"
+	"github.com/sdcereum/go-sdcereum/core/state/pruner"
+	"github.com/sdcereum/go-sdcereum/core/state/snapshot"
 	"github.com/sdcereum/go-sdcereum/core/types"
 	"github.com/sdcereum/go-sdcereum/internal/sdcapi"
 	"github.com/sdcereum/go-sdcereum/log"
@@ -243,6 +245,31 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// errPrunerDisabled is returned by the pruning APIs if online state pruning is
+// not enabled on the node.
+var errPrunerDisabled = errors.New("online state pruning is not enabled")
+
+// PruneState starts a new online state pruning cycle. The call returns right
+// away, the progress of the cycle can be tracked via PruneStatus.
+func (api *AdminAPI) PruneState() (bool, error) {
+	if api.sdc.pruner == nil {
+		return false, errPrunerDisabled
+	}
+	if err := api.sdc.pruner.Prune(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PruneStatus returns the progress of the online state pruner.
+func (api *AdminAPI) PruneStatus() (*pruner.OnlineStatus, error) {
+	if api.sdc.pruner == nil {
+		return nil, errPrunerDisabled
+	}
+	status := api.sdc.pruner.Status()
+	return &status, nil
+}
+
 // DebugAPI is the collection of sdcereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -590,3 +617,82 @@ func (api *DebugAPI) GetAccessibleState(from, to rpc.BlockNumber) (uint64, error
 	}
 	return 0, errors.New("no state found")
 }
+
+// defaultStateStatsTop is the number of contracts retained in the rankings of
+// the state statistics, if not specified otherwise.
+const defaultStateStatsTop = 20
+
+// StateStats iterates the snapshot of the state at the given block and reports
+// the storage footprint of the contracts, along with the largest ones by slot
+// count, storage size and code size.
+func (api *DebugAPI) StateStats(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, top *int) (*snapshot.StateStats, error) {
+	snaps := api.sdc.blockchain.Snapshots()
+	if snaps == nil {
+		return nil, errors.New("state snapshot not available")
+	}
+	block, err := api.blockByNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	limit := defaultStateStatsTop
+	if top != nil {
+		limit = *top
+	}
+	return snaps.StateStats(ctx, block.Root(), limit, nil)
+}
+
+// StateGrowth reports the change in the storage footprint between the states of
+// two blocks, along with the contracts growing and shrinking the most. Both states
+// need to be covered by the snapshot diff layers, the from block being an ancestor
+// of the to block.
+func (api *DebugAPI) StateGrowth(ctx context.Context, from, to rpc.BlockNumberOrHash, top *int) (*snapshot.StateGrowth, error) {
+	snaps := api.sdc.blockchain.Snapshots()
+	if snaps == nil {
+		return nil, errors.New("state snapshot not available")
+	}
+	fromBlock, err := api.blockByNumberOrHash(from)
+	if err != nil {
+		return nil, err
+	}
+	toBlock, err := api.blockByNumberOrHash(to)
+	if err != nil {
+		return nil, err
+	}
+	limit := defaultStateStatsTop
+	if top != nil {
+		limit = *top
+	}
+	return snaps.StateGrowth(ctx, fromBlock.Root(), toBlock.Root(), limit)
+}
+
+// blockByNumberOrHash retrieves a canonical block by number or any block by hash.
+// The pending block is not supported.
+func (api *DebugAPI) blockByNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		var block *types.Block
+		switch number {
+		case rpc.PendingBlockNumber:
+			return nil, errors.New("pending state not supported")
+		case rpc.LatestBlockNumber:
+			block = api.sdc.blockchain.CurrentBlock()
+		case rpc.FinalizedBlockNumber:
+			block = api.sdc.blockchain.CurrentFinalizedBlock()
+		case rpc.SafeBlockNumber:
+			block = api.sdc.blockchain.CurrentSafeBlock()
+		default:
+			block = api.sdc.blockchain.GetBlockByNumber(uint64(number))
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		return block, nil
+	}
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block := api.sdc.blockchain.GetBlockByHash(hash)
+		if block == nil {
+			return nil, fmt.Errorf("block %s not found", hash.Hex())
+		}
+		return block, nil
+	}
+	return nil, errors.New("either block number or block hash must be specified")
+}
@@ -247,6 +247,9 @@ func (b *sdcAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *sdcAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if b.sdc.config.Secondary != "" {
+		return errors.New("transactions are not accepted by a secondary node")
+	}
 	return b.sdc.txPool.AddLocal(signedTx)
 }
 
@@ -23,12 +23,18 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/consensus/sdcash"
+	"github.com/sdcereum/go-sdcereum/core"
 	"github.com/sdcereum/go-sdcereum/core/rawdb"
 	"github.com/sdcereum/go-sdcereum/core/state"
+	"github.com/sdcereum/go-sdcereum/core/state/pruner"
+	"github.com/sdcereum/go-sdcereum/core/vm"
 	"github.com/sdcereum/go-sdcereum/crypto"
+	"github.com/sdcereum/go-sdcereum/params"
 	"github.com/sdcereum/go-sdcereum/trie"
 )
 
@@ -219,3 +225,46 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the online pruning APIs start cycles and report their outcome.
+func TestPruneStateAPI(t *testing.T) {
+	api := NewAdminAPI(&sdcereum{})
+	if _, err := api.PruneState(); err != errPrunerDisabled {
+		t.Fatalf("disabled pruner: error mismatch: have %v, want %v", err, errPrunerDisabled)
+	}
+	if _, err := api.PruneStatus(); err != errPrunerDisabled {
+		t.Fatalf("disabled pruner: error mismatch: have %v, want %v", err, errPrunerDisabled)
+	}
+	db := rawdb.NewMemoryDatabase()
+	config := &core.CacheConfig{TrieCleanLimit: 16, TrieDirtyLimit: 16, TrieTimeLimit: 5 * time.Minute, SnapshotLimit: 16, SnapshotWait: true}
+	chain, err := core.NewBlockChain(db, config, &core.Genesis{Config: params.TestChainConfig}, nil, sdcash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// The cycle fails right away, as the node reports to be syncing
+	p := pruner.NewOnlinePruner(db, chain.StateCache().TrieDB(), chain, func() bool { return true }, pruner.OnlineConfig{})
+	p.Start()
+	defer p.Stop()
+
+	api = NewAdminAPI(&sdcereum{pruner: p})
+	if ok, err := api.PruneState(); !ok || err != nil {
+		t.Fatalf("failed to start pruning: %v", err)
+	}
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		status, err := api.PruneStatus()
+		if err != nil {
+			t.Fatalf("failed to retrieve pruning status: %v", err)
+		}
+		if status.Phase == pruner.PhaseIdle && status.Error != "" {
+			if status.Error != "node is syncing" || status.Cycles != 0 {
+				t.Fatalf("status mismatch: %+v", status)
+			}
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("pruning cycle didn't finish: %+v", status)
+		}
+	}
+}
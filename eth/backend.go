@@ -24,6 +24,7 @@ import (
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/sdcereum/go-sdcereum/accounts"
 	"github.com/sdcereum/go-sdcereum/common"
@@ -43,6 +44,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/sdc/protocols/sdc"
 	"github.com/sdcereum/go-sdcereum/sdc/protocols/snap"
 	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/sdcdb/remotedb"
 	"github.com/sdcereum/go-sdcereum/event"
 	"github.com/sdcereum/go-sdcereum/internal/sdcapi"
 	"github.com/sdcereum/go-sdcereum/internal/shutdowncheck"
@@ -72,9 +74,11 @@ type sdcereum struct {
 	sdcDialCandidates  enode.Iterator
 	snapDialCandidates enode.Iterator
 	merger             *consensus.Merger
+	pruner             *pruner.OnlinePruner // Background state pruner, nil if disabled
 
 	// DB interfaces
-	chainDb sdcdb.Database // Block chain database
+	chainDb  sdcdb.Database   // Block chain database
+	dbServer *remotedb.Server // Server exposing the chain database to other processes, nil if disabled
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -98,6 +102,8 @@ type sdcereum struct {
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and sdcerbase)
 
 	shutdownTracker *shutdowncheck.ShutdownTracker // Tracks if and when the node has shutdown ungracefully
+
+	closeHeadFollower chan struct{} // Channel to stop following the head of a secondary database
 }
 
 // New creates a new sdcereum object (including the
@@ -126,12 +132,27 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 	log.Info("Allocated trie memory caches", "clean", common.StorageSize(config.TrieCleanCache)*1024*1024, "dirty", common.StorageSize(config.TrieDirtyCache)*1024*1024)
 
 	// Assemble the sdcereum object
-	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "sdc/db/chaindata/", false)
+	var (
+		chainDb sdcdb.Database
+		err     error
+	)
+	switch {
+	case config.Secondary != "":
+		log.Info("Following database of another node", "datadir", config.Secondary, "refresh", config.SecondaryRefresh)
+		chainDb, err = stack.OpenSecondaryDatabase(config.Secondary, "chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "sdc/db/chaindata/", config.SecondaryRefresh)
+	case config.RemoteDB != "":
+		log.Info("Using remote database server", "endpoint", config.RemoteDB)
+		chainDb, err = remotedb.Dial(config.RemoteDB, false)
+	default:
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "sdc/db/chaindata/", false)
+	}
 	if err != nil {
 		return nil, err
 	}
-	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
-		log.Error("Failed to recover state", "error", err)
+	if config.Secondary == "" && config.RemoteDB == "" {
+		if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
+			log.Error("Failed to recover state", "error", err)
+		}
 	}
 	// Transfer mining-related config to the sdcash config.
 	sdcashConfig := config.sdcash
@@ -157,6 +178,7 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 		bloomIndexer:      core.NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
 		p2pServer:         stack.Server(),
 		shutdownTracker:   shutdowncheck.NewShutdownTracker(chainDb),
+		closeHeadFollower: make(chan struct{}),
 	}
 
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
@@ -167,6 +189,9 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 	log.Info("Initialising sdcereum protocol", "network", config.NetworkId, "dbversion", dbVer)
 
 	if !config.SkipBcVersionCheck {
+		if config.Secondary != "" && (bcVersion == nil || *bcVersion != core.BlockChainVersion) {
+			return nil, fmt.Errorf("database version is %s, read-only access requires v%d", dbVer, core.BlockChainVersion)
+		}
 		if bcVersion != nil && *bcVersion > core.BlockChainVersion {
 			return nil, fmt.Errorf("database version is v%d, Gsdc %s only supports v%d", *bcVersion, params.VersionWithMeta, core.BlockChainVersion)
 		} else if bcVersion == nil || *bcVersion < core.BlockChainVersion {
@@ -191,7 +216,17 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
 		}
+		txLookupLimit = &config.TxLookupLimit
 	)
+	// A secondary node never modifies the followed database, neither by importing
+	// blocks nor by persisting caches, snapshots or transaction indices.
+	if config.Secondary != "" {
+		cacheConfig.ReadOnly = true
+		cacheConfig.TrieCleanJournal = ""
+		cacheConfig.TrieCleanRejournal = 0
+		cacheConfig.SnapshotLimit = 0
+		txLookupLimit = nil
+	}
 	// Override the chain config with provided settings.
 	var overrides core.ChainOverrides
 	if config.OverrideTerminalTotalDifficulty != nil {
@@ -200,12 +235,26 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 	if config.OverrideTerminalTotalDifficultyPassed != nil {
 		overrides.OverrideTerminalTotalDifficultyPassed = config.OverrideTerminalTotalDifficultyPassed
 	}
-	sdc.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, &overrides, sdc.engine, vmConfig, sdc.shouldPreserve, &config.TxLookupLimit)
+	sdc.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, &overrides, sdc.engine, vmConfig, sdc.shouldPreserve, txLookupLimit)
 	if err != nil {
 		return nil, err
 	}
-	sdc.bloomIndexer.Start(sdc.blockchain)
+	if config.Secondary == "" {
+		sdc.bloomIndexer.Start(sdc.blockchain)
+	}
+	if config.OnlinePruning && config.Secondary != "" {
+		log.Warn("Online state pruning is not available on a secondary node")
+	} else if config.OnlinePruning {
+		if config.NoPruning || config.SnapshotCache == 0 {
+			log.Warn("Online state pruning requires pruning and snapshots to be enabled")
+		} else {
+			sdc.pruner = pruner.NewOnlinePruner(chainDb, sdc.blockchain.StateCache().TrieDB(), sdc.blockchain, sdc.syncing, config.OnlinePruner)
+		}
+	}
 
+	if config.Secondary != "" {
+		config.TxPool.Journal = ""
+	}
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
@@ -217,6 +266,15 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 	if checkpoint == nil {
 		checkpoint = params.TrustedCheckpoints[sdc.blockchain.Genesis().Hash()]
 	}
+	// If the trusted anchor is known in full, require peers to be on its chain too
+	requiredBlocks := config.RequiredBlocks
+	if anchor := config.SyncAnchor; anchor != nil && anchor.Header != nil {
+		requiredBlocks = make(map[uint64]common.Hash, len(config.RequiredBlocks)+1)
+		for number, hash := range config.RequiredBlocks {
+			requiredBlocks[number] = hash
+		}
+		requiredBlocks[anchor.Header.Number.Uint64()] = anchor.Hash
+	}
 	if sdc.handler, err = newHandler(&handlerConfig{
 		Database:       chainDb,
 		Chain:          sdc.blockchain,
@@ -227,7 +285,8 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       sdc.eventMux,
 		Checkpoint:     checkpoint,
-		RequiredBlocks: config.RequiredBlocks,
+		RequiredBlocks: requiredBlocks,
+		Anchor:         config.SyncAnchor,
 	}); err != nil {
 		return nil, err
 	}
@@ -261,11 +320,15 @@ func New(stack *node.Node, config *sdcconfig.Config) (*sdcereum, error) {
 
 	// Register the backend on the node
 	stack.RegisterAPIs(sdc.APIs())
-	stack.RegisterProtocols(sdc.Protocols())
+	if config.Secondary == "" {
+		stack.RegisterProtocols(sdc.Protocols())
+	}
 	stack.RegisterLifecycle(sdc)
 
 	// Successful startup; push a marker and check previous unclean shutdowns.
-	sdc.shutdownTracker.MarkStartup()
+	if config.Secondary == "" {
+		sdc.shutdownTracker.MarkStartup()
+	}
 
 	return sdc, nil
 }
@@ -413,6 +476,9 @@ func (s *sdcereum) Setsdcerbase(sdcerbase common.Address) {
 // is already running, this msdcod adjust the number of threads allowed to use
 // and updates the minimum price required by the transaction pool.
 func (s *sdcereum) StartMining(threads int) error {
+	if s.config.Secondary != "" {
+		return errors.New("mining is not available on a secondary node")
+	}
 	// Update the thread count within the consensus engine
 	type threaded interface {
 		SetThreads(threads int)
@@ -498,6 +564,12 @@ func (s *sdcereum) SyncMode() downloader.SyncMode {
 	return mode
 }
 
+// syncing reports whether the node is downloading chain or state data, either by
+// snap syncing or by any other running sync cycle.
+func (s *sdcereum) syncing() bool {
+	return atomic.LoadUint32(&s.handler.snapSync) == 1 || s.handler.downloader.Synchronising()
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *sdcereum) Protocols() []p2p.Protocol {
@@ -511,6 +583,21 @@ func (s *sdcereum) Protocols() []p2p.Protocol {
 // Start implements node.Lifecycle, starting all internal goroutines needed by the
 // sdcereum protocol implementation.
 func (s *sdcereum) Start() error {
+	// Expose the chain database to other processes if requested
+	if s.config.RemoteDBServe != "" {
+		s.dbServer = remotedb.NewServer(s.chainDb, true)
+		if _, err := s.dbServer.Listen(s.config.RemoteDBServe); err != nil {
+			s.dbServer.Close()
+			s.dbServer = nil
+			return fmt.Errorf("failed to serve the chain database: %w", err)
+		}
+	}
+	// A secondary node only serves the followed database, without networking
+	if s.config.Secondary != "" {
+		s.startBloomHandlers(params.BloomBitsBlocks)
+		go s.followHead(s.config.SecondaryRefresh)
+		return nil
+	}
 	sdc.StartENRUpdater(s.blockchain, s.p2pServer.LocalNode())
 
 	// Start the bloom bits servicing goroutines
@@ -519,6 +606,11 @@ func (s *sdcereum) Start() error {
 	// Regularly update shutdown marker
 	s.shutdownTracker.Start()
 
+	// Start pruning stale state in the background if requested
+	if s.pruner != nil {
+		s.pruner.Start()
+	}
+
 	// Figure out a max peers count based on the server limits
 	maxPeers := s.p2pServer.MaxPeers
 	if s.config.LightServ > 0 {
@@ -538,21 +630,54 @@ func (s *sdcereum) Stop() error {
 	// Stop all the peer-related stuff first.
 	s.sdcDialCandidates.Close()
 	s.snapDialCandidates.Close()
-	s.handler.Stop()
+	if s.config.Secondary != "" {
+		close(s.closeHeadFollower)
+		s.handler.downloader.Terminate()
+	} else {
+		s.handler.Stop()
+	}
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
+	if s.pruner != nil {
+		s.pruner.Stop()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 
 	// Clean shutdown marker as the last thing before closing db
-	s.shutdownTracker.Stop()
-
+	if s.config.Secondary == "" {
+		s.shutdownTracker.Stop()
+	}
+	if s.dbServer != nil {
+		s.dbServer.Close()
+	}
 	s.chainDb.Close()
 	s.eventMux.Stop()
 
 	return nil
 }
+
+// followHead periodically reloads the head of the chain from a secondary
+// database, following the blocks imported by the node owning it.
+func (s *sdcereum) followHead(refresh time.Duration) {
+	if refresh <= 0 {
+		refresh = sdcconfig.Defaults.SecondaryRefresh
+	}
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.blockchain.ReloadHead(); err != nil {
+				log.Debug("Failed to reload chain head", "err", err)
+			}
+		case <-s.closeHeadFollower:
+			return
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/core/types"
+	"github.com/sdcereum/go-sdcereum/sdc/protocols/sdc"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+// errAnchorUnavailable is returned if a peer cannot deliver the header of the
+// trusted sync anchor.
+var errAnchorUnavailable = errors.New("trusted anchor unavailable")
+
+// TrustedAnchor is a block trusted to be part of the canonical chain, acting as
+// a weak subjectivity checkpoint to sync from. Instead of walking the headers up
+// from the genesis, the chain is backfilled in reverse from the anchor, with the
+// state synced at the anchor itself.
+type TrustedAnchor struct {
+	Hash      common.Hash   // Hash of the trusted block
+	Header    *types.Header // Header of the trusted block, retrieved from the network if nil
+	NoHistory bool          // Whsdcer to skip the bodies and receipts before the anchor
+}
+
+// ParseTrustedAnchor parses a trusted sync anchor from either a block hash or the
+// path of a checkpoint file. The checkpoint file contains the JSON encoded header
+// of the trusted block, as returned by the RPC APIs.
+func ParseTrustedAnchor(value string) (*TrustedAnchor, error) {
+	if strings.HasPrefix(value, "0x") && len(value) == 2+2*common.HashLength {
+		var hash common.Hash
+		if err := hash.UnmarshalText([]byte(value)); err != nil {
+			return nil, fmt.Errorf("invalid anchor hash: %v", err)
+		}
+		return &TrustedAnchor{Hash: hash}, nil
+	}
+	blob, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %v", err)
+	}
+	header := new(types.Header)
+	if err := json.Unmarshal(blob, header); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file: %v", err)
+	}
+	// If the checkpoint carries the block hash too, cross check it
+	var meta struct {
+		Hash *common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(blob, &meta); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file: %v", err)
+	}
+	if meta.Hash != nil && *meta.Hash != header.Hash() {
+		return nil, fmt.Errorf("checkpoint hash mismatch: have %x, want %x", header.Hash(), *meta.Hash)
+	}
+	return &TrustedAnchor{Hash: header.Hash(), Header: header}, nil
+}
+
+// AnchorSync starts backfilling the chain from a trusted anchor block. If only
+// the hash of the anchor is known, its header is retrieved from the given peer.
+//
+// The headers are downloaded in reverse from the anchor via the skeleton syncer
+// until they link up with the local chain, after which the chain and the state
+// of the anchor are snap synced, same as for a beacon head.
+func (d *Downloader) AnchorSync(id string, anchor *TrustedAnchor) error {
+	header := anchor.Header
+	if header == nil {
+		p := d.peers.Peer(id)
+		if p == nil {
+			return errUnknownPeer
+		}
+		var err error
+		if header, err = d.fetchAnchorHeader(p, anchor.Hash); err != nil {
+			return err
+		}
+	}
+	if hash := header.Hash(); hash != anchor.Hash {
+		return fmt.Errorf("anchor hash mismatch: have %x, want %x", hash, anchor.Hash)
+	}
+	d.anchorLock.Lock()
+	d.anchor = &TrustedAnchor{Hash: anchor.Hash, Header: header, NoHistory: anchor.NoHistory}
+	d.anchorLock.Unlock()
+
+	log.Info("Syncing from trusted anchor", "number", header.Number, "hash", anchor.Hash, "history", !anchor.NoHistory)
+	return d.beaconSync(SnapSync, header, true)
+}
+
+// getAnchor returns the trusted anchor the chain is backfilled from, if any.
+func (d *Downloader) getAnchor() *TrustedAnchor {
+	d.anchorLock.RLock()
+	defer d.anchorLock.RUnlock()
+
+	return d.anchor
+}
+
+// fetchAnchorHeader retrieves the header of the trusted anchor from a peer.
+func (d *Downloader) fetchAnchorHeader(p *peerConnection, hash common.Hash) (*types.Header, error) {
+	resCh := make(chan *sdc.Response)
+
+	req, err := p.peer.RequestHeadersByHash(hash, 1, 0, false, resCh)
+	if err != nil {
+		return nil, err
+	}
+	defer req.Close()
+
+	timeout := time.NewTimer(d.peers.rates.TargetTimeout())
+	defer timeout.Stop()
+
+	select {
+	case <-d.quitCh:
+		return nil, errCanceled
+
+	case <-timeout.C:
+		p.log.Debug("Anchor header request timed out", "hash", hash)
+		return nil, errTimeout
+
+	case res := <-resCh:
+		// Peers not (yet) having the anchor are fine, but delivering something
+		// else than requested is a protocol violation.
+		headers := *res.Res.(*sdc.BlockHeadersPacket)
+		if len(headers) == 0 {
+			res.Done <- nil
+			return nil, fmt.Errorf("%w: %x", errAnchorUnavailable, hash)
+		}
+		if len(headers) != 1 || headers[0].Hash() != hash {
+			res.Done <- errors.New("invalid anchor header response")
+			return nil, fmt.Errorf("%w: invalid anchor header", errBadPeer)
+		}
+		res.Done <- nil
+		return headers[0], nil
+	}
+}
+
+// skipAnchorHistory imports the skeleton headers preceding the trusted anchor
+// into the local chain without the bodies and receipts, so that the sync cycle
+// only needs to retrieve the anchor block itself.
+func (d *Downloader) skipAnchorHistory(anchor *types.Header) error {
+	_, tail, err := d.skeleton.Bounds()
+	if err != nil {
+		return err
+	}
+	var (
+		from = d.blockchain.CurrentFastBlock().NumberU64() + 1
+		to   = anchor.Number.Uint64()
+	)
+	if from >= to {
+		return nil // History already skipped, or chain synced past the anchor
+	}
+	if from < tail.Number.Uint64() {
+		// The skeleton is linked to the local chain above our snap head, so there
+		// are headers missing. Fall back to retrieving the full chain history.
+		log.Warn("Chain history not skippable", "snap", from-1, "tail", tail.Number)
+		return nil
+	}
+	log.Info("Skipping chain history before anchor", "from", from, "to", to-1)
+	for from < to {
+		headers := make([]*types.Header, 0, maxHeadersProcess)
+		for ; from < to && len(headers) < maxHeadersProcess; from++ {
+			header := d.skeleton.Header(from)
+			if header == nil {
+				return fmt.Errorf("missing skeleton header %d", from)
+			}
+			headers = append(headers, header)
+		}
+		if n, err := d.blockchain.InsertAncientHeaders(headers); err != nil {
+			log.Warn("Failed to skip chain history", "number", headers[n].Number, "hash", headers[n].Hash(), "err", err)
+			return err
+		}
+		select {
+		case <-d.cancelCh:
+			return errCanceled
+		default:
+		}
+	}
+	return nil
+}
@@ -132,6 +132,9 @@ type Downloader struct {
 	// Skeleton sync
 	skeleton *skeleton // Header skeleton to backfill the chain with (sdc2 mode)
 
+	anchor     *TrustedAnchor // Trusted block the skeleton is being backfilled from, if any
+	anchorLock sync.RWMutex   // Lock protecting the trusted anchor
+
 	// State sync
 	pivotHeader *types.Header // Pivot block header to dynamically push the syncing state root
 	pivotLock   sync.RWMutex  // Lock protecting pivot header reads from updates
@@ -204,6 +207,10 @@ type BlockChain interface {
 	// InsertReceiptChain inserts a batch of receipts into the local chain.
 	InsertReceiptChain(types.Blocks, []types.Receipts, uint64) (int, error)
 
+	// InsertAncientHeaders inserts a batch of headers into the local chain without
+	// the associated block bodies and receipts.
+	InsertAncientHeaders([]*types.Header) (int, error)
+
 	// Snapshots returns the blockchain snapshot tree to paused it during sync.
 	Snapshots() *snapshot.Tree
 }
@@ -505,6 +512,16 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td, ttd *
 				return errNoPivotHeader
 			}
 		}
+		// If the skeleton is backfilled from a trusted anchor, sync the state of
+		// the anchor itself, optionally skipping the chain history before it.
+		if anchor := d.getAnchor(); anchor != nil && anchor.Hash == latest.Hash() && latest.Number.Uint64() > uint64(fsMinFullBlocks) {
+			pivot = latest
+			if anchor.NoHistory && mode == SnapSync {
+				if err := d.skipAnchorHistory(latest); err != nil {
+					return err
+				}
+			}
+		}
 	}
 	// If no pivot block was returned, the head is below the min full block
 	// threshold (i.e. new chain). In that case we won't really snap sync
@@ -1492,3 +1492,48 @@ func testBeaconSync(t *testing.T, protocol uint, mode SyncMode) {
 		})
 	}
 }
+
+// Tests that the chain can be synced from a trusted anchor, backfilling the
+// headers in reverse and optionally skipping the history before the anchor.
+func TestAnchorSync66(t *testing.T)          { testAnchorSync(t, sdc.sdc66, false) }
+func TestAnchorSync66NoHistory(t *testing.T) { testAnchorSync(t, sdc.sdc66, true) }
+
+func testAnchorSync(t *testing.T, protocol uint, noHistory bool) {
+	success := make(chan struct{})
+	tester := newTesterWithNotification(t, func() {
+		close(success)
+	})
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+	tester.newPeer("peer", protocol, chain.blocks[1:])
+
+	// Sync from the anchor, retrieving its header from the network
+	anchor := chain.blocks[len(chain.blocks)-1]
+	if err := tester.downloader.AnchorSync("peer", &TrustedAnchor{Hash: common.Hash{0x01}}); err == nil {
+		t.Fatalf("unknown anchor accepted")
+	}
+	if err := tester.downloader.AnchorSync("peer", &TrustedAnchor{Hash: anchor.Hash(), NoHistory: noHistory}); err != nil {
+		t.Fatalf("failed to sync from anchor: %v", err)
+	}
+	select {
+	case <-success:
+	case <-time.NewTimer(time.Second * 3).C:
+		t.Fatalf("Failed to sync chain in three seconds")
+	}
+	if head := tester.chain.CurrentBlock(); head.Hash() != anchor.Hash() {
+		t.Fatalf("head mismatch: have %d, want %d", head.NumberU64(), anchor.NumberU64())
+	}
+	if !tester.chain.HasBlockAndState(anchor.Hash(), anchor.NumberU64()) {
+		t.Fatalf("anchor state missing")
+	}
+	// Ensure the history before the anchor is only available if requested
+	for _, block := range []*types.Block{chain.blocks[1], chain.blocks[len(chain.blocks)/2]} {
+		if header := tester.chain.GetHeaderByNumber(block.NumberU64()); header == nil || header.Hash() != block.Hash() {
+			t.Fatalf("block %d: header mismatch", block.NumberU64())
+		}
+		if have := tester.chain.GetBlockByNumber(block.NumberU64()); (have == nil) != noHistory {
+			t.Fatalf("block %d: body availability mismatch: have %v, want %v", block.NumberU64(), have != nil, !noHistory)
+		}
+	}
+}
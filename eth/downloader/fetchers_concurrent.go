@@ -368,6 +368,12 @@ func (d *Downloader) concurrentFetch(queue typedQueue, beaconMode bool) error {
 				// idle. If the delivery's stale, the peer should have already been idled.
 				if !errors.Is(err, errStaleDelivery) {
 					queue.updateCapacity(peer, accepted, res.Time)
+
+					// Responses without any of the requested items, or with
+					// items not matching them, are useless
+					if accepted == 0 || err != nil {
+						res.ReportUseless()
+					}
 				}
 			}
 
@@ -31,6 +31,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/consensus/clique"
 	"github.com/sdcereum/go-sdcereum/consensus/sdcash"
 	"github.com/sdcereum/go-sdcereum/core"
+	"github.com/sdcereum/go-sdcereum/core/state/pruner"
 	"github.com/sdcereum/go-sdcereum/sdc/downloader"
 	"github.com/sdcereum/go-sdcereum/sdc/gasprice"
 	"github.com/sdcereum/go-sdcereum/sdcdb"
@@ -77,6 +78,7 @@ var Defaults = Config{
 	LightPeers:              100,
 	UltraLightFraction:      75,
 	DatabaseCache:           512,
+	SecondaryRefresh:        5 * time.Second,
 	TrieCleanCache:          154,
 	TrieCleanCacheJournal:   "triecache",
 	TrieCleanCacheRejournal: 60 * time.Minute,
@@ -90,6 +92,11 @@ var Defaults = Config{
 	RPCEVMTimeout:           5 * time.Second,
 	GPO:                     FullNodeGPO,
 	RPCTxFeeCap:             1, // 1 sdcer
+	OnlinePruner: pruner.OnlineConfig{
+		BloomSize: 2048,
+		Interval:  24 * time.Hour,
+		Throttle:  50 * time.Millisecond,
+	},
 }
 
 func init() {
@@ -133,6 +140,9 @@ type Config struct {
 	NoPruning  bool // Whsdcer to disable pruning and flush everything to disk
 	NoPrefetch bool // Whsdcer to disable prefetching and only load state on demand
 
+	OnlinePruning bool                // Enables pruning stale state in the background while running
+	OnlinePruner  pruner.OnlineConfig `toml:",omitempty"` // Settings of the background state pruner
+
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
@@ -140,6 +150,10 @@ type Config struct {
 	// presence of these blocks for every new peer connection.
 	RequiredBlocks map[uint64]common.Hash `toml:"-"`
 
+	// SyncAnchor is a block trusted to be canonical, from which the chain of an
+	// empty database is backfilled in reverse instead of synced from the genesis.
+	SyncAnchor *downloader.TrustedAnchor `toml:"-"`
+
 	// Light client options
 	LightServ          int  `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightIngress       int  `toml:",omitempty"` // Incoming bandwidth limit for light servers
@@ -160,6 +174,12 @@ type Config struct {
 	DatabaseCache      int
 	DatabaseFreezer    string
 
+	Secondary        string        `toml:",omitempty"` // Data directory of the node whose database to follow read-only
+	SecondaryRefresh time.Duration `toml:",omitempty"` // Time interval to catch up with the followed database
+
+	RemoteDB      string `toml:",omitempty"` // Endpoint of a database server to use as the chain database
+	RemoteDBServe string `toml:",omitempty"` // Endpoint to serve the chain database on read-only
+
 	TrieCleanCache          int
 	TrieCleanCacheJournal   string        `toml:",omitempty"` // Disk journal directory for trie cache to survive node restarts
 	TrieCleanCacheRejournal time.Duration `toml:",omitempty"` // Time interval to regenerate the journal for clean cache
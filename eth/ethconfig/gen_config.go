@@ -9,10 +9,11 @@ import (
 	"github.com/sdcereum/go-sdcereum/common"
 	"github.com/sdcereum/go-sdcereum/consensus/sdcash"
 	"github.com/sdcereum/go-sdcereum/core"
-	"github.com/sdcereum/go-sdcereum/sdc/downloader"
-	"github.com/sdcereum/go-sdcereum/sdc/gasprice"
+	"github.com/sdcereum/go-sdcereum/core/state/pruner"
 	"github.com/sdcereum/go-sdcereum/miner"
 	"github.com/sdcereum/go-sdcereum/params"
+	"github.com/sdcereum/go-sdcereum/sdc/downloader"
+	"github.com/sdcereum/go-sdcereum/sdc/gasprice"
 )
 
 // MarshalTOML marshals as TOML.
@@ -25,22 +26,29 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SnapDiscoveryURLs                     []string
 		NoPruning                             bool
 		NoPrefetch                            bool
-		TxLookupLimit                         uint64                 `toml:",omitempty"`
-		RequiredBlocks                        map[uint64]common.Hash `toml:"-"`
-		LightServ                             int                    `toml:",omitempty"`
-		LightIngress                          int                    `toml:",omitempty"`
-		LightEgress                           int                    `toml:",omitempty"`
-		LightPeers                            int                    `toml:",omitempty"`
-		LightNoPrune                          bool                   `toml:",omitempty"`
-		LightNoSyncServe                      bool                   `toml:",omitempty"`
-		SyncFromCheckpoint                    bool                   `toml:",omitempty"`
-		UltraLightServers                     []string               `toml:",omitempty"`
-		UltraLightFraction                    int                    `toml:",omitempty"`
-		UltraLightOnlyAnnounce                bool                   `toml:",omitempty"`
-		SkipBcVersionCheck                    bool                   `toml:"-"`
-		DatabaseHandles                       int                    `toml:"-"`
+		OnlinePruning                         bool
+		OnlinePruner                          pruner.OnlineConfig       `toml:",omitempty"`
+		TxLookupLimit                         uint64                    `toml:",omitempty"`
+		RequiredBlocks                        map[uint64]common.Hash    `toml:"-"`
+		SyncAnchor                            *downloader.TrustedAnchor `toml:"-"`
+		LightServ                             int                       `toml:",omitempty"`
+		LightIngress                          int                       `toml:",omitempty"`
+		LightEgress                           int                       `toml:",omitempty"`
+		LightPeers                            int                       `toml:",omitempty"`
+		LightNoPrune                          bool                      `toml:",omitempty"`
+		LightNoSyncServe                      bool                      `toml:",omitempty"`
+		SyncFromCheckpoint                    bool                      `toml:",omitempty"`
+		UltraLightServers                     []string                  `toml:",omitempty"`
+		UltraLightFraction                    int                       `toml:",omitempty"`
+		UltraLightOnlyAnnounce                bool                      `toml:",omitempty"`
+		SkipBcVersionCheck                    bool                      `toml:"-"`
+		DatabaseHandles                       int                       `toml:"-"`
 		DatabaseCache                         int
 		DatabaseFreezer                       string
+		Secondary                             string        `toml:",omitempty"`
+		SecondaryRefresh                      time.Duration `toml:",omitempty"`
+		RemoteDB                              string        `toml:",omitempty"`
+		RemoteDBServe                         string        `toml:",omitempty"`
 		TrieCleanCache                        int
 		TrieCleanCacheJournal                 string        `toml:",omitempty"`
 		TrieCleanCacheRejournal               time.Duration `toml:",omitempty"`
@@ -71,8 +79,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SnapDiscoveryURLs = c.SnapDiscoveryURLs
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
+	enc.OnlinePruning = c.OnlinePruning
+	enc.OnlinePruner = c.OnlinePruner
 	enc.TxLookupLimit = c.TxLookupLimit
 	enc.RequiredBlocks = c.RequiredBlocks
+	enc.SyncAnchor = c.SyncAnchor
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
 	enc.LightEgress = c.LightEgress
@@ -87,6 +98,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.DatabaseFreezer = c.DatabaseFreezer
+	enc.Secondary = c.Secondary
+	enc.SecondaryRefresh = c.SecondaryRefresh
+	enc.RemoteDB = c.RemoteDB
+	enc.RemoteDBServe = c.RemoteDBServe
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieCleanCacheJournal = c.TrieCleanCacheJournal
 	enc.TrieCleanCacheRejournal = c.TrieCleanCacheRejournal
@@ -121,22 +136,29 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SnapDiscoveryURLs                     []string
 		NoPruning                             *bool
 		NoPrefetch                            *bool
-		TxLookupLimit                         *uint64                `toml:",omitempty"`
-		RequiredBlocks                        map[uint64]common.Hash `toml:"-"`
-		LightServ                             *int                   `toml:",omitempty"`
-		LightIngress                          *int                   `toml:",omitempty"`
-		LightEgress                           *int                   `toml:",omitempty"`
-		LightPeers                            *int                   `toml:",omitempty"`
-		LightNoPrune                          *bool                  `toml:",omitempty"`
-		LightNoSyncServe                      *bool                  `toml:",omitempty"`
-		SyncFromCheckpoint                    *bool                  `toml:",omitempty"`
-		UltraLightServers                     []string               `toml:",omitempty"`
-		UltraLightFraction                    *int                   `toml:",omitempty"`
-		UltraLightOnlyAnnounce                *bool                  `toml:",omitempty"`
-		SkipBcVersionCheck                    *bool                  `toml:"-"`
-		DatabaseHandles                       *int                   `toml:"-"`
+		OnlinePruning                         *bool
+		OnlinePruner                          *pruner.OnlineConfig      `toml:",omitempty"`
+		TxLookupLimit                         *uint64                   `toml:",omitempty"`
+		RequiredBlocks                        map[uint64]common.Hash    `toml:"-"`
+		SyncAnchor                            *downloader.TrustedAnchor `toml:"-"`
+		LightServ                             *int                      `toml:",omitempty"`
+		LightIngress                          *int                      `toml:",omitempty"`
+		LightEgress                           *int                      `toml:",omitempty"`
+		LightPeers                            *int                      `toml:",omitempty"`
+		LightNoPrune                          *bool                     `toml:",omitempty"`
+		LightNoSyncServe                      *bool                     `toml:",omitempty"`
+		SyncFromCheckpoint                    *bool                     `toml:",omitempty"`
+		UltraLightServers                     []string                  `toml:",omitempty"`
+		UltraLightFraction                    *int                      `toml:",omitempty"`
+		UltraLightOnlyAnnounce                *bool                     `toml:",omitempty"`
+		SkipBcVersionCheck                    *bool                     `toml:"-"`
+		DatabaseHandles                       *int                      `toml:"-"`
 		DatabaseCache                         *int
 		DatabaseFreezer                       *string
+		Secondary                             *string        `toml:",omitempty"`
+		SecondaryRefresh                      *time.Duration `toml:",omitempty"`
+		RemoteDB                              *string        `toml:",omitempty"`
+		RemoteDBServe                         *string        `toml:",omitempty"`
 		TrieCleanCache                        *int
 		TrieCleanCacheJournal                 *string        `toml:",omitempty"`
 		TrieCleanCacheRejournal               *time.Duration `toml:",omitempty"`
@@ -184,12 +206,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NoPrefetch != nil {
 		c.NoPrefetch = *dec.NoPrefetch
 	}
+	if dec.OnlinePruning != nil {
+		c.OnlinePruning = *dec.OnlinePruning
+	}
+	if dec.OnlinePruner != nil {
+		c.OnlinePruner = *dec.OnlinePruner
+	}
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
+	if dec.SyncAnchor != nil {
+		c.SyncAnchor = dec.SyncAnchor
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -232,6 +263,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseFreezer != nil {
 		c.DatabaseFreezer = *dec.DatabaseFreezer
 	}
+	if dec.Secondary != nil {
+		c.Secondary = *dec.Secondary
+	}
+	if dec.SecondaryRefresh != nil {
+		c.SecondaryRefresh = *dec.SecondaryRefresh
+	}
+	if dec.RemoteDB != nil {
+		c.RemoteDB = *dec.RemoteDB
+	}
+	if dec.RemoteDBServe != nil {
+		c.RemoteDBServe = *dec.RemoteDBServe
+	}
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
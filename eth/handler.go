@@ -29,6 +29,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/consensus/beacon"
 	"github.com/sdcereum/go-sdcereum/core"
 	"github.com/sdcereum/go-sdcereum/core/forkid"
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
 	"github.com/sdcereum/go-sdcereum/core/types"
 	"github.com/sdcereum/go-sdcereum/sdc/downloader"
 	"github.com/sdcereum/go-sdcereum/sdc/fetcher"
@@ -87,6 +88,7 @@ type handlerConfig struct {
 	EventMux       *event.TypeMux            // Legacy event mux, deprecate for `feed`
 	Checkpoint     *params.TrustedCheckpoint // Hard coded checkpoint for sync challenges
 	RequiredBlocks map[uint64]common.Hash    // Hard coded map of required block hashes for sync challenges
+	Anchor         *downloader.TrustedAnchor // Trusted block to backfill the chain from, if any
 }
 
 type handler struct {
@@ -99,6 +101,9 @@ type handler struct {
 	checkpointNumber uint64      // Block number for the sync progress validator to cross reference
 	checkpointHash   common.Hash // Block hash for the sync progress validator to cross reference
 
+	anchor     *downloader.TrustedAnchor // Trusted block to backfill the chain from
+	anchorSync uint32                    // Progress of the sync from the trusted anchor (anchorSync* constants)
+
 	database sdcdb.Database
 	txpool   txPool
 	chain    *core.BlockChain
@@ -166,6 +171,18 @@ func newHandler(config *handlerConfig) (*handler, error) {
 			h.snapSync = uint32(1)
 		}
 	}
+	// If a trusted anchor was given for an empty database, backfill the chain from
+	// it instead of syncing up from the genesis
+	if config.Anchor != nil {
+		if number := rawdb.ReadHeaderNumber(config.Database, config.Anchor.Hash); number != nil && h.chain.HasFastBlock(config.Anchor.Hash, *number) {
+			log.Info("Chain already synced past trusted anchor", "number", *number, "hash", config.Anchor.Hash)
+		} else if h.snapSync == 0 {
+			log.Warn("Ignoring trusted anchor, snap sync unavailable", "hash", config.Anchor.Hash)
+		} else {
+			h.anchor = config.Anchor
+			h.anchorSync = anchorSyncPending
+		}
+	}
 	// If we have trusted checkpoints, enforce them on the chain
 	if config.Checkpoint != nil {
 		h.checkpointNumber = (config.Checkpoint.SectionIndex+1)*params.CHTFrequency - 1
@@ -174,6 +191,11 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	// If sync succeeds, pass a callback to potentially disable snap sync mode
 	// and enable transaction propagation.
 	success := func() {
+		// If the chain was backfilled from a trusted anchor, resume the regular
+		// chain sync from there on
+		if atomic.CompareAndSwapUint32(&h.anchorSync, anchorSyncRunning, anchorSyncDone) {
+			log.Info("Synced from trusted anchor", "hash", h.anchor.Hash)
+		}
 		// If we were running snap sync and it finished, disable doing another
 		// round on next sync cycle
 		if atomic.LoadUint32(&h.snapSync) == 1 {
@@ -292,7 +314,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		}
 		return n, err
 	}
-	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, h.BroadcastBlock, heighter, nil, inserter, h.removePeer)
+	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, h.BroadcastBlock, heighter, nil, inserter, h.removeInvalidPeer)
 
 	fetchTx := func(peer string, hashes []common.Hash) error {
 		p := h.peers.peer(peer)
@@ -508,6 +530,16 @@ func (h *handler) removePeer(id string) {
 	}
 }
 
+// removeInvalidPeer lowers the reputation of a peer for delivering an invalid
+// block and requests its disconnection.
+func (h *handler) removeInvalidPeer(id string) {
+	peer := h.peers.peer(id)
+	if peer != nil {
+		peer.Peer.Report(p2p.InvalidBlock)
+		peer.Peer.Disconnect(p2p.DiscUselessPeer)
+	}
+}
+
 // unregisterPeer removes a peer from the downloader, fetchers and main peer set.
 func (h *handler) unregisterPeer(id string) {
 	// Create a custom logger to avoid printing the entire id
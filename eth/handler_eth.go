@@ -67,9 +67,12 @@ func (h *sdcHandler) Handle(peer *sdc.Peer, packet sdc.Packet) error {
 	case *sdc.NewBlockPacket:
 		return h.handleBlockBroadcast(peer, packet.Block, packet.TD)
 
-	case *sdc.NewPooledTransactionHashesPacket:
+	case *sdc.NewPooledTransactionHashesPacket66:
 		return h.txFetcher.Notify(peer.ID(), *packet)
 
+	case *sdc.NewPooledTransactionHashesPacket68:
+		return h.txFetcher.Notify(peer.ID(), packet.Hashes)
+
 	case *sdc.TransactionsPacket:
 		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
 
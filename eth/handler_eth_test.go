@@ -61,10 +61,14 @@ func (h *testsdcHandler) Handle(peer *sdc.Peer, packet sdc.Packet) error {
 		h.blockBroadcasts.Send(packet.Block)
 		return nil
 
-	case *sdc.NewPooledTransactionHashesPacket:
+	case *sdc.NewPooledTransactionHashesPacket66:
 		h.txAnnounces.Send(([]common.Hash)(*packet))
 		return nil
 
+	case *sdc.NewPooledTransactionHashesPacket68:
+		h.txAnnounces.Send(packet.Hashes)
+		return nil
+
 	case *sdc.TransactionsPacket:
 		h.txBroadcasts.Send(([]*types.Transaction)(*packet))
 		return nil
@@ -235,6 +239,7 @@ func testForkIDSplit(t *testing.T, protocol uint) {
 
 // Tests that received transactions are added to the local pool.
 func TestRecvTransactions66(t *testing.T) { testRecvTransactions(t, sdc.sdc66) }
+func TestRecvTransactions68(t *testing.T) { testRecvTransactions(t, sdc.sdc68) }
 
 func testRecvTransactions(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -292,6 +297,7 @@ func testRecvTransactions(t *testing.T, protocol uint) {
 
 // This test checks that pending transactions are sent.
 func TestSendTransactions66(t *testing.T) { testSendTransactions(t, sdc.sdc66) }
+func TestSendTransactions68(t *testing.T) { testSendTransactions(t, sdc.sdc68) }
 
 func testSendTransactions(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -350,7 +356,7 @@ func testSendTransactions(t *testing.T, protocol uint) {
 	seen := make(map[common.Hash]struct{})
 	for len(seen) < len(insert) {
 		switch protocol {
-		case 66:
+		case 66, 68:
 			select {
 			case hashes := <-anns:
 				for _, hash := range hashes {
@@ -377,6 +383,7 @@ func testSendTransactions(t *testing.T, protocol uint) {
 // Tests that transactions get propagated to all attached peers, either via direct
 // broadcasts or via announcements/retrievals.
 func TestTransactionPropagation66(t *testing.T) { testTransactionPropagation(t, sdc.sdc66) }
+func TestTransactionPropagation68(t *testing.T) { testTransactionPropagation(t, sdc.sdc68) }
 
 func testTransactionPropagation(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -107,6 +107,14 @@ type Response struct {
 	Done chan error    // Channel to signal message handling to the reader
 }
 
+// ReportUseless lowers the reputation of the peer which sent the response, for
+// delivering data the requester can't use.
+func (r *Response) ReportUseless() {
+	if r.Req != nil && r.Req.peer != nil { // Tests mock out the dispatcher
+		r.Req.peer.Report(p2p.UselessResponse)
+	}
+}
+
 // response is a wrapper around a remote Response that has an error channel to
 // signal on if processing the response failed.
 type response struct {
@@ -150,6 +158,7 @@ func (p *Peer) dispatchResponse(res *Response, metadata func() interface{}) erro
 	case p.resDispatch <- resOp:
 		// Ensure the response is accepted by the dispatcher
 		if err := <-resOp.fail; err != nil {
+			p.Report(p2p.UselessResponse)
 			return nil
 		}
 		// Request was accepted, run any postprocessing step to generate metadata
@@ -171,7 +180,12 @@ func (p *Peer) dispatchResponse(res *Response, metadata func() interface{}) erro
 			// for fresh cancellations too
 			select {
 			case res.Req.sink <- res:
-				return <-res.Done // Response delivered, return any errors
+				// Response delivered, return any errors
+				err := <-res.Done
+				if err != nil {
+					res.ReportUseless()
+				}
+				return err
 			case <-res.Req.cancel:
 				return nil // Request cancelled, silently discard response
 			}
@@ -194,7 +208,7 @@ func (p *Peer) dispatcher() {
 			req := reqOp.req
 			req.Sent = time.Now()
 
-			requestTracker.Track(p.id, p.version, req.code, req.want, req.id)
+			requestTracker.TrackExpiry(p.id, p.version, req.code, req.want, req.id, p.reportTimeout)
 			err := p2p.Send(p.rw, req.code, req.data)
 			reqOp.fail <- err
 
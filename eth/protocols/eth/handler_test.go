@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/sdcereum/go-sdcereum/common"
 	"github.com/sdcereum/go-sdcereum/consensus/sdcash"
@@ -557,3 +558,26 @@ func testGetBlockReceipts(t *testing.T, protocol uint) {
 		t.Errorf("receipts mismatch: %v", err)
 	}
 }
+
+// Tests that responses to requests never made lower the score of the peer.
+func TestDanglingResponseScore66(t *testing.T) { testDanglingResponseScore(t, sdc66) }
+
+func testDanglingResponseScore(t *testing.T, protocol uint) {
+	t.Parallel()
+
+	backend := newTestBackend(4)
+	defer backend.close()
+
+	peer, _ := newTestPeer("peer", protocol, backend)
+	defer peer.close()
+
+	if err := p2p.Send(peer.app, BlockHeadersMsg, &BlockHeadersPacket66{RequestId: 1}); err != nil {
+		t.Fatalf("failed to send response: %v", err)
+	}
+	for i := 0; i < 100 && peer.Peer.Peer.Score() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if score := peer.Peer.Peer.Score(); score >= 0 {
+		t.Fatalf("score not lowered: have %v", score)
+	}
+}
@@ -19,6 +19,7 @@ package sdc
 import (
 	"bytes"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/sdcereum/go-sdcereum/common"
@@ -264,3 +265,30 @@ func Testsdc66Messages(t *testing.T) {
 		}
 	}
 }
+
+// TestNewPooledTransactionHashes68 tests the encoding of sdc/68 transaction
+// announcements, which carry the type and size next to each hash.
+func TestNewPooledTransactionHashes68(t *testing.T) {
+	packet := NewPooledTransactionHashesPacket68{
+		Types:  []byte{types.LegacyTxType, types.DynamicFeeTxType},
+		Sizes:  []uint32{111, 222},
+		Hashes: []common.Hash{{1}, {2}},
+	}
+	enc, err := rlp.EncodeToBytes(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.FromHex("f84b820002c36f81de" +
+		"f842a00100000000000000000000000000000000000000000000000000000000000000" +
+		"a00200000000000000000000000000000000000000000000000000000000000000")
+	if !bytes.Equal(enc, want) {
+		t.Fatalf("wrong encoding\nhave %x\nwant %x", enc, want)
+	}
+	var dec NewPooledTransactionHashesPacket68
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dec, packet) {
+		t.Fatalf("wrong decoded packet %+v", dec)
+	}
+}
@@ -71,12 +71,26 @@ func (p *Peer) Log() log.Logger {
 	return p.logger
 }
 
+// report scores the peer based on its behaviour, if it's backed by a network
+// connection.
+func (p *Peer) report(sig p2p.PeerSignal) {
+	if p.Peer != nil {
+		p.Peer.Report(sig)
+	}
+}
+
+// reportTimeout lowers the reputation of the peer for not answering a request
+// in time.
+func (p *Peer) reportTimeout() {
+	p.report(p2p.RequestTimeout)
+}
+
 // RequestAccountRange fetches a batch of accounts rooted in a specific account
 // trie, starting with the origin.
 func (p *Peer) RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error {
 	p.logger.Trace("Fetching range of accounts", "reqid", id, "root", root, "origin", origin, "limit", limit, "bytes", common.StorageSize(bytes))
 
-	requestTracker.Track(p.id, p.version, GetAccountRangeMsg, AccountRangeMsg, id)
+	requestTracker.TrackExpiry(p.id, p.version, GetAccountRangeMsg, AccountRangeMsg, id, p.reportTimeout)
 	return p2p.Send(p.rw, GetAccountRangeMsg, &GetAccountRangePacket{
 		ID:     id,
 		Root:   root,
@@ -95,7 +109,7 @@ func (p *Peer) RequestStorageRanges(id uint64, root common.Hash, accounts []comm
 	} else {
 		p.logger.Trace("Fetching ranges of small storage slots", "reqid", id, "root", root, "accounts", len(accounts), "first", accounts[0], "bytes", common.StorageSize(bytes))
 	}
-	requestTracker.Track(p.id, p.version, GetStorageRangesMsg, StorageRangesMsg, id)
+	requestTracker.TrackExpiry(p.id, p.version, GetStorageRangesMsg, StorageRangesMsg, id, p.reportTimeout)
 	return p2p.Send(p.rw, GetStorageRangesMsg, &GetStorageRangesPacket{
 		ID:       id,
 		Root:     root,
@@ -110,7 +124,7 @@ func (p *Peer) RequestStorageRanges(id uint64, root common.Hash, accounts []comm
 func (p *Peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
 	p.logger.Trace("Fetching set of byte codes", "reqid", id, "hashes", len(hashes), "bytes", common.StorageSize(bytes))
 
-	requestTracker.Track(p.id, p.version, GetByteCodesMsg, ByteCodesMsg, id)
+	requestTracker.TrackExpiry(p.id, p.version, GetByteCodesMsg, ByteCodesMsg, id, p.reportTimeout)
 	return p2p.Send(p.rw, GetByteCodesMsg, &GetByteCodesPacket{
 		ID:     id,
 		Hashes: hashes,
@@ -123,7 +137,7 @@ func (p *Peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) e
 func (p *Peer) RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error {
 	p.logger.Trace("Fetching set of trie nodes", "reqid", id, "root", root, "pathsets", len(paths), "bytes", common.StorageSize(bytes))
 
-	requestTracker.Track(p.id, p.version, GetTrieNodesMsg, TrieNodesMsg, id)
+	requestTracker.TrackExpiry(p.id, p.version, GetTrieNodesMsg, TrieNodesMsg, id, p.reportTimeout)
 	return p2p.Send(p.rw, GetTrieNodesMsg, &GetTrieNodesPacket{
 		ID:    id,
 		Root:  root,
@@ -40,6 +40,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/event"
 	"github.com/sdcereum/go-sdcereum/light"
 	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/p2p"
 	"github.com/sdcereum/go-sdcereum/p2p/msgrate"
 	"github.com/sdcereum/go-sdcereum/rlp"
 	"github.com/sdcereum/go-sdcereum/trie"
@@ -2319,6 +2320,31 @@ func (s *Syncer) forwardAccountTask(task *accountTask) {
 	log.Debug("Persisted range of accounts", "accounts", len(res.accounts), "bytes", s.accountBytes-oldAccountBytes)
 }
 
+// reportDelivery scores a peer based on a delivered response. Responses arriving
+// within the target round trip time are rewarded. Empty responses are neither
+// rewarded nor penalized, honest peers serve them too if the requested state root
+// went stale, e.g. around pivot moves. Peers not backed by a network connection
+// are ignored.
+func (s *Syncer) reportDelivery(peer SyncPeer, elapsed time.Duration, items int) {
+	p, ok := peer.(*Peer)
+	if !ok || items == 0 {
+		return
+	}
+	if elapsed <= s.rates.TargetRoundTrip() {
+		p.report(p2p.GoodThroughput)
+	}
+}
+
+// reportUseless lowers the score of a peer for a response which can't be used,
+// either because it doesn't belong to any pending request or because it fails
+// validation against the requested state. Peers not backed by a network
+// connection are ignored.
+func (s *Syncer) reportUseless(peer SyncPeer) {
+	if p, ok := peer.(*Peer); ok {
+		p.report(p2p.UselessResponse)
+	}
+}
+
 // OnAccounts is a callback msdcod to invoke when a range of accounts are
 // received from a remote peer.
 func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
@@ -2352,11 +2378,13 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 	if !ok {
 		// Request stale, perhaps the peer timed out but came through in the end
 		logger.Warn("Unexpected account range packet")
+		s.reportUseless(peer)
 		s.lock.Unlock()
 		return nil
 	}
 	delete(s.accountReqs, id)
 	s.rates.Update(peer.ID(), AccountRangeMsg, time.Since(req.time), int(size))
+	s.reportDelivery(peer, time.Since(req.time), int(size))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2399,6 +2427,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 	cont, err := trie.VerifyRangeProof(root, req.origin[:], end, keys, accounts, proofdb)
 	if err != nil {
 		logger.Warn("Account range failed proof", "err", err)
+		s.reportUseless(peer)
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertAccountRequest(req)
 		return err
@@ -2468,11 +2497,13 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 	if !ok {
 		// Request stale, perhaps the peer timed out but came through in the end
 		logger.Warn("Unexpected bytecode packet")
+		s.reportUseless(peer)
 		s.lock.Unlock()
 		return nil
 	}
 	delete(s.bytecodeReqs, id)
 	s.rates.Update(peer.ID(), ByteCodesMsg, time.Since(req.time), len(bytecodes))
+	s.reportDelivery(peer, time.Since(req.time), len(bytecodes))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2518,6 +2549,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 		}
 		// We've either ran out of hashes, or got unrequested data
 		logger.Warn("Unexpected bytecodes", "count", len(bytecodes)-i)
+		s.reportUseless(peer)
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertBytecodeRequest(req)
 		return errors.New("unexpected bytecode")
@@ -2581,11 +2613,13 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 	if !ok {
 		// Request stale, perhaps the peer timed out but came through in the end
 		logger.Warn("Unexpected storage ranges packet")
+		s.reportUseless(peer)
 		s.lock.Unlock()
 		return nil
 	}
 	delete(s.storageReqs, id)
 	s.rates.Update(peer.ID(), StorageRangesMsg, time.Since(req.time), int(size))
+	s.reportDelivery(peer, time.Since(req.time), int(size))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2601,12 +2635,14 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 		s.lock.Unlock()
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		logger.Warn("Hash and slot set size mismatch", "hashset", len(hashes), "slotset", len(slots))
+		s.reportUseless(peer)
 		return errors.New("hash and slot set size mismatch")
 	}
 	if len(hashes) > len(req.accounts) {
 		s.lock.Unlock()
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		logger.Warn("Hash set larger than requested", "hashset", len(hashes), "requested", len(req.accounts))
+		s.reportUseless(peer)
 		return errors.New("hash set larger than requested")
 	}
 	// Response is valid, but check if peer is signalling that it does not have
@@ -2645,6 +2681,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 			if err != nil {
 				s.scheduleRevertStorageRequest(req) // reschedule request
 				logger.Warn("Storage slots failed proof", "err", err)
+				s.reportUseless(peer)
 				return err
 			}
 		} else {
@@ -2660,6 +2697,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 			if err != nil {
 				s.scheduleRevertStorageRequest(req) // reschedule request
 				logger.Warn("Storage range failed proof", "err", err)
+				s.reportUseless(peer)
 				return err
 			}
 		}
@@ -2712,11 +2750,13 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 	if !ok {
 		// Request stale, perhaps the peer timed out but came through in the end
 		logger.Warn("Unexpected trienode heal packet")
+		s.reportUseless(peer)
 		s.lock.Unlock()
 		return nil
 	}
 	delete(s.trienodeHealReqs, id)
 	s.rates.Update(peer.ID(), TrieNodesMsg, time.Since(req.time), len(trienodes))
+	s.reportDelivery(peer, time.Since(req.time), len(trienodes))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2765,6 +2805,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 		}
 		// We've either ran out of hashes, or got unrequested data
 		logger.Warn("Unexpected healing trienodes", "count", len(trienodes)-i)
+		s.reportUseless(peer)
 
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertTrienodeHealRequest(req)
@@ -2819,11 +2860,13 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 	if !ok {
 		// Request stale, perhaps the peer timed out but came through in the end
 		logger.Warn("Unexpected bytecode heal packet")
+		s.reportUseless(peer)
 		s.lock.Unlock()
 		return nil
 	}
 	delete(s.bytecodeHealReqs, id)
 	s.rates.Update(peer.ID(), ByteCodesMsg, time.Since(req.time), len(bytecodes))
+	s.reportDelivery(peer, time.Since(req.time), len(bytecodes))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2869,6 +2912,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 		}
 		// We've either ran out of hashes, or got unrequested data
 		logger.Warn("Unexpected healing bytecodes", "count", len(bytecodes)-i)
+		s.reportUseless(peer)
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertBytecodeHealRequest(req)
 		return errors.New("unexpected healing bytecode")
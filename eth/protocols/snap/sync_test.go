@@ -34,6 +34,8 @@ import (
 	"github.com/sdcereum/go-sdcereum/sdcdb"
 	"github.com/sdcereum/go-sdcereum/light"
 	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/p2p"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
 	"github.com/sdcereum/go-sdcereum/rlp"
 	"github.com/sdcereum/go-sdcereum/trie"
 	"golang.org/x/crypto/sha3"
@@ -626,6 +628,33 @@ func setupSyncer(peers ...*testPeer) *Syncer {
 	return syncer
 }
 
+// TestUselessResponseScore tests that responses not belonging to any request
+// lower the score of the peer delivering them.
+func TestUselessResponseScore(t *testing.T) {
+	t.Parallel()
+
+	var id enode.ID
+	rand.Read(id[:])
+	peer := NewPeer(SNAP1, p2p.NewPeer(id, "test", nil), nil)
+	syncer := setupSyncer()
+
+	deliveries := map[string]func() error{
+		"accounts":  func() error { return syncer.OnAccounts(peer, 1, nil, nil, nil) },
+		"bytecodes": func() error { return syncer.OnByteCodes(peer, 2, nil) },
+		"storage":   func() error { return syncer.OnStorage(peer, 3, nil, nil, nil) },
+		"trienodes": func() error { return syncer.OnTrieNodes(peer, 4, nil) },
+	}
+	for name, deliver := range deliveries {
+		score := peer.Peer.Score()
+		if err := deliver(); err != nil {
+			t.Fatalf("%s: failed to deliver: %v", name, err)
+		}
+		if have := peer.Peer.Score(); have >= score {
+			t.Fatalf("%s: score not lowered: have %v, had %v", name, have, score)
+		}
+	}
+}
+
 // TestSync tests a basic sync with one peer
 func TestSync(t *testing.T) {
 	t.Parallel()
@@ -35,6 +35,13 @@ const (
 	defaultMinSyncPeers = 5                // Amount of peers desired to start syncing
 )
 
+// Progress markers of the sync from a trusted anchor.
+const (
+	anchorSyncDone    uint32 = iota // No anchor to sync from, or the backfill finished
+	anchorSyncPending               // Anchor sync requested, waiting for a peer to start
+	anchorSyncRunning               // Chain being backfilled from the anchor
+)
+
 // syncTransactions starts sending all currently pending transactions to the given peer.
 func (h *handler) syncTransactions(p *sdc.Peer) {
 	// Assemble the set of transaction to broadcast or announce to the remote
@@ -69,6 +76,7 @@ type chainSyncer struct {
 	warned      time.Time
 	peerEventCh chan struct{}
 	doneCh      chan error // non-nil when sync is running
+	anchorRetry time.Time  // Time before which the anchor sync is not retried
 }
 
 // chainSyncOp is a scheduled sync operation.
@@ -77,6 +85,8 @@ type chainSyncOp struct {
 	peer *sdc.Peer
 	td   *big.Int
 	head common.Hash
+
+	anchor bool // Whsdcer to start backfilling from the trusted anchor
 }
 
 // newChainSyncer creates a chainSyncer.
@@ -126,6 +136,12 @@ func (cs *chainSyncer) loop() {
 			cs.force.Reset(forceSyncCycle)
 			cs.forced = false
 
+			// If the anchor sync couldn't be started, back off before retrying
+			if err != nil && atomic.LoadUint32(&cs.handler.anchorSync) == anchorSyncPending {
+				log.Debug("Failed to start trusted anchor sync", "err", err)
+				cs.anchorRetry = time.Now().Add(forceSyncCycle)
+			}
+
 			// If we've reached the merge transition but no beacon client is available, or
 			// it has not yet switched us over, keep warning the user that their infra is
 			// potentially flaky.
@@ -155,6 +171,21 @@ func (cs *chainSyncer) nextSyncOp() *chainSyncOp {
 	if cs.doneCh != nil {
 		return nil // Sync already running
 	}
+	// If the chain is backfilled from a trusted anchor, suspend the legacy sync
+	// until done. The backfill itself only needs a peer to retrieve the anchor.
+	switch atomic.LoadUint32(&cs.handler.anchorSync) {
+	case anchorSyncRunning:
+		return nil
+	case anchorSyncPending:
+		if time.Now().Before(cs.anchorRetry) {
+			return nil
+		}
+		peer := cs.handler.peers.peerWithHighestTD()
+		if peer == nil {
+			return nil
+		}
+		return &chainSyncOp{mode: downloader.SnapSync, peer: peer, anchor: true}
+	}
 	// If a beacon client once took over control, disable the entire legacy sync
 	// path from here on end. Note, there is a slight "race" between reaching TTD
 	// and the beacon client taking over. The downloader will enforce that nothing
@@ -233,6 +264,16 @@ func (cs *chainSyncer) startSync(op *chainSyncOp) {
 
 // doSync synchronizes the local blockchain with a remote peer.
 func (h *handler) doSync(op *chainSyncOp) error {
+	if op.anchor {
+		// Mark the backfill running beforehand, as it might finish before the
+		// sync start returns
+		atomic.StoreUint32(&h.anchorSync, anchorSyncRunning)
+		if err := h.downloader.AnchorSync(op.peer.ID(), h.anchor); err != nil {
+			atomic.StoreUint32(&h.anchorSync, anchorSyncPending)
+			return err
+		}
+		return nil
+	}
 	if op.mode == downloader.SnapSync {
 		// Before launch the snap sync, we have to ensure user uses the same
 		// txlookup limit.
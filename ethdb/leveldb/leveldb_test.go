@@ -17,6 +17,8 @@
 package leveldb
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/sdcereum/go-sdcereum/sdcdb"
@@ -38,3 +40,67 @@ func TestLevelDB(t *testing.T) {
 		})
 	})
 }
+
+func TestSecondary(t *testing.T) {
+	dir := t.TempDir()
+
+	primary, err := New(dir, 0, 0, "", false)
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	defer primary.Close()
+
+	if err := primary.Put([]byte("key-0"), []byte("value-0")); err != nil {
+		t.Fatalf("failed to write primary: %v", err)
+	}
+	secondary, err := NewSecondary(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open secondary: %v", err)
+	}
+	defer secondary.Close()
+
+	if val, err := secondary.Get([]byte("key-0")); err != nil || !bytes.Equal(val, []byte("value-0")) {
+		t.Fatalf("value mismatch: have %q, want %q (err %v)", val, "value-0", err)
+	}
+	if err := secondary.Put([]byte("key-0"), nil); err == nil {
+		t.Fatal("secondary accepted write")
+	}
+	// Changes of the primary must only show up after catching up, without
+	// invalidating live iterators
+	it := secondary.NewIterator([]byte("key-"), nil)
+	defer it.Release()
+
+	for i := 1; i < 10; i++ {
+		if err := primary.Put([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatalf("failed to write primary: %v", err)
+		}
+	}
+	if has, _ := secondary.Has([]byte("key-9")); has {
+		t.Fatal("secondary observed write before catching up")
+	}
+	view := secondary.current
+	if err := secondary.CatchUp(); err != nil {
+		t.Fatalf("failed to catch up: %v", err)
+	}
+	if secondary.current == view {
+		t.Fatal("database not reopened after primary changes")
+	}
+	if val, err := secondary.Get([]byte("key-9")); err != nil || !bytes.Equal(val, []byte("value-9")) {
+		t.Fatalf("value mismatch: have %q, want %q (err %v)", val, "value-9", err)
+	}
+	// Without further changes of the primary, the database is not reopened
+	view = secondary.current
+	if err := secondary.CatchUp(); err != nil {
+		t.Fatalf("failed to catch up: %v", err)
+	}
+	if secondary.current != view {
+		t.Fatal("database reopened without primary changes")
+	}
+	var count int
+	for it.Next() {
+		count++
+	}
+	if err := it.Error(); err != nil || count != 1 {
+		t.Fatalf("stale iterator mismatch: have %d items, want %d (err %v)", count, 1, err)
+	}
+}
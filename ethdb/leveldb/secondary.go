@@ -0,0 +1,529 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// errSecondaryReadOnly is returned if a write is attempted on a secondary database.
+var errSecondaryReadOnly = errors.New("leveldb: secondary database is read-only")
+
+// errSecondaryClosed is returned if a secondary database is accessed after Close.
+var errSecondaryClosed = errors.New("leveldb: secondary database closed")
+
+// Secondary is a read-only view of a LevelDB database owned by another process.
+//
+// The owning process holds an exclusive lock on the database, so the secondary
+// bypasses the lock and only ever opens the database files for reading. Since
+// LevelDB has no notion of followers, data written by the primary after the
+// secondary was opened only becomes visible after CatchUp reopens the database
+// on top of the latest manifest and journals. The database is only reopened if
+// the primary modified any of them in the meantime.
+//
+// Tables may be removed by compactions of the primary while the secondary still
+// references them. Reads failing for any reason other than a missing key are
+// therefore retried once after catching up.
+type Secondary struct {
+	fn      string       // filename for reporting
+	options *opt.Options // Options used to (re)open the database
+
+	current *secondaryInstance // Currently active database instance
+	lock    sync.RWMutex       // Lock protecting the current instance
+	catchup sync.Mutex         // Lock serializing catch-up attempts
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// secondaryInstance is a single opened view of the primary's database. Views
+// are reference counted, so that iterators and snapshots created from an old
+// view remain usable after catching up.
+type secondaryInstance struct {
+	db    *leveldb.DB
+	state string // Fingerprint of the primary's files the view was opened on
+	refs  int32
+}
+
+// release drops a reference to the instance, closing it when unused.
+func (inst *secondaryInstance) release() {
+	if atomic.AddInt32(&inst.refs, -1) == 0 {
+		inst.db.Close()
+	}
+}
+
+// NewSecondary opens the LevelDB database at the given path as a read-only
+// secondary of the process owning it.
+func NewSecondary(file string, cache int, handles int) (*Secondary, error) {
+	if cache < minCache {
+		cache = minCache
+	}
+	if handles < minHandles {
+		handles = minHandles
+	}
+	options := configureOptions(func(options *opt.Options) {
+		options.OpenFilesCacheCapacity = handles
+		options.BlockCacheCapacity = cache / 2 * opt.MiB
+		options.ReadOnly = true
+		options.ErrorIfMissing = true
+	})
+	logger := log.New("database", file)
+	logger.Info("Allocated secondary cache and file handles", "cache", common.StorageSize(options.GetBlockCacheCapacity()), "handles", handles)
+
+	db := &Secondary{
+		fn:      file,
+		options: options,
+		log:     logger,
+	}
+	state, err := (&secondaryStorage{path: file}).state()
+	if err != nil {
+		return nil, err
+	}
+	inst, err := db.open(state)
+	if err != nil {
+		return nil, err
+	}
+	db.current = inst
+	return db, nil
+}
+
+// open creates a new view of the primary's database from its current files.
+func (db *Secondary) open(state string) (*secondaryInstance, error) {
+	ldb, err := leveldb.Open(&secondaryStorage{path: db.fn}, db.options)
+	if err != nil {
+		return nil, err
+	}
+	return &secondaryInstance{db: ldb, state: state, refs: 1}, nil
+}
+
+// CatchUp reopens the database to pick up any changes made by the primary since
+// the last time it was opened. The current view is kept if the primary didn't
+// modify its manifest or journals since, or if reopening fails. Readers keep
+// using the current view until the new one replaces it.
+func (db *Secondary) CatchUp() error {
+	db.catchup.Lock()
+	defer db.catchup.Unlock()
+
+	// The state is taken before reopening, so changes made by the primary while
+	// reopening are picked up by the next catch-up
+	state, err := (&secondaryStorage{path: db.fn}).state()
+	if err != nil {
+		return err
+	}
+	db.lock.RLock()
+	current := db.current
+	db.lock.RUnlock()
+
+	if current == nil {
+		return errSecondaryClosed
+	}
+	if current.state == state {
+		return nil
+	}
+	inst, err := db.open(state)
+	if err != nil {
+		return err
+	}
+	db.lock.Lock()
+	old := db.current
+	if old == nil {
+		db.lock.Unlock()
+		inst.release()
+		return errSecondaryClosed
+	}
+	db.current = inst
+	db.lock.Unlock()
+
+	old.release()
+	return nil
+}
+
+// acquire retrieves the current database view, holding a reference to it.
+func (db *Secondary) acquire() (*secondaryInstance, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.current == nil {
+		return nil, errSecondaryClosed
+	}
+	atomic.AddInt32(&db.current.refs, 1)
+	return db.current, nil
+}
+
+// read runs the given retrieval against the current database view. If it fails
+// with anything other than a missing key, the view is caught up and the read is
+// retried once, as the failure might be caused by tables already compacted away
+// by the primary.
+func (db *Secondary) read(fn func(ldb *leveldb.DB) error) error {
+	inst, err := db.acquire()
+	if err != nil {
+		return err
+	}
+	err = fn(inst.db)
+	inst.release()
+
+	if err == nil || err == leveldb.ErrNotFound {
+		return err
+	}
+	if cerr := db.CatchUp(); cerr != nil {
+		db.log.Debug("Failed to catch up with primary", "err", cerr)
+		return err
+	}
+	if inst, err = db.acquire(); err != nil {
+		return err
+	}
+	defer inst.release()
+	return fn(inst.db)
+}
+
+// Close releases the current database view. Outstanding iterators and snapshots
+// keep their own views open until released.
+func (db *Secondary) Close() error {
+	db.lock.Lock()
+	inst := db.current
+	db.current = nil
+	db.lock.Unlock()
+
+	if inst == nil {
+		return errSecondaryClosed
+	}
+	inst.release()
+	return nil
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *Secondary) Has(key []byte) (bool, error) {
+	var has bool
+	err := db.read(func(ldb *leveldb.DB) (err error) {
+		has, err = ldb.Has(key, nil)
+		return err
+	})
+	return has, err
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *Secondary) Get(key []byte) ([]byte, error) {
+	var dat []byte
+	err := db.read(func(ldb *leveldb.DB) (err error) {
+		dat, err = ldb.Get(key, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dat, nil
+}
+
+// Put is not supported by a secondary database.
+func (db *Secondary) Put(key []byte, value []byte) error {
+	return errSecondaryReadOnly
+}
+
+// Delete is not supported by a secondary database.
+func (db *Secondary) Delete(key []byte) error {
+	return errSecondaryReadOnly
+}
+
+// NewBatch creates a batch which can be filled, but always fails to be written.
+func (db *Secondary) NewBatch() sdcdb.Batch {
+	return &secondaryBatch{}
+}
+
+// NewBatchWithSize creates a batch which can be filled, but always fails to be
+// written.
+func (db *Secondary) NewBatchWithSize(size int) sdcdb.Batch {
+	return &secondaryBatch{}
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix, starting at a particular
+// initial key (or after, if it does not exist).
+func (db *Secondary) NewIterator(prefix []byte, start []byte) sdcdb.Iterator {
+	inst, err := db.acquire()
+	if err != nil {
+		return iterator.NewEmptyIterator(err)
+	}
+	return &secondaryIterator{
+		Iterator: inst.db.NewIterator(bytesPrefixRange(prefix, start), nil),
+		inst:     inst,
+	}
+}
+
+// NewSnapshot creates a database snapshot based on the current view of the
+// primary's database.
+func (db *Secondary) NewSnapshot() (sdcdb.Snapshot, error) {
+	inst, err := db.acquire()
+	if err != nil {
+		return nil, err
+	}
+	snap, err := inst.db.GetSnapshot()
+	if err != nil {
+		inst.release()
+		return nil, err
+	}
+	return &secondarySnapshot{snapshot: snapshot{db: snap}, inst: inst}, nil
+}
+
+// Stat returns a particular internal stat of the database.
+func (db *Secondary) Stat(property string) (string, error) {
+	var stat string
+	err := db.read(func(ldb *leveldb.DB) (err error) {
+		stat, err = ldb.GetProperty(property)
+		return err
+	})
+	return stat, err
+}
+
+// Compact is not supported by a secondary database.
+func (db *Secondary) Compact(start []byte, limit []byte) error {
+	return errSecondaryReadOnly
+}
+
+// Path returns the path to the database directory.
+func (db *Secondary) Path() string {
+	return db.fn
+}
+
+// secondaryIterator is an iterator pinning the database view it was created on.
+type secondaryIterator struct {
+	iterator.Iterator
+	inst *secondaryInstance
+	once sync.Once
+}
+
+// Release releases associated resources, including the pinned database view.
+func (it *secondaryIterator) Release() {
+	it.Iterator.Release()
+	it.once.Do(it.inst.release)
+}
+
+// secondarySnapshot is a snapshot pinning the database view it was created on.
+type secondarySnapshot struct {
+	snapshot
+	inst *secondaryInstance
+	once sync.Once
+}
+
+// Release releases associated resources, including the pinned database view.
+func (snap *secondarySnapshot) Release() {
+	snap.snapshot.Release()
+	snap.once.Do(snap.inst.release)
+}
+
+// secondaryBatch is a batch which tracks its size, but can't be written.
+type secondaryBatch struct {
+	size int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *secondaryBatch) Put(key, value []byte) error {
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *secondaryBatch) Delete(key []byte) error {
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *secondaryBatch) ValueSize() int {
+	return b.size
+}
+
+// Write always fails, since secondary databases are read-only.
+func (b *secondaryBatch) Write() error {
+	return errSecondaryReadOnly
+}
+
+// Reset resets the batch for reuse.
+func (b *secondaryBatch) Reset() {
+	b.size = 0
+}
+
+// Replay is a noop, the batch does not retain its contents.
+func (b *secondaryBatch) Replay(w sdcdb.KeyValueWriter) error {
+	return nil
+}
+
+// secondaryStorage is a LevelDB storage giving read-only access to the files of
+// a database without acquiring the database lock held by its owner.
+type secondaryStorage struct {
+	path string
+}
+
+// secondaryLock is the no-op lock handed out by secondaryStorage.
+type secondaryLock struct{}
+
+func (secondaryLock) Unlock() {}
+
+// Lock implements storage.Storage, without touching the lock file.
+func (s *secondaryStorage) Lock() (storage.Locker, error) {
+	return secondaryLock{}, nil
+}
+
+// Log implements storage.Storage, dropping the internal LevelDB logs.
+func (s *secondaryStorage) Log(str string) {}
+
+// SetMeta implements storage.Storage, refusing to modify the database.
+func (s *secondaryStorage) SetMeta(fd storage.FileDesc) error {
+	return errSecondaryReadOnly
+}
+
+// GetMeta implements storage.Storage, returning the manifest the CURRENT file of
+// the primary points to.
+func (s *secondaryStorage) GetMeta() (storage.FileDesc, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(s.path, "CURRENT"))
+	if err != nil {
+		return storage.FileDesc{}, err
+	}
+	if len(blob) == 0 || blob[len(blob)-1] != '\n' {
+		return storage.FileDesc{}, &storage.ErrCorrupted{Err: errors.New("leveldb/storage: incomplete CURRENT file")}
+	}
+	fd, ok := parseSecondaryName(string(bytes.TrimSuffix(blob, []byte{'\n'})))
+	if !ok || fd.Type != storage.TypeManifest {
+		return storage.FileDesc{}, &storage.ErrCorrupted{Err: fmt.Errorf("leveldb/storage: invalid CURRENT file %q", blob)}
+	}
+	return fd, nil
+}
+
+// state returns a fingerprint of the manifest and journals of the primary, which
+// changes whenever the primary persists new data or compacts its tables.
+func (s *secondaryStorage) state() (string, error) {
+	manifest, err := s.GetMeta()
+	if err != nil {
+		return "", err
+	}
+	entries, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return "", err
+	}
+	var state strings.Builder
+	for _, entry := range entries {
+		fd, ok := parseSecondaryName(entry.Name())
+		if !ok || (fd.Type != storage.TypeJournal && fd != manifest) {
+			continue
+		}
+		fmt.Fprintf(&state, "%s:%d:%d;", entry.Name(), entry.Size(), entry.ModTime().UnixNano())
+	}
+	return state.String(), nil
+}
+
+// List implements storage.Storage, listing the database files of the given types.
+func (s *secondaryStorage) List(ft storage.FileType) ([]storage.FileDesc, error) {
+	entries, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var fds []storage.FileDesc
+	for _, entry := range entries {
+		if fd, ok := parseSecondaryName(entry.Name()); ok && fd.Type&ft != 0 {
+			fds = append(fds, fd)
+		}
+	}
+	return fds, nil
+}
+
+// Open implements storage.Storage, opening a database file for reading. Tables
+// are looked up under both their current and legacy names.
+func (s *secondaryStorage) Open(fd storage.FileDesc) (storage.Reader, error) {
+	f, err := os.Open(filepath.Join(s.path, secondaryName(fd)))
+	if os.IsNotExist(err) && fd.Type == storage.TypeTable {
+		f, err = os.Open(filepath.Join(s.path, fmt.Sprintf("%06d.sst", fd.Num)))
+	}
+	if os.IsNotExist(err) {
+		err = os.ErrNotExist
+	}
+	return f, err
+}
+
+// Create implements storage.Storage, refusing to modify the database.
+func (s *secondaryStorage) Create(fd storage.FileDesc) (storage.Writer, error) {
+	return nil, errSecondaryReadOnly
+}
+
+// Remove implements storage.Storage, refusing to modify the database.
+func (s *secondaryStorage) Remove(fd storage.FileDesc) error {
+	return errSecondaryReadOnly
+}
+
+// Rename implements storage.Storage, refusing to modify the database.
+func (s *secondaryStorage) Rename(oldfd, newfd storage.FileDesc) error {
+	return errSecondaryReadOnly
+}
+
+// Close implements storage.Storage. There are no resources to release.
+func (s *secondaryStorage) Close() error {
+	return nil
+}
+
+// secondaryName returns the file name of a database file, using the same naming
+// scheme as LevelDB's own file storage.
+func secondaryName(fd storage.FileDesc) string {
+	switch fd.Type {
+	case storage.TypeManifest:
+		return fmt.Sprintf("MANIFEST-%06d", fd.Num)
+	case storage.TypeJournal:
+		return fmt.Sprintf("%06d.log", fd.Num)
+	case storage.TypeTable:
+		return fmt.Sprintf("%06d.ldb", fd.Num)
+	default:
+		return fmt.Sprintf("%06d.tmp", fd.Num)
+	}
+}
+
+// parseSecondaryName parses a database file name, the inverse of secondaryName.
+func parseSecondaryName(name string) (fd storage.FileDesc, ok bool) {
+	var tail string
+	if _, err := fmt.Sscanf(name, "%d.%s", &fd.Num, &tail); err == nil {
+		switch tail {
+		case "log":
+			fd.Type = storage.TypeJournal
+		case "ldb", "sst":
+			fd.Type = storage.TypeTable
+		case "tmp":
+			fd.Type = storage.TypeTemp
+		default:
+			return fd, false
+		}
+		return fd, true
+	}
+	if n, _ := fmt.Sscanf(name, "MANIFEST-%d%s", &fd.Num, &tail); n == 1 {
+		fd.Type = storage.TypeManifest
+		return fd, true
+	}
+	return fd, false
+}
@@ -0,0 +1,500 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotedb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/sdcereum/go-sdcereum/common/hexutil"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/rlp"
+	"github.com/sdcereum/go-sdcereum/rpc"
+)
+
+// iteratorChunkItems is the number of key-value pairs requested from the server
+// in one go while iterating.
+const iteratorChunkItems = 1024
+
+// Client is a database backed by a remote database server. It implements the
+// full database interface, including writes to both the key-value and ancient
+// store, unless either the client or the server is read-only.
+//
+// Note, reads grouped by ReadAncients are not isolated from concurrent writes
+// of other clients to the same server.
+type Client struct {
+	remote   *rpc.Client
+	conn     io.Closer // Underlying connection for transports not owned by the RPC client
+	readonly bool      // Whsdcer write operations are rejected without reaching the server
+}
+
+// Dial connects to a remote database server listening on the given endpoint. The
+// endpoint is either a TCP address prefixed with tcp://, or the path of a Unix
+// domain socket optionally prefixed with unix://.
+func Dial(endpoint string, readonly bool) (*Client, error) {
+	return DialContext(context.Background(), endpoint, readonly)
+}
+
+// DialContext connects to a remote database server listening on the given endpoint.
+// The context is used for the initial connection establishment only.
+func DialContext(ctx context.Context, endpoint string, readonly bool) (*Client, error) {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		remote, err := rpc.DialIPC(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		return newClient(remote, nil, readonly), nil
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := rpc.DialIO(ctx, conn, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return newClient(remote, conn, readonly), nil
+}
+
+// newClient wraps an RPC client connected to a remote database server.
+func newClient(remote *rpc.Client, conn io.Closer, readonly bool) *Client {
+	return &Client{remote: remote, conn: conn, readonly: readonly}
+}
+
+// write invokes a modifying operation of the remote database service, unless the
+// client is read-only.
+func (db *Client) write(result interface{}, op string, args ...interface{}) error {
+	if db.readonly {
+		return errReadOnly
+	}
+	return db.call(result, op, args...)
+}
+
+// call invokes an operation of the remote database service.
+func (db *Client) call(result interface{}, op string, args ...interface{}) error {
+	return db.remote.Call(result, namespace+"_"+op, args...)
+}
+
+// Has retrieves if a key is present in the key-value data store.
+func (db *Client) Has(key []byte) (bool, error) {
+	var has bool
+	err := db.call(&has, "has", hexutil.Bytes(key))
+	return has, err
+}
+
+// Get retrieves the given key if it's present in the key-value data store.
+func (db *Client) Get(key []byte) ([]byte, error) {
+	var value hexutil.Bytes
+	if err := db.call(&value, "get", hexutil.Bytes(key)); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Put inserts the given value into the key-value data store.
+func (db *Client) Put(key []byte, value []byte) error {
+	return db.write(nil, "put", hexutil.Bytes(key), hexutil.Bytes(value))
+}
+
+// Delete removes the key from the key-value data store.
+func (db *Client) Delete(key []byte) error {
+	return db.write(nil, "delete", hexutil.Bytes(key))
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to the
+// remote database until a final write is called.
+func (db *Client) NewBatch() sdcdb.Batch {
+	return &batch{db: db}
+}
+
+// NewBatchWithSize creates a write-only database batch with pre-allocated buffer.
+func (db *Client) NewBatchWithSize(size int) sdcdb.Batch {
+	return &batch{db: db, data: make([]byte, 0, size)}
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of database
+// content with a particular key prefix, starting at a particular initial key
+// (or after, if it does not exist).
+func (db *Client) NewIterator(prefix []byte, start []byte) sdcdb.Iterator {
+	it := &iterator{db: db}
+	if err := db.call(&it.id, "newIterator", hexutil.Bytes(prefix), hexutil.Bytes(start)); err != nil {
+		it.err, it.done = err, true
+		return it
+	}
+	it.fetch()
+	return it
+}
+
+// NewSnapshot creates a database snapshot based on the current state.
+func (db *Client) NewSnapshot() (sdcdb.Snapshot, error) {
+	snap := &snapshot{db: db}
+	if err := db.call(&snap.id, "newSnapshot"); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Stat returns a particular internal stat of the database.
+func (db *Client) Stat(property string) (string, error) {
+	var stat string
+	err := db.call(&stat, "stat", property)
+	return stat, err
+}
+
+// Compact flattens the underlying data store for the given key range.
+func (db *Client) Compact(start []byte, limit []byte) error {
+	return db.write(nil, "compact", hexutil.Bytes(start), hexutil.Bytes(limit))
+}
+
+// HasAncient returns an indicator whsdcer the specified data exists in the
+// ancient store.
+func (db *Client) HasAncient(kind string, number uint64) (bool, error) {
+	var has bool
+	err := db.call(&has, "hasAncient", kind, hexutil.Uint64(number))
+	return has, err
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (db *Client) Ancient(kind string, number uint64) ([]byte, error) {
+	var blob hexutil.Bytes
+	if err := db.call(&blob, "ancient", kind, hexutil.Uint64(number)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// AncientRange retrieves multiple items in sequence, starting from the index 'start'.
+func (db *Client) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	var blobs []hexutil.Bytes
+	if err := db.call(&blobs, "ancientRange", kind, hexutil.Uint64(start), hexutil.Uint64(count), hexutil.Uint64(maxBytes)); err != nil {
+		return nil, err
+	}
+	items := make([][]byte, len(blobs))
+	for i, blob := range blobs {
+		items[i] = blob
+	}
+	return items, nil
+}
+
+// Ancients returns the ancient item numbers in the ancient store.
+func (db *Client) Ancients() (uint64, error) {
+	var n hexutil.Uint64
+	err := db.call(&n, "ancients")
+	return uint64(n), err
+}
+
+// Tail returns the number of first stored item in the freezer.
+func (db *Client) Tail() (uint64, error) {
+	var n hexutil.Uint64
+	err := db.call(&n, "tail")
+	return uint64(n), err
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (db *Client) AncientSize(kind string) (uint64, error) {
+	var n hexutil.Uint64
+	err := db.call(&n, "ancientSize", kind)
+	return uint64(n), err
+}
+
+// ReadAncients runs the given read operation on the remote ancient store.
+func (db *Client) ReadAncients(fn func(op sdcdb.AncientReaderOp) error) (err error) {
+	return fn(db)
+}
+
+// ModifyAncients runs a write operation on the ancient store. The items are
+// collected locally and only sent to the server if the operation succeeds, where
+// they are applied atomically.
+func (db *Client) ModifyAncients(fn func(sdcdb.AncientWriteOp) error) (int64, error) {
+	op := new(ancientWriteOp)
+	if err := fn(op); err != nil {
+		return 0, err
+	}
+	var size int64
+	err := db.write(&size, "modifyAncients", op.items)
+	return size, err
+}
+
+// TruncateHead discards all but the first n ancient data from the ancient store.
+func (db *Client) TruncateHead(n uint64) error {
+	return db.write(nil, "truncateHead", hexutil.Uint64(n))
+}
+
+// TruncateTail discards the first n ancient data from the ancient store.
+func (db *Client) TruncateTail(n uint64) error {
+	return db.write(nil, "truncateTail", hexutil.Uint64(n))
+}
+
+// Sync flushes all in-memory ancient store data to disk.
+func (db *Client) Sync() error {
+	return db.write(nil, "sync")
+}
+
+// MigrateTable is not supported on remote databases, as the conversion function
+// can't be shipped to the server.
+func (db *Client) MigrateTable(s string, f func([]byte) ([]byte, error)) error {
+	return errNotSupported
+}
+
+// AncientDatadir returns the path of root ancient directory on the server.
+func (db *Client) AncientDatadir() (string, error) {
+	var dir string
+	err := db.call(&dir, "ancientDatadir")
+	return dir, err
+}
+
+// Close disconnects from the remote database server. The remote database itself
+// is not closed.
+func (db *Client) Close() error {
+	// The connection needs to be closed first for transports not owned by the
+	// RPC client, otherwise it would wait for the blocked reader indefinitely.
+	var err error
+	if db.conn != nil {
+		err = db.conn.Close()
+	}
+	db.remote.Close()
+	return err
+}
+
+// batch is a write-only key-value store that buffers changes to the remote
+// database until a final write is called.
+type batch struct {
+	db   *Client
+	ops  []batchOp
+	data []byte // Buffer holding the keys and values of the queued operations
+	size int
+}
+
+// copy appends the given bytes to the data buffer of the batch, returning the
+// copied slice.
+func (b *batch) copy(blob []byte) []byte {
+	start := len(b.data)
+	b.data = append(b.data, blob...)
+	return b.data[start:len(b.data):len(b.data)]
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, batchOp{Key: b.copy(key), Value: b.copy(value)})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{Key: b.copy(key), Delete: true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to the remote database.
+func (b *batch) Write() error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	return b.db.write(nil, "write", b.ops)
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.data = b.data[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w sdcdb.KeyValueWriter) error {
+	for _, op := range b.ops {
+		if op.Delete {
+			if err := w.Delete(op.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(op.Key, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iteratorFetch is the result of retrieving the next chunk of a remote iterator.
+type iteratorFetch struct {
+	chunk iteratorChunk
+	err   error
+}
+
+// iterator iterates over a remote database, retrieving the key-value pairs from
+// the server in chunks. The next chunk is always requested in the background
+// while the current one is consumed, so iteration isn't stalled by a round trip
+// to the server for every chunk.
+type iterator struct {
+	db *Client
+	id hexutil.Uint64
+
+	keys    []hexutil.Bytes
+	values  []hexutil.Bytes
+	pos     int                 // Position of the current item within the chunk, starting at 1
+	pending chan *iteratorFetch // Delivers the chunk requested in the background, nil if none
+
+	done     bool   // Whsdcer the server side iterator is exhausted
+	failure  string // Error reported by the server side iterator on exhaustion
+	released bool
+	err      error
+}
+
+// Next moves the iterator to the next key/value pair. It returns whsdcer the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	if it.err != nil || it.released {
+		return false
+	}
+	if it.pos < len(it.keys) {
+		it.pos++
+		return true
+	}
+	if it.done {
+		if it.failure != "" {
+			it.err = errors.New(it.failure)
+		}
+		it.keys, it.values, it.pos = nil, nil, 0
+		return false
+	}
+	res := <-it.pending
+	it.pending = nil
+	if res.err != nil {
+		it.err = res.err
+		it.keys, it.values, it.pos = nil, nil, 0
+		return false
+	}
+	it.keys, it.values, it.pos = res.chunk.Keys, res.chunk.Values, 0
+	it.done, it.failure = res.chunk.Done, res.chunk.Error
+	if !it.done {
+		it.fetch()
+	}
+	if len(it.keys) == 0 {
+		return it.Next()
+	}
+	it.pos = 1
+	return true
+}
+
+// fetch requests the next chunk of the iterator from the server in the background.
+func (it *iterator) fetch() {
+	pending := make(chan *iteratorFetch, 1)
+	go func() {
+		res := new(iteratorFetch)
+		res.err = it.db.call(&res.chunk, "iteratorNext", it.id, iteratorChunkItems)
+		pending <- res
+	}()
+	it.pending = pending
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *iterator) Key() []byte {
+	if it.pos == 0 || it.pos > len(it.keys) {
+		return nil
+	}
+	return it.keys[it.pos-1]
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *iterator) Value() []byte {
+	if it.pos == 0 || it.pos > len(it.values) {
+		return nil
+	}
+	return it.values[it.pos-1]
+}
+
+// Release releases the server side iterator.
+func (it *iterator) Release() {
+	if it.released {
+		return
+	}
+	it.released = true
+	it.keys, it.values, it.pos, it.pending = nil, nil, 0, nil
+	if it.id != 0 {
+		it.db.call(nil, "releaseIterator", it.id)
+	}
+}
+
+// snapshot is a snapshot of a remote database.
+type snapshot struct {
+	db *Client
+	id hexutil.Uint64
+}
+
+// Has retrieves if a key is present in the snapshot.
+func (snap *snapshot) Has(key []byte) (bool, error) {
+	var has bool
+	err := snap.db.call(&has, "snapshotHas", snap.id, hexutil.Bytes(key))
+	return has, err
+}
+
+// Get retrieves the given key if it's present in the snapshot.
+func (snap *snapshot) Get(key []byte) ([]byte, error) {
+	var value hexutil.Bytes
+	if err := snap.db.call(&value, "snapshotGet", snap.id, hexutil.Bytes(key)); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Release releases the server side snapshot.
+func (snap *snapshot) Release() {
+	snap.db.call(nil, "releaseSnapshot", snap.id)
+}
+
+// ancientWriteOp collects the items appended to the ancient store, to be sent to
+// the server in one go.
+type ancientWriteOp struct {
+	items []ancientOp
+}
+
+// Append adds an RLP-encoded item.
+func (op *ancientWriteOp) Append(kind string, number uint64, item interface{}) error {
+	blob, err := rlp.EncodeToBytes(item)
+	if err != nil {
+		return err
+	}
+	return op.AppendRaw(kind, number, blob)
+}
+
+// AppendRaw adds an item without RLP-encoding it.
+func (op *ancientWriteOp) AppendRaw(kind string, number uint64, item []byte) error {
+	op.items = append(op.items, ancientOp{Kind: kind, Number: hexutil.Uint64(number), Item: append([]byte{}, item...)})
+	return nil
+}
@@ -19,6 +19,11 @@
 // read-only database.
 // There really are no guarantees in this database, since the local gsdc does not
 // exclusive access, but it can be used for basic diagnostics of a remote node.
+//
+// The package also implements a dedicated database server exposing a local
+// database over Unix domain sockets or TCP, along with a client supporting the
+// full database interface, including batched writes, iterators, snapshots and
+// the ancient store. This permits several processes to share a single store.
 package remotedb
 
 import (
@@ -0,0 +1,270 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotedb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sdcereum/go-sdcereum/core/rawdb"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/sdcdb/dbtest"
+	"github.com/sdcereum/go-sdcereum/sdcdb/memorydb"
+)
+
+// closingClient closes the backing server along with the client.
+type closingClient struct {
+	*Client
+	srv *Server
+}
+
+func (c *closingClient) Close() error {
+	err := c.Client.Close()
+	c.srv.Close()
+	return err
+}
+
+func TestRemoteDB(t *testing.T) {
+	t.Run("DatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() sdcdb.KeyValueStore {
+			srv := NewServer(rawdb.NewDatabase(memorydb.New()), false)
+			return &closingClient{Client: srv.DialInProc(false), srv: srv}
+		})
+	})
+}
+
+func TestRemoteDBTransports(t *testing.T) {
+	endpoints := []string{"tcp://127.0.0.1:0"}
+	if runtime.GOOS != "windows" {
+		endpoints = append(endpoints, "unix://"+filepath.Join(t.TempDir(), "remotedb.sock"))
+	}
+	for _, endpoint := range endpoints {
+		srv := NewServer(rawdb.NewDatabase(memorydb.New()), false)
+		addr, err := srv.Listen(endpoint)
+		if err != nil {
+			t.Fatalf("%s: failed to listen: %v", endpoint, err)
+		}
+		dial := endpoint
+		if addr.Network() == "tcp" {
+			dial = "tcp://" + addr.String()
+		}
+		// Write through one client and read through another one
+		writer, err := Dial(dial, false)
+		if err != nil {
+			t.Fatalf("%s: failed to dial: %v", endpoint, err)
+		}
+		reader, err := Dial(dial, false)
+		if err != nil {
+			t.Fatalf("%s: failed to dial: %v", endpoint, err)
+		}
+		batch := writer.NewBatch()
+		for i := 0; i < 3*iteratorChunkItems; i++ {
+			batch.Put([]byte(fmt.Sprintf("key-%05d", i)), []byte{byte(i)})
+		}
+		if err := batch.Write(); err != nil {
+			t.Fatalf("%s: failed to write batch: %v", endpoint, err)
+		}
+		it := reader.NewIterator([]byte("key-"), nil)
+		var count int
+		for it.Next() {
+			if want := fmt.Sprintf("key-%05d", count); !bytes.Equal(it.Key(), []byte(want)) {
+				t.Fatalf("%s: key mismatch: have %s, want %s", endpoint, it.Key(), want)
+			}
+			count++
+		}
+		if err := it.Error(); err != nil {
+			t.Fatalf("%s: iteration failed: %v", endpoint, err)
+		}
+		it.Release()
+		if count != 3*iteratorChunkItems {
+			t.Fatalf("%s: item count mismatch: have %d, want %d", endpoint, count, 3*iteratorChunkItems)
+		}
+		writer.Close()
+		reader.Close()
+		srv.Close()
+	}
+}
+
+func TestRemoteDBIteratorRelease(t *testing.T) {
+	srv := NewServer(rawdb.NewDatabase(memorydb.New()), false)
+	defer srv.Close()
+	client := srv.DialInProc(false)
+	defer client.Close()
+
+	// Fill the database through a sized batch, reusing it after a reset
+	batch := client.NewBatchWithSize(16)
+	for i := 0; i < 2*iteratorChunkItems; i++ {
+		batch.Put([]byte(fmt.Sprintf("key-%05d", i)), []byte(fmt.Sprintf("value-%05d", i)))
+		if i == iteratorChunkItems-1 {
+			if err := batch.Write(); err != nil {
+				t.Fatalf("failed to write batch: %v", err)
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	// Release an iterator while the next chunk is being retrieved
+	it := client.NewIterator([]byte("key-"), nil)
+	for i := 0; i < 10; i++ {
+		if !it.Next() {
+			t.Fatalf("iterator exhausted at item %d: %v", i, it.Error())
+		}
+		if want := fmt.Sprintf("value-%05d", i); !bytes.Equal(it.Value(), []byte(want)) {
+			t.Fatalf("value mismatch: have %s, want %s", it.Value(), want)
+		}
+	}
+	it.Release()
+	if it.Next() {
+		t.Fatalf("released iterator not exhausted")
+	}
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if len(srv.iterators) != 0 {
+		t.Fatalf("server iterators not released: %d left", len(srv.iterators))
+	}
+}
+
+func TestRemoteDBPublicEndpoint(t *testing.T) {
+	srv := NewServer(rawdb.NewDatabase(memorydb.New()), false)
+	defer srv.Close()
+
+	for _, endpoint := range []string{"tcp://:0", "tcp://0.0.0.0:0", "tcp://[::]:0", "tcp://example.com:0"} {
+		if _, err := srv.Listen(endpoint); !errors.Is(err, errPublicEndpoint) {
+			t.Errorf("%s: error mismatch: have %v, want %v", endpoint, err, errPublicEndpoint)
+		}
+	}
+	for _, endpoint := range []string{"tcp://127.0.0.1:0", "tcp://localhost:0"} {
+		if _, err := srv.Listen(endpoint); err != nil {
+			t.Errorf("%s: failed to listen: %v", endpoint, err)
+		}
+	}
+}
+
+func TestRemoteDBReadOnly(t *testing.T) {
+	db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	db.Put([]byte("key"), []byte("value"))
+
+	readonlySrv := NewServer(db, true)
+	defer readonlySrv.Close()
+	writableSrv := NewServer(db, false)
+	defer writableSrv.Close()
+
+	tests := []struct {
+		name   string
+		client *Client
+	}{
+		{"read-only server", readonlySrv.DialInProc(false)},
+		{"read-only client", writableSrv.DialInProc(true)},
+	}
+	for _, test := range tests {
+		defer test.client.Close()
+
+		// Reads are still permitted
+		if value, err := test.client.Get([]byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+			t.Errorf("%s: value mismatch: have %q, want %q (err %v)", test.name, value, "value", err)
+		}
+		// Every write must be rejected
+		batch := test.client.NewBatch()
+		batch.Put([]byte("batch"), []byte("value"))
+
+		writes := map[string]func() error{
+			"put":          func() error { return test.client.Put([]byte("key"), []byte("other")) },
+			"delete":       func() error { return test.client.Delete([]byte("key")) },
+			"batch":        batch.Write,
+			"compact":      func() error { return test.client.Compact(nil, nil) },
+			"truncateHead": func() error { return test.client.TruncateHead(0) },
+			"truncateTail": func() error { return test.client.TruncateTail(0) },
+			"sync":         test.client.Sync,
+			"modifyAncients": func() error {
+				_, err := test.client.ModifyAncients(func(op sdcdb.AncientWriteOp) error {
+					return op.AppendRaw("hashes", 0, []byte{0})
+				})
+				return err
+			},
+		}
+		for op, write := range writes {
+			if err := write(); err == nil || err.Error() != errReadOnly.Error() {
+				t.Errorf("%s: %s error mismatch: have %v, want %v", test.name, op, err, errReadOnly)
+			}
+		}
+	}
+	if value, _ := db.Get([]byte("key")); !bytes.Equal(value, []byte("value")) {
+		t.Errorf("database modified: have %q, want %q", value, "value")
+	}
+	if has, _ := db.Has([]byte("batch")); has {
+		t.Errorf("batch written to database")
+	}
+	if n, _ := db.Ancients(); n != 0 {
+		t.Errorf("ancients appended to database: have %d items", n)
+	}
+}
+
+func TestRemoteAncients(t *testing.T) {
+	db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	srv := NewServer(db, false)
+	defer srv.Close()
+
+	client := srv.DialInProc(false)
+	defer client.Close()
+
+	// Append a few items to every table and read them back
+	tables := []string{"headers", "hashes", "bodies", "receipts", "diffs"}
+	_, err = client.ModifyAncients(func(op sdcdb.AncientWriteOp) error {
+		for i := uint64(0); i < 3; i++ {
+			for _, table := range tables {
+				if err := op.AppendRaw(table, i, []byte{byte(i)}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to append ancients: %v", err)
+	}
+	if n, err := client.Ancients(); err != nil || n != 3 {
+		t.Fatalf("ancient count mismatch: have %d, want %d (err %v)", n, 3, err)
+	}
+	if blob, err := client.Ancient("hashes", 1); err != nil || !bytes.Equal(blob, []byte{1}) {
+		t.Fatalf("ancient mismatch: have %x, want %x (err %v)", blob, []byte{1}, err)
+	}
+	items, err := client.AncientRange("hashes", 0, 3, 1024)
+	if err != nil || len(items) != 3 {
+		t.Fatalf("ancient range mismatch: have %d items, want %d (err %v)", len(items), 3, err)
+	}
+	if err := client.TruncateHead(1); err != nil {
+		t.Fatalf("failed to truncate ancients: %v", err)
+	}
+	if has, _ := client.HasAncient("hashes", 1); has {
+		t.Fatal("truncated ancient still present")
+	}
+}
@@ -0,0 +1,581 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotedb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/hexutil"
+	"github.com/sdcereum/go-sdcereum/sdcdb"
+	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/rpc"
+)
+
+const (
+	// namespace is the RPC namespace the database service is registered under.
+	namespace = "remotedb"
+
+	// handleExpiry is the time after which an iterator or snapshot which wasn't
+	// accessed by the remote side is released, protecting the server against
+	// clients disappearing without cleaning up.
+	handleExpiry = 5 * time.Minute
+
+	// maxChunkItems is the maximum number of items returned by a single iterator
+	// request.
+	maxChunkItems = 4096
+)
+
+var (
+	// errUnknownHandle is returned if an iterator or snapshot is requested which
+	// doesn't exist, or was already released.
+	errUnknownHandle = errors.New("unknown handle")
+
+	// errNotSupported is returned for operations which can't be performed on a
+	// remote database.
+	errNotSupported = errors.New("not supported")
+
+	// errReadOnly is returned for write operations if either the server or the
+	// client was opened in read-only mode.
+	errReadOnly = errors.New("remote database is read-only")
+
+	// errPublicEndpoint is returned if a TCP listener is requested on an address
+	// reachable from other hosts. Connections are not authenticated, so the server
+	// only listens on loopback addresses.
+	errPublicEndpoint = errors.New("remote database server must listen on a loopback address")
+)
+
+// batchOp is a single key-value write operation of a batch.
+type batchOp struct {
+	Key    hexutil.Bytes `json:"key"`
+	Value  hexutil.Bytes `json:"value,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// ancientOp is a single item appended to the ancient store.
+type ancientOp struct {
+	Kind   string         `json:"kind"`
+	Number hexutil.Uint64 `json:"number"`
+	Item   hexutil.Bytes  `json:"item"`
+}
+
+// iteratorChunk is a batch of key-value pairs retrieved from a remote iterator.
+type iteratorChunk struct {
+	Keys   []hexutil.Bytes `json:"keys"`
+	Values []hexutil.Bytes `json:"values"`
+	Done   bool            `json:"done"`            // Whsdcer the iterator is exhausted
+	Error  string          `json:"error,omitempty"` // Error encountered by the iterator, if any
+}
+
+// serverIterator is an iterator opened on behalf of a remote client.
+type serverIterator struct {
+	it   sdcdb.Iterator
+	used time.Time
+	lock sync.Mutex
+}
+
+// serverSnapshot is a snapshot opened on behalf of a remote client.
+type serverSnapshot struct {
+	snap sdcdb.Snapshot
+	used time.Time
+}
+
+// Server exposes a local database to remote clients. Any number of clients may
+// read and, unless the server is read-only, write the database concurrently.
+// Batches are applied atomically.
+//
+// The server speaks JSON-RPC over stream connections, which may be Unix domain
+// sockets, TCP connections or in-process pipes. Connections are not authenticated,
+// so TCP listeners are restricted to loopback addresses.
+type Server struct {
+	db       sdcdb.Database
+	readonly bool // Whsdcer all write operations are rejected
+	rpc      *rpc.Server
+
+	iterators map[uint64]*serverIterator
+	snapshots map[uint64]*serverSnapshot
+	nextID    uint64
+	lock      sync.Mutex
+
+	listeners []net.Listener
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a database server backed by the given database. The server
+// doesn't take ownership of the database, it needs to be closed separately after
+// the server is closed. A read-only server rejects all write operations.
+func NewServer(db sdcdb.Database, readonly bool) *Server {
+	srv := &Server{
+		db:        db,
+		readonly:  readonly,
+		rpc:       rpc.NewServer(),
+		iterators: make(map[uint64]*serverIterator),
+		snapshots: make(map[uint64]*serverSnapshot),
+		closeCh:   make(chan struct{}),
+	}
+	if err := srv.rpc.RegisterName(namespace, &serverAPI{srv}); err != nil {
+		panic(err) // The API is static, can't fail
+	}
+	srv.wg.Add(1)
+	go srv.expireLoop()
+	return srv
+}
+
+// Listen opens a listener on the given endpoint and serves remote clients on it
+// in the background until the server is closed. The endpoint is either a TCP
+// address on a loopback interface prefixed with tcp://, or the path of a Unix
+// domain socket optionally prefixed with unix://.
+func (s *Server) Listen(endpoint string) (net.Addr, error) {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("%w: %s", errPublicEndpoint, address)
+		}
+	}
+	if network == "unix" {
+		// Clean up any stale socket left behind by a previous run
+		os.Remove(address)
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	s.listeners = append(s.listeners, listener)
+	s.lock.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.Serve(listener)
+	}()
+	log.Info("Remote database server started", "endpoint", listener.Addr())
+	return listener.Addr(), nil
+}
+
+// Serve accepts connections on the listener and serves remote clients on them.
+// It blocks until the listener is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.rpc.ServeListener(listener)
+}
+
+// DialInProc creates a client connected to the server in-process, without any
+// network or socket in between.
+func (s *Server) DialInProc(readonly bool) *Client {
+	return newClient(rpc.DialInProc(s.rpc), nil, readonly)
+}
+
+// Close stops the server, closing all listeners and releasing all iterators and
+// snapshots held on behalf of remote clients.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+
+		s.lock.Lock()
+		for _, listener := range s.listeners {
+			listener.Close()
+		}
+		s.lock.Unlock()
+
+		s.rpc.Stop()
+		s.wg.Wait()
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		for id, it := range s.iterators {
+			it.lock.Lock()
+			it.it.Release()
+			it.lock.Unlock()
+			delete(s.iterators, id)
+		}
+		for id, snap := range s.snapshots {
+			snap.snap.Release()
+			delete(s.snapshots, id)
+		}
+	})
+}
+
+// expireLoop periodically releases the iterators and snapshots which weren't
+// used by the remote side for a while.
+func (s *Server) expireLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(handleExpiry / 5)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.expire(time.Now().Add(-handleExpiry))
+		case <-s.closeCh:
+			return
+		}
+	}
+}
+
+// expire releases all the iterators and snapshots not used since the deadline.
+func (s *Server) expire(deadline time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for id, it := range s.iterators {
+		if it.used.Before(deadline) {
+			delete(s.iterators, id)
+			go func(it *serverIterator) {
+				it.lock.Lock()
+				defer it.lock.Unlock()
+				it.it.Release()
+			}(it)
+			log.Debug("Released idle remote iterator", "id", id)
+		}
+	}
+	for id, snap := range s.snapshots {
+		if snap.used.Before(deadline) {
+			delete(s.snapshots, id)
+			snap.snap.Release()
+			log.Debug("Released idle remote snapshot", "id", id)
+		}
+	}
+}
+
+// serverAPI is the RPC service exposing the database of the server. It's kept
+// separate from the server to avoid exporting the server lifecycle over RPC.
+type serverAPI struct {
+	srv *Server
+}
+
+// writable returns an error if the server rejects write operations.
+func (api *serverAPI) writable() error {
+	if api.srv.readonly {
+		return errReadOnly
+	}
+	return nil
+}
+
+// Has retrieves if a key is present in the database.
+func (api *serverAPI) Has(key hexutil.Bytes) (bool, error) {
+	return api.srv.db.Has(key)
+}
+
+// Get retrieves the given key if it's present in the database.
+func (api *serverAPI) Get(key hexutil.Bytes) (hexutil.Bytes, error) {
+	return api.srv.db.Get(key)
+}
+
+// Put inserts the given value into the database.
+func (api *serverAPI) Put(key hexutil.Bytes, value hexutil.Bytes) error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	return api.srv.db.Put(key, value)
+}
+
+// Delete removes the key from the database.
+func (api *serverAPI) Delete(key hexutil.Bytes) error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	return api.srv.db.Delete(key)
+}
+
+// Write applies a batch of write operations atomically.
+func (api *serverAPI) Write(ops []batchOp) error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	batch := api.srv.db.NewBatch()
+	for _, op := range ops {
+		var err error
+		if op.Delete {
+			err = batch.Delete(op.Key)
+		} else {
+			err = batch.Put(op.Key, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// NewIterator creates an iterator over a subset of the database content and
+// returns its handle.
+func (api *serverAPI) NewIterator(prefix hexutil.Bytes, start hexutil.Bytes) hexutil.Uint64 {
+	srv := api.srv
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.nextID++
+	srv.iterators[srv.nextID] = &serverIterator{
+		it:   srv.db.NewIterator(prefix, start),
+		used: time.Now(),
+	}
+	return hexutil.Uint64(srv.nextID)
+}
+
+// IteratorNext retrieves the next batch of key-value pairs from the iterator,
+// limited by the given number of items and the ideal batch size.
+func (api *serverAPI) IteratorNext(id hexutil.Uint64, limit int) (*iteratorChunk, error) {
+	srv := api.srv
+
+	srv.lock.Lock()
+	it := srv.iterators[uint64(id)]
+	if it != nil {
+		it.used = time.Now()
+	}
+	srv.lock.Unlock()
+
+	if it == nil {
+		return nil, errUnknownHandle
+	}
+	if limit <= 0 || limit > maxChunkItems {
+		limit = maxChunkItems
+	}
+	it.lock.Lock()
+	defer it.lock.Unlock()
+
+	var (
+		chunk = new(iteratorChunk)
+		size  int
+	)
+	for len(chunk.Keys) < limit && size < sdcdb.IdealBatchSize {
+		if !it.it.Next() {
+			chunk.Done = true
+			if err := it.it.Error(); err != nil {
+				chunk.Error = err.Error()
+			}
+			break
+		}
+		key, value := it.it.Key(), it.it.Value()
+		chunk.Keys = append(chunk.Keys, append([]byte{}, key...))
+		chunk.Values = append(chunk.Values, append([]byte{}, value...))
+		size += len(key) + len(value)
+	}
+	return chunk, nil
+}
+
+// ReleaseIterator releases the iterator with the given handle.
+func (api *serverAPI) ReleaseIterator(id hexutil.Uint64) {
+	srv := api.srv
+
+	srv.lock.Lock()
+	it := srv.iterators[uint64(id)]
+	delete(srv.iterators, uint64(id))
+	srv.lock.Unlock()
+
+	if it != nil {
+		it.lock.Lock()
+		defer it.lock.Unlock()
+		it.it.Release()
+	}
+}
+
+// NewSnapshot creates a snapshot of the current database state and returns its
+// handle.
+func (api *serverAPI) NewSnapshot() (hexutil.Uint64, error) {
+	snap, err := api.srv.db.NewSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	srv := api.srv
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.nextID++
+	srv.snapshots[srv.nextID] = &serverSnapshot{snap: snap, used: time.Now()}
+	return hexutil.Uint64(srv.nextID), nil
+}
+
+// snapshot retrieves the snapshot with the given handle, refreshing its expiry.
+func (api *serverAPI) snapshot(id hexutil.Uint64) (sdcdb.Snapshot, error) {
+	srv := api.srv
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	snap := srv.snapshots[uint64(id)]
+	if snap == nil {
+		return nil, errUnknownHandle
+	}
+	snap.used = time.Now()
+	return snap.snap, nil
+}
+
+// SnapshotHas retrieves if a key is present in the snapshot.
+func (api *serverAPI) SnapshotHas(id hexutil.Uint64, key hexutil.Bytes) (bool, error) {
+	snap, err := api.snapshot(id)
+	if err != nil {
+		return false, err
+	}
+	return snap.Has(key)
+}
+
+// SnapshotGet retrieves the given key if it's present in the snapshot.
+func (api *serverAPI) SnapshotGet(id hexutil.Uint64, key hexutil.Bytes) (hexutil.Bytes, error) {
+	snap, err := api.snapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Get(key)
+}
+
+// ReleaseSnapshot releases the snapshot with the given handle.
+func (api *serverAPI) ReleaseSnapshot(id hexutil.Uint64) {
+	srv := api.srv
+
+	srv.lock.Lock()
+	snap := srv.snapshots[uint64(id)]
+	delete(srv.snapshots, uint64(id))
+	srv.lock.Unlock()
+
+	if snap != nil {
+		snap.snap.Release()
+	}
+}
+
+// Stat returns a particular internal stat of the database.
+func (api *serverAPI) Stat(property string) (string, error) {
+	return api.srv.db.Stat(property)
+}
+
+// Compact flattens the underlying data store for the given key range.
+func (api *serverAPI) Compact(start hexutil.Bytes, limit hexutil.Bytes) error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	return api.srv.db.Compact(start, limit)
+}
+
+// HasAncient returns an indicator whsdcer the specified data exists in the
+// ancient store.
+func (api *serverAPI) HasAncient(kind string, number hexutil.Uint64) (bool, error) {
+	return api.srv.db.HasAncient(kind, uint64(number))
+}
+
+// Ancient retrieves an ancient binary blob from the ancient store.
+func (api *serverAPI) Ancient(kind string, number hexutil.Uint64) (hexutil.Bytes, error) {
+	return api.srv.db.Ancient(kind, uint64(number))
+}
+
+// AncientRange retrieves multiple items in sequence from the ancient store.
+func (api *serverAPI) AncientRange(kind string, start, count, maxBytes hexutil.Uint64) ([]hexutil.Bytes, error) {
+	items, err := api.srv.db.AncientRange(kind, uint64(start), uint64(count), uint64(maxBytes))
+	if err != nil {
+		return nil, err
+	}
+	blobs := make([]hexutil.Bytes, len(items))
+	for i, item := range items {
+		blobs[i] = item
+	}
+	return blobs, nil
+}
+
+// Ancients returns the ancient item numbers in the ancient store.
+func (api *serverAPI) Ancients() (hexutil.Uint64, error) {
+	n, err := api.srv.db.Ancients()
+	return hexutil.Uint64(n), err
+}
+
+// Tail returns the number of first stored item in the ancient store.
+func (api *serverAPI) Tail() (hexutil.Uint64, error) {
+	n, err := api.srv.db.Tail()
+	return hexutil.Uint64(n), err
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (api *serverAPI) AncientSize(kind string) (hexutil.Uint64, error) {
+	n, err := api.srv.db.AncientSize(kind)
+	return hexutil.Uint64(n), err
+}
+
+// ModifyAncients appends a batch of items to the ancient store atomically and
+// returns the total size of the written data.
+func (api *serverAPI) ModifyAncients(ops []ancientOp) (int64, error) {
+	if err := api.writable(); err != nil {
+		return 0, err
+	}
+	return api.srv.db.ModifyAncients(func(op sdcdb.AncientWriteOp) error {
+		for _, item := range ops {
+			if err := op.AppendRaw(item.Kind, uint64(item.Number), item.Item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TruncateHead discards all but the first n ancient data from the ancient store.
+func (api *serverAPI) TruncateHead(n hexutil.Uint64) error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	return api.srv.db.TruncateHead(uint64(n))
+}
+
+// TruncateTail discards the first n ancient data from the ancient store.
+func (api *serverAPI) TruncateTail(n hexutil.Uint64) error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	return api.srv.db.TruncateTail(uint64(n))
+}
+
+// Sync flushes all in-memory ancient store data to disk.
+func (api *serverAPI) Sync() error {
+	if err := api.writable(); err != nil {
+		return err
+	}
+	return api.srv.db.Sync()
+}
+
+// AncientDatadir returns the path of root ancient directory.
+func (api *serverAPI) AncientDatadir() (string, error) {
+	return api.srv.db.AncientDatadir()
+}
+
+// parseEndpoint splits a database endpoint into the network type and address.
+func parseEndpoint(endpoint string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(endpoint, "tcp://"):
+		return "tcp", strings.TrimPrefix(endpoint, "tcp://"), nil
+	case strings.HasPrefix(endpoint, "unix://"):
+		return "unix", strings.TrimPrefix(endpoint, "unix://"), nil
+	case strings.Contains(endpoint, "://"):
+		return "", "", fmt.Errorf("unsupported endpoint %q", endpoint)
+	case endpoint == "":
+		return "", "", errors.New("empty endpoint")
+	default:
+		return "unix", endpoint, nil
+	}
+}
@@ -141,6 +141,21 @@ web3._extend({
 			call: 'admin_removeTrustedPeer',
 			params: 1
 		}),
+		new web3._extend.Msdcod({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Msdcod({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
+		new web3._extend.Msdcod({
+			name: 'listBans',
+			call: 'admin_listBans'
+		}),
 		new web3._extend.Msdcod({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -152,6 +167,14 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Msdcod({
+			name: 'pruneState',
+			call: 'admin_pruneState'
+		}),
+		new web3._extend.Msdcod({
+			name: 'pruneStatus',
+			call: 'admin_pruneStatus'
+		}),
 		new web3._extend.Msdcod({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -475,6 +498,18 @@ web3._extend({
 			params: 2,
 			inputFormatter:[web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter],
 		}),
+		new web3._extend.Msdcod({
+			name: 'stateStats',
+			call: 'debug_stateStats',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null],
+		}),
+		new web3._extend.Msdcod({
+			name: 'stateGrowth',
+			call: 'debug_stateGrowth',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null],
+		}),
 		new web3._extend.Msdcod({
 			name: 'dbGet',
 			call: 'debug_dbGet',
@@ -18,8 +18,11 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/sdcereum/go-sdcereum/common/hexutil"
 	"github.com/sdcereum/go-sdcereum/crypto"
@@ -116,6 +119,92 @@ func (api *adminAPI) RemoveTrustedPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// defaultBanDuration is the duration of bans created without explicit duration.
+const defaultBanDuration = 24 * time.Hour
+
+// BanPeer bans a node or a range of IP addresses for the given number of seconds,
+// or a day if no duration is given. The target is either an enode URL, a node ID,
+// an IP address or an IP network in CIDR notation. Matching peers are disconnected,
+// unless they are trusted.
+func (api *adminAPI) BanPeer(target string, seconds *uint64) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	duration := defaultBanDuration
+	if seconds != nil {
+		if *seconds == 0 {
+			return false, errors.New("ban duration must be positive")
+		}
+		duration = time.Duration(*seconds) * time.Second
+	}
+	id, network, err := parseBanTarget(target)
+	if err != nil {
+		return false, err
+	}
+	until := time.Now().Add(duration)
+	if network != nil {
+		err = server.BanNetwork(network, until)
+	} else {
+		err = server.BanNode(id, until)
+	}
+	return err == nil, err
+}
+
+// UnbanPeer lifts the ban of a node or a range of IP addresses. Networks have to be
+// given exactly as they were banned.
+func (api *adminAPI) UnbanPeer(target string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, network, err := parseBanTarget(target)
+	if err != nil {
+		return false, err
+	}
+	if network != nil {
+		err = server.UnbanNetwork(network)
+	} else {
+		err = server.UnbanNode(id)
+	}
+	return err == nil, err
+}
+
+// ListBans retrieves all active node and network bans.
+func (api *adminAPI) ListBans() ([]p2p.Ban, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Bans(), nil
+}
+
+// parseBanTarget parses the target of a ban, which is either a node or an IP network.
+// Single IP addresses are treated as networks containing just that address.
+func parseBanTarget(target string) (enode.ID, *net.IPNet, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return enode.ID{}, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	if _, network, err := net.ParseCIDR(target); err == nil {
+		return enode.ID{}, network, nil
+	}
+	if id, err := enode.ParseID(target); err == nil {
+		return id, nil, nil
+	}
+	node, err := enode.Parse(enode.ValidSchemes, target)
+	if err != nil {
+		return enode.ID{}, nil, fmt.Errorf("invalid ban target %q: not a node or IP network", target)
+	}
+	return node.ID(), nil, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
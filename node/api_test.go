@@ -25,6 +25,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/sdcereum/go-sdcereum/p2p"
 	"github.com/sdcereum/go-sdcereum/rpc"
 	"github.com/stretchr/testify/assert"
 )
@@ -296,6 +297,46 @@ func TestStartRPC(t *testing.T) {
 	}
 }
 
+// This test checks the admin_banPeer, admin_unbanPeer and admin_listBans APIs.
+func TestAdminBans(t *testing.T) {
+	stack, err := New(&Config{P2P: p2p.Config{NoDiscovery: true}})
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	defer stack.Close()
+	if err := stack.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	api := &adminAPI{stack}
+
+	id := "a448f24c6d18e575453db13171562b71999873db5b286df957af199ec94617f7"
+	for _, target := range []string{id, "10.1.2.3", "2001:db8::/32"} {
+		if ok, err := api.BanPeer(target, nil); !ok || err != nil {
+			t.Fatalf("can't ban %s: %v", target, err)
+		}
+	}
+	if _, err := api.BanPeer("not a node", nil); err == nil {
+		t.Fatal("invalid ban target accepted")
+	}
+	var have []string
+	bans, _ := api.ListBans()
+	for _, ban := range bans {
+		have = append(have, ban.ID+ban.Network)
+	}
+	want := []string{id, "10.1.2.3/32", "2001:db8::/32"}
+	assert.Equal(t, want, have)
+
+	if ok, err := api.UnbanPeer("10.1.2.3/32"); !ok || err != nil {
+		t.Fatal("can't unban network:", err)
+	}
+	if ok, err := api.UnbanPeer(id); !ok || err != nil {
+		t.Fatal("can't unban node:", err)
+	}
+	if bans, _ := api.ListBans(); len(bans) != 1 || bans[0].Network != "2001:db8::/32" {
+		t.Fatalf("wrong bans after unban: %+v", bans)
+	}
+}
+
 // checkReachable checks if the TCP endpoint in rawurl is open.
 func checkReachable(rawurl string) bool {
 	u, err := url.Parse(rawurl)
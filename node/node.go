@@ -27,6 +27,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sdcereum/go-sdcereum/accounts"
 	"github.com/sdcereum/go-sdcereum/common"
@@ -732,6 +733,34 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, ancient
 	return db, err
 }
 
+// OpenSecondaryDatabase opens the database with the given name and its chain
+// freezer from within the instance directory of another node using the given
+// data directory, as a read-only secondary following the writes of that node.
+// The refresh interval defines how often the changes of the owner are picked up.
+func (n *Node) OpenSecondaryDatabase(datadir string, name string, cache, handles int, ancient string, namespace string, refresh time.Duration) (sdcdb.Database, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.state == closedState {
+		return nil, ErrNodeStopped
+	}
+	if datadir == "" {
+		return nil, errors.New("secondary database requires the data directory of the primary")
+	}
+	primary := &Config{Name: n.config.Name, DataDir: datadir}
+
+	switch {
+	case ancient == "":
+		ancient = filepath.Join(primary.ResolvePath(name), "ancient")
+	case !filepath.IsAbs(ancient):
+		ancient = primary.ResolvePath(ancient)
+	}
+	db, err := rawdb.NewLevelDBSecondaryDatabase(primary.ResolvePath(name), cache, handles, ancient, namespace, refresh)
+	if err != nil {
+		return nil, err
+	}
+	return n.wrapDatabase(db), nil
+}
+
 // ResolvePath returns the absolute path of a resource in the instance directory.
 func (n *Node) ResolvePath(x string) string {
 	return n.config.ResolvePath(x)
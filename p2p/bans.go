@@ -0,0 +1,162 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+)
+
+// Ban is an entry of the ban list, covering either a single node or a range of
+// IP addresses.
+type Ban struct {
+	ID      string    `json:"id,omitempty"`      // Banned node identifier
+	Network string    `json:"network,omitempty"` // Banned IP network in CIDR notation
+	Expiry  time.Time `json:"expiry"`            // Time at which the ban is lifted
+}
+
+// banList keeps the nodes and IP networks which are not allowed to connect. All
+// bans are persisted in the node database. Network bans are also cached in memory
+// because every connection attempt is matched against all of them.
+type banList struct {
+	db    *enode.DB
+	clock mclock.Clock
+	start time.Time      // Wall clock time at creation
+	base  mclock.AbsTime // Clock reading at creation
+
+	mu   sync.RWMutex
+	nets map[string]netBan
+}
+
+type netBan struct {
+	network *net.IPNet
+	until   time.Time
+}
+
+func newBanList(db *enode.DB, clock mclock.Clock) *banList {
+	b := &banList{
+		db:    db,
+		clock: clock,
+		start: time.Now(),
+		base:  clock.Now(),
+		nets:  make(map[string]netBan),
+	}
+	for cidr, until := range db.NetBans(b.now()) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Warn("Ignoring invalid network ban", "network", cidr, "err", err)
+			continue
+		}
+		b.nets[network.String()] = netBan{network, until}
+	}
+	return b
+}
+
+// now returns the current time according to the clock of the ban list. The ban
+// expiry times are persisted as wall clock times, so the clock readings are taken
+// relative to the wall clock time at creation.
+func (b *banList) now() time.Time {
+	return b.start.Add(time.Duration(b.clock.Now() - b.base))
+}
+
+// banNode bans a node until the given time.
+func (b *banList) banNode(id enode.ID, until time.Time) error {
+	return b.db.UpdateBan(id, until)
+}
+
+// unbanNode lifts the ban of a node.
+func (b *banList) unbanNode(id enode.ID) error {
+	return b.db.UpdateBan(id, time.Time{})
+}
+
+// banNetwork bans all IP addresses of a network until the given time.
+func (b *banList) banNetwork(network *net.IPNet, until time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.db.UpdateNetBan(network, until); err != nil {
+		return err
+	}
+	b.nets[network.String()] = netBan{network, until}
+	return nil
+}
+
+// unbanNetwork lifts the ban of an IP network.
+func (b *banList) unbanNetwork(network *net.IPNet) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.db.UpdateNetBan(network, time.Time{}); err != nil {
+		return err
+	}
+	delete(b.nets, network.String())
+	return nil
+}
+
+// nodeBanned reports whsdcer a node is currently banned.
+func (b *banList) nodeBanned(id enode.ID) bool {
+	return b.now().Before(b.db.BanExpiry(id))
+}
+
+// ipBanned reports whsdcer an IP address is within a currently banned network.
+func (b *banList) ipBanned(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := b.now()
+	for _, ban := range b.nets {
+		if now.Before(ban.until) && ban.network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// banned reports whsdcer a node is banned by identity or by any of its addresses.
+func (b *banList) banned(n *enode.Node) bool {
+	return b.nodeBanned(n.ID()) || b.ipBanned(n.IPv4()) || b.ipBanned(n.IPv6())
+}
+
+// list returns all active bans, node bans first.
+func (b *banList) list() []Ban {
+	now := b.now()
+
+	var nodes, nets []Ban
+	for id, until := range b.db.Bans(now) {
+		nodes = append(nodes, Ban{ID: id.String(), Expiry: until})
+	}
+	b.mu.RLock()
+	for cidr, ban := range b.nets {
+		if now.Before(ban.until) {
+			nets = append(nets, Ban{Network: cidr, Expiry: ban.until})
+		}
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	sort.Slice(nets, func(i, j int) bool { return nets[i].Network < nets[j].Network })
+	return append(nodes, nets...)
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/internal/testlog"
+	"github.com/sdcereum/go-sdcereum/log"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/p2p/enr"
+)
+
+func TestBanList(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		bans  = newBanList(db, mclock.System{})
+		until = time.Now().Add(time.Hour).Truncate(time.Second)
+		id    = enode.ID{1}
+	)
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	bans.banNetwork(network, until)
+	bans.banNode(id, until)
+
+	// Bans are loaded from the database.
+	bans = newBanList(db, mclock.System{})
+	if !bans.ipBanned(net.IP{10, 1, 2, 3}) {
+		t.Error("IP within banned network not banned")
+	}
+	if bans.ipBanned(net.IP{11, 1, 2, 3}) {
+		t.Error("IP outside of banned network banned")
+	}
+	var r enr.Record
+	r.Set(enr.IPv4{10, 0, 0, 1})
+	if !bans.banned(enode.SignNull(&r, enode.ID{2})) {
+		t.Error("node within banned network not banned")
+	}
+	if !bans.banned(enode.SignNull(new(enr.Record), id)) {
+		t.Error("banned node not banned")
+	}
+	want := []Ban{{ID: id.String(), Expiry: until}, {Network: "10.0.0.0/8", Expiry: until}}
+	if have := bans.list(); len(have) != 2 || have[0] != want[0] || have[1] != want[1] {
+		t.Errorf("wrong ban list: %+v", have)
+	}
+
+	// Lifted bans are removed from the database as well.
+	bans.unbanNetwork(network)
+	bans.unbanNode(id)
+	bans = newBanList(db, mclock.System{})
+	if bans.ipBanned(net.IP{10, 1, 2, 3}) || bans.nodeBanned(id) {
+		t.Error("lifted ban still active")
+	}
+}
+
+// This test checks that banning a connected node disconnects it, and that it
+// can't connect again until the ban is lifted.
+func TestServerBanNode(t *testing.T) {
+	remoteKey := newkey()
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    10,
+			NoDial:      true,
+			NoDiscovery: true,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remoteKey.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+	id := randomID()
+	if err := srv.checkpoint(newconn(id), srv.checkpointAddPeer); err != nil {
+		t.Fatal("could not add conn:", err)
+	}
+
+	events := make(chan *PeerEvent, 10)
+	sub := srv.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+	if err := srv.BanNode(id, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal("can't ban node:", err)
+	}
+	timeout := time.After(2 * time.Second)
+	for dropped := false; !dropped; {
+		select {
+		case ev := <-events:
+			dropped = ev.Type == PeerEventTypeDrop && ev.Peer == id
+		case <-timeout:
+			t.Fatal("banned peer not disconnected")
+		}
+	}
+	if err := srv.checkpoint(newconn(id), srv.checkpointPostHandshake); err != DiscUselessPeer {
+		t.Fatal("wrong error for banned node:", err)
+	}
+	if err := srv.UnbanNode(id); err != nil {
+		t.Fatal("can't unban node:", err)
+	}
+	if err := srv.checkpoint(newconn(id), srv.checkpointPostHandshake); err != nil {
+		t.Fatal("unbanned node rejected:", err)
+	}
+}
@@ -64,13 +64,36 @@ type tcpDialer struct {
 }
 
 func (t tcpDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
-	return t.d.DialContext(ctx, "tcp", nodeAddr(dest).String())
+	// Dual-stack nodes are dialed on IPv6 if connecting on IPv4 fails.
+	var (
+		fd  net.Conn
+		err error
+	)
+	for _, addr := range nodeAddrs(dest) {
+		if fd, err = t.d.DialContext(ctx, "tcp", addr.String()); err == nil {
+			return fd, nil
+		}
+	}
+	return nil, err
 }
 
-func nodeAddr(n *enode.Node) net.Addr {
+// nodeAddr returns the TCP endpoint of a node. This prefers IPv4 addresses.
+func nodeAddr(n *enode.Node) *net.TCPAddr {
+	if n.IPv4() == nil && n.IPv6() != nil {
+		return &net.TCPAddr{IP: n.IPv6(), Port: n.TCP6()}
+	}
 	return &net.TCPAddr{IP: n.IP(), Port: n.TCP()}
 }
 
+// nodeAddrs returns all TCP endpoints of a node, in the order they are dialed.
+func nodeAddrs(n *enode.Node) []*net.TCPAddr {
+	addrs := []*net.TCPAddr{nodeAddr(n)}
+	if n.IPv4() != nil && n.IPv6() != nil {
+		addrs = append(addrs, &net.TCPAddr{IP: n.IPv6(), Port: n.TCP6()})
+	}
+	return addrs
+}
+
 // checkDial errors:
 var (
 	errSelf             = errors.New("is self")
@@ -79,6 +102,8 @@ var (
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
 	errNoPort           = errors.New("node does not provide TCP port")
+	errBanned           = errors.New("banned")
+	errLowScore         = errors.New("low reputation")
 )
 
 // dialer creates outbound connections and submits them into Server.
@@ -138,6 +163,8 @@ type dialConfig struct {
 	log            log.Logger
 	clock          mclock.Clock
 	rand           *mrand.Rand
+	rep            *reputation // node scores, disabled if nil
+	bans           *banList    // banned nodes and networks, disabled if nil
 }
 
 func (cfg dialConfig) withDefaults() dialConfig {
@@ -241,7 +268,11 @@ loop:
 
 		select {
 		case node := <-nodesCh:
-			if err := d.checkDial(node); err != nil {
+			err := d.checkDial(node)
+			if err == nil {
+				err = d.checkReputation(node)
+			}
+			if err != nil {
 				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IP(), "reason", err)
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
@@ -282,7 +313,7 @@ loop:
 			}
 			task := newDialTask(node, staticDialedConn)
 			d.static[id] = task
-			if d.checkDial(node) == nil {
+			if d.checkStatic(task) == nil {
 				d.addToStaticPool(task)
 			}
 
@@ -388,7 +419,7 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if n.ID() == d.self {
 		return errSelf
 	}
-	if n.IP() != nil && n.TCP() == 0 {
+	if n.IP() != nil && nodeAddr(n).Port == 0 {
 		// This check can trigger if a non-TCP node is found
 		// by discovery. If there is no IP, the node is a static
 		// node and the actual endpoint will be resolved later in dialTask.
@@ -406,13 +437,35 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
+	if d.bans != nil && d.bans.banned(n) {
+		return errBanned
+	}
 	return nil
 }
 
+// checkReputation returns an error if a discovered node shouldn't be dialed
+// because of its past behaviour. Static nodes are exempt from this check.
+func (d *dialScheduler) checkReputation(n *enode.Node) error {
+	if d.rep != nil && d.rep.score(n.ID()) < dialScore {
+		return errLowScore
+	}
+	return nil
+}
+
+// checkStatic returns an error if static dial task shouldn't be started. As bans
+// end without notice, banned static nodes are rechecked after a while.
+func (d *dialScheduler) checkStatic(task *dialTask) error {
+	err := d.checkDial(task.dest)
+	if err == errBanned {
+		d.history.add(string(task.dest.ID().Bytes()), d.clock.Now().Add(dialHistoryExpiration))
+	}
+	return err
+}
+
 // startStaticDials starts n static dial tasks.
 func (d *dialScheduler) startStaticDials(n int) (started int) {
 	for started = 0; started < n && len(d.staticPool) > 0; started++ {
-		idx := d.rand.Intn(len(d.staticPool))
+		idx := d.pickStatic()
 		task := d.staticPool[idx]
 		d.startDial(task)
 		d.removeFromStaticPool(idx)
@@ -420,10 +473,26 @@ func (d *dialScheduler) startStaticDials(n int) (started int) {
 	return started
 }
 
+// pickStatic selects the static dial task to start next. Nodes with a better
+// reputation are preferred, ties are broken randomly.
+func (d *dialScheduler) pickStatic() int {
+	idx := d.rand.Intn(len(d.staticPool))
+	if d.rep == nil {
+		return idx
+	}
+	best := d.rep.score(d.staticPool[idx].dest.ID())
+	for i, task := range d.staticPool {
+		if score := d.rep.score(task.dest.ID()); score > best {
+			idx, best = i, score
+		}
+	}
+	return idx
+}
+
 // updateStaticPool attempts to move the given static dial back into staticPool.
 func (d *dialScheduler) updateStaticPool(id enode.ID) {
 	task, ok := d.static[id]
-	if ok && task.staticPoolIndex < 0 && d.checkDial(task.dest) == nil {
+	if ok && task.staticPoolIndex < 0 && d.checkStatic(task) == nil {
 		d.addToStaticPool(task)
 	}
 }
@@ -31,6 +31,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/internal/testlog"
 	"github.com/sdcereum/go-sdcereum/log"
 	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/p2p/enr"
 	"github.com/sdcereum/go-sdcereum/p2p/netutil"
 )
 
@@ -670,3 +671,40 @@ func (t *dialTestResolver) Resolve(n *enode.Node) *enode.Node {
 	t.calls = append(t.calls, n.ID())
 	return t.answers[n.ID()]
 }
+
+// This test checks that dual-stack nodes are dialed on IPv6 if IPv4 fails.
+func TestTCPDialerDualStack(t *testing.T) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 not available:", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	// Find a closed IPv4 port.
+	closed, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	var r enr.Record
+	r.Set(enr.IPv4{127, 0, 0, 1})
+	r.Set(enr.TCP(closed.Addr().(*net.TCPAddr).Port))
+	r.Set(enr.IPv6(net.IPv6loopback))
+	r.Set(enr.TCP6(listener.Addr().(*net.TCPAddr).Port))
+	node := enode.SignNull(&r, enode.ID{1})
+
+	dialer := tcpDialer{&net.Dialer{Timeout: time.Second}}
+	conn, err := dialer.Dial(context.Background(), node)
+	if err != nil {
+		t.Fatal("dial failed:", err)
+	}
+	defer conn.Close()
+	if addr := conn.RemoteAddr().(*net.TCPAddr); !addr.IP.Equal(net.IPv6loopback) {
+		t.Fatalf("dialed wrong address %v", addr)
+	}
+}
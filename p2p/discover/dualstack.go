@@ -0,0 +1,202 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/p2p/netutil"
+)
+
+var errNoSocket = errors.New("no socket for address family")
+
+// DualStackConn combines an IPv4 and an IPv6 socket into a single UDPConn. Packets
+// are sent on the socket matching the address family of the destination, and are
+// received from both sockets. Either of the sockets may be nil, in which case the
+// respective address family is unreachable.
+type DualStackConn struct {
+	conn4, conn6 UDPConn
+
+	readCh    chan dualStackPacket
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// dualStackPacket is a packet or error read from one of the sockets.
+type dualStackPacket struct {
+	data []byte
+	addr *net.UDPAddr
+	err  error
+}
+
+// NewDualStackConn creates a connection on the given IPv4 and IPv6 sockets.
+func NewDualStackConn(conn4, conn6 UDPConn) *DualStackConn {
+	c := &DualStackConn{
+		conn4:   conn4,
+		conn6:   conn6,
+		readCh:  make(chan dualStackPacket),
+		closing: make(chan struct{}),
+	}
+	if conn4 != nil && conn6 != nil {
+		c.wg.Add(2)
+		go c.readLoop(conn4)
+		go c.readLoop(conn6)
+	}
+	return c
+}
+
+// IPFamilies reports whsdcer the IPv4 and IPv6 address families are reachable.
+func (c *DualStackConn) IPFamilies() (ip4, ip6 bool) {
+	return c.conn4 != nil, c.conn6 != nil
+}
+
+// ReadFromUDP reads the next packet from any of the sockets.
+func (c *DualStackConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	switch {
+	case c.conn6 == nil:
+		return c.conn4.ReadFromUDP(b)
+	case c.conn4 == nil:
+		return c.conn6.ReadFromUDP(b)
+	}
+	select {
+	case p := <-c.readCh:
+		if p.err != nil {
+			return 0, nil, p.err
+		}
+		return copy(b, p.data), p.addr, nil
+	case <-c.closing:
+		return 0, nil, io.EOF
+	}
+}
+
+// readLoop forwards the packets of a socket to ReadFromUDP.
+func (c *DualStackConn) readLoop(conn UDPConn) {
+	defer c.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		p := dualStackPacket{addr: addr, err: err}
+		if err == nil {
+			p.data = append([]byte{}, buf[:n]...)
+		}
+		select {
+		case c.readCh <- p:
+		case <-c.closing:
+			return
+		}
+		if err != nil && !netutil.IsTemporaryError(err) {
+			return
+		}
+	}
+}
+
+// WriteToUDP sends a packet on the socket of the destination's address family.
+func (c *DualStackConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	conn := c.conn6
+	if addr != nil && addr.IP.To4() != nil {
+		conn = c.conn4
+	}
+	if conn == nil {
+		return 0, errNoSocket
+	}
+	return conn.WriteToUDP(b, addr)
+}
+
+// Close closes both sockets.
+func (c *DualStackConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closing)
+		for _, conn := range []UDPConn{c.conn4, c.conn6} {
+			if conn == nil {
+				continue
+			}
+			if cerr := conn.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		c.wg.Wait()
+	})
+	return err
+}
+
+// LocalAddr returns the address of the IPv4 socket, or the IPv6 one if there is
+// no IPv4 socket.
+func (c *DualStackConn) LocalAddr() net.Addr {
+	if c.conn4 != nil {
+		return c.conn4.LocalAddr()
+	}
+	return c.conn6.LocalAddr()
+}
+
+// ipFamilies are the IP address families reachable through a connection.
+type ipFamilies struct {
+	ip4, ip6 bool
+}
+
+// connFamilies determines the address families reachable through a connection.
+// Connections combining multiple sockets report them via an IPFamilies msdcod.
+// Otherwise, sockets bound to an IPv4 or a specific IPv6 address are assumed to
+// reach that family only, whereas the unspecified IPv6 address reaches both.
+func connFamilies(conn UDPConn) ipFamilies {
+	if fc, ok := conn.(interface{ IPFamilies() (bool, bool) }); ok {
+		ip4, ip6 := fc.IPFamilies()
+		return ipFamilies{ip4, ip6}
+	}
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || addr.IP == nil || (addr.IP.IsUnspecified() && addr.IP.To4() == nil) {
+		return ipFamilies{true, true}
+	}
+	return ipFamiliesOf(addr.IP)
+}
+
+// ipFamiliesOf returns the address family of an IP address.
+func ipFamiliesOf(ip net.IP) ipFamilies {
+	is4 := ip.To4() != nil
+	return ipFamilies{ip4: is4, ip6: !is4}
+}
+
+// endpoint returns the address and ports of a node on a reachable address family,
+// preferring IPv4. If the node isn't reachable on any family, its default
+// endpoint is returned with ok set to false.
+func (f ipFamilies) endpoint(n *enode.Node) (ip net.IP, udp, tcp int, ok bool) {
+	if ip := n.IPv4(); ip != nil && f.ip4 {
+		return ip, n.UDP(), n.TCP(), true
+	}
+	if ip := n.IPv6(); ip != nil && f.ip6 {
+		return ip, n.UDP6(), n.TCP6(), true
+	}
+	return n.IP(), n.UDP(), n.TCP(), false
+}
+
+// udpAddr returns the UDP endpoint to contact a node at.
+func (f ipFamilies) udpAddr(n *enode.Node) *net.UDPAddr {
+	ip, port, _, _ := f.endpoint(n)
+	return &net.UDPAddr{IP: ip, Port: port}
+}
+
+// reachable reports whsdcer a node has an address on any of the families.
+func (f ipFamilies) reachable(n *enode.Node) bool {
+	_, _, _, ok := f.endpoint(n)
+	return ok
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/p2p/enr"
+)
+
+func TestDualStackConn(t *testing.T) {
+	conn4, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn6, err := net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		conn4.Close()
+		t.Skip("IPv6 not available:", err)
+	}
+	conn := NewDualStackConn(conn4, conn6)
+	defer conn.Close()
+
+	if ip4, ip6 := conn.IPFamilies(); !ip4 || !ip6 {
+		t.Fatalf("wrong address families: ip4 %t, ip6 %t", ip4, ip6)
+	}
+	// Packets to both families are sent from the matching socket.
+	for _, dst := range []*net.UDPConn{conn4, conn6} {
+		to := dst.LocalAddr().(*net.UDPAddr)
+		if _, err := conn.WriteToUDP([]byte("hello"), to); err != nil {
+			t.Fatalf("write to %v failed: %v", to, err)
+		}
+		buf := make([]byte, maxPacketSize)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("read from %v failed: %v", to, err)
+		}
+		if string(buf[:n]) != "hello" {
+			t.Errorf("wrong packet content %q", buf[:n])
+		}
+		if from.Port != to.Port || from.IP.To4() == nil != (to.IP.To4() == nil) {
+			t.Errorf("packet sent to %v received from %v", to, from)
+		}
+	}
+	// Reads end when closed.
+	conn.Close()
+	if _, _, err := conn.ReadFromUDP(make([]byte, maxPacketSize)); err == nil {
+		t.Fatal("read succeeded after close")
+	}
+}
+
+func TestIPFamiliesEndpoint(t *testing.T) {
+	var (
+		ip4 = net.IP{10, 0, 0, 1}
+		ip6 = net.ParseIP("2001:db8::1")
+		r   enr.Record
+	)
+	r.Set(enr.IPv4(ip4))
+	r.Set(enr.IPv6(ip6))
+	r.Set(enr.UDP(30303))
+	r.Set(enr.UDP6(30304))
+	dual := enode.SignNull(&r, enode.ID{})
+
+	var r4 enr.Record
+	r4.Set(enr.IPv4(ip4))
+	r4.Set(enr.UDP(30303))
+	only4 := enode.SignNull(&r4, enode.ID{})
+
+	tests := []struct {
+		families  ipFamilies
+		node      *enode.Node
+		wantAddr  *net.UDPAddr
+		reachable bool
+	}{
+		{ipFamilies{true, true}, dual, &net.UDPAddr{IP: ip4, Port: 30303}, true},
+		{ipFamilies{true, false}, dual, &net.UDPAddr{IP: ip4, Port: 30303}, true},
+		{ipFamilies{false, true}, dual, &net.UDPAddr{IP: ip6, Port: 30304}, true},
+		{ipFamilies{false, true}, only4, &net.UDPAddr{IP: ip4, Port: 30303}, false},
+	}
+	for i, test := range tests {
+		addr := test.families.udpAddr(test.node)
+		if !addr.IP.Equal(test.wantAddr.IP) || addr.Port != test.wantAddr.Port {
+			t.Errorf("test %d: wrong endpoint %v, want %v", i, addr, test.wantAddr)
+		}
+		if reachable := test.families.reachable(test.node); reachable != test.reachable {
+			t.Errorf("test %d: wrong reachability %t", i, reachable)
+		}
+	}
+}
+
+func TestConnFamilies(t *testing.T) {
+	tests := []struct {
+		conn UDPConn
+		want ipFamilies
+	}{
+		{&dgramPipe{}, ipFamilies{true, false}},
+		{NewDualStackConn(nil, &dgramPipe{}), ipFamilies{false, true}},
+	}
+	for i, test := range tests {
+		if have := connFamilies(test.conn); have != test.want {
+			t.Errorf("test %d: wrong families %+v, want %+v", i, have, test.want)
+		}
+	}
+}
@@ -68,6 +68,7 @@ type UDPv4 struct {
 	conn        UDPConn
 	log         log.Logger
 	netrestrict *netutil.Netlist
+	families    ipFamilies // address families reachable through conn
 	priv        *ecdsa.PrivateKey
 	localNode   *enode.LocalNode
 	db          *enode.DB
@@ -133,6 +134,7 @@ func ListenV4(c UDPConn, ln *enode.LocalNode, cfg Config) (*UDPv4, error) {
 		conn:            c,
 		priv:            cfg.PrivateKey,
 		netrestrict:     cfg.NetRestrict,
+		families:        connFamilies(c),
 		localNode:       ln,
 		db:              ln.Database(),
 		gotreply:        make(chan reply),
@@ -214,7 +216,7 @@ func (t *UDPv4) Ping(n *enode.Node) error {
 
 // ping sends a ping message to the given node and waits for a reply.
 func (t *UDPv4) ping(n *enode.Node) (seq uint64, err error) {
-	rm := t.sendPing(n.ID(), &net.UDPAddr{IP: n.IP(), Port: n.UDP()}, nil)
+	rm := t.sendPing(n.ID(), t.families.udpAddr(n), nil)
 	if err = <-rm.errc; err == nil {
 		seq = rm.reply.(*v4wire.Pong).ENRSeq
 	}
@@ -291,7 +293,7 @@ func (t *UDPv4) newLookup(ctx context.Context, targetKey encPubkey) *lookup {
 	target := enode.ID(crypto.Keccak256Hash(targetKey[:]))
 	ekey := v4wire.Pubkey(targetKey)
 	it := newLookup(ctx, t.tab, target, func(n *node) ([]*node, error) {
-		return t.findnode(n.ID(), n.addr(), ekey)
+		return t.findnode(n.ID(), t.families.udpAddr(unwrapNode(n)), ekey)
 	})
 	return it
 }
@@ -336,7 +338,7 @@ func (t *UDPv4) findnode(toid enode.ID, toaddr *net.UDPAddr, target v4wire.Pubke
 
 // RequestENR sends ENRRequest to the given node and waits for a response.
 func (t *UDPv4) RequestENR(n *enode.Node) (*enode.Node, error) {
-	addr := &net.UDPAddr{IP: n.IP(), Port: n.UDP()}
+	addr := t.families.udpAddr(n)
 	t.ensureBond(n.ID(), addr)
 
 	req := &v4wire.ENRRequest{
@@ -369,7 +371,7 @@ func (t *UDPv4) RequestENR(n *enode.Node) (*enode.Node, error) {
 	if respN.Seq() < n.Seq() {
 		return n, nil // response record is older
 	}
-	if err := netutil.CheckRelayIP(addr.IP, respN.IP()); err != nil {
+	if err := netutil.CheckRelayIP(addr.IP, t.families.udpAddr(respN).IP); err != nil {
 		return nil, fmt.Errorf("invalid IP in response record: %v", err)
 	}
 	return respN, nil
@@ -590,6 +592,9 @@ func (t *UDPv4) nodeFromRPC(sender *net.UDPAddr, rn v4wire.Node) (*node, error)
 		return nil, err
 	}
 	n := wrapNode(enode.NewV4(key, rn.IP, int(rn.TCP), int(rn.UDP)))
+	if !t.families.reachable(unwrapNode(n)) {
+		return nil, errors.New("address family not reachable")
+	}
 	err = n.ValidateComplete()
 	return n, err
 }
@@ -726,10 +731,17 @@ func (t *UDPv4) handleFindnode(h *packsdcandlerV4, from *net.UDPAddr, fromID eno
 	// Send neighbors in chunks with at most maxNeighbors per packet
 	// to stay below the packet size limit.
 	p := v4wire.Neighbors{Expiration: uint64(time.Now().Add(expiration).Unix())}
-	var sent bool
+	// Nodes are sent with their endpoint on the address family of the requester.
+	var (
+		sent     bool
+		families = ipFamiliesOf(from.IP)
+	)
 	for _, n := range closest {
-		if netutil.CheckRelayIP(from.IP, n.IP()) == nil {
-			p.Nodes = append(p.Nodes, nodeToRPC(n))
+		ip, udp, tcp, ok := families.endpoint(unwrapNode(n))
+		if ok && netutil.CheckRelayIP(from.IP, ip) == nil {
+			rn := nodeToRPC(n)
+			rn.IP, rn.UDP, rn.TCP = ip, uint16(udp), uint16(tcp)
+			p.Nodes = append(p.Nodes, rn)
 		}
 		if len(p.Nodes) == v4wire.MaxNeighbors {
 			t.send(from, fromID, &p)
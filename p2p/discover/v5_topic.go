@@ -0,0 +1,536 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/p2p/discover/v5wire"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/rlp"
+)
+
+// Topic advertisement works as follows: an advertiser looks up the nodes closest
+// to the topic hash (the registrars) and asks each of them for a ticket. The ticket
+// carries a waiting time, after which the advertiser registers with the ticket and
+// its ad is put into the topic queue of the registrar. Ads expire after a fixed
+// lifetime, so the waiting time is the time until a slot frees up in the queue.
+//
+// Searchers look up the registrars of a topic as well, and query their topic
+// queues for the advertised nodes.
+
+const (
+	topicQueueLength  = 50               // Maximum number of ads in a topic queue
+	topicTableLimit   = 500              // Maximum number of ads across all topic queues
+	topicAdLifetime   = 15 * time.Minute // Time an ad stays in a topic queue
+	topicTicketWindow = 10 * time.Second // Time after the waiting time in which a ticket is valid
+
+	topicRegistrars      = 8                // Number of registrars to keep an ad placed with
+	topicLookupInterval  = 1 * time.Minute  // Time between lookups for registrars of a topic
+	topicRetryDelay      = 5 * time.Second  // Time to wait after a failed registration
+	topicSearchInterval  = 10 * time.Second // Minimum time between queries of a registrar
+	topicSearchRegistrar = bucketSize       // Number of registrars queried by searches
+)
+
+var (
+	errInvalidTicket = errors.New("invalid ticket")
+	errTicketTime    = errors.New("ticket used outside of its window")
+	errInvalidTopic  = errors.New("invalid topic")
+)
+
+// topicHash computes the DHT key of a topic. Registrars are the nodes closest to it.
+func topicHash(topic string) enode.ID {
+	return sha256.Sum256([]byte(topic))
+}
+
+// topicTicket is the content of a ticket issued by a registrar. Apart from the
+// waiting time, tickets are only interpreted by their issuer, who authenticates
+// them using a local secret.
+type topicTicket struct {
+	Topic  enode.ID
+	Node   enode.ID // Node the ticket was issued to
+	Issued uint64   // Local time of issuance
+	Wait   uint64   // Waiting time in milliseconds
+	MAC    []byte
+}
+
+// waitTime returns the waiting time before the ticket can be used.
+func (tk *topicTicket) waitTime() time.Duration {
+	return time.Duration(tk.Wait) * time.Millisecond
+}
+
+// mac computes the authentication code of the ticket fields.
+func (tk *topicTicket) mac(key []byte) []byte {
+	enc, _ := rlp.EncodeToBytes([]interface{}{tk.Topic, tk.Node, tk.Issued, tk.Wait})
+	h := hmac.New(sha256.New, key)
+	h.Write(enc)
+	return h.Sum(nil)
+}
+
+// topicAd is an advertisement of a node in a topic queue.
+type topicAd struct {
+	node    *enode.Node
+	expires mclock.AbsTime
+}
+
+// topicTable stores the ads placed with the local node as a registrar. Ads are
+// kept in per-topic queues ordered by expiry. It is not safe for concurrent use,
+// all access happens in the dispatch loop of UDPv5.
+type topicTable struct {
+	queues map[enode.ID][]*topicAd
+	count  int
+}
+
+func newTopicTable() *topicTable {
+	return &topicTable{queues: make(map[enode.ID][]*topicAd)}
+}
+
+// expire drops all ads whose lifetime has passed.
+func (tt *topicTable) expire(now mclock.AbsTime) {
+	for topic, queue := range tt.queues {
+		i := 0
+		for ; i < len(queue) && queue[i].expires <= now; i++ {
+		}
+		tt.count -= i
+		if i == len(queue) {
+			delete(tt.queues, topic)
+		} else if i > 0 {
+			tt.queues[topic] = queue[i:]
+		}
+	}
+}
+
+// waitTime computes the waiting time of a node registering for a topic, i.e.
+// the time until a slot in the topic queue frees up. A node having an ad in the
+// queue already has to wait until it expires.
+func (tt *topicTable) waitTime(topic enode.ID, id enode.ID, now mclock.AbsTime) time.Duration {
+	tt.expire(now)
+
+	queue := tt.queues[topic]
+	for _, ad := range queue {
+		if ad.node.ID() == id {
+			return time.Duration(ad.expires - now)
+		}
+	}
+	if len(queue) >= topicQueueLength {
+		return time.Duration(queue[0].expires - now)
+	}
+	if tt.count >= topicTableLimit {
+		// The table is full, wait for the oldest ad of any topic
+		var oldest mclock.AbsTime
+		for _, queue := range tt.queues {
+			if oldest == 0 || queue[0].expires < oldest {
+				oldest = queue[0].expires
+			}
+		}
+		return time.Duration(oldest - now)
+	}
+	return 0
+}
+
+// add places an ad for the node in the topic queue. It returns false if there
+// is no space for it.
+func (tt *topicTable) add(topic enode.ID, n *enode.Node, now mclock.AbsTime) bool {
+	if tt.waitTime(topic, n.ID(), now) > 0 {
+		return false
+	}
+	tt.queues[topic] = append(tt.queues[topic], &topicAd{node: n, expires: now.Add(topicAdLifetime)})
+	tt.count++
+	return true
+}
+
+// nodes returns the nodes advertised for a topic, most recent first.
+func (tt *topicTable) nodes(topic enode.ID, limit int, now mclock.AbsTime) []*enode.Node {
+	tt.expire(now)
+
+	queue := tt.queues[topic]
+	nodes := make([]*enode.Node, 0, min(limit, len(queue)))
+	for i := len(queue) - 1; i >= 0 && len(nodes) < limit; i-- {
+		nodes = append(nodes, queue[i].node)
+	}
+	return nodes
+}
+
+// topicSearchTable is the search state of a topic, shared by all iterators
+// searching it. It tracks the registrars of the topic and when they were last
+// queried.
+type topicSearchTable struct {
+	mu         sync.Mutex
+	registrars nodesByDistance
+	queried    map[enode.ID]mclock.AbsTime
+	lastLookup mclock.AbsTime
+}
+
+func newTopicSearchTable(topic enode.ID) *topicSearchTable {
+	return &topicSearchTable{
+		registrars: nodesByDistance{target: topic},
+		queried:    make(map[enode.ID]mclock.AbsTime),
+	}
+}
+
+// needsLookup reports whsdcer the registrars should be looked up again.
+func (st *topicSearchTable) needsLookup(now mclock.AbsTime) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return len(st.registrars.entries) == 0 || st.lastLookup == 0 || now.Sub(st.lastLookup) >= topicLookupInterval
+}
+
+// addRegistrars records the result of a registrar lookup.
+func (st *topicSearchTable) addRegistrars(nodes []*enode.Node, now mclock.AbsTime) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, n := range nodes {
+		if !contains(st.registrars.entries, n.ID()) {
+			st.registrars.push(wrapNode(n), topicSearchRegistrar)
+		}
+	}
+	st.lastLookup = now
+}
+
+// removeRegistrar drops an unresponsive registrar.
+func (st *topicSearchTable) removeRegistrar(n *enode.Node) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.registrars.entries = deleteNode(st.registrars.entries, wrapNode(n))
+	delete(st.queried, n.ID())
+}
+
+// nextQuery picks the closest registrar not queried recently, and marks it as
+// queried. If all of them were, it returns the time until one can be queried again.
+func (st *topicSearchTable) nextQuery(now mclock.AbsTime) (*enode.Node, time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	wait := topicSearchInterval
+	for _, n := range st.registrars.entries {
+		last, ok := st.queried[n.ID()]
+		if !ok || now.Sub(last) >= topicSearchInterval {
+			st.queried[n.ID()] = now
+			return unwrapNode(n), 0
+		}
+		if left := topicSearchInterval - now.Sub(last); left < wait {
+			wait = left
+		}
+	}
+	return nil, wait
+}
+
+// topicIterator iterates over the nodes advertising a topic. It keeps querying
+// the registrars of the topic for new ads until closed.
+type topicIterator struct {
+	t      *UDPv5
+	topic  enode.ID
+	search *topicSearchTable
+	ctx    context.Context
+	cancel func()
+	buffer []*enode.Node
+}
+
+// Node returns the current node.
+func (it *topicIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Next moves to the next node.
+func (it *topicIterator) Next() bool {
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	for len(it.buffer) == 0 {
+		if it.ctx.Err() != nil {
+			it.buffer = nil
+			return false
+		}
+		if it.search.needsLookup(it.t.clock.Now()) {
+			nodes := it.t.newLookup(it.ctx, it.topic).run()
+			it.search.addRegistrars(nodes, it.t.clock.Now())
+		}
+		registrar, wait := it.search.nextQuery(it.t.clock.Now())
+		if registrar == nil {
+			it.t.sleep(it.ctx, wait)
+			continue
+		}
+		nodes, err := it.t.topicQuery(registrar, it.topic)
+		if err != nil && len(nodes) == 0 {
+			it.t.log.Trace("Topic query failed", "id", registrar.ID(), "err", err)
+			it.search.removeRegistrar(registrar)
+			continue
+		}
+		for _, n := range nodes {
+			if n.ID() != it.t.Self().ID() {
+				it.buffer = append(it.buffer, n)
+			}
+		}
+	}
+	return true
+}
+
+// Close ends the iterator.
+func (it *topicIterator) Close() {
+	it.cancel()
+}
+
+// RegisterTopic starts advertising the local node under the given topic with the
+// nodes closest to the topic hash. The ad is kept placed until UnregisterTopic is
+// called or the transport is closed.
+func (t *UDPv5) RegisterTopic(topic string) {
+	t.topicLock.Lock()
+	defer t.topicLock.Unlock()
+
+	hash := topicHash(topic)
+	if _, ok := t.topicAds[hash]; ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	t.topicAds[hash] = cancel
+	go t.advertiseTopic(ctx, topic, hash)
+}
+
+// UnregisterTopic stops advertising the local node under the given topic. Ads
+// already placed stay until they expire.
+func (t *UDPv5) UnregisterTopic(topic string) {
+	t.topicLock.Lock()
+	defer t.topicLock.Unlock()
+
+	hash := topicHash(topic)
+	if cancel, ok := t.topicAds[hash]; ok {
+		cancel()
+		delete(t.topicAds, hash)
+	}
+}
+
+// TopicNodes returns an iterator of the nodes advertising the given topic.
+func (t *UDPv5) TopicNodes(topic string) enode.Iterator {
+	hash := topicHash(topic)
+
+	t.topicLock.Lock()
+	search := t.topicSearch[hash]
+	if search == nil {
+		search = newTopicSearchTable(hash)
+		t.topicSearch[hash] = search
+	}
+	t.topicLock.Unlock()
+
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	return &topicIterator{t: t, topic: hash, search: search, ctx: ctx, cancel: cancel}
+}
+
+// advertiseTopic keeps the local node advertised under a topic by up to
+// topicRegistrars nodes, replacing registrars that fail.
+func (t *UDPv5) advertiseTopic(ctx context.Context, topic string, hash enode.ID) {
+	var (
+		active = make(map[enode.ID]bool)
+		done   = make(chan enode.ID)
+		lookup = t.clock.NewTimer(0)
+	)
+	defer lookup.Stop()
+
+	for {
+		select {
+		case <-lookup.C():
+			for _, n := range t.newLookup(ctx, hash).run() {
+				if len(active) >= topicRegistrars {
+					break
+				}
+				if !active[n.ID()] {
+					active[n.ID()] = true
+					go t.registerTopicWith(ctx, n, topic, hash, done)
+				}
+			}
+			lookup.Reset(topicLookupInterval)
+
+		case id := <-done:
+			delete(active, id)
+
+		case <-ctx.Done():
+			for len(active) > 0 {
+				delete(active, <-done)
+			}
+			return
+		}
+	}
+}
+
+// registerTopicWith keeps an ad of the local node placed with a registrar, until
+// the registrar fails or the advertisement is stopped.
+func (t *UDPv5) registerTopicWith(ctx context.Context, n *enode.Node, topic string, hash enode.ID, done chan<- enode.ID) {
+	defer func() { done <- n.ID() }()
+
+	for {
+		ticket, wait, err := t.requestTicket(n, hash)
+		if err != nil {
+			t.log.Trace("Topic ticket request failed", "id", n.ID(), "topic", topic, "err", err)
+			return
+		}
+		if wait > topicAdLifetime {
+			t.log.Trace("Topic registrar too busy", "id", n.ID(), "topic", topic, "wait", wait)
+			return
+		}
+		if !t.sleep(ctx, wait) {
+			return
+		}
+		registered, err := t.regtopic(n, ticket)
+		if err != nil {
+			t.log.Trace("Topic registration failed", "id", n.ID(), "topic", topic, "err", err)
+			return
+		}
+		delay := topicRetryDelay
+		if registered {
+			t.log.Trace("Registered topic", "id", n.ID(), "topic", topic)
+			delay = topicAdLifetime
+		}
+		if !t.sleep(ctx, delay) {
+			return
+		}
+	}
+}
+
+// sleep waits for the given duration. It returns false if the context was
+// canceled in the meantime.
+func (t *UDPv5) sleep(ctx context.Context, d time.Duration) bool {
+	timer := t.clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// requestTicket calls REQTICKET on a node and returns the ticket along with its
+// waiting time.
+func (t *UDPv5) requestTicket(n *enode.Node, topic enode.ID) ([]byte, time.Duration, error) {
+	resp := t.call(n, v5wire.TicketMsg, &v5wire.RequestTicket{Topic: topic[:]})
+	defer t.callDone(resp)
+
+	select {
+	case respMsg := <-resp.ch:
+		ticket := respMsg.(*v5wire.Ticket).Ticket
+		var tk topicTicket
+		if err := rlp.DecodeBytes(ticket, &tk); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", errInvalidTicket, err)
+		}
+		return ticket, tk.waitTime(), nil
+	case err := <-resp.err:
+		return nil, 0, err
+	}
+}
+
+// regtopic calls REGTOPIC on a node and reports whsdcer the ad was placed.
+func (t *UDPv5) regtopic(n *enode.Node, ticket []byte) (bool, error) {
+	req := &v5wire.Regtopic{Ticket: ticket, ENR: t.Self().Record()}
+	resp := t.call(n, v5wire.RegconfirmationMsg, req)
+	defer t.callDone(resp)
+
+	select {
+	case respMsg := <-resp.ch:
+		return respMsg.(*v5wire.Regconfirmation).Registered, nil
+	case err := <-resp.err:
+		return false, err
+	}
+}
+
+// topicQuery calls TOPICQUERY on a node and waits for the advertised nodes.
+func (t *UDPv5) topicQuery(n *enode.Node, topic enode.ID) ([]*enode.Node, error) {
+	resp := t.call(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic[:]})
+	return t.waitForNodes(resp, nil)
+}
+
+// handleRequestTicket issues a ticket for the requested topic queue.
+func (t *UDPv5) handleRequestTicket(p *v5wire.RequestTicket, fromID enode.ID, fromAddr *net.UDPAddr) {
+	if len(p.Topic) != len(enode.ID{}) {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", errInvalidTopic)
+		return
+	}
+	var (
+		now = t.clock.Now()
+		tk  = &topicTicket{Node: fromID, Issued: uint64(now)}
+	)
+	copy(tk.Topic[:], p.Topic)
+	tk.Wait = uint64(t.topicTable.waitTime(tk.Topic, fromID, now) / time.Millisecond)
+	tk.MAC = tk.mac(t.ticketKey)
+
+	ticket, _ := rlp.EncodeToBytes(tk)
+	t.sendResponse(fromID, fromAddr, &v5wire.Ticket{ReqID: p.ReqID, Ticket: ticket})
+}
+
+// handleRegtopic places an ad of the sender if its ticket is valid.
+func (t *UDPv5) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr *net.UDPAddr) {
+	registered, err := t.registerTopic(p, fromID)
+	if err != nil {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+	}
+	t.sendResponse(fromID, fromAddr, &v5wire.Regconfirmation{ReqID: p.ReqID, Registered: registered})
+}
+
+// registerTopic validates a REGTOPIC request and adds the ad to the topic table.
+func (t *UDPv5) registerTopic(p *v5wire.Regtopic, fromID enode.ID) (bool, error) {
+	var tk topicTicket
+	if err := rlp.DecodeBytes(p.Ticket, &tk); err != nil {
+		return false, fmt.Errorf("%w: %v", errInvalidTicket, err)
+	}
+	if !hmac.Equal(tk.MAC, tk.mac(t.ticketKey)) || tk.Node != fromID {
+		return false, errInvalidTicket
+	}
+	var (
+		now   = t.clock.Now()
+		start = mclock.AbsTime(tk.Issued).Add(tk.waitTime())
+	)
+	if now < start || now > start.Add(topicTicketWindow) {
+		return false, errTicketTime
+	}
+	if p.ENR == nil {
+		return false, errors.New("missing record")
+	}
+	n, err := enode.New(t.validSchemes, p.ENR)
+	if err != nil {
+		return false, err
+	}
+	if n.ID() != fromID {
+		return false, errors.New("record of other node")
+	}
+	return t.topicTable.add(tk.Topic, n, now), nil
+}
+
+// handleTopicQuery returns the nodes advertised for a topic.
+func (t *UDPv5) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr *net.UDPAddr) {
+	var nodes []*enode.Node
+	if len(p.Topic) == len(enode.ID{}) {
+		var topic enode.ID
+		copy(topic[:], p.Topic)
+		nodes = t.topicTable.nodes(topic, findnodeResultLimit, t.clock.Now())
+	}
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		t.sendResponse(fromID, fromAddr, resp)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common"
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/p2p/discover/v5wire"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/rlp"
+)
+
+// This test checks the waiting times handed out by the topic table.
+func TestTopicTable(t *testing.T) {
+	var (
+		tab   = newTopicTable()
+		topic = topicHash("foo")
+		now   = mclock.AbsTime(0)
+		nodes = make([]*enode.Node, topicQueueLength+1)
+	)
+	for i := range nodes {
+		nodes[i] = unwrapNode(nodeAtDistance(enode.ID{}, 200, net.IP{10, 0, 0, byte(i)}))
+	}
+	// Fill up the queue, one ad per second.
+	for i := 0; i < topicQueueLength; i++ {
+		if wait := tab.waitTime(topic, nodes[i].ID(), now); wait != 0 {
+			t.Fatalf("ad %d: non-zero waiting time %v for queue with free slots", i, wait)
+		}
+		if !tab.add(topic, nodes[i], now) {
+			t.Fatalf("ad %d: not added", i)
+		}
+		now = now.Add(time.Second)
+	}
+	// Registered nodes have to wait for their own ad to expire.
+	if wait, want := tab.waitTime(topic, nodes[1].ID(), now), topicAdLifetime-time.Duration(topicQueueLength-1)*time.Second; wait != want {
+		t.Fatalf("wrong waiting time for registered node: have %v, want %v", wait, want)
+	}
+	// Other nodes have to wait for the oldest ad to expire.
+	last := nodes[topicQueueLength]
+	if wait, want := tab.waitTime(topic, last.ID(), now), topicAdLifetime-time.Duration(topicQueueLength)*time.Second; wait != want {
+		t.Fatalf("wrong waiting time for full queue: have %v, want %v", wait, want)
+	}
+	if tab.add(topic, last, now) {
+		t.Fatal("ad added to full queue")
+	}
+	// Other topics are not affected.
+	if wait := tab.waitTime(topicHash("bar"), last.ID(), now); wait != 0 {
+		t.Fatalf("non-zero waiting time %v for empty queue", wait)
+	}
+	// Once the oldest ad expired, there is space again.
+	now = mclock.AbsTime(0).Add(topicAdLifetime)
+	if !tab.add(topic, last, now) {
+		t.Fatal("ad not added after expiry")
+	}
+	if n := len(tab.nodes(topic, topicQueueLength+1, now)); n != topicQueueLength {
+		t.Fatalf("wrong number of ads: have %d, want %d", n, topicQueueLength)
+	}
+	if got := tab.nodes(topic, 1, now); len(got) != 1 || got[0].ID() != last.ID() {
+		t.Fatal("most recent ad not returned first")
+	}
+}
+
+// This test checks that REQTICKET, REGTOPIC and TOPICQUERY are handled correctly.
+func TestUDPv5_topicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic  = topicHash("foo")
+		remote = test.getNode(test.remotekey, test.remoteaddr).Node()
+		ticket []byte
+	)
+	test.packetIn(&v5wire.RequestTicket{ReqID: []byte("1"), Topic: topic[:]})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte("1")) {
+			t.Error("wrong request ID in response:", p.ReqID)
+		}
+		var tk topicTicket
+		if err := rlp.DecodeBytes(p.Ticket, &tk); err != nil {
+			t.Fatal("invalid ticket:", err)
+		}
+		if tk.Topic != topic || tk.Node != remote.ID() || tk.Wait != 0 {
+			t.Errorf("wrong ticket content: %+v", tk)
+		}
+		ticket = p.Ticket
+	})
+
+	// Forged tickets are rejected.
+	forged := common.CopyBytes(ticket)
+	forged[len(forged)-1] ^= 0xff
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("2"), Ticket: forged, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Regconfirmation, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if p.Registered {
+			t.Error("registered with forged ticket")
+		}
+	})
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("3"), Ticket: ticket, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Regconfirmation, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte("3")) {
+			t.Error("wrong request ID in response:", p.ReqID)
+		}
+		if !p.Registered {
+			t.Error("not registered with valid ticket")
+		}
+	})
+
+	// The ad is returned for the topic only.
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte("4"), Topic: topic[:]})
+	test.expectNodes([]byte("4"), 1, []*enode.Node{remote})
+
+	other := topicHash("bar")
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte("5"), Topic: other[:]})
+	test.expectNodes([]byte("5"), 1, nil)
+}
+
+// Real sockets, real crypto: this test checks that advertised nodes can be found.
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 5
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			bn := nodes[0].Self()
+			cfg.Bootnodes = []*enode.Node{bn}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	// Advertise two of the nodes, and search them from another one.
+	nodes[1].RegisterTopic("test")
+	nodes[2].RegisterTopic("test")
+
+	it := nodes[N-1].TopicNodes("test")
+	defer it.Close()
+
+	var (
+		found = make(chan enode.ID)
+		done  = make(chan struct{})
+	)
+	defer close(done)
+	go func() {
+		for it.Next() {
+			select {
+			case found <- it.Node().ID():
+			case <-done:
+				return
+			}
+		}
+	}()
+	want := map[enode.ID]bool{nodes[1].Self().ID(): true, nodes[2].Self().ID(): true}
+	timeout := time.After(30 * time.Second)
+	for len(want) > 0 {
+		select {
+		case id := <-found:
+			delete(want, id)
+		case <-timeout:
+			t.Fatalf("advertised nodes not found, missing %d", len(want))
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"sync"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/sdcereum/go-sdcereum/common/mclock"
 	"github.com/sdcereum/go-sdcereum/log"
 	"github.com/sdcereum/go-sdcereum/p2p/discover/v5wire"
@@ -44,6 +45,8 @@ const (
 	nodesResponseItemLimit  = 3  // applies in sendNodes
 
 	respTimeoutV5 = 700 * time.Millisecond
+
+	relayCacheSize = 1024 // number of remembered hole punching relays
 )
 
 // codecV5 is implemented by v5wire.Codec (and testCodec).
@@ -65,6 +68,7 @@ type UDPv5 struct {
 	conn         UDPConn
 	tab          *Table
 	netrestrict  *netutil.Netlist
+	families     ipFamilies // address families reachable through conn
 	priv         *ecdsa.PrivateKey
 	localNode    *enode.LocalNode
 	db           *enode.DB
@@ -76,6 +80,15 @@ type UDPv5 struct {
 	trlock     sync.Mutex
 	trhandlers map[string]TalkRequestHandler
 
+	// relays maps node IDs to the node which told us about them. The
+	// relay is asked to help with hole punching when the node is unreachable.
+	relays *lru.Cache
+
+	// topic advertisement and search
+	topicLock   sync.Mutex
+	topicAds    map[enode.ID]context.CancelFunc // Topics the local node is advertised under
+	topicSearch map[enode.ID]*topicSearchTable  // Search state of the topics looked for
+
 	// channels into dispatch
 	packetInCh    chan ReadPacket
 	readNextCh    chan struct{}
@@ -88,6 +101,8 @@ type UDPv5 struct {
 	activeCallByNode map[enode.ID]*callV5
 	activeCallByAuth map[v5wire.Nonce]*callV5
 	callQueue        map[enode.ID][]*callV5
+	topicTable       *topicTable // Ads placed with the local node
+	ticketKey        []byte      // Secret authenticating the issued tickets
 
 	// shutdown stuff
 	closeOnce      sync.Once
@@ -113,6 +128,8 @@ type callV5 struct {
 	handshakeCount int               // # times we attempted handshake for this call
 	challenge      *v5wire.Whoareyou // last sent handshake challenge
 	timeout        mclock.Timer
+	answered       bool // whsdcer any response was received
+	relayed        bool // whsdcer hole punching was attempted
 }
 
 // callTimeout is the response timeout event of a call.
@@ -144,11 +161,14 @@ func newUDPv5(conn UDPConn, ln *enode.LocalNode, cfg Config) (*UDPv5, error) {
 		localNode:    ln,
 		db:           ln.Database(),
 		netrestrict:  cfg.NetRestrict,
+		families:     connFamilies(conn),
 		priv:         cfg.PrivateKey,
 		log:          cfg.Log,
 		validSchemes: cfg.ValidSchemes,
 		clock:        cfg.Clock,
 		trhandlers:   make(map[string]TalkRequestHandler),
+		topicAds:     make(map[enode.ID]context.CancelFunc),
+		topicSearch:  make(map[enode.ID]*topicSearchTable),
 		// channels into dispatch
 		packetInCh:    make(chan ReadPacket, 1),
 		readNextCh:    make(chan struct{}, 1),
@@ -160,10 +180,15 @@ func newUDPv5(conn UDPConn, ln *enode.LocalNode, cfg Config) (*UDPv5, error) {
 		activeCallByNode: make(map[enode.ID]*callV5),
 		activeCallByAuth: make(map[v5wire.Nonce]*callV5),
 		callQueue:        make(map[enode.ID][]*callV5),
+		topicTable:       newTopicTable(),
+		ticketKey:        make([]byte, 32),
 		// shutdown
 		closeCtx:       closeCtx,
 		cancelCloseCtx: cancelCloseCtx,
 	}
+	crand.Read(t.ticketKey)
+	t.relays, _ = lru.New(relayCacheSize)
+
 	tab, err := newTable(t, t.db, cfg.Bootnodes, cfg.Log)
 	if err != nil {
 		return nil, err
@@ -404,10 +429,14 @@ func (t *UDPv5) verifyResponseNode(c *callV5, r *enr.Record, distances []uint, s
 	if err != nil {
 		return nil, err
 	}
-	if err := netutil.CheckRelayIP(c.node.IP(), node.IP()); err != nil {
+	ip, _, _, ok := t.families.endpoint(node)
+	if !ok {
+		return nil, errors.New("address family not reachable")
+	}
+	if err := netutil.CheckRelayIP(t.families.udpAddr(c.node).IP, ip); err != nil {
 		return nil, err
 	}
-	if t.netrestrict != nil && !t.netrestrict.Contains(node.IP()) {
+	if t.netrestrict != nil && !t.netrestrict.Contains(ip) {
 		return nil, errors.New("not contained in netrestrict list")
 	}
 	if c.node.UDP() <= 1024 {
@@ -423,6 +452,7 @@ func (t *UDPv5) verifyResponseNode(c *callV5, r *enr.Record, distances []uint, s
 		return nil, fmt.Errorf("duplicate record")
 	}
 	seen[node.ID()] = struct{}{}
+	t.relays.Add(node.ID(), c.node)
 	return node, nil
 }
 
@@ -503,7 +533,7 @@ func (t *UDPv5) dispatch() {
 
 		case ct := <-t.respTimeoutCh:
 			active := t.activeCallByNode[ct.c.node.ID()]
-			if ct.c == active && ct.timer == active.timeout {
+			if ct.c == active && ct.timer == active.timeout && !t.holePunch(active) {
 				ct.c.err <- errTimeout
 			}
 
@@ -586,13 +616,34 @@ func (t *UDPv5) sendCall(c *callV5) {
 		delete(t.activeCallByAuth, c.nonce)
 	}
 
-	addr := &net.UDPAddr{IP: c.node.IP(), Port: c.node.UDP()}
+	addr := t.families.udpAddr(c.node)
 	newNonce, _ := t.send(c.node.ID(), addr, c.packet, c.challenge)
 	c.nonce = newNonce
 	t.activeCallByAuth[newNonce] = c
 	t.startResponseTimeout(c)
 }
 
+// holePunch asks a relay node for help with a call that received no response. The
+// call is resent to keep the local NAT open for the recipient, and the relay forwards
+// the nonce of the call to the recipient. The recipient then answers with WHOAREYOU,
+// which opens its own NAT and continues the call as a handshake. It returns false if
+// hole punching is not possible for the call.
+func (t *UDPv5) holePunch(c *callV5) bool {
+	if c.answered || c.relayed || c.handshakeCount > 0 {
+		return false
+	}
+	v, ok := t.relays.Get(c.node.ID())
+	if !ok {
+		return false
+	}
+	relay := v.(*enode.Node)
+	c.relayed = true
+	t.sendCall(c)
+	req := &v5wire.RelayInit{Initiator: t.Self().Record(), Target: c.node.ID(), Nonce: c.nonce}
+	t.sendResponse(relay.ID(), t.families.udpAddr(relay), req)
+	return true
+}
+
 // sendResponse sends a response packet to the given node.
 // This doesn't trigger a handshake even if no keys are available.
 func (t *UDPv5) sendResponse(toID enode.ID, toAddr *net.UDPAddr, packet v5wire.Packet) error {
@@ -672,7 +723,7 @@ func (t *UDPv5) handleCallResponse(fromID enode.ID, fromAddr *net.UDPAddr, p v5w
 		t.log.Debug(fmt.Sprintf("Unsolicited/late %s response", p.Name()), "id", fromID, "addr", fromAddr)
 		return false
 	}
-	if !fromAddr.IP.Equal(ac.node.IP()) || fromAddr.Port != ac.node.UDP() {
+	if addr := t.families.udpAddr(ac.node); !fromAddr.IP.Equal(addr.IP) || fromAddr.Port != addr.Port {
 		t.log.Debug(fmt.Sprintf("%s from wrong endpoint", p.Name()), "id", fromID, "addr", fromAddr)
 		return false
 	}
@@ -681,6 +732,7 @@ func (t *UDPv5) handleCallResponse(fromID enode.ID, fromAddr *net.UDPAddr, p v5w
 		return false
 	}
 	t.startResponseTimeout(ac)
+	ac.answered = true
 	ac.ch <- p
 	return true
 }
@@ -717,6 +769,20 @@ func (t *UDPv5) handle(p v5wire.Packet, fromID enode.ID, fromAddr *net.UDPAddr)
 		t.handleTalkRequest(p, fromID, fromAddr)
 	case *v5wire.TalkResponse:
 		t.handleCallResponse(fromID, fromAddr, p)
+	case *v5wire.RequestTicket:
+		t.handleRequestTicket(p, fromID, fromAddr)
+	case *v5wire.Ticket:
+		t.handleCallResponse(fromID, fromAddr, p)
+	case *v5wire.Regtopic:
+		t.handleRegtopic(p, fromID, fromAddr)
+	case *v5wire.Regconfirmation:
+		t.handleCallResponse(fromID, fromAddr, p)
+	case *v5wire.TopicQuery:
+		t.handleTopicQuery(p, fromID, fromAddr)
+	case *v5wire.RelayInit:
+		t.handleRelayInit(p, fromID, fromAddr)
+	case *v5wire.Relay:
+		t.handleRelay(p, fromID, fromAddr)
 	}
 }
 
@@ -859,3 +925,54 @@ func (t *UDPv5) handleTalkRequest(p *v5wire.TalkRequest, fromID enode.ID, fromAd
 	resp := &v5wire.TalkResponse{ReqID: p.ReqID, Message: response}
 	t.sendResponse(fromID, fromAddr, resp)
 }
+
+// handleRelayInit forwards a hole punching request to its target. Requests are only
+// relayed to nodes in the local table, and only for initiators which sent the request
+// from the IP address in their record.
+func (t *UDPv5) handleRelayInit(p *v5wire.RelayInit, fromID enode.ID, fromAddr *net.UDPAddr) {
+	initiator, err := enode.New(t.validSchemes, p.Initiator)
+	if err != nil {
+		t.log.Debug("Invalid initiator record in "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	if initiator.ID() != fromID {
+		t.log.Debug("Initiator mismatch in "+p.Name(), "id", fromID, "addr", fromAddr)
+		return
+	}
+	if ip, _, _, ok := t.families.endpoint(initiator); !ok || !ip.Equal(fromAddr.IP) {
+		t.log.Debug("Initiator endpoint mismatch in "+p.Name(), "id", fromID, "addr", fromAddr)
+		return
+	}
+	target := t.tab.getNode(p.Target)
+	if target == nil {
+		t.log.Debug("Unknown target in "+p.Name(), "id", fromID, "addr", fromAddr, "target", p.Target)
+		return
+	}
+	t.sendResponse(target.ID(), t.families.udpAddr(target), &v5wire.Relay{Initiator: p.Initiator, Nonce: p.Nonce})
+}
+
+// handleRelay answers a hole punching request by sending WHOAREYOU to the initiator.
+// Only requests relayed by nodes in the local table are answered.
+func (t *UDPv5) handleRelay(p *v5wire.Relay, fromID enode.ID, fromAddr *net.UDPAddr) {
+	if t.tab.getNode(fromID) == nil {
+		t.log.Debug(p.Name()+" from unknown relay", "id", fromID, "addr", fromAddr)
+		return
+	}
+	initiator, err := enode.New(t.validSchemes, p.Initiator)
+	if err != nil {
+		t.log.Debug("Invalid initiator record in "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	ip, udp, _, ok := t.families.endpoint(initiator)
+	if !ok || udp == 0 {
+		t.log.Debug("Unreachable initiator in "+p.Name(), "id", fromID, "addr", fromAddr)
+		return
+	}
+	if t.netrestrict != nil && !t.netrestrict.Contains(ip) {
+		t.log.Debug("Initiator not in netrestrict list in "+p.Name(), "id", fromID, "addr", fromAddr)
+		return
+	}
+	challenge := &v5wire.Whoareyou{Nonce: p.Nonce, Node: initiator, RecordSeq: initiator.Seq()}
+	crand.Read(challenge.IDNonce[:])
+	t.sendResponse(initiator.ID(), t.families.udpAddr(initiator), challenge)
+}
@@ -631,6 +631,127 @@ func TestUDPv5_PingWithIPV4MappedAddress(t *testing.T) {
 
 // udpV5Test is the framework for all tests above.
 // It runs the UDPv5 transport on a virtual socket and allows testing outgoing packets.
+// This test checks that an unanswered call is retried through a relay, and that the
+// call completes when the target answers the relayed request with WHOAREYOU.
+func TestUDPv5_holePunch(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		relayKey   = newkey()
+		relayAddr  = &net.UDPAddr{IP: net.IP{10, 0, 1, 1}, Port: 30303}
+		relay      = test.getNode(relayKey, relayAddr).Node()
+		target     = test.getNode(test.remotekey, test.remoteaddr).Node()
+		done       = make(chan error, 1)
+		relayNonce v5wire.Nonce
+	)
+	test.udp.relays.Add(target.ID(), relay)
+	go func() {
+		_, err := test.udp.ping(target)
+		done <- err
+	}()
+
+	// The first ping isn't answered, so it is resent and the relay is asked for help.
+	test.waitPacketOut(func(p *v5wire.Ping, addr *net.UDPAddr, _ v5wire.Nonce) {})
+	test.waitPacketOut(func(p *v5wire.Ping, addr *net.UDPAddr, nonce v5wire.Nonce) {
+		relayNonce = nonce
+	})
+	test.waitPacketOut(func(p *v5wire.RelayInit, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !addr.IP.Equal(relayAddr.IP) {
+			t.Errorf("RELAYINIT sent to wrong address %v", addr)
+		}
+		if p.Target != target.ID() {
+			t.Errorf("wrong target %v in RELAYINIT", p.Target)
+		}
+		if p.Nonce != relayNonce {
+			t.Errorf("wrong nonce %x in RELAYINIT, want %x", p.Nonce, relayNonce)
+		}
+		if n, err := enode.New(enode.ValidSchemesForTesting, p.Initiator); err != nil || n.ID() != test.udp.Self().ID() {
+			t.Errorf("wrong initiator record in RELAYINIT")
+		}
+	})
+
+	// The target answers the relayed request, which completes the call.
+	test.packetIn(&v5wire.Whoareyou{Nonce: relayNonce})
+	test.waitPacketOut(func(p *v5wire.Ping, addr *net.UDPAddr, _ v5wire.Nonce) {
+		test.packetIn(&v5wire.Pong{ReqID: p.ReqID})
+	})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// This test checks that RELAYINIT is forwarded to the target.
+func TestUDPv5_relayInitHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		targetKey  = newkey()
+		targetAddr = &net.UDPAddr{IP: net.IP{10, 0, 1, 2}, Port: 30303}
+		target     = test.getNode(targetKey, targetAddr).Node()
+		initiator  = test.getNode(test.remotekey, test.remoteaddr).Node()
+		other      = test.getNode(newkey(), &net.UDPAddr{IP: net.IP{10, 0, 1, 3}, Port: 30303}).Node()
+		nonce      = v5wire.Nonce{1, 2, 3}
+	)
+
+	// These are ignored: the target is unknown, and the initiator record
+	// doesn't belong to the sender.
+	test.packetIn(&v5wire.RelayInit{Initiator: initiator.Record(), Target: target.ID(), Nonce: nonce})
+	test.table.addSeenNode(wrapNode(target))
+	test.packetIn(&v5wire.RelayInit{Initiator: other.Record(), Target: target.ID(), Nonce: nonce})
+
+	test.packetIn(&v5wire.RelayInit{Initiator: initiator.Record(), Target: target.ID(), Nonce: nonce})
+	test.waitPacketOut(func(p *v5wire.Relay, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !addr.IP.Equal(targetAddr.IP) || addr.Port != targetAddr.Port {
+			t.Errorf("RELAYMSG sent to wrong address %v", addr)
+		}
+		if p.Nonce != nonce {
+			t.Errorf("wrong nonce %x in RELAYMSG", p.Nonce)
+		}
+		if n, err := enode.New(enode.ValidSchemesForTesting, p.Initiator); err != nil || n.ID() != initiator.ID() {
+			t.Errorf("wrong initiator record in RELAYMSG")
+		}
+	})
+}
+
+// This test checks that a relayed request is answered with WHOAREYOU.
+func TestUDPv5_relayHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		initiatorKey  = newkey()
+		initiatorAddr = &net.UDPAddr{IP: net.IP{10, 0, 1, 2}, Port: 30303}
+		initiator     = test.getNode(initiatorKey, initiatorAddr).Node()
+		relay         = test.getNode(test.remotekey, test.remoteaddr).Node()
+		nonce         = v5wire.Nonce{1, 2, 3}
+	)
+
+	// This is ignored because the relay isn't known.
+	test.packetIn(&v5wire.Relay{Initiator: initiator.Record(), Nonce: nonce})
+
+	test.table.addSeenNode(wrapNode(relay))
+	test.packetIn(&v5wire.Relay{Initiator: initiator.Record(), Nonce: nonce})
+	test.waitPacketOut(func(p *v5wire.Whoareyou, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !addr.IP.Equal(initiatorAddr.IP) || addr.Port != initiatorAddr.Port {
+			t.Errorf("WHOAREYOU sent to wrong address %v", addr)
+		}
+		if p.Nonce != nonce {
+			t.Errorf("wrong nonce %x in WHOAREYOU", p.Nonce)
+		}
+		if p.IDNonce == ([16]byte{}) {
+			t.Error("all zero ID nonce")
+		}
+		if p.RecordSeq != initiator.Seq() {
+			t.Errorf("wrong record seq %d in WHOAREYOU", p.RecordSeq)
+		}
+	})
+}
+
 type udpV5Test struct {
 	t                   *testing.T
 	pipe                *dgramPipe
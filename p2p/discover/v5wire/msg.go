@@ -47,6 +47,8 @@ const (
 	RegtopicMsg
 	RegconfirmationMsg
 	TopicQueryMsg
+	RelayInitMsg
+	RelayMsg
 
 	UnknownPacket   = byte(255) // any non-decryptable packet
 	WhoareyouPacket = byte(254) // the WHOAREYOU packet
@@ -143,6 +145,21 @@ type (
 		ReqID []byte
 		Topic []byte
 	}
+
+	// RelayInit asks a mutual peer to relay a hole punching request to the target.
+	// Nonce is the nonce of the unanswered request packet sent to the target.
+	RelayInit struct {
+		Initiator *enr.Record
+		Target    enode.ID
+		Nonce     Nonce
+	}
+
+	// Relay is the hole punching request forwarded to the target. The target
+	// answers it by sending WHOAREYOU for the given nonce to the initiator.
+	Relay struct {
+		Initiator *enr.Record
+		Nonce     Nonce
+	}
 )
 
 // DecodeMessage decodes the message body of a packet.
@@ -171,6 +188,10 @@ func DecodeMessage(ptype byte, body []byte) (Packet, error) {
 		dec = new(Regconfirmation)
 	case TopicQueryMsg:
 		dec = new(TopicQuery)
+	case RelayInitMsg:
+		dec = new(RelayInit)
+	case RelayMsg:
+		dec = new(Relay)
 	default:
 		return nil, fmt.Errorf("unknown packet type %d", ptype)
 	}
@@ -247,3 +268,13 @@ func (*TopicQuery) Name() string             { return "TOPICQUERY/v5" }
 func (*TopicQuery) Kind() byte               { return TopicQueryMsg }
 func (p *TopicQuery) RequestID() []byte      { return p.ReqID }
 func (p *TopicQuery) SetRequestID(id []byte) { p.ReqID = id }
+
+func (*RelayInit) Name() string        { return "RELAYINIT/v5" }
+func (*RelayInit) Kind() byte          { return RelayInitMsg }
+func (*RelayInit) RequestID() []byte   { return nil }
+func (*RelayInit) SetRequestID([]byte) {}
+
+func (*Relay) Name() string        { return "RELAYMSG/v5" }
+func (*Relay) Kind() byte          { return RelayMsg }
+func (*Relay) RequestID() []byte   { return nil }
+func (*Relay) SetRequestID([]byte) {}
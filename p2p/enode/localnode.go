@@ -202,8 +202,8 @@ func (ln *LocalNode) SetFallbackIP(ip net.IP) {
 	ln.updateEndpoints()
 }
 
-// SetFallbackUDP sets the last-resort UDP-on-IPv4 port. This port is used
-// if no endpoint prediction can be made.
+// SetFallbackUDP sets the last-resort UDP port of both address families. This
+// port is used if no endpoint prediction can be made.
 func (ln *LocalNode) SetFallbackUDP(port int) {
 	ln.mu.Lock()
 	defer ln.mu.Unlock()
@@ -213,6 +213,16 @@ func (ln *LocalNode) SetFallbackUDP(port int) {
 	ln.updateEndpoints()
 }
 
+// SetFallbackUDP6 sets the last-resort UDP-on-IPv6 port, for nodes listening on
+// a different port for IPv6. This port is used if no endpoint prediction can be made.
+func (ln *LocalNode) SetFallbackUDP6(port int) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	ln.endpoint6.fallbackUDP = uint16(port)
+	ln.updateEndpoints()
+}
+
 // UDPEndpointStatement should be called whenever a statement about the local node's
 // UDP endpoint is received. It feeds the local endpoint predictor.
 func (ln *LocalNode) UDPEndpointStatement(fromaddr, endpoint *net.UDPAddr) {
@@ -233,6 +243,29 @@ func (ln *LocalNode) UDPContact(toaddr *net.UDPAddr) {
 	ln.updateEndpoints()
 }
 
+// NATPrediction describes the NAT in front of the local node, as predicted from the
+// statements of other nodes about the local IPv4 UDP endpoint.
+type NATPrediction struct {
+	Endpoint  *net.UDPAddr // predicted external endpoint, nil if unknown
+	FullCone  bool         // whsdcer uncontacted nodes can reach the endpoint
+	Symmetric bool         // whsdcer the external port differs for every remote node
+}
+
+// PredictNAT returns the current prediction of the NAT type.
+func (ln *LocalNode) PredictNAT() NATPrediction {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	var p NATPrediction
+	track := ln.endpoint4.track
+	if ip, port := predictAddr(track); ip != nil {
+		p.Endpoint = &net.UDPAddr{IP: ip.To4(), Port: int(port)}
+		p.FullCone = track.PredictFullConeNAT()
+	}
+	p.Symmetric = track.PredictSymmetricNAT()
+	return p
+}
+
 // updateEndpoints updates the record with predicted endpoints.
 func (ln *LocalNode) updateEndpoints() {
 	ip4, udp4 := ln.endpoint4.get()
@@ -314,7 +347,11 @@ func (ln *LocalNode) sign() {
 		panic(fmt.Errorf("enode: can't verify local record: %v", err))
 	}
 	ln.cur.Store(n)
-	log.Info("New local node record", "seq", ln.seq, "id", n.ID(), "ip", n.IP(), "udp", n.UDP(), "tcp", n.TCP())
+	ctx := []interface{}{"seq", ln.seq, "id", n.ID(), "ip", n.IP(), "udp", n.UDP(), "tcp", n.TCP()}
+	if ip6 := n.IPv6(); ip6 != nil && n.IPv4() != nil {
+		ctx = append(ctx, "ip6", ip6, "udp6", n.UDP6())
+	}
+	log.Info("New local node record", ctx...)
 }
 
 func (ln *LocalNode) bumpSeq() {
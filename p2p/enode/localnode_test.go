@@ -127,3 +127,70 @@ func TestLocalNodeEndpoint(t *testing.T) {
 	assert.Equal(t, fallback.Port, ln.Node().UDP())
 	assert.Equal(t, initialSeq+3, ln.Node().Seq())
 }
+
+// This test checks that the endpoints of both address families are predicted.
+func TestLocalNodeEndpointDualStack(t *testing.T) {
+	var (
+		predicted4 = &net.UDPAddr{IP: net.IP{127, 0, 1, 2}, Port: 81}
+		predicted6 = &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 82}
+	)
+	ln, db := newLocalNodeForTesting()
+	defer db.Close()
+
+	ln.SetFallbackUDP(80)
+	ln.SetFallbackUDP6(90)
+	assert.Equal(t, 80, ln.Node().UDP())
+	assert.Equal(t, 90, ln.Node().UDP6())
+
+	// Add endpoint statements for both families from random hosts.
+	for i := 0; i < iptrackMinStatements; i++ {
+		from4 := &net.UDPAddr{IP: make(net.IP, 4), Port: 90}
+		rand.Read(from4.IP)
+		ln.UDPEndpointStatement(from4, predicted4)
+
+		from6 := &net.UDPAddr{IP: make(net.IP, 16), Port: 90}
+		rand.Read(from6.IP)
+		ln.UDPEndpointStatement(from6, predicted6)
+	}
+	n := ln.Node()
+	assert.Equal(t, predicted4.IP, n.IPv4())
+	assert.Equal(t, predicted4.Port, n.UDP())
+	assert.Equal(t, predicted6.IP, n.IPv6())
+	assert.Equal(t, predicted6.Port, n.UDP6())
+	assert.Equal(t, predicted4.IP, n.IP())
+
+	// Without a separate IPv6 port, the IPv4 one applies to both.
+	var r enr.Record
+	r.Set(enr.UDP(30303))
+	r.Set(enr.TCP(30304))
+	n = SignNull(&r, ID{})
+	assert.Equal(t, 30303, n.UDP6())
+	assert.Equal(t, 30304, n.TCP6())
+}
+
+func TestLocalNodePredictNAT(t *testing.T) {
+	ln, db := newLocalNodeForTesting()
+	defer db.Close()
+
+	assert.Equal(t, NATPrediction{}, ln.PredictNAT())
+
+	// Statements from contacted hosts with a fixed endpoint.
+	predicted := &net.UDPAddr{IP: net.IP{127, 0, 1, 2}, Port: 81}
+	for i := 0; i < iptrackMinStatements; i++ {
+		from := &net.UDPAddr{IP: make(net.IP, 4), Port: 90}
+		rand.Read(from.IP)
+		ln.UDPContact(from)
+		ln.UDPEndpointStatement(from, predicted)
+	}
+	assert.Equal(t, NATPrediction{Endpoint: predicted}, ln.PredictNAT())
+
+	// Statements with a different port for every host.
+	ln, db = newLocalNodeForTesting()
+	defer db.Close()
+	for i := 0; i < iptrackMinStatements; i++ {
+		from := &net.UDPAddr{IP: make(net.IP, 4), Port: 90}
+		rand.Read(from.IP)
+		ln.UDPEndpointStatement(from, &net.UDPAddr{IP: predicted.IP, Port: 1000 + i})
+	}
+	assert.Equal(t, NATPrediction{Symmetric: true}, ln.PredictNAT())
+}
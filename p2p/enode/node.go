@@ -101,17 +101,28 @@ func (n *Node) Load(k enr.Entry) error {
 
 // IP returns the IP address of the node. This prefers IPv4 addresses.
 func (n *Node) IP() net.IP {
-	var (
-		ip4 enr.IPv4
-		ip6 enr.IPv6
-	)
-	if n.Load(&ip4) == nil {
-		return net.IP(ip4)
+	if ip := n.IPv4(); ip != nil {
+		return ip
 	}
-	if n.Load(&ip6) == nil {
-		return net.IP(ip6)
+	return n.IPv6()
+}
+
+// IPv4 returns the IPv4 address of the node, or nil if it has none.
+func (n *Node) IPv4() net.IP {
+	var ip enr.IPv4
+	if n.Load(&ip) != nil {
+		return nil
 	}
-	return nil
+	return net.IP(ip)
+}
+
+// IPv6 returns the IPv6 address of the node, or nil if it has none.
+func (n *Node) IPv6() net.IP {
+	var ip enr.IPv6
+	if n.Load(&ip) != nil {
+		return nil
+	}
+	return net.IP(ip)
 }
 
 // UDP returns the UDP port of the node.
@@ -128,6 +139,26 @@ func (n *Node) TCP() int {
 	return int(port)
 }
 
+// UDP6 returns the UDP port of the node on IPv6. Records without a separate
+// IPv6 port use the same port on both address families.
+func (n *Node) UDP6() int {
+	var port enr.UDP6
+	if n.Load(&port) != nil {
+		return n.UDP()
+	}
+	return int(port)
+}
+
+// TCP6 returns the TCP port of the node on IPv6. Records without a separate
+// IPv6 port use the same port on both address families.
+func (n *Node) TCP6() int {
+	var port enr.TCP6
+	if n.Load(&port) != nil {
+		return n.TCP()
+	}
+	return int(port)
+}
+
 // Pubkey returns the secp256k1 public key of the node, if present.
 func (n *Node) Pubkey() *ecdsa.PublicKey {
 	var key ecdsa.PublicKey
@@ -40,6 +40,8 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbBanPrefix    = "ban:"    // Identifier to prefix node bans with, keyed by ID only
+	dbNetBanPrefix = "netban:" // Identifier to prefix IP network bans with, keyed by CIDR
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -131,6 +133,11 @@ func newPersistentDB(path string) (*DB, error) {
 	return &DB{lvl: db, quit: make(chan struct{})}, nil
 }
 
+// banKey returns the database key for the ban of a node.
+func banKey(id ID) []byte {
+	return append([]byte(dbBanPrefix), id[:]...)
+}
+
 // nodeKey returns the database key for a node record.
 func nodeKey(id ID) []byte {
 	key := append([]byte(dbNodePrefix), id[:]...)
@@ -315,6 +322,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireBans()
 		case <-db.quit:
 			return
 		}
@@ -360,6 +368,20 @@ func (db *DB) expireNodes() {
 	}
 }
 
+// expireBans deletes all node and network bans which have run out.
+func (db *DB) expireBans() {
+	now := time.Now().Unix()
+	for _, prefix := range []string{dbBanPrefix, dbNetBanPrefix} {
+		it := db.lvl.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+		for it.Next() {
+			if until, _ := binary.Varint(it.Value()); until <= now {
+				db.lvl.Delete(it.Key(), nil)
+			}
+		}
+		it.Release()
+	}
+}
+
 // LastPingReceived retrieves the time of the last ping packet received from
 // a remote node.
 func (db *DB) LastPingReceived(id ID, ip net.IP) time.Time {
@@ -427,6 +449,69 @@ func (db *DB) UpdateFindFailsV5(id ID, ip net.IP, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// BanExpiry retrieves the time until which a node is banned. The zero time is
+// returned if the node was never banned.
+func (db *DB) BanExpiry(id ID) time.Time {
+	until := db.fetchInt64(banKey(id))
+	if until == 0 {
+		return time.Time{}
+	}
+	return time.Unix(until, 0)
+}
+
+// UpdateBan bans a node until the given time. Passing the zero time lifts the ban.
+func (db *DB) UpdateBan(id ID, until time.Time) error {
+	if until.IsZero() {
+		return db.lvl.Delete(banKey(id), nil)
+	}
+	return db.storeInt64(banKey(id), until.Unix())
+}
+
+// Bans retrieves all nodes which are banned at the given time, along with the
+// expiry of their ban.
+func (db *DB) Bans(now time.Time) map[ID]time.Time {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbBanPrefix)), nil)
+	defer it.Release()
+
+	bans := make(map[ID]time.Time)
+	for it.Next() {
+		var id ID
+		if len(it.Key()) != len(dbBanPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(dbBanPrefix):])
+		if until, _ := binary.Varint(it.Value()); until > now.Unix() {
+			bans[id] = time.Unix(until, 0)
+		}
+	}
+	return bans
+}
+
+// UpdateNetBan bans an IP network until the given time. Passing the zero time
+// lifts the ban.
+func (db *DB) UpdateNetBan(network *net.IPNet, until time.Time) error {
+	key := []byte(dbNetBanPrefix + network.String())
+	if until.IsZero() {
+		return db.lvl.Delete(key, nil)
+	}
+	return db.storeInt64(key, until.Unix())
+}
+
+// NetBans retrieves all IP networks which are banned at the given time, along with
+// the expiry of their ban.
+func (db *DB) NetBans(now time.Time) map[string]time.Time {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbNetBanPrefix)), nil)
+	defer it.Release()
+
+	bans := make(map[string]time.Time)
+	for it.Next() {
+		if until, _ := binary.Varint(it.Value()); until > now.Unix() {
+			bans[string(it.Key()[len(dbNetBanPrefix):])] = time.Unix(until, 0)
+		}
+	}
+	return bans
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
@@ -467,3 +467,73 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+// This test checks that node bans are stored, listed and expired.
+func TestDBBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		now     = time.Now()
+		active  = ID{1}
+		expired = ID{2}
+		until   = now.Add(time.Hour).Truncate(time.Second)
+	)
+	db.UpdateBan(active, until)
+	db.UpdateBan(expired, now.Add(-time.Hour))
+
+	if have := db.BanExpiry(active); !have.Equal(until) {
+		t.Errorf("wrong ban expiry: have %v, want %v", have, until)
+	}
+	if have := db.BanExpiry(ID{3}); !have.IsZero() {
+		t.Errorf("unbanned node has ban expiry %v", have)
+	}
+	if bans := db.Bans(now); len(bans) != 1 || !bans[active].Equal(until) {
+		t.Errorf("wrong active bans: %v", bans)
+	}
+
+	// Expired bans are removed, active ones are retained.
+	db.expireBans()
+	if !db.BanExpiry(expired).IsZero() {
+		t.Error("expired ban not removed")
+	}
+	if db.BanExpiry(active).IsZero() {
+		t.Error("active ban removed")
+	}
+	// Lifting the ban removes it.
+	db.UpdateBan(active, time.Time{})
+	if !db.BanExpiry(active).IsZero() {
+		t.Error("ban not lifted")
+	}
+}
+
+// This test checks that IP network bans are stored, listed and expired.
+func TestDBNetBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		now   = time.Now()
+		until = now.Add(time.Hour).Truncate(time.Second)
+	)
+	_, active, _ := net.ParseCIDR("10.0.0.0/8")
+	_, expired, _ := net.ParseCIDR("2001:db8::/32")
+	db.UpdateNetBan(active, until)
+	db.UpdateNetBan(expired, now.Add(-time.Hour))
+	db.UpdateBan(ID{1}, until)
+
+	if bans := db.NetBans(now); len(bans) != 1 || !bans["10.0.0.0/8"].Equal(until) {
+		t.Errorf("wrong active network bans: %v", bans)
+	}
+	db.expireBans()
+	if bans := db.NetBans(now.Add(-2 * time.Hour)); len(bans) != 1 {
+		t.Errorf("expired network ban not removed: %v", bans)
+	}
+	if bans := db.Bans(now); len(bans) != 1 {
+		t.Errorf("network bans listed as node bans: %v", bans)
+	}
+	db.UpdateNetBan(active, time.Time{})
+	if bans := db.NetBans(now); len(bans) != 0 {
+		t.Errorf("network ban not lifted: %v", bans)
+	}
+}
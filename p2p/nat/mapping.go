@@ -0,0 +1,180 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package nat
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+const (
+	mapTimeout  = 10 * time.Minute // lifetime of port mappings
+	mapRenew    = mapTimeout / 2   // renewal interval, well before the mapping expires
+	mapRetryMin = 10 * time.Second // first retry delay after a failed renewal
+)
+
+// NAT types, as reported by the p2p server. They describe how the NAT in front of
+// the local host maps internal endpoints to external ones.
+const (
+	TypeUnknown    = "unknown"    // not enough information
+	TypeNone       = "none"       // host has a public IP address
+	TypeFullCone   = "full-cone"  // any remote host can reach the mapped endpoint
+	TypeRestricted = "restricted" // only contacted hosts can reach the mapped endpoint
+	TypeSymmetric  = "symmetric"  // mapped endpoint differs for every remote host
+)
+
+// Map adds a port mapping on m and keeps it alive until c is closed.
+// This function is typically invoked in its own goroutine.
+func Map(m Interface, c <-chan struct{}, protocol string, extport, intport int, name string) {
+	NewMapping(m, protocol, extport, intport, name).Run(c, nil)
+}
+
+// MapStatus is the state of a port mapping.
+type MapStatus struct {
+	Protocol   string    `json:"protocol"`
+	ExtPort    int       `json:"extPort"`
+	IntPort    int       `json:"intPort"`
+	Mapped     bool      `json:"mapped"`
+	ExternalIP net.IP    `json:"externalIP,omitempty"`
+	Renewed    time.Time `json:"renewed"`         // time of the last successful renewal
+	Failures   int       `json:"failures"`        // number of consecutive failed renewals
+	Error      string    `json:"error,omitempty"` // error of the last failed renewal
+}
+
+// Mapping is a port mapping which is renewed periodically while it is running.
+// Failed renewals are retried with increasing delay, and the mapping is considered
+// lost when it hasn't been renewed within its lifetime.
+type Mapping struct {
+	m        Interface
+	name     string
+	timeout  time.Duration
+	renew    time.Duration
+	retryMin time.Duration
+
+	mu     sync.Mutex
+	status MapStatus
+}
+
+// NewMapping creates a mapping of extport to intport on m. The mapping is added
+// when Run is called.
+func NewMapping(m Interface, protocol string, extport, intport int, name string) *Mapping {
+	return &Mapping{
+		m:        m,
+		name:     name,
+		timeout:  mapTimeout,
+		renew:    mapRenew,
+		retryMin: mapRetryMin,
+		status:   MapStatus{Protocol: protocol, ExtPort: extport, IntPort: intport},
+	}
+}
+
+// Status returns the current state of the mapping.
+func (mp *Mapping) Status() MapStatus {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	return mp.status
+}
+
+// Run adds the mapping and keeps it alive until c is closed. The mapping is deleted
+// when Run returns. If notify is non-nil, it is called whenever the mapping is added
+// or lost, and when the external IP address of the gateway changes.
+func (mp *Mapping) Run(c <-chan struct{}, notify func(MapStatus)) {
+	st := mp.Status()
+	log := log.New("proto", st.Protocol, "extport", st.ExtPort, "intport", st.IntPort, "interface", mp.m)
+	defer func() {
+		log.Debug("Deleting port mapping")
+		mp.m.DeleteMapping(st.Protocol, st.ExtPort, st.IntPort)
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			timer.Reset(mp.update(log, notify))
+		}
+	}
+}
+
+// update renews the mapping and returns the delay until the next renewal.
+func (mp *Mapping) update(log log.Logger, notify func(MapStatus)) time.Duration {
+	st := mp.Status()
+	err := mp.m.AddMapping(st.Protocol, st.ExtPort, st.IntPort, mp.name, mp.timeout)
+	var ip net.IP
+	if err == nil {
+		ip, _ = mp.m.ExternalIP()
+	}
+
+	mp.mu.Lock()
+	prev := mp.status
+	now := time.Now()
+	if err != nil {
+		mp.status.Failures++
+		mp.status.Error = err.Error()
+		if mp.status.Mapped && now.Sub(mp.status.Renewed) >= mp.timeout {
+			mp.status.Mapped = false
+		}
+	} else {
+		mp.status.Mapped = true
+		mp.status.Renewed = now
+		mp.status.Failures = 0
+		mp.status.Error = ""
+		if ip != nil {
+			mp.status.ExternalIP = ip
+		}
+	}
+	st = mp.status
+	mp.mu.Unlock()
+
+	changed := st.Mapped != prev.Mapped || !st.ExternalIP.Equal(prev.ExternalIP)
+	switch {
+	case st.Mapped && !prev.Mapped:
+		log.Info("Mapped network port", "ip", st.ExternalIP)
+	case !st.Mapped && prev.Mapped:
+		log.Warn("Lost port mapping", "failures", st.Failures, "err", err)
+	case st.Mapped && changed:
+		log.Info("External IP address changed", "old", prev.ExternalIP, "new", st.ExternalIP)
+	case err != nil:
+		log.Debug("Couldn't add port mapping", "failures", st.Failures, "err", err)
+	default:
+		log.Trace("Refreshed port mapping")
+	}
+	if changed && notify != nil {
+		notify(st)
+	}
+
+	if st.Failures == 0 {
+		return mp.renew
+	}
+	// Retry failed renewals with exponential backoff, but at least once per
+	// renewal interval.
+	delay := mp.retryMin
+	for i := 1; i < st.Failures && delay < mp.renew; i++ {
+		delay *= 2
+	}
+	if delay > mp.renew {
+		delay = mp.renew
+	}
+	return delay
+}
@@ -25,7 +25,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/sdcereum/go-sdcereum/log"
 	natpmp "github.com/jackpal/go-nat-pmp"
 )
 
@@ -90,41 +89,6 @@ func Parse(spec string) (Interface, error) {
 	}
 }
 
-const (
-	mapTimeout = 10 * time.Minute
-)
-
-// Map adds a port mapping on m and keeps it alive until c is closed.
-// This function is typically invoked in its own goroutine.
-func Map(m Interface, c <-chan struct{}, protocol string, extport, intport int, name string) {
-	log := log.New("proto", protocol, "extport", extport, "intport", intport, "interface", m)
-	refresh := time.NewTimer(mapTimeout)
-	defer func() {
-		refresh.Stop()
-		log.Debug("Deleting port mapping")
-		m.DeleteMapping(protocol, extport, intport)
-	}()
-	if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-		log.Debug("Couldn't add port mapping", "err", err)
-	} else {
-		log.Info("Mapped network port")
-	}
-	for {
-		select {
-		case _, ok := <-c:
-			if !ok {
-				return
-			}
-		case <-refresh.C:
-			log.Trace("Refreshing port mapping")
-			if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-				log.Debug("Couldn't add port mapping", "err", err)
-			}
-			refresh.Reset(mapTimeout)
-		}
-	}
-}
-
 // ExtIP assumes that the local machine is reachable on the given
 // external IP address, and that any required ports were mapped manually.
 // Mapping operations will not return an error but won't actually do anything.
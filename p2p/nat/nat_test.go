@@ -17,7 +17,9 @@
 package nat
 
 import (
+	"errors"
 	"net"
+	"sync"
 	"testing"
 	"time"
 )
@@ -61,3 +63,88 @@ func TestAutoDiscRace(t *testing.T) {
 		}
 	}
 }
+
+type fakeMapper struct {
+	mu      sync.Mutex
+	ip      net.IP
+	err     error
+	deleted bool
+}
+
+func (f *fakeMapper) AddMapping(string, int, int, string, time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+func (f *fakeMapper) DeleteMapping(string, int, int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = true
+	return nil
+}
+
+func (f *fakeMapper) ExternalIP() (net.IP, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ip, nil
+}
+
+func (f *fakeMapper) String() string { return "fake" }
+
+func (f *fakeMapper) set(ip net.IP, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ip, f.err = ip, err
+}
+
+// This test checks that Mapping reports added and lost mappings as well as
+// changes of the external IP address.
+func TestMappingRenewal(t *testing.T) {
+	var (
+		fm     = &fakeMapper{ip: net.IP{1, 2, 3, 4}}
+		mp     = NewMapping(fm, "udp", 30303, 30303, "test")
+		quit   = make(chan struct{})
+		done   = make(chan struct{})
+		notify = make(chan MapStatus, 10)
+	)
+	mp.timeout = 200 * time.Millisecond
+	mp.renew = 20 * time.Millisecond
+	mp.retryMin = 5 * time.Millisecond
+	go func() {
+		mp.Run(quit, func(st MapStatus) { notify <- st })
+		close(done)
+	}()
+
+	next := func() MapStatus {
+		t.Helper()
+		select {
+		case st := <-notify:
+			return st
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for status update")
+			return MapStatus{}
+		}
+	}
+	if st := next(); !st.Mapped || !st.ExternalIP.Equal(net.IP{1, 2, 3, 4}) {
+		t.Fatalf("wrong status after start: %+v", st)
+	}
+	fm.set(net.IP{5, 6, 7, 8}, nil)
+	if st := next(); !st.Mapped || !st.ExternalIP.Equal(net.IP{5, 6, 7, 8}) {
+		t.Fatalf("wrong status after IP change: %+v", st)
+	}
+	fm.set(net.IP{5, 6, 7, 8}, errors.New("gateway gone"))
+	if st := next(); st.Mapped || st.Failures == 0 || st.Error != "gateway gone" {
+		t.Fatalf("wrong status after failure: %+v", st)
+	}
+	fm.set(net.IP{5, 6, 7, 8}, nil)
+	if st := next(); !st.Mapped || st.Failures != 0 || st.Error != "" {
+		t.Fatalf("wrong status after recovery: %+v", st)
+	}
+
+	close(quit)
+	<-done
+	if !fm.deleted {
+		t.Fatal("mapping not deleted")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+	"github.com/sdcereum/go-sdcereum/p2p/nat"
+)
+
+// NATInfo describes the NAT traversal state of the host.
+type NATInfo struct {
+	Mechanism string          `json:"mechanism"`          // configured port mapping mechanism
+	Type      string          `json:"type"`               // NAT type predicted by discovery
+	Endpoint  string          `json:"endpoint,omitempty"` // external UDP endpoint predicted by discovery
+	Mappings  []nat.MapStatus `json:"mappings,omitempty"`
+}
+
+// startNATMapping maps the given port on the NAT device and keeps the mapping alive
+// until the server is stopped.
+func (srv *Server) startNATMapping(protocol string, port int, name string) {
+	m := nat.NewMapping(srv.NAT, protocol, port, port, name)
+	srv.natmaps = append(srv.natmaps, m)
+	srv.loopWG.Add(1)
+	go func() {
+		defer srv.loopWG.Done()
+		m.Run(srv.quit, srv.natMappingChanged)
+	}()
+}
+
+// natMappingChanged updates the local node record when the external IP address
+// reported by the NAT device changes.
+func (srv *Server) natMappingChanged(st nat.MapStatus) {
+	if st.Mapped && st.ExternalIP != nil {
+		srv.localnode.SetStaticIP(st.ExternalIP)
+	}
+}
+
+// natInfo returns the NAT traversal state of the server.
+func (srv *Server) natInfo() NATInfo {
+	info := NATInfo{Mechanism: "none", Type: nat.TypeUnknown}
+	if srv.NAT != nil {
+		info.Mechanism = srv.NAT.String()
+	}
+	for _, m := range srv.natmaps {
+		info.Mappings = append(info.Mappings, m.Status())
+	}
+	if srv.localnode != nil {
+		p := srv.localnode.PredictNAT()
+		info.Type = natType(p, isLocalIP)
+		if p.Endpoint != nil {
+			info.Endpoint = p.Endpoint.String()
+		}
+	}
+	return info
+}
+
+// natType classifies the NAT in front of the host.
+func natType(p enode.NATPrediction, isLocal func(net.IP) bool) string {
+	switch {
+	case p.Symmetric:
+		return nat.TypeSymmetric
+	case p.Endpoint == nil:
+		return nat.TypeUnknown
+	case isLocal(p.Endpoint.IP):
+		return nat.TypeNone
+	case p.FullCone:
+		return nat.TypeFullCone
+	default:
+		return nat.TypeRestricted
+	}
+}
+
+// isLocalIP reports whsdcer ip is assigned to a network interface of the host.
+func isLocalIP(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -17,6 +17,7 @@
 package netutil
 
 import (
+	"net"
 	"time"
 
 	"github.com/sdcereum/go-sdcereum/common/mclock"
@@ -73,6 +74,36 @@ func (it *IPTracker) PredictFullConeNAT() bool {
 	return false
 }
 
+// PredictSymmetricNAT checks whsdcer the local host is behind symmetric NAT, i.e. NAT
+// which maps the local endpoint to a different external port for every remote host. It
+// predicts by checking whsdcer enough statements agree on the IP address, but no
+// endpoint has enough statements.
+func (it *IPTracker) PredictSymmetricNAT() bool {
+	it.gcStatements(it.clock.Now())
+
+	ipCounts := make(map[string]int)
+	endpointCounts := make(map[string]int)
+	for _, s := range it.statements {
+		host, _, err := net.SplitHostPort(s.endpoint)
+		if err != nil {
+			continue
+		}
+		ipCounts[host]++
+		endpointCounts[s.endpoint]++
+	}
+	for _, c := range endpointCounts {
+		if c >= it.minStatements {
+			return false
+		}
+	}
+	for _, c := range ipCounts {
+		if c >= it.minStatements {
+			return true
+		}
+	}
+	return false
+}
+
 // PredictEndpoint returns the current prediction of the external endpoint.
 func (it *IPTracker) PredictEndpoint() string {
 	it.gcStatements(it.clock.Now())
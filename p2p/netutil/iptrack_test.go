@@ -30,6 +30,7 @@ const (
 	opContact
 	opPredict
 	opCheckFullCone
+	opCheckSymmetric
 )
 
 type iptrackTestEvent struct {
@@ -76,6 +77,20 @@ func TestIPTracker(t *testing.T) {
 			{opContact, 3010, "", "127.0.0.4"},
 			{opCheckFullCone, 3500, "true", ""},
 		},
+		"symmetric": {
+			{opStatement, 0, "127.0.0.1:1000", "127.0.0.2"},
+			{opStatement, 10, "127.0.0.1:1001", "127.0.0.3"},
+			{opCheckSymmetric, 20, "false", ""},
+			{opStatement, 30, "127.0.0.1:1002", "127.0.0.4"},
+			{opCheckSymmetric, 40, "true", ""},
+		},
+		"symmetric_2": {
+			{opStatement, 0, "127.0.0.1:1000", "127.0.0.2"},
+			{opStatement, 10, "127.0.0.1:1000", "127.0.0.3"},
+			{opStatement, 20, "127.0.0.1:1001", "127.0.0.4"},
+			{opStatement, 30, "127.0.0.1:1000", "127.0.0.5"},
+			{opCheckSymmetric, 40, "false", ""},
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) { runIPTrackerTest(t, test) })
@@ -105,6 +120,11 @@ func runIPTrackerTest(t *testing.T, evs []iptrackTestEvent) {
 			if pred != ev.ip {
 				t.Errorf("op %d: wrong prediction %s, want %s", i, pred, ev.ip)
 			}
+		case opCheckSymmetric:
+			pred := fmt.Sprintf("%t", it.PredictSymmetricNAT())
+			if pred != ev.ip {
+				t.Errorf("op %d: wrong prediction %s, want %s", i, pred, ev.ip)
+			}
 		}
 	}
 }
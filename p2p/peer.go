@@ -117,9 +117,13 @@ type Peer struct {
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
+
+	rep     *reputation // scores of remote nodes, nil if not run by a Server or test peer
+	evicted bool        // set when disconnecting in favour of a new peer, used by Server.run
 }
 
-// NewPeer returns a peer for testing purposes.
+// NewPeer returns a peer for testing purposes. The peer keeps a score of its own,
+// but is never banned.
 func NewPeer(id enode.ID, name string, caps []Cap) *Peer {
 	// Generate a fake set of local protocols to match as running caps. Almost
 	// no fields needs to be meaningful here as we're only using it to cross-
@@ -133,6 +137,7 @@ func NewPeer(id enode.ID, name string, caps []Cap) *Peer {
 	node := enode.SignNull(new(enr.Record), id)
 	conn := &conn{fd: pipe, transport: nil, node: node, caps: caps, name: name}
 	peer := newPeer(log.Root(), conn, protos)
+	peer.rep = newReputation(nil, mclock.System{})
 	close(peer.closed) // ensures Disconnect doesn't block
 	return peer
 }
@@ -219,6 +224,33 @@ func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x %v", id[:8], p.RemoteAddr())
 }
 
+// Report scores the peer based on an observation about its behaviour. Peers
+// reaching a low enough score are disconnected and banned for a while, unless
+// they are trusted.
+func (p *Peer) Report(sig PeerSignal) {
+	if p.rep == nil {
+		return
+	}
+	score := p.rep.report(p.ID(), sig)
+	p.log.Trace("Scored peer", "signal", sig, "score", score)
+	if score <= banScore && !p.rw.is(trustedConn) {
+		p.log.Debug("Banning peer with low score", "score", score, "duration", banDuration)
+		if err := p.rep.ban(p.ID()); err != nil {
+			p.log.Warn("Failed to store peer ban", "err", err)
+		}
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
+// Score returns the reputation of the peer. Good behaviour raises the score,
+// whereas useless or harmful behaviour lowers it.
+func (p *Peer) Score() float64 {
+	if p.rep == nil {
+		return 0
+	}
+	return p.rep.score(p.ID())
+}
+
 // Inbound returns true if the peer is an inbound connection
 func (p *Peer) Inbound() bool {
 	return p.rw.is(inboundConn)
@@ -443,6 +475,7 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+	limits bandwidthLimiters // bandwidth limits of the protocol, set by Server
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
@@ -454,6 +487,9 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 
 	msg.Code += rw.offset
 
+	if err := rw.limits.waitEgress(msg.Size, rw.closed); err != nil {
+		return err
+	}
 	select {
 	case <-rw.wstart:
 		err = rw.w.WriteMsg(msg)
@@ -472,6 +508,13 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
 		msg.Code -= rw.offset
+
+		// Hold off the protocol handler while over the bandwidth limit. This is
+		// done after dispatching, so the read loop keeps answering pings.
+		if err := rw.limits.waitIngress(msg.Size, rw.closed); err != nil {
+			msg.Discard()
+			return Msg{}, io.EOF
+		}
 		return msg, nil
 	case <-rw.closed:
 		return Msg{}, io.EOF
@@ -487,6 +530,7 @@ type PeerInfo struct {
 	ID      string   `json:"id"`            // Unique node identifier
 	Name    string   `json:"name"`          // Name of the node, including client type, version, OS, custom data
 	Caps    []string `json:"caps"`          // Protocols advertised by this peer
+	Score   float64  `json:"score"`         // Reputation of the peer
 	Network struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
@@ -510,6 +554,7 @@ func (p *Peer) Info() *PeerInfo {
 		ID:        p.ID().String(),
 		Name:      p.Fullname(),
 		Caps:      caps,
+		Score:     p.Score(),
 		Protocols: make(map[string]interface{}),
 	}
 	if p.Node().Seq() > 0 {
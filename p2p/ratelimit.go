@@ -0,0 +1,122 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit configures a bandwidth limit in bytes per second, measured on the
+// payload of subprotocol messages. Zero means unlimited.
+type RateLimit struct {
+	Ingress uint64 `toml:",omitempty"`
+	Egress  uint64 `toml:",omitempty"`
+}
+
+// bandwidthLimiter limits the traffic in both directions using a token bucket each.
+// The buckets hold up to one second worth of traffic, and are nil if unlimited.
+type bandwidthLimiter struct {
+	ingress, egress *rate.Limiter
+	clock           mclock.Clock
+}
+
+// newBandwidthLimiter creates a limiter for the given limits. It returns nil if
+// the traffic is not limited in any direction.
+func newBandwidthLimiter(limit RateLimit, clock mclock.Clock) *bandwidthLimiter {
+	if limit.Ingress == 0 && limit.Egress == 0 {
+		return nil
+	}
+	return &bandwidthLimiter{
+		ingress: newTokenBucket(limit.Ingress),
+		egress:  newTokenBucket(limit.Egress),
+		clock:   clock,
+	}
+}
+
+func newTokenBucket(limit uint64) *rate.Limiter {
+	if limit == 0 {
+		return nil
+	}
+	burst := int(limit)
+	if uint64(burst) != limit || burst < 0 {
+		burst = int(^uint(0) >> 1)
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+// bandwidthLimiters is the set of limiters applying to the traffic of a single
+// protocol connection, e.g. the global, protocol and peer limiters.
+type bandwidthLimiters []*bandwidthLimiter
+
+// waitIngress blocks until n bytes of ingress traffic are allowed by all limiters,
+// or the closed channel is closed.
+func (ls bandwidthLimiters) waitIngress(n uint32, closed <-chan struct{}) error {
+	for _, l := range ls {
+		if err := l.wait(l.ingress, int(n), closed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitEgress blocks until n bytes of egress traffic are allowed by all limiters,
+// or the closed channel is closed.
+func (ls bandwidthLimiters) waitEgress(n uint32, closed <-chan struct{}) error {
+	for _, l := range ls {
+		if err := l.wait(l.egress, int(n), closed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wait takes n tokens from the bucket, waiting for them to become available.
+// Requests exceeding the bucket size are taken in multiple steps.
+func (l *bandwidthLimiter) wait(bucket *rate.Limiter, n int, closed <-chan struct{}) error {
+	if bucket == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := n
+		if burst := bucket.Burst(); chunk > burst {
+			chunk = burst
+		}
+		now := l.now()
+		r := bucket.ReserveN(now, chunk)
+		if delay := r.DelayFrom(now); delay > 0 {
+			timer := l.clock.NewTimer(delay)
+			select {
+			case <-timer.C():
+			case <-closed:
+				timer.Stop()
+				r.CancelAt(l.now())
+				return ErrShuttingDown
+			}
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// now returns the reading of the limiter clock as the time value used by the
+// token buckets.
+func (l *bandwidthLimiter) now() time.Time {
+	return time.Unix(0, int64(l.clock.Now()))
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/log"
+)
+
+func TestBandwidthLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	limits := bandwidthLimiters{newBandwidthLimiter(RateLimit{Egress: 100000}, mclock.System{})}
+
+	// Ingress is unlimited, the egress bucket starts out full and refills at
+	// 100kB/s. Sending 250kB must take about 1.5 seconds.
+	start := time.Now()
+	if err := limits.waitIngress(1000000, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := limits.waitEgress(250000, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 1400*time.Millisecond || elapsed > 3*time.Second {
+		t.Fatalf("wrong wait time %v, want ~1.5s", elapsed)
+	}
+}
+
+func TestBandwidthLimiterClose(t *testing.T) {
+	t.Parallel()
+
+	var (
+		limits = bandwidthLimiters{newBandwidthLimiter(RateLimit{Ingress: 1000}, mclock.System{})}
+		closed = make(chan struct{})
+		errc   = make(chan error)
+	)
+	go func() { errc <- limits.waitIngress(10000, closed) }()
+	close(closed)
+	select {
+	case err := <-errc:
+		if err != ErrShuttingDown {
+			t.Fatalf("wrong error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait not interrupted by close")
+	}
+}
+
+func TestBandwidthLimiterClock(t *testing.T) {
+	var (
+		clock  = new(mclock.Simulated)
+		limits = bandwidthLimiters{newBandwidthLimiter(RateLimit{Ingress: 1000}, clock)}
+		errc   = make(chan error)
+	)
+	// The bucket starts out full, the remaining 500 bytes take half a second.
+	go func() { errc <- limits.waitIngress(1500, nil) }()
+	clock.WaitForTimers(1)
+	clock.Run(499 * time.Millisecond)
+	select {
+	case err := <-errc:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Run(time.Millisecond)
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait not finished after refill")
+	}
+}
+
+// This test checks that pings are answered while a protocol handler is held off
+// by the ingress limit.
+func TestPeerIngressLimitPing(t *testing.T) {
+	var (
+		clock    = new(mclock.Simulated)
+		received = make(chan uint64, 1)
+	)
+	proto := Protocol{
+		Name:   "a",
+		Length: 5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return err
+			}
+			received <- msg.Code
+			return msg.Discard()
+		},
+	}
+	var (
+		fd1, fd2   = net.Pipe()
+		key1, key2 = newkey(), newkey()
+		c1         = &conn{fd: fd1, node: newNode(uintID(1), ""), transport: newTestTransport(&key2.PublicKey, fd1, nil), caps: []Cap{proto.cap()}}
+		c2         = &conn{fd: fd2, node: newNode(uintID(2), ""), transport: newTestTransport(&key1.PublicKey, fd2, &key1.PublicKey), caps: []Cap{proto.cap()}}
+	)
+	defer c2.close(errServerStopped)
+
+	peer := newPeer(log.Root(), c1, []Protocol{proto})
+	peer.running["a"].limits = bandwidthLimiters{newBandwidthLimiter(RateLimit{Ingress: 1000}, clock)}
+	go peer.run()
+
+	// Send a message exceeding the bucket, followed by a ping
+	if err := Send(c2, baseProtocolLength+1, make([]byte, 10000)); err != nil {
+		t.Fatal(err)
+	}
+	pong := make(chan error, 1)
+	go func() {
+		if err := SendItems(c2, pingMsg); err != nil {
+			pong <- err
+			return
+		}
+		pong <- ExpectMsg(c2, pongMsg, nil)
+	}()
+	select {
+	case err := <-pong:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ping not answered while throttled")
+	}
+	select {
+	case <-received:
+		t.Fatal("message delivered before the limit allowed it")
+	default:
+	}
+	// The message is taken from the bucket in one second chunks
+	for i := 0; i < 10; i++ {
+		clock.WaitForTimers(1)
+		clock.Run(time.Second)
+	}
+	select {
+	case code := <-received:
+		if code != 1 {
+			t.Fatalf("wrong message code %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not delivered after refill")
+	}
+}
+
+func TestServerPeerLimiters(t *testing.T) {
+	srv := &Server{Config: Config{
+		RateLimit:          RateLimit{Ingress: 1000},
+		PeerRateLimit:      RateLimit{Egress: 1000},
+		ProtocolRateLimits: map[string]RateLimit{"a": {Egress: 1000}, "b": {}},
+	}}
+	srv.setupRateLimits()
+
+	p := NewPeer(randomID(), "test", []Cap{{"a", 1}, {"b", 1}})
+	srv.peerLimiters(p)
+
+	if n := len(p.running["a"].limits); n != 3 {
+		t.Errorf("protocol a has %d limiters, want 3", n)
+	}
+	if n := len(p.running["b"].limits); n != 2 {
+		t.Errorf("protocol b has %d limiters, want 2", n)
+	}
+	if p.running["a"].limits[2] != p.running["b"].limits[1] {
+		t.Error("peer limiter not shared between protocols")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+)
+
+const (
+	// Scores are kept within these bounds and decay towards zero, halving in the
+	// given amount of time.
+	minScore       = -100
+	maxScore       = 100
+	scoreHalfLife  = 10 * time.Minute
+	maxScoredNodes = 10000
+
+	banScore    = -50       // Peers reaching this score are disconnected and banned
+	banDuration = time.Hour // Time for which low scored peers are banned
+	evictScore  = -10       // Peers below this score are evicted for new ones if full
+	dialScore   = -20       // Nodes below this score are not dialed from discovery
+)
+
+// PeerSignal is an observation about the behaviour of a remote peer, reported by
+// the subprotocols to score the peer.
+type PeerSignal int
+
+const (
+	UselessResponse PeerSignal = iota // Response not containing the requested data
+	RequestTimeout                    // Request not answered in time
+	InvalidBlock                      // Block or header failing validation
+	GoodThroughput                    // Response delivered at a good rate
+)
+
+var peerSignalWeights = [...]float64{
+	UselessResponse: -5,
+	RequestTimeout:  -10,
+	InvalidBlock:    -40,
+	GoodThroughput:  1,
+}
+
+var peerSignalNames = [...]string{
+	UselessResponse: "useless response",
+	RequestTimeout:  "request timeout",
+	InvalidBlock:    "invalid block",
+	GoodThroughput:  "good throughput",
+}
+
+func (s PeerSignal) String() string {
+	if s < 0 || int(s) >= len(peerSignalNames) {
+		return "unknown signal"
+	}
+	return peerSignalNames[s]
+}
+
+// reputation tracks the scores of remote nodes. Scores are kept in memory and
+// survive disconnects, nodes with low scores are put on the ban list.
+type reputation struct {
+	bans  *banList
+	clock mclock.Clock
+
+	mu     sync.Mutex
+	scores map[enode.ID]*nodeScore
+}
+
+// nodeScore is the score of a node at the time of its last update.
+type nodeScore struct {
+	value   float64
+	updated mclock.AbsTime
+}
+
+func newReputation(bans *banList, clock mclock.Clock) *reputation {
+	return &reputation{
+		bans:   bans,
+		clock:  clock,
+		scores: make(map[enode.ID]*nodeScore),
+	}
+}
+
+// score returns the current score of a node.
+func (r *reputation) score(id enode.ID) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s := r.scores[id]; s != nil {
+		return s.decayed(r.clock.Now())
+	}
+	return 0
+}
+
+// report applies a signal to the score of a node, returning the new score.
+func (r *reputation) report(id enode.ID, sig PeerSignal) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	s := r.scores[id]
+	if s == nil {
+		if len(r.scores) >= maxScoredNodes {
+			r.prune(now)
+		}
+		s = new(nodeScore)
+		r.scores[id] = s
+	}
+	s.value = math.Max(minScore, math.Min(maxScore, s.decayed(now)+peerSignalWeights[sig]))
+	s.updated = now
+	return s.value
+}
+
+// prune removes the scores which have decayed to insignificance.
+func (r *reputation) prune(now mclock.AbsTime) {
+	for id, s := range r.scores {
+		if math.Abs(s.decayed(now)) < 1 {
+			delete(r.scores, id)
+		}
+	}
+}
+
+// ban bans a node for banDuration. Reputations without a ban list, as used by
+// test peers, don't ban anyone.
+func (r *reputation) ban(id enode.ID) error {
+	if r.bans == nil {
+		return nil
+	}
+	return r.bans.banNode(id, r.bans.now().Add(banDuration))
+}
+
+// decayed returns the score value at the given time.
+func (s *nodeScore) decayed(now mclock.AbsTime) float64 {
+	elapsed := time.Duration(now - s.updated)
+	if elapsed <= 0 {
+		return s.value
+	}
+	return s.value * math.Exp2(-float64(elapsed)/float64(scoreHalfLife))
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The go-sdcereum Authors
+// This file is part of the go-sdcereum library.
+//
+// The go-sdcereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-sdcereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-sdcereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sdcereum/go-sdcereum/common/mclock"
+	"github.com/sdcereum/go-sdcereum/p2p/enode"
+)
+
+func TestReputationScore(t *testing.T) {
+	var (
+		clock mclock.Simulated
+		db, _ = enode.OpenDB("")
+		rep   = newReputation(newBanList(db, &clock), &clock)
+		id    = enode.ID{1}
+	)
+	defer db.Close()
+
+	if score := rep.score(id); score != 0 {
+		t.Fatalf("unknown node has score %v", score)
+	}
+	for i := 0; i < 10; i++ {
+		rep.report(id, RequestTimeout)
+	}
+	if score := rep.score(id); score != minScore {
+		t.Fatalf("score not capped: have %v, want %v", score, minScore)
+	}
+	// Scores halve every scoreHalfLife.
+	clock.Run(scoreHalfLife)
+	if score := rep.score(id); score != minScore/2 {
+		t.Fatalf("wrong score after decay: have %v, want %v", score, minScore/2)
+	}
+	if score := rep.report(id, GoodThroughput); score != minScore/2+1 {
+		t.Fatalf("wrong score after good behaviour: have %v, want %v", score, minScore/2+1)
+	}
+	// Decayed scores are pruned.
+	clock.Run(10 * scoreHalfLife)
+	rep.prune(clock.Now())
+	if len(rep.scores) != 0 {
+		t.Fatal("decayed score not pruned")
+	}
+}
+
+// This test checks that peers are banned and disconnected when their score
+// drops too low, unless they are trusted.
+func TestPeerReportBan(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+	bans := newBanList(db, mclock.System{})
+	rep := newReputation(bans, mclock.System{})
+
+	for _, trusted := range []bool{false, true} {
+		p := NewPeer(randomID(), "test", nil)
+		p.rep = rep
+		p.rw.set(trustedConn, trusted)
+
+		p.Report(InvalidBlock)
+		if bans.nodeBanned(p.ID()) {
+			t.Fatalf("trusted=%t: banned after single signal", trusted)
+		}
+		p.Report(InvalidBlock)
+		if banned := bans.nodeBanned(p.ID()); banned == trusted {
+			t.Fatalf("trusted=%t: wrong ban state %t", trusted, banned)
+		}
+		if info := p.Info(); info.Score > banScore {
+			t.Fatalf("trusted=%t: wrong score %v in peer info", trusted, info.Score)
+		}
+	}
+}
+
+// This test checks that bans for low scores expire according to the server clock.
+func TestReputationBanExpiry(t *testing.T) {
+	var (
+		clock mclock.Simulated
+		db, _ = enode.OpenDB("")
+		bans  = newBanList(db, &clock)
+		rep   = newReputation(bans, &clock)
+		id    = enode.ID{1}
+	)
+	defer db.Close()
+
+	if err := rep.ban(id); err != nil {
+		t.Fatal("can't ban node:", err)
+	}
+	if !bans.nodeBanned(id) {
+		t.Fatal("node not banned")
+	}
+	clock.Run(banDuration - time.Second)
+	if !bans.nodeBanned(id) {
+		t.Fatal("ban lifted early")
+	}
+	if list := bans.list(); len(list) != 1 || list[0].ID != id.String() {
+		t.Fatalf("wrong ban list: %+v", list)
+	}
+	clock.Run(time.Second)
+	if bans.nodeBanned(id) {
+		t.Fatal("ban not lifted after expiry")
+	}
+	if list := bans.list(); len(list) != 0 {
+		t.Fatalf("expired ban listed: %+v", list)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"sort"
 	"sync"
@@ -85,6 +86,16 @@ type Config struct {
 	// Setting DialRatio to zero defaults it to 3.
 	DialRatio int `toml:",omitempty"`
 
+	// RateLimit caps the total bandwidth used by all peer connections.
+	RateLimit RateLimit `toml:",omitempty"`
+
+	// PeerRateLimit caps the bandwidth used by each peer connection.
+	PeerRateLimit RateLimit `toml:",omitempty"`
+
+	// ProtocolRateLimits caps the bandwidth used by a subprotocol across all peer
+	// connections. It is keyed by protocol name.
+	ProtocolRateLimits map[string]RateLimit `toml:",omitempty"`
+
 	// NoDiscovery can be used to disable the peer discovery mechanism.
 	// Disabling is useful for protocol debugging (manual topology).
 	NoDiscovery bool
@@ -93,6 +104,10 @@ type Config struct {
 	// protocol should be started or not.
 	DiscoveryV5 bool `toml:",omitempty"`
 
+	// DiscoveryTopics are the topics the local node is advertised under on the V5
+	// discovery DHT. Nodes advertising them are also searched as dial candidates.
+	DiscoveryTopics []string `toml:",omitempty"`
+
 	// Name sets the node name of this server.
 	// Use common.MakeName to create a name that follows existing conventions.
 	Name string `toml:"-"`
@@ -183,11 +198,18 @@ type Server struct {
 	log          log.Logger
 
 	nodedb    *enode.DB
+	bans      *banList
+	rep       *reputation
 	localnode *enode.LocalNode
 	ntab      *discover.UDPv4
 	DiscV5    *discover.UDPv5
 	discmix   *enode.FairMix
 	dialsched *dialScheduler
+	natmaps   []*nat.Mapping // port mappings kept alive while the server runs
+
+	// Bandwidth limiters shared by all peers.
+	globalLimiter *bandwidthLimiter
+	protoLimiters map[string]*bandwidthLimiter
 
 	// Channels into the run loop.
 	quit                    chan struct{}
@@ -374,6 +396,75 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// BanNode bans a node until the given time and disconnects it. Banned nodes are
+// neither dialed nor accepted as peers, unless they are trusted.
+func (srv *Server) BanNode(id enode.ID, until time.Time) error {
+	if !srv.isRunning() {
+		return errServerStopped
+	}
+	if err := srv.bans.banNode(id, until); err != nil {
+		return err
+	}
+	srv.disconnectBanned(func(p *Peer) bool { return p.ID() == id })
+	return nil
+}
+
+// UnbanNode lifts the ban of a node.
+func (srv *Server) UnbanNode(id enode.ID) error {
+	if !srv.isRunning() {
+		return errServerStopped
+	}
+	return srv.bans.unbanNode(id)
+}
+
+// BanNetwork bans all IP addresses of a network until the given time and disconnects
+// the peers connected from it. Trusted nodes are exempt from network bans as well.
+func (srv *Server) BanNetwork(network *net.IPNet, until time.Time) error {
+	if !srv.isRunning() {
+		return errServerStopped
+	}
+	if err := srv.bans.banNetwork(network, until); err != nil {
+		return err
+	}
+	srv.disconnectBanned(func(p *Peer) bool { return network.Contains(netutil.AddrIP(p.RemoteAddr())) })
+	return nil
+}
+
+// UnbanNetwork lifts the ban of an IP network. The network has to match a previous
+// call to BanNetwork exactly.
+func (srv *Server) UnbanNetwork(network *net.IPNet) error {
+	if !srv.isRunning() {
+		return errServerStopped
+	}
+	return srv.bans.unbanNetwork(network)
+}
+
+// Bans returns all active node and network bans.
+func (srv *Server) Bans() []Ban {
+	if !srv.isRunning() {
+		return nil
+	}
+	return srv.bans.list()
+}
+
+// disconnectBanned disconnects all peers matching a new ban, except trusted ones.
+func (srv *Server) disconnectBanned(match func(*Peer) bool) {
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		for _, p := range peers {
+			if !p.rw.is(trustedConn) && match(p) {
+				p.Disconnect(DiscUselessPeer)
+			}
+		}
+	})
+}
+
+// isRunning reports whsdcer the server is started.
+func (srv *Server) isRunning() bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.running
+}
+
 // SubscribeEvents subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -412,7 +503,7 @@ func (srv *Server) Stop() {
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
 // messages that were found unprocessable and sent to the unhandled channel by the primary listener.
 type sharedUDPConn struct {
-	*net.UDPConn
+	*discover.DualStackConn
 	unhandled chan discover.ReadPacket
 }
 
@@ -477,6 +568,7 @@ func (srv *Server) Start() (err error) {
 	if err := srv.setupLocalNode(); err != nil {
 		return err
 	}
+	srv.setupRateLimits()
 	if srv.ListenAddr != "" {
 		if err := srv.setupListening(); err != nil {
 			return err
@@ -507,6 +599,8 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.bans = newBanList(db, srv.clock)
+	srv.rep = newReputation(srv.bans, srv.clock)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -536,6 +630,28 @@ func (srv *Server) setupLocalNode() error {
 	return nil
 }
 
+func (srv *Server) setupRateLimits() {
+	srv.globalLimiter = newBandwidthLimiter(srv.RateLimit, srv.clock)
+	srv.protoLimiters = make(map[string]*bandwidthLimiter)
+	for name, limit := range srv.ProtocolRateLimits {
+		if l := newBandwidthLimiter(limit, srv.clock); l != nil {
+			srv.protoLimiters[name] = l
+		}
+	}
+}
+
+// peerLimiters assigns the bandwidth limiters to the protocols of a new peer.
+func (srv *Server) peerLimiters(p *Peer) {
+	peerLimiter := newBandwidthLimiter(srv.PeerRateLimit, srv.clock)
+	for _, proto := range p.running {
+		for _, l := range []*bandwidthLimiter{srv.globalLimiter, srv.protoLimiters[proto.Name], peerLimiter} {
+			if l != nil {
+				proto.limits = append(proto.limits, l)
+			}
+		}
+	}
+}
+
 func (srv *Server) setupDiscovery() error {
 	srv.discmix = enode.NewFairMix(discmixTimeout)
 
@@ -561,26 +677,29 @@ func (srv *Server) setupDiscovery() error {
 		listenAddr = srv.DiscAddr
 	}
 
-	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	conn4, conn6, err := srv.listenUDP(listenAddr)
 	if err != nil {
 		return err
 	}
-	conn, err := net.ListenUDP("udp", addr)
-	if err != nil {
-		return err
+	var sock4, sock6 discover.UDPConn
+	if conn4 != nil {
+		sock4 = conn4
+	}
+	if conn6 != nil {
+		sock6 = conn6
 	}
+	conn := discover.NewDualStackConn(sock4, sock6)
+
 	realaddr := conn.LocalAddr().(*net.UDPAddr)
-	srv.log.Debug("UDP listener up", "addr", realaddr)
-	if srv.NAT != nil {
+	if srv.NAT != nil && conn4 != nil {
 		if !realaddr.IP.IsLoopback() {
-			srv.loopWG.Add(1)
-			go func() {
-				nat.Map(srv.NAT, srv.quit, "udp", realaddr.Port, realaddr.Port, "sdcereum discovery")
-				srv.loopWG.Done()
-			}()
+			srv.startNATMapping("udp", realaddr.Port, "sdcereum discovery")
 		}
 	}
 	srv.localnode.SetFallbackUDP(realaddr.Port)
+	if conn4 != nil && conn6 != nil {
+		srv.localnode.SetFallbackUDP6(conn6.LocalAddr().(*net.UDPAddr).Port)
+	}
 
 	// Discovery V4
 	var unhandled chan discover.ReadPacket
@@ -622,10 +741,53 @@ func (srv *Server) setupDiscovery() error {
 		if err != nil {
 			return err
 		}
+		for _, topic := range srv.DiscoveryTopics {
+			srv.DiscV5.RegisterTopic(topic)
+			srv.discmix.AddSource(srv.DiscV5.TopicNodes(topic))
+		}
 	}
 	return nil
 }
 
+// listenUDP binds the discovery sockets. If the listening address doesn't specify
+// an IP, separate IPv4 and IPv6 sockets are bound, otherwise a single one of the
+// address family of the IP. Failing to bind one of two sockets is tolerated.
+func (srv *Server) listenUDP(listenAddr string) (conn4, conn6 *net.UDPConn, err error) {
+	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case addr.IP == nil:
+		var err4, err6 error
+		conn4, err4 = net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: addr.Port})
+		conn6, err6 = net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6unspecified, Port: addr.Port})
+		switch {
+		case err4 != nil && err6 != nil:
+			return nil, nil, err4
+		case err4 != nil:
+			srv.log.Warn("IPv4 UDP listener failed, using IPv6 only", "err", err4)
+		case err6 != nil:
+			srv.log.Debug("IPv6 UDP listener failed, using IPv4 only", "err", err6)
+		}
+	case addr.IP.To4() != nil:
+		if conn4, err = net.ListenUDP("udp4", addr); err != nil {
+			return nil, nil, err
+		}
+	default:
+		if conn6, err = net.ListenUDP("udp6", addr); err != nil {
+			return nil, nil, err
+		}
+	}
+	if conn4 != nil {
+		srv.log.Debug("UDP listener up", "addr", conn4.LocalAddr())
+	}
+	if conn6 != nil {
+		srv.log.Debug("UDP listener up", "addr", conn6.LocalAddr())
+	}
+	return conn4, conn6, nil
+}
+
 func (srv *Server) setupDialScheduler() {
 	config := dialConfig{
 		self:           srv.localnode.ID(),
@@ -635,6 +797,8 @@ func (srv *Server) setupDialScheduler() {
 		netRestrict:    srv.NetRestrict,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
+		rep:            srv.rep,
+		bans:           srv.bans,
 	}
 	if srv.ntab != nil {
 		config.resolver = srv.ntab
@@ -680,11 +844,7 @@ func (srv *Server) setupListening() error {
 	if tcp, ok := listener.Addr().(*net.TCPAddr); ok {
 		srv.localnode.Set(enr.TCP(tcp.Port))
 		if !tcp.IP.IsLoopback() && srv.NAT != nil {
-			srv.loopWG.Add(1)
-			go func() {
-				nat.Map(srv.NAT, srv.quit, "tcp", tcp.Port, tcp.Port, "sdcereum p2p")
-				srv.loopWG.Done()
-			}()
+			srv.startNATMapping("tcp", tcp.Port, "sdcereum p2p")
 		}
 	}
 
@@ -766,7 +926,13 @@ running:
 			// Its capabilities are known and the remote identity is verified.
 			err := srv.addPeerChecks(peers, inboundCount, c)
 			if err == nil {
-				// The handshakes are done and it passed all checks.
+				// The handshakes are done and it passed all checks. If a
+				// limit is hit, a low scored peer makes room for it.
+				if victim := srv.evictionCandidate(peers, inboundCount, c); victim != nil {
+					srv.log.Debug("Evicting p2p peer", "id", victim.ID(), "score", victim.Score(), "for", c.node.ID())
+					victim.evicted = true
+					victim.Disconnect(DiscTooManyPeers)
+				}
 				p := srv.launchPeer(c)
 				peers[c.node.ID()] = p
 				srv.log.Debug("Adding p2p peer", "peercount", len(peers), "id", p.ID(), "conn", c.flags, "addr", p.RemoteAddr(), "name", p.Name())
@@ -814,17 +980,55 @@ running:
 
 func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount int, c *conn) error {
 	switch {
-	case !c.is(trustedConn) && len(peers) >= srv.MaxPeers:
-		return DiscTooManyPeers
-	case !c.is(trustedConn) && c.is(inboundConn) && inboundCount >= srv.maxInboundConns():
+	case srv.limitsReached(peers, inboundCount, c) && srv.evictionCandidate(peers, inboundCount, c) == nil:
 		return DiscTooManyPeers
 	case peers[c.node.ID()] != nil:
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case !c.is(trustedConn) && srv.bans.nodeBanned(c.node.ID()):
+		return DiscUselessPeer
+	case !c.is(trustedConn) && srv.bans.ipBanned(netutil.AddrIP(c.fd.RemoteAddr())):
+		return DiscUselessPeer
+	default:
+		return nil
+	}
+}
+
+// limitsReached reports whsdcer accepting c would exceed the peer limits.
+func (srv *Server) limitsReached(peers map[enode.ID]*Peer, inboundCount int, c *conn) bool {
+	switch {
+	case c.is(trustedConn):
+		return false
+	case len(peers) >= srv.MaxPeers:
+		return true
 	default:
+		return c.is(inboundConn) && inboundCount >= srv.maxInboundConns()
+	}
+}
+
+// evictionCandidate selects a peer to disconnect in favour of c if the peer limits
+// are reached. Only peers which are neither trusted nor static and whose score is
+// below evictScore are evicted, and only for a node with a better score. If the
+// inbound limit is hit by c, the candidate has to be an inbound peer as well.
+func (srv *Server) evictionCandidate(peers map[enode.ID]*Peer, inboundCount int, c *conn) *Peer {
+	if !srv.limitsReached(peers, inboundCount, c) {
 		return nil
 	}
+	var (
+		inbound    = c.is(inboundConn) && inboundCount >= srv.maxInboundConns()
+		worst      *Peer
+		worstScore = math.Min(evictScore, srv.rep.score(c.node.ID()))
+	)
+	for _, p := range peers {
+		if p.evicted || p.rw.is(trustedConn|staticDialedConn) || (inbound && !p.Inbound()) {
+			continue
+		}
+		if score := p.Score(); score < worstScore {
+			worst, worstScore = p, score
+		}
+	}
+	return worst
 }
 
 func (srv *Server) addPeerChecks(peers map[enode.ID]*Peer, inboundCount int, c *conn) error {
@@ -913,7 +1117,8 @@ func (srv *Server) checkInboundConn(remoteIP net.IP) error {
 	if remoteIP == nil {
 		return nil
 	}
-	// Reject connections that do not match NetRestrict.
+	// Reject connections that do not match NetRestrict. Banned networks are only
+	// checked after the handshake, as trusted nodes are exempt from bans.
 	if srv.NetRestrict != nil && !srv.NetRestrict.Contains(remoteIP) {
 		return fmt.Errorf("not in netrestrict list")
 	}
@@ -1025,6 +1230,8 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.rep = srv.rep
+	srv.peerLimiters(p)
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
@@ -1079,6 +1286,7 @@ type NodeInfo struct {
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
 	ListenAddr string                 `json:"listenAddr"`
+	NAT        NATInfo                `json:"nat"`
 	Protocols  map[string]interface{} `json:"protocols"`
 }
 
@@ -1097,6 +1305,7 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	info.Ports.Discovery = node.UDP()
 	info.Ports.Listener = node.TCP()
 	info.ENR = node.String()
+	info.NAT = srv.natInfo()
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
@@ -32,6 +32,7 @@ import (
 	"github.com/sdcereum/go-sdcereum/log"
 	"github.com/sdcereum/go-sdcereum/p2p/enode"
 	"github.com/sdcereum/go-sdcereum/p2p/enr"
+	"github.com/sdcereum/go-sdcereum/p2p/nat"
 	"github.com/sdcereum/go-sdcereum/p2p/rlpx"
 )
 
@@ -300,6 +301,111 @@ func TestServerAtCap(t *testing.T) {
 	}
 }
 
+// This test checks that low scored peers make room for new ones, and that banned
+// nodes are rejected.
+func TestServerEviction(t *testing.T) {
+	remoteKey := newkey()
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    2,
+			NoDial:      true,
+			NoDiscovery: true,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remoteKey.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+	// Fill up the peer set.
+	for i := 0; i < 2; i++ {
+		if err := srv.checkpoint(newconn(randomID()), srv.checkpointAddPeer); err != nil {
+			t.Fatalf("could not add conn %d: %v", i, err)
+		}
+	}
+	if err := srv.checkpoint(newconn(randomID()), srv.checkpointPostHandshake); err != DiscTooManyPeers {
+		t.Fatal("wrong error for insert with well-behaved peers:", err)
+	}
+
+	// Make one of the peers misbehave. It should be evicted for the next one.
+	var (
+		victim = srv.Peers()[0]
+		events = make(chan *PeerEvent, 10)
+		sub    = srv.SubscribeEvents(events)
+	)
+	defer sub.Unsubscribe()
+	victim.Report(InvalidBlock)
+	if err := srv.checkpoint(newconn(randomID()), srv.checkpointAddPeer); err != nil {
+		t.Fatal("could not add conn after misbehaviour:", err)
+	}
+	timeout := time.After(2 * time.Second)
+	for dropped := false; !dropped; {
+		select {
+		case ev := <-events:
+			dropped = ev.Type == PeerEventTypeDrop && ev.Peer == victim.ID()
+		case <-timeout:
+			t.Fatal("low scored peer not evicted")
+		}
+	}
+
+	// Banned nodes are rejected, even if there is room.
+	banned := randomID()
+	srv.rep.ban(banned)
+	srv.RemovePeer(newNode(srv.Peers()[0].ID(), ""))
+	if err := srv.checkpoint(newconn(banned), srv.checkpointPostHandshake); err != DiscUselessPeer {
+		t.Fatal("wrong error for banned node:", err)
+	}
+}
+
+// This test checks that connections from banned networks are rejected, unless they
+// belong to a trusted node.
+func TestServerBannedNetwork(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    10,
+			NoDial:      true,
+			NoDiscovery: true,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	if err := srv.BanNetwork(network, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("could not ban network: %v", err)
+	}
+	newconn := func(ip string, flags connFlag) *conn {
+		fd, _ := net.Pipe()
+		addr := &net.TCPAddr{IP: net.ParseIP(ip), Port: 30303}
+		node := enode.SignNull(new(enr.Record), randomID())
+		return &conn{fd: &fakeAddrConn{fd, addr}, flags: flags, node: node, cont: make(chan error)}
+	}
+	if err := srv.checkInboundConn(net.ParseIP("10.1.2.3")); err != nil {
+		t.Fatalf("inbound connection rejected before handshake: %v", err)
+	}
+	if err := srv.checkpoint(newconn("10.1.2.3", inboundConn), srv.checkpointPostHandshake); err != DiscUselessPeer {
+		t.Fatal("wrong error for node in banned network:", err)
+	}
+	if err := srv.checkpoint(newconn("10.1.2.3", inboundConn|trustedConn), srv.checkpointPostHandshake); err != nil {
+		t.Fatal("trusted node in banned network rejected:", err)
+	}
+	if err := srv.checkpoint(newconn("192.168.1.1", inboundConn), srv.checkpointPostHandshake); err != nil {
+		t.Fatal("node outside banned network rejected:", err)
+	}
+}
+
 func TestServerPeerLimits(t *testing.T) {
 	srvkey := newkey()
 	clientkey := newkey()
@@ -621,3 +727,26 @@ func syncAddPeer(srv *Server, node *enode.Node) bool {
 		}
 	}
 }
+
+func TestNATType(t *testing.T) {
+	var (
+		public  = &net.UDPAddr{IP: net.IP{203, 0, 113, 1}, Port: 30303}
+		local   = &net.UDPAddr{IP: net.IP{198, 51, 100, 1}, Port: 30303}
+		isLocal = func(ip net.IP) bool { return ip.Equal(local.IP) }
+		tests   = []struct {
+			p    enode.NATPrediction
+			want string
+		}{
+			{enode.NATPrediction{}, nat.TypeUnknown},
+			{enode.NATPrediction{Symmetric: true}, nat.TypeSymmetric},
+			{enode.NATPrediction{Endpoint: local}, nat.TypeNone},
+			{enode.NATPrediction{Endpoint: public, FullCone: true}, nat.TypeFullCone},
+			{enode.NATPrediction{Endpoint: public}, nat.TypeRestricted},
+		}
+	)
+	for _, test := range tests {
+		if typ := natType(test.p, isLocal); typ != test.want {
+			t.Errorf("%+v: got NAT type %q, want %q", test.p, typ, test.want)
+		}
+	}
+}
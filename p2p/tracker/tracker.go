@@ -53,8 +53,9 @@ type request struct {
 	reqCode uint64 // Protocol message code of the request
 	resCode uint64 // Protocol message code of the expected response
 
-	time   time.Time     // Timestamp when the request was made
-	expire *list.Element // Expiration marker to untrack it
+	time    time.Time     // Timestamp when the request was made
+	expire  *list.Element // Expiration marker to untrack it
+	expired func()        // Callback to invoke if the request times out
 }
 
 // Tracker is a pending network request tracker to measure how much time it takes
@@ -87,6 +88,18 @@ func (t *Tracker) Track(peer string, version uint, reqCode uint64, resCode uint6
 	if !metrics.Enabled {
 		return
 	}
+	t.track(peer, version, reqCode, resCode, id, nil)
+}
+
+// TrackExpiry is like Track, but additionally invokes the given callback if the
+// request times out. As the callback is used to hold the remote peer accountable,
+// requests are tracked even if metrics are disabled.
+func (t *Tracker) TrackExpiry(peer string, version uint, reqCode uint64, resCode uint64, id uint64, expired func()) {
+	t.track(peer, version, reqCode, resCode, id, expired)
+}
+
+// track adds a network request to the tracker.
+func (t *Tracker) track(peer string, version uint, reqCode uint64, resCode uint64, id uint64, expired func()) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
@@ -110,6 +123,7 @@ func (t *Tracker) Track(peer string, version uint, reqCode uint64, resCode uint6
 		resCode: resCode,
 		time:    time.Now(),
 		expire:  t.expire.PushBack(id),
+		expired: expired,
 	}
 	g := fmt.Sprintf("%s/%s/%d/%#02x", trackedGaugeName, t.protocol, version, reqCode)
 	metrics.GetOrRegisterGauge(g, nil).Inc(1)
@@ -147,6 +161,10 @@ func (t *Tracker) clean() {
 
 		m := fmt.Sprintf("%s/%s/%d/%#02x", lostMeterName, t.protocol, req.version, req.reqCode)
 		metrics.GetOrRegisterMeter(m, nil).Mark(1)
+
+		if req.expired != nil {
+			go req.expired()
+		}
 	}
 	t.schedule()
 }
@@ -163,15 +181,15 @@ func (t *Tracker) schedule() {
 
 // Fulfil fills a pending request, if any is available, reporting on various metrics.
 func (t *Tracker) Fulfil(peer string, version uint, code uint64, id uint64) {
-	if !metrics.Enabled {
-		return
-	}
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
 	// If it's a non existing request, track as stale response
 	req, ok := t.pending[id]
 	if !ok {
+		if !metrics.Enabled {
+			return
+		}
 		m := fmt.Sprintf("%s/%s/%d/%#02x", staleMeterName, t.protocol, version, code)
 		metrics.GetOrRegisterMeter(m, nil).Mark(1)
 		return
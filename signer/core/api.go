@@ -26,7 +26,9 @@ import (
 	"reflect"
 
 	"github.com/spacedogechain/go-spacedogechain/accounts"
+	"github.com/spacedogechain/go-spacedogechain/accounts/hdwallet"
 	"github.com/spacedogechain/go-spacedogechain/accounts/keystore"
+	"github.com/spacedogechain/go-spacedogechain/accounts/pkcs11wallet"
 	"github.com/spacedogechain/go-spacedogechain/accounts/scwallet"
 	"github.com/spacedogechain/go-spacedogechain/accounts/usbwallet"
 	"github.com/spacedogechain/go-spacedogechain/common"
@@ -42,9 +44,9 @@ const (
 	// numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
 	numberOfAccountsToDerive = 10
 	// ExternalAPIVersion -- see extapi_changelog.md
-	ExternalAPIVersion = "6.1.0"
+	ExternalAPIVersion = "6.4.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "7.0.1"
+	InternalAPIVersion = "7.3.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -65,6 +67,8 @@ type ExternalAPI interface {
 	Version(ctx context.Context) (string, error)
 	// SignGnosisSafeTransaction signs/confirms a gnosis-safe multisig transaction
 	SignGnosisSafeTx(ctx context.Context, signerAddress common.MixedcaseAddress, gnosisTx GnosisSafeTx, methodSelector *string) (*GnosisSafeTx, error)
+	// SignUserOperation signs an ERC-4337 user operation of a smart contract account
+	SignUserOperation(ctx context.Context, signerAddress common.MixedcaseAddress, entryPoint common.Address, userOp UserOperation, methodSelector *string) (*UserOperation, error)
 }
 
 // UIClientAPI specifies what method a UI needs to implement to be able to be used as a
@@ -241,6 +245,8 @@ type (
 		Callinfo    []apitypes.ValidationInfo `json:"call_info"`
 		Hash        hexutil.Bytes             `json:"hash"`
 		Meta        Metadata                  `json:"meta"`
+
+		UserOperation *UserOperationInfo `json:"user_operation,omitempty"`
 	}
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
@@ -280,13 +286,13 @@ var ErrRequestDenied = errors.New("request denied")
 // ksLocation specifies the directory where to store the password protected private
 // key that is generated when a new Account is created.
 // noUSB disables USB support that is required to support hardware devices such as
-// ledger and trezor.
+// ledger and trezor. PKCS#11 tokens and HD wallets are handled regardless.
 func NewSignerAPI(am *accounts.Manager, chainID int64, noUSB bool, ui UIClientAPI, validator Validator, advancedMode bool, credentials storage.Storage) *SignerAPI {
 	if advancedMode {
 		log.Info("Clef is in advanced mode: will warn instead of reject")
 	}
 	signer := &SignerAPI{big.NewInt(chainID), am, ui, validator, !advancedMode, credentials}
-	if !noUSB {
+	if !noUSB || len(am.Backends(reflect.TypeOf(&pkcs11wallet.Hub{}))) > 0 || len(am.Backends(reflect.TypeOf(&hdwallet.Hub{}))) > 0 {
 		signer.startUSBListener()
 	}
 	return signer
@@ -321,18 +327,59 @@ func (api *SignerAPI) openTrezor(url accounts.URL) {
 	}
 }
 
-// startUSBListener starts a listener for USB events, for hardware wallet interaction
+// openWithSecret prompts the user for the PIN or password of a wallet and opens
+// it with the answer.
+func (api *SignerAPI) openWithSecret(url accounts.URL, request UserInputRequest) {
+	resp, err := api.UI.OnInputRequired(request)
+	if err != nil {
+		log.Warn("failed getting wallet secret", "wallet", url, "err", err)
+		return
+	}
+	w, err := api.am.Wallet(url.String())
+	if err != nil {
+		log.Warn("wallet unavailable", "url", url)
+		return
+	}
+	// PKCS#11 tokens lock up after a few wrong PINs, so don't prompt again on failure
+	if err = w.Open(resp.Text); err != nil {
+		log.Warn("failed to open wallet", "wallet", url, "err", err)
+	}
+}
+
+// openWallet opens a wallet, prompting the user in the background for the PIN or
+// password of wallets which need one.
+func (api *SignerAPI) openWallet(wallet accounts.Wallet) error {
+	err := wallet.Open("")
+	switch url := wallet.URL(); err {
+	case usbwallet.ErrTrezorPINNeeded:
+		go api.openTrezor(url)
+	case pkcs11wallet.ErrPINNeeded:
+		go api.openWithSecret(url, UserInputRequest{
+			Prompt:     fmt.Sprintf("User PIN required to open PKCS#11 token %s", url),
+			IsPassword: true,
+			Title:      "PKCS#11 token unlock",
+		})
+	case hdwallet.ErrPasswordNeeded:
+		go api.openWithSecret(url, UserInputRequest{
+			Prompt:     fmt.Sprintf("Password required to open HD wallet %s", url),
+			IsPassword: true,
+			Title:      "HD wallet unlock",
+		})
+	}
+	return err
+}
+
+// startUSBListener starts a listener for USB events, for hardware wallet interaction.
+// It also opens PKCS#11 tokens and HD wallets, prompting the user for their PIN or
+// password.
 func (api *SignerAPI) startUSBListener() {
 	eventCh := make(chan accounts.WalletEvent, 16)
 	am := api.am
 	am.Subscribe(eventCh)
 	// Open any wallets already attached
 	for _, wallet := range am.Wallets() {
-		if err := wallet.Open(""); err != nil {
+		if err := api.openWallet(wallet); err != nil {
 			log.Warn("Failed to open wallet", "url", wallet.URL(), "err", err)
-			if err == usbwallet.ErrTrezorPINNeeded {
-				go api.openTrezor(wallet.URL())
-			}
 		}
 	}
 	go api.derivationLoop(eventCh)
@@ -344,15 +391,17 @@ func (api *SignerAPI) derivationLoop(events chan accounts.WalletEvent) {
 	for event := range events {
 		switch event.Kind {
 		case accounts.WalletArrived:
-			if err := event.Wallet.Open(""); err != nil {
+			if err := api.openWallet(event.Wallet); err != nil {
 				log.Warn("New wallet appeared, failed to open", "url", event.Wallet.URL(), "err", err)
-				if err == usbwallet.ErrTrezorPINNeeded {
-					go api.openTrezor(event.Wallet.URL())
-				}
 			}
 		case accounts.WalletOpened:
 			status, _ := event.Wallet.Status()
 			log.Info("New wallet appeared", "url", event.Wallet.URL(), "status", status)
+
+			// PKCS#11 tokens hold plain keys, there's nothing to derive
+			if event.Wallet.URL().Scheme == pkcs11wallet.Scheme {
+				continue
+			}
 			var derive = func(limit int, next func() accounts.DerivationPath) {
 				// Derive first N accounts, hardcoded for now
 				for i := 0; i < limit; i++ {
@@ -515,6 +564,14 @@ func logDiff(original *SignTxRequest, new *SignTxResponse) bool {
 		modified = true
 		log.Info("Nonce changed by UI", "was", n0, "is", n1)
 	}
+	if l0, l1 := original.Transaction.AccessList, new.Transaction.AccessList; !reflect.DeepEqual(l0, l1) {
+		modified = true
+		log.Info("Access list changed by UI", "was", l0, "is", l1)
+	}
+	if t0, t1 := original.Transaction.Type, new.Transaction.Type; !reflect.DeepEqual(t0, t1) {
+		modified = true
+		log.Info("Transaction type changed by UI", "was", t0, "is", t1)
+	}
 	return modified
 }
 
@@ -587,7 +644,10 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args apitypes.SendTxA
 		return nil, err
 	}
 	// Convert fields into a real transaction
-	var unsignedTx = result.Transaction.ToTransaction()
+	unsignedTx, err := result.Transaction.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
 	// Get the password for the transaction
 	pw, err := api.lookupOrQueryPassword(acc.Address, "Account password",
 		fmt.Sprintf("Please enter the password for account %s", acc.Address.String()))
@@ -640,6 +700,55 @@ func (api *SignerAPI) SignGnosisSafeTx(ctx context.Context, signerAddress common
 	return &gnosisTx, nil
 }
 
+// SignUserOperation signs the hash of an ERC-4337 user operation, to be submitted
+// through the given EntryPoint, with the owner key of the smart contract account.
+// The hash is signed as an Ethereum signed message, as verified by the common
+// accounts, and the operation is returned with the signature filled in.
+func (api *SignerAPI) SignUserOperation(ctx context.Context, signerAddress common.MixedcaseAddress, entryPoint common.Address, userOp UserOperation, methodSelector *string) (*UserOperation, error) {
+	version, err := userOp.EntryPointVersion(entryPoint)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := userOp.Hash(entryPoint, api.chainID)
+	if err != nil {
+		return nil, err
+	}
+	// Do the usual validations, but on the call the EntryPoint makes to the account
+	msgs, err := api.validator.ValidateTransaction(methodSelector, userOp.ArgsForValidation(entryPoint, api.chainID))
+	if err != nil {
+		return nil, err
+	}
+	// If we are in 'rejectMode', then reject rather than show the user warnings
+	if api.rejectMode {
+		if err := msgs.GetWarnings(); err != nil {
+			return nil, err
+		}
+	}
+	sighash, msg := ethereumTextAndHash(hash.Bytes())
+	req := &SignDataRequest{
+		ContentType: apitypes.TextPlain.Mime,
+		Address:     signerAddress,
+		Rawdata:     []byte(msg),
+		Messages:    userOp.Format(entryPoint, version, api.chainID, hash),
+		Callinfo:    msgs.Messages,
+		Hash:        sighash,
+		Meta:        MetadataFromContext(ctx),
+		UserOperation: &UserOperationInfo{
+			EntryPoint: entryPoint,
+			Version:    version,
+			ChainID:    (*hexutil.Big)(api.chainID),
+			Operation:  &userOp,
+		},
+	}
+	signature, err := api.sign(req, true)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	userOp.Signature = signature
+	return &userOp, nil
+}
+
 // Returns the external api version. This method does not require user acceptance. Available methods are
 // available via enumeration anyway, and this info does not contain user-specific data
 func (api *SignerAPI) Version(ctx context.Context) (string, error) {
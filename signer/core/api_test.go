@@ -318,3 +318,50 @@ func TestSignTx(t *testing.T) {
 		t.Error("Expected tx to be modified by UI")
 	}
 }
+
+func TestSignDynamicFeeTx(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := mkTestTx(common.NewMixedcaseAddress(list[0]))
+	tx.GasPrice = nil
+	tx.MaxFeePerGas = (*hexutil.Big)(big.NewInt(3000000000))
+	tx.MaxPriorityFeePerGas = (*hexutil.Big)(big.NewInt(3000000001))
+	tx.AccessList = &types.AccessList{{Address: common.HexToAddress("0x1337"), StorageKeys: []common.Hash{{0x01}}}}
+
+	// A tip above the fee cap is rejected before asking the UI
+	if _, err := api.SignTransaction(context.Background(), tx, nil); err == nil {
+		t.Fatal("Expected error for tip above fee cap")
+	}
+	tx.MaxPriorityFeePerGas = (*hexutil.Big)(big.NewInt(1000000000))
+
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	res, err := api.SignTransaction(context.Background(), tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		t.Fatal(err)
+	}
+	if signed.Type() != types.DynamicFeeTxType {
+		t.Errorf("Expected dynamic fee transaction, got type %d", signed.Type())
+	}
+	if signed.GasFeeCap().Cmp(tx.MaxFeePerGas.ToInt()) != 0 || signed.GasTipCap().Cmp(tx.MaxPriorityFeePerGas.ToInt()) != 0 {
+		t.Errorf("Fee mismatch: have %v/%v, want %v/%v", signed.GasFeeCap(), signed.GasTipCap(), tx.MaxFeePerGas, tx.MaxPriorityFeePerGas)
+	}
+	if al := signed.AccessList(); len(al) != 1 || al.StorageKeys() != 1 {
+		t.Errorf("Access list mismatch: have %v", al)
+	}
+	if signed.ChainId().Cmp(big.NewInt(1337)) != 0 {
+		t.Errorf("Chain id mismatch: have %v, want 1337", signed.ChainId())
+	}
+	if sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), signed); err != nil || sender != list[0] {
+		t.Errorf("Sender mismatch: have %v (%v), want %v", sender, err, list[0])
+	}
+}
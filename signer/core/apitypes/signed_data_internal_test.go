@@ -18,6 +18,7 @@ package apitypes
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -125,6 +126,13 @@ func TestParseInteger(t *testing.T) {
 		{"int32", "-123", big.NewInt(-123)},
 		{"uint32", "0xff", big.NewInt(0xff)},
 		{"int8", "0xffff", nil},
+		{"int8", "127", big.NewInt(127)},
+		{"int8", "128", nil},
+		{"int8", "-128", big.NewInt(-128)},
+		{"int8", "-129", nil},
+		{"uint24", "0xffffff", big.NewInt(0xffffff)},
+		{"uint7", "1", nil},
+		{"int512", "1", nil},
 	} {
 		res, err := parseInteger(tt.t, tt.v)
 		if tt.exp == nil && res == nil {
@@ -143,3 +151,135 @@ func TestParseInteger(t *testing.T) {
 		}
 	}
 }
+
+func TestTypedDataSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		title string
+		want  map[string]string
+	}{
+		{
+			name: "eip-2612 permit",
+			data: `{
+				"types": {
+					"EIP712Domain": [{"name": "name", "type": "string"}, {"name": "verifyingContract", "type": "address"}],
+					"Permit": [
+						{"name": "owner", "type": "address"}, {"name": "spender", "type": "address"},
+						{"name": "value", "type": "uint256"}, {"name": "nonce", "type": "uint256"}, {"name": "deadline", "type": "uint256"}
+					]
+				},
+				"primaryType": "Permit",
+				"domain": {"name": "Token", "verifyingContract": "0x00000000000000000000000000000000000070c0"},
+				"message": {
+					"owner": "0x000000000000000000000000000000000000a11c", "spender": "0x0000000000000000000000000000000000000b0b",
+					"value": "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "nonce": 0, "deadline": 1700000000
+				}
+			}`,
+			title: "Token permit (EIP-2612)",
+			want: map[string]string{
+				"token":    "0x00000000000000000000000000000000000070c0",
+				"spender":  "0x0000000000000000000000000000000000000B0b",
+				"amount":   "unlimited",
+				"deadline": "2023-11-14T22:13:20Z",
+			},
+		},
+		{
+			name: "permit2 allowance",
+			data: `{
+				"types": {
+					"EIP712Domain": [{"name": "name", "type": "string"}],
+					"PermitDetails": [
+						{"name": "token", "type": "address"}, {"name": "amount", "type": "uint160"},
+						{"name": "expiration", "type": "uint48"}, {"name": "nonce", "type": "uint48"}
+					],
+					"PermitSingle": [
+						{"name": "details", "type": "PermitDetails"}, {"name": "spender", "type": "address"}, {"name": "sigDeadline", "type": "uint256"}
+					]
+				},
+				"primaryType": "PermitSingle",
+				"domain": {"name": "Permit2"},
+				"message": {
+					"details": {"token": "0x00000000000000000000000000000000000070c0", "amount": "1000", "expiration": 0, "nonce": 1},
+					"spender": "0x0000000000000000000000000000000000000b0b", "sigDeadline": "1700000000"
+				}
+			}`,
+			title: "Token permit (Permit2)",
+			want: map[string]string{
+				"token":      "0x00000000000000000000000000000000000070c0",
+				"amount":     "1000",
+				"expiration": "1970-01-01T00:00:00Z",
+			},
+		},
+		{
+			name: "seaport order",
+			data: `{
+				"types": {
+					"EIP712Domain": [{"name": "name", "type": "string"}],
+					"OrderComponents": [
+						{"name": "offerer", "type": "address"}, {"name": "offer", "type": "OfferItem[]"},
+						{"name": "consideration", "type": "ConsiderationItem[]"}, {"name": "startTime", "type": "uint256"},
+						{"name": "endTime", "type": "uint256"}
+					],
+					"OfferItem": [
+						{"name": "itemType", "type": "uint8"}, {"name": "token", "type": "address"}, {"name": "identifierOrCriteria", "type": "uint256"},
+						{"name": "startAmount", "type": "uint256"}, {"name": "endAmount", "type": "uint256"}
+					],
+					"ConsiderationItem": [
+						{"name": "itemType", "type": "uint8"}, {"name": "token", "type": "address"}, {"name": "identifierOrCriteria", "type": "uint256"},
+						{"name": "startAmount", "type": "uint256"}, {"name": "endAmount", "type": "uint256"}, {"name": "recipient", "type": "address"}
+					]
+				},
+				"primaryType": "OrderComponents",
+				"domain": {"name": "Seaport"},
+				"message": {
+					"offerer": "0x000000000000000000000000000000000000a11c",
+					"offer": [{"itemType": 2, "token": "0x00000000000000000000000000000000000070c0", "identifierOrCriteria": "7", "startAmount": "1", "endAmount": "1"}],
+					"consideration": [{"itemType": 0, "token": "0x0000000000000000000000000000000000000000", "identifierOrCriteria": "0", "startAmount": "1000", "endAmount": "1000", "recipient": "0x000000000000000000000000000000000000a11c"}],
+					"startTime": 0, "endTime": "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+				}
+			}`,
+			title: "Marketplace order (Seaport)",
+			want: map[string]string{
+				"offer[0]":         "1 ERC721 of 0x00000000000000000000000000000000000070c0 #7",
+				"consideration[0]": "1000 native",
+				"end":              "never",
+			},
+		},
+		{
+			name: "unknown data",
+			data: `{
+				"types": {"EIP712Domain": [{"name": "name", "type": "string"}], "Permit": [{"name": "owner", "type": "address"}]},
+				"primaryType": "Permit",
+				"domain": {"name": "Lookalike"},
+				"message": {"owner": "0x000000000000000000000000000000000000a11c"}
+			}`,
+		},
+	}
+	for _, test := range tests {
+		var typedData TypedData
+		if err := json.Unmarshal([]byte(test.data), &typedData); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		summary := typedData.Summary()
+		if test.title == "" {
+			if summary != nil {
+				t.Errorf("%s: unexpected summary %q", test.name, summary.Name)
+			}
+			continue
+		}
+		if summary == nil || summary.Name != test.title {
+			t.Errorf("%s: wrong summary %+v, want %q", test.name, summary, test.title)
+			continue
+		}
+		entries := make(map[string]string)
+		for _, entry := range summary.Value.([]*NameValueType) {
+			entries[entry.Name] = entry.Value.(string)
+		}
+		for name, want := range test.want {
+			if have := entries[name]; have != want {
+				t.Errorf("%s: %s mismatch: have %q, want %q", test.name, name, have, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apitypes
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+)
+
+// typedDataSummarizer interprets a well-known kind of typed data, returning nil
+// if the data is not of its kind.
+type typedDataSummarizer func(typedData *TypedData) *NameValueType
+
+// knownTypedData are the summarizers of well-known typed data, tried in order.
+var knownTypedData = []typedDataSummarizer{
+	summarizePermit,
+	summarizeDaiPermit,
+	summarizePermit2,
+	summarizeSeaportOrder,
+}
+
+// Summary returns a human readable interpretation of well-known typed data, like
+// token permits and marketplace orders. It returns nil for unknown data.
+func (typedData *TypedData) Summary() *NameValueType {
+	for _, summarize := range knownTypedData {
+		if summary := summarize(typedData); summary != nil {
+			return summary
+		}
+	}
+	return nil
+}
+
+// hasFields reports whether the struct type contains all of the given fields
+// with the given types.
+func (typedData *TypedData) hasFields(typeName string, fields map[string]string) bool {
+	found := 0
+	for _, field := range typedData.Types[typeName] {
+		if typ, ok := fields[field.Name]; ok {
+			if typ != field.Type {
+				return false
+			}
+			found++
+		}
+	}
+	return found == len(fields)
+}
+
+// summaryBuilder collects the entries of a summary, and whether any of the values
+// couldn't be interpreted.
+type summaryBuilder struct {
+	entries []*NameValueType
+	failed  bool
+}
+
+func (b *summaryBuilder) add(name, typ, value string) {
+	b.entries = append(b.entries, &NameValueType{Name: name, Typ: typ, Value: value})
+}
+
+func (b *summaryBuilder) address(name string, value interface{}) {
+	s, ok := value.(string)
+	if !ok || !common.IsHexAddress(s) {
+		b.failed = true
+		return
+	}
+	b.add(name, "address", common.HexToAddress(s).Hex())
+}
+
+func (b *summaryBuilder) integer(value interface{}, typ string) *big.Int {
+	v, err := parseInteger(typ, value)
+	if err != nil {
+		b.failed = true
+		return new(big.Int)
+	}
+	return v
+}
+
+// amount adds a token amount of the given bit size, showing the maximum value as
+// unlimited.
+func (b *summaryBuilder) amount(name string, value interface{}, typ string, bits uint) {
+	v := b.integer(value, typ)
+	if v.Cmp(new(big.Int).Sub(new(big.Int).Lsh(common.Big1, bits), common.Big1)) == 0 {
+		b.add(name, "amount", "unlimited")
+		return
+	}
+	b.add(name, "amount", v.String())
+}
+
+// timestamp adds a unix timestamp, showing zero or implausibly large values as
+// never expiring if zeroIsNever is set.
+func (b *summaryBuilder) timestamp(name string, value interface{}, typ string, zeroIsNever bool) {
+	v := b.integer(value, typ)
+	switch {
+	case zeroIsNever && v.Sign() == 0, !v.IsInt64() || v.Int64() > 253402300799: // Year 9999
+		b.add(name, "time", "never")
+	default:
+		b.add(name, "time", time.Unix(v.Int64(), 0).UTC().Format(time.RFC3339))
+	}
+}
+
+func (b *summaryBuilder) result(title string) *NameValueType {
+	if b.failed {
+		return nil
+	}
+	return &NameValueType{Name: title, Typ: "summary", Value: b.entries}
+}
+
+// summarizePermit interprets EIP-2612 token permits.
+func summarizePermit(typedData *TypedData) *NameValueType {
+	if typedData.PrimaryType != "Permit" || !typedData.hasFields("Permit", map[string]string{
+		"owner": "address", "spender": "address", "value": "uint256", "nonce": "uint256", "deadline": "uint256",
+	}) {
+		return nil
+	}
+	var (
+		b   summaryBuilder
+		msg = typedData.Message
+	)
+	b.add("action", "string", "approve token spending")
+	if typedData.Domain.VerifyingContract != "" {
+		b.address("token", typedData.Domain.VerifyingContract)
+	}
+	b.address("owner", msg["owner"])
+	b.address("spender", msg["spender"])
+	b.amount("amount", msg["value"], "uint256", 256)
+	b.timestamp("deadline", msg["deadline"], "uint256", false)
+	return b.result("Token permit (EIP-2612)")
+}
+
+// summarizeDaiPermit interprets the permits of DAI-style tokens, which approve or
+// revoke unlimited spending.
+func summarizeDaiPermit(typedData *TypedData) *NameValueType {
+	if typedData.PrimaryType != "Permit" || !typedData.hasFields("Permit", map[string]string{
+		"holder": "address", "spender": "address", "nonce": "uint256", "expiry": "uint256", "allowed": "bool",
+	}) {
+		return nil
+	}
+	var (
+		b   summaryBuilder
+		msg = typedData.Message
+	)
+	allowed, ok := msg["allowed"].(bool)
+	if !ok {
+		return nil
+	}
+	if allowed {
+		b.add("action", "string", "approve unlimited token spending")
+	} else {
+		b.add("action", "string", "revoke token spending")
+	}
+	if typedData.Domain.VerifyingContract != "" {
+		b.address("token", typedData.Domain.VerifyingContract)
+	}
+	b.address("owner", msg["holder"])
+	b.address("spender", msg["spender"])
+	b.timestamp("deadline", msg["expiry"], "uint256", true)
+	return b.result("Token permit (DAI)")
+}
+
+// summarizePermit2 interprets single token allowances of the Permit2 contract.
+func summarizePermit2(typedData *TypedData) *NameValueType {
+	if typedData.PrimaryType != "PermitSingle" ||
+		!typedData.hasFields("PermitSingle", map[string]string{
+			"details": "PermitDetails", "spender": "address", "sigDeadline": "uint256",
+		}) ||
+		!typedData.hasFields("PermitDetails", map[string]string{
+			"token": "address", "amount": "uint160", "expiration": "uint48", "nonce": "uint48",
+		}) {
+		return nil
+	}
+	var (
+		b   summaryBuilder
+		msg = typedData.Message
+	)
+	details, ok := msg["details"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	b.add("action", "string", "approve token spending")
+	b.address("token", details["token"])
+	b.address("spender", msg["spender"])
+	b.amount("amount", details["amount"], "uint160", 160)
+	b.timestamp("expiration", details["expiration"], "uint48", false)
+	b.timestamp("deadline", msg["sigDeadline"], "uint256", false)
+	return b.result("Token permit (Permit2)")
+}
+
+// seaportItemTypes are the names of the item types of Seaport orders.
+var seaportItemTypes = []string{"native", "ERC20", "ERC721", "ERC1155", "ERC721 (criteria)", "ERC1155 (criteria)"}
+
+// summarizeSeaportOrder interprets Seaport marketplace orders, listing the items
+// offered and the items the offerer receives in return.
+func summarizeSeaportOrder(typedData *TypedData) *NameValueType {
+	if typedData.PrimaryType != "OrderComponents" ||
+		!typedData.hasFields("OrderComponents", map[string]string{
+			"offerer": "address", "offer": "OfferItem[]", "consideration": "ConsiderationItem[]",
+			"startTime": "uint256", "endTime": "uint256",
+		}) {
+		return nil
+	}
+	item := map[string]string{
+		"itemType": "uint8", "token": "address", "identifierOrCriteria": "uint256", "startAmount": "uint256", "endAmount": "uint256",
+	}
+	if !typedData.hasFields("OfferItem", item) || !typedData.hasFields("ConsiderationItem", item) {
+		return nil
+	}
+	var (
+		b   summaryBuilder
+		msg = typedData.Message
+	)
+	b.add("action", "string", "create marketplace order")
+	b.address("offerer", msg["offerer"])
+
+	describe := func(name string, items interface{}) {
+		list, ok := items.([]interface{})
+		if !ok {
+			b.failed = true
+			return
+		}
+		for i, raw := range list {
+			item, ok := raw.(map[string]interface{})
+			if !ok {
+				b.failed = true
+				return
+			}
+			kind := b.integer(item["itemType"], "uint8")
+			if !kind.IsInt64() || kind.Int64() >= int64(len(seaportItemTypes)) {
+				b.failed = true
+				return
+			}
+			amount := b.integer(item["startAmount"], "uint256").String()
+			if end := b.integer(item["endAmount"], "uint256").String(); end != amount {
+				amount += " to " + end
+			}
+			desc := fmt.Sprintf("%s %s", amount, seaportItemTypes[kind.Int64()])
+			if kind.Int64() != 0 {
+				token, _ := item["token"].(string)
+				desc += " of " + common.HexToAddress(token).Hex()
+			}
+			if kind.Int64() >= 2 {
+				desc += fmt.Sprintf(" #%v", b.integer(item["identifierOrCriteria"], "uint256"))
+			}
+			b.add(fmt.Sprintf("%s[%d]", name, i), "item", desc)
+		}
+	}
+	describe("offer", msg["offer"])
+	describe("consideration", msg["consideration"])
+	b.timestamp("start", msg["startTime"], "uint256", false)
+	b.timestamp("end", msg["endTime"], "uint256", false)
+	return b.result("Marketplace order (Seaport)")
+}
@@ -38,7 +38,10 @@ import (
 	"github.com/spacedogechain/go-spacedogechain/crypto"
 )
 
-var typedDataReferenceTypeRegexp = regexp.MustCompile(`^[A-Z](\w*)(\[\])?$`)
+var (
+	typedDataReferenceTypeRegexp = regexp.MustCompile(`^[A-Z](\w*)(\[\d*\])*$`)
+	typedDataIdentifierRegexp    = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+)
 
 type ValidationInfo struct {
 	Typ     string `json:"type"`
@@ -100,6 +103,10 @@ type SendTxArgs struct {
 	// For non-legacy transactions
 	AccessList *types.AccessList `json:"accessList,omitempty"`
 	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
+	// Type optionally requests a specific transaction type, which is otherwise
+	// derived from the fields present
+	Type *hexutil.Uint64 `json:"type,omitempty"`
 }
 
 func (args SendTxArgs) String() string {
@@ -110,8 +117,53 @@ func (args SendTxArgs) String() string {
 	return err.Error()
 }
 
-// ToTransaction converts the arguments to a transaction.
-func (args *SendTxArgs) ToTransaction() *types.Transaction {
+// TxType returns the type of the transaction described by the arguments. Unless
+// requested explicitly, the type is derived from the fields present: fee caps
+// imply a dynamic fee transaction and an access list an access list transaction.
+//
+// An error is returned if the requested type is unsupported or doesn't match the
+// fields present.
+func (args *SendTxArgs) TxType() (byte, error) {
+	dynamic := args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil
+	if args.Type == nil {
+		switch {
+		case dynamic:
+			return types.DynamicFeeTxType, nil
+		case args.AccessList != nil:
+			return types.AccessListTxType, nil
+		default:
+			return types.LegacyTxType, nil
+		}
+	}
+	switch typ := uint64(*args.Type); typ {
+	case types.LegacyTxType:
+		if dynamic {
+			return 0, errors.New("legacy transaction with 'maxFeePerGas' or 'maxPriorityFeePerGas'")
+		}
+		if args.AccessList != nil {
+			return 0, errors.New("legacy transaction with 'accessList'")
+		}
+	case types.AccessListTxType:
+		if dynamic {
+			return 0, errors.New("access list transaction with 'maxFeePerGas' or 'maxPriorityFeePerGas'")
+		}
+	case types.DynamicFeeTxType:
+		if args.GasPrice != nil {
+			return 0, errors.New("dynamic fee transaction with 'gasPrice'")
+		}
+	default:
+		return 0, fmt.Errorf("unsupported transaction type %d", typ)
+	}
+	return byte(*args.Type), nil
+}
+
+// ToTransaction converts the arguments to a transaction of the type returned by
+// TxType.
+func (args *SendTxArgs) ToTransaction() (*types.Transaction, error) {
+	typ, err := args.TxType()
+	if err != nil {
+		return nil, err
+	}
 	// Add the To-field, if specified
 	var to *common.Address
 	if args.To != nil {
@@ -126,13 +178,13 @@ func (args *SendTxArgs) ToTransaction() *types.Transaction {
 		input = *args.Data
 	}
 
+	al := types.AccessList{}
+	if args.AccessList != nil {
+		al = *args.AccessList
+	}
 	var data types.TxData
-	switch {
-	case args.MaxFeePerGas != nil:
-		al := types.AccessList{}
-		if args.AccessList != nil {
-			al = *args.AccessList
-		}
+	switch typ {
+	case types.DynamicFeeTxType:
 		data = &types.DynamicFeeTx{
 			To:         to,
 			ChainID:    (*big.Int)(args.ChainID),
@@ -144,7 +196,7 @@ func (args *SendTxArgs) ToTransaction() *types.Transaction {
 			Data:       input,
 			AccessList: al,
 		}
-	case args.AccessList != nil:
+	case types.AccessListTxType:
 		data = &types.AccessListTx{
 			To:         to,
 			ChainID:    (*big.Int)(args.ChainID),
@@ -153,7 +205,7 @@ func (args *SendTxArgs) ToTransaction() *types.Transaction {
 			GasPrice:   (*big.Int)(args.GasPrice),
 			Value:      (*big.Int)(&args.Value),
 			Data:       input,
-			AccessList: *args.AccessList,
+			AccessList: al,
 		}
 	default:
 		data = &types.LegacyTx{
@@ -165,7 +217,7 @@ func (args *SendTxArgs) ToTransaction() *types.Transaction {
 			Data:     input,
 		}
 	}
-	return types.NewTx(data)
+	return types.NewTx(data), nil
 }
 
 type SigFormat struct {
@@ -212,16 +264,40 @@ type Type struct {
 }
 
 func (t *Type) isArray() bool {
-	return strings.HasSuffix(t.Type, "[]")
+	return strings.HasSuffix(t.Type, "]")
 }
 
-// typeName returns the canonical name of the type. If the type is 'Person[]', then
-// this method returns 'Person'
+// typeName returns the canonical name of the type. If the type is 'Person[]' or
+// 'Person[2][]', then this method returns 'Person'
 func (t *Type) typeName() string {
-	if strings.HasSuffix(t.Type, "[]") {
-		return strings.TrimSuffix(t.Type, "[]")
+	return baseTypeName(t.Type)
+}
+
+// baseTypeName strips all array dimensions from a type.
+func baseTypeName(typ string) string {
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// parseArrayType splits the outermost dimension off an array type, returning the
+// type of the elements and the length of the array. Dynamic arrays have a length
+// of -1, e.g. 'Person[2][]' is a dynamic array of 'Person[2]' elements.
+func parseArrayType(typ string) (string, int, error) {
+	open := strings.LastIndexByte(typ, '[')
+	if open <= 0 || !strings.HasSuffix(typ, "]") {
+		return "", 0, fmt.Errorf("invalid array type '%s'", typ)
+	}
+	elem, size := typ[:open], typ[open+1:len(typ)-1]
+	if size == "" {
+		return elem, -1, nil
 	}
-	return t.Type
+	length, err := strconv.Atoi(size)
+	if err != nil || length <= 0 || size[0] == '0' {
+		return "", 0, fmt.Errorf("invalid array size in type '%s'", typ)
+	}
+	return elem, length, nil
 }
 
 func (t *Type) isReferenceType() bool {
@@ -251,6 +327,42 @@ type TypedDataDomain struct {
 	Salt              string                `json:"salt"`
 }
 
+// UnmarshalJSON decodes a domain, accepting the chain id as either a number or
+// a (hex or decimal) string, since both are common in the wild.
+func (domain *TypedDataDomain) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		Name              string          `json:"name"`
+		Version           string          `json:"version"`
+		ChainId           json.RawMessage `json:"chainId"`
+		VerifyingContract string          `json:"verifyingContract"`
+		Salt              string          `json:"salt"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*domain = TypedDataDomain{
+		Name:              dec.Name,
+		Version:           dec.Version,
+		VerifyingContract: dec.VerifyingContract,
+		Salt:              dec.Salt,
+	}
+	if len(dec.ChainId) == 0 || string(dec.ChainId) == "null" {
+		return nil
+	}
+	text := string(dec.ChainId)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(dec.ChainId, &text); err != nil {
+			return err
+		}
+	}
+	chainId, ok := math.ParseBig256(text)
+	if !ok {
+		return fmt.Errorf("invalid domain chainId %s", dec.ChainId)
+	}
+	domain.ChainId = (*math.HexOrDecimal256)(chainId)
+	return nil
+}
+
 // TypedDataAndHash is a helper function that calculates a hash for typed data conforming to EIP-712.
 // This hash can then be safely used to calculate a signature.
 //
@@ -281,7 +393,7 @@ func (typedData *TypedData) HashStruct(primaryType string, data TypedDataMessage
 
 // Dependencies returns an array of custom types ordered by their hierarchical reference tree
 func (typedData *TypedData) Dependencies(primaryType string, found []string) []string {
-	primaryType = strings.TrimSuffix(primaryType, "[]")
+	primaryType = baseTypeName(primaryType)
 	includes := func(arr []string, str string) bool {
 		for _, obj := range arr {
 			if obj == str {
@@ -326,13 +438,14 @@ func (typedData *TypedData) EncodeType(primaryType string) hexutil.Bytes {
 	for _, dep := range deps {
 		buffer.WriteString(dep)
 		buffer.WriteString("(")
-		for _, obj := range typedData.Types[dep] {
+		for i, obj := range typedData.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
 			buffer.WriteString(obj.Type)
 			buffer.WriteString(" ")
 			buffer.WriteString(obj.Name)
-			buffer.WriteString(",")
 		}
-		buffer.Truncate(buffer.Len() - 1)
 		buffer.WriteString(")")
 	}
 	return buffer.Bytes()
@@ -354,66 +467,80 @@ func (typedData *TypedData) EncodeData(primaryType string, data map[string]inter
 
 	buffer := bytes.Buffer{}
 
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type '%s'", primaryType)
+	}
 	// Verify extra data
-	if exp, got := len(typedData.Types[primaryType]), len(data); exp < got {
+	if exp, got := len(fields), len(data); exp < got {
 		return nil, fmt.Errorf("there is extra data provided in the message (%d < %d)", exp, got)
 	}
+	for name := range data {
+		if !typedData.Types.hasField(primaryType, name) {
+			return nil, fmt.Errorf("there is extra data provided in the message: '%s' is not a field of '%s'", name, primaryType)
+		}
+	}
 
 	// Add typehash
 	buffer.Write(typedData.TypeHash(primaryType))
 
 	// Add field contents. Structs and arrays have special handlers.
-	for _, field := range typedData.Types[primaryType] {
-		encType := field.Type
-		encValue := data[field.Name]
-		if encType[len(encType)-1:] == "]" {
-			arrayValue, ok := encValue.([]interface{})
-			if !ok {
-				return nil, dataMismatchError(encType, encValue)
-			}
+	for _, field := range fields {
+		encoded, err := typedData.encodeValue(field.Type, data[field.Name], depth)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
 
-			arrayBuffer := bytes.Buffer{}
-			parsedType := strings.Split(encType, "[")[0]
-			for _, item := range arrayValue {
-				if typedData.Types[parsedType] != nil {
-					mapValue, ok := item.(map[string]interface{})
-					if !ok {
-						return nil, dataMismatchError(parsedType, item)
-					}
-					encodedData, err := typedData.EncodeData(parsedType, mapValue, depth+1)
-					if err != nil {
-						return nil, err
-					}
-					arrayBuffer.Write(crypto.Keccak256(encodedData))
-				} else {
-					bytesValue, err := typedData.EncodePrimitiveValue(parsedType, item, depth)
-					if err != nil {
-						return nil, err
-					}
-					arrayBuffer.Write(bytesValue)
-				}
-			}
+// encodeValue encodes a value of any type into its 32 byte representation. Structs
+// are encoded as their hashStruct, arrays as the hash of their encoded items.
+func (typedData *TypedData) encodeValue(encType string, encValue interface{}, depth int) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(encType, "]"):
+		return typedData.encodeArrayValue(encType, encValue, depth)
 
-			buffer.Write(crypto.Keccak256(arrayBuffer.Bytes()))
-		} else if typedData.Types[field.Type] != nil {
-			mapValue, ok := encValue.(map[string]interface{})
-			if !ok {
-				return nil, dataMismatchError(encType, encValue)
-			}
-			encodedData, err := typedData.EncodeData(field.Type, mapValue, depth+1)
-			if err != nil {
-				return nil, err
-			}
-			buffer.Write(crypto.Keccak256(encodedData))
-		} else {
-			byteValue, err := typedData.EncodePrimitiveValue(encType, encValue, depth)
-			if err != nil {
-				return nil, err
-			}
-			buffer.Write(byteValue)
+	case typedData.Types[encType] != nil:
+		mapValue, ok := encValue.(map[string]interface{})
+		if !ok {
+			return nil, dataMismatchError(encType, encValue)
+		}
+		encodedData, err := typedData.EncodeData(encType, mapValue, depth+1)
+		if err != nil {
+			return nil, err
 		}
+		return crypto.Keccak256(encodedData), nil
+
+	default:
+		return typedData.EncodePrimitiveValue(encType, encValue, depth)
 	}
-	return buffer.Bytes(), nil
+}
+
+// encodeArrayValue encodes a (possibly multi-dimensional) array as the keccak256
+// hash of the concatenated encodings of its items.
+func (typedData *TypedData) encodeArrayValue(encType string, encValue interface{}, depth int) ([]byte, error) {
+	arrayValue, ok := encValue.([]interface{})
+	if !ok {
+		return nil, dataMismatchError(encType, encValue)
+	}
+	elemType, length, err := parseArrayType(encType)
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 && len(arrayValue) != length {
+		return nil, fmt.Errorf("provided array of %d items doesn't match type '%s'", len(arrayValue), encType)
+	}
+	arrayBuffer := bytes.Buffer{}
+	for _, item := range arrayValue {
+		encoded, err := typedData.encodeValue(elemType, item, depth)
+		if err != nil {
+			return nil, err
+		}
+		arrayBuffer.Write(encoded)
+	}
+	return crypto.Keccak256(arrayBuffer.Bytes()), nil
 }
 
 // Attempt to parse bytes in different formats: byte array, hex string, hexutil.Bytes.
@@ -450,7 +577,7 @@ func parseInteger(encType string, encValue interface{}) (*big.Int, error) {
 			lengthStr = strings.TrimPrefix(encType, "int")
 		}
 		atoiSize, err := strconv.Atoi(lengthStr)
-		if err != nil {
+		if err != nil || atoiSize <= 0 || atoiSize > 256 || atoiSize%8 != 0 {
 			return nil, fmt.Errorf("invalid size on integer: %v", lengthStr)
 		}
 		length = atoiSize
@@ -476,12 +603,18 @@ func parseInteger(encType string, encValue interface{}) (*big.Int, error) {
 	if b == nil {
 		return nil, fmt.Errorf("invalid integer value %v/%v for type %v", encValue, reflect.TypeOf(encValue), encType)
 	}
-	if b.BitLen() > length {
-		return nil, fmt.Errorf("integer larger than '%v'", encType)
-	}
 	if !signed && b.Sign() == -1 {
 		return nil, fmt.Errorf("invalid negative value for unsigned type %v", encType)
 	}
+	if signed {
+		// Signed integers range from -2^(length-1) to 2^(length-1)-1
+		limit := new(big.Int).Lsh(common.Big1, uint(length-1))
+		if b.Cmp(limit) >= 0 || b.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("integer larger than '%v'", encType)
+		}
+	} else if b.BitLen() > length {
+		return nil, fmt.Errorf("integer larger than '%v'", encType)
+	}
 	return b, nil
 }
 
@@ -576,7 +709,8 @@ func (typedData *TypedData) Map() map[string]interface{} {
 }
 
 // Format returns a representation of typedData, which can be easily displayed by a user-interface
-// without in-depth knowledge about 712 rules
+// without in-depth knowledge about 712 rules. Well-known data, like token permits, is preceded by
+// a summary of its meaning.
 func (typedData *TypedData) Format() ([]*NameValueType, error) {
 	domain, err := typedData.formatData("EIP712Domain", typedData.Domain.Map())
 	if err != nil {
@@ -587,6 +721,9 @@ func (typedData *TypedData) Format() ([]*NameValueType, error) {
 		return nil, err
 	}
 	var nvts []*NameValueType
+	if summary := typedData.Summary(); summary != nil {
+		nvts = append(nvts, summary)
+	}
 	nvts = append(nvts, &NameValueType{
 		Name:  "EIP712Domain",
 		Value: domain,
@@ -612,24 +749,11 @@ func (typedData *TypedData) formatData(primaryType string, data map[string]inter
 			Typ:  field.Type,
 		}
 		if field.isArray() {
-			arrayValue, _ := encValue.([]interface{})
-			parsedType := field.typeName()
-			for _, v := range arrayValue {
-				if typedData.Types[parsedType] != nil {
-					mapValue, _ := v.(map[string]interface{})
-					mapOutput, err := typedData.formatData(parsedType, mapValue)
-					if err != nil {
-						return nil, err
-					}
-					item.Value = mapOutput
-				} else {
-					primitiveOutput, err := formatPrimitiveValue(field.Type, encValue)
-					if err != nil {
-						return nil, err
-					}
-					item.Value = primitiveOutput
-				}
+			arrayOutput, err := typedData.formatArray(field.Type, encValue)
+			if err != nil {
+				return nil, err
 			}
+			item.Value = arrayOutput
 		} else if typedData.Types[field.Type] != nil {
 			if mapValue, ok := encValue.(map[string]interface{}); ok {
 				mapOutput, err := typedData.formatData(field.Type, mapValue)
@@ -652,6 +776,37 @@ func (typedData *TypedData) formatData(primaryType string, data map[string]inter
 	return output, nil
 }
 
+// formatArray formats the items of a (possibly multi-dimensional) array, naming
+// them by their index.
+func (typedData *TypedData) formatArray(encType string, encValue interface{}) ([]*NameValueType, error) {
+	elemType, _, err := parseArrayType(encType)
+	if err != nil {
+		return nil, err
+	}
+	arrayValue, _ := encValue.([]interface{})
+	output := make([]*NameValueType, 0, len(arrayValue))
+	for i, v := range arrayValue {
+		item := &NameValueType{
+			Name: fmt.Sprintf("[%d]", i),
+			Typ:  elemType,
+		}
+		switch {
+		case strings.HasSuffix(elemType, "]"):
+			item.Value, err = typedData.formatArray(elemType, v)
+		case typedData.Types[elemType] != nil:
+			mapValue, _ := v.(map[string]interface{})
+			item.Value, err = typedData.formatData(elemType, mapValue)
+		default:
+			item.Value, err = formatPrimitiveValue(elemType, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, item)
+	}
+	return output, nil
+}
+
 func formatPrimitiveValue(encType string, encValue interface{}) (string, error) {
 	switch encType {
 	case "address":
@@ -682,12 +837,35 @@ func formatPrimitiveValue(encType string, encValue interface{}) (string, error)
 	return "", fmt.Errorf("unhandled type %v", encType)
 }
 
+// hasField reports whether the struct type has a field with the given name.
+func (t Types) hasField(typeName, fieldName string) bool {
+	for _, field := range t[typeName] {
+		if field.Name == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+// domainFieldTypes are the types of the fields an EIP712Domain may contain.
+var domainFieldTypes = map[string]string{
+	"name":              "string",
+	"version":           "string",
+	"chainId":           "uint256",
+	"verifyingContract": "address",
+	"salt":              "bytes32",
+}
+
 // Validate checks if the types object is conformant to the specs
 func (t Types) validate() error {
 	for typeKey, typeArr := range t {
 		if len(typeKey) == 0 {
 			return fmt.Errorf("empty type key")
 		}
+		if !typedDataIdentifierRegexp.MatchString(typeKey) {
+			return fmt.Errorf("invalid type name %q", typeKey)
+		}
+		names := make(map[string]bool)
 		for i, typeObj := range typeArr {
 			if len(typeObj.Type) == 0 {
 				return fmt.Errorf("type %q:%d: empty Type", typeKey, i)
@@ -695,9 +873,25 @@ func (t Types) validate() error {
 			if len(typeObj.Name) == 0 {
 				return fmt.Errorf("type %q:%d: empty Name", typeKey, i)
 			}
+			if !typedDataIdentifierRegexp.MatchString(typeObj.Name) {
+				return fmt.Errorf("type %q:%d: invalid Name %q", typeKey, i, typeObj.Name)
+			}
+			if names[typeObj.Name] {
+				return fmt.Errorf("type %q: duplicate field %q", typeKey, typeObj.Name)
+			}
+			names[typeObj.Name] = true
+
 			if typeKey == typeObj.Type {
 				return fmt.Errorf("type %q cannot reference itself", typeObj.Type)
 			}
+			// Check the dimensions of arrays, e.g. 'uint256[2][]'
+			for elem := typeObj.Type; strings.HasSuffix(elem, "]"); {
+				next, _, err := parseArrayType(elem)
+				if err != nil {
+					return fmt.Errorf("type %q:%d: %v", typeKey, i, err)
+				}
+				elem = next
+			}
 			if typeObj.isReferenceType() {
 				if _, exist := t[typeObj.typeName()]; !exist {
 					return fmt.Errorf("reference type %q is undefined", typeObj.Type)
@@ -705,129 +899,48 @@ func (t Types) validate() error {
 				if !typedDataReferenceTypeRegexp.MatchString(typeObj.Type) {
 					return fmt.Errorf("unknown reference type %q", typeObj.Type)
 				}
-			} else if !isPrimitiveTypeValid(typeObj.Type) {
+			} else if !isPrimitiveTypeValid(typeObj.typeName()) {
 				return fmt.Errorf("unknown type %q", typeObj.Type)
 			}
+			if typeKey == "EIP712Domain" {
+				if want, ok := domainFieldTypes[typeObj.Name]; !ok {
+					return fmt.Errorf("unknown domain field %q", typeObj.Name)
+				} else if typeObj.Type != want {
+					return fmt.Errorf("domain field %q must be of type %q, not %q", typeObj.Name, want, typeObj.Type)
+				}
+			}
 		}
 	}
 	return nil
 }
 
-// Checks if the primitive value is valid
+// Checks if the primitive value is valid. Array types must be stripped of their
+// dimensions before.
 func isPrimitiveTypeValid(primitiveType string) bool {
-	if primitiveType == "address" ||
-		primitiveType == "address[]" ||
-		primitiveType == "bool" ||
-		primitiveType == "bool[]" ||
-		primitiveType == "string" ||
-		primitiveType == "string[]" {
-		return true
-	}
-	if primitiveType == "bytes" ||
-		primitiveType == "bytes[]" ||
-		primitiveType == "bytes1" ||
-		primitiveType == "bytes1[]" ||
-		primitiveType == "bytes2" ||
-		primitiveType == "bytes2[]" ||
-		primitiveType == "bytes3" ||
-		primitiveType == "bytes3[]" ||
-		primitiveType == "bytes4" ||
-		primitiveType == "bytes4[]" ||
-		primitiveType == "bytes5" ||
-		primitiveType == "bytes5[]" ||
-		primitiveType == "bytes6" ||
-		primitiveType == "bytes6[]" ||
-		primitiveType == "bytes7" ||
-		primitiveType == "bytes7[]" ||
-		primitiveType == "bytes8" ||
-		primitiveType == "bytes8[]" ||
-		primitiveType == "bytes9" ||
-		primitiveType == "bytes9[]" ||
-		primitiveType == "bytes10" ||
-		primitiveType == "bytes10[]" ||
-		primitiveType == "bytes11" ||
-		primitiveType == "bytes11[]" ||
-		primitiveType == "bytes12" ||
-		primitiveType == "bytes12[]" ||
-		primitiveType == "bytes13" ||
-		primitiveType == "bytes13[]" ||
-		primitiveType == "bytes14" ||
-		primitiveType == "bytes14[]" ||
-		primitiveType == "bytes15" ||
-		primitiveType == "bytes15[]" ||
-		primitiveType == "bytes16" ||
-		primitiveType == "bytes16[]" ||
-		primitiveType == "bytes17" ||
-		primitiveType == "bytes17[]" ||
-		primitiveType == "bytes18" ||
-		primitiveType == "bytes18[]" ||
-		primitiveType == "bytes19" ||
-		primitiveType == "bytes19[]" ||
-		primitiveType == "bytes20" ||
-		primitiveType == "bytes20[]" ||
-		primitiveType == "bytes21" ||
-		primitiveType == "bytes21[]" ||
-		primitiveType == "bytes22" ||
-		primitiveType == "bytes22[]" ||
-		primitiveType == "bytes23" ||
-		primitiveType == "bytes23[]" ||
-		primitiveType == "bytes24" ||
-		primitiveType == "bytes24[]" ||
-		primitiveType == "bytes25" ||
-		primitiveType == "bytes25[]" ||
-		primitiveType == "bytes26" ||
-		primitiveType == "bytes26[]" ||
-		primitiveType == "bytes27" ||
-		primitiveType == "bytes27[]" ||
-		primitiveType == "bytes28" ||
-		primitiveType == "bytes28[]" ||
-		primitiveType == "bytes29" ||
-		primitiveType == "bytes29[]" ||
-		primitiveType == "bytes30" ||
-		primitiveType == "bytes30[]" ||
-		primitiveType == "bytes31" ||
-		primitiveType == "bytes31[]" ||
-		primitiveType == "bytes32" ||
-		primitiveType == "bytes32[]" {
+	switch primitiveType {
+	case "address", "bool", "string", "bytes", "int", "uint":
 		return true
 	}
-	if primitiveType == "int" ||
-		primitiveType == "int[]" ||
-		primitiveType == "int8" ||
-		primitiveType == "int8[]" ||
-		primitiveType == "int16" ||
-		primitiveType == "int16[]" ||
-		primitiveType == "int32" ||
-		primitiveType == "int32[]" ||
-		primitiveType == "int64" ||
-		primitiveType == "int64[]" ||
-		primitiveType == "int96" ||
-		primitiveType == "int96[]" ||
-		primitiveType == "int128" ||
-		primitiveType == "int128[]" ||
-		primitiveType == "int256" ||
-		primitiveType == "int256[]" {
-		return true
+	var (
+		size   string
+		maxLen int
+		step   int
+	)
+	switch {
+	case strings.HasPrefix(primitiveType, "bytes"):
+		size, maxLen, step = strings.TrimPrefix(primitiveType, "bytes"), 32, 1
+	case strings.HasPrefix(primitiveType, "uint"):
+		size, maxLen, step = strings.TrimPrefix(primitiveType, "uint"), 256, 8
+	case strings.HasPrefix(primitiveType, "int"):
+		size, maxLen, step = strings.TrimPrefix(primitiveType, "int"), 256, 8
+	default:
+		return false
 	}
-	if primitiveType == "uint" ||
-		primitiveType == "uint[]" ||
-		primitiveType == "uint8" ||
-		primitiveType == "uint8[]" ||
-		primitiveType == "uint16" ||
-		primitiveType == "uint16[]" ||
-		primitiveType == "uint32" ||
-		primitiveType == "uint32[]" ||
-		primitiveType == "uint64" ||
-		primitiveType == "uint64[]" ||
-		primitiveType == "uint96" ||
-		primitiveType == "uint96[]" ||
-		primitiveType == "uint128" ||
-		primitiveType == "uint128[]" ||
-		primitiveType == "uint256" ||
-		primitiveType == "uint256[]" {
-		return true
+	length, err := strconv.Atoi(size)
+	if err != nil || size[0] == '0' || size[0] == '+' {
+		return false
 	}
-	return false
+	return length > 0 && length <= maxLen && length%step == 0
 }
 
 // validate checks if the given domain is valid, i.e. contains at least
@@ -0,0 +1,411 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/accounts"
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/internal/ethapi"
+	"github.com/spacedogechain/go-spacedogechain/log"
+	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
+	"github.com/spacedogechain/go-spacedogechain/signer/storage"
+)
+
+const (
+	// pendingKey is the storage key of the persisted approval queue.
+	pendingKey = "approvals/pending"
+
+	// listingWindow is the maximum difference between the current time and the
+	// time an approver signed to list the pending requests.
+	listingWindow = 5 * time.Minute
+)
+
+var (
+	errUnknownRequest   = errors.New("unknown or completed request")
+	errUnknownApprover  = errors.New("signer is not an approver")
+	errAlreadyDecided   = errors.New("approver already decided on request")
+	errInvalidSignature = errors.New("invalid signature")
+	errRequestExpired   = errors.New("request expired")
+	errListingExpired   = errors.New("listing signature expired or from the future")
+)
+
+// Request states of the approval queue.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalExpired  = "expired"
+)
+
+// ApprovalConfig configures the multi-party approval of requests.
+type ApprovalConfig struct {
+	Approvers map[common.Address]string // Approver addresses and their names
+	Threshold int                       // Number of approvals needed to sign
+	Timeout   time.Duration             // Time after which pending requests are rejected
+}
+
+// PendingRequest is a signing request waiting for approvals.
+type PendingRequest struct {
+	ID          common.Hash               `json:"id"`
+	Created     int64                     `json:"created"`
+	Expires     int64                     `json:"expires"`
+	Meta        Metadata                  `json:"meta"`
+	Transaction *apitypes.SendTxArgs      `json:"transaction,omitempty"`
+	Callinfo    []apitypes.ValidationInfo `json:"call_info,omitempty"`
+	SignData    *SignDataRequest          `json:"sign_data,omitempty"`
+	Approvals   []common.Address          `json:"approvals"`
+	Rejections  []common.Address          `json:"rejections"`
+	Status      string                    `json:"status"`
+}
+
+func (r *PendingRequest) decided(addr common.Address) bool {
+	for _, a := range r.Approvals {
+		if a == addr {
+			return true
+		}
+	}
+	for _, a := range r.Rejections {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalMessage returns the text an approver signs (as with personal_sign) to
+// approve or reject the request with the given id and expiry time.
+func ApprovalMessage(id common.Hash, expires int64, approve bool) []byte {
+	if approve {
+		return []byte(fmt.Sprintf("Approve clef request %s expiring %d", id.Hex(), expires))
+	}
+	return []byte(fmt.Sprintf("Reject clef request %s expiring %d", id.Hex(), expires))
+}
+
+// ListingMessage returns the text an approver signs (as with personal_sign) to
+// list the pending requests at the given unix time.
+func ListingMessage(timestamp int64) []byte {
+	return []byte(fmt.Sprintf("List clef requests %d", timestamp))
+}
+
+// ApprovalQueue is a UIClientAPI which requires transaction and data signing
+// requests to be approved by several approvers before they are signed. Requests
+// approved by the next UI are queued until enough approvers have signed off on
+// them through the ApprovalAPI. All other interactions are passed on to the
+// next UI.
+type ApprovalQueue struct {
+	next    UIClientAPI
+	storage storage.Storage
+	config  ApprovalConfig
+	audit   log.Logger
+
+	lock    sync.Mutex
+	pending map[common.Hash]*PendingRequest
+	done    map[common.Hash]chan struct{} // Closed when the request is decided
+}
+
+// NewApprovalQueue creates a multi-party approval queue in front of the given UI.
+// Requests left pending by a previous run are expired, as their callers are gone.
+func NewApprovalQueue(next UIClientAPI, storage storage.Storage, config ApprovalConfig) (*ApprovalQueue, error) {
+	if len(config.Approvers) == 0 {
+		return nil, errors.New("no approvers configured")
+	}
+	if config.Threshold < 1 || config.Threshold > len(config.Approvers) {
+		return nil, fmt.Errorf("invalid approval threshold %d of %d approvers", config.Threshold, len(config.Approvers))
+	}
+	q := &ApprovalQueue{
+		next:    next,
+		storage: storage,
+		config:  config,
+		audit:   log.New("api", "approvals"),
+		pending: make(map[common.Hash]*PendingRequest),
+		done:    make(map[common.Hash]chan struct{}),
+	}
+	if blob, err := storage.Get(pendingKey); err == nil {
+		var stale []*PendingRequest
+		if err := json.Unmarshal([]byte(blob), &stale); err != nil {
+			log.Warn("Failed to load pending approvals", "err", err)
+		}
+		for _, req := range stale {
+			q.audit.Info("Approval", "type", "expired", "id", req.ID, "approvals", len(req.Approvals), "reason", "restart")
+		}
+		storage.Del(pendingKey)
+	}
+	return q, nil
+}
+
+// SetAuditLog makes the queue record its events in the given audit log.
+func (q *ApprovalQueue) SetAuditLog(l *AuditLogger) {
+	q.audit = l.log
+}
+
+// persist stores the pending requests. The lock must be held.
+func (q *ApprovalQueue) persist() {
+	list := make([]*PendingRequest, 0, len(q.pending))
+	for _, req := range q.pending {
+		list = append(list, req)
+	}
+	blob, err := json.Marshal(list)
+	if err != nil {
+		log.Error("Failed to encode pending approvals", "err", err)
+		return
+	}
+	q.storage.Put(pendingKey, string(blob))
+}
+
+// submit queues a request and blocks until it's decided or times out, returning
+// whether it was approved.
+func (q *ApprovalQueue) submit(req *PendingRequest) bool {
+	// Salt the id randomly, so signatures can't be replayed on an identical
+	// request, e.g. after a restart
+	blob, _ := json.Marshal(req)
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		log.Error("Failed to generate approval request id", "err", err)
+		return false
+	}
+	now := time.Now()
+	req.ID = crypto.Keccak256Hash(blob, salt)
+	req.Created = now.Unix()
+	req.Expires = now.Add(q.config.Timeout).Unix()
+	req.Approvals, req.Rejections = []common.Address{}, []common.Address{}
+	req.Status = ApprovalPending
+
+	q.lock.Lock()
+	done := make(chan struct{})
+	q.pending[req.ID] = req
+	q.done[req.ID] = done
+	q.persist()
+	q.lock.Unlock()
+
+	q.audit.Info("Approval", "type", "request", "id", req.ID, "metadata", req.Meta.String(), "threshold", q.config.Threshold, "expires", req.Expires)
+	q.next.ShowInfo(fmt.Sprintf("Request %s awaits %d of %d approvals", req.ID.Hex(), q.config.Threshold, len(q.config.Approvers)))
+
+	timeout := time.NewTimer(q.config.Timeout)
+	defer timeout.Stop()
+	select {
+	case <-done:
+	case <-timeout.C:
+		q.lock.Lock()
+		if req.Status == ApprovalPending {
+			q.finish(req, ApprovalExpired)
+		}
+		q.lock.Unlock()
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return req.Status == ApprovalApproved
+}
+
+// finish removes a decided request from the queue. The lock must be held.
+func (q *ApprovalQueue) finish(req *PendingRequest, status string) {
+	req.Status = status
+	close(q.done[req.ID])
+	delete(q.done, req.ID)
+	delete(q.pending, req.ID)
+	q.persist()
+	q.audit.Info("Approval", "type", "result", "id", req.ID, "status", status, "approvals", req.Approvals, "rejections", req.Rejections)
+}
+
+// decide records the decision of the approver who created the given signature.
+func (q *ApprovalQueue) decide(id common.Hash, approve bool, sig hexutil.Bytes) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	req, ok := q.pending[id]
+	if !ok {
+		return errUnknownRequest
+	}
+	approver, err := recoverApprover(ApprovalMessage(id, req.Expires, approve), sig)
+	if err != nil {
+		return err
+	}
+	name, ok := q.config.Approvers[approver]
+	if !ok {
+		q.audit.Warn("Approval", "type", "unauthorized", "id", id, "signer", approver)
+		return errUnknownApprover
+	}
+	if time.Now().Unix() >= req.Expires {
+		return errRequestExpired
+	}
+	if req.decided(approver) {
+		return errAlreadyDecided
+	}
+	if approve {
+		req.Approvals = append(req.Approvals, approver)
+	} else {
+		req.Rejections = append(req.Rejections, approver)
+	}
+	q.audit.Info("Approval", "type", "decision", "id", id, "approver", approver, "name", name, "approve", approve)
+
+	switch {
+	case len(req.Approvals) >= q.config.Threshold:
+		q.finish(req, ApprovalApproved)
+	case len(req.Rejections) > len(q.config.Approvers)-q.config.Threshold:
+		// Not enough approvers left to reach the threshold
+		q.finish(req, ApprovalRejected)
+	default:
+		q.persist()
+	}
+	return nil
+}
+
+// recoverApprover returns the address which signed the approval message. Messages
+// are signed as with personal_sign, hashed with accounts.TextHash.
+func recoverApprover(msg []byte, sig hexutil.Bytes) (common.Address, error) {
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		return common.Address{}, errInvalidSignature
+	}
+	rsv := common.CopyBytes(sig)
+	rsv[64] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash(msg), rsv)
+	if err != nil {
+		return common.Address{}, errInvalidSignature
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Pending returns copies of the requests awaiting approval.
+func (q *ApprovalQueue) Pending() []*PendingRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	list := make([]*PendingRequest, 0, len(q.pending))
+	for _, req := range q.pending {
+		cpy := *req
+		cpy.Approvals = append([]common.Address{}, req.Approvals...)
+		cpy.Rejections = append([]common.Address{}, req.Rejections...)
+		list = append(list, &cpy)
+	}
+	return list
+}
+
+// list returns the requests awaiting approval to the approver who created the
+// given signature of the listing message for the given unix time.
+func (q *ApprovalQueue) list(timestamp int64, sig hexutil.Bytes) ([]*PendingRequest, error) {
+	approver, err := recoverApprover(ListingMessage(timestamp), sig)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := q.config.Approvers[approver]; !ok {
+		q.audit.Warn("Approval", "type", "unauthorized", "signer", approver)
+		return nil, errUnknownApprover
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > listingWindow || age < -listingWindow {
+		return nil, errListingExpired
+	}
+	return q.Pending(), nil
+}
+
+// ApproveTx asks the next UI to approve the transaction, then queues it, as it
+// will be signed, for the approvers.
+func (q *ApprovalQueue) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	resp, err := q.next.ApproveTx(request)
+	if err != nil || !resp.Approved {
+		return resp, err
+	}
+	tx := resp.Transaction
+	resp.Approved = q.submit(&PendingRequest{
+		Meta:        request.Meta,
+		Transaction: &tx,
+		Callinfo:    request.Callinfo,
+	})
+	return resp, nil
+}
+
+// ApproveSignData asks the next UI to approve the data, then queues it for the
+// approvers.
+func (q *ApprovalQueue) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	resp, err := q.next.ApproveSignData(request)
+	if err != nil || !resp.Approved {
+		return resp, err
+	}
+	resp.Approved = q.submit(&PendingRequest{
+		Meta:     request.Meta,
+		SignData: request,
+	})
+	return resp, nil
+}
+
+func (q *ApprovalQueue) ApproveListing(request *ListRequest) (ListResponse, error) {
+	return q.next.ApproveListing(request)
+}
+
+func (q *ApprovalQueue) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	return q.next.ApproveNewAccount(request)
+}
+
+func (q *ApprovalQueue) ShowError(message string) {
+	q.next.ShowError(message)
+}
+
+func (q *ApprovalQueue) ShowInfo(message string) {
+	q.next.ShowInfo(message)
+}
+
+func (q *ApprovalQueue) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	q.next.OnApprovedTx(tx)
+}
+
+func (q *ApprovalQueue) OnSignerStartup(info StartupInfo) {
+	q.next.OnSignerStartup(info)
+}
+
+func (q *ApprovalQueue) OnInputRequired(info UserInputRequest) (UserInputResponse, error) {
+	return q.next.OnInputRequired(info)
+}
+
+func (q *ApprovalQueue) RegisterUIServer(api *UIServerAPI) {
+	q.next.RegisterUIServer(api)
+}
+
+// ApprovalAPI is the RPC API through which approvers list and decide on pending
+// requests. Listings are authenticated by the approver's signature over a recent
+// ListingMessage, decisions by the signature over the ApprovalMessage of the
+// request.
+type ApprovalAPI struct {
+	queue *ApprovalQueue
+}
+
+// NewApprovalAPI creates the approval RPC API of the given queue.
+func NewApprovalAPI(queue *ApprovalQueue) *ApprovalAPI {
+	return &ApprovalAPI{queue}
+}
+
+// Pending returns the requests awaiting approval to an approver who signed the
+// listing message for the given unix time.
+func (api *ApprovalAPI) Pending(timestamp int64, signature hexutil.Bytes) ([]*PendingRequest, error) {
+	return api.queue.list(timestamp, signature)
+}
+
+// Approve approves the request with the given id.
+func (api *ApprovalAPI) Approve(id common.Hash, signature hexutil.Bytes) error {
+	return api.queue.decide(id, true, signature)
+}
+
+// Reject rejects the request with the given id.
+func (api *ApprovalAPI) Reject(id common.Hash, signature hexutil.Bytes) error {
+	return api.queue.decide(id, false, signature)
+}
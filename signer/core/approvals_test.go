@@ -0,0 +1,288 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/accounts"
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/signer/core"
+	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
+	"github.com/spacedogechain/go-spacedogechain/signer/storage"
+)
+
+func signApproval(t *testing.T, key *ecdsa.PrivateKey, req *core.PendingRequest, approve bool) hexutil.Bytes {
+	t.Helper()
+	sig, err := crypto.Sign(accounts.TextHash(core.ApprovalMessage(req.ID, req.Expires, approve)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[64] += 27
+	return sig
+}
+
+func newApprovalQueue(t *testing.T, store storage.Storage, threshold int, timeout time.Duration) (*core.ApprovalQueue, *headlessUi, []*ecdsa.PrivateKey) {
+	t.Helper()
+	var (
+		keys      []*ecdsa.PrivateKey
+		approvers = make(map[common.Address]string)
+	)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		approvers[crypto.PubkeyToAddress(key.PublicKey)] = string(rune('a' + i))
+	}
+	ui := &headlessUi{make(chan string, 20), make(chan string, 20)}
+	q, err := core.NewApprovalQueue(ui, store, core.ApprovalConfig{
+		Approvers: approvers,
+		Threshold: threshold,
+		Timeout:   timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q, ui, keys
+}
+
+// submitTx submits a transaction to the queue in the background, after the UI
+// approved it as instructed, returning the pending request and a channel
+// delivering the final verdict.
+func submitTx(t *testing.T, q *core.ApprovalQueue, ui *headlessUi, decision string) (*core.PendingRequest, chan bool) {
+	t.Helper()
+	ui.approveCh <- decision
+
+	result := make(chan bool, 1)
+	go func() {
+		to := common.NewMixedcaseAddress(common.HexToAddress("0x1337"))
+		resp, _ := q.ApproveTx(&core.SignTxRequest{Transaction: apitypes.SendTxArgs{
+			From:  common.NewMixedcaseAddress(common.HexToAddress("0xdead")),
+			To:    &to,
+			Value: hexutil.Big(*big.NewInt(1)),
+		}})
+		result <- resp.Approved
+	}()
+	for i := 0; i < 100; i++ {
+		if pending := q.Pending(); len(pending) == 1 {
+			return pending[0], result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("request not queued")
+	return nil, nil
+}
+
+func TestApprovalThreshold(t *testing.T) {
+	q, ui, keys := newApprovalQueue(t, storage.NewEphemeralStorage(), 2, time.Minute)
+	api := core.NewApprovalAPI(q)
+
+	req, result := submitTx(t, q, ui, "Y")
+	id := req.ID
+	if err := api.Approve(id, signApproval(t, keys[0], req, true)); err != nil {
+		t.Fatal(err)
+	}
+	// Duplicate decisions and strangers are refused
+	if err := api.Approve(id, signApproval(t, keys[0], req, true)); err == nil {
+		t.Fatal("duplicate approval accepted")
+	}
+	stranger, _ := crypto.GenerateKey()
+	if err := api.Approve(id, signApproval(t, stranger, req, true)); err == nil {
+		t.Fatal("approval of non-approver accepted")
+	}
+	// Signatures are bound to the decision
+	if err := api.Approve(id, signApproval(t, keys[1], req, false)); err == nil {
+		t.Fatal("rejection signature accepted as approval")
+	}
+	select {
+	case <-result:
+		t.Fatal("request decided below threshold")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := api.Approve(id, signApproval(t, keys[1], req, true)); err != nil {
+		t.Fatal(err)
+	}
+	if approved := <-result; !approved {
+		t.Fatal("request not approved at threshold")
+	}
+	if len(q.Pending()) != 0 {
+		t.Fatal("decided request still pending")
+	}
+	if err := api.Approve(id, signApproval(t, keys[2], req, true)); err == nil {
+		t.Fatal("approval of decided request accepted")
+	}
+}
+
+func TestApprovalRejection(t *testing.T) {
+	q, ui, keys := newApprovalQueue(t, storage.NewEphemeralStorage(), 2, time.Minute)
+	api := core.NewApprovalAPI(q)
+
+	// With two of three approvals needed, two rejections make approval impossible
+	req, result := submitTx(t, q, ui, "Y")
+	id := req.ID
+	if err := api.Reject(id, signApproval(t, keys[0], req, false)); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.Approve(id, signApproval(t, keys[1], req, true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.Reject(id, signApproval(t, keys[2], req, false)); err != nil {
+		t.Fatal(err)
+	}
+	if approved := <-result; approved {
+		t.Fatal("rejected request approved")
+	}
+}
+
+func TestApprovalTimeout(t *testing.T) {
+	q, ui, _ := newApprovalQueue(t, storage.NewEphemeralStorage(), 1, 50*time.Millisecond)
+	_, result := submitTx(t, q, ui, "Y")
+	if approved := <-result; approved {
+		t.Fatal("expired request approved")
+	}
+	if len(q.Pending()) != 0 {
+		t.Fatal("expired request still pending")
+	}
+}
+
+func TestApprovalPersistence(t *testing.T) {
+	store := storage.NewEphemeralStorage()
+	q, ui, _ := newApprovalQueue(t, store, 1, time.Minute)
+	submitTx(t, q, ui, "Y")
+
+	if _, err := store.Get("approvals/pending"); err != nil {
+		t.Fatal("pending request not persisted")
+	}
+	// Requests of a previous run are expired on startup
+	q, _, _ = newApprovalQueue(t, store, 1, time.Minute)
+	if len(q.Pending()) != 0 {
+		t.Fatal("stale request pending after restart")
+	}
+	if _, err := store.Get("approvals/pending"); err == nil {
+		t.Fatal("stale request not removed")
+	}
+}
+
+func TestApprovalReplay(t *testing.T) {
+	q, ui, keys := newApprovalQueue(t, storage.NewEphemeralStorage(), 1, time.Minute)
+	api := core.NewApprovalAPI(q)
+
+	first, result := submitTx(t, q, ui, "Y")
+	sig := signApproval(t, keys[0], first, true)
+	if err := api.Approve(first.ID, sig); err != nil {
+		t.Fatal(err)
+	}
+	<-result
+
+	// An identical request gets a fresh id, the old signature doesn't approve it
+	second, result := submitTx(t, q, ui, "Y")
+	if second.ID == first.ID {
+		t.Fatal("identical requests share an id")
+	}
+	if err := api.Approve(second.ID, sig); err == nil {
+		t.Fatal("replayed approval accepted")
+	}
+	// Signatures are bound to the expiry time
+	expired := *second
+	expired.Expires--
+	if err := api.Approve(second.ID, signApproval(t, keys[0], &expired, true)); err == nil {
+		t.Fatal("approval with wrong expiry accepted")
+	}
+	if err := api.Approve(second.ID, signApproval(t, keys[0], second, true)); err != nil {
+		t.Fatal(err)
+	}
+	if approved := <-result; !approved {
+		t.Fatal("request not approved")
+	}
+}
+
+func TestApprovalAfterUI(t *testing.T) {
+	q, ui, keys := newApprovalQueue(t, storage.NewEphemeralStorage(), 1, time.Minute)
+	api := core.NewApprovalAPI(q)
+
+	// Requests rejected by the UI are not queued
+	ui.approveCh <- "N"
+	resp, err := q.ApproveTx(&core.SignTxRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Approved {
+		t.Fatal("request rejected by the UI approved")
+	}
+	if len(q.Pending()) != 0 {
+		t.Fatal("request rejected by the UI queued")
+	}
+	// Approvers decide on the transaction as modified by the UI
+	req, result := submitTx(t, q, ui, "M")
+	if value := req.Transaction.Value.ToInt(); value.Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("queued value %v, want 2", value)
+	}
+	if err := api.Approve(req.ID, signApproval(t, keys[0], req, true)); err != nil {
+		t.Fatal(err)
+	}
+	if approved := <-result; !approved {
+		t.Fatal("request not approved")
+	}
+}
+
+func TestApprovalListing(t *testing.T) {
+	q, ui, keys := newApprovalQueue(t, storage.NewEphemeralStorage(), 1, time.Minute)
+	api := core.NewApprovalAPI(q)
+
+	req, result := submitTx(t, q, ui, "Y")
+	defer func() {
+		api.Approve(req.ID, signApproval(t, keys[0], req, true))
+		<-result
+	}()
+	signListing := func(key *ecdsa.PrivateKey, timestamp int64) hexutil.Bytes {
+		sig, err := crypto.Sign(accounts.TextHash(core.ListingMessage(timestamp)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig[64] += 27
+		return sig
+	}
+	now := time.Now().Unix()
+	if pending, err := api.Pending(now, signListing(keys[0], now)); err != nil || len(pending) != 1 || pending[0].ID != req.ID {
+		t.Fatalf("listing mismatch: have %v (err %v), want request %x", pending, err, req.ID)
+	}
+	outsider, _ := crypto.GenerateKey()
+	if _, err := api.Pending(now, signListing(outsider, now)); err == nil {
+		t.Fatal("listing by non-approver accepted")
+	}
+	if _, err := api.Pending(now, signListing(keys[0], now-1)); err == nil {
+		t.Fatal("listing with wrong timestamp accepted")
+	}
+	old := now - 3600
+	if _, err := api.Pending(old, signListing(keys[0], old)); err == nil {
+		t.Fatal("stale listing signature accepted")
+	}
+}
+
+func TestApprovalConfig(t *testing.T) {
+	if _, err := core.NewApprovalQueue(nil, storage.NewEphemeralStorage(), core.ApprovalConfig{}); err == nil {
+		t.Fatal("queue without approvers created")
+	}
+	approvers := map[common.Address]string{common.HexToAddress("0x01"): "a"}
+	if _, err := core.NewApprovalQueue(nil, storage.NewEphemeralStorage(), core.ApprovalConfig{Approvers: approvers, Threshold: 2}); err == nil {
+		t.Fatal("queue with unreachable threshold created")
+	}
+}
@@ -0,0 +1,464 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/log"
+)
+
+// auditCheckpointInterval is the number of records after which the audit log is
+// signed again.
+const auditCheckpointInterval = 100
+
+// auditCheckpointPeriod is the time after which records not covered by a signature
+// are signed by a checkpoint record.
+const auditCheckpointPeriod = 10 * time.Minute
+
+var (
+	errAuditLogClosed     = errors.New("audit log closed")
+	errPartialAuditRecord = errors.New("partial record")
+)
+
+// auditAccountKeys are the log context keys holding the account a record is
+// about, in order of preference.
+var auditAccountKeys = []string{"addr", "from", "approver", "signer"}
+
+// AuditRecord is a single entry of the structured audit log, stored as one line
+// of JSON. Every record commits to its predecessor, so modifying, removing or
+// reordering records breaks the hash chain. Checkpoint records are additionally
+// signed by clef, which prevents rewriting the chain up to the last checkpoint.
+type AuditRecord struct {
+	Seq       uint64            `json:"seq"`
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Method    string            `json:"method"`
+	Type      string            `json:"type,omitempty"`
+	Account   *common.Address   `json:"account,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Prev      common.Hash       `json:"prev"`
+	Hash      common.Hash       `json:"hash"`
+	Signature hexutil.Bytes     `json:"signature,omitempty"`
+}
+
+// SealHash returns the hash of the record, which covers all fields but the hash
+// and the signature themselves.
+func (r *AuditRecord) SealHash() common.Hash {
+	cpy := *r
+	cpy.Hash, cpy.Signature = common.Hash{}, nil
+
+	blob, _ := json.Marshal(&cpy) // can ignore error, all fields are marshallable
+	return crypto.Keccak256Hash(blob)
+}
+
+// auditHandler is a log handler appending the records to a hash-chained audit
+// log file, continuing the chain of the records already in it.
+type auditHandler struct {
+	file *os.File
+	key  *ecdsa.PrivateKey // Key to sign checkpoints with, nil if disabled
+
+	seq      uint64      // Sequence number of the next record
+	prev     common.Hash // Hash of the last record
+	signed   bool        // Whether a checkpoint was written since opening the log
+	unsigned int         // Number of records written after the last checkpoint
+	lock     sync.Mutex
+
+	quit chan struct{} // Closed to stop the checkpoint timer
+	done chan struct{} // Closed when the checkpoint timer stopped
+}
+
+// newAuditHandler opens the audit log at the given path, creating it if needed.
+// If checkpoints are signed, records left unsigned for the given period are
+// signed by a checkpoint record.
+func newAuditHandler(path string, key *ecdsa.PrivateKey, period time.Duration) (*auditHandler, error) {
+	h := &auditHandler{key: key}
+	if err := h.resume(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	h.file = file
+	if key != nil {
+		h.quit, h.done = make(chan struct{}), make(chan struct{})
+		go h.loop(period)
+	}
+	return h, nil
+}
+
+// resume continues the chain of an existing audit log. The partial last record of
+// an interrupted write is dropped. Logs which aren't valid structured audit logs,
+// like the free-form logs of older clef versions, are moved aside, starting a new
+// chain.
+func (h *auditHandler) resume(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	last, size, err := scanAuditLog(f)
+	f.Close()
+
+	switch {
+	case err == errPartialAuditRecord:
+		log.Warn("Dropping partial audit log record", "file", path, "offset", size)
+		if err := os.Truncate(path, size); err != nil {
+			return err
+		}
+	case err != nil:
+		old := fmt.Sprintf("%s.%d.old", path, time.Now().Unix())
+		log.Warn("Moving invalid audit log aside", "file", path, "moved", old, "err", err)
+		return os.Rename(path, old)
+	}
+	if last != nil {
+		h.seq, h.prev = last.Seq+1, last.Hash
+	}
+	return nil
+}
+
+// scanAuditLog reads an audit log, returning its last record and the size of the
+// log up to the end of that record. A last line lacking its newline is the remains
+// of an interrupted write, reported as errPartialAuditRecord.
+func scanAuditLog(r io.Reader) (*AuditRecord, int64, error) {
+	var (
+		reader = bufio.NewReader(r)
+		last   *AuditRecord
+		size   int64
+	)
+	for line := 1; ; line++ {
+		blob, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		if len(blob) == 0 {
+			return last, size, nil
+		}
+		if blob[len(blob)-1] != '\n' {
+			return last, size, errPartialAuditRecord
+		}
+		record, err := decodeAuditRecord(blob)
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %v", line, err)
+		}
+		last, size = record, size+int64(len(blob))
+	}
+}
+
+// loop signs the records left unsigned for a period, until the handler is closed.
+func (h *auditHandler) loop(period time.Duration) {
+	defer close(h.done)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.lock.Lock()
+			if err := h.checkpoint("timer"); err != nil {
+				log.Error("Failed to write audit log checkpoint", "err", err)
+			}
+			h.lock.Unlock()
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// checkpoint appends a signed record covering the records which weren't signed
+// yet. The lock must be held.
+func (h *auditHandler) checkpoint(reason string) error {
+	if h.key == nil || h.unsigned == 0 {
+		return nil
+	}
+	return h.write(&AuditRecord{
+		Time:   time.Now().UTC(),
+		Level:  log.LvlInfo.String(),
+		Method: "Checkpoint",
+		Fields: map[string]string{"reason": reason},
+	}, true)
+}
+
+// Close signs the records which weren't signed yet and closes the audit log.
+func (h *auditHandler) Close() error {
+	h.lock.Lock()
+	if h.file == nil {
+		h.lock.Unlock()
+		return errAuditLogClosed
+	}
+	err := h.checkpoint("close")
+	if cerr := h.file.Close(); err == nil {
+		err = cerr
+	}
+	h.file = nil
+	h.lock.Unlock()
+
+	if h.quit != nil {
+		close(h.quit)
+		<-h.done
+	}
+	return err
+}
+
+// Log implements log.Handler, appending the log record to the audit chain.
+func (h *auditHandler) Log(r *log.Record) error {
+	record := &AuditRecord{
+		Time:   r.Time.UTC(),
+		Level:  r.Lvl.String(),
+		Method: r.Msg,
+	}
+	for i := 0; i+1 < len(r.Ctx); i += 2 {
+		key, ok := r.Ctx[i].(string)
+		if !ok {
+			continue
+		}
+		value, ok := formatAuditValue(r.Ctx[i+1])
+		if !ok {
+			continue
+		}
+		if key == "type" {
+			record.Type = value
+			continue
+		}
+		if record.Fields == nil {
+			record.Fields = make(map[string]string)
+		}
+		record.Fields[key] = value
+	}
+	for _, key := range auditAccountKeys {
+		if addr, ok := parseAuditAccount(record.Fields[key]); ok {
+			record.Account = &addr
+			break
+		}
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// Sign the first record written and every checkpoint after that
+	return h.write(record, h.key != nil && (!h.signed || h.seq%auditCheckpointInterval == 0))
+}
+
+// write appends the record to the audit chain, signing it if requested. The lock
+// must be held.
+func (h *auditHandler) write(record *AuditRecord, sign bool) error {
+	if h.file == nil {
+		return errAuditLogClosed
+	}
+	record.Seq, record.Prev = h.seq, h.prev
+	record.Hash = record.SealHash()
+
+	if sign {
+		sig, err := crypto.Sign(record.Hash[:], h.key)
+		if err != nil {
+			return err
+		}
+		record.Signature = sig
+	}
+	blob, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := h.file.Write(append(blob, '\n')); err != nil {
+		return err
+	}
+	h.seq, h.prev = record.Seq+1, record.Hash
+	if sign {
+		h.signed, h.unsigned = true, 0
+	} else {
+		h.unsigned++
+	}
+	return nil
+}
+
+// formatAuditValue converts a log context value into its textual form in the
+// audit log. Nil values are dropped.
+func formatAuditValue(value interface{}) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case error:
+		return v.Error(), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	if blob, err := json.Marshal(value); err == nil {
+		return string(blob), true
+	}
+	return fmt.Sprintf("%+v", value), true
+}
+
+// parseAuditAccount extracts the address from a formatted account field, which
+// may be followed by a checksum note as in mixed-case addresses.
+func parseAuditAccount(value string) (common.Address, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || !common.IsHexAddress(fields[0]) {
+		return common.Address{}, false
+	}
+	return common.HexToAddress(fields[0]), true
+}
+
+// readAuditRecords parses the audit log line by line, calling fn for every record.
+func readAuditRecords(r io.Reader, fn func(*AuditRecord) error) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		blob, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(blob) == 0 && err == io.EOF {
+			return nil
+		}
+		record, derr := decodeAuditRecord(blob)
+		if derr != nil {
+			return fmt.Errorf("line %d: %v", line, derr)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// decodeAuditRecord parses a line of the audit log.
+func decodeAuditRecord(blob []byte) (*AuditRecord, error) {
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+
+	record := new(AuditRecord)
+	if err := dec.Decode(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// AuditSummary describes an audit log that was verified.
+type AuditSummary struct {
+	Records     uint64         // Number of records in the log
+	Checkpoints uint64         // Number of signed records in the log
+	Unsigned    uint64         // Number of records after the last checkpoint
+	Signer      common.Address // Address of the key which signed the checkpoints
+}
+
+// VerifyAuditLog checks the hash chain of the audit log and the signatures of its
+// checkpoints. If signer is given, the checkpoints must be signed by it, otherwise
+// they must all be signed by the same key. The records after the last checkpoint
+// are only protected by the chain, as their hashes can be recomputed after any
+// modification.
+func VerifyAuditLog(r io.Reader, signer *common.Address) (*AuditSummary, error) {
+	var (
+		summary = new(AuditSummary)
+		prev    common.Hash
+	)
+	err := readAuditRecords(r, func(record *AuditRecord) error {
+		if record.Seq != summary.Records {
+			return fmt.Errorf("record %d: sequence number %d out of order", summary.Records, record.Seq)
+		}
+		if record.Prev != prev {
+			return fmt.Errorf("record %d: broken chain, previous hash %x, want %x", record.Seq, record.Prev, prev)
+		}
+		if hash := record.SealHash(); hash != record.Hash {
+			return fmt.Errorf("record %d: hash mismatch, have %x, want %x", record.Seq, record.Hash, hash)
+		}
+		if record.Signature != nil {
+			pubkey, err := crypto.SigToPub(record.Hash[:], record.Signature)
+			if err != nil {
+				return fmt.Errorf("record %d: invalid signature: %v", record.Seq, err)
+			}
+			addr := crypto.PubkeyToAddress(*pubkey)
+			switch {
+			case signer != nil && addr != *signer:
+				return fmt.Errorf("record %d: signed by %s, want %s", record.Seq, addr.Hex(), signer.Hex())
+			case summary.Checkpoints > 0 && addr != summary.Signer:
+				return fmt.Errorf("record %d: signed by %s, previous checkpoints by %s", record.Seq, addr.Hex(), summary.Signer.Hex())
+			}
+			summary.Signer = addr
+			summary.Checkpoints++
+			summary.Unsigned = 0
+		} else {
+			summary.Unsigned++
+		}
+		prev = record.Hash
+		summary.Records++
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// AuditFilter selects audit log records. Unset fields match all records.
+type AuditFilter struct {
+	Account *common.Address // Account the record is about
+	From    time.Time       // Earliest time of the record (inclusive)
+	To      time.Time       // Latest time of the record (exclusive)
+	Method  string          // Name of the API call, case insensitive
+}
+
+// Matches returns whether the record passes the filter.
+func (f *AuditFilter) Matches(record *AuditRecord) bool {
+	if f.Account != nil && (record.Account == nil || *record.Account != *f.Account) {
+		return false
+	}
+	if !f.From.IsZero() && record.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !record.Time.Before(f.To) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(record.Method, f.Method) {
+		return false
+	}
+	return true
+}
+
+// QueryAuditLog calls fn for every record of the audit log matching the filter.
+// The records aren't verified, which is done by VerifyAuditLog.
+func QueryAuditLog(r io.Reader, filter AuditFilter, fn func(*AuditRecord) error) error {
+	return readAuditRecords(r, func(record *AuditRecord) error {
+		if !filter.Matches(record) {
+			return nil
+		}
+		return fn(record)
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/log"
+)
+
+// Tests that records left unsigned are covered by a checkpoint after a while.
+func TestAuditLogCheckpointTimer(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	h, err := newAuditHandler(path, key, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	for i := 0; i < 3; i++ {
+		h.Log(&log.Record{Time: time.Now(), Lvl: log.LvlInfo, Msg: "Version"})
+	}
+	verify := func() *AuditSummary {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		summary, err := VerifyAuditLog(f, &signer)
+		if err != nil {
+			t.Fatalf("failed to verify audit log: %v", err)
+		}
+		return summary
+	}
+	for i := 0; i < 100; i++ {
+		if verify().Unsigned == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if summary := verify(); summary.Records != 4 || summary.Checkpoints != 2 || summary.Unsigned != 0 {
+		t.Fatalf("summary mismatch: have %+v, want 4 records with 2 checkpoints", summary)
+	}
+	// Without new records, no more checkpoints are written
+	time.Sleep(100 * time.Millisecond)
+	if summary := verify(); summary.Records != 4 {
+		t.Fatalf("record count mismatch: have %d, want 4", summary.Records)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Log(&log.Record{Time: time.Now(), Lvl: log.LvlInfo, Msg: "Version"}); err != errAuditLogClosed {
+		t.Fatalf("write to closed log: have %v, want %v", err, errAuditLogClosed)
+	}
+}
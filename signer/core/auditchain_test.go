@@ -0,0 +1,320 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/signer/core"
+)
+
+// auditTestAPI is an external API answering the calls the audit tests make.
+type auditTestAPI struct {
+	core.ExternalAPI
+}
+
+func (auditTestAPI) Version(ctx context.Context) (string, error) {
+	return core.ExternalAPIVersion, nil
+}
+
+func (auditTestAPI) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
+	return make([]byte, 65), nil
+}
+
+var (
+	auditAccountA = common.NewMixedcaseAddress(common.HexToAddress("0x000000000000000000000000000000000000aaaa"))
+	auditAccountB = common.NewMixedcaseAddress(common.HexToAddress("0x000000000000000000000000000000000000bbbb"))
+)
+
+// writeAuditLog opens the audit log at path and records the given number of
+// data signing calls, alternating between two accounts.
+func writeAuditLog(t *testing.T, path string, key *ecdsa.PrivateKey, calls int) {
+	t.Helper()
+
+	logger, closer, err := core.NewAuditLogger(path, auditTestAPI{}, key)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer closer.Close()
+
+	for i := 0; i < calls; i++ {
+		addr := auditAccountA
+		if i%2 == 1 {
+			addr = auditAccountB
+		}
+		if _, err := logger.SignData(context.Background(), "text/plain", addr, hexutil.Bytes("hello")); err != nil {
+			t.Fatalf("failed to sign data: %v", err)
+		}
+	}
+}
+
+func verifyAuditFile(t *testing.T, path string, signer *common.Address) (*core.AuditSummary, error) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return core.VerifyAuditLog(f, signer)
+}
+
+func TestAuditLogChain(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// Two sessions, the second one continuing the chain of the first
+	writeAuditLog(t, path, key, 150) // Records 0-300, checkpoints at 0, 100, 200, 300
+	writeAuditLog(t, path, key, 10)  // Records 301-322, checkpoints at 301 and on close at 322
+
+	summary, err := verifyAuditFile(t, path, &signer)
+	if err != nil {
+		t.Fatalf("failed to verify audit log: %v", err)
+	}
+	want := core.AuditSummary{Records: 323, Checkpoints: 6, Unsigned: 0, Signer: signer}
+	if *summary != want {
+		t.Errorf("summary mismatch: have %+v, want %+v", *summary, want)
+	}
+	if _, err := verifyAuditFile(t, path, nil); err != nil {
+		t.Errorf("failed to verify audit log without signer: %v", err)
+	}
+	other := common.HexToAddress("0x1337")
+	if _, err := verifyAuditFile(t, path, &other); err == nil {
+		t.Errorf("audit log verified with the wrong signer")
+	}
+}
+
+func TestAuditLogQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, path, nil, 5)
+
+	accountA, accountB := auditAccountA.Address(), auditAccountB.Address()
+	tests := []struct {
+		name    string
+		filter  core.AuditFilter
+		records int
+	}{
+		{"all", core.AuditFilter{}, 11},
+		{"account", core.AuditFilter{Account: &accountA}, 6},
+		{"other account", core.AuditFilter{Account: &accountB}, 4},
+		{"method", core.AuditFilter{Method: "signdata"}, 10},
+		{"account and method", core.AuditFilter{Account: &accountB, Method: "SignData"}, 4},
+		{"past", core.AuditFilter{To: time.Now().Add(-time.Hour)}, 0},
+		{"future", core.AuditFilter{From: time.Now().Add(time.Hour)}, 0},
+		{"time range", core.AuditFilter{From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)}, 11},
+	}
+	for _, test := range tests {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records int
+		err = core.QueryAuditLog(f, test.filter, func(record *core.AuditRecord) error {
+			if record.Type == "response" && record.Fields["data"] == "" {
+				t.Errorf("%s: response lacks data: %+v", test.name, record)
+			}
+			records++
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to query audit log: %v", test.name, err)
+		}
+		if records != test.records {
+			t.Errorf("%s: record count mismatch: have %d, want %d", test.name, records, test.records)
+		}
+	}
+}
+
+// rewriteAuditLog applies the modification to the records of the audit log.
+func rewriteAuditLog(t *testing.T, path string, modify func([]*core.AuditRecord) []*core.AuditRecord) {
+	t.Helper()
+
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*core.AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(blob)), "\n") {
+		record := new(core.AuditRecord)
+		if err := json.Unmarshal([]byte(line), record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	for _, record := range modify(records) {
+		if err := enc.Encode(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuditLogTampering(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	forger, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	tests := []struct {
+		name   string
+		modify func([]*core.AuditRecord) []*core.AuditRecord
+		err    string
+	}{
+		{
+			name: "edited record",
+			modify: func(records []*core.AuditRecord) []*core.AuditRecord {
+				records[3].Fields["data"] = "0x00"
+				return records
+			},
+			err: "record 3: hash mismatch",
+		},
+		{
+			name: "resealed record",
+			modify: func(records []*core.AuditRecord) []*core.AuditRecord {
+				records[3].Fields["data"] = "0x00"
+				records[3].Hash = records[3].SealHash()
+				return records
+			},
+			err: "record 4: broken chain",
+		},
+		{
+			name: "removed record",
+			modify: func(records []*core.AuditRecord) []*core.AuditRecord {
+				return append(records[:3], records[4:]...)
+			},
+			err: "record 3: sequence number 4 out of order",
+		},
+		{
+			name: "resealed chain",
+			modify: func(records []*core.AuditRecord) []*core.AuditRecord {
+				var prev common.Hash
+				for i, record := range records {
+					if i == 3 {
+						record.Fields["data"] = "0x00"
+					}
+					record.Prev = prev
+					record.Hash = record.SealHash()
+					if record.Signature != nil {
+						record.Signature, _ = crypto.Sign(record.Hash[:], forger)
+					}
+					prev = record.Hash
+				}
+				return records
+			},
+			err: "record 0: signed by",
+		},
+		{
+			name: "forged signature",
+			modify: func(records []*core.AuditRecord) []*core.AuditRecord {
+				records[3].Signature, _ = crypto.Sign(records[3].Hash[:], forger)
+				return records
+			},
+			err: "record 3: signed by",
+		},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		writeAuditLog(t, path, key, 5)
+		rewriteAuditLog(t, path, test.modify)
+
+		_, err := verifyAuditFile(t, path, &signer)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error mismatch: have %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+// Tests that logs which aren't valid structured audit logs, like the free-form
+// logs of older versions, are moved aside and a new chain is started.
+func TestAuditLogReplaced(t *testing.T) {
+	for _, content := range []string{
+		"t=2019-07-01T15:52:14+0300 lvl=info msg=SignData api=signer type=request\n",
+		"{\"seq\":0,\"unknown\":true}\n{\"seq\":1}\n",
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "audit.log")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		writeAuditLog(t, path, nil, 1)
+
+		summary, err := verifyAuditFile(t, path, nil)
+		if err != nil {
+			t.Fatalf("log %q: failed to verify new audit log: %v", content, err)
+		}
+		if summary.Records != 3 {
+			t.Errorf("log %q: record count mismatch: have %d, want 3", content, summary.Records)
+		}
+		old, _ := filepath.Glob(filepath.Join(dir, "audit.log.*.old"))
+		if len(old) != 1 {
+			t.Fatalf("log %q: old log not moved aside: %v", content, old)
+		}
+		if blob, _ := os.ReadFile(old[0]); string(blob) != content {
+			t.Errorf("log %q: old log content mismatch: have %q", content, blob)
+		}
+	}
+}
+
+// Tests that the partial last record of an interrupted write is dropped, and the
+// chain continued from the record before it.
+func TestAuditLogPartialRecord(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	writeAuditLog(t, path, key, 2) // Records 0-5, checkpoint on close at 5
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":6,"time":"2022-01-01T00:00:00Z","level":"info","method":"Configured","prev":"0x00`)
+	f.Close()
+
+	writeAuditLog(t, path, key, 1) // Records 6-9, checkpoint on close at 9
+	summary, err := verifyAuditFile(t, path, &signer)
+	if err != nil {
+		t.Fatalf("failed to verify audit log: %v", err)
+	}
+	want := core.AuditSummary{Records: 10, Checkpoints: 4, Signer: signer}
+	if *summary != want {
+		t.Errorf("summary mismatch: have %+v, want %+v", *summary, want)
+	}
+	// A log holding nothing but a partial record starts over
+	if err := os.WriteFile(path, []byte(`{"seq":0,"time":"2022-01-01`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeAuditLog(t, path, key, 1)
+	if summary, err := verifyAuditFile(t, path, &signer); err != nil || summary.Records != 4 {
+		t.Fatalf("restarted log: have %+v (%v), want 4 records", summary, err)
+	}
+}
@@ -18,7 +18,9 @@ package core
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
+	"io"
 
 	"github.com/spacedogechain/go-spacedogechain/common"
 	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
@@ -50,14 +52,14 @@ func (l *AuditLogger) SignTransaction(ctx context.Context, args apitypes.SendTxA
 		sel = *methodSelector
 	}
 	l.log.Info("SignTransaction", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"tx", args.String(),
+		"from", args.From.String(), "tx", args.String(),
 		"methodSelector", sel)
 
 	res, e := l.api.SignTransaction(ctx, args, methodSelector)
 	if res != nil {
-		l.log.Info("SignTransaction", "type", "response", "data", common.Bytes2Hex(res.Raw), "error", e)
+		l.log.Info("SignTransaction", "type", "response", "from", args.From.String(), "data", common.Bytes2Hex(res.Raw), "error", e)
 	} else {
-		l.log.Info("SignTransaction", "type", "response", "data", res, "error", e)
+		l.log.Info("SignTransaction", "type", "response", "from", args.From.String(), "data", res, "error", e)
 	}
 	return res, e
 }
@@ -65,9 +67,9 @@ func (l *AuditLogger) SignTransaction(ctx context.Context, args apitypes.SendTxA
 func (l *AuditLogger) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
 	marshalledData, _ := json.Marshal(data) // can ignore error, marshalling what we just unmarshalled
 	l.log.Info("SignData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"addr", addr.String(), "data", marshalledData, "content-type", contentType)
+		"addr", addr.String(), "data", string(marshalledData), "content-type", contentType)
 	b, e := l.api.SignData(ctx, contentType, addr, data)
-	l.log.Info("SignData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	l.log.Info("SignData", "type", "response", "addr", addr.String(), "data", common.Bytes2Hex(b), "error", e)
 	return b, e
 }
 
@@ -82,9 +84,26 @@ func (l *AuditLogger) SignGnosisSafeTx(ctx context.Context, addr common.Mixedcas
 	res, e := l.api.SignGnosisSafeTx(ctx, addr, gnosisTx, methodSelector)
 	if res != nil {
 		data, _ := json.Marshal(res) // can ignore error, marshalling what we just unmarshalled
-		l.log.Info("SignGnosisSafeTx", "type", "response", "data", string(data), "error", e)
+		l.log.Info("SignGnosisSafeTx", "type", "response", "addr", addr.String(), "data", string(data), "error", e)
 	} else {
-		l.log.Info("SignGnosisSafeTx", "type", "response", "data", res, "error", e)
+		l.log.Info("SignGnosisSafeTx", "type", "response", "addr", addr.String(), "data", res, "error", e)
+	}
+	return res, e
+}
+
+func (l *AuditLogger) SignUserOperation(ctx context.Context, addr common.MixedcaseAddress, entryPoint common.Address, userOp UserOperation, methodSelector *string) (*UserOperation, error) {
+	sel := "<nil>"
+	if methodSelector != nil {
+		sel = *methodSelector
+	}
+	data, _ := json.Marshal(userOp) // can ignore error, marshalling what we just unmarshalled
+	l.log.Info("SignUserOperation", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "entryPoint", entryPoint.Hex(), "data", string(data), "selector", sel)
+	res, e := l.api.SignUserOperation(ctx, addr, entryPoint, userOp, methodSelector)
+	if res != nil {
+		l.log.Info("SignUserOperation", "type", "response", "addr", addr.String(), "data", common.Bytes2Hex(res.Signature), "error", e)
+	} else {
+		l.log.Info("SignUserOperation", "type", "response", "addr", addr.String(), "data", res, "error", e)
 	}
 	return res, e
 }
@@ -93,7 +112,7 @@ func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAd
 	l.log.Info("SignTypedData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"addr", addr.String(), "data", data)
 	b, e := l.api.SignTypedData(ctx, addr, data)
-	l.log.Info("SignTypedData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	l.log.Info("SignTypedData", "type", "response", "addr", addr.String(), "data", common.Bytes2Hex(b), "error", e)
 	return b, e
 }
 
@@ -112,13 +131,17 @@ func (l *AuditLogger) Version(ctx context.Context) (string, error) {
 	return data, err
 }
 
-func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
+// NewAuditLogger creates an audit logger recording the calls to the given API in
+// the hash-chained audit log at path. If key is not nil, the log is periodically
+// signed with it. The returned closer signs the last records and closes the log;
+// it's kept apart from the logger, whose methods are all served over RPC.
+func NewAuditLogger(path string, api ExternalAPI, key *ecdsa.PrivateKey) (*AuditLogger, io.Closer, error) {
 	l := log.New("api", "signer")
-	handler, err := log.FileHandler(path, log.LogfmtFormat())
+	handler, err := newAuditHandler(path, key, auditCheckpointPeriod)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	l.SetHandler(handler)
 	l.Info("Configured", "audit log", path)
-	return &AuditLogger{l, api}, nil
+	return &AuditLogger{l, api}, handler, nil
 }
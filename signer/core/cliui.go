@@ -20,16 +20,25 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"strings"
 	"sync"
 
 	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
 	"github.com/spacedogechain/go-spacedogechain/console/prompt"
+	"github.com/spacedogechain/go-spacedogechain/core/types"
 	"github.com/spacedogechain/go-spacedogechain/internal/ethapi"
 	"github.com/spacedogechain/go-spacedogechain/log"
 )
 
+// txTypeNames are the names of the transaction types shown to the user.
+var txTypeNames = map[byte]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "access list (EIP-2930)",
+	types.DynamicFeeTxType: "dynamic fee (EIP-1559)",
+}
+
 type CommandlineUI struct {
 	in *bufio.Reader
 	mu sync.Mutex
@@ -115,22 +124,37 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 	fmt.Printf("from:               %v\n", request.Transaction.From.String())
 	fmt.Printf("value:              %v wei\n", weival)
 	fmt.Printf("gas:                %v (%v)\n", request.Transaction.Gas, uint64(request.Transaction.Gas))
-	if request.Transaction.MaxFeePerGas != nil {
+
+	// Show the fees along with the most the transaction can cost
+	typ, err := request.Transaction.TxType()
+	if err != nil {
+		fmt.Printf("type:               invalid (%v)\n", err)
+	} else {
+		fmt.Printf("type:               %v\n", txTypeNames[typ])
+	}
+	feeCap := request.Transaction.GasPrice
+	if typ == types.DynamicFeeTxType {
+		feeCap = request.Transaction.MaxFeePerGas
 		fmt.Printf("maxFeePerGas:          %v wei\n", request.Transaction.MaxFeePerGas.ToInt())
 		fmt.Printf("maxPriorityFeePerGas:  %v wei\n", request.Transaction.MaxPriorityFeePerGas.ToInt())
 	} else {
 		fmt.Printf("gasprice: %v wei\n", request.Transaction.GasPrice.ToInt())
 	}
+	if feeCap != nil {
+		maxFee := new(big.Int).Mul(feeCap.ToInt(), new(big.Int).SetUint64(uint64(request.Transaction.Gas)))
+		fmt.Printf("max fee:            %v wei\n", maxFee)
+		fmt.Printf("max cost:           %v wei (value + max fee)\n", maxFee.Add(maxFee, weival))
+	}
 	fmt.Printf("nonce:    %v (%v)\n", request.Transaction.Nonce, uint64(request.Transaction.Nonce))
 	if chainId := request.Transaction.ChainID; chainId != nil {
 		fmt.Printf("chainid:  %v\n", chainId)
 	}
 	if list := request.Transaction.AccessList; list != nil {
-		fmt.Printf("Accesslist\n")
+		fmt.Printf("Accesslist (%d addresses, %d storage slots)\n", len(*list), list.StorageKeys())
 		for i, el := range *list {
-			fmt.Printf(" %d. %v\n", i, el.Address)
+			fmt.Printf(" %d. %v\n", i, el.Address.Hex())
 			for j, slot := range el.StorageKeys {
-				fmt.Printf("   %d. %v\n", j, slot)
+				fmt.Printf("   %d. %v\n", j, slot.Hex())
 			}
 		}
 	}
@@ -23,6 +23,7 @@ import (
 	"mime"
 
 	"github.com/spacedogechain/go-spacedogechain/accounts"
+	"github.com/spacedogechain/go-spacedogechain/accounts/usbwallet"
 	"github.com/spacedogechain/go-spacedogechain/common"
 	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
 	"github.com/spacedogechain/go-spacedogechain/consensus/clique"
@@ -185,6 +186,23 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 						Value: msg,
 					},
 				}
+				// Hardware wallets only sign text with the Ethereum prefix of their
+				// firmware, so pass the text on with that prefix and tell the user.
+				if api.firmwarePrefixed(addr.Address()) {
+					sighash, msg = ethereumTextAndHash(textData)
+					messages = []*apitypes.NameValueType{
+						{
+							Name:  "message",
+							Typ:   accounts.MimetypeTextPlain,
+							Value: msg,
+						},
+						{
+							Name:  "prefix",
+							Typ:   accounts.MimetypeTextPlain,
+							Value: "the hardware wallet signs with the Ethereum prefix (\\x19Ethereum Signed Message:\\n), not the sdcereum one",
+						},
+					}
+				}
 				req = &SignDataRequest{ContentType: mediaType, Rawdata: []byte(msg), Messages: messages, Hash: sighash}
 			}
 		}
@@ -194,6 +212,32 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 	return req, usespacedogechainV, nil
 }
 
+// ethereumTextPrefix is the EIP-191 personal message prefix used on Ethereum. It
+// differs from the prefix of accounts.TextHash, but is what ERC-4337 accounts and
+// the firmware of hardware wallets sign and verify text with.
+const ethereumTextPrefix = "\x19Ethereum Signed Message:\n"
+
+// ethereumTextAndHash is like accounts.TextAndHash, with the Ethereum prefix:
+// hash = keccak256("\x19Ethereum Signed Message:\n"${message length}${message}).
+func ethereumTextAndHash(data []byte) ([]byte, string) {
+	msg := fmt.Sprintf("%s%d%s", ethereumTextPrefix, len(data), string(data))
+	return crypto.Keccak256([]byte(msg)), msg
+}
+
+// firmwarePrefixed reports whether the account is held by a Ledger or Trezor,
+// whose firmware signs text only with ethereumTextPrefix.
+func (api *SignerAPI) firmwarePrefixed(addr common.Address) bool {
+	wallet, err := api.am.Find(accounts.Account{Address: addr})
+	if err != nil {
+		return false
+	}
+	switch wallet.URL().Scheme {
+	case usbwallet.LedgerScheme, usbwallet.TrezorScheme:
+		return true
+	}
+	return false
+}
+
 // SignTextValidator signs the given message which can be further recovered
 // with the given validator.
 // hash = keccak256("\x19\x00"${address}${data}).
@@ -0,0 +1,35 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+)
+
+// Tests the Ethereum personal message hash against the hash of personal_sign
+// (and eth_sign) on Ethereum for the same message.
+func TestEthereumTextAndHash(t *testing.T) {
+	hash, msg := ethereumTextAndHash([]byte("Hello Joe"))
+	if want := "\x19Ethereum Signed Message:\n9Hello Joe"; msg != want {
+		t.Errorf("message mismatch: have %q, want %q", msg, want)
+	}
+	if want := "0xa080337ae51c4e064c189e113edd0ba391df9206e2f49db658bb32cf2911730b"; hexutil.Encode(hash) != want {
+		t.Errorf("hash mismatch: have %x, want %s", hash, want)
+	}
+}
@@ -19,20 +19,29 @@ package core_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"strings"
 	"testing"
 
+	"github.com/spacedogechain/go-spacedogechain"
+	"github.com/spacedogechain/go-spacedogechain/accounts"
 	"github.com/spacedogechain/go-spacedogechain/accounts/keystore"
+	"github.com/spacedogechain/go-spacedogechain/accounts/usbwallet"
 	"github.com/spacedogechain/go-spacedogechain/common"
 	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
 	"github.com/spacedogechain/go-spacedogechain/common/math"
+	"github.com/spacedogechain/go-spacedogechain/core/types"
 	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/event"
 	"github.com/spacedogechain/go-spacedogechain/signer/core"
 	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
+	"github.com/spacedogechain/go-spacedogechain/signer/fourbyte"
+	"github.com/spacedogechain/go-spacedogechain/signer/storage"
 )
 
 var typesStandard = apitypes.Types{
@@ -812,3 +821,205 @@ func TestComplexTypedData(t *testing.T) {
 		t.Fatalf("Error, got %x, wanted %x", sighash, expSigHash)
 	}
 }
+
+// TestTypedDataConformance checks the signing hashes of typed data exercising
+// nested struct arrays, multi-dimensional arrays and domain edge cases against
+// an independent implementation of EIP-712.
+func TestTypedDataConformance(t *testing.T) {
+	tests := []struct {
+		file    string
+		sighash string
+	}{
+		{"eip712.json", "0x78e95bf9921b27376adfb9554684113fe27fbfd8dfc84805eb014b0073c6d5f4"},
+		{"arrays-1.json", "0x6e6fd7405a0c7f044acdcc7e591e36ad82c6e4b1439de741d7fac715f8ec5653"},
+		{"custom_arraytype.json", "0x528c9e0892b9ae24cf1dd215db6a9ea1910b0b2472cd2c95101214601a5add53"},
+		{"nested-struct-arrays.json", "0x53034d8e7a394ff2e0d5b9ba352ecdfab82551379a99ba7167bf8820610fcd48"},
+		{"multidim-arrays.json", "0xcc132de24832bb5df7b522d8cfffa4c4f04f535fb052cdc2fcfa8625ad90056c"},
+		{"domain-salt.json", "0xecc1017dd81385092cba67979440a9a89f099459e194ddc3501250b49932f19f"},
+		{"domain-chainid-only.json", "0xc0e0ce140d0ddb152970e08b8de82cc4931850e7bb33cbcc45b54cd75d32b3f7"},
+		{"type-ordering.json", "0xec819ad40db3802197931454aea29888da8a96e5917b58a7e727e349ec0fb034"},
+	}
+	for _, test := range tests {
+		data, err := os.ReadFile(path.Join("testdata", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var typedData apitypes.TypedData
+		if err := json.Unmarshal(data, &typedData); err != nil {
+			t.Fatalf("%s: json unmarshalling failed: %v", test.file, err)
+		}
+		_, sighash, err := sign(typedData)
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if have := hexutil.Encode(sighash); have != test.sighash {
+			t.Errorf("%s: sighash mismatch: have %s, want %s", test.file, have, test.sighash)
+		}
+	}
+}
+
+func TestEncodeTypeOrdering(t *testing.T) {
+	data, err := os.ReadFile(path.Join("testdata", "type-ordering.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		t.Fatal(err)
+	}
+	// The primary type comes first, its dependencies follow in alphabetical order
+	want := "Transfer(Zebra to,Asset[] asset,string memo)Asset(Bank issuer,uint96 amount)Bank(string name)Zebra(Bank owner,uint16 stripes)"
+	if have := string(typedData.EncodeType("Transfer")); have != want {
+		t.Errorf("encodeType mismatch:\nhave %s\nwant %s", have, want)
+	}
+	// Structs without fields are encoded with empty parentheses
+	typedData.Types["Empty"] = []apitypes.Type{}
+	if have := string(typedData.EncodeType("Empty")); have != "Empty()" {
+		t.Errorf("encodeType mismatch: have %s, want Empty()", have)
+	}
+}
+
+func TestFormatArrays(t *testing.T) {
+	data, err := os.ReadFile(path.Join("testdata", "multidim-arrays.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		t.Fatal(err)
+	}
+	formatted, err := typedData.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Find grid[2][1] and outlines[0][1].y in the message tree
+	var message []*apitypes.NameValueType
+	for _, item := range formatted {
+		if item.Typ == "primary type" {
+			message = item.Value.([]*apitypes.NameValueType)
+		}
+	}
+	if len(message) != 6 {
+		t.Fatalf("formatted %d fields, want 6", len(message))
+	}
+	grid := message[0].Value.([]*apitypes.NameValueType)
+	if len(grid) != 3 {
+		t.Fatalf("formatted %d grid rows, want 3", len(grid))
+	}
+	cell := grid[2].Value.([]*apitypes.NameValueType)[1]
+	if cell.Name != "[1]" || cell.Typ != "uint256" || cell.Value != "6 (0x6)" {
+		t.Errorf("wrong grid cell: %+v", cell)
+	}
+	point := message[4].Value.([]*apitypes.NameValueType)[0].Value.([]*apitypes.NameValueType)[1]
+	if y := point.Value.([]*apitypes.NameValueType)[1]; y.Name != "y" || y.Value != "2 (0x2)" {
+		t.Errorf("wrong nested struct field: %+v", y)
+	}
+}
+
+// ledgerWallet is a wallet emulating a Ledger, whose firmware signs text only
+// with the Ethereum personal message prefix and can't sign plain hashes.
+type ledgerWallet struct {
+	key     *ecdsa.PrivateKey
+	account accounts.Account
+}
+
+func (w *ledgerWallet) URL() accounts.URL {
+	return accounts.URL{Scheme: usbwallet.LedgerScheme, Path: "mock"}
+}
+func (w *ledgerWallet) Status() (string, error)          { return "Ethereum app online", nil }
+func (w *ledgerWallet) Open(passphrase string) error     { return nil }
+func (w *ledgerWallet) Close() error                     { return nil }
+func (w *ledgerWallet) Accounts() []accounts.Account     { return []accounts.Account{w.account} }
+func (w *ledgerWallet) Contains(a accounts.Account) bool { return a.Address == w.account.Address }
+func (w *ledgerWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+func (w *ledgerWallet) SelfDerive(bases []accounts.DerivationPath, chain sdcereum.ChainStateReader) {}
+
+func (w *ledgerWallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	if mimeType != accounts.MimetypeTextPlain || !bytes.HasPrefix(data, []byte("\x19Ethereum Signed Message:\n")) {
+		return nil, accounts.ErrNotSupported
+	}
+	return crypto.Sign(crypto.Keccak256(data), w.key)
+}
+func (w *ledgerWallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.SignData(account, mimeType, data)
+}
+func (w *ledgerWallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+func (w *ledgerWallet) SignTextWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+func (w *ledgerWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+func (w *ledgerWallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// walletBackend is an account backend of a fixed set of wallets.
+type walletBackend []accounts.Wallet
+
+func (b walletBackend) Wallets() []accounts.Wallet { return b }
+func (b walletBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// signDataUi is a headless UI recording the data signing requests it approves.
+type signDataUi struct {
+	*headlessUi
+	requests []*core.SignDataRequest
+}
+
+func (ui *signDataUi) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	ui.requests = append(ui.requests, request)
+	return ui.headlessUi.ApproveSignData(request)
+}
+
+// Tests that text is signed on hardware wallets with the Ethereum prefix of their
+// firmware, and that the user is shown which prefix is signed.
+func TestSignDataHardwareWallet(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	wallet := &ledgerWallet{key: key, account: accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}}
+	db, err := fourbyte.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := &signDataUi{headlessUi: &headlessUi{make(chan string, 20), make(chan string, 20)}}
+	am := accounts.NewManager(&accounts.Config{}, walletBackend{wallet})
+	api := core.NewSignerAPI(am, 1337, true, ui, db, true, &storage.NoStorage{})
+
+	ui.approveCh <- "Y"
+	ui.inputCh <- ""
+	text := []byte("EHLO world")
+	signature, err := api.SignData(context.Background(), apitypes.TextPlain.Mime, common.NewMixedcaseAddress(wallet.account.Address), hexutil.Encode(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(text), text)))
+	if req := ui.requests[0]; !bytes.Equal(req.Hash, hash) {
+		t.Errorf("shown hash %x, want %x", req.Hash, hash)
+	}
+	var shown bool
+	for _, msg := range ui.requests[0].Messages {
+		if msg.Name == "prefix" && strings.Contains(msg.Value.(string), "Ethereum") {
+			shown = true
+		}
+	}
+	if !shown {
+		t.Error("signed prefix not shown to the user")
+	}
+	signature[64] -= 27
+	pubkey, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != wallet.account.Address {
+		t.Errorf("signer mismatch: have %v, want %v", signer, wallet.account.Address)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/spacedogechain/go-spacedogechain"
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
+)
+
+// Versions of the ERC-4337 EntryPoint contract, which differ in the encoding of
+// user operations and thus their hashes.
+const (
+	EntryPointV06 = "0.6"
+	EntryPointV07 = "0.7"
+)
+
+// entryPoints are the canonical EntryPoint deployments, which share the same
+// address on all chains.
+var entryPoints = map[common.Address]string{
+	common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"): EntryPointV06,
+	common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032"): EntryPointV07,
+}
+
+// maxUint128 is the limit of the gas fields packed into 16 bytes by EntryPoint v0.7.
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// UserOperation is an ERC-4337 user operation of a smart contract account, in
+// the format of the bundler RPC API. EntryPoint v0.6 operations carry the
+// 'initCode' and 'paymasterAndData' fields, v0.7 operations carry them split
+// into the factory and paymaster fields instead.
+type UserOperation struct {
+	Sender               common.MixedcaseAddress `json:"sender"`
+	Nonce                hexutil.Big             `json:"nonce"`
+	InitCode             hexutil.Bytes           `json:"initCode,omitempty"`
+	Factory              *common.Address         `json:"factory,omitempty"`
+	FactoryData          hexutil.Bytes           `json:"factoryData,omitempty"`
+	CallData             hexutil.Bytes           `json:"callData"`
+	CallGasLimit         hexutil.Big             `json:"callGasLimit"`
+	VerificationGasLimit hexutil.Big             `json:"verificationGasLimit"`
+	PreVerificationGas   hexutil.Big             `json:"preVerificationGas"`
+	MaxFeePerGas         hexutil.Big             `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas hexutil.Big             `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     hexutil.Bytes           `json:"paymasterAndData,omitempty"`
+
+	Paymaster                     *common.Address `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit *hexutil.Big    `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big    `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 hexutil.Bytes   `json:"paymasterData,omitempty"`
+
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// UserOperationInfo is attached to the data signing request of a user operation,
+// for UIs and policies to judge the operation by.
+type UserOperationInfo struct {
+	EntryPoint common.Address `json:"entry_point"`
+	Version    string         `json:"version"`
+	ChainID    *hexutil.Big   `json:"chain_id"`
+	Operation  *UserOperation `json:"operation"`
+}
+
+// EntryPointVersion returns the version of the given EntryPoint, and checks that
+// the fields of the operation match that version. Only the canonical EntryPoint
+// deployments are supported.
+func (op *UserOperation) EntryPointVersion(entryPoint common.Address) (string, error) {
+	version, ok := entryPoints[entryPoint]
+	if !ok {
+		return "", fmt.Errorf("unknown EntryPoint %v", entryPoint)
+	}
+	var (
+		v06 = len(op.InitCode) > 0 || len(op.PaymasterAndData) > 0
+		v07 = op.Factory != nil || len(op.FactoryData) > 0 || op.Paymaster != nil ||
+			op.PaymasterVerificationGasLimit != nil || op.PaymasterPostOpGasLimit != nil || len(op.PaymasterData) > 0
+	)
+	switch {
+	case version == EntryPointV06 && v07:
+		return "", errors.New("user operation for EntryPoint v0.6 has factory or paymaster fields, use 'initCode' and 'paymasterAndData'")
+	case version == EntryPointV07 && v06:
+		return "", errors.New("user operation for EntryPoint v0.7 has 'initCode' or 'paymasterAndData', use the factory and paymaster fields")
+	}
+	if version == EntryPointV07 {
+		if op.Factory == nil && len(op.FactoryData) > 0 {
+			return "", errors.New("user operation has 'factoryData' but no 'factory'")
+		}
+		if op.Paymaster == nil && (op.PaymasterVerificationGasLimit != nil || op.PaymasterPostOpGasLimit != nil || len(op.PaymasterData) > 0) {
+			return "", errors.New("user operation has paymaster fields but no 'paymaster'")
+		}
+		packed := []*hexutil.Big{&op.CallGasLimit, &op.VerificationGasLimit, &op.MaxFeePerGas, &op.MaxPriorityFeePerGas,
+			op.PaymasterVerificationGasLimit, op.PaymasterPostOpGasLimit}
+		for _, v := range packed {
+			if v != nil && v.ToInt().Cmp(maxUint128) > 0 {
+				return "", errors.New("user operation gas limits and fees must fit into 128 bits")
+			}
+		}
+	}
+	return version, nil
+}
+
+// initCode returns the account deployment code of the operation, as encoded by
+// EntryPoint v0.6.
+func (op *UserOperation) initCode() []byte {
+	if op.Factory == nil {
+		return op.InitCode
+	}
+	return append(op.Factory.Bytes(), op.FactoryData...)
+}
+
+// paymasterAndData returns the paymaster of the operation and its data, as
+// encoded by EntryPoint v0.6.
+func (op *UserOperation) paymasterAndData() []byte {
+	if op.Paymaster == nil {
+		return op.PaymasterAndData
+	}
+	data := op.Paymaster.Bytes()
+	data = append(data, packUint128(op.PaymasterVerificationGasLimit, op.PaymasterPostOpGasLimit)...)
+	return append(data, op.PaymasterData...)
+}
+
+// packUint128 encodes two values into a 32 byte word of two 128 bit integers.
+func packUint128(hi, lo *hexutil.Big) []byte {
+	word := make([]byte, 32)
+	if hi != nil {
+		hi.ToInt().FillBytes(word[:16])
+	}
+	if lo != nil {
+		lo.ToInt().FillBytes(word[16:])
+	}
+	return word
+}
+
+// Hash returns the hash of the user operation as computed by the EntryPoint
+// contract, which is what the account verifies the signature against.
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	version, err := op.EntryPointVersion(entryPoint)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	word := func(v *big.Int) []byte {
+		return common.LeftPadBytes(v.Bytes(), 32)
+	}
+	var packed []byte
+	packed = append(packed, common.LeftPadBytes(op.Sender.Address().Bytes(), 32)...)
+	packed = append(packed, word(op.Nonce.ToInt())...)
+	packed = append(packed, crypto.Keccak256(op.initCode())...)
+	packed = append(packed, crypto.Keccak256(op.CallData)...)
+	switch version {
+	case EntryPointV06:
+		packed = append(packed, word(op.CallGasLimit.ToInt())...)
+		packed = append(packed, word(op.VerificationGasLimit.ToInt())...)
+		packed = append(packed, word(op.PreVerificationGas.ToInt())...)
+		packed = append(packed, word(op.MaxFeePerGas.ToInt())...)
+		packed = append(packed, word(op.MaxPriorityFeePerGas.ToInt())...)
+	default:
+		packed = append(packed, packUint128(&op.VerificationGasLimit, &op.CallGasLimit)...)
+		packed = append(packed, word(op.PreVerificationGas.ToInt())...)
+		packed = append(packed, packUint128(&op.MaxPriorityFeePerGas, &op.MaxFeePerGas)...)
+	}
+	packed = append(packed, crypto.Keccak256(op.paymasterAndData())...)
+
+	return crypto.Keccak256Hash(
+		crypto.Keccak256(packed),
+		common.LeftPadBytes(entryPoint.Bytes(), 32),
+		word(chainID),
+	), nil
+}
+
+// MaxGasCost returns the highest amount of wei the operation can be charged,
+// which the EntryPoint collects upfront from the account or its paymaster.
+func (op *UserOperation) MaxGasCost(version string) *big.Int {
+	gas := new(big.Int).Set(op.VerificationGasLimit.ToInt())
+	if version == EntryPointV06 && len(op.PaymasterAndData) > 0 {
+		// Paymaster validation and postOp run with the verification gas limit
+		gas.Mul(gas, big.NewInt(3))
+	}
+	for _, limit := range []*hexutil.Big{&op.CallGasLimit, &op.PreVerificationGas, op.PaymasterVerificationGasLimit, op.PaymasterPostOpGasLimit} {
+		if limit != nil {
+			gas.Add(gas, limit.ToInt())
+		}
+	}
+	return gas.Mul(gas, op.MaxFeePerGas.ToInt())
+}
+
+// ArgsForValidation returns a SendTxArgs struct of the call the EntryPoint makes
+// to the account, which can be used for the common validations, e.g. look up
+// 4byte destinations.
+func (op *UserOperation) ArgsForValidation(entryPoint common.Address, chainID *big.Int) *apitypes.SendTxArgs {
+	var (
+		data   = op.CallData
+		maxFee = op.MaxFeePerGas
+		tip    = op.MaxPriorityFeePerGas
+	)
+	return &apitypes.SendTxArgs{
+		From:                 common.NewMixedcaseAddress(entryPoint),
+		To:                   &op.Sender,
+		Gas:                  hexutil.Uint64(op.CallGasLimit.ToInt().Uint64()),
+		MaxFeePerGas:         &maxFee,
+		MaxPriorityFeePerGas: &tip,
+		Nonce:                hexutil.Uint64(op.Nonce.ToInt().Uint64()),
+		Data:                 &data,
+		ChainID:              (*hexutil.Big)(chainID),
+	}
+}
+
+// Format returns the fields of the operation for display in a signing request.
+func (op *UserOperation) Format(entryPoint common.Address, version string, chainID *big.Int, hash common.Hash) []*apitypes.NameValueType {
+	messages := []*apitypes.NameValueType{
+		{
+			Name:  fmt.Sprintf("This is a request to sign an ERC-4337 user operation for EntryPoint v%s", version),
+			Typ:   "description",
+			Value: "",
+		},
+		{Name: "Account", Typ: "address", Value: op.Sender.String()},
+		{Name: "EntryPoint", Typ: "address", Value: entryPoint.Hex()},
+		{Name: "Chain id", Typ: "uint256", Value: chainID.String()},
+		{Name: "Nonce", Typ: "uint256", Value: op.Nonce.ToInt().String()},
+	}
+	if code := op.initCode(); len(code) > 0 {
+		messages = append(messages, &apitypes.NameValueType{Name: "Account deployment code", Typ: "hexdata", Value: hexutil.Encode(code)})
+	}
+	messages = append(messages,
+		&apitypes.NameValueType{Name: "Call data", Typ: "hexdata", Value: op.CallData.String()},
+		&apitypes.NameValueType{Name: "Call gas limit", Typ: "uint256", Value: op.CallGasLimit.ToInt().String()},
+		&apitypes.NameValueType{Name: "Verification gas limit", Typ: "uint256", Value: op.VerificationGasLimit.ToInt().String()},
+		&apitypes.NameValueType{Name: "Pre-verification gas", Typ: "uint256", Value: op.PreVerificationGas.ToInt().String()},
+		&apitypes.NameValueType{Name: "Max fee per gas", Typ: "uint256", Value: op.MaxFeePerGas.ToInt().String()},
+		&apitypes.NameValueType{Name: "Max priority fee per gas", Typ: "uint256", Value: op.MaxPriorityFeePerGas.ToInt().String()},
+		&apitypes.NameValueType{Name: "Max gas cost (wei)", Typ: "uint256", Value: op.MaxGasCost(version).String()},
+	)
+	if pm := op.paymasterAndData(); len(pm) > 0 {
+		messages = append(messages, &apitypes.NameValueType{Name: "Paymaster", Typ: "address", Value: common.BytesToAddress(pm[:common.AddressLength]).Hex()})
+	} else {
+		messages = append(messages, &apitypes.NameValueType{Name: "Paymaster", Typ: "description", Value: "none, the account pays the gas"})
+	}
+	return append(messages, &apitypes.NameValueType{Name: "User operation hash", Typ: "bytes32", Value: hash.Hex()})
+}
+
+// erc1271MagicValue is returned by isValidSignature(bytes32,bytes) of ERC-1271
+// contracts for valid signatures. It is also the selector of the call.
+var erc1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+// CheckERC1271Signature asks the contract account at the given address if the
+// signature is valid for the hash, as defined by ERC-1271. It returns false
+// without error if the contract doesn't confirm the signature, and the error of
+// the call if it failed, e.g. because the contract reverted.
+func CheckERC1271Signature(ctx context.Context, caller sdcereum.ContractCaller, account common.Address, hash common.Hash, signature []byte) (bool, error) {
+	data := append([]byte{}, erc1271MagicValue...)
+	data = append(data, hash.Bytes()...)
+	data = append(data, common.LeftPadBytes(big.NewInt(64).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(signature))).Bytes(), 32)...)
+	data = append(data, common.RightPadBytes(signature, (len(signature)+31)/32*32)...)
+
+	res, err := caller.CallContract(ctx, sdcereum.CallMsg{To: &account, Data: data}, nil)
+	if err != nil {
+		return false, err
+	}
+	// The magic value is returned as bytes4, i.e. left aligned in a word
+	return len(res) == 32 && bytes.Equal(res[:4], erc1271MagicValue), nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/spacedogechain/go-spacedogechain"
+	"github.com/spacedogechain/go-spacedogechain/accounts/abi"
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/signer/core"
+)
+
+var (
+	entryPointV06 = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	entryPointV07 = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+)
+
+func hexBig(v int64) hexutil.Big {
+	return hexutil.Big(*big.NewInt(v))
+}
+
+func mkTestUserOp() core.UserOperation {
+	return core.UserOperation{
+		Sender:               common.NewMixedcaseAddress(common.HexToAddress("0x000000000000000000000000000000000000aa00")),
+		Nonce:                hexBig(7),
+		CallData:             hexutil.MustDecode("0xb61d27f6000000000000000000000000000000000000000000000000000000000000beef000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000000"),
+		CallGasLimit:         hexBig(100000),
+		VerificationGasLimit: hexBig(200000),
+		PreVerificationGas:   hexBig(50000),
+		MaxFeePerGas:         hexBig(2000000000),
+		MaxPriorityFeePerGas: hexBig(1000000000),
+	}
+}
+
+// abiHash computes the hash of a user operation from its fields as packed by
+// the EntryPoint, using the ABI encoder.
+func abiHash(t *testing.T, packed []interface{}, types []string, entryPoint common.Address, chainID *big.Int) common.Hash {
+	t.Helper()
+
+	encode := func(values []interface{}, types ...string) []byte {
+		var args abi.Arguments
+		for _, typ := range types {
+			ty, err := abi.NewType(typ, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args = append(args, abi.Argument{Type: ty})
+		}
+		blob, err := args.Pack(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blob
+	}
+	inner := crypto.Keccak256Hash(encode(packed, types...))
+	return crypto.Keccak256Hash(encode([]interface{}{inner, entryPoint, chainID}, "bytes32", "address", "uint256"))
+}
+
+func TestUserOperationHash(t *testing.T) {
+	chainID := big.NewInt(1337)
+
+	// EntryPoint v0.6 hashes all fields as words
+	op := mkTestUserOp()
+	op.InitCode = hexutil.MustDecode("0x00000000000000000000000000000000000fac70deadbeef")
+	op.PaymasterAndData = hexutil.MustDecode("0x0000000000000000000000000000000000000a1dcafe")
+	have, err := op.Hash(entryPointV06, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := abiHash(t, []interface{}{
+		op.Sender.Address(), op.Nonce.ToInt(), crypto.Keccak256Hash(op.InitCode), crypto.Keccak256Hash(op.CallData),
+		op.CallGasLimit.ToInt(), op.VerificationGasLimit.ToInt(), op.PreVerificationGas.ToInt(),
+		op.MaxFeePerGas.ToInt(), op.MaxPriorityFeePerGas.ToInt(), crypto.Keccak256Hash(op.PaymasterAndData),
+	}, []string{"address", "uint256", "bytes32", "bytes32", "uint256", "uint256", "uint256", "uint256", "uint256", "bytes32"}, entryPointV06, chainID)
+	if have != want {
+		t.Errorf("v0.6 hash mismatch: have %v, want %v", have, want)
+	}
+
+	// EntryPoint v0.7 packs the gas fields and joins the factory and paymaster fields
+	op = mkTestUserOp()
+	factory, paymaster := common.HexToAddress("0xfac70"), common.HexToAddress("0xa1d")
+	pmVerification, pmPostOp := hexBig(30000), hexBig(40000)
+	op.Factory, op.FactoryData = &factory, hexutil.MustDecode("0xdeadbeef")
+	op.Paymaster, op.PaymasterData = &paymaster, hexutil.MustDecode("0xcafe")
+	op.PaymasterVerificationGasLimit, op.PaymasterPostOpGasLimit = &pmVerification, &pmPostOp
+
+	have, err = op.Hash(entryPointV07, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	word := func(hi, lo int64) common.Hash {
+		var w common.Hash
+		big.NewInt(hi).FillBytes(w[:16])
+		big.NewInt(lo).FillBytes(w[16:])
+		return w
+	}
+	initCode := append(factory.Bytes(), op.FactoryData...)
+	paymasterAndData := append(paymaster.Bytes(), word(30000, 40000).Bytes()...)
+	paymasterAndData = append(paymasterAndData, op.PaymasterData...)
+
+	want = abiHash(t, []interface{}{
+		op.Sender.Address(), op.Nonce.ToInt(), crypto.Keccak256Hash(initCode), crypto.Keccak256Hash(op.CallData),
+		word(200000, 100000), op.PreVerificationGas.ToInt(), word(1000000000, 2000000000), crypto.Keccak256Hash(paymasterAndData),
+	}, []string{"address", "uint256", "bytes32", "bytes32", "bytes32", "uint256", "bytes32", "bytes32"}, entryPointV07, chainID)
+	if have != want {
+		t.Errorf("v0.7 hash mismatch: have %v, want %v", have, want)
+	}
+	// The hash is bound to the chain and the EntryPoint
+	if other, _ := op.Hash(entryPointV07, big.NewInt(1)); other == have {
+		t.Error("hash doesn't depend on the chain id")
+	}
+}
+
+func TestUserOperationVersion(t *testing.T) {
+	factory := common.HexToAddress("0xfac70")
+	tooBig := hexutil.Big(*new(big.Int).Lsh(big.NewInt(1), 128))
+
+	tests := []struct {
+		name       string
+		entryPoint common.Address
+		modify     func(op *core.UserOperation)
+		version    string
+	}{
+		{"v0.6", entryPointV06, func(op *core.UserOperation) {}, core.EntryPointV06},
+		{"v0.7", entryPointV07, func(op *core.UserOperation) {}, core.EntryPointV07},
+		{"unknown entry point", common.HexToAddress("0x1337"), func(op *core.UserOperation) {}, ""},
+		{"v0.7 fields for v0.6", entryPointV06, func(op *core.UserOperation) { op.Factory = &factory }, ""},
+		{"v0.6 fields for v0.7", entryPointV07, func(op *core.UserOperation) { op.InitCode = []byte{1} }, ""},
+		{"factory data without factory", entryPointV07, func(op *core.UserOperation) { op.FactoryData = []byte{1} }, ""},
+		{"paymaster data without paymaster", entryPointV07, func(op *core.UserOperation) { op.PaymasterData = []byte{1} }, ""},
+		{"gas limit above 128 bits", entryPointV07, func(op *core.UserOperation) { op.CallGasLimit = tooBig }, ""},
+		{"large gas limit for v0.6", entryPointV06, func(op *core.UserOperation) { op.CallGasLimit = tooBig }, core.EntryPointV06},
+	}
+	for _, test := range tests {
+		op := mkTestUserOp()
+		test.modify(&op)
+		version, err := op.EntryPointVersion(test.entryPoint)
+		if test.version == "" {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil || version != test.version {
+			t.Errorf("%s: have version %q (%v), want %q", test.name, version, err, test.version)
+		}
+	}
+}
+
+func TestSignUserOperation(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.NewMixedcaseAddress(list[0])
+	op := mkTestUserOp()
+
+	// Operations for unknown EntryPoints are rejected before asking the UI
+	if _, err := api.SignUserOperation(context.Background(), owner, common.HexToAddress("0x1337"), op, nil); err == nil {
+		t.Fatal("Expected error for unknown EntryPoint")
+	}
+	// Denied
+	control.approveCh <- "N"
+	if _, err := api.SignUserOperation(context.Background(), owner, entryPointV07, op, nil); err != core.ErrRequestDenied {
+		t.Fatalf("Expected ErrRequestDenied, got %v", err)
+	}
+	// Approved
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	signed, err := api.SignUserOperation(context.Background(), owner, entryPointV07, op, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed.Signature) != 65 || signed.Signature[64] != 27 && signed.Signature[64] != 28 {
+		t.Fatalf("Invalid signature %x", signed.Signature)
+	}
+	hash, _ := op.Hash(entryPointV07, big.NewInt(1337))
+	sig := common.CopyBytes(signed.Signature)
+	sig[64] -= 27
+	// ERC-4337 accounts verify the signature over the Ethereum signed message
+	// of the 32 byte operation hash
+	sighash := crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), hash.Bytes())
+	pubkey, err := crypto.SigToPub(sighash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != list[0] {
+		t.Errorf("Signer mismatch: have %v, want %v", signer, list[0])
+	}
+}
+
+// erc1271Account is a contract caller emulating an ERC-1271 account, which
+// accepts a single signature.
+type erc1271Account struct {
+	address   common.Address
+	hash      common.Hash
+	signature []byte
+}
+
+func (c *erc1271Account) CallContract(ctx context.Context, call sdcereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To != c.address {
+		return nil, nil // No code
+	}
+	if !bytes.Equal(call.Data[:4], hexutil.MustDecode("0x1626ba7e")) {
+		return nil, errors.New("execution reverted")
+	}
+	args := call.Data[4:]
+	size := new(big.Int).SetBytes(args[64:96]).Int64()
+	if common.BytesToHash(args[:32]) == c.hash && bytes.Equal(args[96:96+size], c.signature) {
+		return common.RightPadBytes(hexutil.MustDecode("0x1626ba7e"), 32), nil
+	}
+	return common.RightPadBytes([]byte{0xff, 0xff, 0xff, 0xff}, 32), nil
+}
+
+func TestCheckERC1271Signature(t *testing.T) {
+	account := &erc1271Account{
+		address:   common.HexToAddress("0xaa00"),
+		hash:      common.HexToHash("0x01"),
+		signature: bytes.Repeat([]byte{0x42}, 65),
+	}
+	tests := []struct {
+		name      string
+		account   common.Address
+		hash      common.Hash
+		signature []byte
+		valid     bool
+	}{
+		{"valid", account.address, account.hash, account.signature, true},
+		{"wrong hash", account.address, common.HexToHash("0x02"), account.signature, false},
+		{"wrong signature", account.address, account.hash, bytes.Repeat([]byte{0x42}, 64), false},
+		{"no contract", common.HexToAddress("0xbb00"), account.hash, account.signature, false},
+	}
+	for _, test := range tests {
+		valid, err := core.CheckERC1271Signature(context.Background(), account, test.account, test.hash, test.signature)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if valid != test.valid {
+			t.Errorf("%s: have valid %v, want %v", test.name, valid, test.valid)
+		}
+	}
+}
@@ -74,6 +74,16 @@ func verifySelector(selector string, calldata []byte) (*decodedCallData, error)
 	return parseCallData(calldata, string(abidata))
 }
 
+// VerifyCallData checks that the given call data is an invocation of the method
+// with the given signature, returning a human readable form of the call.
+func VerifyCallData(selector string, calldata []byte) (string, error) {
+	info, err := verifySelector(selector, calldata)
+	if err != nil {
+		return "", err
+	}
+	return info.String(), nil
+}
+
 // parseSelector converts a method selector into an ABI JSON spec. The returned
 // data is a valid JSON string which can be consumed by the standard abi package.
 func parseSelector(unescapedSelector string) ([]byte, error) {
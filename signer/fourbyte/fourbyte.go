@@ -34,6 +34,8 @@ type Database struct {
 	embedded   map[string]string
 	custom     map[string]string
 	customPath string
+
+	feeOracle FeeOracle // Optional source of fee levels to judge transactions against
 }
 
 // newEmpty exists for testing purposes.
@@ -73,8 +75,11 @@ func NewFromFile(path string) (*Database, error) {
 // file) as well as a custom database. The latter will be used to write new
 // values into if they are submitted via the API.
 func NewWithFile(path string) (*Database, error) {
-	db := &Database{make(map[string]string), make(map[string]string), path}
-	db.customPath = path
+	db := &Database{
+		embedded:   make(map[string]string),
+		custom:     make(map[string]string),
+		customPath: path,
+	}
 
 	if err := json.Unmarshal(embeddedJSON, &db.embedded); err != nil {
 		return nil, err
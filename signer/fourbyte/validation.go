@@ -18,14 +18,42 @@ package fourbyte
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/core/types"
+	"github.com/spacedogechain/go-spacedogechain/params"
 	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
 )
 
+// extremeFeeFactor is how many times the fee levels suggested by the fee oracle
+// a transaction may pay before it is considered a mistake.
+const extremeFeeFactor = 10
+
+// feeOracleTimeout is the maximum time to wait for the fee oracle.
+const feeOracleTimeout = 3 * time.Second
+
+// FeeOracle provides the current fee levels of the network, which the fees of
+// transactions are judged against. It is implemented by ethclient.Client.
+type FeeOracle interface {
+	// SuggestGasPrice returns the gas price (base fee plus tip on networks with
+	// dynamic fees) needed for timely inclusion.
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+
+	// SuggestGasTipCap returns the tip needed for timely inclusion.
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// SetFeeOracle configures the fee oracle to warn about transactions paying
+// extreme fees with.
+func (db *Database) SetFeeOracle(oracle FeeOracle) {
+	db.feeOracle = oracle
+}
+
 // ValidateTransaction does a number of checks on the supplied transaction, and
 // returns either a list of warnings, or an error (indicating that the transaction
 // should be immediately rejected).
@@ -45,6 +73,13 @@ func (db *Database) ValidateTransaction(selector *string, tx *apitypes.SendTxArg
 	if tx.Data != nil {
 		data = *tx.Data
 	}
+	// Reject invalid fees (show stopper) and judge valid ones
+	if err := db.validateFees(tx, messages); err != nil {
+		return nil, err
+	}
+	if tx.AccessList != nil {
+		validateAccessList(*tx.AccessList, messages)
+	}
 	// Contract creation doesn't validate call data, handle first
 	if tx.To == nil {
 		// Contract creation should contain sufficient data to deploy a contract. A
@@ -73,6 +108,17 @@ func (db *Database) ValidateTransaction(selector *string, tx *apitypes.SendTxArg
 	if bytes.Equal(tx.To.Address().Bytes(), common.Address{}.Bytes()) {
 		messages.Crit("Transaction recipient is the zero address")
 	}
+	// Semantic fields validated, try to make heads or tails of the call data
+	db.ValidateCallData(selector, data, messages)
+	return messages, nil
+}
+
+// validateFees checks that the fee fields match the transaction type and each
+// other, and warns about fees far above the levels suggested by the fee oracle.
+func (db *Database) validateFees(tx *apitypes.SendTxArgs, messages *apitypes.ValidationMessages) error {
+	if _, err := tx.TxType(); err != nil {
+		return err
+	}
 	switch {
 	case tx.GasPrice == nil && tx.MaxFeePerGas == nil:
 		messages.Crit("Neither 'gasPrice' nor 'maxFeePerGas' specified.")
@@ -83,9 +129,76 @@ func (db *Database) ValidateTransaction(selector *string, tx *apitypes.SendTxArg
 	case tx.GasPrice != nil && tx.MaxPriorityFeePerGas != nil:
 		messages.Crit("Both 'gasPrice' and 'maxPriorityFeePerGas' specified.")
 	}
-	// Semantic fields validated, try to make heads or tails of the call data
-	db.ValidateCallData(selector, data, messages)
-	return messages, nil
+	// The tip is capped by the fee cap, a higher tip makes the transaction invalid
+	if tx.MaxFeePerGas != nil && tx.MaxPriorityFeePerGas != nil {
+		if feeCap, tip := tx.MaxFeePerGas.ToInt(), tx.MaxPriorityFeePerGas.ToInt(); tip.Cmp(feeCap) > 0 {
+			return fmt.Errorf("'maxPriorityFeePerGas' (%v wei) is higher than 'maxFeePerGas' (%v wei)", tip, feeCap)
+		}
+	}
+	if db.feeOracle == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), feeOracleTimeout)
+	defer cancel()
+
+	feeCap := tx.GasPrice
+	if tx.MaxFeePerGas != nil {
+		feeCap = tx.MaxFeePerGas
+	}
+	if feeCap != nil {
+		price, err := db.feeOracle.SuggestGasPrice(ctx)
+		if err != nil {
+			messages.Info(fmt.Sprintf("Fee oracle unavailable: %v", err))
+			return nil
+		}
+		if price.Sign() > 0 {
+			switch {
+			case feeCap.ToInt().Cmp(new(big.Int).Mul(price, big.NewInt(extremeFeeFactor))) > 0:
+				messages.Warn(fmt.Sprintf("Fee cap of %v wei is more than %d times the current gas price of %v wei", feeCap.ToInt(), extremeFeeFactor, price))
+			case feeCap.ToInt().Cmp(price) < 0:
+				messages.Info(fmt.Sprintf("Fee cap of %v wei is below the current gas price of %v wei, the transaction might not be included soon", feeCap.ToInt(), price))
+			}
+		}
+	}
+	if tx.MaxPriorityFeePerGas != nil {
+		tip, err := db.feeOracle.SuggestGasTipCap(ctx)
+		if err != nil {
+			messages.Info(fmt.Sprintf("Fee oracle unavailable: %v", err))
+			return nil
+		}
+		if tip.Sign() > 0 && tx.MaxPriorityFeePerGas.ToInt().Cmp(new(big.Int).Mul(tip, big.NewInt(extremeFeeFactor))) > 0 {
+			messages.Warn(fmt.Sprintf("Priority fee of %v wei is more than %d times the current tip of %v wei", tx.MaxPriorityFeePerGas.ToInt(), extremeFeeFactor, tip))
+		}
+	}
+	return nil
+}
+
+// validateAccessList warns about duplicate entries of an access list, which cost
+// gas without any benefit, and summarizes the accesses declared.
+func validateAccessList(list types.AccessList, messages *apitypes.ValidationMessages) {
+	var (
+		addresses = make(map[common.Address]struct{})
+		slots     int
+	)
+	for _, tuple := range list {
+		if _, ok := addresses[tuple.Address]; ok {
+			messages.Warn(fmt.Sprintf("Access list contains address %v more than once", tuple.Address))
+		}
+		addresses[tuple.Address] = struct{}{}
+
+		keys := make(map[common.Hash]struct{})
+		for _, key := range tuple.StorageKeys {
+			if _, ok := keys[key]; ok {
+				messages.Warn(fmt.Sprintf("Access list contains storage slot %v of address %v more than once", key, tuple.Address))
+			}
+			keys[key] = struct{}{}
+		}
+		slots += len(tuple.StorageKeys)
+	}
+	if len(list) > 0 {
+		gas := uint64(len(list))*params.TxAccessListAddressGas + uint64(slots)*params.TxAccessListStorageKeyGas
+		messages.Info(fmt.Sprintf("Transaction declares access to %d addresses and %d storage slots, costing %d gas upfront", len(list), slots, gas))
+	}
 }
 
 // ValidateCallData checks if the ABI call-data + method selector (if given) can
@@ -17,11 +17,13 @@
 package fourbyte
 
 import (
+	"context"
 	"math/big"
 	"testing"
 
 	"github.com/spacedogechain/go-spacedogechain/common"
 	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/core/types"
 	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
 )
 
@@ -134,3 +136,92 @@ func TestTransactionValidation(t *testing.T) {
 		}
 	}
 }
+
+// testFeeOracle is a fee oracle suggesting fixed fee levels.
+type testFeeOracle struct {
+	price, tip *big.Int
+}
+
+func (o *testFeeOracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return o.price, nil
+}
+
+func (o *testFeeOracle) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return o.tip, nil
+}
+
+func TestFeeValidation(t *testing.T) {
+	db := newEmpty()
+	db.SetFeeOracle(&testFeeOracle{price: big.NewInt(100), tip: big.NewInt(10)})
+
+	wei := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+	typ := func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+
+	var (
+		to    = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+		mixed = common.NewMixedcaseAddress(to)
+		slot  = common.Hash{0x01}
+	)
+	testcases := []struct {
+		gasPrice, maxFee, maxTip *hexutil.Big
+		accessList               *types.AccessList
+		typ                      *hexutil.Uint64
+		expectErr                bool
+		numMessages              int
+	}{
+		// Fees in line with the oracle
+		{gasPrice: wei(120)},
+		{maxFee: wei(200), maxTip: wei(20)},
+		// Tip above fee cap
+		{maxFee: wei(200), maxTip: wei(201), expectErr: true},
+		// Extreme fee cap, tip and gas price
+		{maxFee: wei(1001), maxTip: wei(20), numMessages: 1},
+		{maxFee: wei(2000), maxTip: wei(101), numMessages: 2},
+		{gasPrice: wei(1001), numMessages: 1},
+		// Fee cap too low for timely inclusion
+		{maxFee: wei(50), maxTip: wei(10), numMessages: 1},
+		// Explicit types
+		{gasPrice: wei(120), typ: typ(types.LegacyTxType)},
+		{gasPrice: wei(120), typ: typ(types.AccessListTxType)},
+		{maxFee: wei(200), maxTip: wei(20), typ: typ(types.DynamicFeeTxType)},
+		{gasPrice: wei(120), typ: typ(types.DynamicFeeTxType), expectErr: true},
+		{maxFee: wei(200), maxTip: wei(20), typ: typ(types.LegacyTxType), expectErr: true},
+		{maxFee: wei(200), maxTip: wei(20), typ: typ(types.AccessListTxType), expectErr: true},
+		{gasPrice: wei(120), accessList: &types.AccessList{}, typ: typ(types.LegacyTxType), expectErr: true},
+		{gasPrice: wei(120), typ: typ(3), expectErr: true},
+		// Access lists
+		{gasPrice: wei(120), accessList: &types.AccessList{}},
+		{gasPrice: wei(120), accessList: &types.AccessList{{Address: to, StorageKeys: []common.Hash{slot}}}, numMessages: 1},
+		{gasPrice: wei(120), accessList: &types.AccessList{{Address: to}, {Address: to}}, numMessages: 2},
+		{maxFee: wei(200), maxTip: wei(20), accessList: &types.AccessList{{Address: to, StorageKeys: []common.Hash{slot, slot}}}, numMessages: 2},
+	}
+	for i, test := range testcases {
+		tx := &apitypes.SendTxArgs{
+			From:                 mixed,
+			To:                   &mixed,
+			Gas:                  21000,
+			GasPrice:             test.gasPrice,
+			MaxFeePerGas:         test.maxFee,
+			MaxPriorityFeePerGas: test.maxTip,
+			AccessList:           test.accessList,
+			Type:                 test.typ,
+		}
+		msgs, err := db.ValidateTransaction(nil, tx)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if len(msgs.Messages) != test.numMessages {
+			for _, msg := range msgs.Messages {
+				t.Logf("* %s: %s", msg.Typ, msg.Message)
+			}
+			t.Errorf("test %d: expected %d messages, got %d", i, test.numMessages, len(msgs.Messages))
+		}
+	}
+}
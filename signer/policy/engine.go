@@ -0,0 +1,451 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/internal/ethapi"
+	"github.com/spacedogechain/go-spacedogechain/log"
+	"github.com/spacedogechain/go-spacedogechain/signer/core"
+	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
+	"github.com/spacedogechain/go-spacedogechain/signer/fourbyte"
+	"github.com/spacedogechain/go-spacedogechain/signer/storage"
+)
+
+// SelectorDB resolves 4byte method selectors into method signatures. It is
+// implemented by fourbyte.Database.
+type SelectorDB interface {
+	Selector(id []byte) (string, error)
+}
+
+// verdict is the outcome of evaluating a request against the policy.
+type verdict int
+
+const (
+	verdictManual verdict = iota // Not covered by the policy, ask the user
+	verdictApprove
+	verdictReject
+)
+
+func (v verdict) String() string {
+	switch v {
+	case verdictApprove:
+		return "approve"
+	case verdictReject:
+		return "reject"
+	default:
+		return "manual"
+	}
+}
+
+// Engine is a UIClientAPI which approves or rejects requests according to a
+// declarative policy. Requests the policy doesn't cover are forwarded to the
+// next UI for manual processing.
+type Engine struct {
+	next    core.UIClientAPI
+	db      SelectorDB
+	storage storage.Storage // Persistent spending counters
+	policy  *compiledPolicy
+	dryRun  bool
+
+	lock sync.Mutex // Serializes counter updates
+	now  func() time.Time
+}
+
+// NewEngine creates a policy engine in front of the given UI. In dry-run mode,
+// the engine only logs its verdicts and forwards every request to the UI.
+func NewEngine(next core.UIClientAPI, db SelectorDB, storage storage.Storage, policy *Policy, dryRun bool) (*Engine, error) {
+	cp, err := policy.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{
+		next:    next,
+		db:      db,
+		storage: storage,
+		policy:  cp,
+		dryRun:  dryRun,
+		now:     time.Now,
+	}, nil
+}
+
+// violation returns the verdict for a request breaking the policy.
+func (e *Engine) violation() verdict {
+	if e.policy.manualOnViolation {
+		return verdictManual
+	}
+	return verdictReject
+}
+
+func (e *Engine) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	tx := &request.Transaction
+	v, reason := e.evaluateTx(tx)
+	if e.dryRun {
+		log.Info("Policy dry-run", "request", "transaction", "from", tx.From.Address(), "verdict", v, "reason", reason)
+		return e.next.ApproveTx(request)
+	}
+	switch v {
+	case verdictApprove:
+		log.Info("Policy approved transaction", "from", tx.From.Address())
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+	case verdictReject:
+		log.Warn("Policy rejected transaction", "from", tx.From.Address(), "reason", reason)
+		return core.SignTxResponse{Approved: false}, nil
+	default:
+		log.Info("Policy forwarding transaction", "from", tx.From.Address(), "reason", reason)
+		return e.next.ApproveTx(request)
+	}
+}
+
+// evaluateTx checks a transaction against the policy. Approved transactions
+// are added to the spending counters, unless running in dry-run mode.
+func (e *Engine) evaluateTx(tx *apitypes.SendTxArgs) (verdict, string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	from := tx.From.Address()
+	rules := e.policy.rules(from)
+	if rules == nil {
+		return verdictManual, "no policy for account"
+	}
+	if tx.To == nil {
+		return e.violation(), "contract creation"
+	}
+	if to := tx.To.Address(); rules.recipients != nil && !rules.recipients[to] {
+		return e.violation(), fmt.Sprintf("recipient %v not allowed", to)
+	}
+	var data []byte
+	if tx.Input != nil {
+		data = *tx.Input
+	} else if tx.Data != nil {
+		data = *tx.Data
+	}
+	if len(data) > 0 {
+		if reason := e.checkCall(rules, data); reason != "" {
+			return e.violation(), reason
+		}
+	}
+	if rules.maxGasPrice != nil {
+		price := tx.GasPrice
+		if tx.MaxFeePerGas != nil {
+			price = tx.MaxFeePerGas
+		}
+		if price == nil || price.ToInt().Cmp(rules.maxGasPrice) > 0 {
+			return e.violation(), fmt.Sprintf("gas price above %v wei", rules.maxGasPrice)
+		}
+	}
+	return e.chargeLimits(from, rules, tx.Value.ToInt())
+}
+
+// chargeLimits checks a transfer of the given value against the spending limits
+// of an account. Transfers within the limits are added to the spending counters,
+// unless running in dry-run mode. The lock must be held.
+func (e *Engine) chargeLimits(from common.Address, rules *accountRules, value *big.Int) (verdict, string) {
+	history, err := e.spendings(from)
+	if err != nil {
+		// The limits can't be enforced without the history, never let such
+		// requests through, not even to manual approval.
+		log.Error("Unreadable policy spending history", "account", from, "err", err)
+		return verdictReject, "unreadable spending history"
+	}
+	now := e.now()
+	for _, limit := range rules.limits {
+		spent := new(big.Int).Set(value)
+		for _, s := range history {
+			if now.Sub(time.Unix(s.Time, 0)) < limit.window {
+				spent.Add(spent, s.Value.ToInt())
+			}
+		}
+		if spent.Cmp(limit.amount) > 0 {
+			return e.violation(), fmt.Sprintf("spending limit of %v wei per %v exceeded", limit.amount, limit.window)
+		}
+	}
+	if !e.dryRun && value.Sign() > 0 && len(rules.limits) > 0 {
+		e.recordSpending(from, history, value, now)
+	}
+	return verdictApprove, ""
+}
+
+// checkCall checks the call data of a transaction against the method allowlist,
+// returning the reason for rejecting it or an empty string.
+func (e *Engine) checkCall(rules *accountRules, data []byte) string {
+	if len(data) < 4 {
+		return "invalid call data"
+	}
+	var id [4]byte
+	copy(id[:], data)
+
+	sig, allowed := rules.methods[id]
+	if !allowed && !rules.anyMethod {
+		method := "0x" + hex.EncodeToString(id[:])
+		if name, err := e.db.Selector(id[:]); err == nil {
+			method = name
+		}
+		return fmt.Sprintf("method %s not allowed", method)
+	}
+	// Ensure the call data actually decodes as the allowed method, resolving
+	// the signature of bare selectors through the 4byte database.
+	if sig == "" {
+		if name, err := e.db.Selector(id[:]); err == nil {
+			sig = name
+		}
+	}
+	if sig != "" {
+		if _, err := fourbyte.VerifyCallData(sig, data); err != nil {
+			return fmt.Sprintf("call data does not match %s: %v", sig, err)
+		}
+	}
+	return ""
+}
+
+// spending is an entry of the spending history of an account.
+type spending struct {
+	Time  int64        `json:"time"`
+	Value *hexutil.Big `json:"value"`
+}
+
+func spendingKey(addr common.Address) string {
+	return "policy/spent/" + strings.ToLower(addr.Hex())
+}
+
+// spendings returns the spending history of an account. Only a missing history
+// is treated as empty, any other storage failure is returned.
+func (e *Engine) spendings(addr common.Address) ([]spending, error) {
+	blob, err := e.storage.Get(spendingKey(addr))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []spending
+	if err := json.Unmarshal([]byte(blob), &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// recordSpending adds a transfer to the spending history of an account, dropping
+// entries which are older than all spending windows.
+func (e *Engine) recordSpending(addr common.Address, history []spending, value *big.Int, now time.Time) {
+	var kept []spending
+	for _, s := range history {
+		if now.Sub(time.Unix(s.Time, 0)) < e.policy.maxWindow {
+			kept = append(kept, s)
+		}
+	}
+	kept = append(kept, spending{Time: now.Unix(), Value: (*hexutil.Big)(value)})
+	blob, err := json.Marshal(kept)
+	if err != nil {
+		log.Error("Failed to encode policy spending history", "err", err)
+		return
+	}
+	e.storage.Put(spendingKey(addr), string(blob))
+}
+
+func (e *Engine) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	addr := request.Address.Address()
+	v, reason := e.evaluateSignData(request)
+	if e.dryRun {
+		log.Info("Policy dry-run", "request", "sign data", "address", addr, "verdict", v, "reason", reason)
+		return e.next.ApproveSignData(request)
+	}
+	switch v {
+	case verdictApprove:
+		log.Info("Policy approved data signing", "address", addr)
+		return core.SignDataResponse{Approved: true}, nil
+	case verdictReject:
+		log.Warn("Policy rejected data signing", "address", addr, "reason", reason)
+		return core.SignDataResponse{Approved: false}, nil
+	default:
+		log.Info("Policy forwarding data signing", "address", addr, "reason", reason)
+		return e.next.ApproveSignData(request)
+	}
+}
+
+// evaluateSignData checks a data signing request against the policy. Only typed
+// data and user operations can be approved, based on their domain and EntryPoint.
+func (e *Engine) evaluateSignData(request *core.SignDataRequest) (verdict, string) {
+	rules := e.policy.rules(request.Address.Address())
+	if rules == nil {
+		return verdictManual, "no policy for account"
+	}
+	if request.UserOperation != nil {
+		return e.evaluateUserOperation(request.Address.Address(), rules, request.UserOperation)
+	}
+	if request.ContentType != apitypes.DataTyped.Mime || len(rules.domains) == 0 {
+		return verdictManual, "not covered by policy"
+	}
+	domain, ok := typedDataDomain(request.Messages)
+	if !ok {
+		return e.violation(), "missing typed data domain"
+	}
+	for _, rule := range rules.domains {
+		if rule.matches(domain) {
+			return verdictApprove, ""
+		}
+	}
+	return e.violation(), fmt.Sprintf("typed data domain %q not allowed", domain["name"])
+}
+
+// evaluateUserOperation checks a user operation against the policy, treating the
+// call executed by the smart contract account like a transaction. The value moved
+// by approved operations is added to the spending counters of the signer.
+func (e *Engine) evaluateUserOperation(from common.Address, rules *accountRules, info *core.UserOperationInfo) (verdict, string) {
+	if len(rules.entryPoints) == 0 {
+		return verdictManual, "not covered by policy"
+	}
+	if !rules.entryPoints[info.EntryPoint] {
+		return e.violation(), fmt.Sprintf("entry point %v not allowed", info.EntryPoint)
+	}
+	op := info.Operation
+	value := new(big.Int)
+	if len(op.CallData) > 0 {
+		to, amount, data, err := decodeExecute(op.CallData)
+		if err != nil {
+			return e.violation(), err.Error()
+		}
+		if rules.recipients != nil && !rules.recipients[to] {
+			return e.violation(), fmt.Sprintf("recipient %v not allowed", to)
+		}
+		if len(data) > 0 {
+			if reason := e.checkCall(rules, data); reason != "" {
+				return e.violation(), reason
+			}
+		}
+		value = amount
+	}
+	if rules.maxGasPrice != nil && op.MaxFeePerGas.ToInt().Cmp(rules.maxGasPrice) > 0 {
+		return e.violation(), fmt.Sprintf("gas price above %v wei", rules.maxGasPrice)
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.chargeLimits(from, rules, value)
+}
+
+// executeSignature is the method of smart contract accounts through which user
+// operations call other contracts and transfer value.
+const executeSignature = "execute(address,uint256,bytes)"
+
+var executeSelector = crypto.Keccak256([]byte(executeSignature))[:4]
+
+// decodeExecute unpacks the call data of a user operation into the destination,
+// value and call data of the call executed by the smart contract account. Only
+// calls of execute can be evaluated, batches and other methods are rejected.
+func decodeExecute(data []byte) (common.Address, *big.Int, []byte, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], executeSelector) {
+		return common.Address{}, nil, nil, fmt.Errorf("calls other than %s not allowed", executeSignature)
+	}
+	if _, err := fourbyte.VerifyCallData(executeSignature, data); err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("call data does not match %s: %v", executeSignature, err)
+	}
+	// The call data was checked to be canonically encoded, so the inner call
+	// data follows its length right after the static arguments.
+	args := data[4:]
+	size := new(big.Int).SetBytes(args[96:128]).Uint64()
+	return common.BytesToAddress(args[:32]), new(big.Int).SetBytes(args[32:64]), args[128 : 128+size], nil
+}
+
+// typedDataDomain extracts the EIP-712 domain fields from the formatted typed
+// data of a signing request.
+func typedDataDomain(messages []*apitypes.NameValueType) (map[string]string, bool) {
+	for _, msg := range messages {
+		if msg.Typ != "domain" {
+			continue
+		}
+		fields, ok := msg.Value.([]*apitypes.NameValueType)
+		if !ok {
+			return nil, false
+		}
+		domain := make(map[string]string)
+		for _, field := range fields {
+			if value, ok := field.Value.(string); ok {
+				domain[field.Name] = value
+			}
+		}
+		return domain, true
+	}
+	return nil, false
+}
+
+func (rule *domainRule) matches(domain map[string]string) bool {
+	if rule.name != "" && domain["name"] != rule.name {
+		return false
+	}
+	if rule.version != "" && domain["version"] != rule.version {
+		return false
+	}
+	if rule.chainID != nil {
+		// Integers are formatted as "<decimal> (<hex>)"
+		id, ok := new(big.Int).SetString(strings.Fields(domain["chainId"] + " ")[0], 10)
+		if !ok || id.Cmp(rule.chainID) != 0 {
+			return false
+		}
+	}
+	if rule.contract != nil {
+		contract, ok := domain["verifyingContract"]
+		if !ok || !common.IsHexAddress(contract) || common.HexToAddress(contract) != *rule.contract {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	return e.next.ApproveListing(request)
+}
+
+func (e *Engine) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return e.next.ApproveNewAccount(request)
+}
+
+func (e *Engine) ShowError(message string) {
+	e.next.ShowError(message)
+}
+
+func (e *Engine) ShowInfo(message string) {
+	e.next.ShowInfo(message)
+}
+
+func (e *Engine) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	e.next.OnApprovedTx(tx)
+}
+
+func (e *Engine) OnSignerStartup(info core.StartupInfo) {
+	e.next.OnSignerStartup(info)
+}
+
+func (e *Engine) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return e.next.OnInputRequired(info)
+}
+
+func (e *Engine) RegisterUIServer(api *core.UIServerAPI) {
+	e.next.RegisterUIServer(api)
+}
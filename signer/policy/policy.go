@@ -0,0 +1,315 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package policy implements a declarative alternative to the javascript rules
+// of clef. Policies are read from a YAML or JSON file and evaluated natively.
+package policy
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"gopkg.in/yaml.v2"
+)
+
+// Policy is the declarative signing policy as stored in the policy file.
+type Policy struct {
+	// OnViolation decides what happens to requests breaking the policy of their
+	// account: "reject" (the default) denies them, "manual" passes them on to
+	// the user for approval.
+	OnViolation string `yaml:"onViolation"`
+
+	// Accounts maps signer addresses to the rules of the account. The rules of
+	// the "*" entry apply to all accounts which are not listed explicitly.
+	// Requests from accounts without rules are always passed on to the user.
+	Accounts map[string]*AccountPolicy `yaml:"accounts"`
+}
+
+// AccountPolicy contains the rules for requests of a single account. Requests
+// are approved if they satisfy all of the rules.
+type AccountPolicy struct {
+	// SpendingLimits caps the value sent by approved transactions within
+	// rolling time windows.
+	SpendingLimits []SpendingLimit `yaml:"spendingLimits"`
+
+	// Recipients is the list of addresses transactions may be sent to. If empty,
+	// any recipient is allowed. Contract creations are never approved.
+	Recipients []string `yaml:"recipients"`
+
+	// Methods is the list of contract methods transactions may invoke, either
+	// as signatures like "transfer(address,uint256)" or as hex 4byte selectors.
+	// Transactions carrying call data are only approved if their method is
+	// listed here, "*" allows any method.
+	Methods []string `yaml:"methods"`
+
+	// MaxGasPrice is the highest gas price (or fee cap for dynamic fee
+	// transactions) which is approved.
+	MaxGasPrice string `yaml:"maxGasPrice"`
+
+	// TypedData lists the EIP-712 domains typed data may be signed for. Typed
+	// data is approved if its domain matches any of the entries, all other
+	// data signing requests are passed on to the user.
+	TypedData []DomainRule `yaml:"typedData"`
+
+	// EntryPoints lists the ERC-4337 EntryPoints user operations may be signed
+	// for. User operations are approved if they target one of these, call execute
+	// on the smart contract account, and the destination, call data and value of
+	// the executed call and the fee cap satisfy the recipient, method, spending
+	// and gas price rules, as for transactions. Batches are not approved.
+	EntryPoints []string `yaml:"entryPoints"`
+}
+
+// SpendingLimit caps the total value of transactions approved within a rolling
+// time window. Gas costs are not included.
+type SpendingLimit struct {
+	Window string `yaml:"window"` // Duration of the window, e.g. "24h"
+	Amount string `yaml:"amount"` // Maximum value, e.g. "1.5 sdcer", "300 gwei" or "1000" (wei)
+}
+
+// DomainRule matches EIP-712 domains. Empty fields match any value.
+type DomainRule struct {
+	Name              string `yaml:"name"`
+	Version           string `yaml:"version"`
+	ChainID           string `yaml:"chainId"`
+	VerifyingContract string `yaml:"verifyingContract"`
+}
+
+// Load reads a policy from the given YAML or JSON file.
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a policy from YAML or JSON. Unknown fields are rejected, so
+// that misspelt rules can't silently weaken the policy.
+func Parse(data []byte) (*Policy, error) {
+	p := new(Policy)
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	return p, nil
+}
+
+// wildcard is the account key of the default rules.
+const wildcard = "*"
+
+// compiledPolicy is the validated form of a Policy.
+type compiledPolicy struct {
+	manualOnViolation bool
+	accounts          map[common.Address]*accountRules
+	fallback          *accountRules
+	maxWindow         time.Duration // Longest spending window, for pruning history
+}
+
+type accountRules struct {
+	limits      []spendingLimit
+	recipients  map[common.Address]bool // nil if any recipient is allowed
+	methods     map[[4]byte]string      // selector -> signature, if known
+	anyMethod   bool
+	maxGasPrice *big.Int
+	domains     []domainRule
+	entryPoints map[common.Address]bool
+}
+
+type spendingLimit struct {
+	window time.Duration
+	amount *big.Int
+}
+
+type domainRule struct {
+	name, version string
+	chainID       *big.Int
+	contract      *common.Address
+}
+
+// compile validates the policy and converts it into its internal form.
+func (p *Policy) compile() (*compiledPolicy, error) {
+	cp := &compiledPolicy{accounts: make(map[common.Address]*accountRules)}
+	switch p.OnViolation {
+	case "", "reject":
+	case "manual":
+		cp.manualOnViolation = true
+	default:
+		return nil, fmt.Errorf("invalid onViolation %q, want \"reject\" or \"manual\"", p.OnViolation)
+	}
+	for key, ap := range p.Accounts {
+		if ap == nil {
+			ap = new(AccountPolicy)
+		}
+		rules, err := ap.compile()
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", key, err)
+		}
+		for _, limit := range rules.limits {
+			if limit.window > cp.maxWindow {
+				cp.maxWindow = limit.window
+			}
+		}
+		if key == wildcard {
+			cp.fallback = rules
+			continue
+		}
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("invalid account %q", key)
+		}
+		addr := common.HexToAddress(key)
+		if _, exists := cp.accounts[addr]; exists {
+			return nil, fmt.Errorf("duplicate account %v", addr)
+		}
+		cp.accounts[addr] = rules
+	}
+	return cp, nil
+}
+
+// rules returns the rules for the given account, or nil if it has none.
+func (cp *compiledPolicy) rules(addr common.Address) *accountRules {
+	if rules, ok := cp.accounts[addr]; ok {
+		return rules
+	}
+	return cp.fallback
+}
+
+func (ap *AccountPolicy) compile() (*accountRules, error) {
+	rules := new(accountRules)
+	for _, limit := range ap.SpendingLimits {
+		window, err := time.ParseDuration(limit.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid spending limit window %q", limit.Window)
+		}
+		amount, err := parseAmount(limit.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spending limit amount: %v", err)
+		}
+		rules.limits = append(rules.limits, spendingLimit{window, amount})
+	}
+	if len(ap.Recipients) > 0 {
+		rules.recipients = make(map[common.Address]bool)
+		for _, r := range ap.Recipients {
+			if !common.IsHexAddress(r) {
+				return nil, fmt.Errorf("invalid recipient %q", r)
+			}
+			rules.recipients[common.HexToAddress(r)] = true
+		}
+	}
+	rules.methods = make(map[[4]byte]string)
+	for _, m := range ap.Methods {
+		if m == wildcard {
+			rules.anyMethod = true
+			continue
+		}
+		sig, selector, err := parseMethod(m)
+		if err != nil {
+			return nil, err
+		}
+		rules.methods[selector] = sig
+	}
+	if ap.MaxGasPrice != "" {
+		price, err := parseAmount(ap.MaxGasPrice)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max gas price: %v", err)
+		}
+		rules.maxGasPrice = price
+	}
+	for _, d := range ap.TypedData {
+		rule := domainRule{name: d.Name, version: d.Version}
+		if d.ChainID != "" {
+			id, ok := new(big.Int).SetString(d.ChainID, 0)
+			if !ok {
+				return nil, fmt.Errorf("invalid typed data chain id %q", d.ChainID)
+			}
+			rule.chainID = id
+		}
+		if d.VerifyingContract != "" {
+			if !common.IsHexAddress(d.VerifyingContract) {
+				return nil, fmt.Errorf("invalid typed data verifying contract %q", d.VerifyingContract)
+			}
+			addr := common.HexToAddress(d.VerifyingContract)
+			rule.contract = &addr
+		}
+		rules.domains = append(rules.domains, rule)
+	}
+	if len(ap.EntryPoints) > 0 {
+		rules.entryPoints = make(map[common.Address]bool)
+		for _, ep := range ap.EntryPoints {
+			if !common.IsHexAddress(ep) {
+				return nil, fmt.Errorf("invalid entry point %q", ep)
+			}
+			rules.entryPoints[common.HexToAddress(ep)] = true
+		}
+	}
+	return rules, nil
+}
+
+// parseMethod parses a method signature or hex selector, returning the
+// canonical signature (empty for selectors) and the 4byte selector.
+func parseMethod(m string) (string, [4]byte, error) {
+	var selector [4]byte
+	if strings.HasPrefix(m, "0x") {
+		id, err := hex.DecodeString(m[2:])
+		if err != nil || len(id) != 4 {
+			return "", selector, fmt.Errorf("invalid method selector %q", m)
+		}
+		copy(selector[:], id)
+		return "", selector, nil
+	}
+	sig := strings.ReplaceAll(m, " ", "")
+	if open := strings.IndexByte(sig, '('); open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", selector, fmt.Errorf("invalid method signature %q", m)
+	}
+	copy(selector[:], crypto.Keccak256([]byte(sig)))
+	return sig, selector, nil
+}
+
+// denominations are the units accepted in amounts.
+var denominations = map[string]*big.Int{
+	"wei":   big.NewInt(1),
+	"gwei":  big.NewInt(1e9),
+	"sdcer": big.NewInt(1e18),
+}
+
+// parseAmount parses an amount like "1.5 sdcer", "30 gwei" or "1000" (wei)
+// into wei.
+func parseAmount(s string) (*big.Int, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	unit := denominations["wei"]
+	if len(fields) == 2 {
+		var ok bool
+		if unit, ok = denominations[strings.ToLower(fields[1])]; !ok {
+			return nil, fmt.Errorf("invalid amount %q: unknown unit %q", s, fields[1])
+		}
+	}
+	value, ok := new(big.Rat).SetString(fields[0])
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	value.Mul(value, new(big.Rat).SetInt(unit))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("invalid amount %q: fractional wei", s)
+	}
+	return value.Num(), nil
+}
@@ -0,0 +1,570 @@
+// Copyright 2022 The go-spacedogechain Authors
+// This file is part of the go-spacedogechain library.
+//
+// The go-spacedogechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-spacedogechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-spacedogechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacedogechain/go-spacedogechain/common"
+	"github.com/spacedogechain/go-spacedogechain/common/hexutil"
+	"github.com/spacedogechain/go-spacedogechain/common/math"
+	"github.com/spacedogechain/go-spacedogechain/crypto"
+	"github.com/spacedogechain/go-spacedogechain/internal/ethapi"
+	"github.com/spacedogechain/go-spacedogechain/signer/core"
+	"github.com/spacedogechain/go-spacedogechain/signer/core/apitypes"
+	"github.com/spacedogechain/go-spacedogechain/signer/storage"
+)
+
+var (
+	alice    = common.HexToAddress("0x000000000000000000000000000000000000a11c")
+	bob      = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	token    = common.HexToAddress("0x00000000000000000000000000000000000070c0")
+	mallory  = common.HexToAddress("0x000000000000000000000000000000000000bad0")
+	verifier = common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+)
+
+const testPolicy = `
+onViolation: reject
+accounts:
+  "0x000000000000000000000000000000000000a11c":
+    spendingLimits:
+      - window: 24h
+        amount: 1 sdcer
+      - window: 1h
+        amount: 0.5 sdcer
+    recipients:
+      - "0x0000000000000000000000000000000000000b0b"
+      - "0x00000000000000000000000000000000000070c0"
+    methods:
+      - transfer(address, uint256)
+      - "0x095ea7b3"
+    maxGasPrice: 100 gwei
+    typedData:
+      - name: Permit
+        chainId: 1
+        verifyingContract: "0xcccccccccccccccccccccccccccccccccccccccc"
+`
+
+// forwardingUI records the requests forwarded to it and denies them.
+type forwardingUI struct {
+	txs, data int
+}
+
+func (ui *forwardingUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	ui.txs++
+	return core.SignTxResponse{Transaction: request.Transaction, Approved: false}, nil
+}
+
+func (ui *forwardingUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	ui.data++
+	return core.SignDataResponse{Approved: false}, nil
+}
+
+func (ui *forwardingUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	return core.ListResponse{}, nil
+}
+
+func (ui *forwardingUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return core.NewAccountResponse{}, nil
+}
+
+func (ui *forwardingUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return core.UserInputResponse{}, nil
+}
+
+func (ui *forwardingUI) ShowError(message string)                     {}
+func (ui *forwardingUI) ShowInfo(message string)                      {}
+func (ui *forwardingUI) OnApprovedTx(tx ethapi.SignTransactionResult) {}
+func (ui *forwardingUI) OnSignerStartup(info core.StartupInfo)        {}
+func (ui *forwardingUI) RegisterUIServer(api *core.UIServerAPI)       {}
+
+// testSelectors is a minimal 4byte database.
+type testSelectors map[string]string
+
+func (db testSelectors) Selector(id []byte) (string, error) {
+	if sig, ok := db[hexutil.Encode(id)]; ok {
+		return sig, nil
+	}
+	return "", errors.New("not found")
+}
+
+var selectors = testSelectors{
+	"0xa9059cbb": "transfer(address,uint256)",
+	"0x095ea7b3": "approve(address,uint256)",
+	"0x23b872dd": "transferFrom(address,address,uint256)",
+}
+
+func newTestEngine(t *testing.T, policy string, store storage.Storage, dryRun bool) (*Engine, *forwardingUI) {
+	t.Helper()
+	p, err := Parse([]byte(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := new(forwardingUI)
+	e, err := NewEngine(ui, selectors, store, p, dryRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, ui
+}
+
+func sdcer(f float64) *big.Int {
+	v, _ := new(big.Float).Mul(big.NewFloat(f), big.NewFloat(1e18)).Int(nil)
+	return v
+}
+
+func txRequest(from common.Address, to *common.Address, value *big.Int, data []byte) *core.SignTxRequest {
+	tx := apitypes.SendTxArgs{
+		From:     common.NewMixedcaseAddress(from),
+		Value:    hexutil.Big(*value),
+		GasPrice: (*hexutil.Big)(big.NewInt(1e9)),
+	}
+	if to != nil {
+		addr := common.NewMixedcaseAddress(*to)
+		tx.To = &addr
+	}
+	if data != nil {
+		input := hexutil.Bytes(data)
+		tx.Input = &input
+	}
+	return &core.SignTxRequest{Transaction: tx}
+}
+
+// callData creates ABI call data for the given selector with the given number
+// of arguments.
+func callData(selector string, args int) []byte {
+	data := hexutil.MustDecode(selector)
+	for i := 0; i < args; i++ {
+		data = append(data, common.LeftPadBytes([]byte{byte(i + 1)}, 32)...)
+	}
+	return data
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *big.Int
+	}{
+		{"1000", big.NewInt(1000)},
+		{"1000 wei", big.NewInt(1000)},
+		{"30 gwei", big.NewInt(30e9)},
+		{"1.5 sdcer", big.NewInt(1.5e18)},
+		{"1e3", big.NewInt(1000)},
+		{"0.5 wei", nil},
+		{"-1", nil},
+		{"1 dogecoin", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		have, err := parseAmount(test.in)
+		switch {
+		case test.want == nil && err == nil:
+			t.Errorf("%q: expected error, got %v", test.in, have)
+		case test.want != nil && err != nil:
+			t.Errorf("%q: unexpected error: %v", test.in, err)
+		case test.want != nil && have.Cmp(test.want) != 0:
+			t.Errorf("%q: have %v, want %v", test.in, have, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		`accounts: {"0x000000000000000000000000000000000000a11c": {recipient: []}}`,
+		`accounts: {"0xa11c": {}}`,
+		`accounts: {"*": {recipients: ["bob"]}}`,
+		`accounts: {"*": {methods: ["transfer"]}}`,
+		`accounts: {"*": {methods: ["0xa9059c"]}}`,
+		`accounts: {"*": {spendingLimits: [{window: "forever", amount: "1"}]}}`,
+		`accounts: {"*": {maxGasPrice: "lots"}}`,
+		`accounts: {"*": {entryPoints: ["entrypoint"]}}`,
+		`onViolation: ignore`,
+	}
+	for _, test := range tests {
+		p, err := Parse([]byte(test))
+		if err == nil {
+			_, err = p.compile()
+		}
+		if err == nil {
+			t.Errorf("no error for invalid policy %s", test)
+		}
+	}
+	// JSON policies are accepted as well
+	p, err := Parse([]byte(`{"accounts": {"*": {"recipients": ["0x0000000000000000000000000000000000000b0b"]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := p.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := cp.rules(alice); rules == nil || !rules.recipients[bob] {
+		t.Fatal("wildcard rules not applied")
+	}
+}
+
+func TestTransactionRules(t *testing.T) {
+	e, ui := newTestEngine(t, testPolicy, storage.NewEphemeralStorage(), false)
+	tests := []struct {
+		name    string
+		req     *core.SignTxRequest
+		approve bool
+	}{
+		{"plain transfer", txRequest(alice, &bob, sdcer(0.1), nil), true},
+		{"contract creation", txRequest(alice, nil, big.NewInt(0), []byte{0x60}), false},
+		{"unknown recipient", txRequest(alice, &mallory, sdcer(0.1), nil), false},
+		{"allowed method", txRequest(alice, &token, big.NewInt(0), callData("0xa9059cbb", 2)), true},
+		{"allowed selector", txRequest(alice, &token, big.NewInt(0), callData("0x095ea7b3", 2)), true},
+		{"method not allowed", txRequest(alice, &token, big.NewInt(0), callData("0x23b872dd", 3)), false},
+		{"malformed call data", txRequest(alice, &token, big.NewInt(0), callData("0xa9059cbb", 1)), false},
+		{"short call data", txRequest(alice, &token, big.NewInt(0), []byte{0xa9}), false},
+	}
+	for _, test := range tests {
+		resp, err := e.ApproveTx(test.req)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if resp.Approved != test.approve {
+			t.Errorf("%s: approved %v, want %v", test.name, resp.Approved, test.approve)
+		}
+	}
+	// Gas price limits apply to both legacy and dynamic fee transactions
+	req := txRequest(alice, &bob, big.NewInt(1), nil)
+	req.Transaction.GasPrice = (*hexutil.Big)(big.NewInt(101e9))
+	if resp, _ := e.ApproveTx(req); resp.Approved {
+		t.Error("transaction above max gas price approved")
+	}
+	req.Transaction.GasPrice = nil
+	req.Transaction.MaxFeePerGas = (*hexutil.Big)(big.NewInt(100e9))
+	if resp, _ := e.ApproveTx(req); !resp.Approved {
+		t.Error("transaction at max fee cap rejected")
+	}
+	// Accounts without policy are passed on to the UI
+	if resp, _ := e.ApproveTx(txRequest(bob, &alice, big.NewInt(1), nil)); resp.Approved || ui.txs != 1 {
+		t.Errorf("transaction of unknown account not forwarded: approved %v, forwarded %d", resp.Approved, ui.txs)
+	}
+}
+
+func TestSpendingLimits(t *testing.T) {
+	var (
+		store = storage.NewEphemeralStorage()
+		now   = time.Unix(1_600_000_000, 0)
+		clock = func() time.Time { return now }
+	)
+	e, _ := newTestEngine(t, testPolicy, store, false)
+	e.now = clock
+
+	check := func(value *big.Int, want bool) {
+		t.Helper()
+		resp, _ := e.ApproveTx(txRequest(alice, &bob, value, nil))
+		if resp.Approved != want {
+			t.Fatalf("%v wei at %v: approved %v, want %v", value, now, resp.Approved, want)
+		}
+	}
+	check(sdcer(0.3), true)
+	check(sdcer(0.3), false) // above the hourly limit
+	now = now.Add(time.Hour)
+	check(sdcer(0.3), true)
+	now = now.Add(time.Hour)
+	check(sdcer(0.3), true)
+	check(sdcer(0.2), false) // above the daily limit
+
+	// Counters are persisted across restarts
+	e, _ = newTestEngine(t, testPolicy, store, false)
+	e.now = clock
+	check(sdcer(0.1), true)
+	check(big.NewInt(1), false)
+
+	// Spendings leave the window after a day, only the first 0.3 sdcer expired
+	now = now.Add(22*time.Hour + time.Second)
+	check(sdcer(0.3), true)
+	check(big.NewInt(1), false)
+}
+
+// failingStorage is a storage whose reads fail, e.g. because the encrypted file
+// was tampered with.
+type failingStorage struct {
+	storage.Storage
+}
+
+func (failingStorage) Get(key string) (string, error) {
+	return "", errors.New("decryption failed")
+}
+
+// Tests that spending limits are not bypassed if the spending history can't be
+// read, even if violations are forwarded to manual approval.
+func TestSpendingHistoryErrors(t *testing.T) {
+	corrupt := storage.NewEphemeralStorage()
+	corrupt.Put(spendingKey(alice), "not json")
+
+	stores := map[string]storage.Storage{
+		"unreadable": failingStorage{storage.NewEphemeralStorage()},
+		"corrupt":    corrupt,
+	}
+	for name, store := range stores {
+		for _, onViolation := range []string{"reject", "manual"} {
+			policy := strings.Replace(testPolicy, "onViolation: reject", "onViolation: "+onViolation, 1)
+			e, ui := newTestEngine(t, policy, store, false)
+
+			if resp, _ := e.ApproveTx(txRequest(alice, &bob, sdcer(0.1), nil)); resp.Approved || ui.txs != 0 {
+				t.Errorf("%s history, %s on violation: approved %v, forwarded %d", name, onViolation, resp.Approved, ui.txs)
+			}
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	store := storage.NewEphemeralStorage()
+	e, ui := newTestEngine(t, testPolicy, store, true)
+	for i := 0; i < 3; i++ {
+		if resp, _ := e.ApproveTx(txRequest(alice, &bob, sdcer(0.5), nil)); resp.Approved {
+			t.Fatal("transaction approved in dry-run mode")
+		}
+	}
+	if ui.txs != 3 {
+		t.Fatalf("forwarded %d transactions, want 3", ui.txs)
+	}
+	// Dry runs don't touch the counters
+	if _, err := store.Get(spendingKey(alice)); err == nil {
+		t.Fatal("spending recorded in dry-run mode")
+	}
+}
+
+func TestManualOnViolation(t *testing.T) {
+	policy := "onViolation: manual\naccounts: {\"*\": {recipients: [\"0x0000000000000000000000000000000000000b0b\"]}}"
+	e, ui := newTestEngine(t, policy, storage.NewEphemeralStorage(), false)
+	if resp, _ := e.ApproveTx(txRequest(alice, &bob, big.NewInt(1), nil)); !resp.Approved {
+		t.Error("allowed transaction not approved")
+	}
+	if resp, _ := e.ApproveTx(txRequest(alice, &mallory, big.NewInt(1), nil)); resp.Approved || ui.txs != 1 {
+		t.Errorf("violating transaction not forwarded: approved %v, forwarded %d", resp.Approved, ui.txs)
+	}
+}
+
+func typedDataRequest(t *testing.T, from common.Address, name string, chainID int64, contract common.Address) *core.SignDataRequest {
+	t.Helper()
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": {
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              name,
+			ChainId:           math.NewHexOrDecimal256(chainID),
+			VerifyingContract: contract.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"spender": bob.Hex(),
+			"value":   "1000",
+		},
+	}
+	messages, err := typedData.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &core.SignDataRequest{
+		ContentType: apitypes.DataTyped.Mime,
+		Address:     common.NewMixedcaseAddress(from),
+		Messages:    messages,
+	}
+}
+
+func TestTypedDataDomains(t *testing.T) {
+	e, ui := newTestEngine(t, testPolicy, storage.NewEphemeralStorage(), false)
+	tests := []struct {
+		name    string
+		req     *core.SignDataRequest
+		approve bool
+	}{
+		{"allowed domain", typedDataRequest(t, alice, "Permit", 1, verifier), true},
+		{"wrong name", typedDataRequest(t, alice, "Order", 1, verifier), false},
+		{"wrong chain", typedDataRequest(t, alice, "Permit", 5, verifier), false},
+		{"wrong contract", typedDataRequest(t, alice, "Permit", 1, mallory), false},
+	}
+	for _, test := range tests {
+		resp, _ := e.ApproveSignData(test.req)
+		if resp.Approved != test.approve {
+			t.Errorf("%s: approved %v, want %v", test.name, resp.Approved, test.approve)
+		}
+	}
+	if ui.data != 0 {
+		t.Fatalf("forwarded %d typed data requests", ui.data)
+	}
+	// Other data and other accounts are left to the user
+	e.ApproveSignData(&core.SignDataRequest{ContentType: apitypes.TextPlain.Mime, Address: common.NewMixedcaseAddress(alice)})
+	e.ApproveSignData(typedDataRequest(t, bob, "Permit", 1, verifier))
+	if ui.data != 2 {
+		t.Fatalf("forwarded %d requests, want 2", ui.data)
+	}
+}
+
+const userOpPolicy = `
+accounts:
+  "0x000000000000000000000000000000000000a11c":
+    recipients:
+      - "0x00000000000000000000000000000000000070c0"
+    methods:
+      - transfer(address,uint256)
+    maxGasPrice: 100 gwei
+    entryPoints:
+      - "0x0000000071727De22E5E9d8BAf0edAc6f37da032"
+  "0x0000000000000000000000000000000000000b0b":
+    recipients:
+      - "0x00000000000000000000000000000000000070c0"
+`
+
+var (
+	entryPointV07 = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	account       = common.HexToAddress("0x00000000000000000000000000000000000aa000")
+)
+
+func userOpRequest(from, account, entryPoint common.Address, data []byte, maxFee *big.Int) *core.SignDataRequest {
+	return &core.SignDataRequest{
+		ContentType: apitypes.TextPlain.Mime,
+		Address:     common.NewMixedcaseAddress(from),
+		UserOperation: &core.UserOperationInfo{
+			EntryPoint: entryPoint,
+			Version:    core.EntryPointV07,
+			Operation: &core.UserOperation{
+				Sender:       common.NewMixedcaseAddress(account),
+				CallData:     data,
+				MaxFeePerGas: hexutil.Big(*maxFee),
+			},
+		},
+	}
+}
+
+// Tests that user operations are judged by the call executed by the smart
+// contract account, not by the account and its execute method.
+func TestUserOperations(t *testing.T) {
+	e, ui := newTestEngine(t, userOpPolicy, storage.NewEphemeralStorage(), false)
+	transfer := executeCall(token, big.NewInt(0), callData("0xa9059cbb", 2))
+	tests := []struct {
+		name    string
+		req     *core.SignDataRequest
+		approve bool
+	}{
+		{"allowed", userOpRequest(alice, account, entryPointV07, transfer, big.NewInt(1e9)), true},
+		{"no call", userOpRequest(alice, account, entryPointV07, nil, big.NewInt(1e9)), true},
+		{"plain transfer", userOpRequest(alice, account, entryPointV07, executeCall(token, big.NewInt(1), nil), big.NewInt(1e9)), true},
+		{"wrong entry point", userOpRequest(alice, account, mallory, transfer, big.NewInt(1e9)), false},
+		{"wrong recipient", userOpRequest(alice, account, entryPointV07, executeCall(mallory, big.NewInt(0), callData("0xa9059cbb", 2)), big.NewInt(1e9)), false},
+		{"wrong method", userOpRequest(alice, account, entryPointV07, executeCall(token, big.NewInt(0), callData("0x095ea7b3", 2)), big.NewInt(1e9)), false},
+		{"bad inner call", userOpRequest(alice, account, entryPointV07, executeCall(token, big.NewInt(0), callData("0xa9059cbb", 1)), big.NewInt(1e9)), false},
+		{"fee too high", userOpRequest(alice, account, entryPointV07, transfer, big.NewInt(101e9)), false},
+
+		// Only calls of execute can be evaluated, the outer call is never checked
+		// against the method rules of the owner
+		{"direct call", userOpRequest(alice, account, entryPointV07, callData("0xa9059cbb", 2), big.NewInt(1e9)), false},
+		{"batch", userOpRequest(alice, account, entryPointV07, executeBatchCall(token, transfer[4:]), big.NewInt(1e9)), false},
+	}
+	for _, test := range tests {
+		resp, _ := e.ApproveSignData(test.req)
+		if resp.Approved != test.approve {
+			t.Errorf("%s: approved %v, want %v", test.name, resp.Approved, test.approve)
+		}
+	}
+	if ui.data != 0 {
+		t.Fatalf("forwarded %d user operations", ui.data)
+	}
+	// Accounts without allowed entry points leave user operations to the user
+	e.ApproveSignData(userOpRequest(bob, account, entryPointV07, nil, big.NewInt(1e9)))
+	if ui.data != 1 {
+		t.Fatalf("forwarded %d requests, want 1", ui.data)
+	}
+}
+
+const userOpLimitPolicy = `
+accounts:
+  "0x000000000000000000000000000000000000a11c":
+    spendingLimits:
+      - window: 24h
+        amount: 1 sdcer
+    recipients:
+      - "0x0000000000000000000000000000000000000b0b"
+    methods:
+      - transfer(address,uint256)
+    entryPoints:
+      - "0x0000000071727De22E5E9d8BAf0edAc6f37da032"
+`
+
+// executeCall returns the call data of execute(to, value, call).
+func executeCall(to common.Address, value *big.Int, call []byte) []byte {
+	data := common.CopyBytes(executeSelector)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(value.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes([]byte{0x60}, 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(call))).Bytes(), 32)...)
+	return append(data, common.RightPadBytes(call, (len(call)+31)/32*32)...)
+}
+
+// executeBatchCall returns the call data of executeBatch([to], [call]).
+func executeBatchCall(to common.Address, call []byte) []byte {
+	data := crypto.Keccak256([]byte("executeBatch(address[],bytes[])"))[:4]
+	for _, word := range []int64{0x40, 0x80, 1} {
+		data = append(data, common.LeftPadBytes(big.NewInt(word).Bytes(), 32)...)
+	}
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	for _, word := range []int64{1, 0x20, int64(len(call))} {
+		data = append(data, common.LeftPadBytes(big.NewInt(word).Bytes(), 32)...)
+	}
+	return append(data, common.RightPadBytes(call, (len(call)+31)/32*32)...)
+}
+
+// Tests that the value transferred by user operations is charged against the
+// spending limits, sharing the counters with transactions.
+func TestUserOperationLimits(t *testing.T) {
+	e, ui := newTestEngine(t, userOpLimitPolicy, storage.NewEphemeralStorage(), false)
+	userOp := func(data []byte, approve bool) {
+		t.Helper()
+		resp, _ := e.ApproveSignData(userOpRequest(alice, token, entryPointV07, data, big.NewInt(1e9)))
+		if resp.Approved != approve {
+			t.Fatalf("user operation approved %v, want %v", resp.Approved, approve)
+		}
+	}
+	userOp(executeCall(bob, sdcer(0.6), nil), true)
+	userOp(executeCall(bob, sdcer(0.5), nil), false)
+	userOp(nil, true)
+
+	// Transactions spend from the same counters
+	resp, _ := e.ApproveTx(txRequest(alice, &bob, sdcer(0.4), nil))
+	if !resp.Approved {
+		t.Fatal("transaction within the limit rejected")
+	}
+	userOp(executeCall(bob, big.NewInt(1), nil), false)
+	userOp(executeCall(bob, big.NewInt(0), callData("0xa9059cbb", 2)), true)
+
+	// The value of other calls is unknown, so they can't be approved
+	userOp(callData("0xa9059cbb", 2), false)
+	userOp(executeCall(bob, big.NewInt(0), nil)[:68], false)
+
+	if ui.data != 0 {
+		t.Fatalf("forwarded %d user operations", ui.data)
+	}
+}
@@ -87,6 +87,9 @@ type Database struct {
 	childrenSize common.StorageSize // Storage size of the external children tracking
 	preimages    *preimageStore     // The store for caching preimages
 
+	flushHook func(common.Hash) // Optional callback invoked for every node persisted to disk
+	hookLock  sync.RWMutex      // Lock protecting the flush hook
+
 	lock sync.RWMutex
 }
 
@@ -572,6 +575,7 @@ func (db *Database) Cap(limit common.StorageSize) error {
 		// Fetch the oldest referenced node and push into the batch
 		node := db.dirties[oldest]
 		rawdb.WriteTrieNode(batch, oldest, node.rlp())
+		db.flushed(oldest)
 
 		// If we exceeded the ideal batch size, commit and reset
 		if batch.ValueSize() >= sdcdb.IdealBatchSize {
@@ -704,6 +708,7 @@ func (db *Database) commit(hash common.Hash, batch sdcdb.Batch, uncacher *cleane
 	}
 	// If we've reached an optimal batch size, commit and start over
 	rawdb.WriteTrieNode(batch, hash, node.rlp())
+	db.flushed(hash)
 	if callback != nil {
 		callback(hash)
 	}
@@ -722,6 +727,29 @@ func (db *Database) commit(hash common.Hash, batch sdcdb.Batch, uncacher *cleane
 	return nil
 }
 
+// SetFlushHook installs a callback which is invoked for every trie node that is
+// about to be persisted from the dirty cache into the disk database, either by
+// Commit or by Cap. The callback runs before the node is actually written out,
+// which allows an online state pruner to protect freshly persisted nodes from
+// deletion. Passing nil removes the hook.
+func (db *Database) SetFlushHook(hook func(common.Hash)) {
+	db.hookLock.Lock()
+	defer db.hookLock.Unlock()
+
+	db.flushHook = hook
+}
+
+// flushed notifies the installed flush hook (if any) about a node being moved
+// into the disk database.
+func (db *Database) flushed(hash common.Hash) {
+	db.hookLock.RLock()
+	defer db.hookLock.RUnlock()
+
+	if db.flushHook != nil {
+		db.flushHook(hash)
+	}
+}
+
 // cleaner is a database batch replayer that takes a batch of write operations
 // and cleans up the trie database from anything written to disk.
 type cleaner struct {
@@ -17,6 +17,7 @@
 package trie
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/sdcereum/go-sdcereum/common"
@@ -31,3 +32,71 @@ func TestDatabaseMetarootFetch(t *testing.T) {
 		t.Fatalf("metaroot retrieval succeeded")
 	}
 }
+
+// Tests that the flush hook is notified about every node persisted by either
+// Commit or Cap, before the node is written to disk.
+func TestDatabaseFlushHook(t *testing.T) {
+	diskdb := memorydb.New()
+	triedb := NewDatabase(diskdb)
+
+	// newTrie inserts a fresh trie into the dirty cache of the database.
+	newTrie := func(seed byte) common.Hash {
+		trie := NewEmpty(triedb)
+		for i := byte(0); i < 100; i++ {
+			trie.Update([]byte{seed, i}, bytes.Repeat([]byte{seed, i}, 20))
+		}
+		root, nodes, _ := trie.Commit(false)
+		triedb.Update(NewWithNodeSet(nodes))
+		return root
+	}
+	var (
+		flushed = make(map[common.Hash]struct{})
+		early   []common.Hash
+	)
+	triedb.SetFlushHook(func(hash common.Hash) {
+		if ok, _ := diskdb.Has(hash.Bytes()); ok {
+			early = append(early, hash)
+		}
+		flushed[hash] = struct{}{}
+	})
+	// checkFlushed ensures that all the nodes on disk were reported
+	checkFlushed := func(stage string) {
+		t.Helper()
+
+		var nodes int
+		it := diskdb.NewIterator(nil, nil)
+		defer it.Release()
+		for it.Next() {
+			if len(it.Key()) != common.HashLength {
+				continue
+			}
+			nodes++
+			if _, ok := flushed[common.BytesToHash(it.Key())]; !ok {
+				t.Errorf("%s: node %x persisted without notification", stage, it.Key())
+			}
+		}
+		if nodes != len(flushed) {
+			t.Errorf("%s: notification count mismatch: have %d, want %d", stage, len(flushed), nodes)
+		}
+		if len(early) > 0 {
+			t.Errorf("%s: %d nodes notified after being persisted", stage, len(early))
+		}
+	}
+	triedb.Commit(newTrie(1), false, nil)
+	if len(flushed) == 0 {
+		t.Fatalf("no nodes notified on commit")
+	}
+	checkFlushed("commit")
+
+	newTrie(2)
+	triedb.Cap(0)
+	checkFlushed("cap")
+
+	// Removed hooks must not be notified anymore
+	triedb.SetFlushHook(nil)
+	notified := len(flushed)
+	triedb.Commit(newTrie(3), false, nil)
+	if len(flushed) != notified {
+		t.Errorf("removed hook notified about %d nodes", len(flushed)-notified)
+	}
+}